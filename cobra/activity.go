@@ -0,0 +1,189 @@
+package cobra
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	activityState       = new(string)
+	activityUser        = new(string)
+	activityApplication = new(string)
+	activityMinDuration = new(time.Duration)
+	activityAll         = new(bool)
+	activityAllDBs      = new(bool)
+	activityOrderBy     = new(string)
+	activityWatch       = new(time.Duration)
+	killTerminate       = new(bool)
+	killYes             = new(bool)
+)
+
+func init() {
+	DBCmd.AddCommand(activityCmd)
+	DBCmd.AddCommand(killCmd)
+
+	activityCmd.Flags().StringVar(activityState, "state", "", "Show only sessions in this state, e.g. active or \"idle in transaction\"")
+	activityCmd.Flags().StringVar(activityUser, "user", "", "Show only sessions of this role")
+	activityCmd.Flags().StringVar(activityApplication, "application", "", "Show only sessions with this application_name")
+	activityCmd.Flags().DurationVar(activityMinDuration, "min-duration", 0, "Show only sessions running or in their state at least this long, e.g. 30s")
+	activityCmd.Flags().BoolVar(activityAll, "all", false, "Include idle sessions")
+	activityCmd.Flags().BoolVar(activityAllDBs, "all-databases", false, "Show the sessions of every database, not only of the --db database")
+	activityCmd.Flags().StringVar(activityOrderBy, "order-by", database.ActivityOrderDuration, "Order by duration, transaction, pid, user or state")
+	activityCmd.Flags().DurationVar(activityWatch, "watch", 0, "Refresh the sessions at this interval until interrupted, e.g. 2s")
+
+	killCmd.Flags().BoolVar(killTerminate, "terminate", false, "Terminate the session instead of cancelling its query")
+	killCmd.Flags().BoolVarP(killYes, "yes", "y", false, "Signal the backend without asking for confirmation")
+
+	addErrorFlags(activityCmd)
+	addErrorFlags(killCmd)
+}
+
+var activityCmd = &cobra.Command{
+	Use:   "activity",
+	Short: "Show the sessions of the database, longest running first",
+	Long: `Show the client sessions of the database selected with --db, or of every database with
+--all-databases, from pg_stat_activity. With --watch, the table is refreshed at the given
+interval until interrupted, or a JSON line is appended with --json:
+
+  db activity --state active --min-duration 30s --order-by transaction
+  db activity --all-databases --watch 2s`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := validateWatch(cmd, *activityWatch); err != nil {
+			handleError(cmd, err, "get_activity")
+			return
+		}
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		filter := database.ActivityFilter{
+			State:        *activityState,
+			User:         *activityUser,
+			Application:  *activityApplication,
+			MinDuration:  *activityMinDuration,
+			IncludeIdle:  *activityAll,
+			AllDatabases: *activityAllDBs,
+			OrderBy:      *activityOrderBy,
+		}
+		if *activityWatch > 0 {
+			watch(cmd, *activityWatch, "get_activity", func(ctx context.Context) (map[string]interface{}, error) {
+				activity, err := db.Introspection().GetActivity(ctx, filter)
+				if err != nil {
+					return nil, err
+				}
+				if textOutput(cmd) {
+					cmd.Printf("%d sessions\n\n", len(activity))
+					printActivity(cmd, activity, filter.AllDatabases)
+				}
+				return map[string]interface{}{"sessions": activity}, nil
+			})
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		activity, err := db.Introspection().GetActivity(ctx, filter)
+		if err != nil {
+			handleError(cmd, err, "get_activity")
+			return
+		}
+
+		if textOutput(cmd) {
+			printActivity(cmd, activity, filter.AllDatabases)
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("%d sessions", len(activity)), map[string]interface{}{
+			"sessions": activity,
+		})
+	},
+}
+
+// printActivity prints the sessions, with their database if they may come from several
+func printActivity(cmd *cobra.Command, activity []database.SessionActivity, showDatabase bool) {
+	if len(activity) == 0 {
+		return
+	}
+	databaseColumn := func(session database.SessionActivity) string {
+		if !showDatabase {
+			return ""
+		}
+		return fmt.Sprintf("%-16s  ", valueOr(session.Database, "-"))
+	}
+
+	header := ""
+	if showDatabase {
+		header = fmt.Sprintf("%-16s  ", "DATABASE")
+	}
+	cmd.Printf("%-8s  %s%-12s  %-20s  %-20s  %10s  %s\n", "PID", header, "USER", "STATE", "WAIT", "DURATION", "QUERY")
+	for _, session := range activity {
+		wait := "-"
+		if session.WaitEventType != nil {
+			wait = *session.WaitEventType + ":" + valueOr(session.WaitEvent, "")
+		}
+		cmd.Printf("%-8d  %s%-12s  %-20s  %-20s  %10s  %s\n", session.PID, databaseColumn(session), valueOr(session.User, "-"), valueOr(session.State, "-"),
+			wait, session.Duration.Round(time.Second), summarizeQuery(session.Query))
+	}
+}
+
+var killCmd = &cobra.Command{
+	Use:   "kill <pid>",
+	Short: "Cancel the query of a backend, or terminate its session",
+	Long: `Cancel the current query of a backend, found with db activity or db locks, leaving
+the session connected. With --terminate, end the session and roll back its open
+transaction instead. Asks for confirmation unless --yes is given; without a
+terminal, --yes is required.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !confirmProduction(cmd, "signal a backend") {
+			return
+		}
+
+		pid, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || pid <= 0 {
+			handleError(cmd, database.NewValidationError(fmt.Sprintf("invalid PID %q", args[0]), err), "kill")
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		action, signal := "cancel", db.CancelBackend
+		question := fmt.Sprintf("Cancel the current query of backend %d", pid)
+		if *killTerminate {
+			action, signal = "terminate", db.TerminateBackend
+			question = fmt.Sprintf("Terminate backend %d and roll back its open transaction", pid)
+		}
+
+		if !confirmDestructive(cmd, *killYes, db.Config(), question, "kill") {
+			return
+		}
+
+		if err := signal(ctx, pid); err != nil {
+			handleError(cmd, err, action+"_backend")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Backend %d signalled to %s", pid, action), map[string]interface{}{
+			"pid":    pid,
+			"action": action,
+		})
+	},
+}
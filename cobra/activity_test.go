@@ -0,0 +1,69 @@
+package cobra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityCommand(t *testing.T) {
+	assert.Equal(t, "activity", activityCmd.Use)
+	for _, name := range []string{"state", "user", "application", "min-duration", "all", "all-databases", "order-by", "watch", "json"} {
+		assert.NotNil(t, activityCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+	assert.Error(t, activityCmd.Args(activityCmd, []string{"extra"}))
+}
+
+func TestKillCommand(t *testing.T) {
+	assert.Equal(t, "kill <pid>", killCmd.Use)
+	for _, name := range []string{"terminate", "yes", "json"} {
+		assert.NotNil(t, killCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+	assert.NotNil(t, killCmd.Flags().ShorthandLookup("y"))
+
+	assert.Error(t, killCmd.Args(killCmd, []string{}))
+	assert.NoError(t, killCmd.Args(killCmd, []string{"1234"}))
+	assert.Error(t, killCmd.Args(killCmd, []string{"1234", "5678"}))
+}
+
+func TestPrintActivity(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printActivity(cmd, nil, false)
+	assert.Empty(t, out.String())
+
+	user, state, waitType, wait := "app", "active", "Lock", "relation"
+	query := "SELECT * FROM orders WHERE id = $1"
+	printActivity(cmd, []database.SessionActivity{{
+		PID:           4321,
+		User:          &user,
+		State:         &state,
+		WaitEventType: &waitType,
+		WaitEvent:     &wait,
+		Duration:      95 * time.Second,
+		Query:         &query,
+	}}, false)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "4321")
+	assert.Contains(t, lines[1], "Lock:relation")
+	assert.Contains(t, lines[1], "1m35s")
+	assert.Contains(t, lines[1], query)
+	assert.NotContains(t, lines[0], "DATABASE")
+
+	out.Reset()
+	datname := "billing"
+	printActivity(cmd, []database.SessionActivity{{PID: 4321, Database: &datname, User: &user}}, true)
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "PID       DATABASE          USER"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "4321      billing           app"), lines[1])
+}
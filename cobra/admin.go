@@ -0,0 +1,132 @@
+package cobra
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	createDBOwner       = new(string)
+	createDBTemplate    = new(string)
+	createDBEncoding    = new(string)
+	createDBLocale      = new(string)
+	createDBIfNotExists = new(bool)
+	dropDBIfExists      = new(bool)
+	dropDBForce         = new(bool)
+	dropDBYes           = new(bool)
+)
+
+func init() {
+	DBCmd.AddCommand(createDatabaseCmd)
+	DBCmd.AddCommand(dropDatabaseCmd)
+
+	createDatabaseCmd.Flags().StringVar(createDBOwner, "owner", "", "Role owning the database, the connecting user by default")
+	createDatabaseCmd.Flags().StringVar(createDBTemplate, "template", "", "Database to copy, template1 by default")
+	createDatabaseCmd.Flags().StringVar(createDBEncoding, "encoding", "", "Character encoding, e.g. UTF8; the template's by default")
+	createDatabaseCmd.Flags().StringVar(createDBLocale, "locale", "", "Locale, e.g. en_US.UTF-8; the template's by default")
+	createDatabaseCmd.Flags().BoolVar(createDBIfNotExists, "if-not-exists", false, "Succeed without changes if the database exists")
+
+	dropDatabaseCmd.Flags().BoolVar(dropDBIfExists, "if-exists", false, "Succeed without changes if the database does not exist")
+	dropDatabaseCmd.Flags().BoolVar(dropDBForce, "force", false, "Terminate the sessions connected to the database (PostgreSQL 13+)")
+	dropDatabaseCmd.Flags().BoolVarP(dropDBYes, "yes", "y", false, "Drop without asking for confirmation")
+
+	addErrorFlags(createDatabaseCmd)
+	addErrorFlags(dropDatabaseCmd)
+}
+
+var createDatabaseCmd = &cobra.Command{
+	Use:   "create [database]",
+	Short: "Create a database",
+	Long: `Create the database given as argument, or the configured one, connecting through the
+postgres maintenance database of the same server:
+
+  db create app_test --template template0 --encoding UTF8 --locale en_US.UTF-8
+  db --profile dev create --if-not-exists`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := targetDatabaseConfig(args)
+		if err != nil {
+			handleError(cmd, err, "create_database")
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 5*time.Minute)
+		defer cancel()
+
+		opts := database.CreateDatabaseOptions{
+			Owner:       *createDBOwner,
+			Template:    *createDBTemplate,
+			Encoding:    *createDBEncoding,
+			Locale:      *createDBLocale,
+			IfNotExists: *createDBIfNotExists,
+		}
+		if err := database.CreateDatabase(ctx, config, opts); err != nil {
+			handleError(cmd, err, "create_database")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Database %s created", config.DBName), map[string]interface{}{
+			"database": config.DBName,
+			"host":     config.Host,
+			"owner":    opts.Owner,
+			"template": opts.Template,
+			"encoding": opts.Encoding,
+			"locale":   opts.Locale,
+		})
+	},
+}
+
+var dropDatabaseCmd = &cobra.Command{
+	Use:   "drop [database]",
+	Short: "Drop a database",
+	Long: `Drop the database given as argument, or the configured one, connecting through the
+postgres maintenance database of the same server. Asks for confirmation unless --yes is
+given; without a terminal, --yes is required.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !confirmProduction(cmd, "drop the database") {
+			return
+		}
+
+		config, err := targetDatabaseConfig(args)
+		if err != nil {
+			handleError(cmd, err, "drop_database")
+			return
+		}
+
+		if !confirmDestructive(cmd, *dropDBYes, config, "Drop all data", "drop_database") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 5*time.Minute)
+		defer cancel()
+
+		opts := database.DropDatabaseOptions{IfExists: *dropDBIfExists, Force: *dropDBForce}
+		if err := database.DropDatabase(ctx, config, opts); err != nil {
+			handleError(cmd, err, "drop_database")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Database %s dropped", config.DBName), map[string]interface{}{
+			"database": config.DBName,
+			"host":     config.Host,
+		})
+	},
+}
+
+// targetDatabaseConfig returns the connection settings with the database given as argument,
+// if any
+func targetDatabaseConfig(args []string) (database.Config, error) {
+	config, err := newConfig()
+	if err != nil {
+		return config, err
+	}
+	if len(args) > 0 {
+		config.DBName = args[0]
+	}
+	return config, nil
+}
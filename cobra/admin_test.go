@@ -0,0 +1,33 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDropDatabaseCommands(t *testing.T) {
+	assert.Equal(t, "create [database]", createDatabaseCmd.Use)
+	for _, flag := range []string{"owner", "template", "encoding", "locale", "if-not-exists", "json"} {
+		assert.NotNil(t, createDatabaseCmd.Flags().Lookup(flag), "create should have --%s", flag)
+	}
+
+	assert.Equal(t, "drop [database]", dropDatabaseCmd.Use)
+	for _, flag := range []string{"if-exists", "force", "yes", "json"} {
+		assert.NotNil(t, dropDatabaseCmd.Flags().Lookup(flag), "drop should have --%s", flag)
+	}
+	assert.Error(t, dropDatabaseCmd.Args(dropDatabaseCmd, []string{"a", "b"}))
+}
+
+func TestTargetDatabaseConfig(t *testing.T) {
+	setRootFlag(t, "db", "app")
+
+	config, err := targetDatabaseConfig(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "app", config.DBName)
+
+	config, err = targetDatabaseConfig([]string{"app_test"})
+	require.NoError(t, err)
+	assert.Equal(t, "app_test", config.DBName)
+}
@@ -0,0 +1,274 @@
+package cobra
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	suggestMinTableSize = new(int64)
+	suggestMinSeqScans  = new(int64)
+	suggestStatements   = new(bool)
+	topQueriesOrderBy   = new(string)
+	topQueriesLimit     = new(int)
+	analyzeSchema       = new(string)
+	analyzeAll          = new(bool)
+	analyzeJobs         = new(int)
+)
+
+func init() {
+	DBCmd.AddCommand(analyzeCmd)
+	analyzeCmd.AddCommand(suggestIndexesCmd)
+	analyzeCmd.AddCommand(topQueriesCmd)
+	analyzeCmd.AddCommand(maintenanceCmd)
+
+	suggestIndexesCmd.Flags().Int64Var(suggestMinTableSize, "min-table-size", 10<<20, "Skip tables smaller than this many bytes")
+	suggestIndexesCmd.Flags().Int64Var(suggestMinSeqScans, "min-seq-scans", 50, "Skip tables scanned sequentially fewer times")
+	suggestIndexesCmd.Flags().BoolVar(suggestStatements, "statements", true, "Read predicate columns from pg_stat_statements, if installed")
+	topQueriesCmd.Flags().StringVar(topQueriesOrderBy, "order-by", database.QueryOrderTotalTime, "Order by total_time, mean_time, calls or rows")
+	topQueriesCmd.Flags().IntVar(topQueriesLimit, "limit", 20, "Maximum number of queries to show")
+
+	// Running analyze without a subcommand analyzes tables
+	analyzeCmd.Flags().StringVar(analyzeSchema, "schema", "", "Analyze all tables of this schema")
+	analyzeCmd.Flags().BoolVar(analyzeAll, "all", false, "Analyze all tables of the database")
+	analyzeCmd.Flags().IntVar(analyzeJobs, "jobs", 1, "Number of tables to analyze at once")
+
+	addErrorFlags(analyzeCmd)
+	addErrorFlags(suggestIndexesCmd)
+	addErrorFlags(topQueriesCmd)
+	addErrorFlags(maintenanceCmd)
+}
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze [table...]",
+	Short: "Run ANALYZE on tables, or analyze database statistics for tuning opportunities",
+	Long: `Run ANALYZE on the tables given as arguments, optionally schema-qualified, or on all
+tables of a schema with --schema or of the database with --all; --verbose streams the
+progress messages of the server to stderr. The subcommands report tuning opportunities
+from the database statistics:
+
+  db analyze public.orders --verbose
+  db analyze --all --jobs 4
+  db analyze maintenance`,
+	Args: cobra.ArbitraryArgs,
+	Run:  runAnalyze,
+}
+
+// runAnalyze runs the analyze command without a subcommand, showing its help if no tables
+// are selected
+func runAnalyze(cmd *cobra.Command, args []string) {
+	if len(args) == 0 && *analyzeSchema == "" && !*analyzeAll {
+		if err := cmd.Help(); err != nil {
+			handleError(cmd, err, "analyze")
+		}
+		return
+	}
+
+	opts, err := maintenanceSelection(args, *analyzeSchema, *analyzeAll)
+	if err != nil {
+		handleError(cmd, err, "analyze")
+		return
+	}
+	opts.Jobs = *analyzeJobs
+
+	runMaintenance(cmd, "analyze", opts)
+}
+
+var suggestIndexesCmd = &cobra.Command{
+	Use:   "suggest-indexes [schema_name]",
+	Short: "Suggest indexes for tables read mostly by sequential scans",
+	Long: `Suggest indexes from the sequential scan counts and sizes of tables and, if
+pg_stat_statements is installed, the columns the most expensive statements filter on.
+Suggestions are heuristics: check them with EXPLAIN before creating indexes.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		opts := database.SuggestIndexOptions{
+			MinTableBytes: *suggestMinTableSize,
+			MinSeqScans:   *suggestMinSeqScans,
+			UseStatements: *suggestStatements,
+		}
+		if len(args) > 0 {
+			opts.Schema = args[0]
+		}
+
+		suggestions, err := db.Introspection().SuggestIndexes(ctx, opts)
+		if err != nil {
+			handleError(cmd, err, "suggest_indexes")
+			return
+		}
+
+		if textOutput(cmd) {
+			printIndexSuggestions(cmd, suggestions)
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("%d index suggestions", len(suggestions)), map[string]interface{}{
+			"schema":      opts.Schema,
+			"suggestions": suggestions,
+		})
+	},
+}
+
+var topQueriesCmd = &cobra.Command{
+	Use:   "top-queries",
+	Short: "Show the most expensive queries from pg_stat_statements",
+	Long: `Show the statements of the current database with the most execution time, calls or
+rows from pg_stat_statements. Without the extension, report why no queries are available.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		top, err := db.Introspection().GetTopQueries(ctx, *topQueriesOrderBy, *topQueriesLimit)
+		if err != nil {
+			handleError(cmd, err, "get_top_queries")
+			return
+		}
+
+		message := fmt.Sprintf("%d top queries by %s", len(top.Queries), *topQueriesOrderBy)
+		if !top.Available {
+			message = top.Reason
+		}
+		if textOutput(cmd) {
+			printTopQueries(cmd, top.Queries)
+		}
+
+		handleSuccess(cmd, message, map[string]interface{}{
+			"available": top.Available,
+			"reason":    top.Reason,
+			"order_by":  *topQueriesOrderBy,
+			"queries":   top.Queries,
+		})
+	},
+}
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance [schema_name]",
+	Short: "Show tables that need a vacuum or analyze",
+	Long: `Show the vacuum and analyze state of tables from pg_stat_user_tables and recommend a
+vacuum for tables with many dead tuples and an analyze for tables never analyzed or
+modified much since the last analyze. The JSON output includes every table.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		schema := ""
+		if len(args) > 0 {
+			schema = args[0]
+		}
+
+		stats, err := db.Introspection().GetMaintenanceStats(ctx, schema)
+		if err != nil {
+			handleError(cmd, err, "get_maintenance_stats")
+			return
+		}
+
+		var needed int
+		for _, table := range stats {
+			if len(table.Recommendations) > 0 {
+				needed++
+			}
+		}
+		if textOutput(cmd) {
+			printMaintenanceStats(cmd, stats)
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("%d of %d tables need maintenance", needed, len(stats)), map[string]interface{}{
+			"schema": schema,
+			"tables": stats,
+		})
+	},
+}
+
+func printIndexSuggestions(cmd *cobra.Command, suggestions []database.IndexSuggestion) {
+	if len(suggestions) == 0 {
+		return
+	}
+	cmd.Printf("%-10s  %-6s  %10s  %s\n", "CONFIDENCE", "IMPACT", "SIZE", "TABLE")
+	for _, s := range suggestions {
+		table := s.Schema + "." + s.Table
+		if len(s.Columns) > 0 {
+			table += " (" + strings.Join(s.Columns, ", ") + ")"
+		}
+		cmd.Printf("%-10s  %-6s  %10s  %s\n", s.Confidence, s.Impact, formatBytes(s.TableBytes), table)
+		cmd.Printf("    %s\n", s.Reason)
+		if s.Statement != "" {
+			cmd.Printf("    %s;\n", s.Statement)
+		}
+	}
+}
+
+func printTopQueries(cmd *cobra.Command, queries []database.QueryStats) {
+	if len(queries) == 0 {
+		return
+	}
+	cmd.Printf("%10s  %12s  %12s  %12s  %6s  %s\n", "CALLS", "TOTAL", "MEAN", "ROWS", "HIT", "QUERY")
+	for _, q := range queries {
+		hitRatio := "-"
+		if q.HitRatio != nil {
+			hitRatio = fmt.Sprintf("%.1f%%", *q.HitRatio*100)
+		}
+		cmd.Printf("%10d  %12s  %12s  %12d  %6s  %s\n", q.Calls, q.TotalTime.Round(time.Millisecond),
+			q.MeanTime.Round(time.Microsecond), q.Rows, hitRatio, summarizeQuery(&q.Query))
+	}
+}
+
+// printMaintenanceStats prints the tables with maintenance recommendations
+func printMaintenanceStats(cmd *cobra.Command, stats []database.MaintenanceStats) {
+	header := false
+	for _, s := range stats {
+		if len(s.Recommendations) == 0 {
+			continue
+		}
+		if !header {
+			cmd.Printf("%12s  %12s  %-19s  %-19s  %s\n", "DEAD", "MODIFIED", "LAST VACUUM", "LAST ANALYZE", "TABLE")
+			header = true
+		}
+		cmd.Printf("%12d  %12d  %-19s  %-19s  %s.%s\n", s.DeadTuples, s.ModificationsSinceAnalyze,
+			latestTime(s.LastVacuum, s.LastAutovacuum), latestTime(s.LastAnalyze, s.LastAutoanalyze), s.Schema, s.Table)
+		for _, r := range s.Recommendations {
+			cmd.Printf("    %s: %s\n", r.Action, r.Reason)
+		}
+		cmd.Printf("    %s;\n", s.Statement)
+	}
+}
+
+// latestTime formats the later of a manual and an automatic run, "never" if neither happened
+func latestTime(manual, auto *time.Time) string {
+	latest := manual
+	if auto != nil && (latest == nil || auto.After(*latest)) {
+		latest = auto
+	}
+	if latest == nil {
+		return "never"
+	}
+	return latest.Format("2006-01-02 15:04:05")
+}
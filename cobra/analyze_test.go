@@ -0,0 +1,122 @@
+package cobra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestIndexesCommand(t *testing.T) {
+	assert.Equal(t, "suggest-indexes [schema_name]", suggestIndexesCmd.Use)
+	assert.Equal(t, analyzeCmd, suggestIndexesCmd.Parent())
+
+	for _, name := range []string{"min-table-size", "min-seq-scans", "statements", "json"} {
+		assert.NotNil(t, suggestIndexesCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+	assert.Equal(t, "true", suggestIndexesCmd.Flags().Lookup("statements").DefValue)
+
+	assert.NoError(t, suggestIndexesCmd.Args(suggestIndexesCmd, []string{"public"}))
+	assert.Error(t, suggestIndexesCmd.Args(suggestIndexesCmd, []string{"public", "extra"}))
+}
+
+func TestPrintIndexSuggestions(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printIndexSuggestions(cmd, nil)
+	assert.Empty(t, out.String())
+
+	printIndexSuggestions(cmd, []database.IndexSuggestion{{
+		Schema:     "public",
+		Table:      "orders",
+		Columns:    []string{"status"},
+		Statement:  `CREATE INDEX CONCURRENTLY ON "public"."orders" ("status")`,
+		Reason:     "5000 sequential scans read 250000000 rows, 100 index scans; 1230 statement calls filter on unindexed column status",
+		Confidence: database.LevelHigh,
+		Impact:     database.LevelHigh,
+		TableBytes: 2 << 30,
+	}})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[1], "2.0 GiB")
+	assert.Contains(t, lines[1], "public.orders (status)")
+	assert.Contains(t, lines[3], `CREATE INDEX CONCURRENTLY ON "public"."orders" ("status");`)
+}
+
+func TestTopQueriesCommand(t *testing.T) {
+	assert.Equal(t, "top-queries", topQueriesCmd.Use)
+	assert.Equal(t, analyzeCmd, topQueriesCmd.Parent())
+
+	for _, name := range []string{"order-by", "limit", "json"} {
+		assert.NotNil(t, topQueriesCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+	assert.Equal(t, database.QueryOrderTotalTime, topQueriesCmd.Flags().Lookup("order-by").DefValue)
+
+	assert.NoError(t, topQueriesCmd.Args(topQueriesCmd, []string{}))
+	assert.Error(t, topQueriesCmd.Args(topQueriesCmd, []string{"extra"}))
+}
+
+func TestPrintTopQueries(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printTopQueries(cmd, nil)
+	assert.Empty(t, out.String())
+
+	hitRatio := 0.987
+	printTopQueries(cmd, []database.QueryStats{
+		{Query: "SELECT *\n  FROM orders WHERE status = $1", Calls: 1200, TotalTime: 90 * time.Second, MeanTime: 75 * time.Millisecond, Rows: 4800, HitRatio: &hitRatio},
+		{Query: "VACUUM orders", Calls: 1, TotalTime: 2 * time.Second, MeanTime: 2 * time.Second},
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[1], "1m30s")
+	assert.Contains(t, lines[1], "98.7%")
+	assert.Contains(t, lines[1], "SELECT * FROM orders WHERE status = $1")
+	assert.Contains(t, lines[2], "-  VACUUM orders")
+}
+
+func TestMaintenanceCommand(t *testing.T) {
+	assert.Equal(t, "maintenance [schema_name]", maintenanceCmd.Use)
+	assert.NoError(t, maintenanceCmd.Args(maintenanceCmd, []string{"public"}))
+	assert.Error(t, maintenanceCmd.Args(maintenanceCmd, []string{"public", "extra"}))
+}
+
+func TestPrintMaintenanceStats(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	healthy := database.MaintenanceStats{Schema: "public", Table: "users", Recommendations: []database.MaintenanceRecommendation{}}
+	printMaintenanceStats(cmd, []database.MaintenanceStats{healthy})
+	assert.Empty(t, out.String())
+
+	vacuumed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	autovacuumed := vacuumed.Add(time.Hour)
+	printMaintenanceStats(cmd, []database.MaintenanceStats{
+		{
+			Schema: "public", Table: "orders", DeadTuples: 25000, LastVacuum: &vacuumed, LastAutovacuum: &autovacuumed,
+			Recommendations: []database.MaintenanceRecommendation{
+				{Action: database.MaintenanceVacuum, Reason: "25000 dead tuples (20% of the table)"},
+				{Action: database.MaintenanceAnalyze, Reason: "never analyzed"},
+			},
+			Statement: `VACUUM (ANALYZE) "public"."orders"`,
+		},
+		healthy,
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Contains(t, lines[1], "2024-03-01 13:00:00  never")
+	assert.Contains(t, lines[1], "public.orders")
+	assert.Equal(t, "    vacuum: 25000 dead tuples (20% of the table)", lines[2])
+	assert.Equal(t, "    analyze: never analyzed", lines[3])
+	assert.Equal(t, `    VACUUM (ANALYZE) "public"."orders";`, lines[4])
+}
@@ -0,0 +1,102 @@
+package cobra
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	anonymizeDryRun  = new(bool)
+	anonymizeYes     = new(bool)
+	anonymizeTimeout = new(time.Duration)
+)
+
+func init() {
+	DBCmd.AddCommand(anonymizeCmd)
+
+	anonymizeCmd.Flags().BoolVar(anonymizeDryRun, "dry-run", false, "List the columns that would be masked without masking them")
+	anonymizeCmd.Flags().BoolVarP(anonymizeYes, "yes", "y", false, "Skip the confirmation prompt")
+	anonymizeCmd.Flags().DurationVar(anonymizeTimeout, "timeout", time.Hour, "Cancel the anonymization after this long")
+
+	addErrorFlags(anonymizeCmd)
+}
+
+var anonymizeCmd = &cobra.Command{
+	Use:   "anonymize <masking.yaml>",
+	Short: "Replace personal data with fake values using a masking config",
+	Long: `Mask the columns listed in a masking config in place, in one transaction, so that a copy
+of production can be shared with developers. The config maps tables to columns and their
+masking strategy: email, name, first_name, last_name, phone, hash, redact or null.
+
+  seed: 8f0c2b7e          # optional; a random seed is used for each run otherwise
+  tables:
+    users:
+      email: email
+      full_name: name
+      phone: phone
+    billing.cards:
+      number: redact
+
+Fake values are derived from the original value, so equal values get equal masks in every
+table. Run it on a clone or restored backup, never on production itself; the original values
+cannot be recovered. Exports can be masked instead with db export --mask:
+
+  db clone myapp_share --replace
+  db --db myapp_share anonymize masking.yaml --yes`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := database.LoadMaskingConfig(args[0])
+		if err != nil {
+			handleError(cmd, err, "anonymize")
+			return
+		}
+
+		if *anonymizeDryRun {
+			columns := config.Columns()
+			if textOutput(cmd) {
+				printRows(cmd, columns, "table", "column", "strategy")
+			}
+			handleSuccess(cmd, fmt.Sprintf("%d columns of %d tables would be masked", len(columns), len(config.Tables)), map[string]interface{}{
+				"dry_run": true,
+				"columns": columns,
+			})
+			return
+		}
+
+		if !confirmProduction(cmd, "anonymize the database") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, *anonymizeTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		question := fmt.Sprintf("Mask %d tables in place", len(config.Tables))
+		if !confirmDestructive(cmd, *anonymizeYes, db.Config(), question, "anonymize") {
+			return
+		}
+
+		tables, err := db.Anonymize(ctx, config)
+		if err != nil {
+			handleError(cmd, err, "anonymize")
+			return
+		}
+		if textOutput(cmd) {
+			printRows(cmd, tables, "table", "columns", "rows")
+		}
+		handleSuccess(cmd, fmt.Sprintf("%d tables anonymized", len(tables)), map[string]interface{}{
+			"config": args[0],
+			"tables": tables,
+		})
+	},
+}
@@ -0,0 +1,16 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnonymizeCommand(t *testing.T) {
+	assert.Equal(t, "anonymize <masking.yaml>", anonymizeCmd.Use)
+	assert.NoError(t, anonymizeCmd.Args(anonymizeCmd, []string{"masking.yaml"}))
+	assert.Error(t, anonymizeCmd.Args(anonymizeCmd, []string{}))
+	assert.Equal(t, "y", anonymizeCmd.Flags().Lookup("yes").Shorthand)
+	assert.Equal(t, "false", anonymizeCmd.Flags().Lookup("dry-run").DefValue)
+	assert.NotNil(t, exportDataCmd.Flags().Lookup("mask"))
+}
@@ -0,0 +1,378 @@
+package cobra
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keepLast    = new(int)
+	keepDaily   = new(int)
+	keepWeekly  = new(int)
+	keepMonthly = new(int)
+	pruneDryRun = new(bool)
+	restoreYes  = new(bool)
+
+	backupFile       = new(string)
+	backupFormat     = new(string)
+	backupCompress   = new(string)
+	backupJobs       = new(int)
+	backupSchemaOnly = new(bool)
+	backupDataOnly   = new(bool)
+
+	restoreFile        = new(string)
+	restoreJobs        = new(int)
+	restoreSchemaOnly  = new(bool)
+	restoreDataOnly    = new(bool)
+	restoreClean       = new(bool)
+	restoreStopOnError = new(bool)
+	restoreDryRun      = new(bool)
+)
+
+func init() {
+	DBCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(createBackupCmd)
+	backupCmd.AddCommand(restoreBackupCmd)
+	backupCmd.AddCommand(pruneCmd)
+	backupCmd.AddCommand(listBackupsCmd)
+	backupCmd.AddCommand(verifyBackupCmd)
+
+	pruneCmd.Flags().IntVar(keepLast, "keep-last", 0, "Keep the most recent N backups")
+	pruneCmd.Flags().IntVar(keepDaily, "keep-daily", 0, "Keep the newest backup of each of the last N days")
+	pruneCmd.Flags().IntVar(keepWeekly, "keep-weekly", 0, "Keep the newest backup of each of the last N weeks")
+	pruneCmd.Flags().IntVar(keepMonthly, "keep-monthly", 0, "Keep the newest backup of each of the last N months")
+	pruneCmd.Flags().BoolVar(pruneDryRun, "dry-run", false, "Show which backups would be deleted without deleting them")
+
+	createBackupCmd.Flags().StringVarP(backupFile, "file", "f", "", "Backup file, directory or storage URL; a timestamped name in the backups directory if empty")
+	createBackupCmd.Flags().StringVar(backupFormat, "format", database.FormatPlain, "pg_dump format: plain, custom or directory")
+	createBackupCmd.Flags().StringVar(backupCompress, "compress", database.CompressionNone, "Compression: none, gzip or zstd")
+	createBackupCmd.Flags().IntVarP(backupJobs, "jobs", "j", 0, "Dump this many tables in parallel; requires --format directory")
+	createBackupCmd.Flags().BoolVar(backupSchemaOnly, "schema-only", false, "Dump only object definitions")
+	createBackupCmd.Flags().BoolVar(backupDataOnly, "data-only", false, "Dump only data")
+
+	restoreBackupCmd.Flags().BoolVarP(restoreYes, "yes", "y", false, "Restore without asking for confirmation")
+	restoreBackupCmd.Flags().StringVarP(restoreFile, "file", "f", "", "Backup file, directory or storage URL, instead of the argument")
+	restoreBackupCmd.Flags().IntVarP(restoreJobs, "jobs", "j", 0, "Restore this many tables in parallel; custom and directory formats only")
+	restoreBackupCmd.Flags().BoolVar(restoreSchemaOnly, "schema-only", false, "Restore only object definitions; custom and directory formats only")
+	restoreBackupCmd.Flags().BoolVar(restoreDataOnly, "data-only", false, "Restore only data; custom and directory formats only")
+	restoreBackupCmd.Flags().BoolVar(restoreClean, "clean", true, "Drop the objects of the backup before recreating them, in every format")
+	restoreBackupCmd.Flags().BoolVar(restoreStopOnError, "stop-on-error", false, "Abort pg_restore at the first error")
+	restoreBackupCmd.Flags().BoolVar(restoreDryRun, "dry-run", false, "Check the backup and print the restore plan without restoring")
+
+	addErrorFlags(backupCmd)
+	addErrorFlags(createBackupCmd)
+	addErrorFlags(restoreBackupCmd)
+	addErrorFlags(pruneCmd)
+	addErrorFlags(listBackupsCmd)
+	addErrorFlags(verifyBackupCmd)
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Manage database backups",
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			handleError(cmd, err, "help")
+		}
+	},
+}
+
+var createBackupCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Back up the database into the backups directory or storage",
+	Run: func(cmd *cobra.Command, _ []string) {
+		// Dumping a large database can take a while
+		ctx, cancel := commandContext(cmd, 2*time.Hour)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		path, err := db.BackupWithOptions(ctx, *backupFile, database.BackupOptions{
+			Format:      *backupFormat,
+			Compression: *backupCompress,
+			Jobs:        *backupJobs,
+			SchemaOnly:  *backupSchemaOnly,
+			DataOnly:    *backupDataOnly,
+		})
+		if err != nil {
+			handleError(cmd, err, "backup")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Backup created at %s", path), map[string]interface{}{
+			"path": path,
+		})
+	},
+}
+
+var restoreBackupCmd = &cobra.Command{
+	Use:   "restore [backup]",
+	Short: "Restore the database from a backup file, directory or storage URL",
+	Long: `Restore the database from a backup, given as argument or with --file, dropping
+and recreating the objects it contains. Archives are cleaned by pg_restore; plain
+SQL backups carry the DROP statements written by db backup create, which
+--clean=false skips. Asks for confirmation unless --yes is given; without a
+terminal, --yes is required. --dry-run reads the header of the backup and prints
+how it would be restored.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !*restoreDryRun && !confirmProduction(cmd, "restore a backup") {
+			return
+		}
+
+		backupPath, err := restoreSource(args, *restoreFile)
+		if err != nil {
+			handleError(cmd, err, "restore")
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 2*time.Hour)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		opts := database.RestoreOptions{
+			Clean:       *restoreClean,
+			Jobs:        *restoreJobs,
+			SchemaOnly:  *restoreSchemaOnly,
+			DataOnly:    *restoreDataOnly,
+			StopOnError: *restoreStopOnError,
+		}
+
+		if *restoreDryRun {
+			plan, err := db.PlanRestore(ctx, backupPath, opts)
+			if err != nil {
+				handleError(cmd, err, "restore")
+				return
+			}
+			if textOutput(cmd) {
+				printRestorePlan(cmd, plan)
+			}
+			handleSuccess(cmd, fmt.Sprintf("Would restore %s into %s", backupPath, plan.Database), map[string]interface{}{
+				"plan":    plan,
+				"dry_run": true,
+			})
+			return
+		}
+
+		question := fmt.Sprintf("Overwrite existing data with %s", backupPath)
+		if !confirmDestructive(cmd, *restoreYes, db.Config(), question, "restore") {
+			return
+		}
+
+		err = db.RestoreWithOptions(ctx, backupPath, opts)
+		if err != nil {
+			handleError(cmd, err, "restore")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Database restored from %s", backupPath), map[string]interface{}{
+			"backup": backupPath,
+		})
+	},
+}
+
+func printRestorePlan(cmd *cobra.Command, plan *database.RestorePlan) {
+	layers := plan.Compression
+	if plan.Encrypted {
+		layers += ", encrypted"
+	}
+	verified := "no manifest"
+	if plan.Verified {
+		verified = "matches its manifest"
+	}
+	content := "schema and data"
+	switch {
+	case plan.SchemaOnly:
+		content = "schema only"
+	case plan.DataOnly:
+		content = "data only"
+	}
+
+	cmd.Printf("Restore plan:\n")
+	cmd.Printf("  backup:   %s (%s, %s)\n", plan.Backup, layers, verified)
+	cmd.Printf("  target:   database %s at %s\n", plan.Database, plan.Host)
+	cmd.Printf("  tool:     %s, %s, clean %t\n", plan.Tool, content, plan.Clean)
+}
+
+// restoreSource returns the backup named by the argument or --file, but not both
+func restoreSource(args []string, file string) (string, error) {
+	switch {
+	case len(args) == 1 && file != "":
+		return "", database.NewValidationError("give the backup as argument or with --file, not both", nil)
+	case len(args) == 1:
+		return args[0], nil
+	case file != "":
+		return file, nil
+	default:
+		return "", database.NewValidationError("no backup given", nil)
+	}
+}
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old backups according to a retention policy",
+	Long: `Delete the backups of the database in the backups directory or storage URL that
+no retention rule keeps. A backup is kept if any rule selects it, e.g.
+
+  db backup prune --keep-last 3 --keep-daily 7 --keep-weekly 4 --keep-monthly 12`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if !*pruneDryRun && !confirmProduction(cmd, "prune backups") {
+			return
+		}
+
+		// Remote storage may need many requests
+		ctx, cancel := commandContext(cmd, 5*time.Minute)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		result, err := db.PruneBackups(ctx, database.RetentionPolicy{
+			KeepLast:    *keepLast,
+			KeepDaily:   *keepDaily,
+			KeepWeekly:  *keepWeekly,
+			KeepMonthly: *keepMonthly,
+			DryRun:      *pruneDryRun,
+		})
+		if err != nil {
+			handleError(cmd, err, "prune_backups")
+			return
+		}
+
+		if textOutput(cmd) {
+			printPruneResult(cmd, result, *pruneDryRun)
+		}
+
+		message := fmt.Sprintf("Pruned %d backups, kept %d", len(result.Pruned), len(result.Kept))
+		if *pruneDryRun {
+			message = fmt.Sprintf("Would prune %d backups, keep %d", len(result.Pruned), len(result.Kept))
+		}
+		handleSuccess(cmd, message, map[string]interface{}{
+			"kept":    result.Kept,
+			"pruned":  result.Pruned,
+			"dry_run": *pruneDryRun,
+		})
+	},
+}
+
+func printPruneResult(cmd *cobra.Command, result database.PruneResult, dryRun bool) {
+	action := "delete"
+	if dryRun {
+		action = "would delete"
+	}
+	for _, backup := range result.Kept {
+		cmd.Printf("  keep          %s\n", backup.Key)
+	}
+	for _, backup := range result.Pruned {
+		cmd.Printf("  %-13s %s\n", action, backup.Key)
+	}
+}
+
+var listBackupsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List backups in the backups directory or storage, newest first",
+	Run: func(cmd *cobra.Command, _ []string) {
+		// Remote storage may need a request per manifest
+		ctx, cancel := commandContext(cmd, 5*time.Minute)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		backups, err := db.ListBackups(ctx)
+		if err != nil {
+			handleError(cmd, err, "list_backups")
+			return
+		}
+
+		if textOutput(cmd) {
+			printBackups(cmd, backups)
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Found %d backups", len(backups)), map[string]interface{}{
+			"backups": backups,
+		})
+	},
+}
+
+func printBackups(cmd *cobra.Command, backups []database.BackupEntry) {
+	if len(backups) == 0 {
+		return
+	}
+	cmd.Printf("%-19s  %10s  %-9s  %-5s  %-9s  %-8s  %s\n", "CREATED", "SIZE", "FORMAT", "CODEC", "ENCRYPTED", "VERIFIED", "NAME")
+	for _, backup := range backups {
+		cmd.Printf("%-19s  %10s  %-9s  %-5s  %-9t  %-8t  %s\n",
+			backup.Created.Local().Format("2006-01-02 15:04:05"), formatBytes(backup.Size),
+			backup.Format, backup.Compression, backup.Encrypted, backup.Verified, backup.Name)
+	}
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. 1.5 GiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+var verifyBackupCmd = &cobra.Command{
+	Use:   "verify <backup>",
+	Short: "Verify a backup by restoring it into a temporary database",
+	Long: `Restore a backup file, directory or storage URL into a new temporary database,
+compare the restored tables with the backup's manifest and drop the temporary
+database again. The connecting user needs permission to create databases.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Restoring a large backup can take a while
+		ctx, cancel := commandContext(cmd, 2*time.Hour)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		result, err := db.VerifyBackup(ctx, args[0])
+		if err != nil {
+			handleError(cmd, err, "verify_backup")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Backup verified: %d tables restored in %s", result.Tables, result.Duration.Round(time.Second)), map[string]interface{}{
+			"backup":          result.Backup,
+			"tables":          result.Tables,
+			"expected_tables": result.ExpectedTables,
+			"duration":        result.Duration.String(),
+		})
+	},
+}
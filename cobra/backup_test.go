@@ -0,0 +1,131 @@
+package cobra
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupCommands(t *testing.T) {
+	found := map[string]bool{}
+	for _, cmd := range backupCmd.Commands() {
+		found[cmd.Name()] = true
+	}
+	for _, name := range []string{"create", "restore", "list", "verify", "prune", "base"} {
+		assert.True(t, found[name], "backup %s should be registered", name)
+	}
+
+	for _, flag := range []string{"file", "format", "compress", "jobs", "schema-only", "data-only", "json"} {
+		assert.NotNil(t, createBackupCmd.Flags().Lookup(flag), "create should have --%s", flag)
+	}
+	for _, flag := range []string{"yes", "file", "jobs", "schema-only", "data-only", "clean", "stop-on-error", "dry-run", "json"} {
+		assert.NotNil(t, restoreBackupCmd.Flags().Lookup(flag), "restore should have --%s", flag)
+	}
+
+	for _, flag := range []string{"keep-last", "keep-daily", "keep-weekly", "keep-monthly", "dry-run", "json"} {
+		assert.NotNil(t, pruneCmd.Flags().Lookup(flag), "prune should have --%s", flag)
+	}
+}
+
+func TestPrintRestorePlan(t *testing.T) {
+	var out strings.Builder
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printRestorePlan(cmd, &database.RestorePlan{
+		Backup:      "backup_app.sql.gz",
+		Database:    "app",
+		Host:        "db.internal",
+		Tool:        "psql",
+		Compression: database.CompressionGzip,
+		Verified:    true,
+		Clean:       true,
+	})
+	assert.Equal(t, `Restore plan:
+  backup:   backup_app.sql.gz (gzip, matches its manifest)
+  target:   database app at db.internal
+  tool:     psql, schema and data, clean true
+`, out.String())
+}
+
+func TestPrintPruneResult(t *testing.T) {
+	var out strings.Builder
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printPruneResult(cmd, database.PruneResult{
+		Kept:   []database.BackupObject{{Key: "backup_app_20250102_030000.sql"}},
+		Pruned: []database.BackupObject{{Key: "backup_app_20250101_030000.sql"}},
+	}, true)
+
+	assert.Contains(t, out.String(), "keep          backup_app_20250102_030000.sql")
+	assert.Contains(t, out.String(), "would delete  backup_app_20250101_030000.sql")
+}
+
+func TestPrintBackups(t *testing.T) {
+	var out strings.Builder
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printBackups(cmd, []database.BackupEntry{{
+		Name:        "backup_app_20250102_030000.dump.zst",
+		Created:     time.Date(2025, 1, 2, 3, 0, 0, 0, time.Local),
+		Size:        3 << 20,
+		Format:      database.FormatCustom,
+		Compression: database.CompressionZstd,
+		Verified:    true,
+	}})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "VERIFIED")
+	assert.Contains(t, lines[1], "2025-01-02 03:00:00")
+	assert.Contains(t, lines[1], "3.0 MiB")
+	assert.Contains(t, lines[1], "backup_app_20250102_030000.dump.zst")
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 GiB", formatBytes(2<<30))
+}
+
+func TestRestoreCommand(t *testing.T) {
+	assert.Equal(t, "restore", restoreCmd.Use)
+	for _, flag := range []string{"target-time", "base-backup", "data-dir", "wal-archive", "restore-command", "target-action"} {
+		assert.NotNil(t, restoreCmd.Flags().Lookup(flag), "restore should have --%s", flag)
+	}
+}
+
+func TestParseTargetTime(t *testing.T) {
+	got, err := parseTargetTime("2025-01-02T15:04:05Z")
+	assert.NoError(t, err)
+	assert.True(t, got.Equal(time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)))
+
+	got, err = parseTargetTime("2025-01-02 15:04:05")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Local, got.Location())
+
+	_, err = parseTargetTime("yesterday")
+	assert.Error(t, err)
+}
+
+func TestRestoreSource(t *testing.T) {
+	path, err := restoreSource([]string{"backup.dump"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "backup.dump", path)
+
+	path, err = restoreSource(nil, "s3://bucket/backup.dump")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3://bucket/backup.dump", path)
+
+	_, err = restoreSource([]string{"backup.dump"}, "other.dump")
+	assert.Error(t, err)
+
+	_, err = restoreSource(nil, "")
+	assert.Error(t, err)
+}
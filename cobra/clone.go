@@ -0,0 +1,59 @@
+package cobra
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cloneMethod  = new(string)
+	cloneReplace = new(bool)
+	cloneJobs    = new(int)
+)
+
+func init() {
+	DBCmd.AddCommand(cloneCmd)
+
+	cloneCmd.Flags().StringVar(cloneMethod, "method", "", "Clone method: template or dump (default: template, falling back to dump while the database is in use)")
+	cloneCmd.Flags().BoolVar(cloneReplace, "replace", false, "Drop the target database first if it exists")
+	cloneCmd.Flags().IntVarP(cloneJobs, "jobs", "j", 0, "Dump and restore this many tables in parallel when cloning by dump")
+
+	addErrorFlags(cloneCmd)
+}
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <target>",
+	Short: "Create a copy of the database, e.g. for staging",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Cloning by dump takes as long as a backup and restore
+		ctx, cancel := commandContext(cmd, 2*time.Hour)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		result, err := db.Clone(ctx, args[0], database.CloneOptions{
+			Method:  *cloneMethod,
+			Replace: *cloneReplace,
+			Jobs:    *cloneJobs,
+		})
+		if err != nil {
+			handleError(cmd, err, "clone")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Database %s cloned to %s by %s in %s", db.Config().DBName, result.Target, result.Method, result.Duration.Round(time.Second)), map[string]interface{}{
+			"target":   result.Target,
+			"method":   result.Method,
+			"duration": result.Duration.String(),
+		})
+	},
+}
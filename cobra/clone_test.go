@@ -0,0 +1,14 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloneCommand(t *testing.T) {
+	assert.Equal(t, "clone <target>", cloneCmd.Use)
+	for _, flag := range []string{"method", "replace", "jobs", "json"} {
+		assert.NotNil(t, cloneCmd.Flags().Lookup(flag), "clone should have --%s", flag)
+	}
+}
@@ -0,0 +1,110 @@
+package cobra
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+// completionTimeout bounds the queries of shell completions, so a slow or unreachable
+// server leaves the prompt without suggestions instead of hanging it
+const completionTimeout = 2 * time.Second
+
+func init() {
+	for _, cmd := range []*cobra.Command{schemaCmd, tablesCmd, relationshipsCmd, enumsCmd, functionsCmd, sizesCmd, unusedIndexesCmd, bloatCmd, exportSchemaCmd} {
+		cmd.ValidArgsFunction = completeSchemaArg
+	}
+	for _, cmd := range []*cobra.Command{tableCmd, columnsCmd, indexesCmd, constraintsCmd, triggersCmd, partitionsCmd, grantsCmd} {
+		cmd.ValidArgsFunction = completeSchemaTableArgs
+	}
+}
+
+// completeSchemaArg completes the schema argument of commands taking [schema_name]
+func completeSchemaArg(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeNames(toComplete, func(ctx context.Context, introspection *database.IntrospectionService) ([]string, error) {
+		return introspection.GetSchemas(ctx)
+	})
+}
+
+// completeSchemaTableArgs completes the arguments of commands taking [schema_name]
+// [table_name]
+func completeSchemaTableArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeSchemaArg(cmd, args, toComplete)
+	case 1:
+		return completeNames(toComplete, func(ctx context.Context, introspection *database.IntrospectionService) ([]string, error) {
+			return tableNames(ctx, introspection, args[0])
+		})
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// tableNames returns the names of the tables of schema, without fetching their details
+func tableNames(ctx context.Context, introspection *database.IntrospectionService, schema string) ([]string, error) {
+	tables, err := introspection.GetTablesWithOptions(ctx, database.IntrospectOptions{
+		Schema:           schema,
+		SkipColumns:      true,
+		SkipIndexes:      true,
+		SkipConstraints:  true,
+		SkipTriggers:     true,
+		SkipPartitioning: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	return names, nil
+}
+
+// completeNames suggests the names list returns that start with toComplete. Connection
+// and query errors only show up with cobra's completion debug log.
+func completeNames(toComplete string, list func(context.Context, *database.IntrospectionService) ([]string, error)) ([]string, cobra.ShellCompDirective) {
+	config, err := newConfig()
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if config.ConnectTimeout == 0 || config.ConnectTimeout > completionTimeout {
+		config.ConnectTimeout = completionTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	db, err := database.New(config)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer db.Close()
+
+	names, err := list(ctx, db.Introspection())
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// filterPrefix returns the names starting with prefix
+func filterPrefix(names []string, prefix string) []string {
+	matches := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,33 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletionRegistered(t *testing.T) {
+	assert.NotNil(t, tablesCmd.ValidArgsFunction)
+	assert.NotNil(t, columnsCmd.ValidArgsFunction)
+	assert.NotNil(t, indexesCmd.ValidArgsFunction)
+	assert.NotNil(t, constraintsCmd.ValidArgsFunction)
+}
+
+func TestCompleteSchemaTableArgsComplete(t *testing.T) {
+	// Both arguments given, nothing left to complete and no query to run
+	names, directive := completeSchemaTableArgs(columnsCmd, []string{"public", "users"}, "")
+	assert.Empty(t, names)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	names, directive = completeSchemaArg(tablesCmd, []string{"public"}, "")
+	assert.Empty(t, names)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+}
+
+func TestFilterPrefix(t *testing.T) {
+	names := []string{"orders", "order_items", "users"}
+	assert.Equal(t, []string{"orders", "order_items"}, filterPrefix(names, "order"))
+	assert.Equal(t, names, filterPrefix(names, ""))
+	assert.Empty(t, filterPrefix(names, "x"))
+}
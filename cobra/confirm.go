@@ -0,0 +1,57 @@
+package cobra
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+// isInteractive reports whether the command reads from a terminal
+func isInteractive(cmd *cobra.Command) bool {
+	file, ok := cmd.InOrStdin().(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// confirm asks a yes/no question on the command's input and reports whether it was accepted
+func confirm(cmd *cobra.Command, question string) bool {
+	cmd.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// confirmDestructive asks question before an irreversible operation on the database of
+// config, naming the database and server, unless yes is set; without a terminal, yes is
+// required. Reports whether the command may go on, having reported the error otherwise.
+func confirmDestructive(cmd *cobra.Command, yes bool, config database.Config, question, op string) bool {
+	if yes {
+		return true
+	}
+	question = fmt.Sprintf("%s on database %s at %s:%d?", question, config.DBName, config.Host, config.Port)
+	if isInteractive(cmd) && confirm(cmd, question) {
+		return true
+	}
+	handleError(cmd, database.NewValidationError("not confirmed, re-run with --yes to run without a prompt", nil).
+		WithContext("database", config.DBName).
+		WithContext("host", config.Host), op)
+	return false
+}
@@ -0,0 +1,65 @@
+package cobra
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "y\n", want: true},
+		{input: "YES\n", want: true},
+		{input: "n\n", want: false},
+		{input: "\n", want: false},
+		{input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		cmd := &cobra.Command{}
+		cmd.SetIn(strings.NewReader(tt.input))
+		cmd.SetOut(&strings.Builder{})
+
+		if got := confirm(cmd, "Continue?"); got != tt.want {
+			t.Errorf("confirm(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestIsInteractiveWithReader(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader("y\n"))
+
+	if isInteractive(cmd) {
+		t.Error("Expected a non-file input to be non-interactive")
+	}
+}
+
+func TestConfirmDestructiveWithYes(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader(""))
+	out := &strings.Builder{}
+	cmd.SetOut(out)
+
+	config := database.Config{DBName: "app", Host: "db.internal", Port: 5432}
+	if !confirmDestructive(cmd, true, config, "Roll back all migrations", "reset_migrations") {
+		t.Error("Expected --yes to skip the confirmation")
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no prompt with --yes, got %q", out.String())
+	}
+}
+
+func TestRollbackCommandsHaveYes(t *testing.T) {
+	for _, cmd := range []*cobra.Command{downCmd, downToCmd, downOneCmd, resetCmd, applyCmd, redoCmd} {
+		if cmd.Flags().ShorthandLookup("y") == nil {
+			t.Errorf("Expected %s to have --yes", cmd.Name())
+		}
+	}
+}
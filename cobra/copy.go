@@ -0,0 +1,139 @@
+package cobra
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	copyFrom      = new(string)
+	copyTo        = new(string)
+	copyTables    = new([]string)
+	copyTruncate  = new(bool)
+	copyBatchSize = new(int)
+	copyYes       = new(bool)
+)
+
+func init() {
+	DBCmd.AddCommand(copyCmd)
+
+	copyCmd.Flags().StringVar(copyFrom, "from", "", "Database to copy from: a profile of the config file, a postgres:// URL or a database name on the same server (default the current connection)")
+	copyCmd.Flags().StringVar(copyTo, "to", "", "Database to copy into: a profile of the config file, a postgres:// URL or a database name on the same server")
+	copyCmd.Flags().StringSliceVar(copyTables, "tables", nil, "Tables to copy, optionally schema-qualified (default all tables)")
+	copyCmd.Flags().BoolVar(copyTruncate, "truncate", false, "Empty each target table before copying into it")
+	copyCmd.Flags().IntVar(copyBatchSize, "batch-size", 10000, "Rows committed per target transaction")
+	copyCmd.Flags().BoolVarP(copyYes, "yes", "y", false, "Truncate without asking for confirmation")
+	_ = copyCmd.MarkFlagRequired("to")
+
+	addErrorFlags(copyCmd)
+}
+
+var copyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Copy table data from one database into another",
+	Long: `Stream the rows of tables from the --from database, the current connection by default,
+into the same tables of the --to database with COPY. Both are connection profiles,
+postgres:// URLs or database names on the same server. The target tables must exist with
+the source's columns, e.g. after migrating the target.
+
+Tables are read from one snapshot of the source and copied after the tables they
+reference. Each batch is committed on its own, so a failed copy leaves the rows copied so
+far; re-run it with --truncate. --truncate asks for confirmation unless --yes is given,
+and copying into a production profile asks for its name.
+
+  db copy --from prod --to staging --tables users,orders --truncate
+  db copy --to postgres://dev@localhost/app_dev --batch-size 50000`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !confirmProductionTarget(cmd, *copyTo, "copy data into it") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 6*time.Hour)
+		defer cancel()
+
+		source, target, err := copyConfigs(*copyFrom, *copyTo)
+		if err != nil {
+			handleError(cmd, err, "copy")
+			return
+		}
+		if source.Host == target.Host && source.Port == target.Port && source.DBName == target.DBName {
+			handleError(cmd, database.NewValidationError("the source and target are the same database", nil).
+				WithContext("database", target.DBName), "copy")
+			return
+		}
+
+		if *copyTruncate {
+			question := "Truncate the copied tables"
+			if len(*copyTables) == 0 {
+				question = "Truncate all tables of the source"
+			}
+			if !confirmDestructive(cmd, *copyYes, target, question, "copy") {
+				return
+			}
+		}
+
+		opts := database.CopyOptions{
+			Tables:    *copyTables,
+			Truncate:  *copyTruncate,
+			BatchSize: *copyBatchSize,
+		}
+		if textOutput(cmd) && !jsonLogs() {
+			opts.Progress = func(progress database.CopyProgress) { printCopyProgress(cmd, progress) }
+		}
+
+		start := time.Now()
+		results, err := database.Copy(ctx, source, target, opts)
+		if err != nil {
+			handleError(cmd, err, "copy")
+			return
+		}
+
+		var rows int64
+		for _, result := range results {
+			rows += result.Rows
+		}
+		message := fmt.Sprintf("Copied %d rows of %d tables from %s to %s in %s",
+			rows, len(results), source.DBName, target.DBName, time.Since(start).Round(time.Millisecond))
+		handleSuccess(cmd, message, map[string]interface{}{
+			"source": source.DBName,
+			"target": target.DBName,
+			"rows":   rows,
+			"tables": results,
+		})
+	},
+}
+
+// copyConfigs returns the settings of the --from and --to databases, resolved as the target
+// of diff on top of the current connection
+func copyConfigs(from, to string) (source, target database.Config, err error) {
+	current, err := newConfig()
+	if err != nil {
+		return source, target, err
+	}
+	source = current
+	if from != "" {
+		if source, err = diffTargetConfig(current, from); err != nil {
+			return source, target, err
+		}
+	}
+	target, err = diffTargetConfig(current, to)
+	return source, target, err
+}
+
+// printCopyProgress prints the progress of a table copy on stderr, one line per batch
+func printCopyProgress(cmd *cobra.Command, progress database.CopyProgress) {
+	estimate := ""
+	if progress.EstimatedRows > 0 && !progress.Done {
+		estimate = fmt.Sprintf(" of ~%d", progress.EstimatedRows)
+	}
+	state := "copying"
+	if progress.Done {
+		state = "done in " + progress.Duration.Round(time.Millisecond).String()
+	}
+	cmd.PrintErrf("[%d/%d] %s: %d rows%s, %s\n", progress.Index, progress.Total, progress.Table, progress.Rows, estimate, state)
+}
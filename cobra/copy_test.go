@@ -0,0 +1,65 @@
+package cobra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/b87/db-kit/database"
+)
+
+func TestCopyCommand(t *testing.T) {
+	assert.Equal(t, "copy", copyCmd.Use)
+	for _, name := range []string{"from", "to", "tables", "truncate", "batch-size", "yes", "json"} {
+		assert.NotNil(t, copyCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+	assert.Equal(t, []string{"true"}, copyCmd.Flags().Lookup("to").Annotations[cobra.BashCompOneRequiredFlag])
+	assert.Error(t, copyCmd.Args(copyCmd, []string{"users"}))
+}
+
+func TestCopyConfigs(t *testing.T) {
+	useProfile(t, testCLIConfig, "dev")
+
+	// The source defaults to the current connection
+	source, target, err := copyConfigs("", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "app_dev", source.DBName)
+	assert.Equal(t, "db.internal", target.Host)
+	assert.Equal(t, "app", target.DBName)
+
+	source, target, err = copyConfigs("prod", "app_test")
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", source.Host)
+	assert.Equal(t, "localhost", target.Host)
+	assert.Equal(t, "app_test", target.DBName)
+}
+
+func TestConfirmProductionTarget(t *testing.T) {
+	useProfile(t, testCLIConfig, "dev")
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader(""))
+	cmd.SetErr(&bytes.Buffer{})
+	assert.True(t, confirmProductionTarget(cmd, "app_test", "copy data into it"))
+	assert.True(t, confirmProductionTarget(cmd, "dev", "copy data into it"))
+
+	// Production profiles cannot be confirmed without a terminal
+	assert.False(t, confirmProductionTarget(cmd, "prod", "copy data into it"))
+	err := commandError(cmd)
+	require.Error(t, err)
+	assert.Equal(t, ExitValidation, ExitCode(err))
+}
+
+func TestPrintCopyProgress(t *testing.T) {
+	cmd := &cobra.Command{}
+	var out bytes.Buffer
+	cmd.SetErr(&out)
+
+	printCopyProgress(cmd, database.CopyProgress{Table: "public.users", Index: 1, Total: 2, Rows: 10000, EstimatedRows: 25000})
+	printCopyProgress(cmd, database.CopyProgress{Table: "public.users", Index: 1, Total: 2, Rows: 25000, EstimatedRows: 25000, Done: true, Duration: 1500 * time.Millisecond})
+	assert.Equal(t, "[1/2] public.users: 10000 rows of ~25000, copying\n[1/2] public.users: 25000 rows, done in 1.5s\n", out.String())
+}
@@ -0,0 +1,224 @@
+package cobra
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	exportDataQuery   = new(string)
+	exportDataWhere   = new(string)
+	exportDataFormat  = new(string)
+	exportDataFile    = new(string)
+	exportDataMask    = new(string)
+	exportDataTimeout = new(time.Duration)
+
+	importDataFormat   = new(string)
+	importDataMap      = new([]string)
+	importDataTruncate = new(bool)
+	importDataTimeout  = new(time.Duration)
+)
+
+func init() {
+	DBCmd.AddCommand(exportDataCmd)
+	DBCmd.AddCommand(importDataCmd)
+
+	exportDataCmd.Flags().StringVar(exportDataQuery, "query", "", "Export the result of this SELECT instead of a table")
+	exportDataCmd.Flags().StringVar(exportDataWhere, "where", "", "Only export the rows matching this SQL condition")
+	exportDataCmd.Flags().StringVar(exportDataFormat, "format", "", "Format of the export: csv or ndjson, from the file extension by default, else csv")
+	exportDataCmd.Flags().StringVar(exportDataFile, "file", "", "File to write, stdout by default")
+	exportDataCmd.Flags().StringVar(exportDataMask, "mask", "", "Masking config whose columns of the table are exported with fake values, as for db anonymize")
+	exportDataCmd.Flags().DurationVar(exportDataTimeout, "timeout", time.Hour, "Cancel the export after this long")
+
+	importDataCmd.Flags().StringVar(importDataFormat, "format", "", "Format of the file: csv or ndjson, from the file extension by default")
+	importDataCmd.Flags().StringArrayVar(importDataMap, "map", nil, "Import a file column into a table column of another name, as file_column=table_column (repeatable)")
+	importDataCmd.Flags().BoolVar(importDataTruncate, "truncate-first", false, "Empty the table before importing, in the same transaction")
+	importDataCmd.Flags().DurationVar(importDataTimeout, "timeout", time.Hour, "Cancel the import after this long")
+
+	addErrorFlags(exportDataCmd)
+	addErrorFlags(importDataCmd)
+}
+
+var exportDataCmd = &cobra.Command{
+	Use:   "export [schema.]table",
+	Short: "Export the rows of a table or query to CSV or NDJSON",
+	Long: `Stream the rows of a table, or of the SELECT given with --query, to a file or stdout.
+CSV exports have a header row and write NULL as an empty field; NDJSON exports write one
+JSON object per row. Parquet is not supported yet. With --mask, the columns of the table
+listed in a masking config are exported with fake values (see db anonymize).
+
+  db export users --where "created_at > now() - interval '1 day'" --file users.csv
+  db export --query "SELECT id, email FROM users" --format ndjson > users.ndjson`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := database.ExportDataOptions{
+			Query:  *exportDataQuery,
+			Where:  *exportDataWhere,
+			Format: dataFormat(*exportDataFormat, *exportDataFile),
+		}
+		if len(args) > 0 {
+			opts.Table = args[0]
+		}
+		if *exportDataMask != "" {
+			if opts.Table == "" {
+				handleError(cmd, database.NewValidationError("--mask takes a table to export, not --query", nil), "export_data")
+				return
+			}
+			config, err := database.LoadMaskingConfig(*exportDataMask)
+			if err != nil {
+				handleError(cmd, err, "export_data")
+				return
+			}
+			opts.Masks = config.TableMasks(opts.Table)
+			opts.MaskSeed = config.Seed
+		}
+		if *exportDataFile == "" && !textOutput(cmd) {
+			handleError(cmd, database.NewValidationError("--file is required with structured output, which would mix with the rows on stdout", nil), "export_data")
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, *exportDataTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		out := cmd.OutOrStdout()
+		if *exportDataFile != "" {
+			file, err := os.Create(*exportDataFile)
+			if err != nil {
+				handleError(cmd, database.NewValidationError("failed to create the export file", err).
+					WithContext("file", *exportDataFile), "export_data")
+				return
+			}
+			defer file.Close()
+			out = file
+		}
+
+		rows, err := db.ExportData(ctx, out, opts)
+		if err != nil {
+			if *exportDataFile != "" {
+				_ = os.Remove(*exportDataFile)
+			}
+			handleError(cmd, err, "export_data")
+			return
+		}
+
+		data := map[string]interface{}{
+			"rows":   rows,
+			"format": opts.Format,
+		}
+		if *exportDataFile != "" {
+			data["file"] = *exportDataFile
+		}
+		handleSuccess(cmd, fmt.Sprintf("%d rows exported", rows), data)
+	},
+}
+
+var importDataCmd = &cobra.Command{
+	Use:   "import [schema.]table <file>",
+	Short: "Import CSV or NDJSON rows into a table",
+	Long: `Load the rows of a file into a table with COPY, in one transaction. CSV files need a
+header row naming the columns, and empty fields are imported as NULL; NDJSON files hold one
+JSON object per line, all with the keys of the first. Columns named differently in the file
+are mapped with --map. Use - to read the file from stdin, as CSV unless --format is given.
+
+  db import users users.csv --truncate-first
+  db import audit.events events.ndjson --map ts=created_at --map kind=event_type`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		columns, err := parseColumnMap(*importDataMap)
+		if err != nil {
+			handleError(cmd, err, "import_data")
+			return
+		}
+		opts := database.ImportDataOptions{
+			Table:    args[0],
+			Format:   dataFormat(*importDataFormat, args[1]),
+			Columns:  columns,
+			Truncate: *importDataTruncate,
+		}
+
+		action := "import data"
+		if opts.Truncate {
+			action = "truncate and import data"
+		}
+		if !confirmProduction(cmd, action) {
+			return
+		}
+
+		in := cmd.InOrStdin()
+		if args[1] != "-" {
+			file, err := os.Open(args[1])
+			if err != nil {
+				handleError(cmd, database.NewValidationError("failed to open the import file", err).
+					WithContext("file", args[1]), "import_data")
+				return
+			}
+			defer file.Close()
+			in = file
+		}
+
+		ctx, cancel := commandContext(cmd, *importDataTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		rows, err := db.ImportData(ctx, in, opts)
+		if err != nil {
+			handleError(cmd, err, "import_data")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("%d rows imported into %s", rows, opts.Table), map[string]interface{}{
+			"table":     opts.Table,
+			"file":      args[1],
+			"rows":      rows,
+			"truncated": opts.Truncate,
+		})
+	},
+}
+
+// dataFormat returns the format of --format, or the one of the extension of path. Exports
+// to stdout default to CSV.
+func dataFormat(format, path string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return database.DataFormatNDJSON
+	case ".parquet":
+		return "parquet"
+	}
+	return database.DataFormatCSV
+}
+
+// parseColumnMap parses the file_column=table_column values of --map
+func parseColumnMap(values []string) (map[string]string, error) {
+	columns := make(map[string]string, len(values))
+	for _, value := range values {
+		from, to, ok := strings.Cut(value, "=")
+		if !ok || from == "" || to == "" {
+			return nil, database.NewValidationError(fmt.Sprintf("invalid column mapping %q, expected file_column=table_column", value), nil).
+				WithContext("map", value)
+		}
+		columns[from] = to
+	}
+	return columns, nil
+}
@@ -0,0 +1,36 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/b87/db-kit/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataCommands(t *testing.T) {
+	assert.NoError(t, exportDataCmd.Args(exportDataCmd, []string{}))
+	assert.NoError(t, exportDataCmd.Args(exportDataCmd, []string{"users"}))
+	assert.Error(t, importDataCmd.Args(importDataCmd, []string{"users"}))
+	assert.Equal(t, "", exportDataCmd.Flags().Lookup("file").DefValue)
+	assert.Equal(t, "false", importDataCmd.Flags().Lookup("truncate-first").DefValue)
+}
+
+func TestDataFormat(t *testing.T) {
+	assert.Equal(t, database.DataFormatCSV, dataFormat("", ""))
+	assert.Equal(t, database.DataFormatCSV, dataFormat("", "users.csv"))
+	assert.Equal(t, database.DataFormatNDJSON, dataFormat("", "users.JSONL"))
+	assert.Equal(t, database.DataFormatNDJSON, dataFormat("NDJSON", "users.csv"))
+	assert.Equal(t, "parquet", dataFormat("", "users.parquet"))
+}
+
+func TestParseColumnMap(t *testing.T) {
+	columns, err := parseColumnMap([]string{"ts=created_at", "kind=event_type"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"ts": "created_at", "kind": "event_type"}, columns)
+
+	for _, value := range []string{"ts", "=created_at", "ts="} {
+		_, err := parseColumnMap([]string{value})
+		assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err), value)
+	}
+}
@@ -0,0 +1,101 @@
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffTarget   = new(string)
+	diffExitCode = new(bool)
+)
+
+func init() {
+	DBCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(diffTarget, "target", "", "Database to compare against: a profile of the config file, a postgres:// URL or a database name on the same server")
+	diffCmd.Flags().BoolVar(diffExitCode, "exit-code", false, "Exit with status 1 if the schemas differ")
+	_ = diffCmd.MarkFlagRequired("target")
+
+	addErrorFlags(diffCmd)
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [schema_name]",
+	Short: "Compare the schema with another database",
+	Long: `Compare the tables, columns, indexes and constraints of the database with the
+--target database and report what is added, dropped or altered in the target. The target
+is a connection profile, a postgres:// URL or a database name on the same server. With
+--exit-code, differences exit with status 1, e.g. to gate releases:
+
+  db --profile prod diff --target staging --exit-code
+  POSTGRES_DB=app db diff --target postgres://deploy@staging/app --output json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 5*time.Minute)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		targetConfig, err := diffTargetConfig(db.Config(), *diffTarget)
+		if err != nil {
+			handleError(cmd, err, "connect_target")
+			return
+		}
+		target, err := database.New(targetConfig)
+		if err != nil {
+			handleError(cmd, err, "connect_target")
+			return
+		}
+		defer target.Close()
+
+		var opts database.DiffOptions
+		if len(args) > 0 {
+			opts.Schema = args[0]
+		}
+
+		diff, err := database.DiffSchemas(ctx, db, target, opts)
+		if err != nil {
+			handleError(cmd, err, "diff_schemas")
+			return
+		}
+
+		if !textOutput(cmd) {
+			handleSuccess(cmd, fmt.Sprintf("%d schema changes", len(diff.Changes)), map[string]interface{}{
+				"source":  diff.Source,
+				"target":  diff.Target,
+				"changes": diff.Changes,
+			})
+		} else if err := diff.Report(cmd.OutOrStdout()); err != nil {
+			handleError(cmd, err, "diff_schemas")
+			return
+		}
+
+		if *diffExitCode && diff.HasChanges() {
+			exitWith(cmd, ExitFailure, errors.New("the schemas differ"))
+		}
+	},
+}
+
+// diffTargetConfig returns the settings of the --target database: the profile of that name,
+// or else a URL or database name applied to the source settings
+func diffTargetConfig(source database.Config, target string) (database.Config, error) {
+	config, ok, err := profileConfig(target)
+	if err != nil {
+		return config, err
+	}
+	if !ok {
+		return database.ParseDSN(target, source)
+	}
+	config.Logger = source.Logger
+	return config, nil
+}
@@ -0,0 +1,46 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/b87/db-kit/database"
+)
+
+func TestDiffCommand(t *testing.T) {
+	assert.Equal(t, "diff [schema_name]", diffCmd.Use)
+
+	for _, name := range []string{"target", "exit-code", "json"} {
+		assert.NotNil(t, diffCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+	assert.Equal(t, []string{"true"}, diffCmd.Flags().Lookup("target").Annotations[cobra.BashCompOneRequiredFlag])
+
+	assert.NoError(t, diffCmd.Args(diffCmd, []string{"public"}))
+	assert.Error(t, diffCmd.Args(diffCmd, []string{"public", "extra"}))
+}
+
+func TestDiffTargetConfig(t *testing.T) {
+	useProfile(t, testCLIConfig, "dev")
+	source := database.Config{Host: "localhost", Port: 5432, User: "postgres", DBName: "app_dev"}
+
+	// Profiles are looked up first
+	config, err := diffTargetConfig(source, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", config.Host)
+	assert.Equal(t, 6543, config.Port)
+	assert.Equal(t, "app", config.DBName)
+
+	// Other targets are URLs or database names on the source server
+	config, err = diffTargetConfig(source, "app_test")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", config.Host)
+	assert.Equal(t, "app_test", config.DBName)
+
+	config, err = diffTargetConfig(source, "postgres://deploy@staging:5433/app")
+	require.NoError(t, err)
+	assert.Equal(t, "staging", config.Host)
+	assert.Equal(t, 5433, config.Port)
+}
@@ -0,0 +1,78 @@
+package cobra
+
+import (
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	erdFormat  = new(string)
+	erdSchema  = new(string)
+	erdInclude = new([]string)
+	erdExclude = new([]string)
+)
+
+func init() {
+	DBCmd.AddCommand(erdCmd)
+
+	erdCmd.Flags().StringVar(erdFormat, "format", database.ERDFormatMermaid, "Diagram format: mermaid, dot or svg (requires Graphviz)")
+	erdCmd.Flags().StringVar(erdSchema, "schema", "", "Schema to draw, all schemas by default; same as the schema argument")
+	erdCmd.Flags().StringSliceVar(erdInclude, "include", nil, "Only draw tables matching these glob patterns, e.g. 'billing_*'")
+	erdCmd.Flags().StringSliceVar(erdExclude, "exclude", nil, "Leave out tables matching these glob patterns")
+
+	addErrorFlags(erdCmd)
+}
+
+var erdCmd = &cobra.Command{
+	Use:   "erd [schema_name]",
+	Short: "Render the foreign key graph as a Mermaid, Graphviz or SVG diagram",
+	Long: `Render the tables linked by foreign keys as an entity relationship diagram on stdout.
+Foreign keys are drawn when both their tables pass the --include and --exclude patterns.
+The svg format runs the Graphviz dot command:
+
+  db erd --format mermaid > schema.mmd
+  db erd --schema billing --exclude 'audit_*' --format svg > docs/billing.svg`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		schema := *erdSchema
+		if len(args) > 0 {
+			schema = args[0]
+		}
+
+		relationships, err := db.Introspection().GetForeignKeyRelationships(ctx, schema)
+		if err != nil {
+			handleError(cmd, err, "get_foreign_key_relationships")
+			return
+		}
+		relationships, err = database.FilterRelationships(relationships, database.IntrospectOptions{
+			Schema:        schema,
+			IncludeTables: *erdInclude,
+			ExcludeTables: *erdExclude,
+		})
+		if err != nil {
+			handleError(cmd, err, "render_erd")
+			return
+		}
+
+		if *erdFormat == database.ERDFormatSVG {
+			err = database.RenderERDSVG(ctx, cmd.OutOrStdout(), relationships)
+		} else {
+			err = database.RenderERD(cmd.OutOrStdout(), relationships, *erdFormat)
+		}
+		if err != nil {
+			handleError(cmd, err, "render_erd")
+		}
+	},
+}
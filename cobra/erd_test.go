@@ -0,0 +1,20 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestERDCommand(t *testing.T) {
+	assert.Equal(t, "erd [schema_name]", erdCmd.Use)
+	assert.Equal(t, "mermaid", erdCmd.Flags().Lookup("format").DefValue)
+	assert.NotNil(t, erdCmd.Flags().Lookup("json"))
+	for _, flag := range []string{"schema", "include", "exclude"} {
+		assert.NotNil(t, erdCmd.Flags().Lookup(flag), "erd should have --%s", flag)
+	}
+
+	assert.NoError(t, erdCmd.Args(erdCmd, []string{}))
+	assert.NoError(t, erdCmd.Args(erdCmd, []string{"public"}))
+	assert.Error(t, erdCmd.Args(erdCmd, []string{"public", "extra"}))
+}
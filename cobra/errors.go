@@ -1,18 +1,79 @@
 package cobra
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
 	"errors"
-	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/b87/db-kit/database"
 )
 
+// Exit codes of the CLI by class of failure, for scripts and CI to tell them apart
+const (
+	ExitOK         = 0
+	ExitFailure    = 1 // any other failure
+	ExitConnection = 2 // the database could not be reached or refused the credentials
+	ExitMigration  = 3 // a migration failed, is missing or conflicts
+	ExitValidation = 4 // invalid arguments, flags or configuration, or an unconfirmed operation
+	ExitTimeout    = 5 // an operation or connection timed out
+	ExitBackup     = 6 // a backup or restore failed
+	ExitQuery      = 7 // a query or transaction failed
+)
+
+// ExitError is a failed command: the exit code of its class of failure and the error
+// reported on stderr
+type ExitError struct {
+	Code   int
+	Output ErrorOutput
+	Err    error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// execution is the state of one run of a command, kept in its context rather than in the
+// package so a run of ExecuteArgs never sees the error of another
+type execution struct {
+	// err is the first error reported by the command, returned by ExecuteArgs
+	err *ExitError
+}
+
+type executionKey struct{}
+
+// commandExecution returns the execution of cmd, attaching a new one to its context if it
+// runs outside of ExecuteArgs
+func commandExecution(cmd *cobra.Command) *execution {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if e, ok := ctx.Value(executionKey{}).(*execution); ok {
+		return e
+	}
+	e := &execution{}
+	cmd.SetContext(context.WithValue(ctx, executionKey{}, e))
+	return e
+}
+
+// commandError returns the first error reported by cmd, or nil
+func commandError(cmd *cobra.Command) error {
+	if e := commandExecution(cmd); e.err != nil {
+		return e.err
+	}
+	return nil
+}
+
 // ErrorOutput represents the structure of CLI error output
 type ErrorOutput struct {
 	Error       string                 `json:"error"`
+	ExitCode    int                    `json:"exit_code"`
 	Code        string                 `json:"code,omitempty"`
 	Operation   string                 `json:"operation,omitempty"`
 	Context     map[string]interface{} `json:"context,omitempty"`
@@ -20,38 +81,115 @@ type ErrorOutput struct {
 	Suggestions []string               `json:"suggestions,omitempty"`
 }
 
-// handleError processes errors and provides structured output based on format preference
+// handleError reports err in the format of --output, as the ErrorOutput document on stderr
+// for JSON and YAML, and fails the command with the exit code of its class. Only the first
+// error of a command is reported; callers return after it.
 func handleError(cmd *cobra.Command, err error, operation string) {
-	if err == nil {
+	run := commandExecution(cmd)
+	if err == nil || run.err != nil {
 		return
 	}
 
-	// Check if JSON output is requested
-	jsonOutput, _ := cmd.Flags().GetBool("json")
 	verbose, _ := cmd.Flags().GetBool("verbose")
 
 	// Extract structured error information
 	errorOutput := buildErrorOutput(err, operation)
 
-	if jsonOutput {
-		// Output as JSON
-		if jsonData, jsonErr := json.MarshalIndent(errorOutput, "", "  "); jsonErr == nil {
-			cmd.PrintErrln(string(jsonData))
+	switch format := outputFormat(cmd); format {
+	case outputJSON, outputYAML:
+		// Output as a document on stderr
+		var buf bytes.Buffer
+		if docErr := writeDocument(&buf, errorOutput, format); docErr == nil {
+			cmd.PrintErr(buf.String())
 		} else {
 			cmd.PrintErrf("Error: %v\n", err)
 		}
-	} else {
+	default:
+		if jsonLogs() {
+			logError(errorOutput)
+			break
+		}
 		// Output as human-readable text
 		printHumanError(cmd, errorOutput, verbose)
 	}
 
-	os.Exit(1)
+	run.err = &ExitError{Code: errorOutput.ExitCode, Output: errorOutput, Err: err}
+}
+
+// exitWith fails the command with code without reporting an error, for outcomes the
+// command has already printed, such as the changes found by diff --exit-code
+func exitWith(cmd *cobra.Command, code int, err error) {
+	if run := commandExecution(cmd); run.err == nil {
+		run.err = &ExitError{Code: code, Err: err}
+	}
+}
+
+// ExitCode returns the exit code of the outcome of a command: ExitOK for nil, the code of
+// an ExitError, or the code of the class of err otherwise
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ExitTimeout
+	}
+
+	switch database.GetErrorCode(err) {
+	case database.ErrCodeConnectionFailed, database.ErrCodeConnectionRefused, database.ErrCodeAuthenticationError,
+		database.ErrCodeInvalidCredentials, database.ErrCodeTooManyConnections:
+		return ExitConnection
+	case database.ErrCodeMigrationFailed, database.ErrCodeMigrationNotFound, database.ErrCodeMigrationConflict:
+		return ExitMigration
+	case database.ErrCodeValidation, database.ErrCodeInvalidConfig, database.ErrCodeMissingConfig:
+		return ExitValidation
+	case database.ErrCodeConnectionTimeout, database.ErrCodeOperationTimeout:
+		return ExitTimeout
+	case database.ErrCodeBackupFailed, database.ErrCodeRestoreFailed, database.ErrCodeInvalidBackupFile:
+		return ExitBackup
+	case database.ErrCodeQueryFailed, database.ErrCodeSyntaxError, database.ErrCodeConstraintViolation,
+		database.ErrCodeTransactionBegin, database.ErrCodeTransactionCommit, database.ErrCodeTransactionRollback,
+		database.ErrCodeTransactionFailed:
+		return ExitQuery
+	default:
+		return ExitFailure
+	}
+}
+
+// ExecuteArgs runs the CLI with args and returns the error of the command, an *ExitError
+// carrying its exit code, without exiting the process. Errors are reported as by
+// handleError, including the usage errors of unknown commands, flags and arguments.
+func ExecuteArgs(ctx context.Context, args []string) error {
+	run := &execution{}
+	DBCmd.SetArgs(args)
+	cmd, err := DBCmd.ExecuteContextC(context.WithValue(ctx, executionKey{}, run))
+	// cobra keeps the context of a command across runs otherwise
+	defer cmd.SetContext(nil)
+	if err != nil && run.err == nil {
+		// Unknown commands fail before the flags are parsed, --output included
+		if !cmd.Flags().Parsed() {
+			_ = cmd.ParseFlags(args)
+		}
+		handleError(cmd, database.NewValidationError(err.Error(), err).
+			WithUserMessage(err.Error()), "parse_args")
+		if textOutput(cmd) && !jsonLogs() {
+			cmd.PrintErrf("Run '%s --help' for usage.\n", cmd.CommandPath())
+		}
+	}
+	if run.err != nil {
+		return run.err
+	}
+	return nil
 }
 
 // buildErrorOutput creates structured error output from an error
 func buildErrorOutput(err error, operation string) ErrorOutput {
 	output := ErrorOutput{
 		Error:     err.Error(),
+		ExitCode:  ExitCode(err),
 		Operation: operation,
 	}
 
@@ -66,6 +204,10 @@ func buildErrorOutput(err error, operation string) ErrorOutput {
 		output.Code = string(database.ErrCodeUnknown)
 		output.UserMessage = err.Error()
 	}
+	// Operations cut short by the command timeout may only need a longer one
+	if errors.Is(err, context.DeadlineExceeded) {
+		output.Suggestions = getSuggestions(database.ErrCodeOperationTimeout)
+	}
 
 	return output
 }
@@ -133,6 +275,11 @@ func getSuggestions(code database.ErrorCode) []string {
 			"Increase connection timeout if the database is slow",
 			"Verify the database is not overloaded",
 		}
+	case database.ErrCodeOperationTimeout:
+		return []string{
+			"Raise the time limit with --timeout or DBKIT_TIMEOUT, or set it to 0 for no limit",
+			"Check for locks blocking the operation",
+		}
 	case database.ErrCodeMigrationFailed:
 		return []string{
 			"Check the migration files for syntax errors",
@@ -186,15 +333,18 @@ func getSuggestions(code database.ErrorCode) []string {
 
 // addErrorFlags adds common error handling flags to commands
 func addErrorFlags(cmd *cobra.Command) {
-	cmd.Flags().Bool("json", false, "Output errors in JSON format")
+	cmd.Flags().Bool("json", false, "Output in JSON format, same as --output json")
 	cmd.Flags().Bool("verbose", false, "Show verbose error information")
 }
 
-// handleSuccess handles successful operation output
+// handleSuccess handles successful operation output in the format of --output: the message
+// for tables, the message and data as a JSON or YAML document, or the data as CSV
 func handleSuccess(cmd *cobra.Command, message string, data map[string]interface{}) {
-	jsonOutput, _ := cmd.Flags().GetBool("json")
-
-	if jsonOutput {
+	if commandExecution(cmd).err != nil {
+		return
+	}
+	switch format := outputFormat(cmd); format {
+	case outputJSON, outputYAML:
 		output := map[string]interface{}{
 			"success": true,
 			"message": message,
@@ -203,12 +353,18 @@ func handleSuccess(cmd *cobra.Command, message string, data map[string]interface
 			output["data"] = data
 		}
 
-		if jsonData, err := json.MarshalIndent(output, "", "  "); err == nil {
-			cmd.Println(string(jsonData))
-		} else {
+		if err := writeDocument(cmd.OutOrStdout(), output, format); err != nil {
 			cmd.Println(message)
 		}
-	} else {
+	case outputCSV:
+		if err := writeCSV(cmd.OutOrStdout(), data); err != nil {
+			handleError(cmd, database.NewDBError(database.ErrCodeInternal, "failed to format the output", err), "output")
+		}
+	default:
+		if jsonLogs() {
+			cliLogger.Info(message)
+			return
+		}
 		cmd.Println(message)
 	}
 }
@@ -2,6 +2,7 @@ package cobra
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"strings"
@@ -229,3 +230,120 @@ func TestAddErrorFlags(t *testing.T) {
 		t.Errorf("Expected verbose flag default to be false")
 	}
 }
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, ExitOK},
+		{errors.New("some error"), ExitFailure},
+		{database.NewConnectionError("connection failed", nil), ExitConnection},
+		{database.NewDBError(database.ErrCodeAuthenticationError, "authentication failed", nil), ExitConnection},
+		{database.NewMigrationError("migration failed", nil), ExitMigration},
+		{database.NewValidationError("invalid flag", nil), ExitValidation},
+		{database.NewConfigError("invalid config", nil), ExitValidation},
+		{database.NewDBError(database.ErrCodeOperationTimeout, "timed out", nil), ExitTimeout},
+		{database.WrapError(context.DeadlineExceeded, database.ErrCodeQueryFailed, "query", "query failed"), ExitTimeout},
+		{database.NewBackupError("backup failed", nil), ExitBackup},
+		{database.NewDBError(database.ErrCodeSyntaxError, "syntax error", nil), ExitQuery},
+		{&ExitError{Code: ExitMigration, Err: errors.New("failed")}, ExitMigration},
+	}
+
+	for _, tt := range tests {
+		if code := ExitCode(tt.err); code != tt.code {
+			t.Errorf("ExitCode(%v) = %d, expected %d", tt.err, code, tt.code)
+		}
+	}
+}
+
+func TestHandleErrorDoesNotExit(t *testing.T) {
+	cmd := &cobra.Command{}
+	addErrorFlags(cmd)
+	cmd.Flags().Set("json", "true")
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+
+	handleError(cmd, database.NewValidationError("invalid flag", nil), "test_operation")
+	handleError(cmd, database.NewConnectionError("connection failed", nil), "test_operation")
+
+	var output ErrorOutput
+	if err := json.Unmarshal(stderr.Bytes(), &output); err != nil {
+		t.Fatalf("Expected a single ErrorOutput document on stderr, got %q: %v", stderr.String(), err)
+	}
+	if output.ExitCode != ExitValidation || output.Code != string(database.ErrCodeValidation) {
+		t.Errorf("Expected the first error with exit code %d, got %+v", ExitValidation, output)
+	}
+	if err := commandError(cmd); ExitCode(err) != ExitValidation {
+		t.Errorf("Expected the command to fail with exit code %d, got %v", ExitValidation, err)
+	}
+
+	// Nothing succeeds after a failure
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	handleSuccess(cmd, "Operation successful", nil)
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no success output after an error, got %q", stdout.String())
+	}
+}
+
+func TestExecuteArgs(t *testing.T) {
+	t.Cleanup(func() {
+		DBCmd.SetArgs(nil)
+		DBCmd.SetOut(nil)
+		DBCmd.SetErr(nil)
+		DBCmd.PersistentFlags().Set("output", outputTable)
+		generateStructsCmd.Flags().Set("from", "")
+	})
+
+	run := func(args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		DBCmd.SetOut(&stdout)
+		DBCmd.SetErr(&stderr)
+		err := ExecuteArgs(context.Background(), args)
+		return stderr.String(), err
+	}
+
+	t.Run("usage error", func(t *testing.T) {
+		stderr, err := run("--output", "json", "introspect", "search")
+		if code := ExitCode(err); code != ExitValidation {
+			t.Errorf("Expected exit code %d, got %d", ExitValidation, code)
+		}
+		var output ErrorOutput
+		if jsonErr := json.Unmarshal([]byte(stderr), &output); jsonErr != nil {
+			t.Fatalf("Expected an ErrorOutput document on stderr, got %q: %v", stderr, jsonErr)
+		}
+		if output.Operation != "parse_args" || output.ExitCode != ExitValidation {
+			t.Errorf("Expected a parse_args error with exit code %d, got %+v", ExitValidation, output)
+		}
+	})
+
+	t.Run("command error", func(t *testing.T) {
+		stderr, err := run("--output", "table", "generate", "structs", t.TempDir(), "--from", "missing.yaml")
+		if code := ExitCode(err); code != ExitValidation {
+			t.Errorf("Expected exit code %d, got %d", ExitValidation, code)
+		}
+		if !strings.Contains(stderr, "Error: ") {
+			t.Errorf("Expected a human-readable error, got %q", stderr)
+		}
+	})
+
+	t.Run("success", func(t *testing.T) {
+		if _, err := run("--output", "table", "generate"); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("errors do not carry over", func(t *testing.T) {
+		args := []string{"--output", "table", "generate", "structs", t.TempDir(), "--from", "missing.yaml"}
+		if _, err := run(args...); ExitCode(err) != ExitValidation {
+			t.Fatalf("Expected exit code %d, got %v", ExitValidation, err)
+		}
+		if _, err := run("--output", "table", "generate"); err != nil {
+			t.Errorf("Expected the next run to succeed, got %v", err)
+		}
+		if _, err := run(args...); ExitCode(err) != ExitValidation {
+			t.Errorf("Expected the same command to fail again, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package cobra
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	execTransaction     = new(bool)
+	execContinueOnError = new(bool)
+	execDryRun          = new(bool)
+	execTimeout         = new(time.Duration)
+)
+
+func init() {
+	DBCmd.AddCommand(execCmd)
+
+	execCmd.Flags().BoolVar(execTransaction, "transaction", false, "Run all statements in one transaction, rolled back if any fails")
+	execCmd.Flags().BoolVar(execContinueOnError, "continue-on-error", false, "Run the remaining statements after a failure")
+	execCmd.Flags().BoolVar(execDryRun, "dry-run", false, "Print the statements that would run without executing them")
+	execCmd.Flags().DurationVar(execTimeout, "timeout", 10*time.Minute, "Cancel the script after this long")
+
+	addErrorFlags(execCmd)
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec <script.sql>",
+	Short: "Execute the statements of a SQL script",
+	Long: `Split a SQL script into statements and execute them in order on one connection.
+Execution stops at the first failed statement, reported with its line number, unless
+--continue-on-error is given. Use - to read the script from stdin:
+
+  db exec scripts/backfill.sql --transaction
+  db exec scripts/backfill.sql --dry-run`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		script, err := readScript(cmd, args[0])
+		if err != nil {
+			handleError(cmd, err, "exec_script")
+			return
+		}
+
+		if *execDryRun {
+			statements := database.SplitStatements(script)
+			if textOutput(cmd) {
+				printStatements(cmd, statements)
+			}
+			handleSuccess(cmd, fmt.Sprintf("%d statements would run", len(statements)), map[string]interface{}{
+				"script":     args[0],
+				"dry_run":    true,
+				"statements": statements,
+			})
+			return
+		}
+
+		if !confirmProduction(cmd, "execute a script") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, *execTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		opts := database.ExecOptions{
+			Transaction:     *execTransaction,
+			ContinueOnError: *execContinueOnError,
+		}
+		result, err := db.ExecScript(ctx, script, opts)
+		if err != nil {
+			handleError(cmd, err, "exec_script")
+			return
+		}
+
+		if textOutput(cmd) {
+			printScriptResult(cmd, result)
+		}
+
+		if failed := result.Failed(); len(failed) > 0 {
+			errs := make([]string, 0, len(failed))
+			for _, statement := range failed {
+				errs = append(errs, fmt.Sprintf("%s:%d: %s", args[0], statement.Line, statement.Error))
+			}
+			message := fmt.Sprintf("%d of %d statements failed", len(failed), len(result.Statements))
+			if result.RolledBack {
+				message += ", transaction rolled back"
+			}
+			handleError(cmd, database.NewDBError(database.ErrCodeQueryFailed, message, nil).
+				WithContext("errors", errs).
+				WithContext("script", args[0]), "exec_script")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("%d statements executed", len(result.Statements)), map[string]interface{}{
+			"script":      args[0],
+			"transaction": opts.Transaction,
+			"statements":  result.Statements,
+		})
+	},
+}
+
+// readScript reads the script at path, or stdin for -
+func readScript(cmd *cobra.Command, path string) (string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", database.NewValidationError("failed to read the script", err).
+			WithContext("script", path)
+	}
+	return string(data), nil
+}
+
+// printStatements prints the statements of a dry run, each after a comment with its line
+func printStatements(cmd *cobra.Command, statements []database.Statement) {
+	for _, s := range statements {
+		cmd.Printf("-- line %d: %s\n%s;\n\n", s.Line, s.Type, s.SQL)
+	}
+}
+
+// printScriptResult prints the outcome of each statement
+func printScriptResult(cmd *cobra.Command, result *database.ScriptResult) {
+	for _, s := range result.Statements {
+		switch {
+		case !s.Executed:
+			cmd.Printf("%5d  %-20s  skipped\n", s.Line, s.Type)
+		case s.Error != "":
+			cmd.Printf("%5d  %-20s  failed: %s\n", s.Line, s.Type, s.Error)
+		case s.RowsAffected > 0:
+			cmd.Printf("%5d  %-20s  %d rows in %s\n", s.Line, s.Type, s.RowsAffected, s.Duration.Round(time.Millisecond))
+		default:
+			cmd.Printf("%5d  %-20s  ok in %s\n", s.Line, s.Type, s.Duration.Round(time.Millisecond))
+		}
+	}
+}
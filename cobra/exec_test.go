@@ -0,0 +1,79 @@
+package cobra
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecCommand(t *testing.T) {
+	assert.Equal(t, "exec <script.sql>", execCmd.Use)
+	assert.Equal(t, "false", execCmd.Flags().Lookup("transaction").DefValue)
+	assert.Equal(t, "false", execCmd.Flags().Lookup("dry-run").DefValue)
+
+	assert.NoError(t, execCmd.Args(execCmd, []string{"script.sql"}))
+	assert.Error(t, execCmd.Args(execCmd, []string{}))
+}
+
+func TestReadScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.sql")
+	require.NoError(t, os.WriteFile(path, []byte("SELECT 1;\n"), 0o600))
+
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader("SELECT 2;\n"))
+
+	script, err := readScript(cmd, path)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;\n", script)
+
+	script, err = readScript(cmd, "-")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 2;\n", script)
+
+	_, err = readScript(cmd, filepath.Join(t.TempDir(), "missing.sql"))
+	require.Error(t, err)
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+}
+
+func TestPrintStatements(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printStatements(cmd, database.SplitStatements("CREATE TABLE t (id int);\n\nINSERT INTO t\nVALUES (1);\n"))
+	assert.Equal(t, `-- line 1: CREATE TABLE
+CREATE TABLE t (id int);
+
+-- line 3: INSERT
+INSERT INTO t
+VALUES (1);
+
+`, out.String())
+}
+
+func TestPrintScriptResult(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printScriptResult(cmd, &database.ScriptResult{Statements: []database.StatementResult{
+		{Statement: database.Statement{Line: 1, Type: "CREATE TABLE"}, Executed: true, Duration: 3 * time.Millisecond},
+		{Statement: database.Statement{Line: 2, Type: "INSERT"}, Executed: true, RowsAffected: 2, Duration: time.Millisecond},
+		{Statement: database.Statement{Line: 4, Type: "UPDATE"}, Executed: true, Error: "pq: division by zero"},
+		{Statement: database.Statement{Line: 6, Type: "DELETE"}},
+	}})
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "    1  CREATE TABLE          ok in 3ms", lines[0])
+	assert.Equal(t, "    2  INSERT                2 rows in 1ms", lines[1])
+	assert.Equal(t, "    4  UPDATE                failed: pq: division by zero", lines[2])
+	assert.Equal(t, "    6  DELETE                skipped", lines[3])
+}
@@ -0,0 +1,204 @@
+package cobra
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	listAvailableExtensions = new(bool)
+	installExtVersion       = new(string)
+	installExtSchema        = new(string)
+	installExtCascade       = new(bool)
+	installExtUpdate        = new(bool)
+)
+
+// Outcomes of installing an extension
+const (
+	extensionInstalled = "installed"
+	extensionUpdated   = "updated"
+	extensionUnchanged = "unchanged"
+)
+
+// extensionStatus is the outcome of installing one extension
+type extensionStatus struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Schema  string `json:"schema"`
+	Status  string `json:"status"`
+}
+
+func init() {
+	DBCmd.AddCommand(extensionCmd)
+	extensionCmd.AddCommand(listExtensionsCmd)
+	extensionCmd.AddCommand(installExtensionCmd)
+
+	listExtensionsCmd.Flags().BoolVar(listAvailableExtensions, "available", false, "List the extensions the server can install instead of the installed ones")
+
+	installExtensionCmd.Flags().StringVar(installExtVersion, "version", "", "Version to install or update to, the extension's default by default; only with one extension")
+	installExtensionCmd.Flags().StringVar(installExtSchema, "schema", "", "Schema to install the extension's objects into, the current schema by default")
+	installExtensionCmd.Flags().BoolVar(installExtCascade, "cascade", false, "Also install the extensions it depends on")
+	installExtensionCmd.Flags().BoolVar(installExtUpdate, "update", false, "Update installed extensions to --version or to their default version")
+
+	addErrorFlags(extensionCmd)
+	addErrorFlags(listExtensionsCmd)
+	addErrorFlags(installExtensionCmd)
+}
+
+var extensionCmd = &cobra.Command{
+	Use:   "extensions",
+	Short: "List and install PostgreSQL extensions",
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			handleError(cmd, err, "extensions")
+		}
+	},
+}
+
+var listExtensionsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the installed extensions, or those the server can install",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		if *listAvailableExtensions {
+			available, err := db.Introspection().GetAvailableExtensions(ctx)
+			if err != nil {
+				handleError(cmd, err, "get_available_extensions")
+				return
+			}
+			if textOutput(cmd) {
+				printRows(cmd, available, "name", "default_version", "installed_version", "comment")
+			}
+			handleSuccess(cmd, fmt.Sprintf("%d extensions available", len(available)), map[string]interface{}{
+				"extensions": available,
+			})
+			return
+		}
+
+		extensions, err := db.Introspection().GetExtensions(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_extensions")
+			return
+		}
+		if textOutput(cmd) {
+			printRows(cmd, extensions, "name", "version", "schema", "update_available")
+		}
+		handleSuccess(cmd, fmt.Sprintf("%d extensions installed", len(extensions)), map[string]interface{}{
+			"extensions": extensions,
+		})
+	},
+}
+
+var installExtensionCmd = &cobra.Command{
+	Use:   "install <name>...",
+	Short: "Install extensions unless they are installed",
+	Long: `Install the extensions with CREATE EXTENSION IF NOT EXISTS, so setup scripts can run it
+on every deploy. Installed extensions are left alone unless --update is given:
+
+  db extensions install pgcrypto pg_trgm
+  db extensions install postgis --version 3.4.2 --schema extensions --update`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if *installExtVersion != "" && len(args) > 1 {
+			handleError(cmd, database.NewValidationError("--version applies to one extension at a time", nil), "create_extension")
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 5*time.Minute)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		installed, err := installedExtensions(ctx, db)
+		if err != nil {
+			handleError(cmd, err, "get_extensions")
+			return
+		}
+
+		statuses := make([]extensionStatus, 0, len(args))
+		for _, name := range args {
+			status := extensionStatus{Name: name, Status: extensionUnchanged}
+			extension, ok := installed[name]
+			switch {
+			case !ok:
+				err = db.CreateExtension(ctx, name, database.ExtensionOptions{
+					Schema:  *installExtSchema,
+					Version: *installExtVersion,
+					Cascade: *installExtCascade,
+				})
+				status.Status = extensionInstalled
+			case *installExtUpdate && needsUpdate(extension, *installExtVersion):
+				err = db.UpdateExtension(ctx, name, *installExtVersion)
+				status.Status = extensionUpdated
+			}
+			if err != nil {
+				handleError(cmd, err, "create_extension")
+				return
+			}
+			statuses = append(statuses, status)
+		}
+
+		// Report the versions the server settled on
+		if installed, err = installedExtensions(ctx, db); err != nil {
+			handleError(cmd, err, "get_extensions")
+			return
+		}
+		changed := 0
+		for i := range statuses {
+			statuses[i].Version = installed[statuses[i].Name].Version
+			statuses[i].Schema = installed[statuses[i].Name].Schema
+			if statuses[i].Status != extensionUnchanged {
+				changed++
+			}
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, statuses, "name", "version", "schema", "status")
+		}
+		handleSuccess(cmd, fmt.Sprintf("%d of %d extensions changed", changed, len(statuses)), map[string]interface{}{
+			"extensions": statuses,
+		})
+	},
+}
+
+// installedExtensions returns the installed extensions by name
+func installedExtensions(ctx context.Context, db *database.DB) (map[string]database.ExtensionInfo, error) {
+	extensions, err := db.Introspection().GetExtensions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	installed := make(map[string]database.ExtensionInfo, len(extensions))
+	for _, extension := range extensions {
+		installed[extension.Name] = extension
+	}
+	return installed, nil
+}
+
+// needsUpdate reports whether the installed extension differs from version, or from its
+// default version if empty
+func needsUpdate(extension database.ExtensionInfo, version string) bool {
+	if version != "" {
+		return extension.Version != version
+	}
+	return extension.UpdateAvailable
+}
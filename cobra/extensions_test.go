@@ -0,0 +1,33 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/b87/db-kit/database"
+)
+
+func TestExtensionCommands(t *testing.T) {
+	assert.Equal(t, "extensions", extensionCmd.Use)
+	assert.Equal(t, extensionCmd, listExtensionsCmd.Parent())
+	assert.Equal(t, extensionCmd, installExtensionCmd.Parent())
+
+	assert.NotNil(t, listExtensionsCmd.Flags().Lookup("available"))
+	for _, name := range []string{"version", "schema", "cascade", "update", "json"} {
+		assert.NotNil(t, installExtensionCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+
+	assert.Error(t, installExtensionCmd.Args(installExtensionCmd, []string{}))
+	assert.NoError(t, installExtensionCmd.Args(installExtensionCmd, []string{"pgcrypto", "pg_trgm"}))
+}
+
+func TestNeedsUpdate(t *testing.T) {
+	extension := database.ExtensionInfo{Name: "pg_trgm", Version: "1.5"}
+	assert.False(t, needsUpdate(extension, ""))
+	assert.False(t, needsUpdate(extension, "1.5"))
+	assert.True(t, needsUpdate(extension, "1.6"))
+
+	extension.UpdateAvailable = true
+	assert.True(t, needsUpdate(extension, ""))
+}
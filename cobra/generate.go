@@ -0,0 +1,166 @@
+package cobra
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	generateFrom         = new(string)
+	generateSchema       = new(string)
+	generatePackage      = new(string)
+	generateFile         = new(string)
+	generateNullPointers = new(bool)
+	generateCheck        = new(bool)
+)
+
+func init() {
+	DBCmd.AddCommand(generateCmd)
+	generateCmd.AddCommand(generateStructsCmd)
+
+	generateStructsCmd.Flags().StringVar(generateFrom, "from", "", "Schema document written by introspect export to generate from, instead of the live database")
+	generateStructsCmd.Flags().StringVar(generateSchema, "schema", "", "Only generate the tables and enums of this schema")
+	generateStructsCmd.Flags().StringVar(generatePackage, "package", "", "Package name of the generated file, the name of the directory by default")
+	generateStructsCmd.Flags().StringVar(generateFile, "file", "models_gen.go", "Name of the generated file in the directory")
+	generateStructsCmd.Flags().BoolVar(generateNullPointers, "null-pointers", false, "Type nullable columns as pointers instead of sql.Null types")
+	generateStructsCmd.Flags().BoolVar(generateCheck, "check", false, "Fail if the generated file is out of date instead of writing it")
+
+	addErrorFlags(generateCmd)
+	addErrorFlags(generateStructsCmd)
+}
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate code from the database schema",
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			handleError(cmd, err, "help")
+		}
+	},
+}
+
+var generateStructsCmd = &cobra.Command{
+	Use:   "structs <dir>",
+	Short: "Generate Go structs for the tables and enums of the schema",
+	Long: `Generate a Go file declaring a struct with db and json tags for each table, a constant
+with the name of each table, and a string type with a constant per label for each enum.
+Nullable columns use the sql.Null types, or pointers with --null-pointers.
+
+The schema is introspected from the database, or read with --from from a document written
+by introspect export. Regenerate after each migration, and use --check in CI to fail when
+the models are out of date:
+
+  db generate structs internal/models
+  db generate structs internal/models --from schema.yaml --check`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := args[0]
+		path := filepath.Join(dir, *generateFile)
+		pkg := *generatePackage
+		if pkg == "" {
+			pkg = packageName(dir)
+		}
+
+		doc, err := loadSchemaDocument(cmd, *generateFrom, *generateSchema)
+		if err != nil {
+			handleError(cmd, err, "generate_structs")
+			return
+		}
+
+		src, err := database.GenerateStructs(doc, database.GenerateStructsOptions{
+			Package:      pkg,
+			NullPointers: *generateNullPointers,
+		})
+		if err != nil {
+			handleError(cmd, err, "generate_structs")
+			return
+		}
+
+		data := map[string]interface{}{
+			"path":    path,
+			"package": pkg,
+			"tables":  len(doc.Tables),
+			"enums":   len(doc.Enums),
+		}
+		if *generateCheck {
+			current, err := os.ReadFile(path)
+			if err != nil || !bytes.Equal(current, src) {
+				handleError(cmd, database.NewValidationError(fmt.Sprintf("%s is out of date, regenerate it without --check", path), err).
+					WithContext("path", path), "generate_structs")
+				return
+			}
+			handleSuccess(cmd, fmt.Sprintf("%s is up to date", path), data)
+			return
+		}
+
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			handleError(cmd, database.NewValidationError("failed to create the package directory", err).
+				WithContext("dir", dir), "generate_structs")
+			return
+		}
+		if err := os.WriteFile(path, src, 0o644); err != nil {
+			handleError(cmd, database.NewValidationError("failed to write the generated file", err).
+				WithContext("path", path), "generate_structs")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("Generated %d structs and %d enums in %s", len(doc.Tables), len(doc.Enums), path), data)
+	},
+}
+
+// loadSchemaDocument reads the schema document at path, or exports the schema of the
+// database if path is empty, limited to schema if set
+func loadSchemaDocument(cmd *cobra.Command, path, schema string) (*database.SchemaDocument, error) {
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, database.NewValidationError("failed to open schema document", err).
+				WithContext("path", path)
+		}
+		defer file.Close()
+		doc, err := database.DecodeSchemaDocument(file)
+		if err != nil {
+			return nil, err
+		}
+		if schema != "" {
+			doc = doc.FilterSchema(schema)
+		}
+		return doc, nil
+	}
+
+	ctx, cancel := commandContext(cmd, 5*time.Minute)
+	defer cancel()
+
+	db, err := newDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return db.Introspection().ExportSchema(ctx, database.ExportOptions{Schema: schema})
+}
+
+// packageName derives a package name from the last element of dir, e.g. models from
+// internal/models
+func packageName(dir string) string {
+	base := filepath.Base(filepath.Clean(dir))
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, base)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return "models"
+	}
+	return name
+}
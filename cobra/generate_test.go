@@ -0,0 +1,45 @@
+package cobra
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/b87/db-kit/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateStructsCommand(t *testing.T) {
+	assert.Equal(t, "structs <dir>", generateStructsCmd.Use)
+	assert.NoError(t, generateStructsCmd.Args(generateStructsCmd, []string{"models"}))
+	assert.Error(t, generateStructsCmd.Args(generateStructsCmd, []string{}))
+	assert.Equal(t, "models_gen.go", generateStructsCmd.Flags().Lookup("file").DefValue)
+}
+
+func TestPackageName(t *testing.T) {
+	assert.Equal(t, "models", packageName("internal/models"))
+	assert.Equal(t, "dbmodels", packageName("internal/db-Models/"))
+	assert.Equal(t, "models", packageName("."))
+	assert.Equal(t, "models", packageName("2024"))
+}
+
+func TestLoadSchemaDocument(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.yaml")
+	doc := &database.SchemaDocument{
+		Version: database.SchemaDocumentVersion,
+		Tables:  []database.TableInfo{{Schema: "public", Name: "users"}, {Schema: "billing", Name: "invoices"}},
+	}
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, doc.Encode(file, database.SchemaFormatYAML))
+	require.NoError(t, file.Close())
+
+	loaded, err := loadSchemaDocument(generateStructsCmd, path, "billing")
+	require.NoError(t, err)
+	require.Len(t, loaded.Tables, 1)
+	assert.Equal(t, "invoices", loaded.Tables[0].Name)
+
+	_, err = loadSchemaDocument(generateStructsCmd, filepath.Join(t.TempDir(), "missing.yaml"), "")
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+}
@@ -0,0 +1,241 @@
+package cobra
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	initName          = new(string)
+	initDockerCompose = new(bool)
+	initForce         = new(bool)
+)
+
+// initDatabaseName matches the database names init accepts
+var initDatabaseName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+func init() {
+	DBCmd.AddCommand(initCmd)
+
+	initCmd.Flags().StringVar(initName, "name", "", "Database name of the project (default the directory name)")
+	initCmd.Flags().BoolVar(initDockerCompose, "docker-compose", false, "Also write a docker-compose.yml running PostgreSQL for local development")
+	initCmd.Flags().BoolVar(initForce, "force", false, "Overwrite files that already exist")
+
+	addErrorFlags(initCmd)
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init [dir]",
+	Short: "Set up a project to use db-kit",
+	Long: `Create the db-kit layout of a service in dir, the current directory by default, without
+connecting to a database:
+
+  migrations/                 with a baseline migration, unless it has migrations already
+  seeds/
+  .env, .test.env             connection settings for development and tests
+  .dbkit.yaml                 dev and test connection profiles, read from the working directory
+  docker-compose.yml          PostgreSQL for local development, with --docker-compose
+
+Files that exist are left alone unless --force is given.
+
+  db init --docker-compose
+  db init services/billing --name billing`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+
+		name := *initName
+		if name == "" {
+			name = projectDatabaseName(dir)
+		}
+		if !initDatabaseName.MatchString(name) {
+			handleError(cmd, database.NewValidationError(fmt.Sprintf("invalid database name %q, use lowercase letters, digits and '_'", name), nil).
+				WithContext("name", name), "init")
+			return
+		}
+
+		created, skipped, err := scaffoldProject(dir, name, *initDockerCompose, *initForce, time.Now())
+		if err != nil {
+			handleError(cmd, err, "init")
+			return
+		}
+
+		if textOutput(cmd) && !jsonLogs() {
+			for _, path := range created {
+				cmd.Printf("created  %s\n", path)
+			}
+			for _, path := range skipped {
+				cmd.Printf("exists   %s\n", path)
+			}
+		}
+		handleSuccess(cmd, fmt.Sprintf("Project %s set up in %s; start with db migrate status", name, dir), map[string]interface{}{
+			"name":    name,
+			"dir":     dir,
+			"created": created,
+			"skipped": skipped,
+		})
+	},
+}
+
+// projectDatabaseName derives a database name from the name of dir, e.g. billing_api from
+// billing-api
+func projectDatabaseName(dir string) string {
+	base := filepath.Base(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		base = filepath.Base(abs)
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '_'
+		}
+	}, base)
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "db_" + name
+	}
+	return name
+}
+
+// scaffoldProject writes the project files of init into dir, returning the files created
+// and those left alone because they exist
+func scaffoldProject(dir, name string, dockerCompose, force bool, now time.Time) (created, skipped []string, err error) {
+	created, skipped = []string{}, []string{}
+	for _, sub := range []string{"migrations", "seeds"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return created, skipped, database.NewConfigError("failed to create the project directories", err).
+				WithContext("dir", filepath.Join(dir, sub))
+		}
+	}
+
+	files := map[string]string{
+		".env":         projectEnv(name),
+		".test.env":    projectEnv(name + "_test"),
+		configFileName: fmt.Sprintf(initConfigTemplate, name, name+"_test"),
+	}
+	order := []string{".env", ".test.env", configFileName}
+	if dockerCompose {
+		files["docker-compose.yml"] = fmt.Sprintf(initComposeTemplate, name)
+		order = append(order, "docker-compose.yml")
+	}
+
+	// A baseline only starts projects without migrations
+	migrations, err := os.ReadDir(filepath.Join(dir, "migrations"))
+	if err != nil {
+		return created, skipped, database.NewConfigError("failed to read the migrations directory", err).
+			WithContext("dir", filepath.Join(dir, "migrations"))
+	}
+	if len(migrations) == 0 {
+		baseline := filepath.Join("migrations", now.UTC().Format("20060102150405")+"_baseline.sql")
+		files[baseline] = initBaselineTemplate
+		order = append(order, baseline)
+	}
+
+	for _, file := range order {
+		path := filepath.Join(dir, file)
+		if _, err := os.Stat(path); err == nil && !force {
+			skipped = append(skipped, path)
+			continue
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return created, skipped, database.NewConfigError("failed to check the project files", err).
+				WithContext("path", path)
+		}
+		if err := os.WriteFile(path, []byte(files[file]), 0o644); err != nil {
+			return created, skipped, database.NewConfigError("failed to write the project files", err).
+				WithContext("path", path)
+		}
+		created = append(created, path)
+	}
+	return created, skipped, nil
+}
+
+// projectEnv returns the .env file of a project using database name
+func projectEnv(name string) string {
+	return fmt.Sprintf(initEnvTemplate, name)
+}
+
+const initEnvTemplate = `# db-kit connection settings, read by the CLI from the environment
+POSTGRES_HOST=localhost
+POSTGRES_PORT=5432
+POSTGRES_USER=postgres
+POSTGRES_PASSWORD=postgres
+POSTGRES_DB=%s
+POSTGRES_SSL_MODE=disable
+
+MIGRATIONS_DIR=./migrations
+SEEDS_DIR=./seeds
+BACKUPS_DIR=./backups
+`
+
+const initConfigTemplate = `# db-kit connection profiles: select one with --profile or DBKIT_PROFILE. Add the
+# deployed environments with their dsn, and production: true to make destructive
+# commands ask for the profile name.
+default_profile: dev
+profiles:
+  dev:
+    host: localhost
+    port: 5432
+    user: postgres
+    password: postgres
+    database: %s
+    sslmode: disable
+    migrations: ./migrations
+    seeds: ./seeds
+    backups: ./backups
+  test:
+    host: localhost
+    port: 5432
+    user: postgres
+    password: postgres
+    database: %s
+    sslmode: disable
+    migrations: ./migrations
+    seeds: ./seeds
+    backups: ./backups
+`
+
+const initComposeTemplate = `# PostgreSQL for local development: docker compose up -d
+services:
+  postgres:
+    image: postgres:17
+    environment:
+      POSTGRES_USER: postgres
+      POSTGRES_PASSWORD: postgres
+      POSTGRES_DB: %s
+    ports:
+      - "5432:5432"
+    volumes:
+      - postgres-data:/var/lib/postgresql/data
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -U postgres"]
+      interval: 5s
+      timeout: 5s
+      retries: 10
+
+volumes:
+  postgres-data:
+`
+
+const initBaselineTemplate = `-- +goose Up
+-- Baseline of the schema. New services can leave it empty and add tables with
+-- db migrate create. For an existing database, put its schema here, e.g. from
+-- pg_dump --schema-only, with IF NOT EXISTS guards so it also applies there.
+
+-- +goose Down
+`
@@ -0,0 +1,59 @@
+package cobra
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectDatabaseName(t *testing.T) {
+	assert.Equal(t, "billing_api", projectDatabaseName("services/Billing-API"))
+	assert.Equal(t, "db_42go", projectDatabaseName("/src/42go"))
+}
+
+func TestScaffoldProject(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	created, skipped, err := scaffoldProject(dir, "billing", true, false, now)
+	require.NoError(t, err)
+	assert.Empty(t, skipped)
+	assert.Len(t, created, 5)
+	assert.DirExists(t, filepath.Join(dir, "seeds"))
+	assert.FileExists(t, filepath.Join(dir, "migrations", "20250102150405_baseline.sql"))
+
+	env, err := os.ReadFile(filepath.Join(dir, ".test.env"))
+	require.NoError(t, err)
+	assert.Contains(t, string(env), "POSTGRES_DB=billing_test\n")
+	compose, err := os.ReadFile(filepath.Join(dir, "docker-compose.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(compose), "POSTGRES_DB: billing\n")
+
+	// The config file holds profiles the CLI reads
+	config, err := loadCLIConfig(filepath.Join(dir, configFileName), true)
+	require.NoError(t, err)
+	assert.Equal(t, "dev", config.DefaultProfile)
+	assert.Equal(t, "billing", config.Profiles["dev"].Database)
+	assert.Equal(t, "billing_test", config.Profiles["test"].Database)
+
+	// Existing files are kept, and projects with migrations get no baseline
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("POSTGRES_DB=custom\n"), 0o644))
+	created, skipped, err = scaffoldProject(dir, "billing", false, false, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, created)
+	assert.Len(t, skipped, 3)
+	env, err = os.ReadFile(filepath.Join(dir, ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, "POSTGRES_DB=custom\n", string(env))
+
+	created, _, err = scaffoldProject(dir, "billing", false, true, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, created, 3)
+	migrations, err := os.ReadDir(filepath.Join(dir, "migrations"))
+	require.NoError(t, err)
+	assert.Len(t, migrations, 1)
+}
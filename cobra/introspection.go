@@ -2,8 +2,10 @@ package cobra
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -11,6 +13,22 @@ import (
 	"github.com/b87/db-kit/database"
 )
 
+var (
+	sizesLimit        = new(int)
+	introspectWorkers = new(int)
+	exportFormat      = new(string)
+	exportOutput      = new(string)
+	driftSave         = new(bool)
+	bloatLimit        = new(int)
+	searchSchema      = new(string)
+	searchKinds       = new([]string)
+	searchLimit       = new(int)
+	introspectWide    = new(bool)
+	tablesColumns     = new([]string)
+	columnColumns     = new([]string)
+	indexColumns      = new([]string)
+)
+
 func init() {
 	DBCmd.AddCommand(introspectionCmd)
 	introspectionCmd.AddCommand(schemaCmd)
@@ -19,9 +37,36 @@ func init() {
 	introspectionCmd.AddCommand(columnsCmd)
 	introspectionCmd.AddCommand(indexesCmd)
 	introspectionCmd.AddCommand(constraintsCmd)
+	introspectionCmd.AddCommand(triggersCmd)
+	introspectionCmd.AddCommand(partitionsCmd)
 	introspectionCmd.AddCommand(relationshipsCmd)
+	introspectionCmd.AddCommand(enumsCmd)
+	introspectionCmd.AddCommand(functionsCmd)
+	introspectionCmd.AddCommand(extensionsCmd)
+	introspectionCmd.AddCommand(rolesCmd)
+	introspectionCmd.AddCommand(grantsCmd)
 	introspectionCmd.AddCommand(versionCmd)
 	introspectionCmd.AddCommand(sizeCmd)
+	introspectionCmd.AddCommand(sizesCmd)
+	introspectionCmd.AddCommand(unusedIndexesCmd)
+	introspectionCmd.AddCommand(bloatCmd)
+	introspectionCmd.AddCommand(exportSchemaCmd)
+	introspectionCmd.AddCommand(driftCmd)
+	introspectionCmd.AddCommand(searchCmd)
+
+	introspectionCmd.PersistentFlags().IntVar(introspectWorkers, "workers", 4, "Number of introspection queries to run concurrently")
+	introspectionCmd.PersistentFlags().BoolVar(introspectWide, "wide", false, "Show long values in full instead of truncating them in table output")
+	tablesCmd.Flags().StringSliceVar(tablesColumns, "columns", []string{"schema", "name", "type"}, "Fields to show in table output, e.g. name,total_bytes,estimated_rows; see --output json for all")
+	columnsCmd.Flags().StringSliceVar(columnColumns, "columns", []string{"name", "data_type", "is_nullable", "default_value", "is_primary_key"}, "Fields to show in table output, e.g. name,data_type,comment; see --output json for all")
+	indexesCmd.Flags().StringSliceVar(indexColumns, "columns", []string{"name", "index_type", "columns", "is_unique", "is_primary"}, "Fields to show in table output, e.g. name,columns; see --output json for all")
+	exportSchemaCmd.Flags().StringVar(exportFormat, "format", database.SchemaFormatJSON, "Document format: json or yaml")
+	exportSchemaCmd.Flags().StringVarP(exportOutput, "output", "o", "", "Write the document to a file instead of stdout")
+	driftCmd.Flags().BoolVar(driftSave, "save", false, "Save a new snapshot of the live schema instead of checking for drift")
+	sizesCmd.Flags().IntVar(sizesLimit, "limit", 0, "Show only the N largest tables (0 for all)")
+	bloatCmd.Flags().IntVar(bloatLimit, "limit", 0, "Show only the N most bloated relations (0 for all)")
+	searchCmd.Flags().StringVar(searchSchema, "schema", "", "Only search this schema")
+	searchCmd.Flags().StringSliceVar(searchKinds, "kind", nil, "Only find objects of these kinds: table, view, materialized view, foreign table, column, index, constraint, function or procedure")
+	searchCmd.Flags().IntVar(searchLimit, "limit", 200, "Show only the first N matches (0 for all)")
 
 	// Add error handling flags to all introspection commands
 	addErrorFlags(introspectionCmd)
@@ -31,19 +76,30 @@ func init() {
 	addErrorFlags(columnsCmd)
 	addErrorFlags(indexesCmd)
 	addErrorFlags(constraintsCmd)
+	addErrorFlags(triggersCmd)
+	addErrorFlags(partitionsCmd)
 	addErrorFlags(relationshipsCmd)
+	addErrorFlags(enumsCmd)
+	addErrorFlags(functionsCmd)
+	addErrorFlags(extensionsCmd)
+	addErrorFlags(rolesCmd)
+	addErrorFlags(grantsCmd)
 	addErrorFlags(versionCmd)
 	addErrorFlags(sizeCmd)
+	addErrorFlags(sizesCmd)
+	addErrorFlags(unusedIndexesCmd)
+	addErrorFlags(bloatCmd)
+	addErrorFlags(exportSchemaCmd)
+	addErrorFlags(driftCmd)
+	addErrorFlags(searchCmd)
 }
 
 var introspectionCmd = &cobra.Command{
 	Use:   "introspect",
 	Short: "Introspect database schema and metadata",
 	Run: func(cmd *cobra.Command, args []string) {
-		err := cmd.Help()
-		if err != nil {
-			cmd.PrintErrln(err)
-			os.Exit(1)
+		if err := cmd.Help(); err != nil {
+			handleError(cmd, err, "help")
 		}
 	},
 }
@@ -53,7 +109,7 @@ var schemaCmd = &cobra.Command{
 	Short: "Show database schema information",
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := commandContext(cmd, 30*time.Second)
 		defer cancel()
 
 		db, err := newDB()
@@ -63,7 +119,7 @@ var schemaCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		introspection := db.Introspection()
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
 
 		var schema string
 		if len(args) > 0 {
@@ -77,6 +133,11 @@ var schemaCmd = &cobra.Command{
 				handleError(cmd, err, "get_schemas")
 				return
 			}
+			if textOutput(cmd) {
+				for _, schema := range schemas {
+					fmt.Fprintln(cmd.OutOrStdout(), schema)
+				}
+			}
 			handleSuccess(cmd, "Schemas retrieved successfully", map[string]interface{}{
 				"schemas": schemas,
 			})
@@ -87,6 +148,9 @@ var schemaCmd = &cobra.Command{
 				handleError(cmd, err, "get_schema_tables")
 				return
 			}
+			if textOutput(cmd) {
+				printRows(cmd, tables, "schema", "name", "type")
+			}
 			handleSuccess(cmd, fmt.Sprintf("Schema '%s' tables retrieved successfully", schema), map[string]interface{}{
 				"schema": schema,
 				"tables": tables,
@@ -98,9 +162,19 @@ var schemaCmd = &cobra.Command{
 var tablesCmd = &cobra.Command{
 	Use:   "tables [schema_name]",
 	Short: "List all tables in the database",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `List the tables and views of a schema, or of all schemas. Choose the fields of the table
+output with --columns; the size fields total_bytes, table_bytes, index_bytes and toast_bytes
+show with a unit, and estimated_rows is the planner's estimate:
+
+  db introspect tables public --columns name,total_bytes,estimated_rows`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := validateColumns([]tableListing{}, *tablesColumns); err != nil {
+			handleError(cmd, err, "get_tables")
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 30*time.Second)
 		defer cancel()
 
 		db, err := newDB()
@@ -110,7 +184,7 @@ var tablesCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		introspection := db.Introspection()
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
 
 		var schema string
 		if len(args) > 0 {
@@ -123,6 +197,15 @@ var tablesCmd = &cobra.Command{
 			return
 		}
 
+		if textOutput(cmd) {
+			listing, err := listTables(ctx, introspection, schema, tables, *tablesColumns)
+			if err != nil {
+				handleError(cmd, err, "get_table_sizes")
+				return
+			}
+			printRows(cmd, listing, *tablesColumns...)
+		}
+
 		handleSuccess(cmd, "Tables retrieved successfully", map[string]interface{}{
 			"schema": schema,
 			"tables": tables,
@@ -130,12 +213,57 @@ var tablesCmd = &cobra.Command{
 	},
 }
 
+// tableListing is a row of introspect tables, with the sizes of the table if --columns
+// shows any; views have none
+type tableListing struct {
+	database.TableInfo
+	TotalBytes    *int64 `json:"total_bytes,omitempty"`
+	TableBytes    *int64 `json:"table_bytes,omitempty"`
+	IndexBytes    *int64 `json:"index_bytes,omitempty"`
+	ToastBytes    *int64 `json:"toast_bytes,omitempty"`
+	EstimatedRows *int64 `json:"estimated_rows,omitempty"`
+}
+
+// tableSizeColumns are the columns of introspect tables read with GetTableSizes
+var tableSizeColumns = []string{"total_bytes", "table_bytes", "index_bytes", "toast_bytes", "estimated_rows"}
+
+// listTables returns the rows of introspect tables, querying the table sizes only if
+// columns shows them
+func listTables(ctx context.Context, introspection *database.IntrospectionService, schema string, tables []database.TableInfo, columns []string) ([]tableListing, error) {
+	listing := make([]tableListing, len(tables))
+	for i, table := range tables {
+		listing[i].TableInfo = table
+	}
+	if !slices.ContainsFunc(columns, func(column string) bool { return slices.Contains(tableSizeColumns, column) }) {
+		return listing, nil
+	}
+
+	sizes, err := introspection.GetTableSizes(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	bySchemaName := make(map[string]database.TableSize, len(sizes))
+	for _, size := range sizes {
+		bySchemaName[size.Schema+"."+size.Name] = size
+	}
+	for i := range listing {
+		if size, ok := bySchemaName[listing[i].Schema+"."+listing[i].Name]; ok {
+			listing[i].TotalBytes = &size.TotalBytes
+			listing[i].TableBytes = &size.TableBytes
+			listing[i].IndexBytes = &size.IndexBytes
+			listing[i].ToastBytes = &size.ToastBytes
+			listing[i].EstimatedRows = &size.EstimatedRows
+		}
+	}
+	return listing, nil
+}
+
 var tableCmd = &cobra.Command{
 	Use:   "table [schema_name] [table_name]",
 	Short: "Show detailed information about a specific table",
 	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := commandContext(cmd, 30*time.Second)
 		defer cancel()
 
 		db, err := newDB()
@@ -145,7 +273,7 @@ var tableCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		introspection := db.Introspection()
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
 
 		schema := args[0]
 		tableName := args[1]
@@ -183,6 +311,10 @@ var tableCmd = &cobra.Command{
 			return
 		}
 
+		if textOutput(cmd) {
+			printRows(cmd, tableInfo.Columns, "name", "data_type", "is_nullable", "default_value", "is_primary_key")
+		}
+
 		handleSuccess(cmd, fmt.Sprintf("Table '%s.%s' information retrieved successfully", schema, tableName), map[string]interface{}{
 			"table": tableInfo,
 		})
@@ -194,7 +326,12 @@ var columnsCmd = &cobra.Command{
 	Short: "Show columns for a specific table",
 	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := validateColumns([]database.ColumnInfo{}, *columnColumns); err != nil {
+			handleError(cmd, err, "get_table_columns")
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 30*time.Second)
 		defer cancel()
 
 		db, err := newDB()
@@ -204,7 +341,7 @@ var columnsCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		introspection := db.Introspection()
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
 
 		schema := args[0]
 		tableName := args[1]
@@ -228,6 +365,10 @@ var columnsCmd = &cobra.Command{
 			return
 		}
 
+		if textOutput(cmd) {
+			printRows(cmd, columns, *columnColumns...)
+		}
+
 		handleSuccess(cmd, fmt.Sprintf("Columns for table '%s.%s' retrieved successfully", schema, tableName), map[string]interface{}{
 			"schema":  schema,
 			"table":   tableName,
@@ -241,7 +382,12 @@ var indexesCmd = &cobra.Command{
 	Short: "Show indexes for a specific table",
 	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := validateColumns([]database.IndexInfo{}, *indexColumns); err != nil {
+			handleError(cmd, err, "get_table_indexes")
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 30*time.Second)
 		defer cancel()
 
 		db, err := newDB()
@@ -251,7 +397,7 @@ var indexesCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		introspection := db.Introspection()
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
 
 		schema := args[0]
 		tableName := args[1]
@@ -275,6 +421,10 @@ var indexesCmd = &cobra.Command{
 			return
 		}
 
+		if textOutput(cmd) {
+			printRows(cmd, indexes, *indexColumns...)
+		}
+
 		handleSuccess(cmd, fmt.Sprintf("Indexes for table '%s.%s' retrieved successfully", schema, tableName), map[string]interface{}{
 			"schema":  schema,
 			"table":   tableName,
@@ -288,7 +438,7 @@ var constraintsCmd = &cobra.Command{
 	Short: "Show constraints for a specific table",
 	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := commandContext(cmd, 30*time.Second)
 		defer cancel()
 
 		db, err := newDB()
@@ -298,7 +448,7 @@ var constraintsCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		introspection := db.Introspection()
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
 
 		schema := args[0]
 		tableName := args[1]
@@ -322,6 +472,10 @@ var constraintsCmd = &cobra.Command{
 			return
 		}
 
+		if textOutput(cmd) {
+			printRows(cmd, constraints, "name", "type", "columns", "referenced_table", "referenced_columns")
+		}
+
 		handleSuccess(cmd, fmt.Sprintf("Constraints for table '%s.%s' retrieved successfully", schema, tableName), map[string]interface{}{
 			"schema":      schema,
 			"table":       tableName,
@@ -330,12 +484,120 @@ var constraintsCmd = &cobra.Command{
 	},
 }
 
+var triggersCmd = &cobra.Command{
+	Use:   "triggers [schema_name] [table_name]",
+	Short: "Show triggers for a specific table",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		schema := args[0]
+		tableName := args[1]
+
+		// Check if table exists
+		exists, err := introspection.GetTableExists(ctx, schema, tableName)
+		if err != nil {
+			handleError(cmd, err, "check_table_exists")
+			return
+		}
+
+		if !exists {
+			handleError(cmd, fmt.Errorf("table '%s.%s' does not exist", schema, tableName), "table_not_found")
+			return
+		}
+
+		// Get triggers
+		triggers, err := introspection.GetTableTriggers(ctx, schema, tableName)
+		if err != nil {
+			handleError(cmd, err, "get_table_triggers")
+			return
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, triggers, "name", "timing", "events", "level", "function", "enabled")
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Triggers for table '%s.%s' retrieved successfully", schema, tableName), map[string]interface{}{
+			"schema":   schema,
+			"table":    tableName,
+			"triggers": triggers,
+		})
+	},
+}
+
+var partitionsCmd = &cobra.Command{
+	Use:   "partitions [schema_name] [table_name]",
+	Short: "Show partitioning and partitions of a specific table",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		schema := args[0]
+		tableName := args[1]
+
+		// Check if table exists
+		exists, err := introspection.GetTableExists(ctx, schema, tableName)
+		if err != nil {
+			handleError(cmd, err, "check_table_exists")
+			return
+		}
+
+		if !exists {
+			handleError(cmd, fmt.Errorf("table '%s.%s' does not exist", schema, tableName), "table_not_found")
+			return
+		}
+
+		// Get partitioning
+		partitioning, err := introspection.GetTablePartitioning(ctx, schema, tableName)
+		if err != nil {
+			handleError(cmd, err, "get_table_partitioning")
+			return
+		}
+
+		if partitioning == nil {
+			handleError(cmd, fmt.Errorf("table '%s.%s' is not partitioned", schema, tableName), "table_not_partitioned")
+			return
+		}
+
+		if textOutput(cmd) {
+			fmt.Fprintf(cmd.OutOrStdout(), "Partitioned by %s (%s)\n", partitioning.Strategy, partitioning.Key)
+			printRows(cmd, partitioning.Partitions, "schema", "name", "bound", "is_partitioned")
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Partitions for table '%s.%s' retrieved successfully", schema, tableName), map[string]interface{}{
+			"schema":       schema,
+			"table":        tableName,
+			"partitioning": partitioning,
+		})
+	},
+}
+
 var relationshipsCmd = &cobra.Command{
 	Use:   "relationships [schema_name]",
 	Short: "Show foreign key relationships in the database",
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := commandContext(cmd, 30*time.Second)
 		defer cancel()
 
 		db, err := newDB()
@@ -345,7 +607,7 @@ var relationshipsCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		introspection := db.Introspection()
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
 
 		var schema string
 		if len(args) > 0 {
@@ -359,6 +621,10 @@ var relationshipsCmd = &cobra.Command{
 			return
 		}
 
+		if textOutput(cmd) {
+			printRows(cmd, relationships, "table_name", "name", "columns", "referenced_table", "referenced_columns")
+		}
+
 		handleSuccess(cmd, "Foreign key relationships retrieved successfully", map[string]interface{}{
 			"schema":        schema,
 			"relationships": relationships,
@@ -366,12 +632,207 @@ var relationshipsCmd = &cobra.Command{
 	},
 }
 
+var enumsCmd = &cobra.Command{
+	Use:   "enums [schema_name]",
+	Short: "Show enum types and their values",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		var schema string
+		if len(args) > 0 {
+			schema = args[0]
+		}
+
+		enums, err := introspection.GetEnums(ctx, schema)
+		if err != nil {
+			handleError(cmd, err, "get_enums")
+			return
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, enums, "schema", "name", "values")
+		}
+
+		handleSuccess(cmd, "Enums retrieved successfully", map[string]interface{}{
+			"schema": schema,
+			"enums":  enums,
+		})
+	},
+}
+
+var functionsCmd = &cobra.Command{
+	Use:   "functions [schema_name]",
+	Short: "Show functions and stored procedures",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		var schema string
+		if len(args) > 0 {
+			schema = args[0]
+		}
+
+		functions, err := introspection.GetFunctions(ctx, schema)
+		if err != nil {
+			handleError(cmd, err, "get_functions")
+			return
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, functions, "schema", "name", "kind", "arguments", "return_type", "language")
+		}
+
+		handleSuccess(cmd, "Functions retrieved successfully", map[string]interface{}{
+			"schema":    schema,
+			"functions": functions,
+		})
+	},
+}
+
+var extensionsCmd = &cobra.Command{
+	Use:   "extensions",
+	Short: "Show installed extensions and available updates",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		extensions, err := introspection.GetExtensions(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_extensions")
+			return
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, extensions, "name", "version", "schema", "update_available")
+		}
+
+		handleSuccess(cmd, "Extensions retrieved successfully", map[string]interface{}{
+			"extensions": extensions,
+		})
+	},
+}
+
+var rolesCmd = &cobra.Command{
+	Use:   "roles",
+	Short: "Show roles, their attributes and memberships",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		roles, err := introspection.GetRoles(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_roles")
+			return
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, roles, "name", "login", "superuser", "create_db", "create_role", "replication", "member_of")
+		}
+
+		handleSuccess(cmd, "Roles retrieved successfully", map[string]interface{}{
+			"roles": roles,
+		})
+	},
+}
+
+var grantsCmd = &cobra.Command{
+	Use:   "grants [schema_name] [table_name]",
+	Short: "Show privileges granted on a specific table and its columns",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		schema := args[0]
+		tableName := args[1]
+
+		// Check if table exists
+		exists, err := introspection.GetTableExists(ctx, schema, tableName)
+		if err != nil {
+			handleError(cmd, err, "check_table_exists")
+			return
+		}
+
+		if !exists {
+			handleError(cmd, fmt.Errorf("table '%s.%s' does not exist", schema, tableName), "table_not_found")
+			return
+		}
+
+		// Get grants
+		grants, err := introspection.GetTableGrants(ctx, schema, tableName)
+		if err != nil {
+			handleError(cmd, err, "get_table_grants")
+			return
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, grants, "grantee", "privilege", "column", "is_grantable", "grantor")
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Grants for table '%s.%s' retrieved successfully", schema, tableName), map[string]interface{}{
+			"schema": schema,
+			"table":  tableName,
+			"grants": grants,
+		})
+	},
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show database version information",
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := commandContext(cmd, 30*time.Second)
 		defer cancel()
 
 		db, err := newDB()
@@ -381,7 +842,7 @@ var versionCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		introspection := db.Introspection()
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
 
 		version, err := introspection.GetDatabaseVersion(ctx)
 		if err != nil {
@@ -400,7 +861,7 @@ var sizeCmd = &cobra.Command{
 	Short: "Show database size information",
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := commandContext(cmd, 30*time.Second)
 		defer cancel()
 
 		db, err := newDB()
@@ -410,7 +871,7 @@ var sizeCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		introspection := db.Introspection()
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
 
 		size, err := introspection.GetDatabaseSize(ctx)
 		if err != nil {
@@ -429,3 +890,342 @@ var sizeCmd = &cobra.Command{
 		})
 	},
 }
+
+var sizesCmd = &cobra.Command{
+	Use:   "sizes [schema_name]",
+	Short: "Show table, index and TOAST sizes per table, largest first",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		var schema string
+		if len(args) > 0 {
+			schema = args[0]
+		}
+
+		sizes, err := introspection.GetTableSizes(ctx, schema)
+		if err != nil {
+			handleError(cmd, err, "get_table_sizes")
+			return
+		}
+		if *sizesLimit > 0 && len(sizes) > *sizesLimit {
+			sizes = sizes[:*sizesLimit]
+		}
+
+		if textOutput(cmd) {
+			printTableSizes(cmd, sizes)
+		}
+
+		handleSuccess(cmd, "Table sizes retrieved successfully", map[string]interface{}{
+			"schema": schema,
+			"tables": sizes,
+		})
+	},
+}
+
+func printTableSizes(cmd *cobra.Command, sizes []database.TableSize) {
+	if len(sizes) == 0 {
+		return
+	}
+	cmd.Printf("%10s  %10s  %10s  %10s  %12s  %s\n", "TOTAL", "TABLE", "INDEXES", "TOAST", "ROWS (EST.)", "TABLE NAME")
+	for _, size := range sizes {
+		cmd.Printf("%10s  %10s  %10s  %10s  %12d  %s.%s\n",
+			formatBytes(size.TotalBytes), formatBytes(size.TableBytes), formatBytes(size.IndexBytes),
+			formatBytes(size.ToastBytes), size.EstimatedRows, size.Schema, size.Name)
+	}
+}
+
+var unusedIndexesCmd = &cobra.Command{
+	Use:   "unused-indexes [schema_name]",
+	Short: "Show indexes never scanned since the statistics were reset",
+	Long: `Show indexes without scans since the statistics were last reset, largest first.
+Indexes enforcing primary key, unique or exclusion constraints are left out.
+
+Statistics are per server: check the indexes are unused on replicas too before dropping them.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		var schema string
+		if len(args) > 0 {
+			schema = args[0]
+		}
+
+		report, err := introspection.GetUnusedIndexes(ctx, schema)
+		if err != nil {
+			handleError(cmd, err, "get_unused_indexes")
+			return
+		}
+
+		if textOutput(cmd) {
+			printUnusedIndexes(cmd, report)
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("%d unused indexes using %s", len(report.Indexes), formatBytes(report.TotalBytes)), map[string]interface{}{
+			"schema":      schema,
+			"stats_reset": report.StatsReset,
+			"total_bytes": report.TotalBytes,
+			"indexes":     report.Indexes,
+		})
+	},
+}
+
+func printUnusedIndexes(cmd *cobra.Command, report *database.UnusedIndexReport) {
+	if report.StatsReset != nil {
+		cmd.Printf("Statistics since %s\n", report.StatsReset.Format(time.RFC3339))
+	} else {
+		cmd.Println("Statistics never reset")
+	}
+	if len(report.Indexes) == 0 {
+		return
+	}
+	cmd.Printf("%10s  %s\n", "SIZE", "INDEX")
+	for _, index := range report.Indexes {
+		cmd.Printf("%10s  %s.%s on %s\n", formatBytes(index.SizeBytes), index.Schema, index.Name, index.Table)
+	}
+}
+
+var bloatCmd = &cobra.Command{
+	Use:   "bloat [schema_name]",
+	Short: "Estimate table and index bloat, most wasted space first",
+	Long: `Estimate the space wasted by dead tuples and free space in tables and B-tree indexes
+from the planner statistics, to decide where VACUUM FULL or REINDEX pays off. Run ANALYZE
+first; relations without statistics are left out.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		var schema string
+		if len(args) > 0 {
+			schema = args[0]
+		}
+
+		estimates, err := introspection.GetBloatEstimates(ctx, schema)
+		if err != nil {
+			handleError(cmd, err, "get_bloat_estimates")
+			return
+		}
+		if *bloatLimit > 0 && len(estimates) > *bloatLimit {
+			estimates = estimates[:*bloatLimit]
+		}
+
+		if textOutput(cmd) {
+			printBloatEstimates(cmd, estimates)
+		}
+
+		handleSuccess(cmd, "Bloat estimated successfully", map[string]interface{}{
+			"schema":    schema,
+			"relations": estimates,
+		})
+	},
+}
+
+func printBloatEstimates(cmd *cobra.Command, estimates []database.BloatEstimate) {
+	if len(estimates) == 0 {
+		return
+	}
+	cmd.Printf("%10s  %10s  %6s  %-5s  %s\n", "WASTED", "SIZE", "BLOAT", "KIND", "NAME")
+	for _, estimate := range estimates {
+		name := estimate.Schema + "." + estimate.Table
+		if estimate.Index != "" {
+			name = estimate.Schema + "." + estimate.Index + " on " + estimate.Table
+		}
+		cmd.Printf("%10s  %10s  %5.1f%%  %-5s  %s\n", formatBytes(estimate.WastedBytes), formatBytes(estimate.SizeBytes),
+			estimate.BloatRatio*100, estimate.Kind, name)
+	}
+}
+
+var exportSchemaCmd = &cobra.Command{
+	Use:   "export [schema_name]",
+	Short: "Export the schema as a versioned JSON or YAML document",
+	Long: `Export tables, columns, indexes, constraints, views and enums as a versioned
+document, the input for schema diffs, code generation and documentation.
+
+The document is written to stdout unless --output is given:
+
+  db introspect export --format yaml > schema.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 5*time.Minute)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		var opts database.ExportOptions
+		if len(args) > 0 {
+			opts.Schema = args[0]
+		}
+
+		doc, err := introspection.ExportSchema(ctx, opts)
+		if err != nil {
+			handleError(cmd, err, "export_schema")
+			return
+		}
+
+		if *exportOutput == "" {
+			if err := doc.Encode(cmd.OutOrStdout(), *exportFormat); err != nil {
+				handleError(cmd, err, "export_schema")
+			}
+			return
+		}
+
+		file, err := os.Create(*exportOutput)
+		if err != nil {
+			handleError(cmd, err, "export_schema")
+			return
+		}
+		defer file.Close()
+
+		if err := doc.Encode(file, *exportFormat); err != nil {
+			handleError(cmd, err, "export_schema")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Schema exported to %s", *exportOutput), map[string]interface{}{
+			"path":   *exportOutput,
+			"format": *exportFormat,
+			"tables": len(doc.Tables),
+			"views":  len(doc.Views),
+			"enums":  len(doc.Enums),
+		})
+	},
+}
+
+var driftCmd = &cobra.Command{
+	Use:   "drift <snapshot_file>",
+	Short: "Detect schema changes made outside migrations",
+	Long: `Compare the live schema with a committed snapshot and report unexpected changes,
+exiting with status 1 if the schema drifted. Save the snapshot after applying migrations:
+
+  db introspect drift schema.yaml --save
+  db introspect drift schema.yaml`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 5*time.Minute)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+		path := args[0]
+
+		if *driftSave {
+			if err := introspection.SaveSchemaSnapshot(ctx, path); err != nil {
+				handleError(cmd, err, "save_schema_snapshot")
+				return
+			}
+			handleSuccess(cmd, fmt.Sprintf("Schema snapshot saved to %s", path), map[string]interface{}{
+				"path": path,
+			})
+			return
+		}
+
+		diff, err := introspection.DetectDrift(ctx, path)
+		if err != nil {
+			handleError(cmd, err, "detect_drift")
+			return
+		}
+
+		if !textOutput(cmd) {
+			handleSuccess(cmd, fmt.Sprintf("%d schema changes since the snapshot", len(diff.Changes)), map[string]interface{}{
+				"snapshot": path,
+				"drift":    diff.HasChanges(),
+				"changes":  diff.Changes,
+			})
+		} else if err := diff.Report(cmd.OutOrStdout()); err != nil {
+			handleError(cmd, err, "detect_drift")
+			return
+		}
+
+		if diff.HasChanges() {
+			exitWith(cmd, ExitFailure, errors.New("the schema drifted from the snapshot"))
+		}
+	},
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search <pattern>",
+	Short: "Find tables, columns, indexes, functions and constraints by name",
+	Long: `Find the tables, views, columns, indexes, constraints and functions whose name matches a
+pattern, case-insensitively, across all user schemas, and print where they are. Patterns
+are globs where * matches any characters and ? a single one; patterns without wildcards
+match names containing them:
+
+  db introspect search customer
+  db introspect search '*_id' --kind column --schema billing`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection().WithParallelism(*introspectWorkers)
+
+		results, err := introspection.Search(ctx, args[0], database.SearchOptions{
+			Schema: *searchSchema,
+			Kinds:  *searchKinds,
+			Limit:  *searchLimit,
+		})
+		if err != nil {
+			handleError(cmd, err, "search")
+			return
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, results, "kind", "location", "detail")
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Found %d objects matching '%s'", len(results), args[0]), map[string]interface{}{
+			"pattern": args[0],
+			"results": results,
+		})
+	},
+}
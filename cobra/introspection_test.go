@@ -1,13 +1,17 @@
 package cobra
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/b87/db-kit/database"
 )
 
 func TestIntrospectionCommands(t *testing.T) {
@@ -25,6 +29,7 @@ func TestIntrospectionCommands(t *testing.T) {
 		assert.NotNil(t, cmd)
 		assert.Equal(t, "tables [schema_name]", cmd.Use)
 		assert.Equal(t, "List all tables in the database", cmd.Short)
+		assert.Equal(t, "[schema,name,type]", cmd.Flags().Lookup("columns").DefValue)
 	})
 
 	// Test table command
@@ -59,6 +64,22 @@ func TestIntrospectionCommands(t *testing.T) {
 		assert.Equal(t, "Show constraints for a specific table", cmd.Short)
 	})
 
+	// Test triggers command
+	t.Run("triggers command", func(t *testing.T) {
+		cmd := triggersCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "triggers [schema_name] [table_name]", cmd.Use)
+		assert.Equal(t, "Show triggers for a specific table", cmd.Short)
+	})
+
+	// Test partitions command
+	t.Run("partitions command", func(t *testing.T) {
+		cmd := partitionsCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "partitions [schema_name] [table_name]", cmd.Use)
+		assert.Equal(t, "Show partitioning and partitions of a specific table", cmd.Short)
+	})
+
 	// Test relationships command
 	t.Run("relationships command", func(t *testing.T) {
 		cmd := relationshipsCmd
@@ -67,6 +88,46 @@ func TestIntrospectionCommands(t *testing.T) {
 		assert.Equal(t, "Show foreign key relationships in the database", cmd.Short)
 	})
 
+	// Test enums command
+	t.Run("enums command", func(t *testing.T) {
+		cmd := enumsCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "enums [schema_name]", cmd.Use)
+		assert.Equal(t, "Show enum types and their values", cmd.Short)
+	})
+
+	// Test functions command
+	t.Run("functions command", func(t *testing.T) {
+		cmd := functionsCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "functions [schema_name]", cmd.Use)
+		assert.Equal(t, "Show functions and stored procedures", cmd.Short)
+	})
+
+	// Test extensions command
+	t.Run("extensions command", func(t *testing.T) {
+		cmd := extensionsCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "extensions", cmd.Use)
+		assert.Equal(t, "Show installed extensions and available updates", cmd.Short)
+	})
+
+	// Test roles command
+	t.Run("roles command", func(t *testing.T) {
+		cmd := rolesCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "roles", cmd.Use)
+		assert.Equal(t, "Show roles, their attributes and memberships", cmd.Short)
+	})
+
+	// Test grants command
+	t.Run("grants command", func(t *testing.T) {
+		cmd := grantsCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "grants [schema_name] [table_name]", cmd.Use)
+		assert.Equal(t, "Show privileges granted on a specific table and its columns", cmd.Short)
+	})
+
 	// Test version command
 	t.Run("version command", func(t *testing.T) {
 		cmd := versionCmd
@@ -82,6 +143,86 @@ func TestIntrospectionCommands(t *testing.T) {
 		assert.Equal(t, "size", cmd.Use)
 		assert.Equal(t, "Show database size information", cmd.Short)
 	})
+
+	// Test export command
+	t.Run("export command", func(t *testing.T) {
+		cmd := exportSchemaCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "export [schema_name]", cmd.Use)
+		assert.Equal(t, "Export the schema as a versioned JSON or YAML document", cmd.Short)
+		assert.Equal(t, "json", cmd.Flags().Lookup("format").DefValue)
+		assert.NotNil(t, cmd.Flags().ShorthandLookup("o"))
+	})
+
+	// Test drift command
+	t.Run("drift command", func(t *testing.T) {
+		cmd := driftCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "drift <snapshot_file>", cmd.Use)
+		assert.Equal(t, "Detect schema changes made outside migrations", cmd.Short)
+		assert.Equal(t, "false", cmd.Flags().Lookup("save").DefValue)
+	})
+
+	// Test unused-indexes command
+	t.Run("unused-indexes command", func(t *testing.T) {
+		cmd := unusedIndexesCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "unused-indexes [schema_name]", cmd.Use)
+		assert.Equal(t, "Show indexes never scanned since the statistics were reset", cmd.Short)
+	})
+
+	// Test bloat command
+	t.Run("bloat command", func(t *testing.T) {
+		cmd := bloatCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "bloat [schema_name]", cmd.Use)
+		assert.Equal(t, "Estimate table and index bloat, most wasted space first", cmd.Short)
+		assert.Equal(t, "0", cmd.Flags().Lookup("limit").DefValue)
+	})
+
+	// Test sizes command
+	t.Run("sizes command", func(t *testing.T) {
+		cmd := sizesCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "sizes [schema_name]", cmd.Use)
+		assert.Equal(t, "Show table, index and TOAST sizes per table, largest first", cmd.Short)
+		assert.NotNil(t, cmd.Flags().Lookup("limit"))
+	})
+
+	// Test search command
+	t.Run("search command", func(t *testing.T) {
+		cmd := searchCmd
+		assert.NotNil(t, cmd)
+		assert.Equal(t, "search <pattern>", cmd.Use)
+		assert.Equal(t, "Find tables, columns, indexes, functions and constraints by name", cmd.Short)
+		assert.Equal(t, "200", cmd.Flags().Lookup("limit").DefValue)
+		assert.NotNil(t, cmd.Flags().Lookup("schema"))
+		assert.NotNil(t, cmd.Flags().Lookup("kind"))
+	})
+}
+
+func TestIntrospectionWorkersFlag(t *testing.T) {
+	flag := introspectionCmd.PersistentFlags().Lookup("workers")
+	require.NotNil(t, flag)
+	assert.Equal(t, "4", flag.DefValue)
+
+	// Subcommands inherit the flag
+	assert.NotNil(t, tablesCmd.InheritedFlags().Lookup("workers"))
+}
+
+func TestIntrospectionColumnsFlags(t *testing.T) {
+	assert.Equal(t, "false", introspectionCmd.PersistentFlags().Lookup("wide").DefValue)
+	assert.NotNil(t, columnsCmd.InheritedFlags().Lookup("wide"))
+
+	for cmd, list := range map[*cobra.Command]interface{}{
+		tablesCmd:  []tableListing{},
+		columnsCmd: []database.ColumnInfo{},
+		indexesCmd: []database.IndexInfo{},
+	} {
+		columns, err := cmd.Flags().GetStringSlice("columns")
+		require.NoError(t, err)
+		assert.NoError(t, validateColumns(list, columns), cmd.Use)
+	}
 }
 
 func TestIntrospectionCommandIntegration(t *testing.T) {
@@ -192,6 +333,26 @@ func TestIntrospectionCommandArgs(t *testing.T) {
 		assert.Error(t, cmd.Args(cmd, []string{"public", "test_table", "extra"}))
 	})
 
+	// Test triggers command args
+	t.Run("triggers command args", func(t *testing.T) {
+		cmd := triggersCmd
+		// Should accept exactly 2 arguments
+		assert.Error(t, cmd.Args(cmd, []string{}))
+		assert.Error(t, cmd.Args(cmd, []string{"public"}))
+		assert.NoError(t, cmd.Args(cmd, []string{"public", "test_table"}))
+		assert.Error(t, cmd.Args(cmd, []string{"public", "test_table", "extra"}))
+	})
+
+	// Test partitions command args
+	t.Run("partitions command args", func(t *testing.T) {
+		cmd := partitionsCmd
+		// Should accept exactly 2 arguments
+		assert.Error(t, cmd.Args(cmd, []string{}))
+		assert.Error(t, cmd.Args(cmd, []string{"public"}))
+		assert.NoError(t, cmd.Args(cmd, []string{"public", "test_table"}))
+		assert.Error(t, cmd.Args(cmd, []string{"public", "test_table", "extra"}))
+	})
+
 	// Test relationships command args
 	t.Run("relationships command args", func(t *testing.T) {
 		cmd := relationshipsCmd
@@ -201,6 +362,50 @@ func TestIntrospectionCommandArgs(t *testing.T) {
 		assert.Error(t, cmd.Args(cmd, []string{"public", "extra"}))
 	})
 
+	// Test enums command args
+	t.Run("enums command args", func(t *testing.T) {
+		cmd := enumsCmd
+		// Should accept 0 or 1 arguments
+		assert.NoError(t, cmd.Args(cmd, []string{}))
+		assert.NoError(t, cmd.Args(cmd, []string{"public"}))
+		assert.Error(t, cmd.Args(cmd, []string{"public", "extra"}))
+	})
+
+	// Test functions command args
+	t.Run("functions command args", func(t *testing.T) {
+		cmd := functionsCmd
+		// Should accept 0 or 1 arguments
+		assert.NoError(t, cmd.Args(cmd, []string{}))
+		assert.NoError(t, cmd.Args(cmd, []string{"public"}))
+		assert.Error(t, cmd.Args(cmd, []string{"public", "extra"}))
+	})
+
+	// Test extensions command args
+	t.Run("extensions command args", func(t *testing.T) {
+		cmd := extensionsCmd
+		// Should accept 0 arguments
+		assert.NoError(t, cmd.Args(cmd, []string{}))
+		assert.Error(t, cmd.Args(cmd, []string{"extra"}))
+	})
+
+	// Test roles command args
+	t.Run("roles command args", func(t *testing.T) {
+		cmd := rolesCmd
+		// Should accept 0 arguments
+		assert.NoError(t, cmd.Args(cmd, []string{}))
+		assert.Error(t, cmd.Args(cmd, []string{"extra"}))
+	})
+
+	// Test grants command args
+	t.Run("grants command args", func(t *testing.T) {
+		cmd := grantsCmd
+		// Should accept exactly 2 arguments
+		assert.Error(t, cmd.Args(cmd, []string{}))
+		assert.Error(t, cmd.Args(cmd, []string{"public"}))
+		assert.NoError(t, cmd.Args(cmd, []string{"public", "test_table"}))
+		assert.Error(t, cmd.Args(cmd, []string{"public", "test_table", "extra"}))
+	})
+
 	// Test version command args
 	t.Run("version command args", func(t *testing.T) {
 		cmd := versionCmd
@@ -216,6 +421,124 @@ func TestIntrospectionCommandArgs(t *testing.T) {
 		assert.NoError(t, cmd.Args(cmd, []string{}))
 		assert.Error(t, cmd.Args(cmd, []string{"extra"}))
 	})
+
+	// Test export command args
+	t.Run("export command args", func(t *testing.T) {
+		cmd := exportSchemaCmd
+		// Should accept 0 or 1 arguments
+		assert.NoError(t, cmd.Args(cmd, []string{}))
+		assert.NoError(t, cmd.Args(cmd, []string{"public"}))
+		assert.Error(t, cmd.Args(cmd, []string{"public", "extra"}))
+	})
+
+	// Test drift command args
+	t.Run("drift command args", func(t *testing.T) {
+		cmd := driftCmd
+		// Should require exactly 1 argument
+		assert.Error(t, cmd.Args(cmd, []string{}))
+		assert.NoError(t, cmd.Args(cmd, []string{"schema.yaml"}))
+		assert.Error(t, cmd.Args(cmd, []string{"schema.yaml", "extra"}))
+	})
+
+	// Test unused-indexes command args
+	t.Run("unused-indexes command args", func(t *testing.T) {
+		cmd := unusedIndexesCmd
+		// Should accept 0 or 1 arguments
+		assert.NoError(t, cmd.Args(cmd, []string{}))
+		assert.NoError(t, cmd.Args(cmd, []string{"public"}))
+		assert.Error(t, cmd.Args(cmd, []string{"public", "extra"}))
+	})
+
+	// Test bloat command args
+	t.Run("bloat command args", func(t *testing.T) {
+		cmd := bloatCmd
+		// Should accept 0 or 1 arguments
+		assert.NoError(t, cmd.Args(cmd, []string{}))
+		assert.NoError(t, cmd.Args(cmd, []string{"public"}))
+		assert.Error(t, cmd.Args(cmd, []string{"public", "extra"}))
+	})
+
+	// Test sizes command args
+	t.Run("sizes command args", func(t *testing.T) {
+		cmd := sizesCmd
+		// Should accept 0 or 1 arguments
+		assert.NoError(t, cmd.Args(cmd, []string{}))
+		assert.NoError(t, cmd.Args(cmd, []string{"public"}))
+		assert.Error(t, cmd.Args(cmd, []string{"public", "extra"}))
+	})
+
+	// Test search command args
+	t.Run("search command args", func(t *testing.T) {
+		cmd := searchCmd
+		// Should require exactly 1 argument
+		assert.Error(t, cmd.Args(cmd, []string{}))
+		assert.NoError(t, cmd.Args(cmd, []string{"*_id"}))
+		assert.Error(t, cmd.Args(cmd, []string{"*_id", "extra"}))
+	})
+}
+
+func TestPrintTableSizes(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printTableSizes(cmd, nil)
+	assert.Empty(t, out.String())
+
+	printTableSizes(cmd, []database.TableSize{{
+		Schema:        "public",
+		Name:          "events",
+		TotalBytes:    3 << 30,
+		TableBytes:    2 << 30,
+		IndexBytes:    1 << 30,
+		EstimatedRows: 1200000,
+	}})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "TOTAL")
+	assert.Contains(t, lines[1], "3.0 GiB")
+	assert.Contains(t, lines[1], "1200000")
+	assert.Contains(t, lines[1], "public.events")
+}
+
+func TestPrintUnusedIndexes(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	reset := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	printUnusedIndexes(cmd, &database.UnusedIndexReport{
+		StatsReset: &reset,
+		Indexes:    []database.IndexUsage{{Schema: "public", Table: "events", Name: "events_payload_idx", SizeBytes: 512 << 20}},
+		TotalBytes: 512 << 20,
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "Statistics since 2024-05-01T12:00:00Z", lines[0])
+	assert.Contains(t, lines[2], "512.0 MiB")
+	assert.Contains(t, lines[2], "public.events_payload_idx on events")
+}
+
+func TestPrintBloatEstimates(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printBloatEstimates(cmd, nil)
+	assert.Empty(t, out.String())
+
+	printBloatEstimates(cmd, []database.BloatEstimate{
+		{Schema: "public", Table: "events", Kind: database.RelationTable, SizeBytes: 4 << 30, WastedBytes: 1 << 30, BloatRatio: 0.25},
+		{Schema: "public", Table: "events", Index: "events_pkey", Kind: database.RelationIndex, SizeBytes: 1 << 30, WastedBytes: 512 << 20, BloatRatio: 0.5},
+	})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "WASTED")
+	assert.Contains(t, lines[1], "1.0 GiB")
+	assert.Contains(t, lines[1], "25.0%")
+	assert.Contains(t, lines[1], "public.events")
+	assert.Contains(t, lines[2], "50.0%")
+	assert.Contains(t, lines[2], "public.events_pkey on events")
 }
 
 func TestIntrospectionCommandHelp(t *testing.T) {
@@ -228,9 +551,22 @@ func TestIntrospectionCommandHelp(t *testing.T) {
 		columnsCmd,
 		indexesCmd,
 		constraintsCmd,
+		triggersCmd,
+		partitionsCmd,
 		relationshipsCmd,
+		enumsCmd,
+		functionsCmd,
+		extensionsCmd,
+		rolesCmd,
+		grantsCmd,
 		versionCmd,
 		sizeCmd,
+		sizesCmd,
+		unusedIndexesCmd,
+		bloatCmd,
+		exportSchemaCmd,
+		driftCmd,
+		searchCmd,
 	}
 
 	for _, cmd := range commands {
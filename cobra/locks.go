@@ -0,0 +1,134 @@
+package cobra
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	locksBlockedOnly = new(bool)
+	locksMinWait     = new(time.Duration)
+)
+
+func init() {
+	DBCmd.AddCommand(locksCmd)
+
+	locksCmd.Flags().BoolVar(locksBlockedOnly, "blocked-only", false, "Show only sessions blocking or blocked by others")
+	locksCmd.Flags().DurationVar(locksMinWait, "min-wait", 0, "Show only blocking trees with a session waiting at least this long, e.g. 30s")
+
+	addErrorFlags(locksCmd)
+}
+
+var locksCmd = &cobra.Command{
+	Use:   "locks",
+	Short: "Show locks and which sessions block which",
+	Long: `Show who blocks whom as trees rooted at the sessions holding the contended locks,
+with how long each has held or waited and its query, followed by all locks of the database.
+For alerts, --min-wait keeps the trees in which a session has waited at least that long:
+
+  db locks --blocked-only --min-wait 30s --json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		locks, err := db.Introspection().GetLocks(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_locks")
+			return
+		}
+		trees := filterBlockingTrees(database.BlockingTrees(locks), *locksMinWait)
+
+		data := map[string]interface{}{
+			"blocking": trees,
+		}
+		if !*locksBlockedOnly {
+			data["locks"] = locks
+		}
+
+		if textOutput(cmd) {
+			printBlockingTrees(cmd, trees, "")
+			if !*locksBlockedOnly {
+				printLocks(cmd, locks)
+			}
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("%d locks, %d blocking sessions", len(locks), len(trees)), data)
+	},
+}
+
+// filterBlockingTrees keeps the trees in which a session has waited at least minWait
+func filterBlockingTrees(trees []database.BlockingNode, minWait time.Duration) []database.BlockingNode {
+	if minWait <= 0 {
+		return trees
+	}
+	filtered := []database.BlockingNode{}
+	for _, tree := range trees {
+		if tree.LongestWait() >= minWait {
+			filtered = append(filtered, tree)
+		}
+	}
+	return filtered
+}
+
+func printBlockingTrees(cmd *cobra.Command, trees []database.BlockingNode, indent string) {
+	for _, node := range trees {
+		session := fmt.Sprintf("PID %d", node.PID)
+		if node.User != nil || node.State != nil {
+			session += fmt.Sprintf(" (%s, %s)", valueOr(node.User, "-"), valueOr(node.State, "-"))
+		}
+
+		if node.WaitingFor == nil {
+			cmd.Printf("%s%s holding for %s: %s\n", indent, session, node.Duration.Round(time.Second), summarizeQuery(node.Query))
+		} else {
+			target := node.WaitingFor.LockType
+			if node.WaitingFor.Relation != nil {
+				target = *node.WaitingFor.Relation
+			}
+			cmd.Printf("%s%s waiting %s for %s on %s: %s\n", indent, session, node.Duration.Round(time.Second),
+				node.WaitingFor.Mode, target, summarizeQuery(node.Query))
+		}
+		printBlockingTrees(cmd, node.Blocked, indent+"  ")
+	}
+}
+
+func printLocks(cmd *cobra.Command, locks []database.LockInfo) {
+	if len(locks) == 0 {
+		return
+	}
+	cmd.Printf("%-8s  %-7s  %-24s  %-13s  %-30s  %10s  %s\n", "PID", "GRANTED", "MODE", "TYPE", "RELATION", "DURATION", "QUERY")
+	for _, lock := range locks {
+		cmd.Printf("%-8d  %-7t  %-24s  %-13s  %-30s  %10s  %s\n", lock.PID, lock.Granted, lock.Mode, lock.LockType,
+			valueOr(lock.Relation, "-"), lock.Duration.Round(time.Second), summarizeQuery(lock.Query))
+	}
+}
+
+// summarizeQuery returns the query on one line, shortened to 80 characters
+func summarizeQuery(query *string) string {
+	if query == nil {
+		return "-"
+	}
+	summary := strings.Join(strings.Fields(*query), " ")
+	if len(summary) > 80 {
+		summary = summary[:77] + "..."
+	}
+	return summary
+}
+
+func valueOr(value *string, fallback string) string {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
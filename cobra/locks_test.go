@@ -0,0 +1,77 @@
+package cobra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocksCommand(t *testing.T) {
+	assert.Equal(t, "locks", locksCmd.Use)
+	assert.Equal(t, "false", locksCmd.Flags().Lookup("blocked-only").DefValue)
+	assert.Equal(t, "0s", locksCmd.Flags().Lookup("min-wait").DefValue)
+	assert.NotNil(t, locksCmd.Flags().Lookup("json"))
+
+	assert.NoError(t, locksCmd.Args(locksCmd, []string{}))
+	assert.Error(t, locksCmd.Args(locksCmd, []string{"extra"}))
+}
+
+func TestPrintBlockingTrees(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	user, idle, active := "app", "idle in transaction", "active"
+	relation := "public.orders"
+	update := "UPDATE orders\n   SET status = $1 WHERE id = $2"
+	alter := "ALTER TABLE orders ADD COLUMN note text"
+
+	printBlockingTrees(cmd, []database.BlockingNode{{
+		PID:      10,
+		User:     &user,
+		State:    &idle,
+		Query:    &update,
+		Duration: 90 * time.Second,
+		Blocked: []database.BlockingNode{{
+			PID:        20,
+			User:       &user,
+			State:      &active,
+			Query:      &alter,
+			WaitingFor: &database.LockInfo{LockType: "relation", Mode: "AccessExclusiveLock", Relation: &relation},
+			Duration:   8 * time.Second,
+		}},
+	}}, "")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "PID 10 (app, idle in transaction) holding for 1m30s: UPDATE orders SET status = $1 WHERE id = $2", lines[0])
+	assert.Equal(t, "  PID 20 (app, active) waiting 8s for AccessExclusiveLock on public.orders: ALTER TABLE orders ADD COLUMN note text", lines[1])
+}
+
+func TestFilterBlockingTrees(t *testing.T) {
+	trees := []database.BlockingNode{
+		{PID: 10, Blocked: []database.BlockingNode{{PID: 20, WaitingFor: &database.LockInfo{}, Duration: 45 * time.Second}}},
+		{PID: 30, Blocked: []database.BlockingNode{{PID: 40, WaitingFor: &database.LockInfo{}, Duration: 2 * time.Second}}},
+	}
+
+	assert.Len(t, filterBlockingTrees(trees, 0), 2)
+	filtered := filterBlockingTrees(trees, 30*time.Second)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, int64(10), filtered[0].PID)
+	assert.NotNil(t, filterBlockingTrees(trees, time.Hour))
+}
+
+func TestSummarizeQuery(t *testing.T) {
+	assert.Equal(t, "-", summarizeQuery(nil))
+
+	long := strings.Repeat("x", 100)
+	summary := summarizeQuery(&long)
+	assert.Len(t, summary, 80)
+	assert.True(t, strings.HasSuffix(summary, "..."))
+}
@@ -0,0 +1,80 @@
+package cobra
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/b87/db-kit/database"
+)
+
+// Formats of the --log-format flag
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// cliLogger is the logger of the running command, configured from --log-level and
+// --log-format before it runs. The library logs through it, and with JSON logs the
+// command's own status messages become records too.
+var cliLogger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// newLogger returns a logger writing records of at least level to w in format
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	// POSTGRES_LOG_LEVEL also accepts warning
+	if strings.EqualFold(level, "warning") {
+		level = "warn"
+	}
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, database.NewValidationError(fmt.Sprintf("unsupported log level %q, expected debug, info, warn or error", level), err).
+			WithContext("log_level", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: minLevel}
+	switch strings.ToLower(format) {
+	case logFormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case logFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, database.NewValidationError(fmt.Sprintf("unsupported log format %q, expected text or json", format), nil).
+		WithContext("log_format", format)
+}
+
+// jsonLogs reports whether --log-format json was requested
+func jsonLogs() bool {
+	return strings.EqualFold(*logFormat, logFormatJSON)
+}
+
+// logMigrationEvent logs a migration progress event as a record
+func logMigrationEvent(event database.MigrationEvent) {
+	attrs := []any{
+		slog.String("migration", event.Name),
+		slog.Int("index", event.Index),
+		slog.Int("total", event.Total),
+	}
+	switch event.Type {
+	case database.MigrationStarted:
+		cliLogger.Info("applying migration", attrs...)
+	case database.MigrationFinished:
+		cliLogger.Info("applied migration", append(attrs, slog.Duration("duration", event.Duration.Round(time.Millisecond)))...)
+	case database.MigrationFailed:
+		cliLogger.Error("migration failed", append(attrs, slog.Duration("duration", event.Duration.Round(time.Millisecond)))...)
+	}
+}
+
+// logError logs a command error as a record with its code, operation and context
+func logError(output ErrorOutput) {
+	attrs := []any{slog.String("code", output.Code), slog.String("operation", output.Operation)}
+	for key, value := range output.Context {
+		attrs = append(attrs, slog.Any(key, value))
+	}
+	if output.UserMessage != "" {
+		attrs = append(attrs, slog.String("user_message", output.UserMessage))
+	}
+	cliLogger.Error(output.Error, attrs...)
+}
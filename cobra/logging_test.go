@@ -0,0 +1,64 @@
+package cobra
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/b87/db-kit/database"
+)
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "WARNING", "json")
+	require.NoError(t, err)
+	logger.Info("hidden")
+	logger.Warn("shown", "table", "users")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "shown", record["msg"])
+	assert.Equal(t, "users", record["table"])
+
+	buf.Reset()
+	logger, err = newLogger(&buf, "debug", "text")
+	require.NoError(t, err)
+	logger.Debug("connected")
+	assert.Contains(t, buf.String(), "msg=connected")
+
+	_, err = newLogger(&buf, "verbose", "text")
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+	_, err = newLogger(&buf, "info", "logfmt")
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+}
+
+func TestLogMigrationEvent(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "info", "json")
+	require.NoError(t, err)
+	previous := cliLogger
+	cliLogger = logger
+	defer func() { cliLogger = previous }()
+
+	logMigrationEvent(database.MigrationEvent{Type: database.MigrationFinished, Name: "1_init.sql", Index: 1, Total: 2, Duration: 1500 * time.Millisecond})
+	logError(ErrorOutput{Error: "migration failed", Code: "MIGRATION_FAILED", Operation: "migrate_up", Context: map[string]interface{}{"version": 2}})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "applied migration", record["msg"])
+	assert.Equal(t, "1_init.sql", record["migration"])
+	assert.Equal(t, float64(1500*time.Millisecond), record["duration"])
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "migrate_up", record["operation"])
+	assert.Equal(t, float64(2), record["version"])
+}
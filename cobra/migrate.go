@@ -4,13 +4,30 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/b87/db-kit/database"
 	"github.com/spf13/cobra"
 )
 
 var (
-	createtype = new(string)
+	createtype    = new(string)
+	schemaPattern = new(string)
+	shadowDB      = new(string)
+	downToTime    = new(string)
+	assumeYes     = new(bool)
+	rollbackYes   = new(bool)
+	migrateDryRun = new(bool)
+	statusWatch   = new(time.Duration)
+	applyVersions = new([]int64)
+	applyDown     = new(bool)
+
+	notifyWebhook  = new(string)
+	notifyTemplate = new(string)
 )
 
 func init() {
@@ -20,8 +37,31 @@ func init() {
 	migrateCmd.AddCommand(statusCmd)
 	migrateCmd.AddCommand(createCmd)
 	migrateCmd.AddCommand(resetCmd)
+	migrateCmd.AddCommand(upToCmd)
+	migrateCmd.AddCommand(downToCmd)
+	migrateCmd.AddCommand(upByOneCmd)
+	migrateCmd.AddCommand(downOneCmd)
+	migrateCmd.AddCommand(applyCmd)
+	migrateCmd.AddCommand(redoCmd)
+	migrateCmd.AddCommand(validateCmd)
 
+	migrateCmd.PersistentFlags().StringVar(notifyWebhook, "notify-webhook", os.Getenv("MIGRATION_WEBHOOK_URL"), "Post a notification to this URL after up, down and reset")
+	migrateCmd.PersistentFlags().StringVar(notifyTemplate, "notify-template", os.Getenv("MIGRATION_WEBHOOK_TEMPLATE"), "Go template for the notification payload (JSON of the result by default)")
 	createCmd.Flags().StringVarP(createtype, "type", "t", "sql", "Type of the migration")
+	upCmd.Flags().StringVar(shadowDB, "verify-shadow", "", "Apply migrations to this shadow database (recreated on the same server) before the target")
+	downCmd.Flags().StringVar(downToTime, "to-time", "", "Roll back every migration applied after this time (RFC3339 or '2006-01-02 15:04:05', local time)")
+	upCmd.Flags().BoolVarP(assumeYes, "yes", "y", false, "Apply the migration plan without asking for confirmation")
+	for _, cmd := range []*cobra.Command{downCmd, downToCmd, downOneCmd, resetCmd, applyCmd, redoCmd} {
+		cmd.Flags().BoolVarP(rollbackYes, "yes", "y", false, "Roll back without asking for confirmation")
+	}
+	for _, cmd := range []*cobra.Command{upCmd, downCmd, downToCmd, downOneCmd, resetCmd} {
+		cmd.Flags().BoolVar(migrateDryRun, "dry-run", false, "Print the migrations that would run without running them")
+	}
+	statusCmd.Flags().DurationVar(statusWatch, "watch", 0, "Refresh the status at this interval until interrupted, e.g. 5s")
+	upCmd.Flags().StringVar(schemaPattern, "schemas", "", "Apply migrations to every schema matching this glob pattern (e.g. 'tenant_*')")
+	applyCmd.Flags().Int64SliceVar(applyVersions, "versions", nil, "Versions to migrate to in turn, e.g. 20250102000001,20250102000002")
+	applyCmd.Flags().BoolVar(applyDown, "down", false, "Roll back to the versions, newest first, instead of migrating up")
+	_ = applyCmd.MarkFlagRequired("versions")
 
 	// Add error handling flags to all migration commands
 	addErrorFlags(migrateCmd)
@@ -30,15 +70,20 @@ func init() {
 	addErrorFlags(statusCmd)
 	addErrorFlags(createCmd)
 	addErrorFlags(resetCmd)
+	addErrorFlags(upToCmd)
+	addErrorFlags(downToCmd)
+	addErrorFlags(upByOneCmd)
+	addErrorFlags(downOneCmd)
+	addErrorFlags(applyCmd)
+	addErrorFlags(redoCmd)
+	addErrorFlags(validateCmd)
 }
 
 var migrateCmd = &cobra.Command{
 	Use: "migrate",
 	Run: func(cmd *cobra.Command, _ []string) {
-		err := cmd.Help()
-		if err != nil {
-			cmd.PrintErrln(err)
-			os.Exit(1)
+		if err := cmd.Help(); err != nil {
+			handleError(cmd, err, "help")
 		}
 	},
 }
@@ -47,7 +92,19 @@ var upCmd = &cobra.Command{
 	Use:   "up",
 	Short: "Migrate the database up",
 	Run: func(cmd *cobra.Command, _ []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if *migrateDryRun && (*schemaPattern != "" || *shadowDB != "") {
+			handleError(cmd, database.NewValidationError("--dry-run cannot be combined with --schemas or --verify-shadow", nil), "migrate_up")
+			return
+		}
+		if *schemaPattern != "" && *shadowDB != "" {
+			handleError(cmd, database.NewValidationError("--schemas cannot be combined with --verify-shadow", nil), "migrate_up")
+			return
+		}
+		if !*migrateDryRun && !confirmProduction(cmd, "apply migrations") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
 		defer cancel()
 
 		db, err := newDB()
@@ -57,20 +114,109 @@ var upCmd = &cobra.Command{
 		}
 		defer db.Close()
 
+		if err := setupNotifier(db); err != nil {
+			handleError(cmd, err, "notify_setup")
+			return
+		}
+
+		pending, err := db.Migrator.Pending(ctx)
+		if err != nil {
+			handleError(cmd, err, "migrate_pending")
+			return
+		}
+		if len(pending) == 0 && *schemaPattern == "" {
+			handleSuccess(cmd, "No pending migrations", nil)
+			return
+		}
+
+		// Each schema has its own version table, so the plan lists the schemas instead
+		question := fmt.Sprintf("Apply %d migrations?", len(pending))
+		if *schemaPattern != "" {
+			schemas, err := matchingSchemas(ctx, db, *schemaPattern)
+			if err != nil {
+				handleError(cmd, err, "migrate_up_all_schemas")
+				return
+			}
+			if textOutput(cmd) {
+				cmd.Printf("Schemas matching %s (%d): %s\n", *schemaPattern, len(schemas), strings.Join(schemas, ", "))
+			}
+			question = fmt.Sprintf("Apply migrations to %d schemas?", len(schemas))
+		} else if textOutput(cmd) {
+			printMigrationPlan(cmd, pending)
+		}
+		if *shadowDB != "" {
+			question = fmt.Sprintf("Apply %d migrations after verifying them on shadow database %s?", len(pending), *shadowDB)
+		}
+		if *migrateDryRun {
+			handleSuccess(cmd, fmt.Sprintf("Would apply %d migrations", len(pending)), map[string]interface{}{
+				"pending": pending,
+				"dry_run": true,
+			})
+			return
+		}
+		if !*assumeYes && isInteractive(cmd) && !confirm(cmd, question) {
+			handleError(cmd, database.NewValidationError("migration plan not confirmed, re-run with --yes to apply without a prompt", nil), "migrate_up")
+			return
+		}
+
+		if *schemaPattern != "" {
+			results, err := db.Migrator.UpAllSchemas(ctx, *schemaPattern)
+			for _, result := range results {
+				if result.Error != "" {
+					cmd.Printf("  %s: failed (%s)\n", result.Schema, result.Error)
+				} else {
+					cmd.Printf("  %s: version %d (%s)\n", result.Schema, result.Version, result.Duration)
+				}
+			}
+			if err != nil {
+				handleError(cmd, err, "migrate_up_all_schemas")
+				return
+			}
+			handleSuccess(cmd, fmt.Sprintf("Migrations applied to %d schemas", len(results)), map[string]interface{}{
+				"schemas": results,
+			})
+			return
+		}
+
+		if *shadowDB != "" {
+			shadowConfig := db.Config()
+			shadowConfig.DBName = *shadowDB
+
+			err = db.Migrator.VerifyOnShadow(ctx, shadowConfig)
+			if err != nil {
+				handleError(cmd, err, "migrate_verify_on_shadow")
+				return
+			}
+			handleSuccess(cmd, "Migrations verified on shadow database and applied", map[string]interface{}{
+				"shadow_database": *shadowDB,
+			})
+			return
+		}
+
+		db.Migrator.SetEventHandler(migrationProgress(cmd))
 		err = db.Migrator.Up(ctx)
 		if err != nil {
 			handleError(cmd, err, "migrate_up")
 			return
 		}
-		handleSuccess(cmd, "Migration up completed successfully", nil)
+		handleSuccess(cmd, "Migration up completed successfully", map[string]interface{}{
+			"applied": pending,
+		})
 	},
 }
 
 var downCmd = &cobra.Command{
 	Use:   "down",
 	Short: "Migrate the database down",
+	Long: `Roll back the latest migration, or every migration applied after --to-time. Asks
+for confirmation, naming the database and server, unless --yes is given; without a
+terminal, --yes is required.`,
 	Run: func(cmd *cobra.Command, _ []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if !*migrateDryRun && !confirmProduction(cmd, "roll back migrations") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
 		defer cancel()
 
 		db, err := newDB()
@@ -80,6 +226,42 @@ var downCmd = &cobra.Command{
 		}
 		defer db.Close()
 
+		if *migrateDryRun {
+			plan, err := downPlan(ctx, db.Migrator, *downToTime)
+			if err != nil {
+				handleError(cmd, err, "migrate_down")
+				return
+			}
+			reportRollbackPlan(cmd, plan)
+			return
+		}
+		if !confirmDestructive(cmd, *rollbackYes, db.Config(), "Roll back migrations", "migrate_down") {
+			return
+		}
+
+		if err := setupNotifier(db); err != nil {
+			handleError(cmd, err, "notify_setup")
+			return
+		}
+
+		if *downToTime != "" {
+			target, err := parseMigrationTime(*downToTime)
+			if err != nil {
+				handleError(cmd, err, "migrate_down")
+				return
+			}
+
+			err = db.Migrator.DownToTime(ctx, target)
+			if err != nil {
+				handleError(cmd, err, "migrate_down")
+				return
+			}
+			handleSuccess(cmd, "Migration down completed successfully", map[string]interface{}{
+				"to_time": target.Format(time.RFC3339),
+			})
+			return
+		}
+
 		err = db.Migrator.Down(ctx)
 		if err != nil {
 			handleError(cmd, err, "migrate_down")
@@ -92,9 +274,16 @@ var downCmd = &cobra.Command{
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show migration status",
+	Long: `Show the applied and pending migrations. With --watch, the status is refreshed at the
+given interval until interrupted, or a JSON line is appended with --json, e.g. to follow
+a deploy applying long migrations:
+
+  db migrate status --watch 5s`,
 	Run: func(cmd *cobra.Command, _ []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+		if err := validateWatch(cmd, *statusWatch); err != nil {
+			handleError(cmd, err, "migration_status")
+			return
+		}
 
 		db, err := newDB()
 		if err != nil {
@@ -103,25 +292,47 @@ var statusCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		status, err := db.Migrator.Status(ctx)
-		if err != nil {
-			handleError(cmd, err, "migration_status")
+		if *statusWatch > 0 {
+			watch(cmd, *statusWatch, "migration_status", func(ctx context.Context) (map[string]interface{}, error) {
+				return migrationStatus(ctx, cmd, db)
+			})
 			return
 		}
 
-		// Format status information for output
-		statusInfo := map[string]interface{}{
-			"current_version": status.Current,
-			"latest_version":  status.Latest,
-			"applied_count":   status.Applied,
-			"pending_count":   status.Pending,
-			"migrations":      status.Migrations,
-		}
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
 
+		statusInfo, err := migrationStatus(ctx, cmd, db)
+		if err != nil {
+			handleError(cmd, err, "migration_status")
+			return
+		}
 		handleSuccess(cmd, "Migration status retrieved successfully", statusInfo)
 	},
 }
 
+// migrationStatus prints the migration status with text output and returns it for the
+// structured formats
+func migrationStatus(ctx context.Context, cmd *cobra.Command, db *database.DB) (map[string]interface{}, error) {
+	status, err := db.Migrator.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if textOutput(cmd) {
+		fmt.Fprintf(cmd.OutOrStdout(), "Current version %d, latest %d: %d applied, %d pending\n", status.Current, status.Latest, status.Applied, status.Pending)
+		printRows(cmd, status.Migrations, "version", "description", "is_applied", "applied_at", "source")
+	}
+
+	return map[string]interface{}{
+		"current_version": status.Current,
+		"latest_version":  status.Latest,
+		"applied_count":   status.Applied,
+		"pending_count":   status.Pending,
+		"migrations":      status.Migrations,
+	}, nil
+}
+
 var createCmd = &cobra.Command{
 	Use:   "create [name]",
 	Short: "Create a new migration file",
@@ -129,10 +340,10 @@ var createCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
 			cmd.Help()
-			os.Exit(1)
+			return
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := commandContext(cmd, migrationTimeout)
 		defer cancel()
 
 		name := args[0]
@@ -159,8 +370,14 @@ var createCmd = &cobra.Command{
 var resetCmd = &cobra.Command{
 	Use:   "reset",
 	Short: "Reset the database (reset all migrations)",
+	Long: `Roll back every applied migration. Asks for confirmation, naming the database and
+server, unless --yes is given; without a terminal, --yes is required.`,
 	Run: func(cmd *cobra.Command, _ []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if !*migrateDryRun && !confirmProduction(cmd, "reset the database") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
 		defer cancel()
 
 		db, err := newDB()
@@ -170,6 +387,24 @@ var resetCmd = &cobra.Command{
 		}
 		defer db.Close()
 
+		if *migrateDryRun {
+			plan, err := db.Migrator.RollbackPlan(ctx, 0)
+			if err != nil {
+				handleError(cmd, err, "reset_migrations")
+				return
+			}
+			reportRollbackPlan(cmd, plan)
+			return
+		}
+		if !confirmDestructive(cmd, *rollbackYes, db.Config(), "Roll back all migrations", "reset_migrations") {
+			return
+		}
+
+		if err := setupNotifier(db); err != nil {
+			handleError(cmd, err, "notify_setup")
+			return
+		}
+
 		err = db.Migrator.Reset(ctx)
 		if err != nil {
 			handleError(cmd, err, "reset_migrations")
@@ -178,3 +413,505 @@ var resetCmd = &cobra.Command{
 		handleSuccess(cmd, "Database reset completed successfully", nil)
 	},
 }
+
+var upToCmd = &cobra.Command{
+	Use:   "up-to <version>",
+	Short: "Migrate the database up to a specific version",
+	Args:  versionArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !confirmProduction(cmd, "apply migrations") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		version, _ := parseVersionArg(args[0], 1)
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		err = db.Migrator.UpTo(ctx, version)
+		if err != nil {
+			handleError(cmd, err, "migrate_up_to")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("Migrated up to version %d", version), map[string]interface{}{
+			"version": version,
+		})
+	},
+}
+
+var downToCmd = &cobra.Command{
+	Use:   "down-to <version>",
+	Short: "Roll the database back to a specific version (0 rolls back everything)",
+	Long: `Roll back the migrations newer than the version. Asks for confirmation, naming the
+database and server, unless --yes is given; without a terminal, --yes is required.`,
+	Args: versionArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !*migrateDryRun && !confirmProduction(cmd, "roll back migrations") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		version, _ := parseVersionArg(args[0], 0)
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		if *migrateDryRun {
+			plan, err := db.Migrator.RollbackPlan(ctx, version)
+			if err != nil {
+				handleError(cmd, err, "migrate_down_to")
+				return
+			}
+			reportRollbackPlan(cmd, plan)
+			return
+		}
+		if !confirmDestructive(cmd, *rollbackYes, db.Config(), fmt.Sprintf("Roll back to version %d", version), "migrate_down_to") {
+			return
+		}
+
+		err = db.Migrator.DownTo(ctx, version)
+		if err != nil {
+			handleError(cmd, err, "migrate_down_to")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("Migrated down to version %d", version), map[string]interface{}{
+			"version": version,
+		})
+	},
+}
+
+var upByOneCmd = &cobra.Command{
+	Use:     "up-by-one",
+	Aliases: []string{"up-one"},
+	Short:   "Apply the next pending migration",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if !confirmProduction(cmd, "apply a migration") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		err = db.Migrator.UpByOne(ctx)
+		if err != nil {
+			handleError(cmd, err, "migrate_up_by_one")
+			return
+		}
+
+		version, err := db.Migrator.Version(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_version")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("Migrated up to version %d", version), map[string]interface{}{
+			"version": version,
+		})
+	},
+}
+
+var downOneCmd = &cobra.Command{
+	Use:   "down-one",
+	Short: "Roll back the latest migration",
+	Long: `Roll back the latest applied migration. Asks for confirmation, naming the migration,
+database and server, unless --yes is given; without a terminal, --yes is required.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if !*migrateDryRun && !confirmProduction(cmd, "roll back a migration") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		plan, err := downPlan(ctx, db.Migrator, "")
+		if err != nil {
+			handleError(cmd, err, "migrate_down_one")
+			return
+		}
+		if *migrateDryRun {
+			reportRollbackPlan(cmd, plan)
+			return
+		}
+		if len(plan) == 0 {
+			handleError(cmd, database.NewValidationError("no migration has been applied, nothing to roll back", nil), "migrate_down_one")
+			return
+		}
+		latest := plan[0]
+		if !confirmDestructive(cmd, *rollbackYes, db.Config(), fmt.Sprintf("Roll back migration %d %s", latest.Version, latest.Name), "migrate_down_one") {
+			return
+		}
+
+		err = db.Migrator.DownByOne(ctx)
+		if err != nil {
+			handleError(cmd, err, "migrate_down_one")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("Rolled back migration %d", latest.Version), map[string]interface{}{
+			"version": latest.Version,
+			"name":    latest.Name,
+		})
+	},
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply --versions <version,...>",
+	Short: "Migrate to several versions in turn as one batch",
+	Long: `Migrate up to each of the versions in ascending order, and back to the starting version
+if any step fails. With --down, roll back to each version in descending order instead, and
+up to the starting version again if any step fails; rolling back asks for confirmation
+unless --yes is given.
+
+  db migrate apply --versions 20250102000001,20250102000002
+  db migrate apply --versions 20250101000001 --down --yes`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		versions, err := batchVersions(*applyVersions)
+		if err != nil {
+			handleError(cmd, err, "migrate_apply")
+			return
+		}
+		action := "apply migrations"
+		if *applyDown {
+			action = "roll back migrations"
+		}
+		if !confirmProduction(cmd, action) {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		if *applyDown {
+			question := fmt.Sprintf("Roll back to versions %s", formatVersions(versions))
+			if !confirmDestructive(cmd, *rollbackYes, db.Config(), question, "migrate_apply") {
+				return
+			}
+			err = db.Migrator.DownInTransaction(ctx, versions...)
+		} else {
+			err = db.Migrator.UpInTransaction(ctx, versions...)
+		}
+		if err != nil {
+			handleError(cmd, err, "migrate_apply")
+			return
+		}
+
+		version, err := db.Migrator.Version(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_version")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("Migrated through versions %s, now at version %d", formatVersions(versions), version), map[string]interface{}{
+			"versions": versions,
+			"down":     *applyDown,
+			"version":  version,
+		})
+	},
+}
+
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Roll back the latest migration and apply it again",
+	Long: `Roll back the latest migration and apply it again. Its down migration runs first, so
+asks for confirmation unless --yes is given; without a terminal, --yes is required.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if !confirmProduction(cmd, "redo a migration") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		version, err := db.Migrator.Version(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_version")
+			return
+		}
+		if version == 0 {
+			handleError(cmd, database.NewValidationError("no migration has been applied, nothing to redo", nil), "migrate_redo")
+			return
+		}
+		if !confirmDestructive(cmd, *rollbackYes, db.Config(), fmt.Sprintf("Roll back and reapply migration %d", version), "migrate_redo") {
+			return
+		}
+
+		err = db.Migrator.DownByOne(ctx)
+		if err != nil {
+			handleError(cmd, err, "migrate_redo")
+			return
+		}
+		err = db.Migrator.UpTo(ctx, version)
+		if err != nil {
+			handleError(cmd, err, "migrate_redo")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("Redid migration %d", version), map[string]interface{}{
+			"version": version,
+		})
+	},
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Syntax-check pending migrations without applying them",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		checks, err := db.Migrator.CheckPending(ctx)
+		if err != nil {
+			handleError(cmd, err, "validate_migrations")
+			return
+		}
+
+		if textOutput(cmd) {
+			printMigrationChecks(cmd, checks)
+		}
+
+		failed := 0
+		for _, check := range checks {
+			if check.Failed() {
+				failed++
+			}
+		}
+		if failed > 0 {
+			handleError(cmd, database.NewValidationError(fmt.Sprintf("%d pending migrations failed the syntax check", failed), nil).
+				WithContext("failed_count", failed), "validate_migrations")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("%d pending migrations passed the syntax check", len(checks)), map[string]interface{}{
+			"migrations": checks,
+		})
+	},
+}
+
+// printMigrationChecks prints the statement types and syntax errors of checked migrations
+func printMigrationChecks(cmd *cobra.Command, checks []database.MigrationCheck) {
+	for _, check := range checks {
+		cmd.Printf("%s\n", check.Name)
+		for _, statement := range check.Statements {
+			if statement.Error != "" {
+				cmd.Printf("  line %d: %s: %s\n", statement.Line, statement.Type, statement.Error)
+			} else {
+				cmd.Printf("  line %d: %s\n", statement.Line, statement.Type)
+			}
+		}
+	}
+}
+
+// versionArgs validates that a command receives exactly one version argument of at least minVersion
+func versionArgs(minVersion int64) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		_, err := parseVersionArg(args[0], minVersion)
+		return err
+	}
+}
+
+// parseVersionArg parses a migration version argument
+func parseVersionArg(arg string, minVersion int64) (int64, error) {
+	version, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || version < minVersion {
+		return 0, database.NewValidationError(fmt.Sprintf("invalid migration version %q, expected an integer >= %d", arg, minVersion), err).
+			WithContext("version", arg)
+	}
+	return version, nil
+}
+
+// batchVersions validates the versions of apply and sorts them in ascending order
+func batchVersions(versions []int64) ([]int64, error) {
+	if len(versions) == 0 {
+		return nil, database.NewValidationError("--versions lists no versions", nil)
+	}
+	sorted := append([]int64(nil), versions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i, version := range sorted {
+		if version < 0 || i > 0 && version == sorted[i-1] {
+			return nil, database.NewValidationError(fmt.Sprintf("invalid migration version %d in --versions", version), nil).
+				WithContext("versions", versions)
+		}
+	}
+	return sorted, nil
+}
+
+// formatVersions joins versions with commas
+func formatVersions(versions []int64) string {
+	formatted := make([]string, len(versions))
+	for i, version := range versions {
+		formatted[i] = strconv.FormatInt(version, 10)
+	}
+	return strings.Join(formatted, ",")
+}
+
+// setupNotifier attaches a webhook notifier to the migrator when --notify-webhook is set
+func setupNotifier(db *database.DB) error {
+	if *notifyWebhook == "" {
+		return nil
+	}
+	notifier, err := database.NewWebhookNotifier(*notifyWebhook, *notifyTemplate)
+	if err != nil {
+		return err
+	}
+	db.Migrator.SetNotifier(notifier)
+	return nil
+}
+
+// printMigrationPlan prints the migrations that are about to be applied
+func printMigrationPlan(cmd *cobra.Command, pending []database.PendingMigration) {
+	cmd.Printf("Migration plan (%d pending):\n", len(pending))
+	for _, m := range pending {
+		mode := "transactional"
+		if !m.Transactional {
+			mode = "non-transactional"
+		}
+		cmd.Printf("  %d  %-40s %-4s %8d bytes  %s\n", m.Version, m.Name, m.Type, m.Size, mode)
+	}
+}
+
+// matchingSchemas returns the schemas of db matching the glob pattern of migrate up --schemas
+func matchingSchemas(ctx context.Context, db *database.DB, pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, database.NewValidationError("invalid schema pattern", err).
+			WithContext("pattern", pattern)
+	}
+	schemas, err := db.Introspection().GetSchemas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matching []string
+	for _, schema := range schemas {
+		if matched, _ := path.Match(pattern, schema); matched {
+			matching = append(matching, schema)
+		}
+	}
+	return matching, nil
+}
+
+// downPlan returns the migrations down rolls back: the latest one, or those applied after
+// toTime if set
+func downPlan(ctx context.Context, migrator database.Migrator, toTime string) ([]database.PendingMigration, error) {
+	if toTime == "" {
+		plan, err := migrator.RollbackPlan(ctx, 0)
+		if len(plan) > 1 {
+			plan = plan[:1]
+		}
+		return plan, err
+	}
+
+	target, err := parseMigrationTime(toTime)
+	if err != nil {
+		return nil, err
+	}
+	version, err := migrator.VersionAt(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	return migrator.RollbackPlan(ctx, version)
+}
+
+// reportRollbackPlan prints the migrations a dry run would roll back, newest first
+func reportRollbackPlan(cmd *cobra.Command, plan []database.PendingMigration) {
+	if textOutput(cmd) {
+		cmd.Printf("Rollback plan (%d migrations):\n", len(plan))
+		for _, m := range plan {
+			cmd.Printf("  %d  %-40s %-4s\n", m.Version, m.Name, m.Type)
+		}
+	}
+	handleSuccess(cmd, fmt.Sprintf("Would roll back %d migrations", len(plan)), map[string]interface{}{
+		"rollback": plan,
+		"dry_run":  true,
+	})
+}
+
+// migrationProgress returns an event handler that streams per-migration progress, as
+// log records with JSON logs, or nil when structured output is requested
+func migrationProgress(cmd *cobra.Command) database.MigrationEventHandler {
+	if !textOutput(cmd) {
+		return nil
+	}
+	if jsonLogs() {
+		return logMigrationEvent
+	}
+	return func(event database.MigrationEvent) {
+		switch event.Type {
+		case database.MigrationStarted:
+			cmd.Printf("[%d/%d] applying %s...\n", event.Index, event.Total, event.Name)
+		case database.MigrationFinished:
+			cmd.Printf("[%d/%d] applied %s (%s)\n", event.Index, event.Total, event.Name, event.Duration.Round(time.Millisecond))
+		case database.MigrationFailed:
+			cmd.Printf("[%d/%d] failed %s after %s\n", event.Index, event.Total, event.Name, event.Duration.Round(time.Millisecond))
+		}
+	}
+}
+
+// migrationTimeLayouts are the accepted formats for --to-time, most specific first
+var migrationTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseMigrationTime parses a --to-time value; values without a zone are taken as local time
+func parseMigrationTime(value string) (time.Time, error) {
+	for _, layout := range migrationTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, database.NewValidationError(fmt.Sprintf("invalid time %q, expected RFC3339 or '2006-01-02 15:04:05'", value), nil).
+		WithContext("value", value)
+}
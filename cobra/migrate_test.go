@@ -1,10 +1,16 @@
 package cobra
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/b87/db-kit/database"
 	"github.com/spf13/cobra"
 )
 
@@ -107,3 +113,209 @@ func TestCreateCommandArgs(t *testing.T) {
 		t.Errorf("Expected create command to accept one argument, got error: %v", err)
 	}
 }
+
+func TestParseMigrationTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2025-03-01T10:30:00Z", want: time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC)},
+		{value: "2025-03-01 10:30:00", want: time.Date(2025, 3, 1, 10, 30, 0, 0, time.Local)},
+		{value: "2025-03-01", want: time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)},
+		{value: "yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseMigrationTime(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrintMigrationPlan(t *testing.T) {
+	var out strings.Builder
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printMigrationPlan(cmd, []database.PendingMigration{
+		{Version: 20250102000002, Name: "20250102000002_create_posts.sql", Type: "sql", Size: 120, Transactional: true},
+		{Version: 20250102000004, Name: "20250102000004_concurrent_index.sql", Type: "sql", Size: 80},
+	})
+
+	output := out.String()
+	if !strings.Contains(output, "Migration plan (2 pending)") {
+		t.Errorf("Expected plan header, got %q", output)
+	}
+	if !strings.Contains(output, "20250102000004_concurrent_index.sql") || !strings.Contains(output, "non-transactional") {
+		t.Errorf("Expected non-transactional migration in plan, got %q", output)
+	}
+}
+
+func TestMatchingSchemasInvalidPattern(t *testing.T) {
+	_, err := matchingSchemas(context.Background(), nil, "tenant_[")
+	if database.GetErrorCode(err) != database.ErrCodeValidation {
+		t.Errorf("Expected a validation error for an invalid pattern, got %v", err)
+	}
+}
+
+func TestReportRollbackPlan(t *testing.T) {
+	var out strings.Builder
+	cmd := &cobra.Command{}
+	addErrorFlags(cmd)
+	cmd.SetOut(&out)
+
+	reportRollbackPlan(cmd, []database.PendingMigration{
+		{Version: 20250102000003, Name: "20250102000003_add_users_index.sql", Type: "sql"},
+		{Version: 20250102000002, Name: "20250102000002_create_posts.sql", Type: "sql"},
+	})
+
+	output := out.String()
+	if !strings.Contains(output, "Rollback plan (2 migrations)") || !strings.Contains(output, "Would roll back 2 migrations") {
+		t.Errorf("Unexpected rollback plan output: %q", output)
+	}
+	if strings.Index(output, "20250102000003") > strings.Index(output, "20250102000002") {
+		t.Errorf("Expected the newest migration first, got %q", output)
+	}
+}
+
+func TestMigrateDryRunFlags(t *testing.T) {
+	for _, cmd := range []*cobra.Command{upCmd, downCmd, downToCmd, downOneCmd, resetCmd} {
+		if cmd.Flags().Lookup("dry-run") == nil {
+			t.Errorf("Expected %s to have --dry-run", cmd.Name())
+		}
+	}
+}
+
+func TestMigrateUpFlagConflicts(t *testing.T) {
+	t.Cleanup(func() {
+		DBCmd.SetArgs(nil)
+		DBCmd.SetOut(nil)
+		DBCmd.SetErr(nil)
+		upCmd.Flags().Set("schemas", "")
+		upCmd.Flags().Set("verify-shadow", "")
+		DBCmd.PersistentFlags().Set("output", outputTable)
+	})
+
+	var stderr bytes.Buffer
+	DBCmd.SetOut(io.Discard)
+	DBCmd.SetErr(&stderr)
+	err := ExecuteArgs(context.Background(), []string{"--output", "json", "migrate", "up", "--schemas", "tenant_*", "--verify-shadow", "app_shadow"})
+	if code := ExitCode(err); code != ExitValidation {
+		t.Errorf("Expected exit code %d, got %d", ExitValidation, code)
+	}
+	if !strings.Contains(stderr.String(), "--schemas cannot be combined with --verify-shadow") {
+		t.Errorf("Expected the flag conflict to be reported, got %q", stderr.String())
+	}
+}
+
+func TestMigrateBatchCommands(t *testing.T) {
+	found, _, err := migrateCmd.Find([]string{"up-one"})
+	if err != nil || found != upByOneCmd {
+		t.Errorf("Expected up-one to run up-by-one, got %v (%v)", found, err)
+	}
+	if applyCmd.Flags().Lookup("versions") == nil || applyCmd.Flags().Lookup("down") == nil {
+		t.Error("Expected apply to have --versions and --down")
+	}
+}
+
+func TestBatchVersions(t *testing.T) {
+	versions, err := batchVersions([]int64{20250102000002, 20250102000001})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if formatVersions(versions) != "20250102000001,20250102000002" {
+		t.Errorf("Expected sorted versions, got %v", versions)
+	}
+
+	for _, invalid := range [][]int64{nil, {-1}, {3, 3}} {
+		if _, err := batchVersions(invalid); database.GetErrorCode(err) != database.ErrCodeValidation {
+			t.Errorf("Expected a validation error for %v, got %v", invalid, err)
+		}
+	}
+}
+
+func TestMigrationProgress(t *testing.T) {
+	var out strings.Builder
+	cmd := &cobra.Command{}
+	addErrorFlags(cmd)
+	cmd.SetOut(&out)
+
+	handler := migrationProgress(cmd)
+	handler(database.MigrationEvent{Type: database.MigrationStarted, Name: "1_init.sql", Index: 1, Total: 2})
+	handler(database.MigrationEvent{Type: database.MigrationFinished, Name: "1_init.sql", Index: 1, Total: 2, Duration: 1500 * time.Millisecond})
+
+	if !strings.Contains(out.String(), "[1/2] applied 1_init.sql (1.5s)") {
+		t.Errorf("Unexpected progress output: %q", out.String())
+	}
+
+	if err := cmd.Flags().Set("json", "true"); err != nil {
+		t.Fatalf("Failed to set json flag: %v", err)
+	}
+	if migrationProgress(cmd) != nil {
+		t.Error("Expected no progress handler with JSON output")
+	}
+}
+
+func TestVersionArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmd     *cobra.Command
+		args    []string
+		wantErr bool
+	}{
+		{name: "up-to valid", cmd: upToCmd, args: []string{"20250102000001"}},
+		{name: "up-to zero", cmd: upToCmd, args: []string{"0"}, wantErr: true},
+		{name: "up-to missing", cmd: upToCmd, args: []string{}, wantErr: true},
+		{name: "up-to not a number", cmd: upToCmd, args: []string{"latest"}, wantErr: true},
+		{name: "down-to zero", cmd: downToCmd, args: []string{"0"}},
+		{name: "down-to negative", cmd: downToCmd, args: []string{"-1"}, wantErr: true},
+		{name: "down-to too many", cmd: downToCmd, args: []string{"1", "2"}, wantErr: true},
+		{name: "redo with args", cmd: redoCmd, args: []string{"1"}, wantErr: true},
+		{name: "up-by-one", cmd: upByOneCmd, args: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cmd.Args(tt.cmd, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Args(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintMigrationChecks(t *testing.T) {
+	var out strings.Builder
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printMigrationChecks(cmd, []database.MigrationCheck{{
+		Version: 1,
+		Name:    "1_init.sql",
+		Statements: []database.StatementCheck{
+			{Line: 2, Type: "CREATE TABLE"},
+			{Line: 3, Type: "ALTER TABLE", Error: `syntax error at or near "NUL"`},
+		},
+	}})
+
+	output := out.String()
+	if !strings.Contains(output, "line 2: CREATE TABLE\n") {
+		t.Errorf("Expected statement type in output, got %q", output)
+	}
+	if !strings.Contains(output, `line 3: ALTER TABLE: syntax error at or near "NUL"`) {
+		t.Errorf("Expected syntax error in output, got %q", output)
+	}
+}
@@ -0,0 +1,306 @@
+package cobra
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/b87/db-kit/database"
+)
+
+// Formats of the --output flag
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputCSV   = "csv"
+)
+
+// outputFormat returns the format requested with the global --output flag, json if --json
+// is set
+func outputFormat(cmd *cobra.Command) string {
+	if jsonOutput, _ := cmd.Flags().GetBool("json"); jsonOutput {
+		return outputJSON
+	}
+	// Read from the root so commands with their own --output, such as export, keep the default
+	if format, err := cmd.Root().PersistentFlags().GetString("output"); err == nil && format != "" {
+		return format
+	}
+	return outputTable
+}
+
+// textOutput reports whether the human-readable output is requested
+func textOutput(cmd *cobra.Command) bool {
+	return outputFormat(cmd) == outputTable
+}
+
+// validateOutputFormat checks an --output value
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputYAML, outputCSV:
+		return nil
+	}
+	return database.NewValidationError(fmt.Sprintf("unsupported output format %q, expected table, json, yaml or csv", format), nil).
+		WithContext("output", format)
+}
+
+// writeDocument writes v as indented JSON or as YAML with the same keys
+func writeDocument(w io.Writer, v interface{}, format string) error {
+	var (
+		data []byte
+		err  error
+	)
+	if format == outputYAML {
+		data, err = database.MarshalYAML(v)
+	} else {
+		data, err = json.MarshalIndent(v, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// writeCSV writes the data of handleSuccess as CSV: a row per element of its list, or a
+// single row when it holds no list or several
+func writeCSV(w io.Writer, data map[string]interface{}) error {
+	if data == nil {
+		return nil
+	}
+
+	var (
+		list  interface{}
+		name  string
+		lists int
+	)
+	for key, value := range data {
+		if kind := reflect.ValueOf(value).Kind(); kind == reflect.Slice || kind == reflect.Array {
+			list, name = value, key
+			lists++
+		}
+	}
+	if lists != 1 {
+		list, name = []interface{}{data}, ""
+	}
+
+	columns, records, err := tabulate(list, name)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	fields := make([]string, len(columns))
+	for _, record := range records {
+		for i, column := range columns {
+			fields[i] = record[column]
+		}
+		if err := cw.Write(fields); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// maxCellWidth is the width past which printRows truncates values, unless --wide is set
+const maxCellWidth = 40
+
+// printRows prints the elements of a list as an aligned table with the given JSON fields as
+// columns, all fields if none are given. Missing fields show as NULL, byte counts in fields
+// named *_bytes with a unit, and long values are truncated on commands with a --wide flag
+// unless it is set.
+func printRows(cmd *cobra.Command, list interface{}, columns ...string) {
+	all, records, err := tabulate(list, "value")
+	if err != nil {
+		handleError(cmd, database.NewDBError(database.ErrCodeInternal, "failed to format the output", err), "output")
+		return
+	}
+	if len(columns) == 0 {
+		columns = all
+	}
+	wide, err := cmd.Flags().GetBool("wide")
+	truncate := err == nil && !wide
+
+	result := &database.QueryResult{Columns: columns, Rows: make([][]interface{}, len(records))}
+	for i, record := range records {
+		result.Rows[i] = make([]interface{}, len(columns))
+		for j, column := range columns {
+			value, ok := record[column]
+			if !ok {
+				continue
+			}
+			if strings.HasSuffix(column, "_bytes") {
+				if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+					value = formatBytes(n)
+				}
+			}
+			if truncate {
+				value = truncateCell(value, maxCellWidth)
+			}
+			result.Rows[i][j] = value
+		}
+	}
+	if err := result.Encode(cmd.OutOrStdout(), database.QueryFormatTable); err != nil {
+		handleError(cmd, err, "output")
+	}
+}
+
+// truncateCell shortens value to width characters, ending with an ellipsis if cut
+func truncateCell(value string, width int) string {
+	runes := []rune(value)
+	if len(runes) <= width {
+		return value
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// validateColumns checks that columns, as given to --columns, are JSON fields of the
+// elements of list, or dotted fields of their nested objects
+func validateColumns(list interface{}, columns []string) error {
+	elem := reflect.TypeOf(list).Elem()
+	fields := map[string]bool{}
+	collectJSONFields(elem, fields)
+
+	for _, column := range columns {
+		if !fields[strings.SplitN(column, ".", 2)[0]] {
+			names := make([]string, 0, len(fields))
+			for name := range fields {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return database.NewValidationError(fmt.Sprintf("unknown column %q, expected one of %s", column, strings.Join(names, ", ")), nil).
+				WithContext("column", column)
+		}
+	}
+	return nil
+}
+
+// collectJSONFields adds the JSON names of the fields of struct type t to fields, those of
+// embedded structs included
+func collectJSONFields(t reflect.Type, fields map[string]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case name == "-" || !field.IsExported() && !field.Anonymous:
+		case field.Anonymous && name == "":
+			collectJSONFields(field.Type, fields)
+		case name != "":
+			fields[name] = true
+		default:
+			fields[field.Name] = true
+		}
+	}
+}
+
+// tabulate flattens the elements of a list into records keyed by column, with the columns
+// in the order they first appear. Objects get a column per field, named by their JSON keys
+// and joined with dots for nested objects, e.g. connection.host; nested lists are kept as
+// JSON. Elements that are not objects go in the column called name.
+func tabulate(list interface{}, name string) ([]string, []map[string]string, error) {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, nil, err
+	}
+	// JSON is valid YAML; decoding it into a node keeps the field order
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, err
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.SequenceNode {
+		return nil, nil, nil
+	}
+
+	var (
+		columns []string
+		records []map[string]string
+		seen    = map[string]bool{}
+	)
+	for _, element := range document.Content[0].Content {
+		record := map[string]string{}
+		prefix := ""
+		if element.Kind != yaml.MappingNode {
+			prefix = name
+		}
+		if err := flatten(element, prefix, record); err != nil {
+			return nil, nil, err
+		}
+		for _, column := range recordColumns(element, prefix) {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+		records = append(records, record)
+	}
+	return columns, records, nil
+}
+
+// flatten stores the scalars of node in record under column, or under dotted columns for
+// the fields of objects. null values are left out.
+func flatten(node *yaml.Node, column string, record map[string]string) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := flatten(node.Content[i+1], joinColumn(column, node.Content[i].Value), record); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		record[column] = string(data)
+	default:
+		if node.Tag != "!!null" {
+			record[column] = node.Value
+		}
+	}
+	return nil
+}
+
+// recordColumns lists the columns flatten derives from node, including those of null values
+func recordColumns(node *yaml.Node, column string) []string {
+	if node.Kind != yaml.MappingNode {
+		return []string{column}
+	}
+	var columns []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		columns = append(columns, recordColumns(node.Content[i+1], joinColumn(column, node.Content[i].Value))...)
+	}
+	return columns
+}
+
+func joinColumn(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
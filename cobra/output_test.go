@@ -0,0 +1,148 @@
+package cobra
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/b87/db-kit/database"
+)
+
+// newOutputCommand returns a child of a root with the --output flag, like the commands of DBCmd
+func newOutputCommand(t *testing.T, args ...string) (*cobra.Command, *bytes.Buffer) {
+	root := &cobra.Command{Use: "db"}
+	root.PersistentFlags().StringP("output", "o", outputTable, "")
+	cmd := &cobra.Command{Use: "child"}
+	addErrorFlags(cmd)
+	root.AddCommand(cmd)
+
+	require.NoError(t, cmd.ParseFlags(args))
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	return cmd, &buf
+}
+
+func TestOutputFormat(t *testing.T) {
+	cmd, _ := newOutputCommand(t)
+	assert.Equal(t, outputTable, outputFormat(cmd))
+	assert.True(t, textOutput(cmd))
+
+	cmd, _ = newOutputCommand(t, "-o", "yaml")
+	assert.Equal(t, outputYAML, outputFormat(cmd))
+	assert.False(t, textOutput(cmd))
+
+	// --json is an alias of --output json
+	cmd, _ = newOutputCommand(t, "--json")
+	assert.Equal(t, outputJSON, outputFormat(cmd))
+
+	// Commands without the root flag fall back to tables
+	assert.Equal(t, outputTable, outputFormat(&cobra.Command{}))
+
+	assert.NoError(t, validateOutputFormat(outputCSV))
+	err := validateOutputFormat("xml")
+	require.Error(t, err)
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+}
+
+func TestHandleSuccessFormats(t *testing.T) {
+	data := map[string]interface{}{
+		"schema": "public",
+		"tables": []database.TableInfo{
+			{Schema: "public", Name: "users", Type: "BASE TABLE"},
+			{Schema: "public", Name: "orders", Type: "BASE TABLE", Indexes: []database.IndexInfo{{Name: "orders_pkey"}}},
+		},
+	}
+
+	cmd, buf := newOutputCommand(t, "--output", "yaml")
+	handleSuccess(cmd, "Tables retrieved successfully", data)
+	assert.Contains(t, buf.String(), "message: Tables retrieved successfully\n")
+	assert.Contains(t, buf.String(), "    - name: users\n      schema: public\n      type: BASE TABLE\n")
+
+	cmd, buf = newOutputCommand(t, "--output", "csv")
+	handleSuccess(cmd, "Tables retrieved successfully", data)
+	assert.Equal(t, `name,schema,type,indexes
+users,public,BASE TABLE,
+orders,public,BASE TABLE,"[{""columns"":null,""index_type"":"""",""is_primary"":false,""is_unique"":false,""name"":""orders_pkey"",""table_name"":""""}]"
+`, buf.String())
+
+	cmd, buf = newOutputCommand(t)
+	handleSuccess(cmd, "Tables retrieved successfully", data)
+	assert.Equal(t, "Tables retrieved successfully\n", buf.String())
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Run("single row", func(t *testing.T) {
+		var buf bytes.Buffer
+		status := &DatabaseStatus{}
+		status.Connection.Host = "localhost"
+		status.Connection.Port = 5432
+		require.NoError(t, writeCSV(&buf, map[string]interface{}{"status": status}))
+		assert.Contains(t, buf.String(), "status.connection.host,status.connection.port,")
+		assert.Contains(t, buf.String(), "\nlocalhost,5432,")
+	})
+
+	t.Run("list of values", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeCSV(&buf, map[string]interface{}{"schemas": []string{"public", "audit"}}))
+		assert.Equal(t, "schemas\npublic\naudit\n", buf.String())
+	})
+
+	t.Run("no data", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, writeCSV(&buf, nil))
+		require.NoError(t, writeCSV(&buf, map[string]interface{}{"tables": []database.TableInfo{}}))
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestPrintRows(t *testing.T) {
+	cmd, buf := newOutputCommand(t)
+	defaultValue := "now()"
+	printRows(cmd, []database.ColumnInfo{
+		{Name: "id", DataType: "integer", IsPrimaryKey: true},
+		{Name: "created_at", DataType: "timestamp", IsNullable: true, DefaultValue: &defaultValue},
+	}, "name", "data_type", "default_value")
+
+	assert.Equal(t, `name        data_type  default_value
+----        ---------  -------------
+id          integer    NULL
+created_at  timestamp  now()
+`, buf.String())
+}
+
+func TestPrintRowsHumanized(t *testing.T) {
+	total := int64(3 << 20)
+	defaultValue := "nextval('billing.invoice_line_items_id_seq'::regclass)"
+	rows := []tableListing{{TableInfo: database.TableInfo{Schema: "public", Name: "users", Comment: &defaultValue}, TotalBytes: &total}, {}}
+
+	cmd, buf := newOutputCommand(t)
+	cmd.Flags().Bool("wide", false, "")
+	printRows(cmd, rows, "name", "total_bytes", "comment")
+	assert.Equal(t, `name   total_bytes  comment
+----   -----------  -------
+users  3.0 MiB      nextval('billing.invoice_line_items_id_…
+       NULL         NULL
+`, buf.String())
+
+	// Without truncation on --wide, and on commands without the flag
+	require.NoError(t, cmd.Flags().Set("wide", "true"))
+	buf.Reset()
+	printRows(cmd, rows[:1], "comment")
+	assert.Contains(t, buf.String(), defaultValue)
+
+	cmd, buf = newOutputCommand(t)
+	printRows(cmd, rows[:1], "comment")
+	assert.Contains(t, buf.String(), defaultValue)
+}
+
+func TestValidateColumns(t *testing.T) {
+	assert.NoError(t, validateColumns([]tableListing{}, []string{"schema", "name", "total_bytes", "partitioning.strategy"}))
+	assert.NoError(t, validateColumns([]database.ColumnInfo{}, []string{"name", "default_value"}))
+
+	err := validateColumns([]database.IndexInfo{}, []string{"name", "size"})
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+	assert.Contains(t, err.Error(), "columns, index_type, is_primary, is_unique, name, table_name")
+}
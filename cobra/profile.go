@@ -0,0 +1,218 @@
+package cobra
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/b87/db-kit/database"
+)
+
+// configFileName is the config file read from the home directory without --config
+const configFileName = ".dbkit.yaml"
+
+// cliConfig is the config file of the CLI with named connection profiles, e.g.
+//
+//	default_profile: dev
+//	profiles:
+//	  dev:
+//	    host: localhost
+//	    database: app_dev
+//	  prod:
+//	    dsn: postgres://deploy@db.internal:5432/app?sslmode=require
+//	    production: true
+type cliConfig struct {
+	// DefaultProfile is used when no profile is selected with --profile or DBKIT_PROFILE
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]profile `yaml:"profiles"`
+}
+
+// profile holds the connection settings of a named profile. Unset fields keep the values
+// of the environment.
+type profile struct {
+	// DSN is a postgres:// URL applied before the other fields
+	DSN        string `yaml:"dsn"`
+	Host       string `yaml:"host"`
+	Port       int    `yaml:"port"`
+	User       string `yaml:"user"`
+	Password   string `yaml:"password"`
+	Database   string `yaml:"database"`
+	SSLMode    string `yaml:"sslmode"`
+	Migrations string `yaml:"migrations"`
+	Seeds      string `yaml:"seeds"`
+	Backups    string `yaml:"backups"`
+	// Production makes destructive commands ask for the profile name before they run
+	Production bool `yaml:"production"`
+}
+
+// apply returns config with the settings of the profile
+func (p profile) apply(config database.Config) (database.Config, error) {
+	if p.DSN != "" {
+		var err error
+		if config, err = database.ParseDSN(p.DSN, config); err != nil {
+			return config, err
+		}
+	}
+	if p.Host != "" {
+		config.Host = p.Host
+	}
+	if p.Port != 0 {
+		config.Port = p.Port
+	}
+	if p.User != "" {
+		config.User = p.User
+	}
+	if p.Password != "" {
+		config.Password = p.Password
+	}
+	if p.Database != "" {
+		config.DBName = p.Database
+	}
+	if p.SSLMode != "" {
+		config.SSLMode = p.SSLMode
+	}
+	if p.Migrations != "" {
+		config.MigrationsDir = p.Migrations
+	}
+	if p.Seeds != "" {
+		config.SeedsDir = p.Seeds
+	}
+	if p.Backups != "" {
+		config.BackupsDir = p.Backups
+	}
+	return config, nil
+}
+
+// defaultConfigFile is the .dbkit.yaml of the working directory, as written by init, or
+// else ~/.dbkit.yaml, empty if the home directory is unknown
+func defaultConfigFile() string {
+	if _, err := os.Stat(configFileName); err == nil {
+		return configFileName
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, configFileName)
+}
+
+// loadCLIConfig reads the config file at path. A missing file is an empty config unless
+// required.
+func loadCLIConfig(path string, required bool) (*cliConfig, error) {
+	config := &cliConfig{}
+	if path == "" {
+		return config, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return config, nil
+	}
+	if err != nil {
+		return nil, database.NewConfigError("failed to read the config file", err).
+			WithContext("config", path)
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, database.NewConfigError("invalid config file", err).
+			WithContext("config", path)
+	}
+	return config, nil
+}
+
+// activeProfile returns the profile selected with --profile, DBKIT_PROFILE or the
+// default_profile of the config file, and nil if none is
+func activeProfile() (string, *profile, error) {
+	config, err := loadCLIConfig(*configFile, DBCmd.PersistentFlags().Changed("config"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	name := *profileName
+	if name == "" {
+		name = config.DefaultProfile
+	}
+	if name == "" {
+		return "", nil, nil
+	}
+
+	p, ok := config.Profiles[name]
+	if !ok {
+		return "", nil, database.NewConfigError(fmt.Sprintf("profile %q not found in %s", name, *configFile), nil).
+			WithContext("profile", name).
+			WithContext("config", *configFile)
+	}
+	return name, &p, nil
+}
+
+// profileConfig returns the connection settings of the named profile on top of the
+// environment, and false if the config file has no such profile
+func profileConfig(name string) (database.Config, bool, error) {
+	cli, err := loadCLIConfig(*configFile, DBCmd.PersistentFlags().Changed("config"))
+	if err != nil {
+		return database.Config{}, false, err
+	}
+	p, ok := cli.Profiles[name]
+	if !ok {
+		return database.Config{}, false, nil
+	}
+
+	config, err := database.DefaultConfig()
+	if err != nil {
+		return config, true, err
+	}
+	config, err = p.apply(applyConnectionFlags(config, false))
+	return config, true, err
+}
+
+// confirmProduction makes destructive commands on a profile marked production ask for the
+// profile name before they run, even with --yes. Without a terminal they need
+// --confirm-profile with the profile name. Reports whether the command may go on.
+func confirmProduction(cmd *cobra.Command, action string) bool {
+	name, p, err := activeProfile()
+	if err != nil {
+		handleError(cmd, err, "profile")
+		return false
+	}
+	return confirmProfile(cmd, name, p, action)
+}
+
+// confirmProductionTarget is confirmProduction for a command acting on target, another
+// database given as a profile name, a URL or a database name. Only profiles can be marked
+// production.
+func confirmProductionTarget(cmd *cobra.Command, target, action string) bool {
+	cli, err := loadCLIConfig(*configFile, DBCmd.PersistentFlags().Changed("config"))
+	if err != nil {
+		handleError(cmd, err, "profile")
+		return false
+	}
+	p, ok := cli.Profiles[target]
+	if !ok {
+		return true
+	}
+	return confirmProfile(cmd, target, &p, action)
+}
+
+// confirmProfile asks for the name of profile p, if marked production, before action.
+// --confirm-profile with the same name confirms it without a prompt.
+func confirmProfile(cmd *cobra.Command, name string, p *profile, action string) bool {
+	if p == nil || !p.Production || *confirmedProfile == name {
+		return true
+	}
+
+	if isInteractive(cmd) {
+		cmd.Printf("Profile %s is marked as production. Type its name to %s: ", name, action)
+		answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		if strings.TrimSpace(answer) == name {
+			return true
+		}
+	}
+	handleError(cmd, database.NewValidationError(fmt.Sprintf("%s on production profile %s not confirmed, re-run with --confirm-profile %s to run without a prompt", action, name, name), nil).
+		WithContext("profile", name), "confirm_production")
+	return false
+}
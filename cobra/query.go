@@ -0,0 +1,104 @@
+package cobra
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queryLimit   = new(int)
+	queryTimeout = new(time.Duration)
+)
+
+func init() {
+	DBCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().IntVar(queryLimit, "limit", 1000, "Maximum number of rows to show, 0 for all")
+	queryCmd.Flags().DurationVar(queryTimeout, "timeout", 30*time.Second, "Cancel the statement after this long")
+
+	addErrorFlags(queryCmd)
+}
+
+var queryCmd = &cobra.Command{
+	Use:   "query [sql]",
+	Short: "Run an ad-hoc SQL statement and show its rows",
+	Long: `Run a SQL statement with the configured connection and show the rows it returns as an
+aligned table, JSON, YAML or CSV as chosen with --output. Without an argument the
+statement is read from stdin:
+
+  db query "SELECT id, email FROM users LIMIT 5"
+  echo "SELECT count(*) FROM orders" | db query --output csv
+
+The statement runs as given, in its own transaction, so statements that modify data take
+effect. In formats other than table the rows are written to stdout as a list, without a
+summary line.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		statement, err := readStatement(cmd, args)
+		if err != nil {
+			handleError(cmd, err, "query")
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, *queryTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		result, err := db.Query(ctx, statement, database.QueryOptions{MaxRows: *queryLimit})
+		if err != nil {
+			handleError(cmd, err, "query")
+			return
+		}
+
+		format := outputFormat(cmd)
+		if err := result.Encode(cmd.OutOrStdout(), format); err != nil {
+			handleError(cmd, err, "query")
+			return
+		}
+		if format != outputTable {
+			if result.Truncated {
+				cmd.PrintErrf("Showing the first %d rows, use --limit to show more\n", len(result.Rows))
+			}
+			return
+		}
+		cmd.Println(queryMessage(result))
+	},
+}
+
+// readStatement returns the statement given as argument, or read from stdin without one
+func readStatement(cmd *cobra.Command, args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	if isInteractive(cmd) {
+		return "", database.NewValidationError("no statement given: pass it as argument or on stdin", nil)
+	}
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return "", database.NewDBError(database.ErrCodeInternal, "failed to read the statement from stdin", err)
+	}
+	return string(data), nil
+}
+
+// queryMessage summarizes a result, e.g. "(3 rows in 12ms)"
+func queryMessage(result *database.QueryResult) string {
+	rows := fmt.Sprintf("%d rows", len(result.Rows))
+	if len(result.Rows) == 1 {
+		rows = "1 row"
+	}
+	duration := result.Duration.Round(time.Millisecond)
+	if result.Truncated {
+		return fmt.Sprintf("(first %s in %s, use --limit to show more)", rows, duration)
+	}
+	return fmt.Sprintf("(%s in %s)", rows, duration)
+}
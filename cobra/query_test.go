@@ -0,0 +1,44 @@
+package cobra
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCommand(t *testing.T) {
+	assert.Equal(t, "query [sql]", queryCmd.Use)
+	assert.Equal(t, "1000", queryCmd.Flags().Lookup("limit").DefValue)
+	assert.Equal(t, "30s", queryCmd.Flags().Lookup("timeout").DefValue)
+
+	assert.NoError(t, queryCmd.Args(queryCmd, []string{}))
+	assert.NoError(t, queryCmd.Args(queryCmd, []string{"SELECT 1"}))
+	assert.Error(t, queryCmd.Args(queryCmd, []string{"SELECT 1", "extra"}))
+}
+
+func TestReadStatement(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader("SELECT 2\n"))
+
+	statement, err := readStatement(cmd, []string{"SELECT 1"})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1", statement)
+
+	statement, err = readStatement(cmd, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 2\n", statement)
+}
+
+func TestQueryMessage(t *testing.T) {
+	assert.Equal(t, "(1 row in 12ms)", queryMessage(&database.QueryResult{
+		Rows: [][]interface{}{{int64(1)}}, Duration: 12 * time.Millisecond,
+	}))
+	assert.Equal(t, "(first 2 rows in 1.5s, use --limit to show more)", queryMessage(&database.QueryResult{
+		Rows: [][]interface{}{{int64(1)}, {int64(2)}}, Truncated: true, Duration: 1500 * time.Millisecond,
+	}))
+}
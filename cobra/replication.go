@@ -0,0 +1,128 @@
+package cobra
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	DBCmd.AddCommand(replicationCmd)
+
+	addErrorFlags(replicationCmd)
+}
+
+var replicationCmd = &cobra.Command{
+	Use:   "replication",
+	Short: "Show publications, subscriptions and replication slots",
+	Long: `Show the logical replication publications and subscriptions of the database and the
+replication slots of the server with the WAL they retain. Inactive slots retain WAL
+until they are dropped and can fill the disk.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		introspection := db.Introspection()
+		publications, err := introspection.GetPublications(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_publications")
+			return
+		}
+		subscriptions, err := introspection.GetSubscriptions(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_subscriptions")
+			return
+		}
+		slots, err := introspection.GetReplicationSlots(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_replication_slots")
+			return
+		}
+
+		if textOutput(cmd) {
+			printReplication(cmd, publications, subscriptions, slots)
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("%d publications, %d subscriptions, %d replication slots",
+			len(publications), len(subscriptions), len(slots)), map[string]interface{}{
+			"publications":  publications,
+			"subscriptions": subscriptions,
+			"slots":         slots,
+		})
+	},
+}
+
+func printReplication(cmd *cobra.Command, publications []database.PublicationInfo, subscriptions []database.SubscriptionInfo, slots []database.ReplicationSlot) {
+	if len(publications) > 0 {
+		cmd.Println("Publications:")
+		for _, p := range publications {
+			tables := strings.Join(p.Tables, ", ")
+			if p.AllTables {
+				tables = "all tables"
+			}
+			cmd.Printf("  %s (%s): %s\n", p.Name, publishedOperations(p), tables)
+		}
+	}
+
+	if len(subscriptions) > 0 {
+		cmd.Println("Subscriptions:")
+		for _, s := range subscriptions {
+			status := "disabled"
+			if s.Enabled {
+				status = "enabled, worker not running"
+				if s.WorkerPID != nil {
+					status = fmt.Sprintf("enabled, worker %d", *s.WorkerPID)
+				}
+			}
+			cmd.Printf("  %s (%s) from %s", s.Name, status, strings.Join(s.Publications, ", "))
+			if s.LastMessageAt != nil {
+				cmd.Printf(", last message %s ago", time.Since(*s.LastMessageAt).Round(time.Second))
+			}
+			cmd.Println()
+		}
+	}
+
+	if len(slots) > 0 {
+		cmd.Println("Replication slots:")
+		cmd.Printf("  %-30s  %-8s  %-8s  %-10s  %10s  %10s\n", "NAME", "TYPE", "ACTIVE", "WAL STATUS", "RETAINED", "LAG")
+		for _, s := range slots {
+			cmd.Printf("  %-30s  %-8s  %-8t  %-10s  %10s  %10s\n", s.Name, s.Type, s.Active, valueOr(s.WALStatus, "-"),
+				optionalBytes(s.RetainedWALBytes), optionalBytes(s.LagBytes))
+		}
+	}
+}
+
+// publishedOperations lists the operations a publication publishes, e.g. "insert, update"
+func publishedOperations(p database.PublicationInfo) string {
+	var operations []string
+	for _, op := range []struct {
+		name      string
+		published bool
+	}{{"insert", p.Insert}, {"update", p.Update}, {"delete", p.Delete}, {"truncate", p.Truncate}} {
+		if op.published {
+			operations = append(operations, op.name)
+		}
+	}
+	if len(operations) == 0 {
+		return "nothing"
+	}
+	return strings.Join(operations, ", ")
+}
+
+func optionalBytes(bytes *int64) string {
+	if bytes == nil {
+		return "-"
+	}
+	return formatBytes(*bytes)
+}
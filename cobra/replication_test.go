@@ -0,0 +1,49 @@
+package cobra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicationCommand(t *testing.T) {
+	assert.Equal(t, "replication", replicationCmd.Use)
+	assert.NotNil(t, replicationCmd.Flags().Lookup("json"))
+	assert.Error(t, replicationCmd.Args(replicationCmd, []string{"extra"}))
+}
+
+func TestPrintReplication(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printReplication(cmd, nil, nil, nil)
+	assert.Empty(t, out.String())
+
+	pid, status := int64(812), "extended"
+	retained, lag := int64(3<<30), int64(512<<20)
+	printReplication(cmd,
+		[]database.PublicationInfo{
+			{Name: "orders_pub", Insert: true, Update: true, Tables: []string{"public.orders", "public.order_items"}},
+			{Name: "everything", AllTables: true, Insert: true, Update: true, Delete: true, Truncate: true},
+		},
+		[]database.SubscriptionInfo{{Name: "orders_sub", Enabled: true, WorkerPID: &pid, Publications: []string{"orders_pub"}}},
+		[]database.ReplicationSlot{
+			{Name: "orders_slot", Type: "logical", WALStatus: &status, RetainedWALBytes: &retained, LagBytes: &lag},
+			{Name: "standby", Type: "physical", Active: true},
+		})
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 9)
+	assert.Equal(t, "  orders_pub (insert, update): public.orders, public.order_items", lines[1])
+	assert.Equal(t, "  everything (insert, update, delete, truncate): all tables", lines[2])
+	assert.Equal(t, "  orders_sub (enabled, worker 812) from orders_pub", lines[4])
+	assert.Contains(t, lines[7], "extended")
+	assert.Contains(t, lines[7], "3.0 GiB")
+	assert.Contains(t, lines[7], "512.0 MiB")
+	assert.Contains(t, lines[8], "true")
+}
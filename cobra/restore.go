@@ -0,0 +1,125 @@
+package cobra
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	targetTime     = new(string)
+	baseBackup     = new(string)
+	dataDir        = new(string)
+	walArchiveDir  = new(string)
+	restoreCommand = new(string)
+	targetAction   = new(string)
+)
+
+func init() {
+	DBCmd.AddCommand(restoreCmd)
+	backupCmd.AddCommand(baseBackupCmd)
+
+	restoreCmd.Flags().StringVar(targetTime, "target-time", "", "Recover to this time (RFC 3339 or \"2006-01-02 15:04:05\" in local time)")
+	restoreCmd.Flags().StringVar(baseBackup, "base-backup", "", "pg_basebackup directory to start from")
+	restoreCmd.Flags().StringVar(dataDir, "data-dir", "", "New, empty data directory to recover into")
+	restoreCmd.Flags().StringVar(walArchiveDir, "wal-archive", os.Getenv("WAL_ARCHIVE_DIR"), "Directory WAL segments are archived to")
+	restoreCmd.Flags().StringVar(restoreCommand, "restore-command", "", "restore_command to fetch WAL segments, instead of --wal-archive")
+	restoreCmd.Flags().StringVar(targetAction, "target-action", database.RecoveryActionPromote, "Action once the target is reached: promote, pause or shutdown")
+	_ = restoreCmd.MarkFlagRequired("target-time")
+	_ = restoreCmd.MarkFlagRequired("base-backup")
+	_ = restoreCmd.MarkFlagRequired("data-dir")
+
+	addErrorFlags(restoreCmd)
+	addErrorFlags(baseBackupCmd)
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Prepare a point-in-time recovery from a base backup and archived WAL",
+	Long: `Restore a base backup into a new data directory and configure PostgreSQL to replay
+archived WAL up to --target-time. PostgreSQL is not started; the remaining steps
+are printed. Run this on the host that will run the recovered server, e.g.
+
+  db restore --target-time "2025-01-02 15:04:05" \
+    --base-backup ./backups/basebackup_20250102_030000 \
+    --data-dir /var/lib/postgresql/17/recovered --wal-archive /mnt/wal`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		target, err := parseTargetTime(*targetTime)
+		if err != nil {
+			handleError(cmd, database.NewValidationError("invalid --target-time", err), "prepare_recovery")
+			return
+		}
+
+		// Copying the base backup is bounded by disk speed
+		ctx, cancel := commandContext(cmd, 2*time.Hour)
+		defer cancel()
+
+		plan, err := database.PrepareRecovery(ctx, database.RecoveryOptions{
+			BaseBackup:     *baseBackup,
+			DataDir:        *dataDir,
+			WALArchiveDir:  *walArchiveDir,
+			RestoreCommand: *restoreCommand,
+			TargetTime:     target,
+			TargetAction:   *targetAction,
+		})
+		if err != nil {
+			handleError(cmd, err, "prepare_recovery")
+			return
+		}
+
+		if textOutput(cmd) {
+			cmd.Printf("Recovery settings added to %s/postgresql.auto.conf:\n\n%s\nNext steps:\n", plan.DataDir, plan.Settings)
+			for i, step := range plan.Steps {
+				cmd.Printf("  %d. %s\n", i+1, step)
+			}
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Data directory %s prepared for recovery", plan.DataDir), map[string]interface{}{
+			"data_dir": plan.DataDir,
+			"settings": plan.Settings,
+			"steps":    plan.Steps,
+		})
+	},
+}
+
+// parseTargetTime accepts RFC 3339 times and local times without a zone
+func parseTargetTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+}
+
+var baseBackupCmd = &cobra.Command{
+	Use:   "base [dir]",
+	Short: "Take a physical base backup of the cluster for point-in-time recovery",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 2*time.Hour)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		dir := ""
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		path, err := db.BaseBackup(ctx, dir)
+		if err != nil {
+			handleError(cmd, err, "base_backup")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("Base backup created at %s", path), map[string]interface{}{
+			"path": path,
+		})
+	},
+}
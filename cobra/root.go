@@ -2,8 +2,11 @@
 package cobra
 
 import (
+	"context"
 	"os"
+	osuser "os/user"
 	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -11,31 +14,140 @@ import (
 )
 
 var (
-	host       *string
-	port       *int
-	user       *string
-	password   *string
-	db         *string
-	migrations *string
-	backups    *string
+	host        *string
+	port        *int
+	user        *string
+	password    *string
+	db          *string
+	migrations  *string
+	seeds       *string
+	backups     *string
+	output      *string
+	configFile  *string
+	profileName *string
+	// confirmedProfile confirms destructive commands on this production profile without a prompt
+	confirmedProfile *string
+	logLevel         *string
+	logFormat        *string
+	timeout          *time.Duration
 )
 
+// newDB connects with the settings of newConfig
 func newDB() (*database.DB, error) {
-	return database.NewDefault()
+	config, err := newConfig()
+	if err != nil {
+		return nil, err
+	}
+	return database.New(config)
+}
+
+// newConfig returns the connection settings of the root flags given on the command line,
+// the selected profile and the environment, in this order of precedence
+func newConfig() (database.Config, error) {
+	config, err := database.DefaultConfig()
+	if err != nil {
+		return config, err
+	}
+	_, p, err := activeProfile()
+	if err != nil {
+		return config, err
+	}
+
+	config.Logger = cliLogger
+
+	// The flag defaults hold the environment values, or the CLI defaults where unset
+	config = applyConnectionFlags(config, false)
+	if p != nil {
+		if config, err = p.apply(config); err != nil {
+			return config, err
+		}
+	}
+	return applyConnectionFlags(config, true), nil
+}
+
+// applyConnectionFlags returns config with the values of the root flags, or only of those
+// given on the command line if changedOnly is set
+func applyConnectionFlags(config database.Config, changedOnly bool) database.Config {
+	flags := DBCmd.PersistentFlags()
+	set := func(name string) bool {
+		return !changedOnly || flags.Changed(name)
+	}
+
+	if set("host") {
+		config.Host = *host
+	}
+	if set("port") {
+		config.Port = *port
+	}
+	if set("user") {
+		config.User = *user
+	}
+	if set("password") {
+		config.Password = *password
+	}
+	if set("db") {
+		config.DBName = *db
+	}
+	if set("migrations") {
+		config.MigrationsDir = *migrations
+	}
+	if set("seeds") {
+		config.SeedsDir = *seeds
+	}
+	if set("backups") {
+		config.BackupsDir = *backups
+	}
+	return config
 }
 
 // DBCmd is the root command for the db-kit CLI
 var DBCmd = &cobra.Command{
 	Use: "db",
-	Run: func(cmd *cobra.Command, _ []string) {
-		err := cmd.Help()
+	// Errors are reported by handleError in the format of --output
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if err := validateOutputFormat(outputFormat(cmd)); err != nil {
+			handleError(cmd, err, "output")
+			return commandError(cmd)
+		}
+		if err := validateTimeout(cmd); err != nil {
+			handleError(cmd, err, "timeout")
+			return commandError(cmd)
+		}
+		logger, err := newLogger(cmd.ErrOrStderr(), *logLevel, *logFormat)
 		if err != nil {
-			cmd.PrintErrln(err)
-			os.Exit(1)
+			handleError(cmd, err, "logging")
+			return commandError(cmd)
+		}
+		cliLogger = logger
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		cmd.SetContext(database.WithActor(ctx, cliActor()))
+		return nil
+	},
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			handleError(cmd, err, "help")
 		}
 	},
 }
 
+// cliActor returns the actor recorded in audit entries: DBKIT_ACTOR, else the user
+// running the CLI
+func cliActor() string {
+	if actor := os.Getenv("DBKIT_ACTOR"); actor != "" {
+		return actor
+	}
+	if current, err := osuser.Current(); err == nil {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
 // envOrDefault returns the environment variable value or the default if not set
 func envOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -61,6 +173,7 @@ func init() {
 	defaultPassword := envOrDefault("POSTGRES_PASSWORD", "postgres")
 	defaultDB := envOrDefault("POSTGRES_DB", "dbkit")
 	defaultMigrations := envOrDefault("MIGRATIONS_DIR", "./tmp/migrations")
+	defaultSeeds := envOrDefault("SEEDS_DIR", "./tmp/seeds")
 	defaultBackups := envOrDefault("BACKUPS_DIR", "./tmp/backups")
 
 	host = DBCmd.PersistentFlags().String("host", defaultHost, "postgres host")
@@ -69,14 +182,20 @@ func init() {
 	password = DBCmd.PersistentFlags().String("password", defaultPassword, "postgres password")
 	db = DBCmd.PersistentFlags().String("db", defaultDB, "postgres database")
 	migrations = DBCmd.PersistentFlags().String("migrations", defaultMigrations, "directory to store migrations")
-	backups = DBCmd.PersistentFlags().String("backups", defaultBackups, "directory to store backups")
+	seeds = DBCmd.PersistentFlags().String("seeds", defaultSeeds, "directory to store seeds")
+	backups = DBCmd.PersistentFlags().String("backups", defaultBackups, "directory or storage URL (s3://, gs://, azblob://) to store backups")
+	configFile = DBCmd.PersistentFlags().String("config", defaultConfigFile(), "config file with connection profiles")
+	profileName = DBCmd.PersistentFlags().String("profile", envOrDefault("DBKIT_PROFILE", ""), "connection profile of the config file to use")
+	confirmedProfile = DBCmd.PersistentFlags().String("confirm-profile", "", "name of the production profile destructive commands may run on without a prompt")
+	output = DBCmd.PersistentFlags().StringP("output", "o", outputTable, "output format: table, json, yaml or csv; --json is the same as --output json")
+	logLevel = DBCmd.PersistentFlags().String("log-level", envOrDefault("POSTGRES_LOG_LEVEL", "info"), "minimum level of the logs on stderr: debug, info, warn or error")
+	timeout = DBCmd.PersistentFlags().Duration("timeout", 0, "cancel the command after this long, 0 for no limit; DBKIT_TIMEOUT by default, else each command's own limit")
+	logFormat = DBCmd.PersistentFlags().String("log-format", envOrDefault("DBKIT_LOG_FORMAT", logFormatText), "format of the logs on stderr: text or json; json also logs the status messages of commands")
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
-// This is called by main.main(). It only needs to happen once to the rootCmd.
+// This is called by main.main(). It only needs to happen once to the rootCmd. The process
+// exits with the exit code of the outcome, see ExitCode.
 func Execute() {
-	err := DBCmd.Execute()
-	if err != nil {
-		os.Exit(1)
-	}
+	os.Exit(ExitCode(ExecuteArgs(context.Background(), os.Args[1:])))
 }
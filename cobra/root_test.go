@@ -0,0 +1,43 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/b87/db-kit/database"
+)
+
+// setRootFlag sets a root flag as if given on the command line for the test
+func setRootFlag(t *testing.T, name, value string) {
+	flag := DBCmd.PersistentFlags().Lookup(name)
+	previous := flag.Value.String()
+	require.NoError(t, DBCmd.PersistentFlags().Set(name, value))
+	t.Cleanup(func() {
+		_ = flag.Value.Set(previous)
+		flag.Changed = false
+	})
+}
+
+func TestApplyConnectionFlags(t *testing.T) {
+	base := database.Config{Host: "db.internal", Port: 6543, DBName: "app", MigrationsDir: "./migrations"}
+
+	// Without flags on the command line only the defaults apply
+	assert.Equal(t, base, applyConnectionFlags(base, true))
+	config := applyConnectionFlags(base, false)
+	assert.Equal(t, *host, config.Host)
+	assert.Equal(t, *port, config.Port)
+	assert.Equal(t, *db, config.DBName)
+
+	setRootFlag(t, "host", "other-host")
+	setRootFlag(t, "port", "6432")
+	setRootFlag(t, "backups", "s3://bucket/app")
+
+	config = applyConnectionFlags(base, true)
+	assert.Equal(t, "other-host", config.Host)
+	assert.Equal(t, 6432, config.Port)
+	assert.Equal(t, "s3://bucket/app", config.BackupsDir)
+	assert.Equal(t, "app", config.DBName)
+	assert.Equal(t, "./migrations", config.MigrationsDir)
+}
@@ -0,0 +1,165 @@
+package cobra
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	seedRunDir    = new(string)
+	seedRunEnv    = new(string)
+	seedRunDryRun = new(bool)
+)
+
+func init() {
+	DBCmd.AddCommand(seedCmd)
+	seedCmd.AddCommand(seedUpCmd)
+	seedCmd.AddCommand(seedDownCmd)
+	seedCmd.AddCommand(seedRunCmd)
+
+	seedRunCmd.Flags().StringVar(seedRunDir, "dir", "", "Directory of the seed sets, the seeds directory by default")
+	seedRunCmd.Flags().StringVar(seedRunEnv, "env", "", "Environment whose seed set is applied after the common set, e.g. dev")
+	seedRunCmd.Flags().BoolVar(seedRunDryRun, "dry-run", false, "Show which seeds would be applied without applying them")
+
+	// Add error handling flags to all seed commands
+	addErrorFlags(seedCmd)
+	addErrorFlags(seedUpCmd)
+	addErrorFlags(seedDownCmd)
+	addErrorFlags(seedRunCmd)
+}
+
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Manage versioned seed data",
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			handleError(cmd, err, "help")
+		}
+	},
+}
+
+var seedUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending seeds",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if !confirmProduction(cmd, "apply seeds") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		err = db.Migrator.SeedUp(ctx)
+		if err != nil {
+			handleError(cmd, err, "seed_up")
+			return
+		}
+		handleSuccess(cmd, "Seeds applied successfully", map[string]interface{}{
+			"seeds_dir": db.Migrator.SeedsSource(),
+		})
+	},
+}
+
+var seedDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back the most recently applied seed",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if !confirmProduction(cmd, "roll back a seed") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		err = db.Migrator.SeedDown(ctx)
+		if err != nil {
+			handleError(cmd, err, "seed_down")
+			return
+		}
+		handleSuccess(cmd, "Seed rolled back successfully", map[string]interface{}{
+			"seeds_dir": db.Migrator.SeedsSource(),
+		})
+	},
+}
+
+var seedRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Apply the seed sets of an environment",
+	Long: `Apply the seed files in the common directory of the seeds directory, then those in the
+directory of the environment given with --env. Seed files are SQL scripts (.sql) or fixtures
+(.yaml, .yml or .json) listing the rows to insert by table:
+
+  users:
+    - id: 1
+      email: admin@example.com
+
+Applied seeds are recorded in the dbkit_seeds table and skipped by later runs:
+
+  db seed run --env dev
+  db seed run --dir ./fixtures --env staging --dry-run`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if !*seedRunDryRun && !confirmProduction(cmd, "apply seeds") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, migrationTimeout)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		results, err := db.RunSeeds(ctx, database.SeedOptions{
+			Dir:    *seedRunDir,
+			Env:    *seedRunEnv,
+			DryRun: *seedRunDryRun,
+		})
+		if err != nil {
+			handleError(cmd, err, "seed_run")
+			return
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, results, "name", "status", "rows")
+		}
+
+		count := 0
+		for _, result := range results {
+			if result.Status == database.SeedApplied || result.Status == database.SeedPending {
+				count++
+			}
+		}
+		message := fmt.Sprintf("Applied %d seeds", count)
+		if *seedRunDryRun {
+			message = fmt.Sprintf("Would apply %d seeds", count)
+		}
+		handleSuccess(cmd, message, map[string]interface{}{
+			"seeds":   results,
+			"env":     *seedRunEnv,
+			"dry_run": *seedRunDryRun,
+		})
+	},
+}
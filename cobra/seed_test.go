@@ -0,0 +1,40 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedCommands(t *testing.T) {
+	t.Run("seed up command", func(t *testing.T) {
+		assert.Equal(t, "up", seedUpCmd.Use)
+		assert.NoError(t, seedUpCmd.Args(seedUpCmd, []string{}))
+		assert.Error(t, seedUpCmd.Args(seedUpCmd, []string{"extra"}))
+	})
+
+	t.Run("seed down command", func(t *testing.T) {
+		assert.Equal(t, "down", seedDownCmd.Use)
+		assert.NoError(t, seedDownCmd.Args(seedDownCmd, []string{}))
+		assert.Error(t, seedDownCmd.Args(seedDownCmd, []string{"extra"}))
+	})
+
+	t.Run("seed run command", func(t *testing.T) {
+		assert.Equal(t, "run", seedRunCmd.Use)
+		assert.NoError(t, seedRunCmd.Args(seedRunCmd, []string{}))
+		assert.Error(t, seedRunCmd.Args(seedRunCmd, []string{"extra"}))
+		for _, flag := range []string{"dir", "env", "dry-run"} {
+			assert.NotNil(t, seedRunCmd.Flags().Lookup(flag), "run should have --%s", flag)
+		}
+	})
+
+	t.Run("registered under root", func(t *testing.T) {
+		found := false
+		for _, cmd := range DBCmd.Commands() {
+			if cmd == seedCmd {
+				found = true
+			}
+		}
+		assert.True(t, found, "seed command should be registered on the root command")
+	})
+}
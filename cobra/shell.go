@@ -0,0 +1,436 @@
+package cobra
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/b87/db-kit/database"
+	"github.com/lib/pq"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	shellLimit   = new(int)
+	shellTimeout = new(time.Duration)
+	shellHistory = new(string)
+)
+
+func init() {
+	DBCmd.AddCommand(shellCmd)
+
+	shellCmd.Flags().IntVar(shellLimit, "limit", 1000, "Maximum number of rows to show per statement, 0 for all")
+	shellCmd.Flags().DurationVar(shellTimeout, "timeout", 5*time.Minute, "Cancel a statement after this long")
+	shellCmd.Flags().StringVar(shellHistory, "history", defaultHistoryFile(), "File keeping the statement history, empty to keep none")
+
+	addErrorFlags(shellCmd)
+}
+
+// shellHelp lists the meta commands of the shell
+const shellHelp = `Statements end with a semicolon and may span several lines.
+
+Meta commands:
+  \d <table>        describe a table, optionally schema-qualified
+  \dt [schema]      list tables
+  \dv [schema]      list views
+  \dn               list schemas
+  \dx               list extensions
+  \format <format>  show results as table, json, yaml or csv
+  \?                show this help
+  \q                quit
+`
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Start an interactive SQL shell",
+	Long: `Start an interactive SQL shell on the configured connection, without needing psql.
+Statements end with a semicolon and may span several lines; results are shown as by
+db query. Meta commands such as \dt and \d describe the schema, \? lists them all.
+
+Statements are remembered across sessions in the --history file. Without a terminal the
+statements are read from stdin, one after the other.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		sh := &shell{
+			db:      db,
+			out:     cmd.OutOrStdout(),
+			format:  outputFormat(cmd),
+			limit:   *shellLimit,
+			timeout: commandTimeout(cmd, *shellTimeout),
+		}
+
+		if !isInteractive(cmd) {
+			sh.run(&scannerLines{scanner: bufio.NewScanner(cmd.InOrStdin())})
+			return
+		}
+
+		stdin := cmd.InOrStdin().(*os.File)
+		state, err := term.MakeRaw(int(stdin.Fd()))
+		if err != nil {
+			handleError(cmd, database.NewDBError(database.ErrCodeInternal, "failed to set up the terminal", err), "shell")
+			return
+		}
+		defer func() { _ = term.Restore(int(stdin.Fd()), state) }()
+
+		terminal := term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{stdin, cmd.OutOrStdout()}, "")
+		if width, height, err := term.GetSize(int(stdin.Fd())); err == nil {
+			_ = terminal.SetSize(width, height)
+		}
+		terminal.History = loadHistory(*shellHistory)
+
+		// The terminal translates newlines for raw mode
+		sh.out = terminal
+		fmt.Fprintf(sh.out, "Connected to %s. Type \\? for help, \\q to quit.\n", db.Config().DBName)
+		sh.run(terminal)
+	},
+}
+
+// lineReader reads the input of the shell line by line
+type lineReader interface {
+	ReadLine() (string, error)
+	SetPrompt(prompt string)
+}
+
+// scannerLines reads lines from a non-interactive input, without prompts
+type scannerLines struct {
+	scanner *bufio.Scanner
+}
+
+func (s *scannerLines) ReadLine() (string, error) {
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return s.scanner.Text(), nil
+}
+
+func (s *scannerLines) SetPrompt(string) {}
+
+// shell runs the statements and meta commands read from a lineReader
+type shell struct {
+	db      *database.DB
+	out     io.Writer
+	format  string
+	limit   int
+	timeout time.Duration
+	input   shellInput
+}
+
+// run reads until EOF or \q. An unfinished statement at EOF is discarded.
+func (s *shell) run(lines lineReader) {
+	for {
+		prompt := s.db.Config().DBName + "=> "
+		if s.input.pending() {
+			prompt = s.db.Config().DBName + "-> "
+		}
+		lines.SetPrompt(prompt)
+
+		line, err := lines.ReadLine()
+		if err != nil {
+			return
+		}
+
+		meta, statements := s.input.feed(line)
+		if meta != "" {
+			if quit := s.meta(meta); quit {
+				return
+			}
+			continue
+		}
+		for _, statement := range statements {
+			s.execute(statement.SQL)
+		}
+	}
+}
+
+// execute runs a statement and prints its rows or error
+func (s *shell) execute(statement string) {
+	ctx, cancel := withTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	result, err := s.db.Query(ctx, statement, database.QueryOptions{MaxRows: s.limit})
+	if err != nil {
+		s.printError(err)
+		return
+	}
+	if len(result.Columns) == 0 {
+		fmt.Fprintf(s.out, "OK (%s)\n", result.Duration.Round(time.Millisecond))
+		return
+	}
+	s.print(result)
+	if s.format == outputTable {
+		fmt.Fprintln(s.out, queryMessage(result))
+	}
+}
+
+// meta runs a meta command such as \dt and reports whether the shell should quit
+func (s *shell) meta(command string) bool {
+	fields := strings.Fields(command)
+	name, args := fields[0], fields[1:]
+
+	ctx, cancel := withTimeout(context.Background(), s.timeout)
+	defer cancel()
+	introspection := s.db.Introspection()
+
+	var (
+		result *database.QueryResult
+		err    error
+	)
+	switch name {
+	case `\q`:
+		return true
+	case `\?`:
+		fmt.Fprint(s.out, shellHelp)
+		return false
+	case `\format`:
+		if len(args) != 1 {
+			fmt.Fprintf(s.out, "Current format is %s, use \\format table, json, yaml or csv\n", s.format)
+			return false
+		}
+		if err := validateOutputFormat(args[0]); err != nil {
+			s.printError(err)
+			return false
+		}
+		s.format = args[0]
+		return false
+	case `\dt`:
+		var tables []database.TableInfo
+		tables, err = introspection.GetTablesWithOptions(ctx, database.IntrospectOptions{
+			Schema:           firstArg(args),
+			SkipColumns:      true,
+			SkipIndexes:      true,
+			SkipConstraints:  true,
+			SkipTriggers:     true,
+			SkipPartitioning: true,
+		})
+		result = tablesResult(tables)
+	case `\dv`:
+		var views []database.ViewInfo
+		views, err = introspection.GetViews(ctx, firstArg(args))
+		result = viewsResult(views)
+	case `\dn`:
+		var schemas []string
+		schemas, err = introspection.GetSchemas(ctx)
+		result = schemasResult(schemas)
+	case `\dx`:
+		var extensions []database.ExtensionInfo
+		extensions, err = introspection.GetExtensions(ctx)
+		result = extensionsResult(extensions)
+	case `\d`:
+		if len(args) != 1 {
+			fmt.Fprintln(s.out, `Usage: \d <table>`)
+			return false
+		}
+		schema, table := splitTableName(args[0])
+		var columns []database.ColumnInfo
+		columns, err = introspection.GetTableColumns(ctx, schema, table)
+		if err == nil && len(columns) == 0 {
+			fmt.Fprintf(s.out, "Table %s.%s not found\n", schema, table)
+			return false
+		}
+		result = columnsResult(columns)
+	default:
+		fmt.Fprintf(s.out, "Unknown command %s, type \\? for help\n", name)
+		return false
+	}
+
+	if err != nil {
+		s.printError(err)
+		return false
+	}
+	s.print(result)
+	return false
+}
+
+func (s *shell) print(result *database.QueryResult) {
+	if err := result.Encode(s.out, s.format); err != nil {
+		s.printError(err)
+	}
+}
+
+// printError prints the server's message for statement errors, the error itself otherwise
+func (s *shell) printError(err error) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		fmt.Fprintf(s.out, "ERROR: %s\n", pqErr.Message)
+		return
+	}
+	fmt.Fprintf(s.out, "ERROR: %v\n", err)
+}
+
+// shellInput collects the lines of the shell until they form complete statements
+type shellInput struct {
+	buffer strings.Builder
+}
+
+// feed adds a line and returns the meta command it holds, or the statements it completes.
+// Meta commands are only recognized at the start of a statement.
+func (in *shellInput) feed(line string) (string, []database.Statement) {
+	if !in.pending() && strings.HasPrefix(strings.TrimSpace(line), `\`) {
+		return strings.TrimSpace(line), nil
+	}
+
+	in.buffer.WriteString(line)
+	in.buffer.WriteString("\n")
+	if database.IncompleteStatement(in.buffer.String()) {
+		return "", nil
+	}
+
+	statements := database.SplitStatements(in.buffer.String())
+	in.buffer.Reset()
+	return "", statements
+}
+
+// pending reports whether an unfinished statement was read
+func (in *shellInput) pending() bool {
+	return strings.TrimSpace(in.buffer.String()) != ""
+}
+
+func tablesResult(tables []database.TableInfo) *database.QueryResult {
+	result := &database.QueryResult{Columns: []string{"schema", "name", "type"}, Rows: [][]interface{}{}}
+	for _, t := range tables {
+		result.Rows = append(result.Rows, []interface{}{t.Schema, t.Name, t.Type})
+	}
+	return result
+}
+
+func viewsResult(views []database.ViewInfo) *database.QueryResult {
+	result := &database.QueryResult{Columns: []string{"schema", "name", "materialized"}, Rows: [][]interface{}{}}
+	for _, v := range views {
+		result.Rows = append(result.Rows, []interface{}{v.Schema, v.Name, v.Materialized})
+	}
+	return result
+}
+
+func schemasResult(schemas []string) *database.QueryResult {
+	result := &database.QueryResult{Columns: []string{"name"}, Rows: [][]interface{}{}}
+	for _, schema := range schemas {
+		result.Rows = append(result.Rows, []interface{}{schema})
+	}
+	return result
+}
+
+func extensionsResult(extensions []database.ExtensionInfo) *database.QueryResult {
+	result := &database.QueryResult{Columns: []string{"name", "version", "schema"}, Rows: [][]interface{}{}}
+	for _, e := range extensions {
+		result.Rows = append(result.Rows, []interface{}{e.Name, e.Version, e.Schema})
+	}
+	return result
+}
+
+func columnsResult(columns []database.ColumnInfo) *database.QueryResult {
+	result := &database.QueryResult{Columns: []string{"column", "type", "nullable", "default"}, Rows: [][]interface{}{}}
+	for _, c := range columns {
+		var defaultValue interface{}
+		if c.DefaultValue != nil {
+			defaultValue = *c.DefaultValue
+		}
+		result.Rows = append(result.Rows, []interface{}{c.Name, c.DataType, c.IsNullable, defaultValue})
+	}
+	return result
+}
+
+// splitTableName splits an optionally schema-qualified table name, defaulting to public
+func splitTableName(name string) (schema, table string) {
+	if schema, table, ok := strings.Cut(name, "."); ok {
+		return schema, table
+	}
+	return "public", name
+}
+
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// defaultHistoryFile is ~/.dbkit_history, empty if the home directory is unknown
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".dbkit_history")
+}
+
+// maxHistoryEntries bounds the history kept in memory and in the history file
+const maxHistoryEntries = 1000
+
+// fileHistory is a terminal history appended to a file, so statements are remembered
+// across sessions
+type fileHistory struct {
+	path    string
+	entries []string
+}
+
+// loadHistory reads the history file at path; a missing or unreadable file starts an
+// empty history
+func loadHistory(path string) *fileHistory {
+	history := &fileHistory{path: path}
+	if path == "" {
+		return history
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return history
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			history.entries = append(history.entries, line)
+		}
+	}
+	if len(history.entries) > maxHistoryEntries {
+		history.entries = history.entries[len(history.entries)-maxHistoryEntries:]
+	}
+	return history
+}
+
+// Add records a line and appends it to the history file. Failures to write the file are
+// ignored: the history is a convenience.
+func (h *fileHistory) Add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistoryEntries {
+		h.entries = h.entries[1:]
+	}
+	if h.path == "" {
+		return
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	_, _ = file.WriteString(entry + "\n")
+}
+
+func (h *fileHistory) Len() int {
+	return len(h.entries)
+}
+
+// At returns the entry idx positions back from the most recent one
+func (h *fileHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
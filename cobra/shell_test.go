@@ -0,0 +1,92 @@
+package cobra
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/b87/db-kit/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellCommand(t *testing.T) {
+	assert.Equal(t, "shell", shellCmd.Use)
+	assert.Equal(t, "1000", shellCmd.Flags().Lookup("limit").DefValue)
+	assert.Error(t, shellCmd.Args(shellCmd, []string{"SELECT 1"}))
+}
+
+func TestShellInput(t *testing.T) {
+	var in shellInput
+
+	meta, statements := in.feed(`\dt public`)
+	assert.Equal(t, `\dt public`, meta)
+	assert.Empty(t, statements)
+	assert.False(t, in.pending())
+
+	meta, statements = in.feed("SELECT id,")
+	assert.Empty(t, meta)
+	assert.Empty(t, statements)
+	assert.True(t, in.pending())
+
+	// A backslash continuing a statement is not a meta command
+	meta, statements = in.feed(`  '\x01' AS raw`)
+	assert.Empty(t, meta)
+	assert.Empty(t, statements)
+
+	meta, statements = in.feed("FROM users; SELECT 2;")
+	assert.Empty(t, meta)
+	require.Len(t, statements, 2)
+	assert.Equal(t, "SELECT id,\n  '\\x01' AS raw\nFROM users", statements[0].SQL)
+	assert.Equal(t, "SELECT 2", statements[1].SQL)
+	assert.False(t, in.pending())
+
+	// Semicolons in strings and dollar quotes do not end the statement
+	_, statements = in.feed("SELECT 'a;b', $$")
+	assert.Empty(t, statements)
+	_, statements = in.feed("c;d $$;")
+	require.Len(t, statements, 1)
+	assert.Equal(t, "SELECT 'a;b', $$\nc;d $$", statements[0].SQL)
+}
+
+func TestShellResults(t *testing.T) {
+	defaultValue := "now()"
+	result := columnsResult([]database.ColumnInfo{
+		{Name: "id", DataType: "integer"},
+		{Name: "created_at", DataType: "timestamp", IsNullable: true, DefaultValue: &defaultValue},
+	})
+	assert.Equal(t, []string{"column", "type", "nullable", "default"}, result.Columns)
+	assert.Equal(t, [][]interface{}{
+		{"id", "integer", false, nil},
+		{"created_at", "timestamp", true, "now()"},
+	}, result.Rows)
+
+	result = tablesResult(nil)
+	assert.Equal(t, []string{"schema", "name", "type"}, result.Columns)
+	assert.NotNil(t, result.Rows)
+
+	schema, table := splitTableName("users")
+	assert.Equal(t, "public", schema)
+	assert.Equal(t, "users", table)
+	schema, table = splitTableName("audit.events")
+	assert.Equal(t, "audit", schema)
+	assert.Equal(t, "events", table)
+}
+
+func TestFileHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(path, []byte("SELECT 1;\n\nSELECT 2;\n"), 0o600))
+
+	history := loadHistory(path)
+	require.Equal(t, 2, history.Len())
+	assert.Equal(t, "SELECT 2;", history.At(0))
+	assert.Equal(t, "SELECT 1;", history.At(1))
+
+	history.Add("SELECT 3;")
+	history.Add("  ")
+	assert.Equal(t, 3, history.Len())
+	assert.Equal(t, "SELECT 3;", history.At(0))
+
+	assert.Equal(t, 3, loadHistory(path).Len())
+	assert.Equal(t, 0, loadHistory(filepath.Join(t.TempDir(), "missing")).Len())
+}
@@ -0,0 +1,145 @@
+package cobra
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	snapshotReplace    = new(bool)
+	snapshotDelete     = new(bool)
+	restoreSnapshotYes = new(bool)
+)
+
+func init() {
+	DBCmd.AddCommand(snapshotCmd)
+	DBCmd.AddCommand(restoreSnapshotCmd)
+
+	snapshotCmd.Flags().BoolVar(snapshotReplace, "replace", false, "Replace the snapshot if one of that name exists")
+	snapshotCmd.Flags().BoolVar(snapshotDelete, "delete", false, "Delete the snapshot instead of taking it")
+	restoreSnapshotCmd.Flags().BoolVarP(restoreSnapshotYes, "yes", "y", false, "Restore without asking for confirmation")
+
+	addErrorFlags(snapshotCmd)
+	addErrorFlags(restoreSnapshotCmd)
+}
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot [name]",
+	Short: "Take a named snapshot of the database for development, or list them",
+	Long: `Capture the schema and data of the database as a named snapshot in the backups directory,
+to switch back to that state with restore-snapshot, e.g. between tries of a migration.
+Without a name, list the snapshots of the database, newest first.
+
+Snapshots are custom format dumps kept under .snapshots/<database> in the backups directory,
+out of the backup catalog and retention, so the backups directory must be local.
+
+  db snapshot seeded
+  db migrate up
+  db restore-snapshot seeded --yes`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, time.Hour)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		if len(args) == 0 {
+			if *snapshotDelete || *snapshotReplace {
+				handleError(cmd, database.NewValidationError("--delete and --replace need a snapshot name", nil), "snapshot")
+				return
+			}
+			snapshots, err := db.ListSnapshots(ctx)
+			if err != nil {
+				handleError(cmd, err, "list_snapshots")
+				return
+			}
+			if textOutput(cmd) {
+				printSnapshots(cmd, snapshots)
+			}
+			handleSuccess(cmd, fmt.Sprintf("%d snapshots of %s", len(snapshots), db.Config().DBName), map[string]interface{}{
+				"database":  db.Config().DBName,
+				"snapshots": snapshots,
+			})
+			return
+		}
+
+		if *snapshotDelete {
+			if err := db.DeleteSnapshot(ctx, args[0]); err != nil {
+				handleError(cmd, err, "delete_snapshot")
+				return
+			}
+			handleSuccess(cmd, fmt.Sprintf("Snapshot %s deleted", args[0]), map[string]interface{}{
+				"snapshot": args[0],
+			})
+			return
+		}
+
+		snapshot, err := db.Snapshot(ctx, args[0], *snapshotReplace)
+		if err != nil {
+			handleError(cmd, err, "snapshot")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("Snapshot %s of %s taken (%s)", snapshot.Name, snapshot.Database, formatBytes(snapshot.Size)), map[string]interface{}{
+			"snapshot": snapshot,
+		})
+	},
+}
+
+var restoreSnapshotCmd = &cobra.Command{
+	Use:   "restore-snapshot <name>",
+	Short: "Replace the database with a snapshot taken with snapshot",
+	Long: `Drop the database, terminating the sessions connected to it, and create it again from a
+snapshot taken with snapshot, so it holds exactly the schema and data of that moment.
+Asks for confirmation unless --yes is given; without a terminal, --yes is required.
+Needs PostgreSQL 13 or later.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !confirmProduction(cmd, "restore a snapshot") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, time.Hour)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		question := fmt.Sprintf("Replace all schema and data with snapshot %s", args[0])
+		if !confirmDestructive(cmd, *restoreSnapshotYes, db.Config(), question, "restore_snapshot") {
+			return
+		}
+
+		start := time.Now()
+		snapshot, err := db.RestoreSnapshot(ctx, args[0])
+		if err != nil {
+			handleError(cmd, err, "restore_snapshot")
+			return
+		}
+		handleSuccess(cmd, fmt.Sprintf("Database %s restored from snapshot %s in %s", snapshot.Database, snapshot.Name, time.Since(start).Round(time.Second)), map[string]interface{}{
+			"snapshot": snapshot,
+		})
+	},
+}
+
+func printSnapshots(cmd *cobra.Command, snapshots []database.SnapshotInfo) {
+	if len(snapshots) == 0 {
+		return
+	}
+	cmd.Printf("%-19s  %10s  %s\n", "CREATED", "SIZE", "NAME")
+	for _, snapshot := range snapshots {
+		cmd.Printf("%-19s  %10s  %s\n", snapshot.Created.Local().Format("2006-01-02 15:04:05"), formatBytes(snapshot.Size), snapshot.Name)
+	}
+}
@@ -0,0 +1,20 @@
+package cobra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotCommands(t *testing.T) {
+	assert.Equal(t, "snapshot [name]", snapshotCmd.Use)
+	assert.NoError(t, snapshotCmd.Args(snapshotCmd, []string{}))
+	assert.Error(t, snapshotCmd.Args(snapshotCmd, []string{"a", "b"}))
+	for _, flag := range []string{"replace", "delete", "json"} {
+		assert.NotNil(t, snapshotCmd.Flags().Lookup(flag), "snapshot should have --%s", flag)
+	}
+
+	assert.Equal(t, "restore-snapshot <name>", restoreSnapshotCmd.Use)
+	assert.Error(t, restoreSnapshotCmd.Args(restoreSnapshotCmd, []string{}))
+	assert.NotNil(t, restoreSnapshotCmd.Flags().ShorthandLookup("y"))
+}
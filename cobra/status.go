@@ -10,8 +10,11 @@ import (
 	"github.com/b87/db-kit/database"
 )
 
+var dbStatusWatch = new(time.Duration)
+
 func init() {
 	DBCmd.AddCommand(dbStatusCmd)
+	dbStatusCmd.Flags().DurationVar(dbStatusWatch, "watch", 0, "Refresh the status at this interval until interrupted, e.g. 5s")
 	addErrorFlags(dbStatusCmd)
 }
 
@@ -22,10 +25,17 @@ var dbStatusCmd = &cobra.Command{
 - Connection health and ping status
 - Database metadata (version, size, schemas)
 - Migration status
-- Connection pool statistics`,
+- Connection pool statistics
+
+With --watch, the status is refreshed at the given interval until interrupted, or a JSON
+line is appended with --json, e.g. while waiting for a replica to catch up:
+
+  db status --host replica.internal --watch 5s`,
 	Run: func(cmd *cobra.Command, _ []string) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+		if err := validateWatch(cmd, *dbStatusWatch); err != nil {
+			handleError(cmd, err, "get_status")
+			return
+		}
 
 		db, err := newDB()
 		if err != nil {
@@ -34,6 +44,23 @@ var dbStatusCmd = &cobra.Command{
 		}
 		defer db.Close()
 
+		if *dbStatusWatch > 0 {
+			watch(cmd, *dbStatusWatch, "get_status", func(ctx context.Context) (map[string]interface{}, error) {
+				status, err := getDatabaseStatus(ctx, db)
+				if err != nil {
+					return nil, err
+				}
+				if textOutput(cmd) {
+					displayStatus(cmd, status)
+				}
+				return map[string]interface{}{"status": status}, nil
+			})
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
 		// Get database status information
 		status, err := getDatabaseStatus(ctx, db)
 		if err != nil {
@@ -42,7 +69,9 @@ var dbStatusCmd = &cobra.Command{
 		}
 
 		// Display status information
-		displayStatus(cmd, status)
+		if textOutput(cmd) {
+			displayStatus(cmd, status)
+		}
 		handleSuccess(cmd, "Database status retrieved successfully", map[string]interface{}{
 			"status": status,
 		})
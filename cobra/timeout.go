@@ -0,0 +1,78 @@
+package cobra
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+// timeoutEnv is the environment variable setting the timeout of all commands
+const timeoutEnv = "DBKIT_TIMEOUT"
+
+// migrationTimeout is the own limit of the migrate and seed commands: none, as a migration
+// that is not transactional, cancelled halfway, leaves a half-applied schema
+const migrationTimeout time.Duration = 0
+
+// commandTimeout returns how long cmd may run: --timeout if given, else DBKIT_TIMEOUT if
+// set, else fallback, the command's own limit. 0 means no limit.
+func commandTimeout(cmd *cobra.Command, fallback time.Duration) time.Duration {
+	if flag := cmd.Flags().Lookup("timeout"); flag != nil && flag.Changed {
+		if timeout, err := time.ParseDuration(flag.Value.String()); err == nil {
+			return timeout
+		}
+	}
+	if value := os.Getenv(timeoutEnv); value != "" {
+		if timeout, err := parseTimeout(timeoutEnv, value); err == nil {
+			return timeout
+		}
+	}
+	return fallback
+}
+
+// parseTimeout parses the timeout value given by source, a duration or 0 for no limit
+func parseTimeout(source, value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		message := fmt.Sprintf("invalid %s %q, use a duration such as 90s or 2h, or 0 for no limit", source, value)
+		return 0, database.NewValidationError(message, err).
+			WithContext("timeout", value).
+			WithUserMessage(message)
+	}
+	return timeout, nil
+}
+
+// validateTimeout checks --timeout and DBKIT_TIMEOUT
+func validateTimeout(cmd *cobra.Command) error {
+	if flag := cmd.Flags().Lookup("timeout"); flag != nil && flag.Changed {
+		if _, err := parseTimeout("--timeout", flag.Value.String()); err != nil {
+			return err
+		}
+	}
+	if value := os.Getenv(timeoutEnv); value != "" {
+		_, err := parseTimeout(timeoutEnv, value)
+		return err
+	}
+	return nil
+}
+
+// commandContext returns the context of cmd limited to commandTimeout(cmd, fallback)
+func commandContext(cmd *cobra.Command, fallback time.Duration) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return withTimeout(ctx, commandTimeout(cmd, fallback))
+}
+
+// withTimeout is context.WithTimeout where a timeout of 0 means no limit
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
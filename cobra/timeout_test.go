@@ -0,0 +1,70 @@
+package cobra
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/b87/db-kit/database"
+)
+
+func TestCommandTimeout(t *testing.T) {
+	t.Setenv(timeoutEnv, "")
+	cmd := &cobra.Command{}
+	cmd.Flags().Duration("timeout", 0, "")
+
+	// Commands keep their own limit by default
+	assert.Equal(t, 30*time.Second, commandTimeout(cmd, 30*time.Second))
+
+	t.Setenv(timeoutEnv, "10m")
+	assert.Equal(t, 10*time.Minute, commandTimeout(cmd, 30*time.Second))
+
+	// The flag wins over the environment, and 0 lifts the limit
+	require.NoError(t, cmd.Flags().Set("timeout", "0"))
+	assert.Equal(t, time.Duration(0), commandTimeout(cmd, 30*time.Second))
+	ctx, cancel := commandContext(cmd, 30*time.Second)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	require.NoError(t, cmd.Flags().Set("timeout", "2h"))
+	ctx, cancel = commandContext(cmd, 30*time.Second)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), deadline, time.Minute)
+}
+
+func TestValidateTimeout(t *testing.T) {
+	t.Setenv(timeoutEnv, "")
+	cmd := &cobra.Command{}
+	cmd.Flags().Duration("timeout", 0, "")
+	assert.NoError(t, validateTimeout(cmd))
+
+	require.NoError(t, cmd.Flags().Set("timeout", "-1s"))
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(validateTimeout(cmd)))
+
+	cmd = &cobra.Command{}
+	t.Setenv(timeoutEnv, "soon")
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(validateTimeout(cmd)))
+}
+
+func TestTimeoutSuggestions(t *testing.T) {
+	err := database.WrapError(context.DeadlineExceeded, database.ErrCodeQueryFailed, "migrate_up", "migration failed")
+	output := buildErrorOutput(err, "migrate_up")
+	assert.Equal(t, ExitTimeout, output.ExitCode)
+	assert.Contains(t, output.Suggestions[0], "--timeout")
+}
+
+func TestTimeoutFlag(t *testing.T) {
+	flag := DBCmd.PersistentFlags().Lookup("timeout")
+	require.NotNil(t, flag)
+	assert.Equal(t, "0s", flag.DefValue)
+
+	// Commands with their own --timeout keep its default
+	assert.Equal(t, "30s", queryCmd.Flags().Lookup("timeout").DefValue)
+}
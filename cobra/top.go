@@ -0,0 +1,517 @@
+package cobra
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	topInterval = new(time.Duration)
+	topAllDBs   = new(bool)
+)
+
+func init() {
+	DBCmd.AddCommand(topCmd)
+
+	topCmd.Flags().DurationVar(topInterval, "interval", 2*time.Second, "Refresh at this interval")
+	topCmd.Flags().BoolVar(topAllDBs, "all-databases", false, "Show the sessions of every database, not only of the --db database")
+
+	addErrorFlags(topCmd)
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Live dashboard of connections, active queries, lock waits and replication lag",
+	Long: `Show a live view of the server, refreshed every --interval: the connections used out of
+max_connections by state, the connection pool of db-kit, the active sessions longest running
+first, the sessions waiting for locks with the sessions blocking them, and the lag of the
+standbys.
+
+Keys:
+  ↑/k, ↓/j  select a session
+  c         cancel the query of the selected session
+  x         terminate the selected session
+  r         refresh now
+  q         quit
+
+Cancelling and terminating ask for confirmation, and are disabled on production profiles;
+use db kill there. Without a terminal, or with --json, one snapshot is printed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if *topInterval <= 0 {
+			handleError(cmd, database.NewValidationError("--interval takes a positive interval", nil), "top")
+			return
+		}
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		refresh := func() (*topSnapshot, error) {
+			ctx, cancel := commandContext(cmd, 30*time.Second)
+			defer cancel()
+			return loadTopSnapshot(ctx, db, *topAllDBs)
+		}
+
+		if !textOutput(cmd) || !isInteractive(cmd) {
+			snapshot, err := refresh()
+			if err != nil {
+				handleError(cmd, err, "top")
+				return
+			}
+			if textOutput(cmd) {
+				model := &topModel{config: db.Config(), snapshot: snapshot, allDatabases: *topAllDBs}
+				model.render(cmd.OutOrStdout(), 0, 0)
+			}
+			handleSuccess(cmd, fmt.Sprintf("%d active sessions", len(snapshot.Sessions)), map[string]interface{}{
+				"top": snapshot,
+			})
+			return
+		}
+
+		name, p, err := activeProfile()
+		if err != nil {
+			handleError(cmd, err, "profile")
+			return
+		}
+		model := &topModel{
+			config:       db.Config(),
+			allDatabases: *topAllDBs,
+			interval:     *topInterval,
+			refresh:      refresh,
+			signal: func(action topAction, pid int64) string {
+				return signalTopSession(cmd, db, action, pid)
+			},
+		}
+		if p != nil && p.Production {
+			model.readOnly = name
+		}
+		if err := runTop(cmd, model); err != nil {
+			handleError(cmd, err, "top")
+		}
+	},
+}
+
+// topSnapshot is one refresh of db top
+type topSnapshot struct {
+	Time        time.Time                  `json:"time"`
+	Connections topConnections             `json:"connections"`
+	Pool        topPool                    `json:"pool"`
+	Sessions    []database.SessionActivity `json:"sessions"`
+	// WaitingLocks are the locks sessions wait for, with the sessions blocking them
+	WaitingLocks []database.LockInfo    `json:"waiting_locks"`
+	Standbys     []database.StandbyInfo `json:"standbys"`
+}
+
+// topConnections counts the client connections of the server
+type topConnections struct {
+	Total   int            `json:"total"`
+	Max     int            `json:"max"`
+	ByState map[string]int `json:"by_state"`
+}
+
+// topPool is the client connection pool of db-kit, from sql.DBStats
+type topPool struct {
+	OpenConnections    int           `json:"open_connections"`
+	InUseConnections   int           `json:"in_use_connections"`
+	IdleConnections    int           `json:"idle_connections"`
+	MaxOpenConnections int           `json:"max_open_connections"`
+	WaitCount          int64         `json:"wait_count"`
+	WaitDuration       time.Duration `json:"wait_duration"`
+}
+
+// loadTopSnapshot reads the connections of the server and of the pool, the active sessions of the current
+// database, or of all databases, the lock waits and the standbys
+func loadTopSnapshot(ctx context.Context, db *database.DB, allDatabases bool) (*topSnapshot, error) {
+	introspection := db.Introspection()
+
+	server, err := introspection.GetServerInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := introspection.GetActivity(ctx, database.ActivityFilter{IncludeIdle: true, AllDatabases: true})
+	if err != nil {
+		return nil, err
+	}
+	locks, err := introspection.GetLocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	standbys, err := introspection.GetStandbys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &topSnapshot{
+		Time: time.Now(),
+		// The session reading the snapshot is left out of the activity
+		Connections:  topConnections{Total: len(activity) + 1, Max: server.MaxConnections, ByState: map[string]int{"active": 1}},
+		Sessions:     []database.SessionActivity{},
+		WaitingLocks: []database.LockInfo{},
+		Standbys:     standbys,
+	}
+	stats := db.DB().Stats()
+	snapshot.Pool = topPool{
+		OpenConnections:    stats.OpenConnections,
+		InUseConnections:   stats.InUse,
+		IdleConnections:    stats.Idle,
+		MaxOpenConnections: stats.MaxOpenConnections,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration,
+	}
+	for _, session := range activity {
+		state := valueOr(session.State, "unknown")
+		snapshot.Connections.ByState[state]++
+		if state == "idle" || (!allDatabases && valueOr(session.Database, "") != server.Database) {
+			continue
+		}
+		snapshot.Sessions = append(snapshot.Sessions, session)
+	}
+	for _, lock := range locks {
+		if !lock.Granted {
+			snapshot.WaitingLocks = append(snapshot.WaitingLocks, lock)
+		}
+	}
+	return snapshot, nil
+}
+
+// topAction is what a key asks db top to do
+type topAction int
+
+const (
+	topNone topAction = iota
+	topQuit
+	topRefresh
+	topCancel
+	topTerminate
+)
+
+// topModel is the bubbletea model of the db top screen
+type topModel struct {
+	config       database.Config
+	snapshot     *topSnapshot
+	allDatabases bool
+	interval     time.Duration
+	// refresh loads a snapshot, and signal cancels or terminates a backend, returning the
+	// outcome to show
+	refresh func() (*topSnapshot, error)
+	signal  func(action topAction, pid int64) string
+	// readOnly is the production profile in use, on which backends are not signalled
+	readOnly string
+	// selected is the PID of the selected session, kept across refreshes
+	selected int64
+	// pending is the signal awaiting confirmation for the selected session
+	pending topAction
+	message string
+	// width and height are the size of the terminal, err the error ending the dashboard
+	width, height int
+	err           error
+}
+
+// topSnapshotMsg carries a refresh of db top
+type topSnapshotMsg struct {
+	snapshot *topSnapshot
+	err      error
+}
+
+// topTickMsg asks db top to refresh at the end of an interval
+type topTickMsg struct{}
+
+// topSignalMsg is the outcome of cancelling or terminating a backend
+type topSignalMsg string
+
+// Init implements tea.Model, loading the first snapshot and starting the refresh interval
+func (m *topModel) Init() tea.Cmd {
+	return tea.Batch(m.load, m.tick())
+}
+
+func (m *topModel) load() tea.Msg {
+	snapshot, err := m.refresh()
+	return topSnapshotMsg{snapshot: snapshot, err: err}
+}
+
+func (m *topModel) tick() tea.Cmd {
+	return tea.Tick(m.interval, func(time.Time) tea.Msg { return topTickMsg{} })
+}
+
+// Update implements tea.Model. A failed refresh ends the dashboard with its error.
+func (m *topModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case topTickMsg:
+		return m, tea.Batch(m.load, m.tick())
+	case topSnapshotMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		m.snapshot = msg.snapshot
+		m.selectedSession()
+	case topSignalMsg:
+		m.message = string(msg)
+		return m, m.load
+	case tea.KeyMsg:
+		switch action := m.handleKey(msg.String()); action {
+		case topQuit:
+			return m, tea.Quit
+		case topRefresh:
+			return m, m.load
+		case topCancel, topTerminate:
+			session, ok := m.selectedSession()
+			if !ok {
+				m.message = "No session selected"
+				return m, nil
+			}
+			return m, func() tea.Msg { return topSignalMsg(m.signal(action, session.PID)) }
+		}
+	}
+	return m, nil
+}
+
+// View implements tea.Model
+func (m *topModel) View() string {
+	if m.snapshot == nil {
+		return "Loading..."
+	}
+	var screen strings.Builder
+	m.render(&screen, m.width, m.height)
+	return strings.TrimSuffix(screen.String(), "\n")
+}
+
+// handleKey applies key to the model and returns the action to run
+func (m *topModel) handleKey(key string) topAction {
+	if m.pending != topNone {
+		action := m.pending
+		m.pending = topNone
+		if key == "y" {
+			return action
+		}
+		m.message = "Not confirmed"
+		return topNone
+	}
+
+	m.message = ""
+	switch key {
+	case "q", "ctrl+c":
+		return topQuit
+	case "r":
+		return topRefresh
+	case "up", "k":
+		m.moveSelection(-1)
+	case "down", "j":
+		m.moveSelection(1)
+	case "c", "x":
+		session, ok := m.selectedSession()
+		switch {
+		case !ok:
+			m.message = "No session selected"
+		case m.readOnly != "":
+			m.message = fmt.Sprintf("Profile %s is marked as production, use db kill", m.readOnly)
+		case key == "c":
+			m.pending = topCancel
+			m.message = fmt.Sprintf("Cancel the query of backend %d? [y/N]", session.PID)
+		default:
+			m.pending = topTerminate
+			m.message = fmt.Sprintf("Terminate backend %d and roll back its transaction? [y/N]", session.PID)
+		}
+	}
+	return topNone
+}
+
+// selectedSession returns the selected session, the first one if the selected session is
+// gone
+func (m *topModel) selectedSession() (database.SessionActivity, bool) {
+	if m.snapshot == nil || len(m.snapshot.Sessions) == 0 {
+		return database.SessionActivity{}, false
+	}
+	for _, session := range m.snapshot.Sessions {
+		if session.PID == m.selected {
+			return session, true
+		}
+	}
+	m.selected = m.snapshot.Sessions[0].PID
+	return m.snapshot.Sessions[0], true
+}
+
+func (m *topModel) moveSelection(offset int) {
+	current, ok := m.selectedSession()
+	if !ok {
+		return
+	}
+	sessions := m.snapshot.Sessions
+	for i, session := range sessions {
+		if session.PID == current.PID {
+			m.selected = sessions[max(0, min(len(sessions)-1, i+offset))].PID
+			return
+		}
+	}
+}
+
+// render draws the model in width columns and height lines, without limits if 0
+func (m *topModel) render(w io.Writer, width, height int) {
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		line := fmt.Sprintf(format, args...)
+		if width > 0 {
+			line = truncateCell(line, width)
+		}
+		lines = append(lines, line)
+	}
+
+	snapshot := m.snapshot
+	add("%s on %s:%d, %s", m.config.DBName, m.config.Host, m.config.Port, snapshot.Time.Format("15:04:05"))
+	add("Connections: %d/%d (%s)", snapshot.Connections.Total, snapshot.Connections.Max, formatStateCounts(snapshot.Connections.ByState))
+	add("Pool: %d open of %s (%d in use, %d idle), %d waits for %s", snapshot.Pool.OpenConnections, formatPoolMax(snapshot.Pool.MaxOpenConnections),
+		snapshot.Pool.InUseConnections, snapshot.Pool.IdleConnections, snapshot.Pool.WaitCount, snapshot.Pool.WaitDuration.Round(time.Millisecond))
+	add("")
+
+	// Lock waits and standbys go below the sessions, which get the remaining lines
+	var footer []string
+	if len(snapshot.WaitingLocks) > 0 {
+		footer = append(footer, "", fmt.Sprintf("Waiting for locks: %d", len(snapshot.WaitingLocks)))
+		for _, lock := range snapshot.WaitingLocks {
+			footer = append(footer, fmt.Sprintf("  %-8d %s on %s, %s, blocked by %s", lock.PID, lock.Mode, valueOr(lock.Relation, lock.LockType),
+				lock.Duration.Round(time.Second), formatPIDs(lock.BlockedBy)))
+		}
+	}
+	if len(snapshot.Standbys) > 0 {
+		footer = append(footer, "", "Standbys:")
+		for _, standby := range snapshot.Standbys {
+			lag := "-"
+			if standby.ReplayLagBytes != nil {
+				lag = formatBytes(*standby.ReplayLagBytes)
+			}
+			footer = append(footer, fmt.Sprintf("  %-20s %-16s %-10s %-6s lag %s, %s", standby.Application, valueOr(standby.ClientAddr, "local"),
+				standby.State, standby.SyncState, lag, standby.ReplayLag.Round(time.Millisecond)))
+		}
+	}
+	status := m.message
+	if status == "" && height > 0 {
+		status = "↑↓ select  c cancel  x terminate  r refresh  q quit"
+	}
+	if status != "" {
+		footer = append(footer, "", status)
+	}
+
+	header := ""
+	if m.allDatabases {
+		header = fmt.Sprintf("%-16s ", "DATABASE")
+	}
+	add("  %-8s %s%-12s %-20s %-20s %10s  %s", "PID", header, "USER", "STATE", "WAIT", "DURATION", "QUERY")
+	sessions := snapshot.Sessions
+	rows := len(sessions)
+	if available := height - len(lines) - len(footer); height > 0 && rows > available {
+		// Keep a line to tell how many sessions are left out
+		rows = max(0, available-1)
+	}
+	selected, _ := m.selectedSession()
+	first := 0
+	for i, session := range sessions {
+		if session.PID == selected.PID && i >= rows {
+			first = i - rows + 1
+		}
+	}
+	for _, session := range sessions[first : first+rows] {
+		marker := " "
+		if height > 0 && session.PID == selected.PID {
+			marker = ">"
+		}
+		databaseColumn := ""
+		if m.allDatabases {
+			databaseColumn = fmt.Sprintf("%-16s ", valueOr(session.Database, "-"))
+		}
+		wait := "-"
+		if session.WaitEventType != nil {
+			wait = *session.WaitEventType + ":" + valueOr(session.WaitEvent, "")
+		}
+		add("%s %-8d %s%-12s %-20s %-20s %10s  %s", marker, session.PID, databaseColumn, valueOr(session.User, "-"), valueOr(session.State, "-"),
+			wait, session.Duration.Round(time.Second), summarizeQuery(session.Query))
+	}
+	if rows < len(sessions) {
+		add("  ... %d more", len(sessions)-rows)
+	}
+
+	for _, line := range footer {
+		add("%s", line)
+	}
+	fmt.Fprint(w, strings.Join(lines, "\n")+"\n")
+}
+
+// runTop runs the interactive dashboard until q is pressed
+func runTop(cmd *cobra.Command, model *topModel) error {
+	program := tea.NewProgram(model,
+		tea.WithAltScreen(),
+		tea.WithInput(cmd.InOrStdin()),
+		tea.WithOutput(cmd.OutOrStdout()))
+	if _, err := program.Run(); err != nil {
+		return database.NewDBError(database.ErrCodeInternal, "failed to run the dashboard", err)
+	}
+	return model.err
+}
+
+// signalTopSession cancels the query of backend pid or terminates it, and returns the
+// outcome to show
+func signalTopSession(cmd *cobra.Command, db *database.DB, action topAction, pid int64) string {
+	ctx, cancel := commandContext(cmd, 30*time.Second)
+	defer cancel()
+
+	verb, signal := "Cancelled the query of", db.CancelBackend
+	if action == topTerminate {
+		verb, signal = "Terminated", db.TerminateBackend
+	}
+	if err := signal(ctx, pid); err != nil {
+		return fmt.Sprintf("Failed to signal backend %d: %v", pid, err)
+	}
+	return fmt.Sprintf("%s backend %d", verb, pid)
+}
+
+// formatStateCounts lists connection counts by state, the largest first
+func formatStateCounts(counts map[string]int) string {
+	states := make([]string, 0, len(counts))
+	for state := range counts {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if counts[states[i]] != counts[states[j]] {
+			return counts[states[i]] > counts[states[j]]
+		}
+		return states[i] < states[j]
+	})
+	parts := make([]string, len(states))
+	for i, state := range states {
+		parts[i] = fmt.Sprintf("%s %d", state, counts[state])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatPoolMax formats the maximum of open connections of the pool, 0 for no limit
+func formatPoolMax(limit int) string {
+	if limit <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(limit)
+}
+
+func formatPIDs(pids []int64) string {
+	if len(pids) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(pids))
+	for i, pid := range pids {
+		parts[i] = fmt.Sprint(pid)
+	}
+	return strings.Join(parts, ", ")
+}
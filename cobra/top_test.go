@@ -0,0 +1,147 @@
+package cobra
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/b87/db-kit/database"
+)
+
+func testTopModel() *topModel {
+	active, user := "active", "app"
+	query := "SELECT pg_sleep(60)"
+	lag := int64(2048)
+	return &topModel{
+		config: database.Config{DBName: "app", Host: "localhost", Port: 5432},
+		snapshot: &topSnapshot{
+			Time:        time.Date(2025, 1, 2, 15, 4, 5, 0, time.Local),
+			Connections: topConnections{Total: 12, Max: 100, ByState: map[string]int{"idle": 9, "active": 3}},
+			Pool:        topPool{OpenConnections: 3, InUseConnections: 1, IdleConnections: 2, MaxOpenConnections: 25, WaitCount: 4, WaitDuration: 120 * time.Millisecond},
+			Sessions: []database.SessionActivity{
+				{PID: 101, User: &user, State: &active, Duration: 90 * time.Second, Query: &query},
+				{PID: 102, User: &user, State: &active, Duration: 5 * time.Second, Query: &query},
+				{PID: 103, User: &user, State: &active, Duration: time.Second, Query: &query},
+			},
+			WaitingLocks: []database.LockInfo{{PID: 102, Mode: "ShareLock", LockType: "transactionid", BlockedBy: []int64{101}}},
+			Standbys:     []database.StandbyInfo{{Application: "replica1", State: "streaming", SyncState: "async", ReplayLagBytes: &lag}},
+		},
+	}
+}
+
+func TestTopCommand(t *testing.T) {
+	assert.Equal(t, "top", topCmd.Use)
+	for _, name := range []string{"interval", "all-databases", "json"} {
+		assert.NotNil(t, topCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+	assert.Error(t, topCmd.Args(topCmd, []string{"extra"}))
+}
+
+func TestTopModelUpdate(t *testing.T) {
+	m := testTopModel()
+	snapshot := m.snapshot
+	var signalled int64
+	m.refresh = func() (*topSnapshot, error) { return snapshot, nil }
+	m.signal = func(action topAction, pid int64) string {
+		signalled = pid
+		return "Cancelled the query of backend 102"
+	}
+
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	assert.Equal(t, 80, m.width)
+	for _, line := range strings.Split(m.View(), "\n") {
+		assert.LessOrEqual(t, len([]rune(line)), 80)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	assert.Equal(t, int64(102), m.selected)
+
+	// A confirmed signal runs as a command, then refreshes
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	assert.Nil(t, cmd)
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	msg := cmd()
+	assert.Equal(t, int64(102), signalled)
+	_, cmd = m.Update(msg)
+	assert.Equal(t, "Cancelled the query of backend 102", m.message)
+	assert.Equal(t, topSnapshotMsg{snapshot: snapshot}, cmd())
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	assert.Equal(t, tea.QuitMsg{}, cmd())
+
+	// A failed refresh ends the dashboard with its error
+	failed := errors.New("connection lost")
+	_, cmd = m.Update(topSnapshotMsg{err: failed})
+	assert.Equal(t, tea.QuitMsg{}, cmd())
+	assert.Equal(t, failed, m.err)
+
+	assert.Equal(t, "Loading...", (&topModel{}).View())
+}
+
+func TestTopModelKeys(t *testing.T) {
+	m := testTopModel()
+
+	assert.Equal(t, topNone, m.handleKey("down"))
+	assert.Equal(t, int64(102), m.selected)
+	m.handleKey("j")
+	m.handleKey("down")
+	assert.Equal(t, int64(103), m.selected)
+	m.handleKey("k")
+	assert.Equal(t, int64(102), m.selected)
+
+	// Signals wait for confirmation
+	assert.Equal(t, topNone, m.handleKey("c"))
+	assert.Contains(t, m.message, "backend 102")
+	assert.Equal(t, topCancel, m.handleKey("y"))
+	assert.Equal(t, topNone, m.handleKey("x"))
+	assert.Equal(t, topNone, m.handleKey("n"))
+	assert.Equal(t, "Not confirmed", m.message)
+
+	m.readOnly = "prod"
+	assert.Equal(t, topNone, m.handleKey("x"))
+	assert.Contains(t, m.message, "production")
+	assert.Equal(t, topNone, m.handleKey("y"))
+
+	assert.Equal(t, topRefresh, m.handleKey("r"))
+	assert.Equal(t, topQuit, m.handleKey("q"))
+	assert.Equal(t, topQuit, m.handleKey("ctrl+c"))
+
+	// The first session is selected when the selected one is gone
+	m.snapshot.Sessions = m.snapshot.Sessions[:1]
+	session, ok := m.selectedSession()
+	assert.True(t, ok)
+	assert.Equal(t, int64(101), session.PID)
+}
+
+func TestTopModelRender(t *testing.T) {
+	m := testTopModel()
+	var out bytes.Buffer
+	m.render(&out, 0, 0)
+	screen := out.String()
+	assert.Contains(t, screen, "app on localhost:5432, 15:04:05")
+	assert.Contains(t, screen, "Connections: 12/100 (idle 9, active 3)")
+	assert.Contains(t, screen, "Pool: 3 open of 25 (1 in use, 2 idle), 4 waits for 120ms")
+	assert.Contains(t, screen, "SELECT pg_sleep(60)")
+	assert.Contains(t, screen, "blocked by 101")
+	assert.Contains(t, screen, "replica1")
+	assert.Contains(t, screen, "lag 2.0 KiB")
+	assert.NotContains(t, screen, "q quit")
+
+	// On a terminal the sessions fit the screen, scrolled to the selection
+	m.selected = 103
+	out.Reset()
+	m.render(&out, 60, 15)
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Len(t, lines, 15)
+	for _, line := range lines {
+		assert.LessOrEqual(t, len([]rune(line)), 60)
+	}
+	assert.Contains(t, out.String(), "> 103")
+	assert.Contains(t, out.String(), "more")
+	assert.Contains(t, out.String(), "q quit")
+}
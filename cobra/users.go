@@ -0,0 +1,325 @@
+package cobra
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	listAllRoles = new(bool)
+
+	createUserCreateDB      = new(bool)
+	createUserCreateRole    = new(bool)
+	createUserInRoles       = new([]string)
+	createUserValidUntil    = new(string)
+	createUserIfNotExists   = new(bool)
+	createUserNoPassword    = new(bool)
+	createUserPasswordStdin = new(bool)
+
+	dropUserIfExists   = new(bool)
+	dropUserReassignTo = new(string)
+	dropUserYes        = new(bool)
+)
+
+func init() {
+	DBCmd.AddCommand(usersCmd)
+	usersCmd.AddCommand(listUsersCmd)
+	usersCmd.AddCommand(createUserCmd)
+	usersCmd.AddCommand(dropUserCmd)
+
+	DBCmd.AddCommand(tableGrantsCmd)
+	tableGrantsCmd.AddCommand(showGrantsCmd)
+
+	listUsersCmd.Flags().BoolVar(listAllRoles, "all", false, "Also list the roles that cannot log in")
+
+	createUserCmd.Flags().BoolVar(createUserCreateDB, "createdb", false, "Allow the user to create databases")
+	createUserCmd.Flags().BoolVar(createUserCreateRole, "createrole", false, "Allow the user to create roles")
+	createUserCmd.Flags().StringSliceVar(createUserInRoles, "in-role", nil, "Roles to make the user a member of (repeatable or comma-separated)")
+	createUserCmd.Flags().StringVar(createUserValidUntil, "valid-until", "", "Expire the password at this time (RFC 3339 or YYYY-MM-DD)")
+	createUserCmd.Flags().BoolVar(createUserIfNotExists, "if-not-exists", false, "Succeed without changes if the user exists")
+	createUserCmd.Flags().BoolVar(createUserNoPassword, "no-password", false, "Create the user without a password")
+	createUserCmd.Flags().BoolVar(createUserPasswordStdin, "password-stdin", false, "Read the password from the first line of stdin instead of prompting")
+
+	dropUserCmd.Flags().BoolVar(dropUserIfExists, "if-exists", false, "Succeed without changes if the user does not exist")
+	dropUserCmd.Flags().StringVar(dropUserReassignTo, "reassign-to", "", "Reassign the objects the user owns in the database to this role before dropping it")
+	dropUserCmd.Flags().BoolVarP(dropUserYes, "yes", "y", false, "Drop without asking for confirmation")
+
+	addErrorFlags(usersCmd)
+	addErrorFlags(listUsersCmd)
+	addErrorFlags(createUserCmd)
+	addErrorFlags(dropUserCmd)
+	addErrorFlags(tableGrantsCmd)
+	addErrorFlags(showGrantsCmd)
+}
+
+var usersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "List, create and drop database users",
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			handleError(cmd, err, "users")
+		}
+	},
+}
+
+var listUsersCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the roles that can log in, or all roles with --all",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		roles, err := db.Introspection().GetRoles(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_roles")
+			return
+		}
+		if !*listAllRoles {
+			roles = loginRoles(roles)
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, roles, "name", "superuser", "create_db", "create_role", "valid_until", "member_of")
+		}
+		handleSuccess(cmd, fmt.Sprintf("%d roles", len(roles)), map[string]interface{}{
+			"roles": roles,
+		})
+	},
+}
+
+var createUserCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a user that can log in",
+	Long: `Create a role with LOGIN. The password is asked twice on a terminal, or read from the
+first line of stdin with --password-stdin, and sent as a SCRAM-SHA-256 secret so it never
+reaches the server logs in clear text:
+
+  db users create reporting --in-role readonly --valid-until 2026-12-31
+  echo "$PASSWORD" | db users create app --createdb --password-stdin`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := database.RoleOptions{
+			Login:       true,
+			CreateDB:    *createUserCreateDB,
+			CreateRole:  *createUserCreateRole,
+			InRoles:     *createUserInRoles,
+			IfNotExists: *createUserIfNotExists,
+		}
+		if *createUserValidUntil != "" {
+			validUntil, err := parseValidUntil(*createUserValidUntil)
+			if err != nil {
+				handleError(cmd, err, "create_user")
+				return
+			}
+			opts.ValidUntil = &validUntil
+		}
+		if !*createUserNoPassword {
+			password, err := readNewPassword(cmd, *createUserPasswordStdin)
+			if err != nil {
+				handleError(cmd, err, "create_user")
+				return
+			}
+			opts.Password = password
+		}
+
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		if err := db.CreateRole(ctx, args[0], opts); err != nil {
+			handleError(cmd, err, "create_user")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("User %s created", args[0]), map[string]interface{}{
+			"user":     args[0],
+			"password": opts.Password != "",
+		})
+	},
+}
+
+var dropUserCmd = &cobra.Command{
+	Use:   "drop <name>",
+	Short: "Drop a user",
+	Long: `Drop a user or role. Roles that own objects cannot be dropped until the objects are
+reassigned; --reassign-to hands them to another role in the current database first. Asks
+for confirmation unless --yes is given; without a terminal, --yes is required.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !confirmProduction(cmd, "drop the user") {
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 5*time.Minute)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		question := fmt.Sprintf("Drop user %s", args[0])
+		if *dropUserReassignTo != "" {
+			question = fmt.Sprintf("Drop user %s and reassign its objects to %s", args[0], *dropUserReassignTo)
+		}
+		if !confirmDestructive(cmd, *dropUserYes, db.Config(), question, "drop_user") {
+			return
+		}
+
+		opts := database.DropRoleOptions{IfExists: *dropUserIfExists, ReassignTo: *dropUserReassignTo}
+		if err := db.DropRole(ctx, args[0], opts); err != nil {
+			handleError(cmd, err, "drop_user")
+			return
+		}
+
+		handleSuccess(cmd, fmt.Sprintf("User %s dropped", args[0]), map[string]interface{}{
+			"user": args[0],
+		})
+	},
+}
+
+var tableGrantsCmd = &cobra.Command{
+	Use:   "grants",
+	Short: "Show the privileges granted on tables",
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			handleError(cmd, err, "grants")
+		}
+	},
+}
+
+var showGrantsCmd = &cobra.Command{
+	Use:   "show <table>",
+	Short: "Show the privileges granted on a table and its columns",
+	Long: `Show who may do what on a table, optionally schema-qualified (public by default),
+including the privileges granted on single columns:
+
+  db grants show billing.invoices`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		schema, table := splitTableName(args[0])
+		introspection := db.Introspection()
+
+		exists, err := introspection.GetTableExists(ctx, schema, table)
+		if err != nil {
+			handleError(cmd, err, "check_table_exists")
+			return
+		}
+		if !exists {
+			handleError(cmd, database.NewValidationError(fmt.Sprintf("table %s.%s does not exist", schema, table), nil), "table_not_found")
+			return
+		}
+
+		grants, err := introspection.GetTableGrants(ctx, schema, table)
+		if err != nil {
+			handleError(cmd, err, "get_table_grants")
+			return
+		}
+
+		if textOutput(cmd) {
+			printRows(cmd, grants, "grantee", "privilege", "column", "is_grantable", "grantor")
+		}
+		handleSuccess(cmd, fmt.Sprintf("%d grants on %s.%s", len(grants), schema, table), map[string]interface{}{
+			"schema": schema,
+			"table":  table,
+			"grants": grants,
+		})
+	},
+}
+
+// loginRoles returns the roles that can log in
+func loginRoles(roles []database.RoleInfo) []database.RoleInfo {
+	users := make([]database.RoleInfo, 0, len(roles))
+	for _, role := range roles {
+		if role.Login {
+			users = append(users, role)
+		}
+	}
+	return users
+}
+
+// parseValidUntil parses a password expiry given as RFC 3339 or as a date
+func parseValidUntil(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, database.NewValidationError(fmt.Sprintf("invalid --valid-until %q, use RFC 3339 or YYYY-MM-DD", value), err)
+	}
+	return t, nil
+}
+
+// readNewPassword reads the password of a new user: one line from stdin with fromStdin,
+// otherwise typed twice on the terminal without echo
+func readNewPassword(cmd *cobra.Command, fromStdin bool) (string, error) {
+	if fromStdin {
+		line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+		password := strings.TrimRight(line, "\r\n")
+		if password == "" {
+			return "", database.NewValidationError("no password on stdin", err)
+		}
+		return password, nil
+	}
+
+	if !isInteractive(cmd) {
+		return "", database.NewValidationError("no terminal to ask for the password, use --password-stdin or --no-password", nil)
+	}
+	fd := int(cmd.InOrStdin().(*os.File).Fd())
+	ask := func(prompt string) (string, error) {
+		cmd.PrintErr(prompt)
+		password, err := term.ReadPassword(fd)
+		cmd.PrintErrln()
+		return string(password), err
+	}
+
+	password, err := ask("Password: ")
+	if err != nil {
+		return "", err
+	}
+	if password == "" {
+		return "", database.NewValidationError("empty password, use --no-password to create the user without one", nil)
+	}
+	again, err := ask("Repeat password: ")
+	if err != nil {
+		return "", err
+	}
+	if again != password {
+		return "", database.NewValidationError("passwords do not match", nil)
+	}
+	return password, nil
+}
@@ -0,0 +1,73 @@
+package cobra
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/b87/db-kit/database"
+)
+
+func TestUserCommands(t *testing.T) {
+	assert.Equal(t, usersCmd, listUsersCmd.Parent())
+	assert.Equal(t, usersCmd, createUserCmd.Parent())
+	assert.Equal(t, usersCmd, dropUserCmd.Parent())
+	assert.Equal(t, tableGrantsCmd, showGrantsCmd.Parent())
+
+	for _, name := range []string{"createdb", "createrole", "in-role", "valid-until", "if-not-exists", "no-password", "password-stdin"} {
+		assert.NotNil(t, createUserCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+	for _, name := range []string{"if-exists", "reassign-to", "yes"} {
+		assert.NotNil(t, dropUserCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+
+	assert.Error(t, createUserCmd.Args(createUserCmd, []string{}))
+	assert.NoError(t, showGrantsCmd.Args(showGrantsCmd, []string{"billing.invoices"}))
+	assert.Error(t, showGrantsCmd.Args(showGrantsCmd, []string{"billing", "invoices"}))
+}
+
+func TestLoginRoles(t *testing.T) {
+	roles := []database.RoleInfo{
+		{Name: "app", Login: true},
+		{Name: "readonly"},
+		{Name: "postgres", Login: true, Superuser: true},
+	}
+	users := loginRoles(roles)
+	require.Len(t, users, 2)
+	assert.Equal(t, "app", users[0].Name)
+	assert.Equal(t, "postgres", users[1].Name)
+}
+
+func TestParseValidUntil(t *testing.T) {
+	validUntil, err := parseValidUntil("2026-12-31")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), validUntil)
+
+	validUntil, err = parseValidUntil("2026-12-31T12:00:00+02:00")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 12, 31, 10, 0, 0, 0, time.UTC), validUntil.UTC())
+
+	_, err = parseValidUntil("tomorrow")
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+}
+
+func TestReadNewPassword(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetIn(strings.NewReader("s3cret\nignored\n"))
+	password, err := readNewPassword(cmd, true)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", password)
+
+	cmd.SetIn(strings.NewReader(""))
+	_, err = readNewPassword(cmd, true)
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+
+	// Prompting needs a terminal
+	cmd.SetIn(strings.NewReader("s3cret\n"))
+	_, err = readNewPassword(cmd, false)
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+}
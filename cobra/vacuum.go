@@ -0,0 +1,124 @@
+package cobra
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+var (
+	vacuumSchema  = new(string)
+	vacuumAll     = new(bool)
+	vacuumFull    = new(bool)
+	vacuumAnalyze = new(bool)
+	vacuumJobs    = new(int)
+)
+
+func init() {
+	DBCmd.AddCommand(vacuumCmd)
+
+	vacuumCmd.Flags().StringVar(vacuumSchema, "schema", "", "Vacuum all tables of this schema")
+	vacuumCmd.Flags().BoolVar(vacuumAll, "all", false, "Vacuum all tables of the database")
+	vacuumCmd.Flags().BoolVar(vacuumFull, "full", false, "Rewrite the tables with VACUUM FULL, locking each exclusively while it runs")
+	vacuumCmd.Flags().BoolVar(vacuumAnalyze, "analyze", true, "Also update the planner statistics")
+	vacuumCmd.Flags().IntVar(vacuumJobs, "jobs", 1, "Number of tables to vacuum at once")
+
+	addErrorFlags(vacuumCmd)
+}
+
+var vacuumCmd = &cobra.Command{
+	Use:   "vacuum [table...]",
+	Short: "Run VACUUM (ANALYZE) on tables",
+	Long: `Vacuum the tables given as arguments, optionally schema-qualified, or all tables of a
+schema with --schema or of the database with --all. With --verbose, the progress messages
+of the server are streamed to stderr:
+
+  db vacuum public.orders public.order_items --verbose
+  db vacuum --schema billing --jobs 4
+  db vacuum --all --full`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts, err := maintenanceSelection(args, *vacuumSchema, *vacuumAll)
+		if err != nil {
+			handleError(cmd, err, "vacuum")
+			return
+		}
+		if *vacuumFull && !confirmProduction(cmd, "run VACUUM FULL") {
+			return
+		}
+		opts.Full = *vacuumFull
+		opts.Analyze = *vacuumAnalyze
+		opts.Jobs = *vacuumJobs
+
+		runMaintenance(cmd, "vacuum", opts)
+	},
+}
+
+// maintenanceSelection returns the options selecting the tables given as arguments, the
+// tables of schema or, with all, every table
+func maintenanceSelection(args []string, schema string, all bool) (database.MaintenanceOptions, error) {
+	selections := 0
+	for _, selected := range []bool{len(args) > 0, schema != "", all} {
+		if selected {
+			selections++
+		}
+	}
+	if selections != 1 {
+		return database.MaintenanceOptions{}, database.NewValidationError("select the tables as arguments, with --schema or with --all", nil)
+	}
+	return database.MaintenanceOptions{Tables: args, Schema: schema}, nil
+}
+
+// runMaintenance runs the vacuum or analyze operation and reports the tables
+func runMaintenance(cmd *cobra.Command, op string, opts database.MaintenanceOptions) {
+	// VACUUM FULL of large tables takes long
+	ctx, cancel := commandContext(cmd, 6*time.Hour)
+	defer cancel()
+
+	db, err := newDB()
+	if err != nil {
+		handleError(cmd, err, "connect")
+		return
+	}
+	defer db.Close()
+
+	// --verbose also shows the details of errors
+	opts.Verbose, _ = cmd.Flags().GetBool("verbose")
+	if opts.Verbose {
+		opts.Progress = func(progress database.MaintenanceProgress) {
+			if progress.Message != "" {
+				cmd.PrintErrf("%s: %s\n", progress.Table, progress.Message)
+			}
+		}
+	}
+
+	var results []database.MaintenanceResult
+	if op == "vacuum" {
+		results, err = db.Maintenance().Vacuum(ctx, opts)
+	} else {
+		results, err = db.Maintenance().Analyze(ctx, opts)
+	}
+	if textOutput(cmd) {
+		printMaintenanceResults(cmd, results)
+	}
+	if err != nil {
+		handleError(cmd, err, op)
+		return
+	}
+
+	handleSuccess(cmd, fmt.Sprintf("Processed %d tables", len(results)), map[string]interface{}{
+		"tables": results,
+	})
+}
+
+func printMaintenanceResults(cmd *cobra.Command, results []database.MaintenanceResult) {
+	for _, result := range results {
+		status := "ok"
+		if result.Error != "" {
+			status = "failed: " + result.Error
+		}
+		cmd.Printf("  %-40s %10s  %s\n", result.Table, result.Duration.Round(time.Millisecond), status)
+	}
+}
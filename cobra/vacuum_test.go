@@ -0,0 +1,70 @@
+package cobra
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/b87/db-kit/database"
+)
+
+func TestVacuumCommand(t *testing.T) {
+	assert.Equal(t, "vacuum [table...]", vacuumCmd.Use)
+	for _, name := range []string{"schema", "all", "full", "analyze", "verbose", "jobs", "json"} {
+		assert.NotNil(t, vacuumCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+	assert.Equal(t, "true", vacuumCmd.Flags().Lookup("analyze").DefValue)
+	assert.Equal(t, "1", vacuumCmd.Flags().Lookup("jobs").DefValue)
+}
+
+func TestAnalyzeCommandRunsOnTables(t *testing.T) {
+	for _, name := range []string{"schema", "all", "jobs"} {
+		assert.NotNil(t, analyzeCmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+
+	// Tables go to analyze itself, subcommands keep working
+	cmd, args, err := DBCmd.Find([]string{"analyze", "public.orders", "users"})
+	require.NoError(t, err)
+	assert.Equal(t, analyzeCmd, cmd)
+	assert.Equal(t, []string{"public.orders", "users"}, args)
+
+	cmd, _, err = DBCmd.Find([]string{"analyze", "maintenance"})
+	require.NoError(t, err)
+	assert.Equal(t, maintenanceCmd, cmd)
+}
+
+func TestMaintenanceSelection(t *testing.T) {
+	opts, err := maintenanceSelection([]string{"public.orders"}, "", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"public.orders"}, opts.Tables)
+
+	opts, err = maintenanceSelection(nil, "billing", false)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", opts.Schema)
+
+	_, err = maintenanceSelection(nil, "", true)
+	require.NoError(t, err)
+
+	_, err = maintenanceSelection(nil, "", false)
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+	_, err = maintenanceSelection([]string{"orders"}, "", true)
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(err))
+}
+
+func TestPrintMaintenanceResults(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+
+	printMaintenanceResults(cmd, []database.MaintenanceResult{
+		{Table: "public.orders", Duration: 1500 * time.Millisecond},
+		{Table: "public.missing", Error: `pq: relation "public.missing" does not exist`},
+	})
+	assert.Equal(t, `  public.orders                                  1.5s  ok
+  public.missing                                   0s  failed: pq: relation "public.missing" does not exist
+`, out.String())
+}
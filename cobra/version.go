@@ -0,0 +1,166 @@
+package cobra
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+// Version and Commit identify the build, set at link time:
+//
+//	go build -ldflags "-X github.com/b87/db-kit/cobra.Version=v1.2.0 -X github.com/b87/db-kit/cobra.Commit=$(git rev-parse HEAD)"
+//
+// Unset, they are read from the module and VCS information of the binary.
+var (
+	Version string
+	Commit  string
+)
+
+// driverModule is the module of the PostgreSQL driver
+const driverModule = "github.com/lib/pq"
+
+var versionClientOnly = new(bool)
+
+// ClientInfo describes the db-kit build
+type ClientInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	Driver    string `json:"driver"`
+}
+
+func init() {
+	DBCmd.AddCommand(buildVersionCmd)
+
+	buildVersionCmd.Flags().BoolVar(versionClientOnly, "client", false, "Only show the db-kit build, without connecting")
+
+	addErrorFlags(buildVersionCmd)
+}
+
+var buildVersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the db-kit build and the connected server version",
+	Long: `Show the db-kit version and commit, the Go and driver versions it was built with and,
+unless --client is given, the version and main settings of the connected server. The
+build is shown before connecting, so it is printed even when the server cannot be
+reached. See introspect version for the bare server version string.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		client := clientInfo()
+		text := textOutput(cmd) && !jsonLogs()
+		if text {
+			printClientInfo(cmd, client)
+		}
+		if *versionClientOnly {
+			if !text {
+				handleSuccess(cmd, "db-kit "+client.Version, map[string]interface{}{
+					"client": client,
+				})
+			}
+			return
+		}
+
+		ctx, cancel := commandContext(cmd, 30*time.Second)
+		defer cancel()
+
+		db, err := newDB()
+		if err != nil {
+			handleError(cmd, err, "connect")
+			return
+		}
+		defer db.Close()
+
+		server, err := db.Introspection().GetServerInfo(ctx)
+		if err != nil {
+			handleError(cmd, err, "get_server_info")
+			return
+		}
+		if text {
+			printServerInfo(cmd, db.Config(), server)
+			return
+		}
+		handleSuccess(cmd, "db-kit "+client.Version, map[string]interface{}{
+			"client": client,
+			"server": server,
+		})
+	},
+}
+
+// clientInfo returns the build of the running binary
+func clientInfo() ClientInfo {
+	info := ClientInfo{
+		Version:   Version,
+		Commit:    Commit,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Driver:    driverModule,
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if ok {
+		if info.Version == "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		if info.Commit == "" {
+			var modified bool
+			for _, setting := range build.Settings {
+				switch setting.Key {
+				case "vcs.revision":
+					info.Commit = setting.Value
+				case "vcs.modified":
+					modified = setting.Value == "true"
+				}
+			}
+			if modified && info.Commit != "" {
+				info.Commit += "-dirty"
+			}
+		}
+		for _, dep := range build.Deps {
+			if dep.Path == driverModule {
+				info.Driver += " " + dep.Version
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+func printClientInfo(cmd *cobra.Command, client ClientInfo) {
+	version := client.Version
+	if client.Commit != "" {
+		commit := client.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		version = fmt.Sprintf("%s (%s)", version, commit)
+	}
+	cmd.Printf("%-16s %s\n", "db-kit:", version)
+	cmd.Printf("%-16s %s %s\n", "Go:", client.GoVersion, client.Platform)
+	cmd.Printf("%-16s %s\n", "Driver:", client.Driver)
+}
+
+func printServerInfo(cmd *cobra.Command, config database.Config, server *database.ServerInfo) {
+	ssl := "off"
+	if server.SSL {
+		ssl = "on"
+	}
+	role := "primary"
+	if server.InRecovery {
+		role = "standby"
+	}
+	cmd.Printf("%-16s %s\n", "Server:", server.Version)
+	cmd.Printf("%-16s %s on %s:%d as %s (%s, SSL %s)\n", "Database:", server.Database, config.Host, config.Port, server.User, role, ssl)
+	cmd.Printf("%-16s %s\n", "Encoding:", server.Encoding)
+	cmd.Printf("%-16s %s\n", "Time zone:", server.TimeZone)
+	cmd.Printf("%-16s %d\n", "Max connections:", server.MaxConnections)
+	cmd.Printf("%-16s %s\n", "Shared buffers:", server.SharedBuffers)
+	cmd.Printf("%-16s %t\n", "Data checksums:", server.DataChecksums)
+}
@@ -0,0 +1,43 @@
+package cobra
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionCommand(t *testing.T) {
+	assert.Equal(t, "version", buildVersionCmd.Use)
+	assert.NotNil(t, buildVersionCmd.Flags().Lookup("client"))
+	assert.Error(t, buildVersionCmd.Args(buildVersionCmd, []string{"extra"}))
+
+	// The introspect subcommand keeps showing the bare server version
+	cmd, _, err := DBCmd.Find([]string{"introspect", "version"})
+	assert.NoError(t, err)
+	assert.Same(t, versionCmd, cmd)
+}
+
+func TestClientInfo(t *testing.T) {
+	previousVersion, previousCommit := Version, Commit
+	t.Cleanup(func() { Version, Commit = previousVersion, previousCommit })
+
+	Version, Commit = "v1.2.0", "0123456789abcdef"
+	info := clientInfo()
+	assert.Equal(t, "v1.2.0", info.Version)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, info.Platform)
+	assert.True(t, strings.HasPrefix(info.Driver, driverModule), info.Driver)
+
+	cmd := &cobra.Command{}
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	printClientInfo(cmd, info)
+	assert.Contains(t, out.String(), "v1.2.0 (0123456789ab)")
+
+	Version, Commit = "", ""
+	assert.NotEmpty(t, clientInfo().Version)
+}
@@ -0,0 +1,87 @@
+package cobra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/b87/db-kit/database"
+)
+
+// watchRefresh queries a snapshot for watch, printing it when the output is text, and
+// returns the data of its JSON line
+type watchRefresh func(ctx context.Context) (map[string]interface{}, error)
+
+// validateWatch checks a --watch interval: watching needs table or JSON output
+func validateWatch(cmd *cobra.Command, interval time.Duration) error {
+	if interval < 0 {
+		return database.NewValidationError("--watch takes a positive interval", nil)
+	}
+	if format := outputFormat(cmd); interval > 0 && format != outputTable && format != outputJSON {
+		return database.NewValidationError("--watch takes table or JSON output", nil).
+			WithContext("output", format)
+	}
+	return nil
+}
+
+// watch runs refresh every interval until interrupted. Table output clears the screen and
+// redraws it once a snapshot is complete; JSON output appends one line per snapshot, with
+// its time, for tools following the stream.
+func watch(cmd *cobra.Command, interval time.Duration, op string, refresh watchRefresh) {
+	parent := cmd.Context()
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt)
+	defer stop()
+
+	out := cmd.OutOrStdout()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		var snapshot bytes.Buffer
+		cmd.SetOut(&snapshot)
+		queryCtx, cancel := withTimeout(ctx, commandTimeout(cmd, 30*time.Second))
+		data, err := refresh(queryCtx)
+		cancel()
+		cmd.SetOut(out)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			handleError(cmd, err, op)
+			return
+		}
+
+		now := time.Now()
+		if textOutput(cmd) {
+			cmd.Print("\033[H\033[2J")
+			cmd.Printf("Every %s, %s\n\n", interval, now.Format("15:04:05"))
+			cmd.Print(snapshot.String())
+		} else if err := json.NewEncoder(out).Encode(watchLine(now, data)); err != nil {
+			handleError(cmd, database.NewDBError(database.ErrCodeInternal, "failed to format the output", err), "output")
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchLine returns the JSON line of a snapshot taken at t
+func watchLine(t time.Time, data map[string]interface{}) map[string]interface{} {
+	line := make(map[string]interface{}, len(data)+1)
+	for key, value := range data {
+		line[key] = value
+	}
+	line["time"] = t.Format(time.RFC3339)
+	return line
+}
@@ -0,0 +1,37 @@
+package cobra
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/b87/db-kit/database"
+)
+
+func TestValidateWatch(t *testing.T) {
+	cmd := &cobra.Command{}
+	addErrorFlags(cmd)
+
+	assert.NoError(t, validateWatch(cmd, 0))
+	assert.NoError(t, validateWatch(cmd, 5*time.Second))
+	assert.Equal(t, database.ErrCodeValidation, database.GetErrorCode(validateWatch(cmd, -time.Second)))
+
+	require.NoError(t, cmd.Flags().Set("json", "true"))
+	assert.NoError(t, validateWatch(cmd, 5*time.Second))
+}
+
+func TestWatchLine(t *testing.T) {
+	data := map[string]interface{}{"pending_count": 2}
+	line := watchLine(time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC), data)
+	assert.Equal(t, map[string]interface{}{"pending_count": 2, "time": "2025-01-02T15:04:05Z"}, line)
+	assert.NotContains(t, data, "time")
+}
+
+func TestWatchFlags(t *testing.T) {
+	for _, cmd := range []*cobra.Command{dbStatusCmd, statusCmd, activityCmd} {
+		assert.NotNil(t, cmd.Flags().Lookup("watch"), "%s should have --watch", cmd.Name())
+	}
+}
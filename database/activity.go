@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SessionActivity is a client session from pg_stat_activity
+type SessionActivity struct {
+	PID         int64   `json:"pid" db:"pid"`
+	Database    *string `json:"database,omitempty" db:"datname"`
+	User        *string `json:"user,omitempty" db:"usename"`
+	Application string  `json:"application" db:"application_name"`
+	ClientAddr  *string `json:"client_addr,omitempty" db:"client_addr"`
+	// State is active, idle, idle in transaction, idle in transaction (aborted), fastpath
+	// function call or disabled
+	State         *string `json:"state,omitempty" db:"state"`
+	WaitEventType *string `json:"wait_event_type,omitempty" db:"wait_event_type"`
+	WaitEvent     *string `json:"wait_event,omitempty" db:"wait_event"`
+	// Duration is how long the current query has run for active sessions, and how long the
+	// session has been in its state otherwise
+	Duration time.Duration `json:"duration" db:"duration"`
+	// TransactionDuration is how long the open transaction has run, 0 outside transactions
+	TransactionDuration time.Duration `json:"transaction_duration" db:"transaction_duration"`
+	// Query is the current or, for idle sessions, the last query
+	Query *string `json:"query,omitempty" db:"query"`
+	// BlockedBy are the sessions blocking this session, if it waits for a lock
+	BlockedBy pq.Int64Array `json:"blocked_by,omitempty" db:"blocked_by"`
+}
+
+// ActivityFilter selects the sessions returned by GetActivity
+type ActivityFilter struct {
+	// State limits the sessions to one state, e.g. active or idle in transaction
+	State string
+	// User limits the sessions to one role
+	User string
+	// Application limits the sessions to one application_name
+	Application string
+	// MinDuration leaves out sessions with a shorter Duration
+	MinDuration time.Duration
+	// IncludeIdle includes idle sessions, which are left out unless State is idle
+	IncludeIdle bool
+	// AllDatabases includes the sessions of every database of the server
+	AllDatabases bool
+	// OrderBy is one of the activity orders, ActivityOrderDuration by default
+	OrderBy string
+}
+
+// Orders of GetActivity
+const (
+	// ActivityOrderDuration orders by Duration, longest first
+	ActivityOrderDuration = "duration"
+	// ActivityOrderTransaction orders by TransactionDuration, longest first
+	ActivityOrderTransaction = "transaction"
+	ActivityOrderPID         = "pid"
+	ActivityOrderUser        = "user"
+	ActivityOrderState       = "state"
+)
+
+// activityOrders are the ORDER BY clauses of the activity orders
+var activityOrders = map[string]string{
+	ActivityOrderDuration:    "duration DESC, pid",
+	ActivityOrderTransaction: "transaction_duration DESC, pid",
+	ActivityOrderPID:         "pid",
+	ActivityOrderUser:        "usename, duration DESC, pid",
+	ActivityOrderState:       "state, duration DESC, pid",
+}
+
+// GetActivity retrieves the client sessions of the current database, or of all databases,
+// other than the one running the query, longest running first unless filter.OrderBy is set
+func (is *IntrospectionService) GetActivity(ctx context.Context, filter ActivityFilter) ([]SessionActivity, error) {
+	if filter.OrderBy == "" {
+		filter.OrderBy = ActivityOrderDuration
+	}
+	if _, ok := activityOrders[filter.OrderBy]; !ok {
+		return nil, NewValidationError(fmt.Sprintf("unsupported activity order %q", filter.OrderBy), nil).
+			WithContext("order_by", filter.OrderBy).
+			WithOperation("get_activity")
+	}
+	activity := []SessionActivity{}
+
+	query, args := activityQuery(filter)
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &activity, query, args...)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_activity", "failed to get activity")
+	}
+
+	return activity, nil
+}
+
+// activityQuery builds the pg_stat_activity query for filter
+func activityQuery(filter ActivityFilter) (string, []interface{}) {
+	query := `
+		SELECT * FROM (
+			SELECT
+				a.pid,
+				a.datname,
+				a.usename,
+				coalesce(a.application_name, '') as application_name,
+				host(a.client_addr) as client_addr,
+				a.state,
+				a.wait_event_type,
+				a.wait_event,
+				coalesce(extract(epoch FROM now() - CASE
+					WHEN a.state = 'active' THEN a.query_start
+					ELSE a.state_change
+				END) * 1000000000, 0)::bigint as duration,
+				coalesce(extract(epoch FROM now() - a.xact_start) * 1000000000, 0)::bigint as transaction_duration,
+				a.query,
+				pg_blocking_pids(a.pid) as blocked_by
+			FROM pg_stat_activity a
+			WHERE a.backend_type = 'client backend'
+			AND a.pid <> pg_backend_pid()
+		) activity
+		WHERE true`
+
+	var args []interface{}
+	if !filter.AllDatabases {
+		query += " AND datname = current_database()"
+	}
+	if filter.State != "" {
+		args = append(args, filter.State)
+		query += fmt.Sprintf(" AND state = $%d", len(args))
+	} else if !filter.IncludeIdle {
+		query += " AND state IS DISTINCT FROM 'idle'"
+	}
+	if filter.User != "" {
+		args = append(args, filter.User)
+		query += fmt.Sprintf(" AND usename = $%d", len(args))
+	}
+	if filter.Application != "" {
+		args = append(args, filter.Application)
+		query += fmt.Sprintf(" AND application_name = $%d", len(args))
+	}
+	if filter.MinDuration > 0 {
+		args = append(args, filter.MinDuration.Nanoseconds())
+		query += fmt.Sprintf(" AND duration >= $%d", len(args))
+	}
+
+	order, ok := activityOrders[filter.OrderBy]
+	if !ok {
+		order = activityOrders[ActivityOrderDuration]
+	}
+	return query + "\n\t\tORDER BY " + order, args
+}
+
+// CancelBackend cancels the current query of the backend with the given PID, like
+// pg_cancel_backend. The session stays connected. Cancelling sessions of other roles
+// requires pg_signal_backend or superuser.
+func (d *DB) CancelBackend(ctx context.Context, pid int64) error {
+	return d.signalBackend(ctx, pid, "pg_cancel_backend", "cancel_backend")
+}
+
+// TerminateBackend ends the session of the backend with the given PID, rolling back its
+// open transaction, like pg_terminate_backend
+func (d *DB) TerminateBackend(ctx context.Context, pid int64) error {
+	return d.signalBackend(ctx, pid, "pg_terminate_backend", "terminate_backend")
+}
+
+// signalBackend calls pg_cancel_backend or pg_terminate_backend, which return false if no
+// backend has the PID
+func (d *DB) signalBackend(ctx context.Context, pid int64, function, operation string) error {
+	var signalled bool
+	err := d.WithValidation(ctx, func() error {
+		return d.db.GetContext(ctx, &signalled, "SELECT "+function+"($1)", pid)
+	})
+	if err != nil {
+		return WrapError(err, ErrCodeQueryFailed, operation, fmt.Sprintf("failed to signal backend %d", pid))
+	}
+	if !signalled {
+		return NewValidationError(fmt.Sprintf("no backend with PID %d", pid), nil).
+			WithContext("pid", pid).
+			WithOperation(operation)
+	}
+
+	d.logger.Info("backend signalled",
+		slog.Int64("pid", pid),
+		slog.String("function", function))
+	return nil
+}
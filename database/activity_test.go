@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityQuery(t *testing.T) {
+	query, args := activityQuery(ActivityFilter{})
+	assert.Contains(t, query, "state IS DISTINCT FROM 'idle'")
+	assert.Contains(t, query, "datname = current_database()")
+	assert.True(t, strings.HasSuffix(query, "ORDER BY duration DESC, pid"), query)
+	assert.Empty(t, args)
+
+	query, _ = activityQuery(ActivityFilter{AllDatabases: true, OrderBy: ActivityOrderTransaction})
+	assert.NotContains(t, query, "current_database()")
+	assert.True(t, strings.HasSuffix(query, "ORDER BY transaction_duration DESC, pid"), query)
+
+	query, args = activityQuery(ActivityFilter{IncludeIdle: true})
+	assert.NotContains(t, query, "'idle'")
+	assert.Empty(t, args)
+
+	query, args = activityQuery(ActivityFilter{
+		State:       "idle in transaction",
+		User:        "app",
+		Application: "worker",
+		MinDuration: 30 * time.Second,
+	})
+	assert.NotContains(t, query, "IS DISTINCT FROM")
+	assert.True(t, strings.Contains(query, "state = $1") && strings.Contains(query, "usename = $2") &&
+		strings.Contains(query, "application_name = $3") && strings.Contains(query, "duration >= $4"), query)
+	assert.Equal(t, []interface{}{"idle in transaction", "app", "worker", int64(30 * time.Second)}, args)
+}
+
+func TestGetActivityOrderValidation(t *testing.T) {
+	_, err := (&DB{}).Introspection().GetActivity(context.Background(), ActivityFilter{OrderBy: "query"})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// maintenanceDBName is the database used to issue CREATE/DROP DATABASE statements
+const maintenanceDBName = "postgres"
+
+// maintenanceConfig returns a copy of config pointing at the maintenance database
+func maintenanceConfig(config Config) Config {
+	config.DBName = maintenanceDBName
+	return config
+}
+
+// recreateDatabase drops config.DBName if it exists and creates it again empty
+func recreateDatabase(ctx context.Context, config Config) error {
+	if config.DBName == "" || config.DBName == maintenanceDBName {
+		return NewValidationError("refusing to recreate the maintenance database", nil).
+			WithContext("database", config.DBName).
+			WithOperation("recreate_database")
+	}
+
+	conn, err := connectMaintenance(ctx, config, "recreate_database")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	name := pq.QuoteIdentifier(config.DBName)
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s", name)); err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "recreate_database", "failed to drop database").
+			WithContext("database", config.DBName)
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s", name)); err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "recreate_database", "failed to create database").
+			WithContext("database", config.DBName)
+	}
+	return nil
+}
+
+// CreateDatabaseOptions configures CreateDatabase
+type CreateDatabaseOptions struct {
+	// Owner is the role owning the new database; the connecting user by default
+	Owner string
+	// Template is the database copied into the new one; template1 by default
+	Template string
+	// Encoding, e.g. UTF8, and Locale, e.g. en_US.UTF-8, default to those of the template.
+	// Values other than the template's usually need template0.
+	Encoding string
+	Locale   string
+	// IfNotExists succeeds without changes when the database already exists
+	IfNotExists bool
+}
+
+// DropDatabaseOptions configures DropDatabase
+type DropDatabaseOptions struct {
+	// IfExists succeeds without changes when the database does not exist
+	IfExists bool
+	// Force terminates the sessions connected to the database instead of failing;
+	// needs PostgreSQL 13 or later
+	Force bool
+}
+
+// CreateDatabase creates the database config.DBName, connecting through the maintenance
+// database with the other settings of config
+func CreateDatabase(ctx context.Context, config Config, opts CreateDatabaseOptions) error {
+	if config.DBName == "" {
+		return NewValidationError("no database name given", nil).
+			WithOperation("create_database")
+	}
+
+	if opts.IfNotExists {
+		exists, err := databaseExists(ctx, config)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+	}
+
+	return execMaintenance(ctx, config, "create_database", "failed to create database",
+		createDatabaseStatement(config.DBName, opts))
+}
+
+// DropDatabase drops the database config.DBName, connecting through the maintenance
+// database with the other settings of config. The maintenance database itself cannot be
+// dropped.
+func DropDatabase(ctx context.Context, config Config, opts DropDatabaseOptions) error {
+	if config.DBName == "" || config.DBName == maintenanceDBName {
+		return NewValidationError("refusing to drop the maintenance database", nil).
+			WithContext("database", config.DBName).
+			WithOperation("drop_database")
+	}
+	return execMaintenance(ctx, config, "drop_database", "failed to drop database",
+		dropDatabaseStatement(config.DBName, opts))
+}
+
+func createDatabaseStatement(name string, opts CreateDatabaseOptions) string {
+	statement := "CREATE DATABASE " + pq.QuoteIdentifier(name)
+	if opts.Owner != "" {
+		statement += " OWNER " + pq.QuoteIdentifier(opts.Owner)
+	}
+	if opts.Template != "" {
+		statement += " TEMPLATE " + pq.QuoteIdentifier(opts.Template)
+	}
+	if opts.Encoding != "" {
+		statement += " ENCODING " + pq.QuoteLiteral(opts.Encoding)
+	}
+	if opts.Locale != "" {
+		statement += " LOCALE " + pq.QuoteLiteral(opts.Locale)
+	}
+	return statement
+}
+
+func dropDatabaseStatement(name string, opts DropDatabaseOptions) string {
+	statement := "DROP DATABASE "
+	if opts.IfExists {
+		statement += "IF EXISTS "
+	}
+	statement += pq.QuoteIdentifier(name)
+	if opts.Force {
+		statement += " WITH (FORCE)"
+	}
+	return statement
+}
+
+// createDatabase creates config.DBName, connecting through the maintenance database
+func createDatabase(ctx context.Context, config Config) error {
+	return CreateDatabase(ctx, config, CreateDatabaseOptions{})
+}
+
+// dropDatabase drops config.DBName if it exists, connecting through the maintenance database
+func dropDatabase(ctx context.Context, config Config) error {
+	return DropDatabase(ctx, config, DropDatabaseOptions{IfExists: true})
+}
+
+// databaseExists reports whether config.DBName exists, connecting through the maintenance
+// database
+func databaseExists(ctx context.Context, config Config) (bool, error) {
+	conn, err := connectMaintenance(ctx, config, "database_exists")
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var exists bool
+	if err := conn.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", config.DBName); err != nil {
+		return false, WrapError(err, ErrCodeQueryFailed, "database_exists", "failed to look up database").
+			WithContext("database", config.DBName)
+	}
+	return exists, nil
+}
+
+// execMaintenance runs a single statement against the maintenance database
+func execMaintenance(ctx context.Context, config Config, operation, message, query string) error {
+	conn, err := connectMaintenance(ctx, config, operation)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return WrapError(err, ErrCodeQueryFailed, operation, message).
+			WithContext("database", config.DBName)
+	}
+	return nil
+}
+
+// connectMaintenance connects to the maintenance database of the server of config
+func connectMaintenance(ctx context.Context, config Config, operation string) (*sqlx.DB, error) {
+	conn, err := sqlx.ConnectContext(ctx, "postgres", maintenanceConfig(config).ConnectionString())
+	if err != nil {
+		return nil, NewConnectionError("failed to connect to maintenance database", err).
+			WithContext("host", config.Host).
+			WithContext("port", config.Port).
+			WithOperation(operation)
+	}
+	return conn, nil
+}
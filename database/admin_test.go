@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceConfig(t *testing.T) {
+	config := Config{Host: "db.internal", Port: 5433, DBName: "app"}
+	maintenance := maintenanceConfig(config)
+
+	if maintenance.DBName != maintenanceDBName {
+		t.Errorf("Expected maintenance database %q, got %q", maintenanceDBName, maintenance.DBName)
+	}
+	if maintenance.Host != config.Host || maintenance.Port != config.Port {
+		t.Errorf("Expected connection settings to be preserved, got %+v", maintenance)
+	}
+	if config.DBName != "app" {
+		t.Errorf("Expected original config to be left untouched, got %q", config.DBName)
+	}
+}
+
+func TestRecreateDatabaseRefusesMaintenanceDB(t *testing.T) {
+	for _, name := range []string{"", maintenanceDBName} {
+		err := recreateDatabase(context.Background(), Config{DBName: name})
+		if GetErrorCode(err) != ErrCodeValidation {
+			t.Errorf("Expected validation error for database %q, got %v", name, err)
+		}
+	}
+}
+
+func TestDatabaseStatements(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{createDatabaseStatement("app", CreateDatabaseOptions{}), `CREATE DATABASE "app"`},
+		{
+			createDatabaseStatement("app", CreateDatabaseOptions{Owner: "deploy", Template: "template0", Encoding: "UTF8", Locale: "en_US.UTF-8"}),
+			`CREATE DATABASE "app" OWNER "deploy" TEMPLATE "template0" ENCODING 'UTF8' LOCALE 'en_US.UTF-8'`,
+		},
+		{dropDatabaseStatement("app", DropDatabaseOptions{}), `DROP DATABASE "app"`},
+		{dropDatabaseStatement("app", DropDatabaseOptions{IfExists: true, Force: true}), `DROP DATABASE IF EXISTS "app" WITH (FORCE)`},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("Expected %s, got %s", tt.want, tt.got)
+		}
+	}
+}
+
+func TestCreateDropDatabaseValidation(t *testing.T) {
+	if err := CreateDatabase(context.Background(), Config{}, CreateDatabaseOptions{}); GetErrorCode(err) != ErrCodeValidation {
+		t.Errorf("Expected validation error without a database name, got %v", err)
+	}
+	for _, name := range []string{"", maintenanceDBName} {
+		if err := DropDatabase(context.Background(), Config{DBName: name}, DropDatabaseOptions{}); GetErrorCode(err) != ErrCodeValidation {
+			t.Errorf("Expected validation error for database %q, got %v", name, err)
+		}
+	}
+}
+
+func TestCreateDropDatabase(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	target := db.config
+	target.DBName = fmt.Sprintf("%s_admin_%d", db.config.DBName, time.Now().UnixNano())
+	defer func() { _ = dropDatabase(context.Background(), target) }()
+
+	opts := CreateDatabaseOptions{Template: "template0", Encoding: "UTF8"}
+	if err := CreateDatabase(ctx, target, opts); err != nil {
+		t.Fatalf("CreateDatabase failed: %v", err)
+	}
+	if exists, err := databaseExists(ctx, target); err != nil || !exists {
+		t.Fatalf("Expected the database to exist, got %v, %v", exists, err)
+	}
+
+	if err := CreateDatabase(ctx, target, opts); GetErrorCode(err) != ErrCodeQueryFailed {
+		t.Errorf("Expected creating an existing database to fail, got %v", err)
+	}
+	opts.IfNotExists = true
+	if err := CreateDatabase(ctx, target, opts); err != nil {
+		t.Errorf("Expected IfNotExists to accept an existing database, got %v", err)
+	}
+
+	if err := DropDatabase(ctx, target, DropDatabaseOptions{}); err != nil {
+		t.Fatalf("DropDatabase failed: %v", err)
+	}
+	if err := DropDatabase(ctx, target, DropDatabaseOptions{}); GetErrorCode(err) != ErrCodeQueryFailed {
+		t.Errorf("Expected dropping a missing database to fail, got %v", err)
+	}
+	if err := DropDatabase(ctx, target, DropDatabaseOptions{IfExists: true}); err != nil {
+		t.Errorf("Expected IfExists to accept a missing database, got %v", err)
+	}
+}
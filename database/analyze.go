@@ -0,0 +1,502 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Confidence and impact levels of an IndexSuggestion
+const (
+	LevelHigh   = "high"
+	LevelMedium = "medium"
+	LevelLow    = "low"
+)
+
+// Defaults of SuggestIndexOptions
+const (
+	defaultSuggestMinTableBytes = 10 << 20
+	defaultSuggestMinSeqScans   = 50
+	// suggestStatementLimit is the number of most expensive statements read from pg_stat_statements
+	suggestStatementLimit = 500
+)
+
+// IndexSuggestion is a candidate index for a table read mostly by sequential scans
+type IndexSuggestion struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	// Columns is the predicate column found in pg_stat_statements, empty if the suggestion
+	// is based on the table statistics alone
+	Columns []string `json:"columns,omitempty"`
+	// Statement creates the suggested index, empty without columns
+	Statement string `json:"statement,omitempty"`
+	Reason    string `json:"reason"`
+	// Confidence is high when statements filter on an unindexed column of a table scanned
+	// sequentially more often than by index, medium when statements filter on it but index
+	// scans dominate, and low without predicate columns
+	Confidence string `json:"confidence"`
+	// Impact grades the rows read by sequential scans: high from 100M, medium from 1M
+	Impact        string `json:"impact"`
+	SeqScans      int64  `json:"seq_scans"`
+	SeqTuplesRead int64  `json:"seq_tuples_read"`
+	IndexScans    int64  `json:"index_scans"`
+	TableBytes    int64  `json:"table_bytes"`
+	EstimatedRows int64  `json:"estimated_rows"`
+	// Calls is the number of statement calls filtering on Columns
+	Calls int64 `json:"calls,omitempty"`
+}
+
+// SuggestIndexOptions configures SuggestIndexes
+type SuggestIndexOptions struct {
+	// Schema limits the analysis to one schema; all schemas by default
+	Schema string
+	// MinTableBytes skips smaller tables, where sequential scans are cheap; 10 MiB by default
+	MinTableBytes int64
+	// MinSeqScans skips tables scanned sequentially fewer times; 50 by default
+	MinSeqScans int64
+	// UseStatements reads the predicates of the most expensive statements from
+	// pg_stat_statements, if installed, to suggest index columns
+	UseStatements bool
+}
+
+// tableScanStats are the scan counters of a table from pg_stat_user_tables
+type tableScanStats struct {
+	Schema        string `db:"table_schema"`
+	Name          string `db:"table_name"`
+	SeqScans      int64  `db:"seq_scan"`
+	SeqTuplesRead int64  `db:"seq_tup_read"`
+	IndexScans    int64  `db:"idx_scan"`
+	TableBytes    int64  `db:"table_bytes"`
+	EstimatedRows int64  `db:"estimated_rows"`
+}
+
+// tableColumn is a column of a candidate table and whether an index starts with it
+type tableColumn struct {
+	Schema    string `db:"table_schema"`
+	Table     string `db:"table_name"`
+	Name      string `db:"column_name"`
+	IsIndexed bool   `db:"is_indexed"`
+}
+
+// statementCalls is a normalized statement from pg_stat_statements
+type statementCalls struct {
+	Query string `db:"query"`
+	Calls int64  `db:"calls"`
+}
+
+// SuggestIndexes suggests indexes for tables of at least MinTableBytes scanned sequentially
+// at least MinSeqScans times since the statistics were last reset. With UseStatements,
+// columns compared in WHERE and JOIN conditions of the most expensive statements become
+// candidate index columns unless an index already starts with them. Suggestions are
+// heuristics: check them with EXPLAIN before creating indexes.
+func (is *IntrospectionService) SuggestIndexes(ctx context.Context, opts SuggestIndexOptions) ([]IndexSuggestion, error) {
+	if opts.MinTableBytes == 0 {
+		opts.MinTableBytes = defaultSuggestMinTableBytes
+	}
+	if opts.MinSeqScans == 0 {
+		opts.MinSeqScans = defaultSuggestMinSeqScans
+	}
+
+	var tables []tableScanStats
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &tables, `
+			SELECT
+				s.schemaname as table_schema,
+				s.relname as table_name,
+				s.seq_scan,
+				s.seq_tup_read,
+				COALESCE(s.idx_scan, 0) as idx_scan,
+				pg_table_size(s.relid) as table_bytes,
+				s.n_live_tup as estimated_rows
+			FROM pg_stat_user_tables s
+			WHERE s.seq_scan >= $1
+				AND pg_table_size(s.relid) >= $2
+				AND ($3::text = '' OR s.schemaname = $3)
+			ORDER BY s.seq_tup_read DESC`, opts.MinSeqScans, opts.MinTableBytes, opts.Schema)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "suggest_indexes", "failed to get table statistics")
+	}
+	if len(tables) == 0 || !opts.UseStatements {
+		return suggestIndexes(tables, nil, nil), nil
+	}
+
+	source, err := is.pgStatStatements(ctx)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "suggest_indexes", "failed to check for pg_stat_statements")
+	}
+	if !source.installed {
+		is.db.logger.Warn("pg_stat_statements is not installed, suggesting indexes from table statistics only")
+		return suggestIndexes(tables, nil, nil), nil
+	}
+
+	var columns []tableColumn
+	err = is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &columns, `
+			SELECT
+				n.nspname as table_schema,
+				c.relname as table_name,
+				a.attname as column_name,
+				EXISTS (
+					SELECT 1 FROM pg_index i
+					WHERE i.indrelid = c.oid AND i.indkey[0] = a.attnum
+				) as is_indexed
+			FROM pg_attribute a
+			JOIN pg_class c ON c.oid = a.attrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE a.attnum > 0
+				AND NOT a.attisdropped
+				AND c.relkind IN ('r', 'p')
+				AND ($1::text = '' OR n.nspname = $1)`, opts.Schema)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "suggest_indexes", "failed to get table columns")
+	}
+
+	var statements []statementCalls
+	err = is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &statements, `
+			SELECT query, calls FROM pg_stat_statements
+			WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+			ORDER BY `+source.totalTime+` DESC
+			LIMIT $1`, suggestStatementLimit)
+	})
+	if isStatementsNotLoaded(err) {
+		is.db.logger.Warn("pg_stat_statements is not loaded, suggesting indexes from table statistics only")
+		return suggestIndexes(tables, nil, nil), nil
+	}
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "suggest_indexes", "failed to read pg_stat_statements")
+	}
+
+	return suggestIndexes(tables, columns, statements), nil
+}
+
+// suggestIndexes builds the suggestions for the candidate tables, ordered by rows read by
+// sequential scans and then by statement calls
+func suggestIndexes(tables []tableScanStats, columns []tableColumn, statements []statementCalls) []IndexSuggestion {
+	candidates := make(map[tableKey]tableScanStats, len(tables))
+	for _, table := range tables {
+		candidates[tableKey{table.Schema, table.Name}] = table
+	}
+
+	// indexed[table][column] is false for unindexed columns of candidate tables
+	indexed := make(map[tableKey]map[string]bool)
+	for _, column := range columns {
+		key := tableKey{column.Schema, column.Table}
+		if _, ok := candidates[key]; !ok {
+			continue
+		}
+		if indexed[key] == nil {
+			indexed[key] = make(map[string]bool)
+		}
+		indexed[key][column.Name] = column.IsIndexed
+	}
+
+	// calls[table][column] sums the calls of statements filtering on unindexed columns
+	calls := make(map[tableKey]map[string]int64)
+	for _, statement := range statements {
+		seen := make(map[tableKey]map[string]bool)
+		for _, ref := range resolvePredicates(statement.Query, indexed) {
+			if isIndexed := indexed[ref.table][ref.column]; isIndexed || seen[ref.table][ref.column] {
+				continue
+			}
+			if seen[ref.table] == nil {
+				seen[ref.table] = make(map[string]bool)
+			}
+			seen[ref.table][ref.column] = true
+			if calls[ref.table] == nil {
+				calls[ref.table] = make(map[string]int64)
+			}
+			calls[ref.table][ref.column] += statement.Calls
+		}
+	}
+
+	suggestions := []IndexSuggestion{}
+	for _, table := range tables {
+		key := tableKey{table.Schema, table.Name}
+		base := IndexSuggestion{
+			Schema:        table.Schema,
+			Table:         table.Name,
+			Impact:        scanImpact(table.SeqTuplesRead),
+			SeqScans:      table.SeqScans,
+			SeqTuplesRead: table.SeqTuplesRead,
+			IndexScans:    table.IndexScans,
+			TableBytes:    table.TableBytes,
+			EstimatedRows: table.EstimatedRows,
+		}
+		scanHeavy := table.SeqScans > table.IndexScans
+		scans := fmt.Sprintf("%d sequential scans read %d rows, %d index scans", table.SeqScans, table.SeqTuplesRead, table.IndexScans)
+
+		if len(calls[key]) == 0 {
+			if scanHeavy {
+				suggestion := base
+				suggestion.Confidence = LevelLow
+				suggestion.Reason = scans + "; no statement predicates found, check the queries on this table"
+				suggestions = append(suggestions, suggestion)
+			}
+			continue
+		}
+
+		for column, n := range calls[key] {
+			suggestion := base
+			suggestion.Columns = []string{column}
+			suggestion.Calls = n
+			suggestion.Statement = fmt.Sprintf("CREATE INDEX CONCURRENTLY ON %s.%s (%s)",
+				pq.QuoteIdentifier(table.Schema), pq.QuoteIdentifier(table.Name), pq.QuoteIdentifier(column))
+			suggestion.Reason = fmt.Sprintf("%s; %d statement calls filter on unindexed column %s", scans, n, column)
+			suggestion.Confidence = LevelMedium
+			if scanHeavy {
+				suggestion.Confidence = LevelHigh
+			}
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.SeqTuplesRead != b.SeqTuplesRead {
+			return a.SeqTuplesRead > b.SeqTuplesRead
+		}
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return strings.Join(a.Columns, ",") < strings.Join(b.Columns, ",")
+	})
+	return suggestions
+}
+
+// scanImpact grades the rows read by sequential scans
+func scanImpact(seqTuplesRead int64) string {
+	switch {
+	case seqTuplesRead >= 100_000_000:
+		return LevelHigh
+	case seqTuplesRead >= 1_000_000:
+		return LevelMedium
+	default:
+		return LevelLow
+	}
+}
+
+var (
+	// tableRefPattern matches the table, and optional alias, after FROM, JOIN and UPDATE
+	tableRefPattern = regexp.MustCompile(`(?i)\b(?:from|join|update)\s+((?:"?[a-z_][\w$]*"?\.)?"?[a-z_][\w$]*"?)(?:\s+(?:as\s+)?([a-z_]\w*))?`)
+	// predicatePattern matches an optionally qualified column compared in a condition
+	predicatePattern = regexp.MustCompile(`(?i)\b(?:where|and|or|on)\s+\(?\s*((?:"?[a-z_][\w$]*"?\.)?"?[a-z_][\w$]*"?)\s*(?:=|<>|!=|<=|>=|<|>|\bin\b|\blike\b|\bilike\b|\bbetween\b|\bis\b)`)
+	// aliasKeywords can follow a table name without being its alias
+	aliasKeywords = map[string]bool{
+		"where": true, "join": true, "on": true, "using": true, "left": true, "right": true,
+		"inner": true, "outer": true, "full": true, "cross": true, "natural": true, "set": true,
+		"group": true, "order": true, "limit": true, "offset": true, "having": true, "window": true,
+		"union": true, "returning": true, "for": true, "lateral": true,
+	}
+)
+
+// predicateRef is a column of a table compared in a statement condition
+type predicateRef struct {
+	table  tableKey
+	column string
+}
+
+// resolvePredicates returns the columns compared in the conditions of query, resolved to
+// the tables of known by table alias, table name or, for unqualified columns, by the
+// tables of the statement having a column of that name
+func resolvePredicates(query string, known map[tableKey]map[string]bool) []predicateRef {
+	// sources maps aliases and table names used in the statement to their tables
+	sources := make(map[string][]tableKey)
+	var all []tableKey
+	for _, match := range tableRefPattern.FindAllStringSubmatch(query, -1) {
+		schema, name := splitIdentifier(match[1])
+		var tables []tableKey
+		for key := range known {
+			if key.name == name && (schema == "" || key.schema == schema) {
+				tables = append(tables, key)
+			}
+		}
+		if len(tables) == 0 {
+			continue
+		}
+		all = append(all, tables...)
+		sources[name] = append(sources[name], tables...)
+		if alias := strings.ToLower(match[2]); alias != "" && !aliasKeywords[alias] {
+			sources[alias] = append(sources[alias], tables...)
+		}
+	}
+
+	var refs []predicateRef
+	for _, match := range predicatePattern.FindAllStringSubmatch(query, -1) {
+		qualifier, column := splitIdentifier(match[1])
+		tables := all
+		if qualifier != "" {
+			tables = sources[qualifier]
+		}
+		for _, table := range tables {
+			if _, ok := known[table][column]; ok {
+				refs = append(refs, predicateRef{table: table, column: column})
+			}
+		}
+	}
+	return refs
+}
+
+// splitIdentifier splits a possibly qualified identifier into qualifier and name, removing
+// quotes and folding unquoted names to lower case like PostgreSQL does
+func splitIdentifier(ident string) (qualifier, name string) {
+	parts := strings.SplitN(ident, ".", 2)
+	for i, part := range parts {
+		if strings.HasPrefix(part, `"`) && strings.HasSuffix(part, `"`) && len(part) > 1 {
+			parts[i] = part[1 : len(part)-1]
+		} else {
+			parts[i] = strings.ToLower(part)
+		}
+	}
+	if len(parts) == 1 {
+		return "", parts[0]
+	}
+	return parts[0], parts[1]
+}
+
+// Orders of GetTopQueries
+const (
+	QueryOrderTotalTime = "total_time"
+	QueryOrderMeanTime  = "mean_time"
+	QueryOrderCalls     = "calls"
+	QueryOrderRows      = "rows"
+)
+
+// defaultTopQueriesLimit is the number of statements GetTopQueries returns by default
+const defaultTopQueriesLimit = 20
+
+// QueryStats are the cumulative statistics of a normalized statement from pg_stat_statements
+type QueryStats struct {
+	QueryID *int64  `json:"query_id,omitempty" db:"queryid"`
+	Query   string  `json:"query" db:"query"`
+	User    *string `json:"user,omitempty" db:"rolname"`
+	Calls   int64   `json:"calls" db:"calls"`
+	// TotalTime is the time spent executing the statement over all calls
+	TotalTime time.Duration `json:"total_time" db:"total_time"`
+	MeanTime  time.Duration `json:"mean_time" db:"mean_time"`
+	// Rows is the number of rows retrieved or affected over all calls
+	Rows int64 `json:"rows" db:"rows"`
+	// HitRatio is the fraction of shared blocks found in the buffer cache, nil if the
+	// statement read no blocks
+	HitRatio *float64 `json:"hit_ratio,omitempty" db:"hit_ratio"`
+}
+
+// TopQueries are the most expensive statements of the current database
+type TopQueries struct {
+	// Available is false if pg_stat_statements cannot be read; Queries is empty then
+	Available bool `json:"available"`
+	// Reason explains why pg_stat_statements is unavailable
+	Reason  string       `json:"reason,omitempty"`
+	Queries []QueryStats `json:"queries"`
+}
+
+// statementsSource describes the pg_stat_statements view of the server
+type statementsSource struct {
+	installed bool
+	// totalTime and meanTime are the time columns, renamed in PostgreSQL 13
+	totalTime string
+	meanTime  string
+}
+
+// pgStatStatements checks whether pg_stat_statements is installed in the current database
+func (is *IntrospectionService) pgStatStatements(ctx context.Context) (statementsSource, error) {
+	var row struct {
+		Installed     bool `db:"installed"`
+		VersionNumber int  `db:"version_num"`
+	}
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.GetContext(ctx, &row, `
+			SELECT
+				EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_stat_statements') as installed,
+				current_setting('server_version_num')::int as version_num`)
+	})
+	if err != nil {
+		return statementsSource{}, err
+	}
+
+	source := statementsSource{installed: row.Installed, totalTime: "total_exec_time", meanTime: "mean_exec_time"}
+	if row.VersionNumber < 130000 {
+		source.totalTime, source.meanTime = "total_time", "mean_time"
+	}
+	return source, nil
+}
+
+// isStatementsNotLoaded reports whether err says pg_stat_statements is installed but not
+// in shared_preload_libraries
+func isStatementsNotLoaded(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "55000" // object_not_in_prerequisite_state
+}
+
+// GetTopQueries returns up to limit statements of the current database from
+// pg_stat_statements, 20 by default, ordered by orderBy descending: total_time, the
+// default, mean_time, calls or rows. If the extension is not installed or not loaded, the
+// result is marked unavailable with the reason instead of failing.
+func (is *IntrospectionService) GetTopQueries(ctx context.Context, orderBy string, limit int) (*TopQueries, error) {
+	switch orderBy {
+	case "":
+		orderBy = QueryOrderTotalTime
+	case QueryOrderTotalTime, QueryOrderMeanTime, QueryOrderCalls, QueryOrderRows:
+	default:
+		return nil, NewValidationError(fmt.Sprintf("unsupported query order %q", orderBy), nil).
+			WithContext("order_by", orderBy).
+			WithOperation("get_top_queries")
+	}
+	if limit <= 0 {
+		limit = defaultTopQueriesLimit
+	}
+
+	source, err := is.pgStatStatements(ctx)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_top_queries", "failed to check for pg_stat_statements")
+	}
+	if !source.installed {
+		return &TopQueries{
+			Reason:  "pg_stat_statements is not installed in this database; add it to shared_preload_libraries and run CREATE EXTENSION pg_stat_statements",
+			Queries: []QueryStats{},
+		}, nil
+	}
+
+	top := &TopQueries{Available: true, Queries: []QueryStats{}}
+	query := fmt.Sprintf(`
+		SELECT
+			s.queryid,
+			s.query,
+			r.rolname,
+			s.calls,
+			(s.%s * 1000000)::bigint as total_time,
+			(s.%s * 1000000)::bigint as mean_time,
+			s.rows,
+			CASE WHEN s.shared_blks_hit + s.shared_blks_read > 0
+				THEN s.shared_blks_hit::float8 / (s.shared_blks_hit + s.shared_blks_read)
+			END as hit_ratio
+		FROM pg_stat_statements s
+		LEFT JOIN pg_roles r ON r.oid = s.userid
+		WHERE s.dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+		ORDER BY %s DESC
+		LIMIT $1
+	`, source.totalTime, source.meanTime, orderBy)
+
+	err = is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &top.Queries, query, limit)
+	})
+	if isStatementsNotLoaded(err) {
+		return &TopQueries{
+			Reason:  "pg_stat_statements is installed but not loaded; add it to shared_preload_libraries and restart the server",
+			Queries: []QueryStats{},
+		}, nil
+	}
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_top_queries", "failed to read pg_stat_statements")
+	}
+
+	return top, nil
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePredicates(t *testing.T) {
+	known := map[tableKey]map[string]bool{
+		{"public", "orders"}: {"id": true, "user_id": false, "status": false},
+		{"public", "users"}:  {"id": true, "email": false},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []predicateRef
+	}{
+		{
+			name:     "unqualified column",
+			query:    "SELECT * FROM orders WHERE status = $1",
+			expected: []predicateRef{{tableKey{"public", "orders"}, "status"}},
+		},
+		{
+			name:  "aliases and joins",
+			query: `SELECT o.id FROM public.orders AS o JOIN "users" u ON o.user_id = u.id WHERE u.email LIKE $1 AND o.status IN ($2, $3)`,
+			expected: []predicateRef{
+				{tableKey{"public", "orders"}, "user_id"},
+				{tableKey{"public", "users"}, "email"},
+				{tableKey{"public", "orders"}, "status"},
+			},
+		},
+		{
+			name:     "unknown tables and columns",
+			query:    "UPDATE invoices SET paid = true WHERE total > $1 AND customer = $2",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, resolvePredicates(tt.query, known))
+		})
+	}
+}
+
+func TestSuggestIndexes(t *testing.T) {
+	tables := []tableScanStats{
+		{Schema: "public", Name: "orders", SeqScans: 5000, SeqTuplesRead: 250_000_000, IndexScans: 100, TableBytes: 2 << 30},
+		{Schema: "public", Name: "users", SeqScans: 800, SeqTuplesRead: 4_000_000, IndexScans: 90_000, TableBytes: 64 << 20},
+		{Schema: "public", Name: "events", SeqScans: 300, SeqTuplesRead: 60_000, IndexScans: 10, TableBytes: 12 << 20},
+	}
+	columns := []tableColumn{
+		{Schema: "public", Table: "orders", Name: "id", IsIndexed: true},
+		{Schema: "public", Table: "orders", Name: "status"},
+		{Schema: "public", Table: "users", Name: "id", IsIndexed: true},
+		{Schema: "public", Table: "users", Name: "email"},
+		{Schema: "public", Table: "events", Name: "id", IsIndexed: true},
+	}
+	statements := []statementCalls{
+		{Query: "SELECT * FROM orders WHERE status = $1 AND id > $2", Calls: 1200},
+		{Query: "SELECT id FROM users WHERE email = $1", Calls: 300},
+		{Query: "SELECT * FROM orders o WHERE o.status = $1 AND o.status <> $2", Calls: 30},
+	}
+
+	suggestions := suggestIndexes(tables, columns, statements)
+	require.Len(t, suggestions, 3)
+
+	assert.Equal(t, []string{"status"}, suggestions[0].Columns)
+	assert.Equal(t, int64(1230), suggestions[0].Calls)
+	assert.Equal(t, LevelHigh, suggestions[0].Confidence)
+	assert.Equal(t, LevelHigh, suggestions[0].Impact)
+	assert.Equal(t, `CREATE INDEX CONCURRENTLY ON "public"."orders" ("status")`, suggestions[0].Statement)
+
+	// Index scans dominate on users
+	assert.Equal(t, []string{"email"}, suggestions[1].Columns)
+	assert.Equal(t, LevelMedium, suggestions[1].Confidence)
+	assert.Equal(t, LevelMedium, suggestions[1].Impact)
+
+	// No predicates on events
+	assert.Equal(t, "events", suggestions[2].Table)
+	assert.Empty(t, suggestions[2].Columns)
+	assert.Empty(t, suggestions[2].Statement)
+	assert.Equal(t, LevelLow, suggestions[2].Confidence)
+	assert.Equal(t, LevelLow, suggestions[2].Impact)
+}
+
+func TestSuggestIndexesWithoutStatements(t *testing.T) {
+	suggestions := suggestIndexes([]tableScanStats{
+		{Schema: "public", Name: "orders", SeqScans: 5000, SeqTuplesRead: 250_000_000},
+		// Index scans dominate and there are no statements to go on
+		{Schema: "public", Name: "users", SeqScans: 80, IndexScans: 90_000},
+	}, nil, nil)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, "orders", suggestions[0].Table)
+	assert.Equal(t, LevelLow, suggestions[0].Confidence)
+}
+
+func TestGetTopQueriesInvalidOrder(t *testing.T) {
+	is := &IntrospectionService{}
+	_, err := is.GetTopQueries(context.Background(), "planning_time", 10)
+	require.Error(t, err)
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestIsStatementsNotLoaded(t *testing.T) {
+	notLoaded := &pq.Error{Code: "55000", Message: `pg_stat_statements must be loaded via "shared_preload_libraries"`}
+	assert.True(t, isStatementsNotLoaded(notLoaded))
+	assert.True(t, isStatementsNotLoaded(WrapError(notLoaded, ErrCodeQueryFailed, "get_top_queries", "failed")))
+	assert.False(t, isStatementsNotLoaded(&pq.Error{Code: "42P01"}))
+	assert.False(t, isStatementsNotLoaded(errors.New("connection refused")))
+	assert.False(t, isStatementsNotLoaded(nil))
+}
@@ -0,0 +1,336 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+)
+
+// Masking strategies of a MaskingConfig. The fake values are derived from a hash of the
+// original value and the seed, so equal values get equal masks in every table, which keeps
+// joins on masked columns working, and NULL stays NULL.
+const (
+	// MaskEmail replaces the value with user_<hash>@example.com
+	MaskEmail = "email"
+	// MaskName replaces the value with a fake first and last name
+	MaskName = "name"
+	// MaskFirstName replaces the value with a fake first name
+	MaskFirstName = "first_name"
+	// MaskLastName replaces the value with a fake last name
+	MaskLastName = "last_name"
+	// MaskPhone replaces the value with a fake +1555 phone number
+	MaskPhone = "phone"
+	// MaskHash replaces the value with the hex MD5 of the seed and the value
+	MaskHash = "hash"
+	// MaskRedact replaces the value with REDACTED
+	MaskRedact = "redact"
+	// MaskNull replaces the value with NULL
+	MaskNull = "null"
+)
+
+var (
+	maskFirstNames = []string{"Alex", "Blake", "Casey", "Dana", "Eden", "Finley", "Gray", "Harper", "Indy", "Jordan",
+		"Kai", "Logan", "Morgan", "Noel", "Oakley", "Parker", "Quinn", "Reese", "Sage", "Taylor"}
+	maskLastNames = []string{"Adams", "Baker", "Clark", "Davis", "Evans", "Fisher", "Garcia", "Hughes", "Irwin", "Jones",
+		"Kim", "Lopez", "Miller", "Nguyen", "Owens", "Patel", "Reed", "Smith", "Turner", "Walsh"}
+)
+
+// MaskingConfig lists the columns to mask by table, e.g. in YAML:
+//
+//	seed: 8f0c2b7e
+//	tables:
+//	  users:
+//	    email: email
+//	    full_name: name
+//	  billing.cards:
+//	    number: redact
+type MaskingConfig struct {
+	// Seed keys the hash fake values are derived from. Without one a random seed is used, so
+	// masks only match within one run; with a fixed seed they match across runs, but anyone
+	// knowing it can test guesses of the original values.
+	Seed string `yaml:"seed" json:"seed,omitempty"`
+	// Tables maps tables, optionally schema-qualified, to their masked columns and strategies
+	Tables map[string]map[string]string `yaml:"tables" json:"tables"`
+}
+
+// MaskedColumn is a column of a MaskingConfig with its strategy
+type MaskedColumn struct {
+	Table    string `json:"table"`
+	Column   string `json:"column"`
+	Strategy string `json:"strategy"`
+}
+
+// AnonymizedTable is the outcome of masking a table
+type AnonymizedTable struct {
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+}
+
+// LoadMaskingConfig reads a masking config from a YAML or JSON file
+func LoadMaskingConfig(path string) (*MaskingConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewValidationError("failed to read masking config", err).
+			WithContext("path", path).
+			WithOperation("load_masking_config")
+	}
+
+	// JSON is valid YAML, so both formats share the decoder
+	config := &MaskingConfig{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, NewValidationError("invalid masking config", err).
+			WithContext("path", path).
+			WithOperation("load_masking_config")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate checks the table names and strategies of the config
+func (c *MaskingConfig) Validate() error {
+	if len(c.Tables) == 0 {
+		return NewValidationError("masking config lists no tables", nil).
+			WithOperation("validate_masking_config")
+	}
+	for table, columns := range c.Tables {
+		if _, _, err := splitTableName(table); err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			return NewValidationError(fmt.Sprintf("masking config lists no columns of %s", table), nil).
+				WithContext("table", table).
+				WithOperation("validate_masking_config")
+		}
+		for column, strategy := range columns {
+			if _, err := maskExpression(strategy, column, ""); err != nil {
+				return NewValidationError(fmt.Sprintf("column %s of %s: unknown masking strategy %q", column, table, strategy), nil).
+					WithContext("table", table).
+					WithContext("column", column).
+					WithOperation("validate_masking_config")
+			}
+		}
+	}
+	return nil
+}
+
+// TableMasks returns the masked columns of table, matching names with and without the
+// public schema, or nil if the table is not masked
+func (c *MaskingConfig) TableMasks(table string) map[string]string {
+	target := qualifiedTableName(table)
+	for name, columns := range c.Tables {
+		if qualifiedTableName(name) == target {
+			return columns
+		}
+	}
+	return nil
+}
+
+// Columns returns the masked columns of the config, sorted by table and column
+func (c *MaskingConfig) Columns() []MaskedColumn {
+	columns := []MaskedColumn{}
+	for table, masks := range c.Tables {
+		for column, strategy := range masks {
+			columns = append(columns, MaskedColumn{Table: table, Column: column, Strategy: strategy})
+		}
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		if columns[i].Table != columns[j].Table {
+			return columns[i].Table < columns[j].Table
+		}
+		return columns[i].Column < columns[j].Column
+	})
+	return columns
+}
+
+// seed returns the seed of the config, or a random one
+func (c *MaskingConfig) seed() (string, error) {
+	if c.Seed != "" {
+		return c.Seed, nil
+	}
+	return randomMaskSeed()
+}
+
+// Anonymize masks the columns of the config in place, in one transaction, and returns the
+// rows updated by table. It is meant for copies of production, e.g. a clone or restored
+// backup about to be shared; the original values cannot be recovered.
+func (d *DB) Anonymize(ctx context.Context, config *MaskingConfig) ([]AnonymizedTable, error) {
+	results, err := d.anonymize(ctx, config)
+	if config != nil {
+		tables := make([]string, 0, len(config.Tables))
+		for table := range config.Tables {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+		var rows int64
+		for _, result := range results {
+			rows += result.Rows
+		}
+		d.recordAudit(ctx, "anonymize", "", tables, rows, err)
+	}
+	return results, err
+}
+
+// anonymize masks the tables of Anonymize
+func (d *DB) anonymize(ctx context.Context, config *MaskingConfig) ([]AnonymizedTable, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	seed, err := config.seed()
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0, len(config.Tables))
+	for table := range config.Tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeTransactionBegin, "anonymize", "failed to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	results := make([]AnonymizedTable, 0, len(tables))
+	for _, table := range tables {
+		statement, columns, err := anonymizeStatement(table, config.Tables[table], seed)
+		if err != nil {
+			return nil, err
+		}
+		result, err := tx.ExecContext(ctx, statement)
+		if err != nil {
+			return nil, WrapError(err, ErrCodeQueryFailed, "anonymize", "failed to mask table").
+				WithContext("table", table).
+				WithContext("columns", columns)
+		}
+		rows, _ := result.RowsAffected()
+		results = append(results, AnonymizedTable{Table: table, Columns: columns, Rows: rows})
+		d.logger.Info("table anonymized", "table", table, "columns", len(columns), "rows", rows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, WrapError(err, ErrCodeTransactionCommit, "anonymize", "failed to commit anonymization")
+	}
+	return results, nil
+}
+
+// anonymizeStatement returns the UPDATE masking the columns of table, and the columns in
+// their order in it
+func anonymizeStatement(table string, masks map[string]string, seed string) (string, []string, error) {
+	quoted, err := quoteQualifiedName(table)
+	if err != nil {
+		return "", nil, err
+	}
+	columns := make([]string, 0, len(masks))
+	for column := range masks {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		expression, err := maskExpression(masks[column], pq.QuoteIdentifier(column), seed)
+		if err != nil {
+			return "", nil, err
+		}
+		assignments[i] = pq.QuoteIdentifier(column) + " = " + expression
+	}
+	return fmt.Sprintf("UPDATE %s SET %s", quoted, strings.Join(assignments, ", ")), columns, nil
+}
+
+// maskSelection wraps an export selection so that the masked columns among columns are
+// replaced by their fake values
+func maskSelection(selection string, columns []string, masks map[string]string, seed string) (string, error) {
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+	for column := range masks {
+		if !known[column] {
+			return "", NewValidationError(fmt.Sprintf("masked column %q is not exported", column), nil).
+				WithContext("column", column).
+				WithOperation("export_data")
+		}
+	}
+
+	selected := make([]string, len(columns))
+	for i, column := range columns {
+		reference := "t." + pq.QuoteIdentifier(column)
+		selected[i] = reference
+		if strategy, ok := masks[column]; ok {
+			expression, err := maskExpression(strategy, reference, seed)
+			if err != nil {
+				return "", err
+			}
+			selected[i] = expression + " AS " + pq.QuoteIdentifier(column)
+		}
+	}
+	return fmt.Sprintf("(SELECT %s FROM %s) t", strings.Join(selected, ", "), selection), nil
+}
+
+// maskExpression returns the SQL expression masking the value of column, an SQL
+// expression, with strategy
+func maskExpression(strategy, column, seed string) (string, error) {
+	hash := fmt.Sprintf("md5(%s || %s::text)", pq.QuoteLiteral(seed), column)
+	// hashInt takes 28 bits of the hash from offset, a non-negative int
+	hashInt := func(offset int) string {
+		return fmt.Sprintf("('x' || substr(%s, %d, 7))::bit(28)::int", hash, offset)
+	}
+	pick := func(names []string, offset int) string {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = pq.QuoteLiteral(name)
+		}
+		return fmt.Sprintf("(ARRAY[%s])[1 + %s %% %d]", strings.Join(quoted, ", "), hashInt(offset), len(names))
+	}
+
+	switch strategy {
+	case MaskEmail:
+		return fmt.Sprintf("'user_' || substr(%s, 1, 12) || '@example.com'", hash), nil
+	case MaskName:
+		return pick(maskFirstNames, 1) + " || ' ' || " + pick(maskLastNames, 9), nil
+	case MaskFirstName:
+		return pick(maskFirstNames, 1), nil
+	case MaskLastName:
+		return pick(maskLastNames, 9), nil
+	case MaskPhone:
+		return fmt.Sprintf("'+1555' || lpad((%s %% 10000000)::text, 7, '0')", hashInt(17)), nil
+	case MaskHash:
+		return hash, nil
+	case MaskRedact:
+		return fmt.Sprintf("CASE WHEN %s IS NULL THEN NULL ELSE 'REDACTED' END", column), nil
+	case MaskNull:
+		return "NULL", nil
+	}
+	return "", NewValidationError(fmt.Sprintf("unknown masking strategy %q, expected one of %s", strategy,
+		strings.Join([]string{MaskEmail, MaskName, MaskFirstName, MaskLastName, MaskPhone, MaskHash, MaskRedact, MaskNull}, ", ")), nil).
+		WithContext("strategy", strategy)
+}
+
+// qualifiedTableName returns name qualified with the public schema if it has none
+func qualifiedTableName(name string) string {
+	if !strings.Contains(name, ".") {
+		return "public." + name
+	}
+	return name
+}
+
+// randomMaskSeed returns a seed for a single run
+func randomMaskSeed() (string, error) {
+	seed := make([]byte, 16)
+	if _, err := rand.Read(seed); err != nil {
+		return "", WrapError(err, ErrCodeInternal, "anonymize", "failed to generate a masking seed")
+	}
+	return hex.EncodeToString(seed), nil
+}
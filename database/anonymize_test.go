@@ -0,0 +1,133 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMaskingConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "masking.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+seed: s3cret
+tables:
+  users:
+    email: email
+    full_name: name
+  billing.cards:
+    number: redact
+`), 0o600))
+
+	config, err := LoadMaskingConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", config.Seed)
+	assert.Equal(t, []MaskedColumn{
+		{Table: "billing.cards", Column: "number", Strategy: MaskRedact},
+		{Table: "users", Column: "email", Strategy: MaskEmail},
+		{Table: "users", Column: "full_name", Strategy: MaskName},
+	}, config.Columns())
+
+	assert.Equal(t, map[string]string{"email": MaskEmail, "full_name": MaskName}, config.TableMasks("public.users"))
+	assert.Equal(t, map[string]string{"number": MaskRedact}, config.TableMasks("billing.cards"))
+	assert.Nil(t, config.TableMasks("cards"))
+
+	for name, content := range map[string]string{
+		"empty.yaml":    "seed: x\n",
+		"strategy.yaml": "tables:\n  users:\n    email: scramble\n",
+		"columns.yaml":  "tables:\n  users: {}\n",
+		"table.yaml":    "tables:\n  a.b.c:\n    email: email\n",
+		"invalid.yaml":  "tables: [",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := LoadMaskingConfig(path)
+		assert.Equal(t, ErrCodeValidation, GetErrorCode(err), name)
+	}
+
+	_, err = LoadMaskingConfig(filepath.Join(dir, "missing.yaml"))
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestAnonymizeStatement(t *testing.T) {
+	statement, columns, err := anonymizeStatement("billing.cards", map[string]string{"number": MaskRedact, "holder": MaskNull}, "seed")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"holder", "number"}, columns)
+	assert.Equal(t, `UPDATE "billing"."cards" SET "holder" = NULL, "number" = CASE WHEN "number" IS NULL THEN NULL ELSE 'REDACTED' END`, statement)
+
+	statement, _, err = anonymizeStatement("users", map[string]string{"email": MaskEmail}, "it's")
+	require.NoError(t, err)
+	assert.Equal(t, `UPDATE "users" SET "email" = 'user_' || substr(md5('it''s' || "email"::text), 1, 12) || '@example.com'`, statement)
+}
+
+func TestMaskSelection(t *testing.T) {
+	selection, err := maskSelection(`(SELECT * FROM "users") t`, []string{"id", "email"}, map[string]string{"email": MaskHash}, "seed")
+	require.NoError(t, err)
+	assert.Equal(t, `(SELECT t."id", md5('seed' || t."email"::text) AS "email" FROM (SELECT * FROM "users") t) t`, selection)
+
+	_, err = maskSelection(`(SELECT * FROM "users") t`, []string{"id"}, map[string]string{"email": MaskHash}, "seed")
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestAnonymize(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+	ctx := context.Background()
+
+	_, err := db.db.ExecContext(ctx, `CREATE TABLE test_anonymize (id int PRIMARY KEY, email text, full_name text, phone text, notes text)`)
+	require.NoError(t, err)
+	defer db.db.ExecContext(ctx, "DROP TABLE IF EXISTS test_anonymize")
+	_, err = db.db.ExecContext(ctx, `INSERT INTO test_anonymize VALUES
+		(1, 'ada@example.org', 'Ada Lovelace', '+44 20 7946 0000', 'secret'),
+		(2, 'ada@example.org', NULL, NULL, NULL)`)
+	require.NoError(t, err)
+
+	config := &MaskingConfig{Seed: "seed", Tables: map[string]map[string]string{
+		"test_anonymize": {"email": MaskEmail, "full_name": MaskName, "phone": MaskPhone, "notes": MaskRedact},
+	}}
+
+	// Masked exports leave the table untouched
+	var exported bytes.Buffer
+	_, err = db.ExportData(ctx, &exported, ExportDataOptions{
+		Table:    "test_anonymize",
+		Format:   DataFormatCSV,
+		Where:    "id = 1",
+		Masks:    config.TableMasks("test_anonymize"),
+		MaskSeed: config.Seed,
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, exported.String(), "ada@example.org")
+	assert.Contains(t, exported.String(), "REDACTED")
+
+	tables, err := db.Anonymize(ctx, config)
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+	assert.Equal(t, int64(2), tables[0].Rows)
+
+	type row struct {
+		Email    string  `db:"email"`
+		FullName *string `db:"full_name"`
+		Phone    *string `db:"phone"`
+		Notes    *string `db:"notes"`
+	}
+	var rows []row
+	require.NoError(t, db.db.SelectContext(ctx, &rows, "SELECT email, full_name, phone, notes FROM test_anonymize ORDER BY id"))
+	require.Len(t, rows, 2)
+	assert.True(t, strings.HasPrefix(rows[0].Email, "user_"), rows[0].Email)
+	assert.Equal(t, rows[0].Email, rows[1].Email)
+	assert.Contains(t, exported.String(), rows[0].Email)
+	require.NotNil(t, rows[0].FullName)
+	assert.Contains(t, *rows[0].FullName, " ")
+	require.NotNil(t, rows[0].Phone)
+	assert.Regexp(t, `^\+1555\d{7}$`, *rows[0].Phone)
+	assert.Equal(t, "REDACTED", *rows[0].Notes)
+	assert.Nil(t, rows[1].FullName)
+	assert.Nil(t, rows[1].Phone)
+	assert.Nil(t, rows[1].Notes)
+}
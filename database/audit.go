@@ -0,0 +1,426 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// auditTable records the entries of the table audit sink
+const auditTable = "dbkit_audit"
+
+// AuditEntry records a sensitive operation: a statement that is not a read, run through DB
+// or Transaction, or a restore or anonymization
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// Actor is the actor of the context, see WithActor, or the database user
+	Actor     string `json:"actor"`
+	Database  string `json:"database"`
+	Operation string `json:"operation"`
+	// Fingerprint identifies the statement independently of its literal values, which
+	// Statement has replaced with ?, so entries hold no row data
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Statement   string   `json:"statement,omitempty"`
+	Tables      []string `json:"tables,omitempty"`
+	// Target is what an operation without a statement acted on, e.g. the backup restored
+	Target string `json:"target,omitempty"`
+	// Rows is the number of rows affected, -1 if unknown
+	Rows  int64  `json:"rows"`
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink stores audit entries, see NewTableAuditSink, NewJSONAuditSink and
+// NewFileAuditSink
+type AuditSink interface {
+	Record(ctx context.Context, entry AuditEntry) error
+}
+
+// AuditSinkFunc adapts a function to AuditSink
+type AuditSinkFunc func(ctx context.Context, entry AuditEntry) error
+
+// Record implements AuditSink
+func (f AuditSinkFunc) Record(ctx context.Context, entry AuditEntry) error {
+	return f(ctx, entry)
+}
+
+type actorKey struct{}
+
+// WithActor returns ctx with the actor recorded in the audit entries of its operations,
+// e.g. the user of a request or the operator running the CLI
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set with WithActor, or an empty string
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// auditRecorder is the Hook recording the sensitive statements of a DB with
+// Config.AuditSinks
+type auditRecorder struct {
+	BaseHook
+	sinks    []AuditSink
+	database string
+	user     string
+	logger   *slog.Logger
+}
+
+// newAuditRecorder returns the recorder of a DB, or nil without Config.AuditSinks. Table
+// sinks without a DB record into the DB being created.
+func newAuditRecorder(config Config, logger *slog.Logger, db *DB) *auditRecorder {
+	if len(config.AuditSinks) == 0 {
+		return nil
+	}
+	recorder := &auditRecorder{database: config.DBName, user: config.User, logger: logger}
+	for _, sink := range config.AuditSinks {
+		if table, ok := sink.(*tableAuditSink); ok && table.db == nil {
+			sink = &tableAuditSink{db: db}
+		}
+		recorder.sinks = append(recorder.sinks, sink)
+	}
+	return recorder
+}
+
+func (r *auditRecorder) AfterQuery(ctx context.Context, event *QueryEvent) {
+	var tables []string
+	sensitive := false
+	for _, statement := range SplitStatements(event.Query) {
+		if isReadStatement(statement) {
+			continue
+		}
+		sensitive = true
+		for _, table := range statementTables(statement.SQL) {
+			if !slices.Contains(tables, table) {
+				tables = append(tables, table)
+			}
+		}
+	}
+	if !sensitive {
+		return
+	}
+
+	statement := normalizeStatement(event.Query)
+	entry := r.entry(ctx, event.Operation, event.Err)
+	entry.Time = event.Start
+	entry.Fingerprint = statementFingerprint(statement)
+	entry.Statement = shortenQuery(statement, queryLogLength)
+	entry.Tables = tables
+	if event.Err == nil {
+		entry.Rows = event.Rows
+	}
+	r.record(ctx, entry)
+}
+
+// entry returns an entry of operation in ctx, failed with err if set
+func (r *auditRecorder) entry(ctx context.Context, operation string, err error) AuditEntry {
+	actor := ActorFromContext(ctx)
+	if actor == "" {
+		actor = r.user
+	}
+	entry := AuditEntry{
+		Time:      time.Now(),
+		Actor:     actor,
+		Database:  r.database,
+		Operation: operation,
+		Rows:      -1,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+// record passes entry to every sink. Failures are logged rather than failing the
+// operation, and entries are recorded even if the operation's context was canceled.
+func (r *auditRecorder) record(ctx context.Context, entry AuditEntry) {
+	ctx = context.WithoutCancel(ctx)
+	for _, sink := range r.sinks {
+		if err := sink.Record(ctx, entry); err != nil {
+			r.logger.Error("failed to record audit entry",
+				slog.String("operation", entry.Operation),
+				slog.Any("error", err))
+		}
+	}
+}
+
+// recordAudit records an operation of DB that does not run through the hooks
+func (d *DB) recordAudit(ctx context.Context, operation, target string, tables []string, rows int64, err error) {
+	if d.audit == nil {
+		return
+	}
+	entry := d.audit.entry(ctx, operation, err)
+	entry.Target = target
+	entry.Tables = tables
+	if err == nil {
+		entry.Rows = rows
+	}
+	d.audit.record(ctx, entry)
+}
+
+// tableAuditSink inserts entries into the dbkit_audit table, creating it when missing
+type tableAuditSink struct {
+	db *DB
+}
+
+// NewTableAuditSink returns a sink inserting entries into the dbkit_audit table of db,
+// created on first use. With a nil db, entries go to the database the sink is configured
+// on. Entries are inserted on their own connection, so they are kept when the audited
+// transaction rolls back.
+func NewTableAuditSink(db *DB) AuditSink {
+	return &tableAuditSink{db: db}
+}
+
+func (s *tableAuditSink) Record(ctx context.Context, entry AuditEntry) error {
+	if s.db == nil {
+		return NewConfigError("the audit table sink has no database", nil).
+			WithOperation("record_audit")
+	}
+	err := s.insert(ctx, entry)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "42P01" { // undefined_table
+		if _, err := s.db.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+auditTable+` (
+			id bigserial PRIMARY KEY,
+			recorded_at timestamptz NOT NULL,
+			actor text NOT NULL,
+			database text NOT NULL,
+			operation text NOT NULL,
+			fingerprint text,
+			statement text,
+			tables text[] NOT NULL DEFAULT '{}',
+			target text,
+			rows bigint,
+			error text
+		)`); err != nil {
+			return WrapError(err, ErrCodeQueryFailed, "record_audit", "failed to create the audit table")
+		}
+		err = s.insert(ctx, entry)
+	}
+	if err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "record_audit", "failed to insert the audit entry")
+	}
+	return nil
+}
+
+func (s *tableAuditSink) insert(ctx context.Context, entry AuditEntry) error {
+	var rows *int64
+	if entry.Rows >= 0 {
+		rows = &entry.Rows
+	}
+	tables := pq.StringArray{}
+	tables = append(tables, entry.Tables...)
+	_, err := s.db.db.ExecContext(ctx, `INSERT INTO `+auditTable+`
+		(recorded_at, actor, database, operation, fingerprint, statement, tables, target, rows, error)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, NULLIF($8, ''), $9, NULLIF($10, ''))`,
+		entry.Time, entry.Actor, entry.Database, entry.Operation, entry.Fingerprint, entry.Statement,
+		tables, entry.Target, rows, entry.Error)
+	return err
+}
+
+// jsonAuditSink writes entries as JSON lines
+type jsonAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONAuditSink returns a sink writing entries to w as JSON lines
+func NewJSONAuditSink(w io.Writer) AuditSink {
+	return &jsonAuditSink{w: w}
+}
+
+func (s *jsonAuditSink) Record(_ context.Context, entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return NewDBError(ErrCodeInternal, "failed to encode the audit entry", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return NewDBError(ErrCodeInternal, "failed to write the audit entry", err)
+	}
+	return nil
+}
+
+// fileAuditSink appends entries to a file as JSON lines
+type fileAuditSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileAuditSink returns a sink appending entries to the file at path as JSON lines,
+// creating it if needed
+func NewFileAuditSink(path string) AuditSink {
+	return &fileAuditSink{path: path}
+}
+
+func (s *fileAuditSink) Record(ctx context.Context, entry AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return NewDBError(ErrCodeInternal, "failed to open the audit file", err).
+			WithContext("path", s.path)
+	}
+	if err := NewJSONAuditSink(file).Record(ctx, entry); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// auditSinksFromEnv returns the sinks of DBKIT_AUDIT, a comma-separated list of "table"
+// for the dbkit_audit table of the database and JSON lines file paths
+func auditSinksFromEnv() []AuditSink {
+	var sinks []AuditSink
+	for _, target := range strings.Split(os.Getenv("DBKIT_AUDIT"), ",") {
+		switch target = strings.TrimSpace(target); target {
+		case "":
+		case "table":
+			sinks = append(sinks, NewTableAuditSink(nil))
+		default:
+			sinks = append(sinks, NewFileAuditSink(target))
+		}
+	}
+	return sinks
+}
+
+// normalizeStatement returns sql with its literals and parameters replaced by ?, lists of
+// them collapsed into one, unquoted words lower-cased and comments removed
+func normalizeStatement(sql string) string {
+	var words []string
+	for _, token := range tokenizeSQL(sql) {
+		text := token.text
+		switch token.kind {
+		case tokenWord:
+			text = strings.ToLower(text)
+		case tokenLiteral, tokenParam:
+			text = "?"
+			// (?, ?, ?) becomes (?)
+			if n := len(words); n >= 2 && words[n-1] == "," && words[n-2] == "?" {
+				words = words[:n-1]
+				continue
+			}
+		}
+		words = append(words, text)
+	}
+
+	var b strings.Builder
+	for i, word := range words {
+		if i > 0 && !noSpaceAfter[words[i-1]] && !noSpaceBefore[word] {
+			b.WriteByte(' ')
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// noSpaceAfter and noSpaceBefore are the punctuation normalizeStatement writes without a
+// space after or before
+var (
+	noSpaceAfter  = map[string]bool{"(": true, "[": true, ".": true, ":": true}
+	noSpaceBefore = map[string]bool{",": true, ")": true, "]": true, ".": true, ":": true, ";": true}
+)
+
+// statementFingerprint returns a short hash of a normalized statement
+func statementFingerprint(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+// statementTables returns the tables a statement writes to or changes, e.g. the target of
+// INSERT INTO or ALTER TABLE, on a best effort basis
+func statementTables(sql string) []string {
+	tokens := tokenizeSQL(sql)
+	var tables []string
+	add := func(i int, list bool) {
+		for {
+			for i < len(tokens) && tokens[i].kind == tokenWord && tableNameSkipWords[strings.ToUpper(tokens[i].text)] {
+				i++
+			}
+			name, next := tokenTableName(tokens, i)
+			if name == "" {
+				return
+			}
+			if !slices.Contains(tables, name) {
+				tables = append(tables, name)
+			}
+			if !list || next >= len(tokens) || tokens[next].text != "," {
+				return
+			}
+			i = next + 1
+		}
+	}
+
+	for i, token := range tokens {
+		if token.kind != tokenWord {
+			continue
+		}
+		prev := ""
+		if i > 0 {
+			prev = strings.ToUpper(tokens[i-1].text)
+		}
+		switch strings.ToUpper(token.text) {
+		case "INTO":
+			if prev == "INSERT" || prev == "MERGE" {
+				add(i+1, false)
+			}
+		case "UPDATE":
+			// Only statements and CTEs, not FOR UPDATE or ON CONFLICT DO UPDATE
+			if prev == "" || prev == "(" {
+				add(i+1, false)
+			}
+		case "FROM":
+			if prev == "DELETE" {
+				add(i+1, false)
+			}
+		case "TRUNCATE":
+			add(i+1, true)
+		case "COPY":
+			if i == 0 {
+				add(i+1, false)
+			}
+		case "TABLE":
+			switch prev {
+			case "ALTER", "CREATE", "UNLOGGED", "TEMP", "TEMPORARY":
+				add(i+1, false)
+			case "DROP":
+				add(i+1, true)
+			}
+		}
+	}
+	return tables
+}
+
+// tableNameSkipWords may precede a table name
+var tableNameSkipWords = map[string]bool{"TABLE": true, "ONLY": true, "IF": true, "NOT": true, "EXISTS": true}
+
+// tokenTableName returns the possibly schema-qualified name starting at tokens[i], with
+// unquoted parts lower-cased, and the index after it; the name is empty if there is none
+func tokenTableName(tokens []sqlToken, i int) (string, int) {
+	var parts []string
+	for i < len(tokens) && (tokens[i].kind == tokenWord || tokens[i].kind == tokenQuoted) {
+		part := tokens[i].text
+		if tokens[i].kind == tokenWord {
+			part = strings.ToLower(part)
+		}
+		parts = append(parts, part)
+		i++
+		if i+1 >= len(tokens) || tokens[i].text != "." {
+			break
+		}
+		i++
+	}
+	return strings.Join(parts, "."), i
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newAuditTestDB(sinks ...AuditSink) *DB {
+	config := Config{DBName: "app", User: "postgres", AuditSinks: sinks}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db := &DB{config: config, logger: logger}
+	db.audit = newAuditRecorder(config, logger, db)
+	db.hooks = newHooks(config, logger, db.audit)
+	return db
+}
+
+func TestAuditRecorder(t *testing.T) {
+	var entries []AuditEntry
+	db := newAuditTestDB(AuditSinkFunc(func(_ context.Context, entry AuditEntry) error {
+		entries = append(entries, entry)
+		return nil
+	}))
+
+	ctx := WithActor(context.Background(), "alice")
+	run := func(query string, rows int64, err error) {
+		_ = db.withQueryHooks(ctx, &QueryEvent{Operation: "transaction_exec_context", Query: query}, func(context.Context) (int64, error) {
+			return rows, err
+		})
+	}
+
+	run("SELECT * FROM users WHERE id = 1", 1, nil)
+	if len(entries) != 0 {
+		t.Fatalf("Expected reads not to be audited, got %+v", entries)
+	}
+
+	run("UPDATE users SET email = 'a@example.com' WHERE id = 42", 1, nil)
+	if len(entries) != 1 {
+		t.Fatalf("Expected one entry, got %+v", entries)
+	}
+	entry := entries[0]
+	if entry.Actor != "alice" || entry.Database != "app" || entry.Operation != "transaction_exec_context" || entry.Rows != 1 {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+	if entry.Statement != "update users set email = ? where id = ?" || strings.Contains(entry.Statement, "example.com") {
+		t.Errorf("Expected the statement without its values, got %q", entry.Statement)
+	}
+	if !slices.Equal(entry.Tables, []string{"users"}) || entry.Fingerprint == "" {
+		t.Errorf("Expected the users table and a fingerprint, got %+v", entry)
+	}
+
+	// The same statement with other values has the same fingerprint
+	run("UPDATE users SET email = 'b@example.com' WHERE id = 7", 1, nil)
+	if entries[1].Fingerprint != entry.Fingerprint {
+		t.Errorf("Expected the fingerprint %s, got %s", entry.Fingerprint, entries[1].Fingerprint)
+	}
+
+	// Failures are recorded, and the actor defaults to the database user
+	ctx = context.Background()
+	run("DROP TABLE orders", -1, errors.New("permission denied"))
+	entry = entries[2]
+	if entry.Actor != "postgres" || entry.Error != "permission denied" || entry.Rows != -1 || !slices.Equal(entry.Tables, []string{"orders"}) {
+		t.Errorf("Unexpected entry for a failed statement %+v", entry)
+	}
+
+	db.recordAudit(ctx, "restore", "/backups/app.dump", nil, -1, nil)
+	if entry = entries[3]; entry.Operation != "restore" || entry.Target != "/backups/app.dump" {
+		t.Errorf("Unexpected entry for a restore %+v", entry)
+	}
+}
+
+func TestAuditRecorderDisabled(t *testing.T) {
+	db := newAuditTestDB()
+	if db.audit != nil || len(db.hooks) != 0 {
+		t.Errorf("Expected no recorder without sinks")
+	}
+	db.recordAudit(context.Background(), "restore", "/backups/app.dump", nil, -1, nil)
+}
+
+func TestAuditSinks(t *testing.T) {
+	entry := AuditEntry{Actor: "alice", Database: "app", Operation: "restore", Rows: -1}
+
+	var buf bytes.Buffer
+	if err := NewJSONAuditSink(&buf).Record(context.Background(), entry); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	var decoded AuditEntry
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Actor != "alice" {
+		t.Errorf("Expected a JSON line, got %q (%v)", buf.String(), err)
+	}
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink := NewFileAuditSink(path)
+	for range 2 {
+		if err := sink.Record(context.Background(), entry); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || strings.Count(string(data), "\n") != 2 {
+		t.Errorf("Expected two appended lines, got %q (%v)", data, err)
+	}
+
+	if err := NewTableAuditSink(nil).Record(context.Background(), entry); GetErrorCode(err) != ErrCodeInvalidConfig {
+		t.Errorf("Expected a config error for a table sink without a database, got %v", err)
+	}
+
+	t.Setenv("DBKIT_AUDIT", "table, "+path)
+	sinks := auditSinksFromEnv()
+	if len(sinks) != 2 {
+		t.Fatalf("Expected two sinks, got %d", len(sinks))
+	}
+	if _, ok := sinks[0].(*tableAuditSink); !ok {
+		t.Errorf("Expected a table sink, got %T", sinks[0])
+	}
+	db := newAuditTestDB(sinks...)
+	if table := db.audit.sinks[0].(*tableAuditSink); table.db != db {
+		t.Errorf("Expected the table sink to record into its DB")
+	}
+}
+
+func TestNormalizeStatement(t *testing.T) {
+	tests := map[string]string{
+		"SELECT *\n  FROM Users -- all\n WHERE id = $1":                           "select * from users where id = ?",
+		"INSERT INTO t (a, b) VALUES (1, 'x'), (2, E'y\\'z')":                     "insert into t (a, b) values (?), (?)",
+		`UPDATE "Orders" SET total = 1.5e3, note = $$a;b$$ WHERE id IN (1, 2, 3)`: `update "Orders" set total = ?, note = ? where id in (?)`,
+		"SELECT a::text FROM s.t":                                                 "select a::text from s.t",
+	}
+	for sql, want := range tests {
+		if got := normalizeStatement(sql); got != want {
+			t.Errorf("normalizeStatement(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+func TestStatementTables(t *testing.T) {
+	tests := map[string][]string{
+		"INSERT INTO public.users (name) VALUES ('a') ON CONFLICT (name) DO UPDATE SET name = 'b'": {"public.users"},
+		"UPDATE ONLY Orders SET total = 0":                                                     {"orders"},
+		"DELETE FROM audit.events WHERE id = 1":                                                {"audit.events"},
+		"TRUNCATE TABLE a, b.c RESTART IDENTITY":                                               {"a", "b.c"},
+		"DROP TABLE IF EXISTS x, \"Y\"":                                                        {"x", `"Y"`},
+		"ALTER TABLE IF EXISTS ONLY users ADD COLUMN age int":                                  {"users"},
+		"CREATE UNLOGGED TABLE IF NOT EXISTS cache (k text)":                                   {"cache"},
+		"WITH gone AS (DELETE FROM users RETURNING id) INSERT INTO archive SELECT * FROM gone": {"users", "archive"},
+		"SELECT * FROM users FOR UPDATE":                                                       nil,
+		"GRANT SELECT, UPDATE ON users TO app":                                                 nil,
+	}
+	for sql, want := range tests {
+		if got := statementTables(sql); !slices.Equal(got, want) {
+			t.Errorf("statementTables(%q) = %v, want %v", sql, got, want)
+		}
+	}
+}
@@ -2,24 +2,224 @@
 package database
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
+// Supported pg_dump output formats
+const (
+	FormatPlain     = "plain"
+	FormatCustom    = "custom"
+	FormatDirectory = "directory"
+)
+
+// BackupOptions configures how a backup is produced
+type BackupOptions struct {
+	// Compression codec for the output file: "none" (default), "gzip" or "zstd"
+	Compression string `json:"compression,omitempty"`
+	// CompressionLevel is codec specific (gzip 1-9, zstd 1-22); 0 selects the codec default
+	CompressionLevel int `json:"compression_level,omitempty"`
+	// Format is the pg_dump output format: "plain" (default), "custom" or "directory"
+	Format string `json:"format,omitempty"`
+	// Jobs dumps this many tables in parallel; requires the directory format
+	Jobs int `json:"jobs,omitempty"`
+
+	// SchemaOnly dumps only object definitions, no data
+	SchemaOnly bool `json:"schema_only,omitempty"`
+	// DataOnly dumps only data, no object definitions
+	DataOnly bool `json:"data_only,omitempty"`
+	// IncludeTables restricts the dump to tables matching these patterns, e.g. "tenant_42.*"
+	IncludeTables []string `json:"include_tables,omitempty"`
+	// ExcludeTables skips tables matching these patterns
+	ExcludeTables []string `json:"exclude_tables,omitempty"`
+	// ExcludeSchemas skips schemas matching these patterns
+	ExcludeSchemas []string `json:"exclude_schemas,omitempty"`
+	// NoOwner omits commands setting object ownership
+	NoOwner bool `json:"no_owner,omitempty"`
+	// NoPrivileges omits GRANT and REVOKE commands
+	NoPrivileges bool `json:"no_privileges,omitempty"`
+
+	// Recipients are age public keys (age1...) the backup is encrypted to, in addition to
+	// the recipient of Config.BackupEncryptionKey
+	Recipients []string `json:"recipients,omitempty"`
+}
+
+// Validate checks that the options are consistent
+func (o BackupOptions) Validate() error {
+	if err := validateCompression(o.Compression, o.CompressionLevel); err != nil {
+		return WrapError(err, ErrCodeValidation, "validate_backup_options", "invalid backup options")
+	}
+
+	switch o.Format {
+	case "", FormatPlain, FormatCustom, FormatDirectory:
+	default:
+		return NewValidationError(fmt.Sprintf("unsupported backup format %q, expected plain, custom or directory", o.Format), nil).
+			WithContext("format", o.Format).
+			WithOperation("validate_backup_options")
+	}
+
+	if o.Jobs < 0 {
+		return NewValidationError(fmt.Sprintf("invalid number of jobs %d", o.Jobs), nil).
+			WithContext("jobs", o.Jobs).
+			WithOperation("validate_backup_options")
+	}
+	if o.Jobs > 1 && o.Format != FormatDirectory {
+		return NewValidationError("parallel backups require the directory format", nil).
+			WithContext("jobs", o.Jobs).
+			WithContext("format", o.Format).
+			WithOperation("validate_backup_options")
+	}
+	if o.SchemaOnly && o.DataOnly {
+		return NewValidationError("schema-only and data-only backups are mutually exclusive", nil).
+			WithOperation("validate_backup_options")
+	}
+	for _, patterns := range [][]string{o.IncludeTables, o.ExcludeTables, o.ExcludeSchemas} {
+		for _, pattern := range patterns {
+			if strings.TrimSpace(pattern) == "" {
+				return NewValidationError("table and schema patterns must not be empty", nil).
+					WithOperation("validate_backup_options")
+			}
+		}
+	}
+	if o.Format == FormatDirectory && o.compressed() {
+		return NewValidationError("directory backups cannot be compressed into a single file", nil).
+			WithContext("compression", o.Compression).
+			WithOperation("validate_backup_options")
+	}
+	if _, err := parseRecipients(o.Recipients); err != nil {
+		return WrapError(err, ErrCodeValidation, "validate_backup_options", "invalid backup options")
+	}
+	if o.Format == FormatDirectory && o.encrypted() {
+		return NewValidationError("directory backups cannot be encrypted", nil).
+			WithOperation("validate_backup_options")
+	}
+	return nil
+}
+
+// fullDump reports whether the backup contains every table of the database
+func (o BackupOptions) fullDump() bool {
+	return !o.DataOnly && len(o.IncludeTables) == 0 && len(o.ExcludeTables) == 0 && len(o.ExcludeSchemas) == 0
+}
+
+// compressed reports whether the output is compressed by db-kit
+func (o BackupOptions) compressed() bool {
+	return o.Compression != "" && o.Compression != CompressionNone
+}
+
+// encrypted reports whether the output is encrypted by db-kit
+func (o BackupOptions) encrypted() bool {
+	return len(o.Recipients) > 0
+}
+
+// streamed reports whether pg_dump output is piped through db-kit rather than written by
+// pg_dump itself
+func (o BackupOptions) streamed() bool {
+	return o.compressed() || o.encrypted()
+}
+
+// extension returns the file extension for a backup produced with these options
+func (o BackupOptions) extension() string {
+	switch o.Format {
+	case FormatDirectory:
+		return ""
+	case FormatCustom:
+		return ".dump" + compressionExtension(o.Compression) + o.encryptionExtension()
+	default:
+		return ".sql" + compressionExtension(o.Compression) + o.encryptionExtension()
+	}
+}
+
+func (o BackupOptions) encryptionExtension() string {
+	if o.encrypted() {
+		return encryptionExtension
+	}
+	return ""
+}
+
+// RestoreOptions configures how a backup is restored
+type RestoreOptions struct {
+	// Clean drops database objects before recreating them. Plain SQL backups carry their
+	// own DROP statements, which are skipped when Clean is false.
+	Clean bool
+	// CreateDB creates the target database before restoring into it
+	CreateDB bool
+	// TargetDBName restores into this database instead of the configured one
+	TargetDBName string
+	// Jobs restores this many tables in parallel; only applies to uncompressed custom and
+	// directory format backups
+	Jobs int
+	// SchemaOnly restores only object definitions; requires a custom or directory format backup
+	SchemaOnly bool
+	// DataOnly restores only data; requires a custom or directory format backup
+	DataOnly bool
+	// StopOnError aborts a pg_restore restore at the first failing statement; plain SQL
+	// restores with psql always stop at the first error
+	StopOnError bool
+}
+
+// DefaultRestoreOptions returns the options used by Restore: objects of the configured
+// database are dropped and recreated from the backup
+func DefaultRestoreOptions() RestoreOptions {
+	return RestoreOptions{Clean: true}
+}
+
+// targetDB returns the database restored into
+func (o RestoreOptions) targetDB(config Config) string {
+	if o.TargetDBName != "" {
+		return o.TargetDBName
+	}
+	return config.DBName
+}
+
+// Validate checks that the options are consistent
+func (o RestoreOptions) Validate() error {
+	if o.Jobs < 0 {
+		return NewValidationError(fmt.Sprintf("invalid number of jobs %d", o.Jobs), nil).
+			WithContext("jobs", o.Jobs).
+			WithOperation("validate_restore_options")
+	}
+	if o.SchemaOnly && o.DataOnly {
+		return NewValidationError("schema-only and data-only restores are mutually exclusive", nil).
+			WithOperation("validate_restore_options")
+	}
+	if o.CreateDB && o.TargetDBName == maintenanceDBName {
+		return NewValidationError("refusing to create the maintenance database", nil).
+			WithContext("database", o.TargetDBName).
+			WithOperation("validate_restore_options")
+	}
+	return nil
+}
+
 // Backuper defines the interface for database backup operations
 type Backuper interface {
 	// Backup creates a database backup using default file path
 	Backup(ctx context.Context, config Config) error
 	// BackupToFile creates a database backup to a specific file path
 	BackupToFile(ctx context.Context, config Config, filePath string) error
+	// BackupWithOptions creates a database backup with the given options and returns its path.
+	// An empty filePath creates a timestamped file in the backups directory.
+	BackupWithOptions(ctx context.Context, config Config, filePath string, opts BackupOptions) (string, error)
 }
 
 // Restorer defines the interface for database restore operations
 type Restorer interface {
+	// Restore restores a database from a backup file or directory
 	Restore(ctx context.Context, config Config, backupPath string) error
+	// RestoreWithOptions restores a database from a backup file or directory with the given options
+	RestoreWithOptions(ctx context.Context, config Config, backupPath string, opts RestoreOptions) error
+	// RestoreFromStorage streams the backup stored under key into the database without
+	// staging it on local disk
+	RestoreFromStorage(ctx context.Context, config Config, storage BackupStorage, key string, opts RestoreOptions) error
 }
 
 // pgDump implements the Backuper interface using pg_dump
@@ -32,35 +232,168 @@ func NewPgDump() Backuper {
 
 // Backup creates a database backup using pg_dump with timestamped filename
 func (p *pgDump) Backup(ctx context.Context, config Config) error {
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("backup_%s_%s.sql", config.DBName, timestamp)
-	backupPath := filepath.Join(config.BackupsDir, filename)
-
-	return p.BackupToFile(ctx, config, backupPath)
+	_, err := p.BackupWithOptions(ctx, config, "", BackupOptions{})
+	return err
 }
 
 // BackupToFile creates a database backup to a specific file path
 func (p *pgDump) BackupToFile(ctx context.Context, config Config, filePath string) error {
-	cmd := exec.CommandContext(ctx, "pg_dump",
+	_, err := p.BackupWithOptions(ctx, config, filePath, BackupOptions{})
+	return err
+}
+
+// BackupWithOptions creates a database backup with the given options. Compressed and
+// encrypted backups are streamed from pg_dump's stdout through the compressor and the
+// encryptor, so no plain copy is written to disk. Directory format backups are written by pg_dump into a new directory
+// at filePath. A manifest with the backup's checksum is written alongside it.
+func (p *pgDump) BackupWithOptions(ctx context.Context, config Config, filePath string, opts BackupOptions) (string, error) {
+	recipients, err := encryptionRecipients(config, opts)
+	if err != nil {
+		return "", err
+	}
+	opts.Recipients = recipients
+
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	if filePath == "" {
+		filePath = filepath.Join(config.BackupsDir, backupFileName(config.DBName, opts))
+	}
+
+	cmd := exec.CommandContext(ctx, pgTool(config, "pg_dump"), pgDumpArgs(config, filePath, opts)...)
+
+	// Set PGPASSWORD environment variable for authentication
+	cmd.Env = append(cmd.Env, fmt.Sprintf("PGPASSWORD=%s", config.Password))
+
+	// runStreamed replaces stdout with the backup stream, leaving stderr captured
+	output := captureOutput(cmd, config)
+	if opts.streamed() {
+		if err := runStreamed(cmd, filePath, opts); err != nil {
+			return "", NewBackupError("pg_dump command failed", err).
+				WithContext("backup_path", filePath).
+				WithContext("database", config.DBName).
+				WithContext("compression", opts.Compression).
+				WithContext("encrypted", opts.encrypted()).
+				WithContext("output", output.String()).
+				WithOperation("backup")
+		}
+	} else if err := cmd.Run(); err != nil {
+		return "", NewBackupError("pg_dump command failed", err).
+			WithContext("backup_path", filePath).
+			WithContext("database", config.DBName).
+			WithContext("output", output.String()).
+			WithOperation("backup")
+	}
+
+	if _, err := writeBackupManifest(ctx, config, filePath, opts); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// backupFileName returns the timestamped name of a new backup of dbName
+func backupFileName(dbName string, opts BackupOptions) string {
+	timestamp := time.Now().Format("20060102_150405")
+	return fmt.Sprintf("backup_%s_%s%s", dbName, timestamp, opts.extension())
+}
+
+// pgDumpArgs returns the pg_dump arguments for a backup to filePath
+func pgDumpArgs(config Config, filePath string, opts BackupOptions) []string {
+	args := []string{
 		"--host", config.Host,
 		"--port", fmt.Sprintf("%d", config.Port),
 		"--username", config.User,
 		"--dbname", config.DBName,
-		"--file", filePath,
 		"--verbose",
 		"--no-password",
-	)
+	}
+	if opts.Format != "" && opts.Format != FormatPlain {
+		args = append(args, "--format", opts.Format)
+	} else if !opts.DataOnly {
+		// Plain SQL backups are restored with psql, which cannot clean the target itself
+		args = append(args, "--clean", "--if-exists")
+	}
+	if opts.Jobs > 1 {
+		args = append(args, "--jobs", fmt.Sprintf("%d", opts.Jobs))
+	}
+	if opts.SchemaOnly {
+		args = append(args, "--schema-only")
+	}
+	if opts.DataOnly {
+		args = append(args, "--data-only")
+	}
+	for _, table := range opts.IncludeTables {
+		args = append(args, "--table", table)
+	}
+	for _, table := range opts.ExcludeTables {
+		args = append(args, "--exclude-table", table)
+	}
+	for _, schema := range opts.ExcludeSchemas {
+		args = append(args, "--exclude-schema", schema)
+	}
+	if opts.NoOwner {
+		args = append(args, "--no-owner")
+	}
+	if opts.NoPrivileges {
+		args = append(args, "--no-privileges")
+	}
+	if !opts.streamed() {
+		args = append(args, "--file", filePath)
+	}
+	return args
+}
 
-	// Set PGPASSWORD environment variable for authentication
-	cmd.Env = append(cmd.Env, fmt.Sprintf("PGPASSWORD=%s", config.Password))
+// runStreamed runs cmd with its stdout compressed and/or encrypted into filePath. The file
+// is removed if the command, the compressor or the encryptor fails, so a partial backup is
+// never left behind.
+func runStreamed(cmd *exec.Cmd, filePath string, opts BackupOptions) (err error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(filePath)
+		}
+	}()
 
-	if err := cmd.Run(); err != nil {
-		return NewBackupError("pg_dump command failed", err).
-			WithContext("backup_path", filePath).
-			WithContext("database", config.DBName).
-			WithOperation("backup")
+	buffered := bufio.NewWriterSize(file, 1<<20)
+
+	// Stages are closed in order, compressor before encryptor, so each flushes into the next
+	var stages []io.WriteCloser
+	var out io.Writer = buffered
+	if opts.encrypted() {
+		encryptor, err := newEncryptWriter(out, opts.Recipients)
+		if err != nil {
+			return err
+		}
+		stages = append([]io.WriteCloser{encryptor}, stages...)
+		out = encryptor
 	}
-	return nil
+	if opts.compressed() {
+		compressor, err := newCompressWriter(out, opts.Compression, opts.CompressionLevel)
+		if err != nil {
+			return err
+		}
+		stages = append([]io.WriteCloser{compressor}, stages...)
+		out = compressor
+	}
+
+	cmd.Stdout = out
+	runErr := cmd.Run()
+	for _, stage := range stages {
+		if closeErr := stage.Close(); runErr == nil {
+			runErr = closeErr
+		}
+	}
+	if runErr != nil {
+		return runErr
+	}
+	return buffered.Flush()
 }
 
 // pgRestore implements the Restorer interface using pg_restore and psql
@@ -71,43 +404,356 @@ func NewPgRestore() Restorer {
 	return &pgRestore{}
 }
 
-// Restore restores a database from a backup file using pg_restore or psql
+// Restore restores a database from a backup file using pg_restore or psql.
+// Backups compressed with gzip or zstd, or encrypted with age, are decoded on the fly.
 func (p *pgRestore) Restore(ctx context.Context, config Config, backupPath string) error {
-	// First try with pg_restore (for custom format dumps)
-	cmd := exec.CommandContext(ctx, "pg_restore",
-		"--host", config.Host,
-		"--port", fmt.Sprintf("%d", config.Port),
-		"--username", config.User,
-		"--dbname", config.DBName,
-		"--verbose",
-		"--no-password",
-		"--clean",
-		"--if-exists",
-		backupPath,
-	)
+	return p.RestoreWithOptions(ctx, config, backupPath, DefaultRestoreOptions())
+}
+
+// RestoreWithOptions restores a database from a backup file or directory with the given
+// options. Archives (custom, directory and tar format) are restored with pg_restore and
+// plain SQL backups with psql. Backups with a manifest are refused if their checksum does
+// not match it.
+func (p *pgRestore) RestoreWithOptions(ctx context.Context, config Config, backupPath string, opts RestoreOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		return NewRestoreError("failed to read backup file", err).
+			WithContext("backup_path", backupPath).
+			WithOperation("restore")
+	}
+	if err := verifyBeforeRestore(backupPath); err != nil {
+		return err
+	}
+
+	compression, err := backupCompression(backupPath)
+	if err != nil {
+		return NewRestoreError("failed to read backup file", err).
+			WithContext("backup_path", backupPath).
+			WithOperation("restore")
+	}
+
+	if err := prepareRestoreTarget(ctx, config, opts); err != nil {
+		return err
+	}
+
+	archive := info.IsDir()
+	if !archive && compression == CompressionNone {
+		if archive, err = isArchiveFile(backupPath); err != nil {
+			return NewRestoreError("failed to read backup file", err).
+				WithContext("backup_path", backupPath).
+				WithOperation("restore")
+		}
+	}
+
+	// Plain SQL restores without Clean are streamed so the DROP statements can be skipped
+	if compression != CompressionNone || (!archive && !opts.Clean) {
+		file, err := os.Open(backupPath)
+		if err != nil {
+			return NewRestoreError("failed to open backup file", err).
+				WithContext("backup_path", backupPath).
+				WithOperation("restore")
+		}
+		defer file.Close()
+		return p.restoreStream(ctx, config, file, backupPath, opts)
+	}
+
+	var cmd *exec.Cmd
+	if archive {
+		cmd = exec.CommandContext(ctx, pgTool(config, "pg_restore"), pgRestoreArgs(config, opts)...)
+		if opts.Jobs > 1 {
+			cmd.Args = append(cmd.Args, "--jobs", fmt.Sprintf("%d", opts.Jobs))
+		}
+		cmd.Args = append(cmd.Args, backupPath)
+	} else {
+		args, err := psqlArgs(config, opts)
+		if err != nil {
+			return err
+		}
+		cmd = exec.CommandContext(ctx, pgTool(config, "psql"), args...)
+		cmd.Args = append(cmd.Args, "--file", backupPath)
+	}
 
 	// Set PGPASSWORD environment variable for authentication
 	cmd.Env = append(cmd.Env, fmt.Sprintf("PGPASSWORD=%s", config.Password))
 
-	err := cmd.Run()
+	output := captureOutput(cmd, config)
+	if err := cmd.Run(); err != nil {
+		return NewRestoreError(fmt.Sprintf("%s command failed", filepath.Base(cmd.Path)), err).
+			WithContext("backup_path", backupPath).
+			WithContext("database", opts.targetDB(config)).
+			WithContext("output", output.String()).
+			WithOperation("restore")
+	}
+
+	return nil
+}
+
+// RestoreFromStorage streams the backup stored under key into pg_restore or psql. When the
+// storage holds a manifest for the backup, the streamed bytes are checksummed and a mismatch
+// is reported after the restore, since the data cannot be verified before it is read.
+func (p *pgRestore) RestoreFromStorage(ctx context.Context, config Config, storage BackupStorage, key string, opts RestoreOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	manifest := readStoredManifest(ctx, storage, key)
+
+	reader, err := storage.Get(ctx, key)
 	if err != nil {
-		// If pg_restore fails, try with psql (for plain SQL dumps)
-		cmd = exec.CommandContext(ctx, "psql",
-			"--host", config.Host,
-			"--port", fmt.Sprintf("%d", config.Port),
-			"--username", config.User,
-			"--dbname", config.DBName,
-			"--file", backupPath,
-		)
-
-		cmd.Env = append(cmd.Env, fmt.Sprintf("PGPASSWORD=%s", config.Password))
-		if err := cmd.Run(); err != nil {
-			return NewRestoreError("both pg_restore and psql commands failed", err).
-				WithContext("backup_path", backupPath).
-				WithContext("database", config.DBName).
+		return err
+	}
+	defer reader.Close()
+
+	if err := prepareRestoreTarget(ctx, config, opts); err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	counted := &countingReader{r: io.TeeReader(reader, hash)}
+	if err := p.restoreStream(ctx, config, counted, key, opts); err != nil {
+		return err
+	}
+
+	if manifest != nil {
+		// Drain anything the restore tool did not read so the whole object is hashed
+		_, _ = io.Copy(io.Discard, counted)
+		if sum := hex.EncodeToString(hash.Sum(nil)); counted.n != manifest.Size || sum != manifest.SHA256 {
+			return NewDBError(ErrCodeInvalidBackupFile, "restored backup does not match its manifest", nil).
+				WithContext("key", key).
+				WithContext("expected_sha256", manifest.SHA256).
+				WithContext("actual_sha256", sum).
 				WithOperation("restore")
 		}
 	}
+	return nil
+}
 
+// prepareRestoreTarget creates the target database when requested
+func prepareRestoreTarget(ctx context.Context, config Config, opts RestoreOptions) error {
+	if !opts.CreateDB {
+		return nil
+	}
+	target := config
+	target.DBName = opts.targetDB(config)
+	return createDatabase(ctx, target)
+}
+
+// restoreStream pipes a decrypted and decompressed backup into pg_restore (archive formats)
+// or psql (plain SQL) on stdin. Each layer is detected from the header beneath the previous
+// one. source names the backup in errors.
+func (p *pgRestore) restoreStream(ctx context.Context, config Config, r io.Reader, source string, opts RestoreOptions) error {
+	input, decompressed, compression, encrypted, err := decodeBackup(r, config, source)
+	if err != nil {
+		return err
+	}
+	defer decompressed.Close()
+
+	var cmd *exec.Cmd
+	if isArchiveFormat(input) {
+		cmd = exec.CommandContext(ctx, pgTool(config, "pg_restore"), pgRestoreArgs(config, opts)...)
+	} else {
+		args, err := psqlArgs(config, opts)
+		if err != nil {
+			return err
+		}
+		cmd = exec.CommandContext(ctx, pgTool(config, "psql"), args...)
+		if !opts.Clean {
+			input = bufio.NewReader(&skipDropStatements{r: input})
+		}
+	}
+	cmd.Stdin = input
+	cmd.Env = append(cmd.Env, fmt.Sprintf("PGPASSWORD=%s", config.Password))
+
+	output := captureOutput(cmd, config)
+	if err := cmd.Run(); err != nil {
+		return NewRestoreError(fmt.Sprintf("%s command failed", filepath.Base(cmd.Path)), err).
+			WithContext("backup_path", source).
+			WithContext("database", opts.targetDB(config)).
+			WithContext("compression", compression).
+			WithContext("encrypted", encrypted).
+			WithContext("output", output.String()).
+			WithOperation("restore")
+	}
 	return nil
 }
+
+// decodeBackup decrypts and decompresses a backup stream, detecting each layer from the
+// header beneath the previous one. It returns the pg_dump output, the decompressor to
+// close and the layers found. source names the backup in errors.
+func decodeBackup(r io.Reader, config Config, source string) (*bufio.Reader, io.Closer, string, bool, error) {
+	input := bufio.NewReaderSize(r, 1<<20)
+	encrypted := isEncrypted(input)
+	if encrypted {
+		decrypted, err := newDecryptReader(input, config.BackupEncryptionKey)
+		if err != nil {
+			return nil, nil, "", encrypted, WrapError(err, ErrCodeRestoreFailed, "restore", "failed to decrypt backup file")
+		}
+		input = bufio.NewReaderSize(decrypted, 1<<20)
+	}
+
+	compression := detectCompression(input)
+	decompressed, err := newDecompressReader(input, compression)
+	if err != nil {
+		return nil, nil, compression, encrypted, NewRestoreError("failed to decompress backup file", err).
+			WithContext("backup_path", source).
+			WithContext("compression", compression).
+			WithOperation("restore")
+	}
+	return bufio.NewReaderSize(decompressed, 1<<20), decompressed, compression, encrypted, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// skipDropStatements removes the DROP statements pg_dump writes with --clean --if-exists at
+// the start of a plain SQL backup, so restoring it keeps the existing objects. They come
+// before the first object header; everything after it is passed through unchanged.
+type skipDropStatements struct {
+	r       *bufio.Reader
+	line    []byte
+	err     error
+	through bool
+}
+
+func (s *skipDropStatements) Read(p []byte) (int, error) {
+	for len(s.line) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.through {
+			return s.r.Read(p)
+		}
+		s.line, s.err = s.r.ReadBytes('\n')
+		switch {
+		case bytes.HasPrefix(s.line, []byte("--")) && bytes.Contains(s.line, []byte("Name: ")):
+			s.through = true
+		case isCleanStatement(s.line):
+			s.line = nil
+		}
+	}
+	n := copy(p, s.line)
+	s.line = s.line[n:]
+	return n, nil
+}
+
+// isCleanStatement reports whether line is a statement written by pg_dump --clean --if-exists
+func isCleanStatement(line []byte) bool {
+	return (bytes.HasPrefix(line, []byte("DROP ")) || bytes.HasPrefix(line, []byte("ALTER "))) &&
+		bytes.Contains(line, []byte(" IF EXISTS "))
+}
+
+// pgRestoreArgs returns the pg_restore arguments shared by all restore paths
+func pgRestoreArgs(config Config, opts RestoreOptions) []string {
+	args := []string{
+		"--host", config.Host,
+		"--port", fmt.Sprintf("%d", config.Port),
+		"--username", config.User,
+		"--dbname", opts.targetDB(config),
+		"--verbose",
+		"--no-password",
+	}
+	if opts.Clean {
+		args = append(args, "--clean", "--if-exists")
+	}
+	if opts.SchemaOnly {
+		args = append(args, "--schema-only")
+	}
+	if opts.DataOnly {
+		args = append(args, "--data-only")
+	}
+	if opts.StopOnError {
+		args = append(args, "--exit-on-error")
+	}
+	return args
+}
+
+// psqlArgs returns the psql arguments shared by all restore paths. Plain SQL backups
+// cannot be filtered, so schema-only and data-only restores are refused.
+func psqlArgs(config Config, opts RestoreOptions) ([]string, error) {
+	if opts.SchemaOnly || opts.DataOnly {
+		return nil, NewValidationError("schema-only and data-only restores require a custom or directory format backup", nil).
+			WithOperation("restore")
+	}
+
+	args := []string{
+		"--host", config.Host,
+		"--port", fmt.Sprintf("%d", config.Port),
+		"--username", config.User,
+		"--dbname", opts.targetDB(config),
+		// Stop at the first failing statement so a partial restore is reported as an error
+		"--variable", "ON_ERROR_STOP=1",
+		"--no-password",
+	}
+	return args, nil
+}
+
+// backupCompression detects the compression codec of a backup file from its header.
+// Encrypted backups return "" since their codec is only known after decryption.
+func backupCompression(backupPath string) (string, error) {
+	file, err := os.Open(backupPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return CompressionNone, nil
+	}
+	reader := bufio.NewReader(file)
+	if isEncrypted(reader) {
+		return "", nil
+	}
+	return detectCompression(reader), nil
+}
+
+// pgDumpCustomMagic starts every pg_dump custom-format archive
+var pgDumpCustomMagic = []byte("PGDMP")
+
+// tarMagic is found at tarMagicOffset in tar archives, such as pg_dump's tar format
+var (
+	tarMagic       = []byte("ustar")
+	tarMagicOffset = 257
+)
+
+// isCustomFormat reports whether a stream holds a pg_dump custom-format archive
+func isCustomFormat(r *bufio.Reader) bool {
+	header, _ := r.Peek(len(pgDumpCustomMagic))
+	return bytes.Equal(header, pgDumpCustomMagic)
+}
+
+// isArchiveFormat reports whether a stream holds a pg_dump archive that pg_restore reads
+// (custom or tar format) rather than a plain SQL script
+func isArchiveFormat(r *bufio.Reader) bool {
+	if isCustomFormat(r) {
+		return true
+	}
+	header, _ := r.Peek(tarMagicOffset + len(tarMagic))
+	return len(header) == tarMagicOffset+len(tarMagic) && bytes.Equal(header[tarMagicOffset:], tarMagic)
+}
+
+// isArchiveFile reports whether the file at path is a pg_dump archive
+func isArchiveFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	return isArchiveFormat(bufio.NewReader(file)), nil
+}
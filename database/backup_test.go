@@ -1,10 +1,15 @@
 package database
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -302,3 +307,316 @@ func TestCommandAvailability(t *testing.T) {
 
 	t.Log("All PostgreSQL client tools are available")
 }
+
+func TestCompressedBackupRestore(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	db.config.BackupsDir = t.TempDir()
+
+	for _, compression := range []string{CompressionGzip, CompressionZstd} {
+		t.Run(compression, func(t *testing.T) {
+			path, err := db.BackupWithOptions(ctx, "", BackupOptions{Compression: compression})
+			if err != nil {
+				t.Skipf("Backup failed (pg_dump may not be available): %v", err)
+			}
+
+			if filepath.Ext(path) != compressionExtension(compression) {
+				t.Errorf("Expected %s extension, got %s", compressionExtension(compression), path)
+			}
+			if got, err := backupCompression(path); err != nil || got != compression {
+				t.Errorf("Expected %s backup, detected %s (%v)", compression, got, err)
+			}
+
+			if err := db.Restore(ctx, path); err != nil {
+				t.Errorf("Restore of %s backup failed: %v", compression, err)
+			}
+		})
+	}
+}
+
+func TestPlainRestoreOverExistingObjects(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	db.config.BackupsDir = t.TempDir()
+	table := fmt.Sprintf("plain_restore_%d", time.Now().UnixNano())
+	if _, err := db.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id integer PRIMARY KEY)", table)); err != nil {
+		t.Fatalf("Failed to create %s: %v", table, err)
+	}
+	defer db.db.ExecContext(context.Background(), fmt.Sprintf("DROP TABLE IF EXISTS %s", table))
+	if _, err := db.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (1)", table)); err != nil {
+		t.Fatalf("Failed to insert into %s: %v", table, err)
+	}
+
+	path, err := db.BackupWithOptions(ctx, "", BackupOptions{})
+	if err != nil {
+		t.Skipf("Backup failed (pg_dump may not be available): %v", err)
+	}
+	if _, err := db.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (2)", table)); err != nil {
+		t.Fatalf("Failed to insert into %s: %v", table, err)
+	}
+
+	// The table and its row already exist, so only a clean restore succeeds
+	if err := db.RestoreWithOptions(ctx, path, RestoreOptions{}); err == nil {
+		t.Error("Expected a restore without Clean to stop at the existing table")
+	}
+	if err := db.Restore(ctx, path); err != nil {
+		t.Fatalf("Restore over the existing objects failed: %v", err)
+	}
+
+	var count int
+	if err := db.db.GetContext(ctx, &count, fmt.Sprintf("SELECT count(*) FROM %s", table)); err != nil || count != 1 {
+		t.Errorf("Expected the backed up row only, got %d (%v)", count, err)
+	}
+}
+
+func TestBackupOptionsValidate(t *testing.T) {
+	valid := []BackupOptions{
+		{},
+		{Format: FormatCustom, Compression: CompressionZstd},
+		{Format: FormatDirectory, Jobs: 4},
+		{SchemaOnly: true, IncludeTables: []string{"tenant_42.*"}, NoOwner: true},
+	}
+	for _, opts := range valid {
+		if err := opts.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", opts, err)
+		}
+	}
+
+	invalid := []BackupOptions{
+		{Format: "tar"},
+		{Jobs: -1},
+		{Format: FormatCustom, Jobs: 4},
+		{Format: FormatDirectory, Compression: CompressionGzip},
+		{SchemaOnly: true, DataOnly: true},
+		{ExcludeTables: []string{" "}},
+	}
+	for _, opts := range invalid {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", opts)
+		}
+	}
+}
+
+func TestPgDumpArgs(t *testing.T) {
+	config := Config{Host: "localhost", Port: 5432, User: "postgres", DBName: "app"}
+
+	tests := []struct {
+		name      string
+		opts      BackupOptions
+		extension string
+		want      []string
+		absent    []string
+	}{
+		{"plain", BackupOptions{}, ".sql", []string{"--clean --if-exists", "--file"}, []string{"--format", "--jobs"}},
+		{"custom", BackupOptions{Format: FormatCustom}, ".dump", []string{"--format custom", "--file"}, []string{"--jobs", "--clean"}},
+		{"directory", BackupOptions{Format: FormatDirectory, Jobs: 8}, "", []string{"--format directory", "--jobs 8", "--file"}, nil},
+		{"compressed", BackupOptions{Format: FormatCustom, Compression: CompressionGzip}, ".dump.gz", []string{"--format custom"}, []string{"--file"}},
+		{
+			"filtered",
+			BackupOptions{
+				DataOnly:       true,
+				IncludeTables:  []string{"tenant_42.*", "public.users"},
+				ExcludeTables:  []string{"tenant_42.audit_log"},
+				ExcludeSchemas: []string{"archive"},
+				NoOwner:        true,
+				NoPrivileges:   true,
+			},
+			".sql",
+			[]string{
+				"--data-only", "--table tenant_42.* --table public.users", "--exclude-table tenant_42.audit_log",
+				"--exclude-schema archive", "--no-owner", "--no-privileges",
+			},
+			[]string{"--schema-only", "--clean"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := strings.Join(pgDumpArgs(config, "/tmp/backup", tt.opts), " ")
+			for _, want := range tt.want {
+				if !strings.Contains(args, want) {
+					t.Errorf("Expected %q in %q", want, args)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(args, absent) {
+					t.Errorf("Did not expect %q in %q", absent, args)
+				}
+			}
+			if got := tt.opts.extension(); got != tt.extension {
+				t.Errorf("Expected extension %q, got %q", tt.extension, got)
+			}
+		})
+	}
+}
+
+func TestParallelBackupRestore(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	db.config.BackupsDir = t.TempDir()
+
+	path, err := db.BackupWithOptions(ctx, "", BackupOptions{Format: FormatDirectory, Jobs: 2})
+	if err != nil {
+		t.Skipf("Backup failed (pg_dump may not be available): %v", err)
+	}
+
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		t.Fatalf("Expected backup directory at %s: %v", path, err)
+	}
+
+	if err := db.RestoreWithOptions(ctx, path, RestoreOptions{Jobs: 2}); err != nil {
+		t.Errorf("Parallel restore failed: %v", err)
+	}
+}
+
+func TestEncryptedBackupRestore(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	key, _, err := GenerateBackupKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.config.BackupsDir = t.TempDir()
+	db.config.BackupEncryptionKey = key
+
+	path, err := db.BackupWithOptions(ctx, "", BackupOptions{Compression: CompressionZstd})
+	if err != nil {
+		t.Skipf("Backup failed (pg_dump may not be available): %v", err)
+	}
+	if !strings.HasSuffix(path, ".sql.zst.age") {
+		t.Errorf("Expected encrypted backup name, got %s", path)
+	}
+
+	if err := db.Restore(ctx, path); err != nil {
+		t.Errorf("Restore of encrypted backup failed: %v", err)
+	}
+
+	db.config.BackupEncryptionKey = ""
+	if err := db.Restore(ctx, path); err == nil {
+		t.Error("Expected restore without the key to fail")
+	}
+}
+
+func TestRestoreOptions(t *testing.T) {
+	config := Config{Host: "localhost", Port: 5432, User: "postgres", DBName: "app"}
+
+	assert := func(cond bool, format string, args ...interface{}) {
+		t.Helper()
+		if !cond {
+			t.Errorf(format, args...)
+		}
+	}
+
+	args := strings.Join(pgRestoreArgs(config, DefaultRestoreOptions()), " ")
+	assert(strings.Contains(args, "--dbname app --verbose --no-password --clean --if-exists"), "Default restore must clean the configured database: %s", args)
+
+	opts := RestoreOptions{TargetDBName: "app_copy", SchemaOnly: true, StopOnError: true}
+	args = strings.Join(pgRestoreArgs(config, opts), " ")
+	assert(strings.Contains(args, "--dbname app_copy"), "Expected target database: %s", args)
+	assert(!strings.Contains(args, "--clean"), "Did not expect --clean: %s", args)
+	assert(strings.Contains(args, "--schema-only") && strings.Contains(args, "--exit-on-error"), "Expected filters: %s", args)
+
+	_, err := psqlArgs(config, opts)
+	assert(err != nil, "Expected schema-only plain SQL restore to be refused")
+
+	psql, err := psqlArgs(config, RestoreOptions{TargetDBName: "app_copy", StopOnError: true})
+	assert(err == nil, "Unexpected error: %v", err)
+	args = strings.Join(psql, " ")
+	assert(strings.Contains(args, "--dbname app_copy --variable ON_ERROR_STOP=1"), "Expected psql target and ON_ERROR_STOP: %s", args)
+
+	psql, _ = psqlArgs(config, DefaultRestoreOptions())
+	args = strings.Join(psql, " ")
+	assert(strings.Contains(args, "ON_ERROR_STOP=1"), "Expected psql to always stop on errors: %s", args)
+
+	assert(RestoreOptions{SchemaOnly: true, DataOnly: true}.Validate() != nil, "Expected schema-only and data-only to conflict")
+	assert(RestoreOptions{CreateDB: true, TargetDBName: "postgres"}.Validate() != nil, "Expected maintenance database to be refused")
+	assert(RestoreOptions{CreateDB: true, TargetDBName: "app_copy", Jobs: 4}.Validate() == nil, "Expected valid options")
+}
+
+func TestSkipDropStatements(t *testing.T) {
+	dump := strings.Join([]string{
+		"--",
+		"-- PostgreSQL database dump",
+		"--",
+		"SET client_encoding = 'UTF8';",
+		"ALTER TABLE IF EXISTS ONLY public.orders DROP CONSTRAINT IF EXISTS orders_user_id_fkey;",
+		"DROP TABLE IF EXISTS public.users;",
+		"--",
+		"-- Name: users; Type: TABLE; Schema: public; Owner: postgres",
+		"--",
+		"CREATE TABLE public.users (id integer);",
+		"COPY public.notes (body) FROM stdin;",
+		"DROP TABLE IF EXISTS kept as data",
+		"\\.",
+		"",
+	}, "\n")
+
+	data, err := io.ReadAll(&skipDropStatements{r: bufio.NewReaderSize(strings.NewReader(dump), 16)})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	want := strings.Replace(dump, "ALTER TABLE IF EXISTS ONLY public.orders DROP CONSTRAINT IF EXISTS orders_user_id_fkey;\nDROP TABLE IF EXISTS public.users;\n", "", 1)
+	if string(data) != want {
+		t.Errorf("Expected the DROP statements to be skipped, got %q", data)
+	}
+}
+
+func TestIsArchiveFormat(t *testing.T) {
+	tarHeader := make([]byte, 512)
+	copy(tarHeader[257:], "ustar")
+
+	tests := map[string][]byte{
+		"custom": []byte("PGDMP\x01\x0e"),
+		"tar":    tarHeader,
+	}
+	for name, header := range tests {
+		if !isArchiveFormat(bufio.NewReader(bytes.NewReader(header))) {
+			t.Errorf("Expected %s header to be an archive", name)
+		}
+	}
+	if isArchiveFormat(bufio.NewReader(strings.NewReader("--\n-- PostgreSQL database dump\n--\n"))) {
+		t.Error("Expected plain SQL not to be an archive")
+	}
+}
+
+func TestRestoreIntoNewDatabase(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	db.config.BackupsDir = t.TempDir()
+	path, err := db.BackupWithOptions(ctx, "", BackupOptions{Format: FormatCustom})
+	if err != nil {
+		t.Skipf("Backup failed (pg_dump may not be available): %v", err)
+	}
+
+	target := fmt.Sprintf("%s_restore_%d", db.config.DBName, time.Now().UnixNano())
+	defer func() {
+		cleanup := db.config
+		cleanup.DBName = target
+		_ = dropDatabase(context.Background(), cleanup)
+	}()
+
+	err = db.RestoreWithOptions(ctx, path, RestoreOptions{CreateDB: true, TargetDBName: target, StopOnError: true})
+	if err != nil {
+		t.Fatalf("Restore into %s failed: %v", target, err)
+	}
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+)
+
+// Kinds of relation in a BloatEstimate
+const (
+	RelationTable = "table"
+	RelationIndex = "index"
+)
+
+// BloatEstimate is the estimated space wasted by dead tuples and free space in a table or
+// B-tree index
+type BloatEstimate struct {
+	Schema string `json:"schema" db:"schema_name"`
+	Table  string `json:"table" db:"table_name"`
+	// Index is the index name, empty for tables
+	Index string `json:"index,omitempty" db:"index_name"`
+	// Kind is table or index
+	Kind      string `json:"kind" db:"kind"`
+	SizeBytes int64  `json:"size_bytes" db:"size_bytes"`
+	// WastedBytes is the size beyond what the live rows need at the relation's fillfactor
+	WastedBytes int64 `json:"wasted_bytes" db:"wasted_bytes"`
+	// BloatRatio is WastedBytes divided by SizeBytes
+	BloatRatio float64 `json:"bloat_ratio"`
+}
+
+// tableBloatQuery estimates table bloat from the average row widths in pg_stats, after
+// the widely used estimation query from the ioguix/pgsql-bloat-estimation project. Tables
+// with columns lacking statistics cannot be estimated and are left out.
+const tableBloatQuery = `
+	SELECT schemaname, tblname, NULL::text AS idxname, bs * tblpages AS real_size,
+		CASE WHEN tblpages > 0 AND tblpages - est_tblpages_ff > 0
+			THEN (tblpages - est_tblpages_ff) * bs
+			ELSE 0
+		END AS bloat_size,
+		is_na
+	FROM (
+		SELECT ceil(reltuples / ((bs - page_hdr) * fillfactor / (tpl_size * 100))) + ceil(toasttuples / 4) AS est_tblpages_ff,
+			tblpages, bs, schemaname, tblname, is_na
+		FROM (
+			SELECT
+				(4 + tpl_hdr_size + tpl_data_size + (2 * ma)
+					- CASE WHEN tpl_hdr_size % ma = 0 THEN ma ELSE tpl_hdr_size % ma END
+					- CASE WHEN ceil(tpl_data_size)::int % ma = 0 THEN ma ELSE ceil(tpl_data_size)::int % ma END
+				) AS tpl_size,
+				(heappages + toastpages) AS tblpages, reltuples, toasttuples, bs, page_hdr,
+				schemaname, tblname, fillfactor, is_na
+			FROM (
+				SELECT
+					tbl.oid AS tblid, ns.nspname AS schemaname, tbl.relname AS tblname, greatest(tbl.reltuples, 0) AS reltuples,
+					tbl.relpages AS heappages, coalesce(toast.relpages, 0) AS toastpages,
+					coalesce(toast.reltuples, 0) AS toasttuples,
+					coalesce(substring(array_to_string(tbl.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::smallint, 100) AS fillfactor,
+					current_setting('block_size')::numeric AS bs,
+					CASE WHEN version() ~ 'mingw32' OR version() ~ '64-bit|x86_64|ppc64|ia64|amd64' THEN 8 ELSE 4 END AS ma,
+					24 AS page_hdr,
+					23 + CASE WHEN max(coalesce(s.null_frac, 0)) > 0 THEN (7 + count(s.attname)) / 8 ELSE 0::int END AS tpl_hdr_size,
+					sum((1 - coalesce(s.null_frac, 0)) * coalesce(s.avg_width, 0)) AS tpl_data_size,
+					bool_or(att.atttypid = 'pg_catalog.name'::regtype)
+						OR sum(CASE WHEN att.attnum > 0 THEN 1 ELSE 0 END) <> count(s.attname) AS is_na
+				FROM pg_attribute AS att
+				JOIN pg_class AS tbl ON att.attrelid = tbl.oid
+				JOIN pg_namespace AS ns ON ns.oid = tbl.relnamespace
+				LEFT JOIN pg_stats AS s ON s.schemaname = ns.nspname
+					AND s.tablename = tbl.relname AND s.inherited = false AND s.attname = att.attname
+				LEFT JOIN pg_class AS toast ON tbl.reltoastrelid = toast.oid
+				WHERE NOT att.attisdropped
+					AND att.attnum > 0
+					AND tbl.relkind IN ('r', 'm')
+				GROUP BY 1, 2, 3, 4, 5, 6, 7, 8, 9, 10
+			) AS s
+		) AS s2
+	) AS s3`
+
+// indexBloatQuery estimates B-tree index bloat, after the B-tree estimation query from the
+// ioguix/pgsql-bloat-estimation project
+const indexBloatQuery = `
+	SELECT nspname AS schemaname, tblname, idxname, bs * relpages::bigint AS real_size,
+		CASE WHEN relpages > est_pages_ff
+			THEN bs * (relpages - est_pages_ff)
+			ELSE 0
+		END AS bloat_size,
+		is_na
+	FROM (
+		SELECT coalesce(1 + ceil(reltuples / floor((bs - pageopqdata - pagehdr) * fillfactor / (100 * (4 + nulldatahdrwidth)::float))), 0) AS est_pages_ff,
+			bs, nspname, tblname, idxname, relpages, is_na
+		FROM (
+			SELECT bs, nspname, tblname, idxname, reltuples, relpages, fillfactor,
+				(index_tuple_hdr_bm
+					+ maxalign - CASE WHEN index_tuple_hdr_bm % maxalign = 0 THEN maxalign ELSE index_tuple_hdr_bm % maxalign END
+					+ nulldatawidth + maxalign - CASE
+						WHEN nulldatawidth = 0 THEN 0
+						WHEN nulldatawidth::integer % maxalign = 0 THEN maxalign
+						ELSE nulldatawidth::integer % maxalign
+					END
+				)::numeric AS nulldatahdrwidth,
+				pagehdr, pageopqdata, is_na
+			FROM (
+				SELECT n.nspname, i.tblname, i.idxname, i.reltuples, i.relpages, i.idxoid, i.fillfactor,
+					current_setting('block_size')::numeric AS bs,
+					CASE WHEN version() ~ 'mingw32' OR version() ~ '64-bit|x86_64|ppc64|ia64|amd64' THEN 8 ELSE 4 END AS maxalign,
+					24 AS pagehdr,
+					16 AS pageopqdata,
+					CASE WHEN max(coalesce(s.null_frac, 0)) = 0 THEN 8 ELSE 8 + ((32 + 8 - 1) / 8) END AS index_tuple_hdr_bm,
+					sum((1 - coalesce(s.null_frac, 0)) * coalesce(s.avg_width, 1024)) AS nulldatawidth,
+					max(CASE WHEN i.atttypid = 'pg_catalog.name'::regtype THEN 1 ELSE 0 END) > 0 AS is_na
+				FROM (
+					SELECT ct.relname AS tblname, ct.relnamespace, ic.idxname, ic.reltuples, ic.relpages,
+						ic.idxoid, ic.fillfactor,
+						coalesce(a1.attname, a2.attname) AS attname,
+						coalesce(a1.atttypid, a2.atttypid) AS atttypid,
+						CASE WHEN a1.attnum IS NULL THEN ic.idxname ELSE ct.relname END AS attrelname
+					FROM (
+						SELECT idxname, reltuples, relpages, tbloid, idxoid, fillfactor, indkey,
+							generate_series(1, indnatts) AS attpos
+						FROM (
+							SELECT ci.relname AS idxname, greatest(ci.reltuples, 0) AS reltuples, ci.relpages, i.indrelid AS tbloid,
+								i.indexrelid AS idxoid,
+								coalesce(substring(array_to_string(ci.reloptions, ' ') FROM 'fillfactor=([0-9]+)')::smallint, 90) AS fillfactor,
+								i.indnatts,
+								string_to_array(textin(int2vectorout(i.indkey)), ' ')::int[] AS indkey
+							FROM pg_index i
+							JOIN pg_class ci ON ci.oid = i.indexrelid
+							WHERE ci.relam = (SELECT oid FROM pg_am WHERE amname = 'btree')
+								AND ci.relpages > 0
+						) AS idx_data
+					) AS ic
+					JOIN pg_class ct ON ct.oid = ic.tbloid
+					LEFT JOIN pg_attribute a1 ON ic.indkey[ic.attpos] <> 0
+						AND a1.attrelid = ic.tbloid
+						AND a1.attnum = ic.indkey[ic.attpos]
+					LEFT JOIN pg_attribute a2 ON ic.indkey[ic.attpos] = 0
+						AND a2.attrelid = ic.idxoid
+						AND a2.attnum = ic.attpos
+				) i
+				JOIN pg_namespace n ON n.oid = i.relnamespace
+				JOIN pg_stats s ON s.schemaname = n.nspname
+					AND s.tablename = i.attrelname
+					AND s.attname = i.attname
+				GROUP BY 1, 2, 3, 4, 5, 6, 7
+			) AS rows_data_stats
+		) AS rows_hdr_pdg_stats
+	) AS relation_stats`
+
+// GetBloatEstimates estimates the wasted space of the tables and B-tree indexes in schema,
+// or in all user schemas if empty, largest waste first. The estimates rely on pg_stats, so
+// run ANALYZE first; relations with columns lacking statistics are left out. Use pgstattuple
+// for exact figures before a VACUUM FULL or REINDEX.
+func (is *IntrospectionService) GetBloatEstimates(ctx context.Context, schema string) ([]BloatEstimate, error) {
+	estimates := []BloatEstimate{}
+
+	query := `
+		SELECT
+			schemaname as schema_name,
+			tblname as table_name,
+			coalesce(idxname, '') as index_name,
+			CASE WHEN idxname IS NULL THEN 'table' ELSE 'index' END as kind,
+			real_size::bigint as size_bytes,
+			bloat_size::bigint as wasted_bytes
+		FROM (` + tableBloatQuery + `
+			UNION ALL` + indexBloatQuery + `
+		) bloat
+		WHERE NOT is_na
+			AND schemaname NOT IN ('information_schema', 'pg_catalog')
+			AND schemaname !~ '^pg_toast'
+			AND ($1::text = '' OR schemaname = $1)
+		ORDER BY wasted_bytes DESC, schema_name, table_name, index_name
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &estimates, query, schema)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_bloat_estimates", "failed to estimate bloat")
+	}
+
+	for i := range estimates {
+		if estimates[i].SizeBytes > 0 {
+			estimates[i].BloatRatio = float64(estimates[i].WastedBytes) / float64(estimates[i].SizeBytes)
+		}
+	}
+
+	return estimates, nil
+}
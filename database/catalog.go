@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupEntry describes a backup found in the backups directory or storage
+type BackupEntry struct {
+	Name        string    `json:"name"`
+	Created     time.Time `json:"created"`
+	Size        int64     `json:"size"`
+	Format      string    `json:"format"`
+	Compression string    `json:"compression"`
+	Encrypted   bool      `json:"encrypted"`
+	Database    string    `json:"database,omitempty"`
+	// Verified is set when the backup has a manifest whose recorded size matches the
+	// stored object; VerifyBackupChecksum and restores also compare the checksum
+	Verified bool   `json:"verified"`
+	SHA256   string `json:"sha256,omitempty"`
+}
+
+// generatedBackupName matches backup_<db>_<timestamp> names and captures the database
+var generatedBackupName = regexp.MustCompile(`^backup_(.+)_\d{8}_\d{6}(\.|$)`)
+
+// ListBackups returns the backups in BackupsDir, a local directory or storage URL, newest
+// first. Backups are recognised by their generated name or by a manifest next to them.
+func (d *DB) ListBackups(ctx context.Context) ([]BackupEntry, error) {
+	storage, err := OpenBackupStorage(ctx, d.config.BackupsDir)
+	if err != nil {
+		return nil, err
+	}
+	return listBackups(ctx, storage)
+}
+
+// listBackups builds the backup catalog of storage
+func listBackups(ctx context.Context, storage BackupStorage) ([]BackupEntry, error) {
+	objects, err := storage.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make(map[string]bool)
+	for _, object := range objects {
+		if isManifest(object.Key) {
+			manifests[strings.TrimSuffix(object.Key, manifestSuffix)] = true
+		}
+	}
+
+	entries := []BackupEntry{}
+	for _, object := range objects {
+		name := path.Base(object.Key)
+		if isManifest(object.Key) || (!manifests[object.Key] && !generatedBackupName.MatchString(name)) {
+			continue
+		}
+
+		format, compression, encrypted := backupFormatFromName(name)
+		entry := BackupEntry{
+			Name:        object.Key,
+			Created:     backupTime(object),
+			Size:        object.Size,
+			Format:      format,
+			Compression: compression,
+			Encrypted:   encrypted,
+		}
+		if match := generatedBackupName.FindStringSubmatch(name); match != nil {
+			entry.Database = match[1]
+		}
+
+		if manifests[object.Key] {
+			if manifest := readStoredManifest(ctx, storage, object.Key); manifest != nil {
+				entry.Created = manifest.Timestamp
+				entry.Database = manifest.Database
+				entry.SHA256 = manifest.SHA256
+				entry.Verified = manifest.Size == object.Size
+				if manifest.Options.Format != "" {
+					entry.Format = manifest.Options.Format
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Created.After(entries[j].Created) })
+	return entries, nil
+}
+
+// backupFormatFromName derives the format, compression and encryption of a backup from the
+// extensions added by BackupWithOptions. Names without a known extension are assumed to be
+// directory format backups.
+func backupFormatFromName(name string) (format, compression string, encrypted bool) {
+	if strings.HasSuffix(name, encryptionExtension) {
+		encrypted = true
+		name = strings.TrimSuffix(name, encryptionExtension)
+	}
+
+	compression = CompressionNone
+	for _, codec := range []string{CompressionGzip, CompressionZstd} {
+		if ext := compressionExtension(codec); strings.HasSuffix(name, ext) {
+			compression = codec
+			name = strings.TrimSuffix(name, ext)
+		}
+	}
+
+	switch path.Ext(name) {
+	case ".sql":
+		format = FormatPlain
+	case ".dump":
+		format = FormatCustom
+	default:
+		format = FormatDirectory
+	}
+	return format, compression, encrypted
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBackupFormatFromName(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		compression string
+		encrypted   bool
+	}{
+		{"backup_app_20250101_030000.sql", FormatPlain, CompressionNone, false},
+		{"backup_app_20250101_030000.sql.gz", FormatPlain, CompressionGzip, false},
+		{"backup_app_20250101_030000.dump.zst.age", FormatCustom, CompressionZstd, true},
+		{"backup_app_20250101_030000", FormatDirectory, CompressionNone, false},
+	}
+	for _, tt := range tests {
+		format, compression, encrypted := backupFormatFromName(tt.name)
+		if format != tt.format || compression != tt.compression || encrypted != tt.encrypted {
+			t.Errorf("backupFormatFromName(%q) = (%s, %s, %v)", tt.name, format, compression, encrypted)
+		}
+	}
+}
+
+func TestListBackups(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	storage := NewFileStorage(dir)
+
+	put := func(key, content string) {
+		t.Helper()
+		if err := storage.Put(ctx, key, strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	put("backup_app_20250101_030000.sql.gz", "old")
+	put("backup_app_20250102_030000.dump", "newer")
+	put("backup_app_20250102_030000.dump"+manifestSuffix,
+		`{"timestamp": "2025-01-02T03:00:05Z", "database": "app", "size": 5, "sha256": "abc", "options": {"format": "custom"}}`)
+	put("nightly.sql", "manual, with manifest")
+	put("nightly.sql"+manifestSuffix, `{"timestamp": "2025-01-03T00:00:00Z", "database": "other", "size": 1}`)
+	put("notes.txt", "not a backup")
+
+	entries, err := listBackups(ctx, storage)
+	if err != nil {
+		t.Fatalf("listBackups failed: %v", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	if got := strings.Join(names, ","); got != "nightly.sql,backup_app_20250102_030000.dump,backup_app_20250101_030000.sql.gz" {
+		t.Fatalf("Unexpected catalog order: %s", got)
+	}
+
+	if entries[0].Verified || entries[0].Database != "other" {
+		t.Errorf("Expected size mismatch to be unverified: %+v", entries[0])
+	}
+	if !entries[1].Verified || entries[1].Format != FormatCustom || entries[1].SHA256 != "abc" {
+		t.Errorf("Expected verified custom backup: %+v", entries[1])
+	}
+	if entries[2].Verified || entries[2].Database != "app" || entries[2].Compression != CompressionGzip {
+		t.Errorf("Expected unverified gzip backup of app: %+v", entries[2])
+	}
+}
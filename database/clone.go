@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Clone methods
+const (
+	// CloneTemplate copies the database files with CREATE DATABASE ... TEMPLATE, which is
+	// fast but requires that nobody else is connected to the source database
+	CloneTemplate = "template"
+	// CloneDump copies the database with pg_dump and pg_restore while it is in use
+	CloneDump = "dump"
+)
+
+// CloneOptions configures how a database is cloned
+type CloneOptions struct {
+	// Method is CloneTemplate, CloneDump or empty to try a template copy first and fall
+	// back to dump and restore while the source database is in use
+	Method string
+	// Replace drops the target database first if it exists
+	Replace bool
+	// Jobs dumps and restores this many tables in parallel when cloning by dump
+	Jobs int
+}
+
+// Validate checks that the options are consistent
+func (o CloneOptions) Validate() error {
+	switch o.Method {
+	case "", CloneTemplate, CloneDump:
+	default:
+		return NewValidationError(fmt.Sprintf("unsupported clone method %q", o.Method), nil).
+			WithContext("method", o.Method).
+			WithOperation("validate_clone_options")
+	}
+	if o.Jobs < 0 {
+		return NewValidationError(fmt.Sprintf("invalid number of jobs %d", o.Jobs), nil).
+			WithContext("jobs", o.Jobs).
+			WithOperation("validate_clone_options")
+	}
+	return nil
+}
+
+// CloneResult reports how a database was cloned
+type CloneResult struct {
+	Target   string        `json:"target"`
+	Method   string        `json:"method"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Clone creates targetName as a copy of the configured database, e.g. to spin up a staging
+// copy. The connecting user needs permission to create databases.
+func (d *DB) Clone(ctx context.Context, targetName string, opts CloneOptions) (*CloneResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if targetName == "" || targetName == d.config.DBName || targetName == maintenanceDBName {
+		return nil, NewValidationError(fmt.Sprintf("invalid clone target %q", targetName), nil).
+			WithContext("source", d.config.DBName).
+			WithOperation("clone")
+	}
+
+	started := time.Now()
+	target := d.config
+	target.DBName = targetName
+	if opts.Replace {
+		if err := dropDatabase(ctx, target); err != nil {
+			return nil, err
+		}
+	}
+
+	method := opts.Method
+	if method != CloneDump {
+		err := d.cloneFromTemplate(ctx, target)
+		switch {
+		case err == nil:
+			method = CloneTemplate
+		case method == "" && isObjectInUse(err):
+			d.logger.Info("source database in use, cloning by dump and restore",
+				slog.String("source", d.config.DBName), slog.String("target", targetName))
+			method = CloneDump
+		default:
+			return nil, err
+		}
+	}
+
+	if method == CloneDump {
+		if err := d.cloneByDump(ctx, targetName, opts.Jobs); err != nil {
+			return nil, err
+		}
+	}
+
+	d.logger.Info("database cloned",
+		slog.String("source", d.config.DBName),
+		slog.String("target", targetName),
+		slog.String("method", method))
+	return &CloneResult{Target: targetName, Method: method, Duration: time.Since(started)}, nil
+}
+
+// cloneFromTemplate creates target with the configured database as template. Idle pool
+// connections are closed first since they would count as users of the template.
+func (d *DB) cloneFromTemplate(ctx context.Context, target Config) error {
+	d.db.SetMaxIdleConns(0)
+	defer d.db.SetMaxIdleConns(maxIdleConns(d.config))
+
+	return execMaintenance(ctx, target, "clone", "failed to clone database",
+		fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s",
+			pq.QuoteIdentifier(target.DBName), pq.QuoteIdentifier(d.config.DBName)))
+}
+
+// cloneByDump dumps the configured database into a temporary directory and restores it
+// into a new database named targetName
+func (d *DB) cloneByDump(ctx context.Context, targetName string, jobs int) error {
+	if err := d.checkClientTools(ctx, "pg_dump", "pg_restore", "psql"); err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "db-kit-clone-*")
+	if err != nil {
+		return NewBackupError("failed to create temporary clone directory", err).
+			WithOperation("clone")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	backupOpts := BackupOptions{Format: FormatCustom}
+	if jobs > 1 {
+		backupOpts = BackupOptions{Format: FormatDirectory, Jobs: jobs}
+	}
+	backupPath, err := d.Backuper.BackupWithOptions(ctx, d.config, filepath.Join(tmpDir, "clone"), backupOpts)
+	if err != nil {
+		return err
+	}
+
+	return d.Restorer.RestoreWithOptions(ctx, d.config, backupPath, RestoreOptions{
+		CreateDB:     true,
+		TargetDBName: targetName,
+		Jobs:         jobs,
+		StopOnError:  true,
+	})
+}
+
+// maxIdleConns returns the idle connection limit of the pool as configured by New
+func maxIdleConns(config Config) int {
+	if config.MaxIdleConns > 0 {
+		return config.MaxIdleConns
+	}
+	// database/sql keeps 2 idle connections by default
+	return 2
+}
+
+// isObjectInUse reports whether err is PostgreSQL's object_in_use error, raised when a
+// template database has other sessions
+func isObjectInUse(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "55006"
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestCloneOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    CloneOptions
+		wantErr bool
+	}{
+		{"auto", CloneOptions{}, false},
+		{"template", CloneOptions{Method: CloneTemplate, Replace: true}, false},
+		{"parallel dump", CloneOptions{Method: CloneDump, Jobs: 4}, false},
+		{"unknown method", CloneOptions{Method: "rsync"}, true},
+		{"negative jobs", CloneOptions{Jobs: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsObjectInUse(t *testing.T) {
+	inUse := WrapError(&pq.Error{Code: "55006"}, ErrCodeQueryFailed, "clone", "failed to clone database")
+	if !isObjectInUse(inUse) {
+		t.Error("Expected wrapped object_in_use error to be detected")
+	}
+	if isObjectInUse(&pq.Error{Code: "42P04"}) {
+		t.Error("Did not expect duplicate_database to be treated as in use")
+	}
+}
+
+func TestClone(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if _, err := db.Clone(ctx, db.config.DBName, CloneOptions{}); err == nil {
+		t.Error("Expected cloning onto the source database to be refused")
+	}
+
+	for _, method := range []string{"", CloneDump} {
+		target := fmt.Sprintf("%s_clone_%d", db.config.DBName, time.Now().UnixNano())
+		result, err := db.Clone(ctx, target, CloneOptions{Method: method})
+		cleanup := db.config
+		cleanup.DBName = target
+		defer func() { _ = dropDatabase(context.Background(), cleanup) }()
+
+		if err != nil {
+			t.Fatalf("Clone with method %q failed: %v", method, err)
+		}
+		if result.Target != target || (method != "" && result.Method != method) {
+			t.Errorf("Unexpected clone result %+v", result)
+		}
+	}
+}
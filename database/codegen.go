@@ -0,0 +1,274 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// GenerateStructsOptions configures GenerateStructs
+type GenerateStructsOptions struct {
+	// Package is the name of the generated package
+	Package string
+	// NullPointers types nullable columns as pointers, e.g. *string, instead of the
+	// database/sql null types, e.g. sql.NullString. Pointers encode as JSON null.
+	NullPointers bool
+}
+
+// goInitialisms are the words written in upper case in Go identifiers
+var goInitialisms = map[string]bool{
+	"api": true, "db": true, "dns": true, "html": true, "http": true, "https": true, "id": true,
+	"ip": true, "json": true, "sql": true, "ssh": true, "tcp": true, "tls": true, "ttl": true,
+	"ui": true, "uri": true, "url": true, "utc": true, "uuid": true, "xml": true,
+}
+
+// goScalarTypes maps the data types of information_schema to Go types, strings for those
+// without a better match
+var goScalarTypes = map[string]string{
+	"smallint":                    "int16",
+	"integer":                     "int32",
+	"bigint":                      "int64",
+	"real":                        "float32",
+	"double precision":            "float64",
+	"boolean":                     "bool",
+	"date":                        "time.Time",
+	"timestamp without time zone": "time.Time",
+	"timestamp with time zone":    "time.Time",
+	"json":                        "json.RawMessage",
+	"jsonb":                       "json.RawMessage",
+	"bytea":                       "[]byte",
+}
+
+// goArrayTypes maps the element types of array columns to the pq array types
+var goArrayTypes = map[string]string{
+	"smallint":         "pq.Int32Array",
+	"integer":          "pq.Int32Array",
+	"bigint":           "pq.Int64Array",
+	"real":             "pq.Float32Array",
+	"double precision": "pq.Float64Array",
+	"boolean":          "pq.BoolArray",
+	"bytea":            "pq.ByteaArray",
+}
+
+// goNullTypes maps Go types to their database/sql null types
+var goNullTypes = map[string]string{
+	"int16":     "sql.NullInt16",
+	"int32":     "sql.NullInt32",
+	"int64":     "sql.NullInt64",
+	"float64":   "sql.NullFloat64",
+	"bool":      "sql.NullBool",
+	"string":    "sql.NullString",
+	"time.Time": "sql.NullTime",
+}
+
+// GenerateStructs generates Go source declaring a struct with db and json tags for each
+// table of doc, a constant with the name of each table, and a string type with a constant
+// per label for each enum. The source is formatted and starts with the standard "Code
+// generated" header, so it can be regenerated whenever the schema changes.
+func GenerateStructs(doc *SchemaDocument, opts GenerateStructsOptions) ([]byte, error) {
+	if !token.IsIdentifier(opts.Package) {
+		return nil, NewValidationError(fmt.Sprintf("invalid package name %q", opts.Package), nil).
+			WithContext("package", opts.Package).
+			WithOperation("generate_structs")
+	}
+
+	// All declarations share the package scope, so repeated names are numbered. Enums are
+	// typed by their schema-qualified names, as in ColumnInfo.EnumType.
+	names := newGoNames()
+	enums := make(map[string]string, len(doc.Enums))
+	for _, enum := range doc.Enums {
+		enums[enum.Schema+"."+enum.Name] = names.unique(goTypeName(enum.Schema, enum.Name))
+	}
+	tables := make([]string, len(doc.Tables))
+	for i, table := range doc.Tables {
+		tables[i] = names.unique(goTypeName(table.Schema, table.Name))
+	}
+
+	imports := map[string]bool{}
+	var body bytes.Buffer
+
+	if len(doc.Tables) > 0 {
+		body.WriteString("// Table names\nconst (\n")
+		for i, table := range doc.Tables {
+			fmt.Fprintf(&body, "%s = %q\n", names.unique(tables[i]+"Table"), qualifiedName(table.Schema, table.Name))
+		}
+		body.WriteString(")\n\n")
+	}
+
+	for _, enum := range doc.Enums {
+		typeName := enums[enum.Schema+"."+enum.Name]
+		writeGoComment(&body, enum.Comment, fmt.Sprintf("%s is the %s enum", typeName, qualifiedName(enum.Schema, enum.Name)))
+		fmt.Fprintf(&body, "type %s string\n\n", typeName)
+		if len(enum.Values) == 0 {
+			continue
+		}
+		fmt.Fprintf(&body, "// Labels of %s\nconst (\n", typeName)
+		for _, value := range enum.Values {
+			// The type name prefix makes labels starting with a digit valid identifiers
+			label := goCamelCase(value)
+			if label == "" {
+				label = "Empty"
+			}
+			fmt.Fprintf(&body, "%s %s = %q\n", names.unique(typeName+label), typeName, value)
+		}
+		body.WriteString(")\n\n")
+	}
+
+	for i, table := range doc.Tables {
+		writeGoComment(&body, table.Comment, fmt.Sprintf("%s is a row of the %s %s", tables[i], qualifiedName(table.Schema, table.Name), goTableKind(table.Type)))
+		fmt.Fprintf(&body, "type %s struct {\n", tables[i])
+		fields := newGoNames()
+		for _, column := range table.Columns {
+			goType := goColumnType(column, enums, opts.NullPointers)
+			for _, pkg := range []string{"sql", "json", "time", "pq"} {
+				if strings.Contains(goType, pkg+".") {
+					imports[pkg] = true
+				}
+			}
+			if column.Comment != nil {
+				writeGoComment(&body, column.Comment, "")
+			}
+			fmt.Fprintf(&body, "%s %s `db:%q json:%q`\n", fields.unique(goIdentifier(column.Name, "Column")), goType, column.Name, column.Name)
+		}
+		body.WriteString("}\n\n")
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by db-kit generate structs. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", opts.Package)
+	if len(imports) > 0 {
+		src.WriteString("import (\n")
+		for _, path := range []string{"database/sql", "encoding/json", "time"} {
+			if imports[path[strings.LastIndex(path, "/")+1:]] {
+				fmt.Fprintf(&src, "%q\n", path)
+			}
+		}
+		if imports["pq"] {
+			src.WriteString("\n\"github.com/lib/pq\"\n")
+		}
+		src.WriteString(")\n\n")
+	}
+	src.Write(body.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, NewDBError(ErrCodeInternal, "failed to format generated code", err).
+			WithOperation("generate_structs")
+	}
+	return formatted, nil
+}
+
+// goColumnType returns the Go type of a column, nullable as a pointer or sql null type
+func goColumnType(column ColumnInfo, enums map[string]string, nullPointers bool) string {
+	var goType string
+	switch {
+	case column.EnumType != nil && enums[*column.EnumType] != "":
+		goType = enums[*column.EnumType]
+	case column.DataType == "ARRAY":
+		goType = "pq.StringArray"
+		if column.ElementType != nil && goArrayTypes[*column.ElementType] != "" {
+			goType = goArrayTypes[*column.ElementType]
+		}
+	case goScalarTypes[column.DataType] != "":
+		goType = goScalarTypes[column.DataType]
+	default:
+		goType = "string"
+	}
+
+	// Slices are nil for NULL
+	if !column.IsNullable || strings.HasPrefix(goType, "pq.") || goType == "[]byte" || goType == "json.RawMessage" {
+		return goType
+	}
+	if nullPointers {
+		return "*" + goType
+	}
+	if nullType, ok := goNullTypes[goType]; ok {
+		return nullType
+	}
+	return "sql.Null[" + goType + "]"
+}
+
+// goTableKind names the kind of a table in the comment of its struct
+func goTableKind(tableType string) string {
+	if tableType == "FOREIGN" || tableType == "FOREIGN TABLE" {
+		return "foreign table"
+	}
+	return "table"
+}
+
+// goTypeName returns the Go name of a table or type, prefixed with its schema outside the
+// public schema
+func goTypeName(schema, name string) string {
+	if schema == "" || schema == "public" {
+		return goIdentifier(name, "Table")
+	}
+	return goIdentifier(schema, "Schema") + goIdentifier(name, "Table")
+}
+
+// goIdentifier converts a database name to an exported Go identifier, e.g. user_id to
+// UserID, prefixed with fallback if it would start with a digit or be empty
+func goIdentifier(name, fallback string) string {
+	identifier := goCamelCase(name)
+	if identifier == "" || unicode.IsDigit([]rune(identifier)[0]) {
+		return fallback + identifier
+	}
+	return identifier
+}
+
+// goCamelCase joins the words of name in camel case, with initialisms in upper case
+func goCamelCase(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if goInitialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	return b.String()
+}
+
+// writeGoComment writes comment, or fallback if it is nil or empty, as a Go comment
+func writeGoComment(b *bytes.Buffer, comment *string, fallback string) {
+	text := fallback
+	if comment != nil && strings.TrimSpace(*comment) != "" {
+		text = strings.TrimSpace(*comment)
+	}
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(b, "// %s\n", strings.TrimRight(line, " \t\r"))
+	}
+}
+
+// goNames hands out identifiers, numbering repeated ones
+type goNames map[string]int
+
+func newGoNames() goNames {
+	return goNames{}
+}
+
+func (n goNames) unique(name string) string {
+	n[name]++
+	if n[name] == 1 {
+		return name
+	}
+	return fmt.Sprintf("%s%d", name, n[name])
+}
+
+// qualifiedName returns schema.name, or name alone in the public schema
+func qualifiedName(schema, name string) string {
+	if schema == "" || schema == "public" {
+		return name
+	}
+	return schema + "." + name
+}
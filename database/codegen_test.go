@@ -0,0 +1,103 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateStructs(t *testing.T) {
+	comment := "Registered users"
+	status := "public.user_status"
+	elementType := "text"
+	doc := &SchemaDocument{
+		Enums: []EnumInfo{{Schema: "public", Name: "user_status", Values: []string{"active", "on-hold", "2fa"}}},
+		Tables: []TableInfo{
+			{Schema: "public", Name: "users", Type: "BASE TABLE", Comment: &comment, Columns: []ColumnInfo{
+				{Name: "id", DataType: "bigint", IsPrimaryKey: true},
+				{Name: "email", DataType: "character varying"},
+				{Name: "status", DataType: "USER-DEFINED", EnumType: &status},
+				{Name: "previous_status", DataType: "USER-DEFINED", EnumType: &status, IsNullable: true},
+				{Name: "tags", DataType: "ARRAY", ElementType: &elementType, IsNullable: true},
+				{Name: "profile", DataType: "jsonb", IsNullable: true},
+				{Name: "deleted_at", DataType: "timestamp with time zone", IsNullable: true},
+			}},
+			{Schema: "billing", Name: "invoices", Type: "BASE TABLE", Columns: []ColumnInfo{
+				{Name: "user_id", DataType: "bigint"},
+				{Name: "total", DataType: "numeric", IsNullable: true},
+			}},
+		},
+	}
+
+	src, err := GenerateStructs(doc, GenerateStructsOptions{Package: "models"})
+	require.NoError(t, err)
+	assert.Equal(t, "// Code generated by db-kit generate structs. DO NOT EDIT.\n\n"+`package models
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+// Table names
+const (
+	UsersTable           = "users"
+	BillingInvoicesTable = "billing.invoices"
+)
+
+// UserStatus is the user_status enum
+type UserStatus string
+
+// Labels of UserStatus
+const (
+	UserStatusActive UserStatus = "active"
+	UserStatusOnHold UserStatus = "on-hold"
+	UserStatus2fa    UserStatus = "2fa"
+)
+
+// Registered users
+type Users struct {
+	ID             int64                `+"`db:\"id\" json:\"id\"`"+`
+	Email          string               `+"`db:\"email\" json:\"email\"`"+`
+	Status         UserStatus           `+"`db:\"status\" json:\"status\"`"+`
+	PreviousStatus sql.Null[UserStatus] `+"`db:\"previous_status\" json:\"previous_status\"`"+`
+	Tags           pq.StringArray       `+"`db:\"tags\" json:\"tags\"`"+`
+	Profile        json.RawMessage      `+"`db:\"profile\" json:\"profile\"`"+`
+	DeletedAt      sql.NullTime         `+"`db:\"deleted_at\" json:\"deleted_at\"`"+`
+}
+
+// BillingInvoices is a row of the billing.invoices table
+type BillingInvoices struct {
+	UserID int64          `+"`db:\"user_id\" json:\"user_id\"`"+`
+	Total  sql.NullString `+"`db:\"total\" json:\"total\"`"+`
+}
+`, string(src))
+
+	src, err = GenerateStructs(doc, GenerateStructsOptions{Package: "models", NullPointers: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "PreviousStatus *UserStatus")
+	assert.Contains(t, string(src), "DeletedAt      *time.Time")
+	assert.NotContains(t, string(src), `"database/sql"`)
+
+	_, err = GenerateStructs(doc, GenerateStructsOptions{Package: "my-models"})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestGoIdentifier(t *testing.T) {
+	for name, expected := range map[string]string{
+		"user_id":      "UserID",
+		"api_key_hash": "APIKeyHash",
+		"createdAt":    "CreatedAt",
+		"2fa_enabled":  "Column2faEnabled",
+		"order items":  "OrderItems",
+		"__":           "Column",
+	} {
+		assert.Equal(t, expected, goIdentifier(name, "Column"), name)
+	}
+
+	names := newGoNames()
+	assert.Equal(t, "Users", names.unique("Users"))
+	assert.Equal(t, "Users2", names.unique("Users"))
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"bytes"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+)
+
+// maxCommandOutput bounds the output of pg_dump, pg_restore and psql kept for errors
+const maxCommandOutput = 16 << 10
+
+// commandOutput keeps the tail of a command's output for error reports and logs each
+// line at debug level as it is written
+type commandOutput struct {
+	logger    *slog.Logger
+	tool      string
+	tail      []byte
+	truncated bool
+	line      []byte
+}
+
+// captureOutput directs the stderr of cmd, and its stdout unless already set, to a new
+// commandOutput. It must be called before the command is started.
+func captureOutput(cmd *exec.Cmd, config Config) *commandOutput {
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	out := &commandOutput{logger: logger, tool: filepath.Base(cmd.Path)}
+	if cmd.Stdout == nil {
+		cmd.Stdout = out
+	}
+	cmd.Stderr = out
+	return out
+}
+
+func (o *commandOutput) Write(p []byte) (int, error) {
+	o.tail = append(o.tail, p...)
+	if len(o.tail) > maxCommandOutput {
+		o.tail = o.tail[len(o.tail)-maxCommandOutput:]
+		o.truncated = true
+	}
+
+	o.line = append(o.line, p...)
+	for {
+		i := bytes.IndexByte(o.line, '\n')
+		if i < 0 {
+			break
+		}
+		o.logger.Debug(string(bytes.TrimRight(o.line[:i], "\r")), slog.String("tool", o.tool))
+		o.line = o.line[i+1:]
+	}
+	if len(o.line) > maxCommandOutput {
+		o.line = o.line[:0]
+	}
+	return len(p), nil
+}
+
+// String returns the captured output, prefixed with "..." if earlier output was dropped
+func (o *commandOutput) String() string {
+	out := string(bytes.TrimSpace(o.tail))
+	if o.truncated {
+		return "..." + out
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCommandOutput(t *testing.T) {
+	var logs bytes.Buffer
+	cmd := exec.Command("pg_restore")
+	out := captureOutput(cmd, Config{Logger: slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))})
+
+	if cmd.Stdout != out || cmd.Stderr != out {
+		t.Fatal("Expected stdout and stderr to be captured")
+	}
+
+	_, _ = out.Write([]byte("pg_restore: connecting to database\npg_restore: error: "))
+	_, _ = out.Write([]byte("relation \"users\" already exists\n"))
+
+	if got := out.String(); !strings.HasSuffix(got, `error: relation "users" already exists`) {
+		t.Errorf("Unexpected captured output: %q", got)
+	}
+	if !strings.Contains(logs.String(), "connecting to database") || !strings.Contains(logs.String(), "tool=pg_restore") {
+		t.Errorf("Expected output lines to be logged: %s", logs.String())
+	}
+
+	_, _ = out.Write(bytes.Repeat([]byte("x"), maxCommandOutput))
+	if got := out.String(); !strings.HasPrefix(got, "...") || len(got) != maxCommandOutput+3 {
+		t.Errorf("Expected output to be bounded, got %d bytes", len(got))
+	}
+}
+
+func TestCommandOutputKeepsStdout(t *testing.T) {
+	var stdout bytes.Buffer
+	cmd := exec.Command("pg_dump")
+	cmd.Stdout = &stdout
+	captureOutput(cmd, Config{})
+
+	if cmd.Stdout != &stdout {
+		t.Error("Expected an existing stdout to be kept")
+	}
+}
+
+func TestCommandErrorIncludesOutput(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	cmd := exec.CommandContext(context.Background(), sh, "-c", "echo 'psql: error: connection refused' >&2; exit 2")
+	out := captureOutput(cmd, Config{})
+	if err := cmd.Run(); err == nil {
+		t.Fatal("Expected command to fail")
+	}
+	if out.String() != "psql: error: connection refused" {
+		t.Errorf("Unexpected captured output: %q", out.String())
+	}
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported backup compression codecs
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// validateCompression checks a compression codec and level
+func validateCompression(compression string, level int) error {
+	switch compression {
+	case "", CompressionNone:
+		return nil
+	case CompressionGzip:
+		if level < 0 || level > gzip.BestCompression {
+			return NewValidationError(fmt.Sprintf("invalid gzip compression level %d, expected 1-9", level), nil).
+				WithContext("compression", compression)
+		}
+		return nil
+	case CompressionZstd:
+		if level < 0 || level > 22 {
+			return NewValidationError(fmt.Sprintf("invalid zstd compression level %d, expected 1-22", level), nil).
+				WithContext("compression", compression)
+		}
+		return nil
+	default:
+		return NewValidationError(fmt.Sprintf("unsupported compression %q, expected gzip or zstd", compression), nil).
+			WithContext("compression", compression)
+	}
+}
+
+// compressionExtension returns the file extension appended for a compression codec
+func compressionExtension(compression string) string {
+	switch compression {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// newCompressWriter wraps w with the given codec; a level of 0 selects the codec default
+func newCompressWriter(w io.Writer, compression string, level int) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case CompressionZstd:
+		opts := []zstd.EOption{}
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", compression)
+	}
+}
+
+// detectCompression identifies the codec of a stream from its magic bytes without consuming them
+func detectCompression(r *bufio.Reader) string {
+	header, _ := r.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return CompressionGzip
+	case bytes.HasPrefix(header, zstdMagic):
+		return CompressionZstd
+	default:
+		return CompressionNone
+	}
+}
+
+// newDecompressReader wraps r with a decoder for the given codec
+func newDecompressReader(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return io.NopCloser(r), nil
+	}
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("INSERT INTO users VALUES (1, 'alice');\n"), 100)
+
+	for _, compression := range []string{CompressionGzip, CompressionZstd} {
+		t.Run(compression, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := newCompressWriter(&buf, compression, 0)
+			require.NoError(t, err)
+			_, err = writer.Write(payload)
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+			assert.Less(t, buf.Len(), len(payload))
+
+			reader := bufio.NewReader(&buf)
+			assert.Equal(t, compression, detectCompression(reader))
+
+			decompressed, err := newDecompressReader(reader, compression)
+			require.NoError(t, err)
+			defer decompressed.Close()
+
+			got, err := io.ReadAll(decompressed)
+			require.NoError(t, err)
+			assert.Equal(t, payload, got)
+		})
+	}
+
+	assert.Equal(t, CompressionNone, detectCompression(bufio.NewReader(bytes.NewReader([]byte("-- PostgreSQL dump")))))
+}
+
+func TestValidateCompression(t *testing.T) {
+	assert.NoError(t, validateCompression("", 0))
+	assert.NoError(t, validateCompression(CompressionNone, 0))
+	assert.NoError(t, validateCompression(CompressionGzip, 9))
+	assert.NoError(t, validateCompression(CompressionZstd, 19))
+	assert.Error(t, validateCompression(CompressionGzip, 10))
+	assert.Error(t, validateCompression(CompressionZstd, 23))
+	assert.Error(t, validateCompression("lz4", 0))
+
+	assert.Equal(t, ".sql.gz", BackupOptions{Compression: CompressionGzip}.extension())
+	assert.Equal(t, ".sql.zst", BackupOptions{Compression: CompressionZstd}.extension())
+	assert.Equal(t, ".sql", BackupOptions{}.extension())
+}
+
+func TestRunStreamed(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo not available")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.sql.zst")
+
+	require.NoError(t, runStreamed(exec.Command("echo", "SELECT 1;"), path, BackupOptions{Compression: CompressionZstd}))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	reader, err := newDecompressReader(file, CompressionZstd)
+	require.NoError(t, err)
+	got, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;\n", string(got))
+
+	// A failing command leaves no partial file behind
+	failed := filepath.Join(dir, "failed.sql.gz")
+	assert.Error(t, runStreamed(exec.Command("false"), failed, BackupOptions{Compression: CompressionGzip}))
+	_, err = os.Stat(failed)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,293 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// defaultCopyBatchSize is the number of rows committed per target transaction
+const defaultCopyBatchSize = 10000
+
+// CopyProgress reports the progress of copying one table
+type CopyProgress struct {
+	Table string `json:"table"`
+	// Index and Total position the table in the copy run, starting at 1
+	Index int `json:"index"`
+	Total int `json:"total"`
+	// Rows is the number of rows copied so far
+	Rows int64 `json:"rows"`
+	// EstimatedRows is the planner's row estimate for the source table, 0 if unknown
+	EstimatedRows int64         `json:"estimated_rows"`
+	Done          bool          `json:"done"`
+	Duration      time.Duration `json:"duration"`
+}
+
+// CopyProgressHandler receives copy progress after every batch. It is called synchronously,
+// so slow handlers delay the copy.
+type CopyProgressHandler func(progress CopyProgress)
+
+// CopyOptions configures a copy between two databases
+type CopyOptions struct {
+	// Tables to copy, optionally schema-qualified; all tables of the source by default.
+	// Tables are copied after the tables they reference, in the given order otherwise.
+	Tables []string
+	// Truncate empties each target table before copying into it
+	Truncate bool
+	// BatchSize is the number of rows committed per target transaction, 10000 by default
+	BatchSize int
+	// Progress is called after every batch
+	Progress CopyProgressHandler
+}
+
+// Copy streams the rows of tables from the source database into the same tables of the
+// target database with COPY, e.g. to sync environments or move a tenant. The target tables
+// must exist with the source's columns. All tables are read from one snapshot; each batch
+// is committed on its own, so a failed copy leaves the batches copied so far. Copied tables
+// are logged to target.Logger, if set.
+func Copy(ctx context.Context, source, target Config, opts CopyOptions) ([]CopyProgress, error) {
+	if opts.BatchSize < 0 {
+		return nil, NewValidationError(fmt.Sprintf("invalid batch size %d", opts.BatchSize), nil).
+			WithContext("batch_size", opts.BatchSize).
+			WithOperation("copy")
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = defaultCopyBatchSize
+	}
+
+	src, err := sqlx.ConnectContext(ctx, "postgres", source.ConnectionString())
+	if err != nil {
+		return nil, NewConnectionError("failed to connect to source database", err).
+			WithContext("database", source.DBName).
+			WithOperation("copy")
+	}
+	defer src.Close()
+
+	dst, err := sqlx.ConnectContext(ctx, "postgres", target.ConnectionString())
+	if err != nil {
+		return nil, NewConnectionError("failed to connect to target database", err).
+			WithContext("database", target.DBName).
+			WithOperation("copy")
+	}
+	defer dst.Close()
+
+	snapshot, err := src.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeTransactionFailed, "copy", "failed to begin source transaction")
+	}
+	defer func() { _ = snapshot.Rollback() }()
+
+	var tables []string
+	if len(opts.Tables) == 0 {
+		tables, err = listUserTables(ctx, snapshot)
+	} else {
+		tables, err = qualifyTables(ctx, snapshot, opts.Tables)
+	}
+	if err != nil {
+		return nil, err
+	}
+	dependencies, err := tableDependencies(ctx, snapshot, "")
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "copy", "failed to get source foreign keys")
+	}
+	// Tables on foreign key cycles keep their order; the copy only fails if the target
+	// enforces the constraints
+	if ordered, err := SortTablesByDependencies(tables, dependencies); err == nil {
+		tables = ordered
+	} else if target.Logger != nil {
+		target.Logger.Warn("copying tables in the given order", slog.String("error", err.Error()))
+	}
+
+	results := make([]CopyProgress, 0, len(tables))
+	for i, table := range tables {
+		progress := CopyProgress{Table: table, Index: i + 1, Total: len(tables)}
+		if err := copyTable(ctx, snapshot, dst, &progress, opts); err != nil {
+			return results, err
+		}
+		if target.Logger != nil {
+			target.Logger.Info("copied table",
+				slog.String("table", table),
+				slog.Int64("rows", progress.Rows),
+				slog.Duration("duration", progress.Duration))
+		}
+		results = append(results, progress)
+	}
+	return results, nil
+}
+
+// copyTable copies one table in batches, reporting progress after each
+func copyTable(ctx context.Context, snapshot *sqlx.Tx, dst *sqlx.DB, progress *CopyProgress, opts CopyOptions) error {
+	started := time.Now()
+	schema, name, err := splitTableName(progress.Table)
+	if err != nil {
+		return err
+	}
+	quoted, _ := quoteQualifiedName(progress.Table)
+
+	var columns []string
+	err = snapshot.SelectContext(ctx, &columns, `
+		SELECT attname FROM pg_attribute
+		WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped AND attgenerated = ''
+		ORDER BY attnum`, quoted)
+	if err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "copy", "failed to read table columns").
+			WithContext("table", progress.Table)
+	}
+	_ = snapshot.GetContext(ctx, &progress.EstimatedRows,
+		"SELECT greatest(reltuples, 0)::bigint FROM pg_class WHERE oid = $1::regclass", quoted)
+
+	// Values are read as text, which COPY parses with each column type's input function
+	selected := make([]string, len(columns))
+	for i, column := range columns {
+		selected[i] = pq.QuoteIdentifier(column) + "::text"
+	}
+	rows, err := snapshot.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s", strings.Join(selected, ", "), quoted))
+	if err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "copy", "failed to read source table").
+			WithContext("table", progress.Table)
+	}
+	defer rows.Close()
+
+	copyStatement := pq.CopyIn(name, columns...)
+	if schema != "" {
+		copyStatement = pq.CopyInSchema(schema, name, columns...)
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	first := true
+	for {
+		batch, more, err := copyBatch(ctx, dst, rows, copyStatement, quoted, first && opts.Truncate, values, dest, opts.BatchSize)
+		if err != nil {
+			return WrapError(err, ErrCodeQueryFailed, "copy", "failed to copy rows").
+				WithContext("table", progress.Table).
+				WithContext("rows_copied", progress.Rows)
+		}
+		first = false
+		progress.Rows += batch
+		progress.Duration = time.Since(started)
+		progress.Done = !more
+		if opts.Progress != nil {
+			opts.Progress(*progress)
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// copyBatch copies up to batchSize rows into the target in one transaction and reports
+// whether rows remain
+func copyBatch(ctx context.Context, dst *sqlx.DB, rows *sql.Rows, copyStatement, table string, truncate bool,
+	values []sql.NullString, dest []interface{}, batchSize int) (int64, bool, error) {
+	tx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if truncate {
+		if _, err := tx.ExecContext(ctx, "TRUNCATE "+table); err != nil {
+			return 0, false, err
+		}
+	}
+
+	stmt, err := tx.PrepareContext(ctx, copyStatement)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var copied int64
+	for copied < int64(batchSize) {
+		if !rows.Next() {
+			break
+		}
+		if err := rows.Scan(dest...); err != nil {
+			stmt.Close()
+			return 0, false, err
+		}
+		args := make([]interface{}, len(values))
+		for i, value := range values {
+			if value.Valid {
+				args[i] = value.String
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			stmt.Close()
+			return 0, false, err
+		}
+		copied++
+	}
+	more := copied == int64(batchSize)
+	if !more {
+		if err := rows.Err(); err != nil {
+			stmt.Close()
+			return 0, false, err
+		}
+	}
+
+	// An Exec without arguments flushes the COPY
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return 0, false, err
+	}
+	if err := stmt.Close(); err != nil {
+		return 0, false, err
+	}
+	return copied, more, tx.Commit()
+}
+
+// listUserTables returns the tables outside the system schemas, qualified by schema
+func listUserTables(ctx context.Context, tx *sqlx.Tx) ([]string, error) {
+	var tables []string
+	err := tx.SelectContext(ctx, &tables, `
+		SELECT schemaname || '.' || tablename FROM pg_catalog.pg_tables
+		WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY schemaname, tablename`)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "copy", "failed to list source tables")
+	}
+	return tables, nil
+}
+
+// qualifyTables returns the schema-qualified names of tables as resolved by the source's
+// search path, without duplicates, failing for tables the source does not have
+func qualifyTables(ctx context.Context, tx *sqlx.Tx, tables []string) ([]string, error) {
+	qualified := make([]string, 0, len(tables))
+	for _, table := range tables {
+		quoted, err := quoteQualifiedName(table)
+		if err != nil {
+			return nil, err
+		}
+		var name sql.NullString
+		err = tx.GetContext(ctx, &name, `
+			SELECT n.nspname || '.' || c.relname FROM pg_catalog.pg_class c
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.oid = to_regclass($1)`, quoted)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, WrapError(err, ErrCodeQueryFailed, "copy", "failed to look up source table").
+				WithContext("table", table)
+		}
+		if !name.Valid {
+			return nil, NewValidationError(fmt.Sprintf("table %s not found in the source database", table), nil).
+				WithContext("table", table).
+				WithOperation("copy")
+		}
+		if !slices.Contains(qualified, name.String) {
+			qualified = append(qualified, name.String)
+		}
+	}
+	return qualified, nil
+}
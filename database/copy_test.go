@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSplitTableName(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		table   string
+		wantErr bool
+	}{
+		{"users", "", "users", false},
+		{"tenant_42.orders", "tenant_42", "orders", false},
+		{"a.b.c", "", "", true},
+		{".orders", "", "", true},
+	}
+	for _, tt := range tests {
+		schema, table, err := splitTableName(tt.name)
+		if (err != nil) != tt.wantErr || schema != tt.schema || table != tt.table {
+			t.Errorf("splitTableName(%q) = (%q, %q, %v)", tt.name, schema, table, err)
+		}
+	}
+}
+
+func TestCopyRejectsInvalidBatchSize(t *testing.T) {
+	_, err := Copy(context.Background(), Config{}, Config{}, CopyOptions{BatchSize: -1})
+	if err == nil {
+		t.Error("Expected a negative batch size to be refused")
+	}
+}
+
+func TestCopy(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	target := db.config
+	target.DBName = fmt.Sprintf("%s_copy_%d", db.config.DBName, time.Now().UnixNano())
+	if err := createDatabase(ctx, target); err != nil {
+		t.Skipf("Cannot create target database: %v", err)
+	}
+	defer func() { _ = dropDatabase(context.Background(), target) }()
+
+	table := fmt.Sprintf("copy_test_%d", time.Now().UnixNano())
+	schema := fmt.Sprintf("CREATE TABLE %s (id int PRIMARY KEY, name text, tags text[], data jsonb, raw bytea)", table)
+	if _, err := db.db.ExecContext(ctx, schema); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _, _ = db.db.ExecContext(context.Background(), fmt.Sprintf("DROP TABLE %s", table)) }()
+
+	_, err := db.db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s SELECT i, 'name ' || i, ARRAY['a', 'b'], '{"i": 1}', '\xdeadbeef'
+		FROM generate_series(1, 25) i`, table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (id) VALUES (26)", table)); err != nil {
+		t.Fatal(err)
+	}
+
+	targetDB, err := New(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer targetDB.Close()
+	if _, err := targetDB.db.ExecContext(ctx, schema); err != nil {
+		t.Fatal(err)
+	}
+
+	var batches int
+	results, err := Copy(ctx, db.config, target, CopyOptions{
+		Tables:    []string{table},
+		Truncate:  true,
+		BatchSize: 10,
+		Progress:  func(CopyProgress) { batches++ },
+	})
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Table != "public."+table || results[0].Rows != 26 || !results[0].Done {
+		t.Fatalf("Unexpected copy result %+v", results)
+	}
+	if batches != 3 {
+		t.Errorf("Expected progress after 3 batches, got %d", batches)
+	}
+
+	var nulls, matching int
+	_ = targetDB.db.GetContext(ctx, &nulls, fmt.Sprintf("SELECT count(*) FROM %s WHERE name IS NULL AND data IS NULL", table))
+	_ = targetDB.db.GetContext(ctx, &matching, fmt.Sprintf(`SELECT count(*) FROM %s WHERE tags = ARRAY['a', 'b'] AND data->>'i' = '1' AND raw = '\xdeadbeef'`, table))
+	if nulls != 1 || matching != 25 {
+		t.Errorf("Expected values to round trip, got %d nulls and %d matching rows", nulls, matching)
+	}
+}
+
+func TestCopyOrdersTables(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	target := db.config
+	target.DBName = fmt.Sprintf("%s_copy_%d", db.config.DBName, time.Now().UnixNano())
+	if err := createDatabase(ctx, target); err != nil {
+		t.Skipf("Cannot create target database: %v", err)
+	}
+	defer func() { _ = dropDatabase(context.Background(), target) }()
+
+	suffix := time.Now().UnixNano()
+	parent, child := fmt.Sprintf("copy_parent_%d", suffix), fmt.Sprintf("copy_child_%d", suffix)
+	schema := fmt.Sprintf(`
+		CREATE TABLE %[1]s (id int PRIMARY KEY);
+		CREATE TABLE %[2]s (id int PRIMARY KEY, parent_id int REFERENCES %[1]s)`, parent, child)
+	if _, err := db.db.ExecContext(ctx, schema); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_, _ = db.db.ExecContext(context.Background(), fmt.Sprintf("DROP TABLE %s, %s", child, parent))
+	}()
+	if _, err := db.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (1); INSERT INTO %s VALUES (1, 1)", parent, child)); err != nil {
+		t.Fatal(err)
+	}
+
+	targetDB, err := New(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer targetDB.Close()
+	if _, err := targetDB.db.ExecContext(ctx, schema); err != nil {
+		t.Fatal(err)
+	}
+
+	// The child is given first but copied after the parent it references
+	results, err := Copy(ctx, db.config, target, CopyOptions{Tables: []string{child, "public." + parent, child}})
+	if err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Table != "public."+parent || results[1].Table != "public."+child {
+		t.Fatalf("Expected the parent before the child, got %+v", results)
+	}
+
+	_, err = Copy(ctx, db.config, target, CopyOptions{Tables: []string{"copy_missing"}})
+	if GetErrorCode(err) != ErrCodeValidation {
+		t.Errorf("Expected a validation error for a missing table, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TableDependency is a foreign key edge between two schema-qualified tables: Table
+// references ReferencedTable
+type TableDependency struct {
+	Table           string `json:"table" db:"table_name"`
+	ReferencedTable string `json:"referenced_table" db:"referenced_table"`
+}
+
+// GetTableDependencyOrder returns the schema-qualified tables of schema, or of all user
+// schemas if empty, ordered so that every table comes after the tables it references. Load
+// data in this order and truncate or delete in reverse. Foreign key cycles, other than a
+// table referencing itself, cannot be ordered and return a validation error listing the
+// tables on the cycles.
+func (is *IntrospectionService) GetTableDependencyOrder(ctx context.Context, schema string) ([]string, error) {
+	var tables []string
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &tables, `
+			SELECT schemaname || '.' || tablename FROM pg_catalog.pg_tables
+			WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+				AND ($1::text = '' OR schemaname = $1)
+			ORDER BY 1`, schema)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_table_dependency_order", "failed to get tables")
+	}
+
+	var dependencies []TableDependency
+	err = is.db.WithValidation(ctx, func() error {
+		dependencies, err = tableDependencies(ctx, is.db.db, schema)
+		return err
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_table_dependency_order", "failed to get foreign keys")
+	}
+
+	return SortTablesByDependencies(tables, dependencies)
+}
+
+// tableDependencies returns the foreign key edges of the tables in schema, or in all schemas
+// if empty
+func tableDependencies(ctx context.Context, q sqlx.QueryerContext, schema string) ([]TableDependency, error) {
+	var dependencies []TableDependency
+	err := sqlx.SelectContext(ctx, q, &dependencies, `
+		SELECT DISTINCT
+			cn.nspname || '.' || c.relname AS table_name,
+			rn.nspname || '.' || r.relname AS referenced_table
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace cn ON cn.oid = c.relnamespace
+		JOIN pg_catalog.pg_class r ON r.oid = con.confrelid
+		JOIN pg_catalog.pg_namespace rn ON rn.oid = r.relnamespace
+		WHERE con.contype = 'f'
+			AND ($1::text = '' OR cn.nspname = $1)
+		ORDER BY 1, 2`, schema)
+	return dependencies, err
+}
+
+// SortTablesByDependencies orders tables so that every table comes after the tables it
+// references, keeping the given order where the dependencies allow it. Self references and
+// dependencies on tables outside the list are ignored. Tables on foreign key cycles return
+// a validation error.
+func SortTablesByDependencies(tables []string, dependencies []TableDependency) ([]string, error) {
+	position := make(map[string]int, len(tables))
+	for i, table := range tables {
+		position[table] = i
+	}
+
+	// references[t] are the tables t references, referencedBy[t] the tables referencing t
+	references := make(map[string]map[string]bool, len(tables))
+	referencedBy := make(map[string][]string, len(tables))
+	for _, dep := range dependencies {
+		_, known := position[dep.Table]
+		_, knownReferenced := position[dep.ReferencedTable]
+		if !known || !knownReferenced || dep.Table == dep.ReferencedTable || references[dep.Table][dep.ReferencedTable] {
+			continue
+		}
+		if references[dep.Table] == nil {
+			references[dep.Table] = make(map[string]bool)
+		}
+		references[dep.Table][dep.ReferencedTable] = true
+		referencedBy[dep.ReferencedTable] = append(referencedBy[dep.ReferencedTable], dep.Table)
+	}
+
+	// Kahn's algorithm, always taking the earliest ready table for a stable order
+	pending := make(map[string]int, len(tables))
+	var ready []string
+	for _, table := range tables {
+		pending[table] = len(references[table])
+		if pending[table] == 0 {
+			ready = append(ready, table)
+		}
+	}
+
+	ordered := make([]string, 0, len(tables))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return position[ready[i]] < position[ready[j]] })
+		table := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, table)
+
+		for _, dependent := range referencedBy[table] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(ordered) < len(tables) {
+		cycle := cyclicTables(tables, pending, referencedBy)
+		return ordered, NewValidationError(fmt.Sprintf("foreign key cycle between tables %s", strings.Join(cycle, ", ")), nil).
+			WithContext("tables", cycle).
+			WithOperation("sort_tables_by_dependencies")
+	}
+	return ordered, nil
+}
+
+// cyclicTables returns the tables left unordered by SortTablesByDependencies without the
+// tables that only reference a cycle, which are left over too but are not part of one
+func cyclicTables(tables []string, pending map[string]int, referencedBy map[string][]string) []string {
+	remaining := make(map[string]bool)
+	for _, table := range tables {
+		if pending[table] > 0 {
+			remaining[table] = true
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for table := range remaining {
+			referenced := false
+			for _, dependent := range referencedBy[table] {
+				if remaining[dependent] {
+					referenced = true
+					break
+				}
+			}
+			if !referenced {
+				delete(remaining, table)
+				changed = true
+			}
+		}
+	}
+
+	var cycle []string
+	for _, table := range tables {
+		if remaining[table] {
+			cycle = append(cycle, table)
+		}
+	}
+	return cycle
+}
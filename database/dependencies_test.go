@@ -0,0 +1,40 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortTablesByDependencies(t *testing.T) {
+	tables := []string{"public.comments", "public.posts", "public.tags", "public.users"}
+	dependencies := []TableDependency{
+		{Table: "public.comments", ReferencedTable: "public.posts"},
+		{Table: "public.comments", ReferencedTable: "public.users"},
+		{Table: "public.posts", ReferencedTable: "public.users"},
+		// Ignored: self references and tables outside the list
+		{Table: "public.comments", ReferencedTable: "public.comments"},
+		{Table: "public.posts", ReferencedTable: "audit.events"},
+	}
+
+	ordered, err := SortTablesByDependencies(tables, dependencies)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"public.tags", "public.users", "public.posts", "public.comments"}, ordered)
+}
+
+func TestSortTablesByDependenciesCycle(t *testing.T) {
+	tables := []string{"a", "b", "c", "d"}
+	dependencies := []TableDependency{
+		{Table: "a", ReferencedTable: "b"},
+		{Table: "b", ReferencedTable: "a"},
+		// c is not on the cycle but references it
+		{Table: "c", ReferencedTable: "a"},
+	}
+
+	ordered, err := SortTablesByDependencies(tables, dependencies)
+	require.Error(t, err)
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+	assert.Contains(t, err.Error(), "foreign key cycle between tables a, b")
+	assert.Equal(t, []string{"d"}, ordered)
+}
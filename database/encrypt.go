@@ -0,0 +1,94 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// encryptionExtension is appended to the name of encrypted backups
+const encryptionExtension = ".age"
+
+// ageMagic starts every age encrypted file
+var ageMagic = []byte("age-encryption.org/v1")
+
+// GenerateBackupKey returns a new age identity for Config.BackupEncryptionKey and the
+// public recipient to share with hosts that only take backups
+func GenerateBackupKey() (key string, recipient string, err error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", "", NewDBError(ErrCodeInternal, "failed to generate backup key", err).
+			WithOperation("generate_backup_key")
+	}
+	return identity.String(), identity.Recipient().String(), nil
+}
+
+// encryptionRecipients returns the recipients a backup is encrypted to: opts.Recipients
+// plus the recipient of the configured backup key, without duplicates
+func encryptionRecipients(config Config, opts BackupOptions) ([]string, error) {
+	recipients := append([]string(nil), opts.Recipients...)
+	if config.BackupEncryptionKey != "" {
+		identity, err := age.ParseX25519Identity(strings.TrimSpace(config.BackupEncryptionKey))
+		if err != nil {
+			return nil, NewConfigError("invalid backup encryption key", err).
+				WithOperation("backup")
+		}
+		recipients = append(recipients, identity.Recipient().String())
+	}
+
+	seen := make(map[string]bool)
+	unique := recipients[:0]
+	for _, recipient := range recipients {
+		if !seen[recipient] {
+			seen[recipient] = true
+			unique = append(unique, recipient)
+		}
+	}
+	return unique, nil
+}
+
+// parseRecipients parses age X25519 recipients (age1...)
+func parseRecipients(recipients []string) ([]age.Recipient, error) {
+	parsed := make([]age.Recipient, 0, len(recipients))
+	for _, recipient := range recipients {
+		r, err := age.ParseX25519Recipient(strings.TrimSpace(recipient))
+		if err != nil {
+			return nil, NewValidationError("invalid encryption recipient", err).
+				WithContext("recipient", recipient)
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+// newEncryptWriter encrypts everything written to the returned writer into w. Close must
+// be called to write the final chunk.
+func newEncryptWriter(w io.Writer, recipients []string) (io.WriteCloser, error) {
+	parsed, err := parseRecipients(recipients)
+	if err != nil {
+		return nil, err
+	}
+	return age.Encrypt(w, parsed...)
+}
+
+// isEncrypted reports whether a stream holds an age encrypted file without consuming it
+func isEncrypted(r *bufio.Reader) bool {
+	header, _ := r.Peek(len(ageMagic))
+	return bytes.Equal(header, ageMagic)
+}
+
+// newDecryptReader decrypts an age encrypted stream with the configured backup key
+func newDecryptReader(r io.Reader, key string) (io.Reader, error) {
+	if key == "" {
+		return nil, NewConfigError("backup is encrypted but no backup encryption key is configured", nil).
+			WithUserMessage("Set BACKUP_ENCRYPTION_KEY to the age key the backup was encrypted to")
+	}
+	identity, err := age.ParseX25519Identity(strings.TrimSpace(key))
+	if err != nil {
+		return nil, NewConfigError("invalid backup encryption key", err)
+	}
+	return age.Decrypt(r, identity)
+}
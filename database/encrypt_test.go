@@ -0,0 +1,83 @@
+package database
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptionRecipients(t *testing.T) {
+	key, recipient, err := GenerateBackupKey()
+	require.NoError(t, err)
+
+	_, other, err := GenerateBackupKey()
+	require.NoError(t, err)
+
+	recipients, err := encryptionRecipients(Config{BackupEncryptionKey: key}, BackupOptions{Recipients: []string{other, recipient}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{other, recipient}, recipients)
+
+	recipients, err = encryptionRecipients(Config{}, BackupOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, recipients)
+
+	_, err = encryptionRecipients(Config{BackupEncryptionKey: "not-a-key"}, BackupOptions{})
+	assert.Error(t, err)
+}
+
+func TestEncryptedBackupOptions(t *testing.T) {
+	_, recipient, err := GenerateBackupKey()
+	require.NoError(t, err)
+
+	opts := BackupOptions{Compression: CompressionZstd, Recipients: []string{recipient}}
+	assert.NoError(t, opts.Validate())
+	assert.Equal(t, ".sql.zst.age", opts.extension())
+	assert.NotContains(t, pgDumpArgs(Config{}, "/tmp/backup", opts), "--file")
+
+	assert.Error(t, BackupOptions{Recipients: []string{"age1invalid"}}.Validate())
+	assert.Error(t, BackupOptions{Format: FormatDirectory, Recipients: []string{recipient}}.Validate())
+}
+
+func TestRunStreamedEncrypted(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo not available")
+	}
+
+	key, recipient, err := GenerateBackupKey()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "out.sql.gz.age")
+	opts := BackupOptions{Compression: CompressionGzip, Recipients: []string{recipient}}
+	require.NoError(t, runStreamed(exec.Command("echo", "SELECT 1;"), path, opts))
+
+	compression, err := backupCompression(path)
+	require.NoError(t, err)
+	assert.Equal(t, "", compression, "codec of encrypted backups is unknown before decryption")
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	input := bufio.NewReader(file)
+	require.True(t, isEncrypted(input))
+
+	_, err = newDecryptReader(input, "")
+	assert.Error(t, err, "decrypting without a key must fail")
+
+	decrypted, err := newDecryptReader(input, key)
+	require.NoError(t, err)
+	plain := bufio.NewReader(decrypted)
+	require.Equal(t, CompressionGzip, detectCompression(plain))
+
+	decompressed, err := newDecompressReader(plain, CompressionGzip)
+	require.NoError(t, err)
+	got, err := io.ReadAll(decompressed)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1;\n", string(got))
+}
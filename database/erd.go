@@ -0,0 +1,119 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// ERD formats
+const (
+	// ERDFormatMermaid renders a Mermaid erDiagram
+	ERDFormatMermaid = "mermaid"
+	// ERDFormatDOT renders a Graphviz digraph
+	ERDFormatDOT = "dot"
+	// ERDFormatSVG renders the Graphviz digraph as SVG with the dot command, see RenderERDSVG
+	ERDFormatSVG = "svg"
+)
+
+// mermaidUnsafe matches characters Mermaid does not accept in entity names
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// RenderERD renders the foreign key graph returned by GetForeignKeyRelationships as an
+// entity relationship diagram. Each foreign key becomes an edge from the referencing table
+// to the referenced table labelled with the referencing columns.
+func RenderERD(w io.Writer, relationships []ConstraintInfo, format string) error {
+	var b strings.Builder
+	switch format {
+	case "", ERDFormatMermaid:
+		b.WriteString("erDiagram\n")
+		for _, rel := range relationships {
+			if rel.ReferencedTable == nil {
+				continue
+			}
+			// One referenced row, any number of referencing rows
+			fmt.Fprintf(&b, "    %s ||--o{ %s : %q\n",
+				mermaidUnsafe.ReplaceAllString(*rel.ReferencedTable, "_"),
+				mermaidUnsafe.ReplaceAllString(rel.TableName, "_"),
+				strings.Join(rel.Columns, ", "))
+		}
+	case ERDFormatDOT:
+		b.WriteString("digraph schema {\n")
+		b.WriteString("    rankdir=LR;\n")
+		b.WriteString("    node [shape=box];\n")
+		for _, rel := range relationships {
+			if rel.ReferencedTable == nil {
+				continue
+			}
+			label := fmt.Sprintf("%s -> %s", strings.Join(rel.Columns, ", "), strings.Join(rel.ReferencedColumns, ", "))
+			fmt.Fprintf(&b, "    %q -> %q [label=%q];\n", rel.TableName, *rel.ReferencedTable, label)
+		}
+		b.WriteString("}\n")
+	default:
+		return NewValidationError(fmt.Sprintf("unsupported ERD format %q", format), nil).
+			WithContext("format", format).
+			WithOperation("render_erd")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return NewDBError(ErrCodeInternal, "failed to write ERD", err).
+			WithOperation("render_erd")
+	}
+	return nil
+}
+
+// RenderERDSVG renders the relationships as a Graphviz digraph and converts it to SVG with
+// dot -Tsvg, which must be on the PATH
+func RenderERDSVG(ctx context.Context, w io.Writer, relationships []ConstraintInfo) error {
+	var graph bytes.Buffer
+	if err := RenderERD(&graph, relationships, ERDFormatDOT); err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "dot", "-Tsvg")
+	cmd.Stdin = &graph
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+			return NewConfigError("dot not found", err).
+				WithOperation("render_erd").
+				WithUserMessage("Install Graphviz to render SVG diagrams, or render the dot format instead")
+		}
+		return NewDBError(ErrCodeInternal, "failed to render SVG with dot", err).
+			WithContext("stderr", strings.TrimSpace(stderr.String())).
+			WithOperation("render_erd")
+	}
+	return nil
+}
+
+// FilterRelationships keeps the relationships whose tables are both selected by the
+// IncludeTables and ExcludeTables patterns of opts, so excluded tables do not reappear as
+// the end of an edge. Relationships carry no schema, so patterns containing a dot are
+// matched against opts.Schema.table.
+func FilterRelationships(relationships []ConstraintInfo, opts IntrospectOptions) ([]ConstraintInfo, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	selected := func(name string) bool {
+		tables := opts.filterTables([]TableInfo{{Schema: opts.Schema, Name: name}})
+		return len(tables) == 1
+	}
+
+	var filtered []ConstraintInfo
+	for _, rel := range relationships {
+		if rel.ReferencedTable == nil || !selected(rel.TableName) || !selected(*rel.ReferencedTable) {
+			continue
+		}
+		filtered = append(filtered, rel)
+	}
+	return filtered, nil
+}
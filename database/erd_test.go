@@ -0,0 +1,81 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRelationships() []ConstraintInfo {
+	users, orders := "users", "orders"
+	return []ConstraintInfo{
+		{Name: "orders_user_id_fkey", Type: "FOREIGN KEY", TableName: "orders", Columns: []string{"user_id"},
+			ReferencedTable: &users, ReferencedColumns: []string{"id"}},
+		{Name: "order items_order_fkey", Type: "FOREIGN KEY", TableName: "order items", Columns: []string{"order_id", "tenant_id"},
+			ReferencedTable: &orders, ReferencedColumns: []string{"id", "tenant_id"}},
+	}
+}
+
+func TestRenderERDMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, RenderERD(&buf, testRelationships(), ERDFormatMermaid))
+	assert.Equal(t, `erDiagram
+    users ||--o{ orders : "user_id"
+    orders ||--o{ order_items : "order_id, tenant_id"
+`, buf.String())
+}
+
+func TestRenderERDDOT(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, RenderERD(&buf, testRelationships(), ERDFormatDOT))
+	assert.Equal(t, `digraph schema {
+    rankdir=LR;
+    node [shape=box];
+    "orders" -> "users" [label="user_id -> id"];
+    "order items" -> "orders" [label="order_id, tenant_id -> id, tenant_id"];
+}
+`, buf.String())
+}
+
+func TestRenderERDUnsupportedFormat(t *testing.T) {
+	err := RenderERD(&bytes.Buffer{}, nil, "plantuml")
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestFilterRelationships(t *testing.T) {
+	relationships := testRelationships()
+
+	filtered, err := FilterRelationships(relationships, IntrospectOptions{})
+	require.NoError(t, err)
+	assert.Len(t, filtered, 2)
+
+	// Excluding users drops the edge pointing at it
+	filtered, err = FilterRelationships(relationships, IntrospectOptions{ExcludeTables: []string{"us*"}})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "order items", filtered[0].TableName)
+
+	filtered, err = FilterRelationships(relationships, IntrospectOptions{Schema: "public", IncludeTables: []string{"public.orders", "public.users"}})
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "orders", filtered[0].TableName)
+
+	_, err = FilterRelationships(relationships, IntrospectOptions{IncludeTables: []string{"[orders"}})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestRenderERDSVG(t *testing.T) {
+	if _, err := exec.LookPath("dot"); err == nil {
+		var buf bytes.Buffer
+		require.NoError(t, RenderERDSVG(context.Background(), &buf, testRelationships()))
+		assert.Contains(t, buf.String(), "<svg")
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	err := RenderERDSVG(context.Background(), &bytes.Buffer{}, testRelationships())
+	assert.Equal(t, ErrCodeInvalidConfig, GetErrorCode(err))
+}
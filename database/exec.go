@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// transactionControlStatements would interfere with the transaction of ExecOptions.Transaction
+var transactionControlStatements = map[string]bool{
+	"BEGIN": true, "START": true, "COMMIT": true, "END": true, "ROLLBACK": true, "ABORT": true,
+}
+
+// ExecOptions configures ExecScript
+type ExecOptions struct {
+	// Transaction runs all statements in one transaction, rolled back if any fails. Scripts
+	// with their own BEGIN or COMMIT are rejected.
+	Transaction bool
+	// ContinueOnError executes the remaining statements after a failure instead of
+	// stopping; not allowed with Transaction
+	ContinueOnError bool
+}
+
+// StatementResult is the outcome of one statement of a script
+type StatementResult struct {
+	Statement
+	// Executed is false for statements skipped after a failure
+	Executed bool `json:"executed"`
+	// RowsAffected is the number of rows changed, -1 if the statement does not report it
+	RowsAffected int64         `json:"rows_affected"`
+	Duration     time.Duration `json:"duration"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// ScriptResult is the outcome of ExecScript
+type ScriptResult struct {
+	Statements []StatementResult `json:"statements"`
+	// RolledBack is set when a failure rolled back the statements of a transaction
+	RolledBack bool `json:"rolled_back"`
+}
+
+// Failed returns the statements that failed
+func (r *ScriptResult) Failed() []StatementResult {
+	var failed []StatementResult
+	for _, statement := range r.Statements {
+		if statement.Error != "" {
+			failed = append(failed, statement)
+		}
+	}
+	return failed
+}
+
+// ExecScript splits a SQL script with SplitStatements and executes the statements in
+// order on one connection, so session settings carry over between them. Execution stops
+// at the first failed statement unless ContinueOnError is set. Failures are reported per
+// statement in the result with their line numbers; the error is only set if the script
+// could not be run at all.
+func (d *DB) ExecScript(ctx context.Context, script string, opts ExecOptions) (*ScriptResult, error) {
+	if opts.Transaction && opts.ContinueOnError {
+		return nil, NewValidationError("a transaction cannot continue after a failed statement", nil).
+			WithOperation("exec_script")
+	}
+
+	statements := SplitStatements(script)
+	result := &ScriptResult{Statements: make([]StatementResult, len(statements))}
+	for i, statement := range statements {
+		result.Statements[i] = StatementResult{Statement: statement, RowsAffected: -1}
+		if opts.Transaction && transactionControlStatements[statement.Type] {
+			return nil, NewValidationError(fmt.Sprintf("line %d: %s is not allowed when the script runs in a transaction", statement.Line, statement.Type), nil).
+				WithContext("line", statement.Line).
+				WithOperation("exec_script")
+		}
+	}
+	if len(statements) == 0 {
+		return result, nil
+	}
+
+	if err := d.ValidateConnection(ctx); err != nil {
+		return nil, WrapError(err, ErrCodeConnectionFailed, "exec_script", "connection validation failed")
+	}
+
+	conn, err := d.db.Connx(ctx)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeConnectionFailed, "exec_script", "failed to get a connection")
+	}
+	defer conn.Close()
+
+	var (
+		execer sqlx.ExecerContext = conn
+		tx     *sqlx.Tx
+	)
+	if opts.Transaction {
+		if tx, err = conn.BeginTxx(ctx, nil); err != nil {
+			return nil, WrapError(err, ErrCodeTransactionBegin, "exec_script", "failed to begin transaction")
+		}
+		defer func() { _ = tx.Rollback() }()
+		execer = tx
+	}
+
+	failed := false
+	for i := range result.Statements {
+		statement := &result.Statements[i]
+		start := time.Now()
+		var res sql.Result
+		err := d.withQueryHooks(ctx, &QueryEvent{Operation: "exec_script", Query: statement.SQL}, func(ctx context.Context) (int64, error) {
+			var err error
+			res, err = execer.ExecContext(ctx, statement.SQL)
+			return rowsAffected(res), err
+		})
+		statement.Executed = true
+		statement.Duration = time.Since(start)
+		if err != nil {
+			statement.Error = err.Error()
+			d.logger.Warn("script statement failed",
+				slog.Int("line", statement.Line),
+				slog.String("type", statement.Type),
+				slog.Any("error", err))
+			failed = true
+			if !opts.ContinueOnError {
+				break
+			}
+			continue
+		}
+		statement.RowsAffected = rowsAffected(res)
+	}
+
+	if tx != nil {
+		if failed {
+			result.RolledBack = true
+			return result, nil
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, WrapError(err, ErrCodeTransactionCommit, "exec_script", "failed to commit transaction")
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecScriptValidation(t *testing.T) {
+	db := &DB{}
+	ctx := context.Background()
+
+	_, err := db.ExecScript(ctx, "SELECT 1;", ExecOptions{Transaction: true, ContinueOnError: true})
+	require.Error(t, err)
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+
+	_, err = db.ExecScript(ctx, "SELECT 1;\nBEGIN;\nSELECT 2;\nCOMMIT;", ExecOptions{Transaction: true})
+	require.Error(t, err)
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+	assert.Contains(t, err.Error(), "line 2: BEGIN")
+
+	// Scripts without statements need no connection
+	result, err := db.ExecScript(ctx, "-- nothing to do\n", ExecOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Statements)
+}
+
+func TestScriptResultFailed(t *testing.T) {
+	result := &ScriptResult{Statements: []StatementResult{
+		{Statement: Statement{Line: 1}, Executed: true},
+		{Statement: Statement{Line: 3}, Executed: true, Error: "pq: syntax error"},
+		{Statement: Statement{Line: 5}},
+	}}
+	failed := result.Failed()
+	require.Len(t, failed, 1)
+	assert.Equal(t, 3, failed[0].Line)
+}
+
+func TestExecScript(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+	ctx := context.Background()
+	defer db.db.ExecContext(ctx, "DROP TABLE IF EXISTS test_exec_script")
+
+	result, err := db.ExecScript(ctx, `CREATE TABLE test_exec_script (id INT PRIMARY KEY);
+INSERT INTO test_exec_script VALUES (1), (2);
+SET search_path TO public;
+UPDATE test_exec_script SET id = id + 10;`, ExecOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Statements, 4)
+	assert.Empty(t, result.Failed())
+	assert.Equal(t, int64(2), result.Statements[1].RowsAffected)
+	assert.Equal(t, 4, result.Statements[3].Line)
+
+	// The failed insert rolls back the update before it
+	result, err = db.ExecScript(ctx, `UPDATE test_exec_script SET id = 100 WHERE id = 11;
+INSERT INTO test_exec_script VALUES (12);
+DELETE FROM test_exec_script;`, ExecOptions{Transaction: true})
+	require.NoError(t, err)
+	assert.True(t, result.RolledBack)
+	failed := result.Failed()
+	require.Len(t, failed, 1)
+	assert.Equal(t, 2, failed[0].Line)
+	assert.False(t, result.Statements[2].Executed)
+
+	var ids []int
+	require.NoError(t, db.db.SelectContext(ctx, &ids, "SELECT id FROM test_exec_script ORDER BY id"))
+	assert.Equal(t, []int{11, 12}, ids)
+
+	// Without a transaction, later statements still run
+	result, err = db.ExecScript(ctx, `INSERT INTO test_exec_script VALUES (11);
+DELETE FROM test_exec_script WHERE id = 12;`, ExecOptions{ContinueOnError: true})
+	require.NoError(t, err)
+	assert.Len(t, result.Failed(), 1)
+	assert.True(t, result.Statements[1].Executed)
+	assert.Equal(t, int64(1), result.Statements[1].RowsAffected)
+}
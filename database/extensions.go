@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ExtensionOptions configures CreateExtension
+type ExtensionOptions struct {
+	// Schema to install the extension's objects into, the current schema by default
+	Schema string
+	// Version to install, the extension's default version if empty
+	Version string
+	// Cascade also installs the extensions it depends on
+	Cascade bool
+}
+
+// CreateExtension installs an extension unless it is already installed, e.g. to make sure
+// pgcrypto or pg_stat_statements is present before a deploy. The connecting user needs
+// the privileges the extension requires, often superuser.
+func (d *DB) CreateExtension(ctx context.Context, name string, opts ExtensionOptions) error {
+	if name == "" {
+		return NewValidationError("extension name is required", nil).
+			WithOperation("create_extension")
+	}
+
+	err := d.WithValidation(ctx, func() error {
+		_, err := d.db.ExecContext(ctx, createExtensionQuery(name, opts))
+		return err
+	})
+	if err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "create_extension", "failed to create extension").
+			WithContext("extension", name)
+	}
+
+	d.logger.Info("extension created", slog.String("extension", name))
+	return nil
+}
+
+// createExtensionQuery builds the CREATE EXTENSION statement for CreateExtension
+func createExtensionQuery(name string, opts ExtensionOptions) string {
+	var query strings.Builder
+	query.WriteString("CREATE EXTENSION IF NOT EXISTS ")
+	query.WriteString(pq.QuoteIdentifier(name))
+	if opts.Schema != "" {
+		query.WriteString(" WITH SCHEMA ")
+		query.WriteString(pq.QuoteIdentifier(opts.Schema))
+	}
+	if opts.Version != "" {
+		query.WriteString(" VERSION ")
+		query.WriteString(pq.QuoteLiteral(opts.Version))
+	}
+	if opts.Cascade {
+		query.WriteString(" CASCADE")
+	}
+	return query.String()
+}
+
+// UpdateExtension updates an installed extension to version, or to its default version if
+// empty, like ALTER EXTENSION ... UPDATE
+func (d *DB) UpdateExtension(ctx context.Context, name, version string) error {
+	if name == "" {
+		return NewValidationError("extension name is required", nil).
+			WithOperation("update_extension")
+	}
+
+	err := d.WithValidation(ctx, func() error {
+		_, err := d.db.ExecContext(ctx, updateExtensionQuery(name, version))
+		return err
+	})
+	if err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "update_extension", "failed to update extension").
+			WithContext("extension", name).
+			WithContext("version", version)
+	}
+
+	d.logger.Info("extension updated", slog.String("extension", name), slog.String("version", version))
+	return nil
+}
+
+// updateExtensionQuery builds the ALTER EXTENSION statement for UpdateExtension
+func updateExtensionQuery(name, version string) string {
+	query := "ALTER EXTENSION " + pq.QuoteIdentifier(name) + " UPDATE"
+	if version != "" {
+		query += " TO " + pq.QuoteLiteral(version)
+	}
+	return query
+}
+
+// AvailableExtension is an extension the server can install, from pg_available_extensions
+type AvailableExtension struct {
+	Name           string `json:"name" db:"name"`
+	DefaultVersion string `json:"default_version" db:"default_version"`
+	// InstalledVersion is nil unless the extension is installed in the current database
+	InstalledVersion *string `json:"installed_version,omitempty" db:"installed_version"`
+	Comment          *string `json:"comment,omitempty" db:"comment"`
+}
+
+// GetAvailableExtensions retrieves the extensions the server can install, ordered by name
+func (is *IntrospectionService) GetAvailableExtensions(ctx context.Context) ([]AvailableExtension, error) {
+	extensions := []AvailableExtension{}
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &extensions, `
+			SELECT name, default_version, installed_version, comment
+			FROM pg_available_extensions
+			ORDER BY name`)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_available_extensions", "failed to get available extensions")
+	}
+	return extensions, nil
+}
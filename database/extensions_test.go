@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateExtensionQuery(t *testing.T) {
+	assert.Equal(t, `CREATE EXTENSION IF NOT EXISTS "pgcrypto"`, createExtensionQuery("pgcrypto", ExtensionOptions{}))
+	assert.Equal(t, `CREATE EXTENSION IF NOT EXISTS "uuid-ossp" WITH SCHEMA "extensions" VERSION '1.1' CASCADE`,
+		createExtensionQuery("uuid-ossp", ExtensionOptions{Schema: "extensions", Version: "1.1", Cascade: true}))
+
+	err := (&DB{}).CreateExtension(context.Background(), "", ExtensionOptions{})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestUpdateExtensionQuery(t *testing.T) {
+	assert.Equal(t, `ALTER EXTENSION "pg_trgm" UPDATE`, updateExtensionQuery("pg_trgm", ""))
+	assert.Equal(t, `ALTER EXTENSION "pg_trgm" UPDATE TO '1.6'`, updateExtensionQuery("pg_trgm", "1.6"))
+
+	err := (&DB{}).UpdateExtension(context.Background(), "", "")
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestCreateExtension(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+	ctx := context.Background()
+
+	require.NoError(t, db.CreateExtension(ctx, "pgcrypto", ExtensionOptions{}))
+	// Installing again is a no-op
+	require.NoError(t, db.CreateExtension(ctx, "pgcrypto", ExtensionOptions{}))
+	defer db.db.ExecContext(ctx, "DROP EXTENSION IF EXISTS pgcrypto")
+
+	extensions, err := db.Introspection().GetExtensions(ctx)
+	require.NoError(t, err)
+
+	var pgcrypto *ExtensionInfo
+	for i := range extensions {
+		if extensions[i].Name == "pgcrypto" {
+			pgcrypto = &extensions[i]
+		}
+	}
+	require.NotNil(t, pgcrypto, "pgcrypto should be installed")
+	assert.NotEmpty(t, pgcrypto.Version)
+	require.NotNil(t, pgcrypto.DefaultVersion)
+	assert.False(t, pgcrypto.UpdateAvailable)
+}
+
+func TestGetAvailableExtensions(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	extensions, err := db.Introspection().GetAvailableExtensions(context.Background())
+	require.NoError(t, err)
+
+	found := false
+	for _, extension := range extensions {
+		if extension.Name == "plpgsql" {
+			found = true
+			assert.NotNil(t, extension.InstalledVersion)
+		}
+	}
+	assert.True(t, found, "plpgsql should be available")
+}
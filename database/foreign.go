@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+// ForeignServerInfo is a foreign server and the foreign data wrapper it uses
+type ForeignServerInfo struct {
+	Name  string `json:"name" db:"server_name"`
+	Owner string `json:"owner" db:"owner"`
+	// Wrapper is the foreign data wrapper, e.g. postgres_fdw
+	Wrapper string  `json:"wrapper" db:"wrapper_name"`
+	Type    *string `json:"type,omitempty" db:"server_type"`
+	Version *string `json:"version,omitempty" db:"server_version"`
+	// Options are the server options, e.g. host and dbname. The options of user mappings,
+	// which hold credentials, are left out.
+	Options map[string]string `json:"options,omitempty"`
+	Comment *string           `json:"comment,omitempty" db:"server_comment"`
+}
+
+// ForeignTableInfo is the server and options of a foreign table
+type ForeignTableInfo struct {
+	Server string `json:"server"`
+	// Options are the table options, e.g. schema_name and table_name for postgres_fdw
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// GetForeignServers retrieves the foreign servers of the current database with their
+// wrappers and options
+func (is *IntrospectionService) GetForeignServers(ctx context.Context) ([]ForeignServerInfo, error) {
+	type serverRow struct {
+		ForeignServerInfo
+		ServerOptions pq.StringArray `db:"server_options"`
+	}
+
+	var rows []serverRow
+	query := `
+		SELECT
+			s.srvname as server_name,
+			pg_get_userbyid(s.srvowner) as owner,
+			w.fdwname as wrapper_name,
+			s.srvtype as server_type,
+			s.srvversion as server_version,
+			s.srvoptions as server_options,
+			obj_description(s.oid, 'pg_foreign_server') as server_comment
+		FROM pg_foreign_server s
+		JOIN pg_foreign_data_wrapper w ON w.oid = s.srvfdw
+		ORDER BY s.srvname
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &rows, query)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_foreign_servers", "failed to get foreign servers")
+	}
+
+	servers := make([]ForeignServerInfo, 0, len(rows))
+	for _, row := range rows {
+		server := row.ForeignServerInfo
+		server.Options = parseOptions(row.ServerOptions)
+		servers = append(servers, server)
+	}
+
+	return servers, nil
+}
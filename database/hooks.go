@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// HookEvent identifies when a backup hook runs
+type HookEvent string
+
+const (
+	// BeforeBackup runs before pg_dump starts; a failure skips the backup
+	BeforeBackup HookEvent = "before_backup"
+	// AfterBackup runs after the backup finished or failed
+	AfterBackup HookEvent = "after_backup"
+	// BeforeRestore runs before the restore starts; a failure skips the restore
+	BeforeRestore HookEvent = "before_restore"
+	// AfterRestore runs after the restore finished or failed
+	AfterRestore HookEvent = "after_restore"
+)
+
+// defaultHookTimeout bounds hooks without a Timeout so a stuck hook cannot block backups
+const defaultHookTimeout = 5 * time.Minute
+
+// HookContext describes the operation a hook runs around
+type HookContext struct {
+	Event    HookEvent
+	Database string
+	// Path is the backup file or URL; empty before a backup to a generated name
+	Path string
+	// Err is the error of the operation, only set for after hooks
+	Err error
+}
+
+// BackupHook runs a Go callback or a shell command around backups and restores made
+// through DB, e.g. to pause writers, flush application caches or notify monitoring.
+//
+// Shell commands run with sh -c and get the context in DBKIT_HOOK_EVENT, DBKIT_DATABASE,
+// DBKIT_BACKUP_PATH and DBKIT_ERROR.
+type BackupHook struct {
+	// Name identifies the hook in logs and errors
+	Name   string
+	Events []HookEvent
+	// Func is called if set, otherwise Command is run
+	Func    func(ctx context.Context, hook HookContext) error
+	Command string
+	// ContinueOnError logs a failure and records it in the operation's error context
+	// instead of failing the operation
+	ContinueOnError bool
+	// Timeout bounds the hook, 5 minutes by default
+	Timeout time.Duration
+}
+
+// handles reports whether the hook runs for event
+func (h BackupHook) handles(event HookEvent) bool {
+	for _, e := range h.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// run calls the hook's callback or command
+func (h BackupHook) run(ctx context.Context, hc HookContext, config Config) error {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if h.Func != nil {
+		return h.Func(ctx, hc)
+	}
+	if h.Command == "" {
+		return errors.New("hook has neither a function nor a command")
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Env = append(os.Environ(),
+		"DBKIT_HOOK_EVENT="+string(hc.Event),
+		"DBKIT_DATABASE="+hc.Database,
+		"DBKIT_BACKUP_PATH="+hc.Path,
+	)
+	if hc.Err != nil {
+		cmd.Env = append(cmd.Env, "DBKIT_ERROR="+hc.Err.Error())
+	}
+	output := captureOutput(cmd, config)
+	if err := cmd.Run(); err != nil {
+		if out := output.String(); out != "" {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// hooksFromEnv returns the shell command hooks configured with BACKUP_HOOK_BEFORE,
+// BACKUP_HOOK_AFTER, RESTORE_HOOK_BEFORE and RESTORE_HOOK_AFTER
+func hooksFromEnv() []BackupHook {
+	var hooks []BackupHook
+	for env, event := range map[string]HookEvent{
+		"BACKUP_HOOK_BEFORE":  BeforeBackup,
+		"BACKUP_HOOK_AFTER":   AfterBackup,
+		"RESTORE_HOOK_BEFORE": BeforeRestore,
+		"RESTORE_HOOK_AFTER":  AfterRestore,
+	} {
+		if command := os.Getenv(env); command != "" {
+			hooks = append(hooks, BackupHook{Name: env, Events: []HookEvent{event}, Command: command})
+		}
+	}
+	return hooks
+}
+
+// withHooks runs op between the before and after hooks of the configured BackupHooks. op
+// returns the path of the backup, if known. A failing before hook skips op; a failing after
+// hook fails a successful op. Failures of ContinueOnError hooks are logged and recorded in
+// the context of op's error under "hook_errors".
+func (d *DB) withHooks(ctx context.Context, before, after HookEvent, path string, op func() (string, error)) (string, error) {
+	hc := HookContext{Event: before, Database: d.config.DBName, Path: path}
+	var annotations []string
+
+	failures, err := d.runHooks(ctx, hc)
+	annotations = append(annotations, failures...)
+	if err != nil {
+		return "", err
+	}
+
+	result, opErr := op()
+	if result != "" {
+		hc.Path = result
+	}
+	hc.Event, hc.Err = after, opErr
+
+	failures, hookErr := d.runHooks(ctx, hc)
+	annotations = append(annotations, failures...)
+
+	if opErr != nil {
+		var dbErr *DBError
+		if errors.As(opErr, &dbErr) {
+			if hookErr != nil {
+				annotations = append(annotations, hookErr.Error())
+			}
+			if len(annotations) > 0 {
+				dbErr.WithContext("hook_errors", annotations)
+			}
+		}
+		return result, opErr
+	}
+	return result, hookErr
+}
+
+// runHooks runs the hooks for hc.Event in order. It returns the failures of ContinueOnError
+// hooks and the error of the first other hook that fails, which stops the run.
+func (d *DB) runHooks(ctx context.Context, hc HookContext) ([]string, error) {
+	var failures []string
+	for _, hook := range d.config.BackupHooks {
+		if !hook.handles(hc.Event) {
+			continue
+		}
+
+		started := time.Now()
+		err := hook.run(ctx, hc, d.config)
+		if err == nil {
+			d.logger.Debug("backup hook finished",
+				slog.String("hook", hook.Name),
+				slog.String("event", string(hc.Event)),
+				slog.Duration("duration", time.Since(started)))
+			continue
+		}
+
+		if hook.ContinueOnError {
+			d.logger.Warn("backup hook failed",
+				slog.String("hook", hook.Name),
+				slog.String("event", string(hc.Event)),
+				slog.Any("error", err))
+			failures = append(failures, fmt.Sprintf("%s hook %s failed: %v", hc.Event, hook.Name, err))
+			continue
+		}
+
+		code := ErrCodeBackupFailed
+		if hc.Event == BeforeRestore || hc.Event == AfterRestore {
+			code = ErrCodeRestoreFailed
+		}
+		return failures, NewDBError(code, fmt.Sprintf("%s hook %s failed", hc.Event, hook.Name), err).
+			WithContext("hook", hook.Name).
+			WithContext("backup_path", hc.Path).
+			WithOperation(string(hc.Event))
+	}
+	return failures, nil
+}
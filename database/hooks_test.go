@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// hookTestBackuper records backups without running pg_dump
+type hookTestBackuper struct {
+	err   error
+	calls int
+}
+
+func (b *hookTestBackuper) Backup(ctx context.Context, config Config) error {
+	_, err := b.BackupWithOptions(ctx, config, "", BackupOptions{})
+	return err
+}
+
+func (b *hookTestBackuper) BackupToFile(ctx context.Context, config Config, filePath string) error {
+	_, err := b.BackupWithOptions(ctx, config, filePath, BackupOptions{})
+	return err
+}
+
+func (b *hookTestBackuper) BackupWithOptions(_ context.Context, _ Config, filePath string, _ BackupOptions) (string, error) {
+	b.calls++
+	if b.err != nil {
+		return "", b.err
+	}
+	return "/backups/backup_app_20250101_030000.sql", nil
+}
+
+func newHookTestDB(backuper Backuper, hooks ...BackupHook) *DB {
+	return &DB{
+		config:   Config{DBName: "app", BackupsDir: "/backups", BackupHooks: hooks},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Backuper: backuper,
+	}
+}
+
+func TestBackupHooks(t *testing.T) {
+	var events []string
+	record := func(_ context.Context, hook HookContext) error {
+		events = append(events, string(hook.Event)+":"+hook.Path)
+		return nil
+	}
+
+	backuper := &hookTestBackuper{}
+	db := newHookTestDB(backuper, BackupHook{Name: "record", Events: []HookEvent{BeforeBackup, AfterBackup}, Func: record})
+
+	if _, err := db.BackupWithOptions(context.Background(), "", BackupOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "before_backup:,after_backup:/backups/backup_app_20250101_030000.sql"
+	if got := strings.Join(events, ","); got != want {
+		t.Errorf("Expected hooks %s, got %s", want, got)
+	}
+}
+
+func TestBeforeHookFailureSkipsBackup(t *testing.T) {
+	backuper := &hookTestBackuper{}
+	db := newHookTestDB(backuper, BackupHook{
+		Name:   "pause-writers",
+		Events: []HookEvent{BeforeBackup},
+		Func:   func(context.Context, HookContext) error { return errors.New("writers still running") },
+	})
+
+	err := db.Backup(context.Background())
+	var dbErr *DBError
+	if !errors.As(err, &dbErr) || dbErr.Code != ErrCodeBackupFailed || dbErr.Operation != "before_backup" {
+		t.Fatalf("Expected before_backup hook failure, got %v", err)
+	}
+	if backuper.calls != 0 {
+		t.Error("Expected the backup to be skipped")
+	}
+}
+
+func TestAfterHookFailures(t *testing.T) {
+	failing := func(context.Context, HookContext) error { return errors.New("monitoring unreachable") }
+
+	// A failing after hook fails a successful backup but still returns its path
+	db := newHookTestDB(&hookTestBackuper{}, BackupHook{Name: "notify", Events: []HookEvent{AfterBackup}, Func: failing})
+	path, err := db.BackupWithOptions(context.Background(), "", BackupOptions{})
+	if err == nil || path == "" {
+		t.Errorf("Expected an error and the backup path, got %q, %v", path, err)
+	}
+
+	// ContinueOnError hooks only annotate a failed backup
+	backupErr := NewBackupError("pg_dump command failed", nil)
+	db = newHookTestDB(&hookTestBackuper{err: backupErr},
+		BackupHook{Name: "notify", Events: []HookEvent{AfterBackup}, Func: failing, ContinueOnError: true})
+	_, err = db.BackupWithOptions(context.Background(), "", BackupOptions{})
+	if !errors.Is(err, backupErr) {
+		t.Fatalf("Expected the backup error, got %v", err)
+	}
+	if annotations, _ := backupErr.Context["hook_errors"].([]string); len(annotations) != 1 {
+		t.Errorf("Expected the hook failure in the error context, got %v", backupErr.Context)
+	}
+
+	// ContinueOnError hooks do not fail a successful backup
+	db = newHookTestDB(&hookTestBackuper{},
+		BackupHook{Name: "notify", Events: []HookEvent{AfterBackup}, Func: failing, ContinueOnError: true})
+	if _, err := db.BackupWithOptions(context.Background(), "", BackupOptions{}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCommandHook(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	out := filepath.Join(t.TempDir(), "hook.out")
+	hook := BackupHook{
+		Name:    "env",
+		Events:  []HookEvent{AfterRestore},
+		Command: `echo "$DBKIT_HOOK_EVENT $DBKIT_DATABASE $DBKIT_BACKUP_PATH" > ` + out,
+	}
+
+	err := hook.run(context.Background(), HookContext{Event: AfterRestore, Database: "app", Path: "backup.sql"}, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	if strings.TrimSpace(string(data)) != "after_restore app backup.sql" {
+		t.Errorf("Unexpected hook environment %q", data)
+	}
+
+	hook.Command = "echo 'cache flush failed' >&2; exit 1"
+	err = hook.run(context.Background(), HookContext{Event: AfterRestore}, Config{})
+	if err == nil || !strings.Contains(err.Error(), "cache flush failed") {
+		t.Errorf("Expected the command output in the error, got %v", err)
+	}
+}
+
+func TestHooksFromEnv(t *testing.T) {
+	t.Setenv("BACKUP_HOOK_BEFORE", "systemctl stop worker")
+	t.Setenv("BACKUP_HOOK_AFTER", "")
+	t.Setenv("RESTORE_HOOK_BEFORE", "")
+	t.Setenv("RESTORE_HOOK_AFTER", "")
+
+	hooks := hooksFromEnv()
+	if len(hooks) != 1 || hooks[0].Command != "systemctl stop worker" || !hooks[0].handles(BeforeBackup) {
+		t.Errorf("Unexpected hooks %+v", hooks)
+	}
+}
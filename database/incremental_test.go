@@ -0,0 +1,135 @@
+package database
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIncrementalKey(t *testing.T) {
+	got := incrementalKey("app", "audit.events", "20250101_030000")
+	if got != "incremental_app_audit.events_20250101_030000.jsonl.gz" {
+		t.Errorf("Unexpected key %q", got)
+	}
+	if got := incrementalKey("app", `we"ird/name`, "20250101_030000"); got != "incremental_app_we_ird_name_20250101_030000.jsonl.gz" {
+		t.Errorf("Expected unsafe characters to be replaced, got %q", got)
+	}
+}
+
+func TestQuoteQualifiedName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"events", `"events"`, false},
+		{"audit.events", `"audit"."events"`, false},
+		{`odd"name`, `"odd""name"`, false},
+		{"a.b.c", "", true},
+		{"audit.", "", true},
+	}
+	for _, tt := range tests {
+		got, err := quoteQualifiedName(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("quoteQualifiedName(%q) = %q, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestIncrementalState(t *testing.T) {
+	ctx := context.Background()
+	storage := NewFileStorage(t.TempDir())
+	key := incrementalStateKey("app")
+
+	state, err := readIncrementalState(ctx, storage, key)
+	if err != nil {
+		t.Fatalf("Expected an empty state before the first run: %v", err)
+	}
+	if len(state.Tables) != 0 {
+		t.Errorf("Expected no watermarks, got %v", state.Tables)
+	}
+
+	state.Tables["events"] = incrementalWatermark{Column: "id", Watermark: "42", UpdatedAt: time.Now()}
+	if err := writeIncrementalState(ctx, storage, key, state); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = readIncrementalState(ctx, storage, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Tables["events"].Watermark != "42" {
+		t.Errorf("Expected watermark 42, got %+v", state.Tables["events"])
+	}
+}
+
+func TestExportIncremental(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	table := fmt.Sprintf("incremental_test_%d", time.Now().UnixNano())
+	if _, err := db.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id serial PRIMARY KEY, name text)", table)); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _, _ = db.db.ExecContext(context.Background(), fmt.Sprintf("DROP TABLE %s", table)) }()
+
+	insert := func(names ...string) {
+		t.Helper()
+		for _, name := range names {
+			if _, err := db.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (name) VALUES ($1)", table), name); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	db.config.BackupsDir = t.TempDir()
+	tables := []IncrementalTable{{Table: table, Column: "id"}}
+
+	insert("a", "b")
+	exports, err := db.ExportIncremental(ctx, tables)
+	if err != nil {
+		t.Fatalf("ExportIncremental failed: %v", err)
+	}
+	if exports[0].Rows != 2 || exports[0].To != "2" {
+		t.Fatalf("Expected 2 rows up to id 2, got %+v", exports[0])
+	}
+
+	exports, err = db.ExportIncremental(ctx, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exports[0].Rows != 0 || exports[0].Key != "" {
+		t.Errorf("Expected no changes, got %+v", exports[0])
+	}
+
+	insert("c")
+	exports, err = db.ExportIncremental(ctx, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exports[0].From != "2" || exports[0].Rows != 1 {
+		t.Fatalf("Expected 1 row after id 2, got %+v", exports[0])
+	}
+
+	file, err := os.Open(filepath.Join(db.config.BackupsDir, exports[0].Key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(gz)
+	if !scanner.Scan() || scanner.Text() != `{"id":3,"name":"c"}` {
+		t.Errorf("Unexpected exported row %q", scanner.Text())
+	}
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// IntrospectOptions selects the tables and details fetched by GetTablesWithOptions and
+// GetDatabaseInfoWithOptions. The zero value fetches every user table with all details,
+// like GetTables.
+type IntrospectOptions struct {
+	// Schema limits the tables to one schema; all schemas by default
+	Schema string
+	// IncludeTables keeps only the tables matching one of these patterns, and ExcludeTables
+	// drops the tables matching any of them. Patterns are globs as in path.Match, e.g.
+	// "audit_*", matched against the table name or, if they contain a dot, against
+	// schema.table.
+	IncludeTables []string
+	ExcludeTables []string
+	// IncludeSystemSchemas also returns the tables of pg_catalog and information_schema
+	IncludeSystemSchemas bool
+	// SkipColumns, SkipIndexes, SkipConstraints, SkipTriggers and SkipPartitioning leave the
+	// corresponding table details empty and skip their queries
+	SkipColumns      bool
+	SkipIndexes      bool
+	SkipConstraints  bool
+	SkipTriggers     bool
+	SkipPartitioning bool
+}
+
+// GetTablesWithOptions retrieves the tables selected by opts with the details they ask for.
+// Results of GetTables cached with WithCache are reused when opts skips no details and
+// leaves out system schemas; otherwise, the details are only queried for the tables
+// matching the table patterns.
+func (is *IntrospectionService) GetTablesWithOptions(ctx context.Context, opts IntrospectOptions) ([]TableInfo, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.IncludeSystemSchemas || opts.skipsDetails() {
+		return is.getTables(ctx, opts)
+	}
+	if opts.filters() {
+		if is.cache != nil {
+			if tables, ok := is.cache.tables(opts.Schema); ok {
+				return opts.filterTables(tables), nil
+			}
+		}
+		return is.getTables(ctx, opts)
+	}
+	return is.GetTables(ctx, opts.Schema)
+}
+
+// validate checks the table patterns
+func (o IntrospectOptions) validate() error {
+	for _, patterns := range [][]string{o.IncludeTables, o.ExcludeTables} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return NewValidationError(fmt.Sprintf("invalid table pattern %q", pattern), err).
+					WithContext("pattern", pattern).
+					WithOperation("get_tables")
+			}
+		}
+	}
+	return nil
+}
+
+func (o IntrospectOptions) skipsDetails() bool {
+	return o.SkipColumns || o.SkipIndexes || o.SkipConstraints || o.SkipTriggers || o.SkipPartitioning
+}
+
+// filters reports whether IncludeTables or ExcludeTables is set
+func (o IntrospectOptions) filters() bool {
+	return len(o.IncludeTables) > 0 || len(o.ExcludeTables) > 0
+}
+
+// tableNames returns the names of tables, selected by the table patterns, to limit the
+// detail queries to, or nil without patterns. Tables of other schemas with the same names
+// are queried too, and left out by their keys.
+func (o IntrospectOptions) tableNames(tables []TableInfo) []string {
+	if !o.filters() {
+		return nil
+	}
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		if !slices.Contains(names, table.Name) {
+			names = append(names, table.Name)
+		}
+	}
+	return names
+}
+
+// filterTables applies IncludeTables and ExcludeTables, keeping the order of tables
+func (o IntrospectOptions) filterTables(tables []TableInfo) []TableInfo {
+	if !o.filters() {
+		return tables
+	}
+
+	filtered := make([]TableInfo, 0, len(tables))
+	for _, table := range tables {
+		if len(o.IncludeTables) > 0 && !matchesTable(o.IncludeTables, table) {
+			continue
+		}
+		if matchesTable(o.ExcludeTables, table) {
+			continue
+		}
+		filtered = append(filtered, table)
+	}
+	return filtered
+}
+
+// matchesTable reports whether a pattern matches the table, see IntrospectOptions
+func matchesTable(patterns []string, table TableInfo) bool {
+	for _, pattern := range patterns {
+		name := table.Name
+		if strings.Contains(pattern, ".") {
+			name = table.Schema + "." + table.Name
+		}
+		// Patterns were validated
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
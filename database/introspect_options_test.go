@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospectOptionsFilterTables(t *testing.T) {
+	tables := []TableInfo{
+		{Schema: "public", Name: "users"},
+		{Schema: "public", Name: "audit_users"},
+		{Schema: "public", Name: "audit_orders"},
+		{Schema: "billing", Name: "users"},
+	}
+	names := func(tables []TableInfo) []string {
+		var names []string
+		for _, table := range tables {
+			names = append(names, table.Schema+"."+table.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name     string
+		opts     IntrospectOptions
+		expected []string
+	}{
+		{
+			name:     "no patterns",
+			expected: []string{"public.users", "public.audit_users", "public.audit_orders", "billing.users"},
+		},
+		{
+			name:     "include by name",
+			opts:     IntrospectOptions{IncludeTables: []string{"audit_*"}},
+			expected: []string{"public.audit_users", "public.audit_orders"},
+		},
+		{
+			name:     "exclude by name",
+			opts:     IntrospectOptions{ExcludeTables: []string{"audit_*"}},
+			expected: []string{"public.users", "billing.users"},
+		},
+		{
+			name:     "qualified pattern",
+			opts:     IntrospectOptions{IncludeTables: []string{"billing.*", "*_orders"}},
+			expected: []string{"public.audit_orders", "billing.users"},
+		},
+		{
+			name:     "exclude wins",
+			opts:     IntrospectOptions{IncludeTables: []string{"*users"}, ExcludeTables: []string{"public.users"}},
+			expected: []string{"public.audit_users", "billing.users"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, names(tt.opts.filterTables(tables)))
+		})
+	}
+}
+
+func TestIntrospectOptionsTableNames(t *testing.T) {
+	tables := []TableInfo{
+		{Schema: "public", Name: "users"},
+		{Schema: "billing", Name: "users"},
+		{Schema: "public", Name: "orders"},
+	}
+	assert.Nil(t, IntrospectOptions{}.tableNames(tables))
+
+	opts := IntrospectOptions{ExcludeTables: []string{"audit_*"}}
+	assert.Equal(t, []string{"users", "orders"}, opts.tableNames(tables))
+	assert.Equal(t, []string{}, opts.tableNames(nil))
+}
+
+func TestIntrospectOptionsInvalidPattern(t *testing.T) {
+	is := NewIntrospectionService(nil)
+	_, err := is.GetTablesWithOptions(context.Background(), IntrospectOptions{ExcludeTables: []string{"audit_["}})
+	require.Error(t, err)
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+
+	_, err = is.GetDatabaseInfoWithOptions(context.Background(), IntrospectOptions{IncludeTables: []string{"["}})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
@@ -2,19 +2,47 @@ package database
 
 import (
 	"context"
-	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/sync/errgroup"
 )
 
 // IntrospectionService provides database schema introspection capabilities
 type IntrospectionService struct {
 	db *DB
+	// parallelism bounds the queries GetTables and GetDatabaseInfo run at once
+	parallelism int
+	// cache holds GetTables results, nil unless enabled with WithCache
+	cache *introspectionCache
 }
 
 // NewIntrospectionService creates a new introspection service
 func NewIntrospectionService(db *DB) *IntrospectionService {
-	return &IntrospectionService{db: db}
+	return &IntrospectionService{db: db, parallelism: 1}
+}
+
+// WithParallelism returns a copy of the service that runs up to n queries of GetTables and
+// GetDatabaseInfo concurrently, e.g. to introspect large schemas faster. Each query uses a
+// connection from the pool, so n should stay below MaxOpenConns. Values below 1 mean 1.
+func (is *IntrospectionService) WithParallelism(n int) *IntrospectionService {
+	if n < 1 {
+		n = 1
+	}
+	service := *is
+	service.parallelism = n
+	return &service
+}
+
+// group returns an errgroup bounded by the service's parallelism
+func (is *IntrospectionService) group(ctx context.Context) (*errgroup.Group, context.Context) {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(is.parallelism, 1))
+	return g, gctx
 }
 
 // TableInfo represents information about a database table
@@ -26,6 +54,53 @@ type TableInfo struct {
 	Columns     []ColumnInfo     `json:"columns,omitempty"`
 	Indexes     []IndexInfo      `json:"indexes,omitempty"`
 	Constraints []ConstraintInfo `json:"constraints,omitempty"`
+	Triggers    []TriggerInfo    `json:"triggers,omitempty"`
+	// Partitioning is set for partitioned tables
+	Partitioning *PartitioningInfo `json:"partitioning,omitempty"`
+	// Tablespace is the tablespace of the table, nil for the database default
+	Tablespace *string `json:"tablespace,omitempty" db:"tablespace"`
+	// AccessMethod is the table access method, e.g. heap, nil for views and partitioned tables
+	AccessMethod *string `json:"access_method,omitempty" db:"access_method"`
+	Unlogged     bool    `json:"unlogged,omitempty" db:"is_unlogged"`
+	// StorageParameters are the parameters set with WITH (...) or ALTER TABLE ... SET, e.g.
+	// fillfactor or autovacuum_vacuum_scale_factor; those of the TOAST table are prefixed
+	// with "toast."
+	StorageParameters map[string]string `json:"storage_parameters,omitempty"`
+	// Foreign is set for foreign tables, whose data lives on a foreign server
+	Foreign *ForeignTableInfo `json:"foreign,omitempty"`
+}
+
+// Fillfactor returns the fillfactor storage parameter of the table, 100 if not set
+func (t TableInfo) Fillfactor() int {
+	if value, err := strconv.Atoi(t.StorageParameters["fillfactor"]); err == nil {
+		return value
+	}
+	return 100
+}
+
+// AutovacuumOverrides returns the autovacuum storage parameters of the table and its TOAST
+// table, which override the server settings
+func (t TableInfo) AutovacuumOverrides() map[string]string {
+	overrides := make(map[string]string)
+	for name, value := range t.StorageParameters {
+		if strings.HasPrefix(strings.TrimPrefix(name, "toast."), "autovacuum_") {
+			overrides[name] = value
+		}
+	}
+	return overrides
+}
+
+// parseOptions parses reloptions and ftoptions entries such as "fillfactor=70"
+func parseOptions(options []string) map[string]string {
+	if len(options) == 0 {
+		return nil
+	}
+	parameters := make(map[string]string, len(options))
+	for _, option := range options {
+		name, value, _ := strings.Cut(option, "=")
+		parameters[name] = value
+	}
+	return parameters
 }
 
 // ColumnInfo represents information about a table column
@@ -41,6 +116,75 @@ type ColumnInfo struct {
 	NumericPrecision *int    `json:"numeric_precision,omitempty" db:"numeric_precision"`
 	NumericScale     *int    `json:"numeric_scale,omitempty" db:"numeric_scale"`
 	Comment          *string `json:"comment,omitempty" db:"column_comment"`
+	// EnumType is the schema-qualified enum type of the column, if it is an enum
+	EnumType   *string        `json:"enum_type,omitempty" db:"enum_type"`
+	EnumValues pq.StringArray `json:"enum_values,omitempty" db:"enum_values"`
+	// IsIdentity is set for identity columns, with IdentityGeneration ALWAYS or BY DEFAULT
+	IsIdentity         bool    `json:"is_identity" db:"is_identity"`
+	IdentityGeneration *string `json:"identity_generation,omitempty" db:"identity_generation"`
+	// IsGenerated is set for stored generated columns, computed by GenerationExpression
+	IsGenerated          bool    `json:"is_generated" db:"is_generated"`
+	GenerationExpression *string `json:"generation_expression,omitempty" db:"generation_expression"`
+	// Collation is the collation of the column, if it is not the default of its type
+	Collation *string `json:"collation,omitempty" db:"collation_name"`
+	// DomainType is the schema-qualified domain of the column, if any; DataType and BaseType
+	// describe the type underlying the domain
+	DomainType *string `json:"domain_type,omitempty" db:"domain_type"`
+	BaseType   *string `json:"base_type,omitempty" db:"base_type"`
+	// ElementType is the element type of array columns, e.g. "integer" for integer[]
+	ElementType *string `json:"element_type,omitempty" db:"element_type"`
+}
+
+// PartitioningInfo represents how a partitioned table is split
+type PartitioningInfo struct {
+	// Strategy is range, list or hash
+	Strategy string `json:"strategy"`
+	// Key is the partition key, e.g. "created_at" or "lower(email)"
+	Key        string          `json:"key"`
+	Partitions []PartitionInfo `json:"partitions"`
+}
+
+// PartitionInfo represents a partition of a partitioned table
+type PartitionInfo struct {
+	Name   string `json:"name" db:"partition_name"`
+	Schema string `json:"schema" db:"partition_schema"`
+	// Bound is the partition bound, e.g. "FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')" or "DEFAULT"
+	Bound string `json:"bound" db:"partition_bound"`
+	// IsPartitioned is set for partitions that are partitioned themselves
+	IsPartitioned bool `json:"is_partitioned" db:"is_partitioned"`
+}
+
+// TriggerInfo represents a trigger on a table
+type TriggerInfo struct {
+	Name      string `json:"name"`
+	TableName string `json:"table_name"`
+	// Timing is BEFORE, AFTER or INSTEAD OF
+	Timing string `json:"timing"`
+	// Events are the operations firing the trigger: INSERT, UPDATE, DELETE and TRUNCATE
+	Events []string `json:"events"`
+	// Level is ROW or STATEMENT
+	Level string `json:"level"`
+	// Function is the schema-qualified trigger function
+	Function   string `json:"function"`
+	Enabled    bool   `json:"enabled"`
+	Definition string `json:"definition"`
+}
+
+// ViewInfo represents a view or materialized view
+type ViewInfo struct {
+	Name         string  `json:"name" db:"view_name"`
+	Schema       string  `json:"schema" db:"view_schema"`
+	Materialized bool    `json:"materialized" db:"is_materialized"`
+	Definition   string  `json:"definition" db:"definition"`
+	Comment      *string `json:"comment,omitempty" db:"view_comment"`
+}
+
+// EnumInfo represents an enum type and its labels in sort order
+type EnumInfo struct {
+	Name    string         `json:"name" db:"enum_name"`
+	Schema  string         `json:"schema" db:"enum_schema"`
+	Values  pq.StringArray `json:"values" db:"enum_values"`
+	Comment *string        `json:"comment,omitempty" db:"enum_comment"`
 }
 
 // IndexInfo represents information about a table index
@@ -63,6 +207,78 @@ type ConstraintInfo struct {
 	ReferencedColumns []string `json:"referenced_columns,omitempty"`
 	UpdateRule        *string  `json:"update_rule,omitempty" db:"update_rule"`
 	DeleteRule        *string  `json:"delete_rule,omitempty" db:"delete_rule"`
+	// Definition is the constraint clause as in ALTER TABLE ... ADD CONSTRAINT, e.g. the
+	// expression of a CHECK constraint
+	Definition string `json:"definition,omitempty" db:"definition"`
+}
+
+// FunctionInfo represents a function or stored procedure
+type FunctionInfo struct {
+	Name   string `json:"name" db:"function_name"`
+	Schema string `json:"schema" db:"function_schema"`
+	// Kind is "function" or "procedure"
+	Kind      string `json:"kind" db:"function_kind"`
+	Arguments string `json:"arguments" db:"arguments"`
+	// ReturnType is nil for procedures
+	ReturnType *string `json:"return_type,omitempty" db:"return_type"`
+	Language   string  `json:"language" db:"language"`
+	// Volatility is "immutable", "stable" or "volatile"
+	Volatility string  `json:"volatility" db:"volatility"`
+	Source     string  `json:"source" db:"source"`
+	Comment    *string `json:"comment,omitempty" db:"function_comment"`
+}
+
+// ExtensionInfo represents an installed extension
+type ExtensionInfo struct {
+	Name    string `json:"name" db:"extension_name"`
+	Schema  string `json:"schema" db:"extension_schema"`
+	Version string `json:"version" db:"installed_version"`
+	// DefaultVersion is the version CREATE EXTENSION would install, nil if the extension
+	// is no longer available on the server
+	DefaultVersion  *string `json:"default_version,omitempty" db:"default_version"`
+	UpdateAvailable bool    `json:"update_available" db:"-"`
+	Comment         *string `json:"comment,omitempty" db:"extension_comment"`
+}
+
+// RoleInfo represents a database role and its attributes
+type RoleInfo struct {
+	Name        string `json:"name" db:"role_name"`
+	Login       bool   `json:"login" db:"can_login"`
+	Superuser   bool   `json:"superuser" db:"is_superuser"`
+	CreateDB    bool   `json:"create_db" db:"can_create_db"`
+	CreateRole  bool   `json:"create_role" db:"can_create_role"`
+	Inherit     bool   `json:"inherit" db:"inherits"`
+	Replication bool   `json:"replication" db:"is_replication"`
+	BypassRLS   bool   `json:"bypass_rls" db:"bypasses_rls"`
+	// ConnectionLimit is -1 for no limit
+	ConnectionLimit int        `json:"connection_limit" db:"connection_limit"`
+	ValidUntil      *time.Time `json:"valid_until,omitempty" db:"valid_until"`
+	// MemberOf lists the roles this role is a member of
+	MemberOf pq.StringArray `json:"member_of" db:"member_of"`
+}
+
+// GrantInfo represents a privilege granted on a table or one of its columns
+type GrantInfo struct {
+	// Grantee is a role name or PUBLIC
+	Grantee   string `json:"grantee" db:"grantee"`
+	Grantor   string `json:"grantor" db:"grantor"`
+	Privilege string `json:"privilege" db:"privilege_type"`
+	// Column is nil for privileges on the whole table
+	Column      *string `json:"column,omitempty" db:"column_name"`
+	IsGrantable bool    `json:"is_grantable" db:"is_grantable"`
+}
+
+// TableSize represents the disk usage of a table
+type TableSize struct {
+	Schema string `json:"schema" db:"table_schema"`
+	Name   string `json:"name" db:"table_name"`
+	// TotalBytes is the sum of TableBytes, IndexBytes and ToastBytes
+	TotalBytes int64 `json:"total_bytes" db:"total_bytes"`
+	TableBytes int64 `json:"table_bytes" db:"table_bytes"`
+	IndexBytes int64 `json:"index_bytes" db:"index_bytes"`
+	ToastBytes int64 `json:"toast_bytes" db:"toast_bytes"`
+	// EstimatedRows is the planner's estimate as of the last VACUUM or ANALYZE, 0 if never analyzed
+	EstimatedRows int64 `json:"estimated_rows" db:"estimated_rows"`
 }
 
 // Info represents overall database information
@@ -76,39 +292,67 @@ type Info struct {
 
 // GetDatabaseInfo retrieves comprehensive database information
 func (is *IntrospectionService) GetDatabaseInfo(ctx context.Context) (*Info, error) {
+	return is.GetDatabaseInfoWithOptions(ctx, IntrospectOptions{})
+}
+
+// GetDatabaseInfoWithOptions retrieves database information with the tables selected by
+// opts, see GetTablesWithOptions
+func (is *IntrospectionService) GetDatabaseInfoWithOptions(ctx context.Context, opts IntrospectOptions) (*Info, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
 	info := &Info{
 		Name: is.db.config.DBName,
 	}
 
+	g, gctx := is.group(ctx)
+
 	// Get database version
-	version, err := is.GetDatabaseVersion(ctx)
-	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "get_database_info", "failed to get database version")
-	}
-	info.Version = version
+	g.Go(func() error {
+		version, err := is.GetDatabaseVersion(gctx)
+		if err != nil {
+			return WrapError(err, ErrCodeQueryFailed, "get_database_info", "failed to get database version")
+		}
+		info.Version = version
+		return nil
+	})
 
 	// Get database size
-	size, err := is.GetDatabaseSize(ctx)
-	if err != nil {
-		// Size is optional, log but don't fail
-		is.db.logger.Warn("failed to get database size", "error", err)
-	} else {
+	g.Go(func() error {
+		size, err := is.GetDatabaseSize(gctx)
+		if err != nil {
+			// Size is optional, log but don't fail
+			is.db.logger.Warn("failed to get database size", "error", err)
+			return nil
+		}
 		info.Size = &size
-	}
+		return nil
+	})
 
 	// Get schemas
-	schemas, err := is.GetSchemas(ctx)
-	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "get_database_info", "failed to get schemas")
-	}
-	info.Schemas = schemas
+	g.Go(func() error {
+		schemas, err := is.GetSchemas(gctx)
+		if err != nil {
+			return WrapError(err, ErrCodeQueryFailed, "get_database_info", "failed to get schemas")
+		}
+		info.Schemas = schemas
+		return nil
+	})
 
 	// Get tables
-	tables, err := is.GetTables(ctx, "")
-	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "get_database_info", "failed to get tables")
+	g.Go(func() error {
+		tables, err := is.GetTablesWithOptions(gctx, opts)
+		if err != nil {
+			return WrapError(err, ErrCodeQueryFailed, "get_database_info", "failed to get tables")
+		}
+		info.Tables = tables
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
-	info.Tables = tables
 
 	return info, nil
 }
@@ -125,6 +369,46 @@ func (is *IntrospectionService) GetDatabaseVersion(ctx context.Context) (string,
 	return version, nil
 }
 
+// ServerInfo describes the connected server and session
+type ServerInfo struct {
+	Version        string `json:"version" db:"version"`
+	VersionNum     int    `json:"version_num" db:"version_num"`
+	Database       string `json:"database" db:"database"`
+	User           string `json:"user" db:"user_name"`
+	Encoding       string `json:"encoding" db:"encoding"`
+	TimeZone       string `json:"timezone" db:"timezone"`
+	MaxConnections int    `json:"max_connections" db:"max_connections"`
+	SharedBuffers  string `json:"shared_buffers" db:"shared_buffers"`
+	DataChecksums  bool   `json:"data_checksums" db:"data_checksums"`
+	InRecovery     bool   `json:"in_recovery" db:"in_recovery"`
+	SSL            bool   `json:"ssl" db:"ssl"`
+}
+
+// GetServerInfo retrieves the server version, the main server settings and whether the
+// session is encrypted
+func (is *IntrospectionService) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
+	var info ServerInfo
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.GetContext(ctx, &info, `
+			SELECT
+				version() AS version,
+				current_setting('server_version_num')::int AS version_num,
+				current_database() AS database,
+				current_user AS user_name,
+				current_setting('server_encoding') AS encoding,
+				current_setting('TimeZone') AS timezone,
+				current_setting('max_connections')::int AS max_connections,
+				current_setting('shared_buffers') AS shared_buffers,
+				current_setting('data_checksums')::bool AS data_checksums,
+				pg_is_in_recovery() AS in_recovery,
+				coalesce((SELECT ssl FROM pg_stat_ssl WHERE pid = pg_backend_pid()), false) AS ssl`)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_server_info", "failed to get server information")
+	}
+	return &info, nil
+}
+
 // GetDatabaseSize retrieves the size of the database in bytes
 func (is *IntrospectionService) GetDatabaseSize(ctx context.Context) (int64, error) {
 	var size int64
@@ -155,66 +439,172 @@ func (is *IntrospectionService) GetSchemas(ctx context.Context) ([]string, error
 	return schemas, nil
 }
 
-// GetTables retrieves all tables in the specified schema (empty string for all schemas)
+// tableKey identifies a table in the results of set-based introspection queries
+type tableKey struct {
+	schema string
+	name   string
+}
+
+// GetTables retrieves all tables in the specified schema (empty string for all schemas).
+// Columns, indexes, constraints, triggers and partitioning are fetched with one query each
+// for the whole schema rather than per table. With WithCache, results are reused until they
+// expire or are invalidated.
 func (is *IntrospectionService) GetTables(ctx context.Context, schema string) ([]TableInfo, error) {
-	var tables []TableInfo
+	if is.cache == nil {
+		return is.getTables(ctx, IntrospectOptions{Schema: schema})
+	}
+	if tables, ok := is.cache.tables(schema); ok {
+		return tables, nil
+	}
+
+	tables, err := is.getTables(ctx, IntrospectOptions{Schema: schema})
+	if err != nil {
+		return nil, err
+	}
+	is.cache.storeTables(schema, tables)
+	return slices.Clone(tables), nil
+}
 
+// getTables runs the GetTables queries, fetching only the details opts asks for
+func (is *IntrospectionService) getTables(ctx context.Context, opts IntrospectOptions) ([]TableInfo, error) {
+	type tableRow struct {
+		TableInfo
+		StorageOptions pq.StringArray `db:"storage_options"`
+		ForeignServer  *string        `db:"foreign_server"`
+		ForeignOptions pq.StringArray `db:"foreign_options"`
+	}
+
+	var rows []tableRow
 	query := `
 		SELECT
 			t.table_name,
 			t.table_schema,
 			t.table_type,
-			obj_description(c.oid) as table_comment
+			obj_description(c.oid) as table_comment,
+			ts.spcname as tablespace,
+			am.amname as access_method,
+			coalesce(c.relpersistence = 'u', false) as is_unlogged,
+			coalesce(c.reloptions, '{}') || ARRAY(
+				SELECT 'toast.' || opt FROM unnest(toast.reloptions) opt
+			) as storage_options,
+			fs.srvname as foreign_server,
+			ft.ftoptions as foreign_options
 		FROM information_schema.tables t
-		LEFT JOIN pg_class c ON c.relname = t.table_name
-		LEFT JOIN pg_namespace n ON n.oid = c.relnamespace AND n.nspname = t.table_schema
-		WHERE t.table_schema NOT IN ('information_schema', 'pg_catalog')
+		LEFT JOIN pg_namespace n ON n.nspname = t.table_schema
+		LEFT JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = t.table_name
+		LEFT JOIN pg_tablespace ts ON ts.oid = c.reltablespace
+		LEFT JOIN pg_am am ON am.oid = c.relam
+		LEFT JOIN pg_class toast ON toast.oid = c.reltoastrelid
+		LEFT JOIN pg_foreign_table ft ON ft.ftrelid = c.oid
+		LEFT JOIN pg_foreign_server fs ON fs.oid = ft.ftserver
+		WHERE ($1::bool OR t.table_schema NOT IN ('information_schema', 'pg_catalog'))
 	`
 
-	args := []interface{}{}
-	if schema != "" {
-		query += " AND t.table_schema = $1"
-		args = append(args, schema)
+	args := []interface{}{opts.IncludeSystemSchemas}
+	if opts.Schema != "" {
+		query += " AND t.table_schema = $2"
+		args = append(args, opts.Schema)
 	}
 
 	query += " ORDER BY t.table_schema, t.table_name"
 
 	err := is.db.WithValidation(ctx, func() error {
-		return is.db.db.SelectContext(ctx, &tables, query, args...)
+		return is.db.db.SelectContext(ctx, &rows, query, args...)
 	})
 	if err != nil {
 		return nil, WrapError(err, ErrCodeQueryFailed, "get_tables", "failed to get tables")
 	}
 
-	// Get detailed information for each table
-	for i := range tables {
-		// Get columns
-		columns, err := is.GetTableColumns(ctx, tables[i].Schema, tables[i].Name)
-		if err != nil {
-			return nil, WrapError(err, ErrCodeQueryFailed, "get_tables", fmt.Sprintf("failed to get columns for table %s.%s", tables[i].Schema, tables[i].Name))
+	tables := make([]TableInfo, 0, len(rows))
+	for _, row := range rows {
+		row.TableInfo.StorageParameters = parseOptions(row.StorageOptions)
+		if row.ForeignServer != nil {
+			row.TableInfo.Foreign = &ForeignTableInfo{Server: *row.ForeignServer, Options: parseOptions(row.ForeignOptions)}
 		}
-		tables[i].Columns = columns
+		tables = append(tables, row.TableInfo)
+	}
+	if tables = opts.filterTables(tables); len(tables) == 0 {
+		return tables, nil
+	}
+	// With table patterns, the details are only queried for the selected tables
+	names := opts.tableNames(tables)
+
+	var (
+		columns      map[tableKey][]ColumnInfo
+		indexes      map[tableKey][]IndexInfo
+		constraints  map[tableKey][]ConstraintInfo
+		triggers     map[tableKey][]TriggerInfo
+		partitioning map[tableKey]*PartitioningInfo
+	)
+	g, gctx := is.group(ctx)
+
+	// Get columns
+	if !opts.SkipColumns {
+		g.Go(func() error {
+			var err error
+			if columns, err = is.getColumns(gctx, opts.Schema, names, opts.IncludeSystemSchemas); err != nil {
+				return WrapError(err, ErrCodeQueryFailed, "get_tables", "failed to get columns")
+			}
+			return nil
+		})
+	}
 
-		// Get indexes
-		indexes, err := is.GetTableIndexes(ctx, tables[i].Schema, tables[i].Name)
-		if err != nil {
-			return nil, WrapError(err, ErrCodeQueryFailed, "get_tables", fmt.Sprintf("failed to get indexes for table %s.%s", tables[i].Schema, tables[i].Name))
-		}
-		tables[i].Indexes = indexes
+	// Get indexes
+	if !opts.SkipIndexes {
+		g.Go(func() error {
+			var err error
+			if indexes, err = is.getIndexes(gctx, opts.Schema, names, opts.IncludeSystemSchemas); err != nil {
+				return WrapError(err, ErrCodeQueryFailed, "get_tables", "failed to get indexes")
+			}
+			return nil
+		})
+	}
 
-		// Get constraints - make this optional to avoid timeouts
-		constraints, err := is.GetTableConstraints(ctx, tables[i].Schema, tables[i].Name)
-		if err != nil {
-			// Log warning but don't fail the entire operation
-			is.db.logger.Warn("failed to get constraints for table",
-				"schema", tables[i].Schema,
-				"table", tables[i].Name,
-				"error", err)
-			// Set empty constraints instead of failing
+	// Constraints, triggers and partitioning are optional; log a warning but don't fail
+	// the entire operation
+	if !opts.SkipConstraints {
+		g.Go(func() error {
+			var err error
+			if constraints, err = is.getConstraints(gctx, opts.Schema, names, opts.IncludeSystemSchemas); err != nil {
+				is.db.logger.Warn("failed to get constraints", "schema", opts.Schema, "error", err)
+			}
+			return nil
+		})
+	}
+	if !opts.SkipTriggers {
+		g.Go(func() error {
+			var err error
+			if triggers, err = is.getTriggers(gctx, opts.Schema, names, opts.IncludeSystemSchemas); err != nil {
+				is.db.logger.Warn("failed to get triggers", "schema", opts.Schema, "error", err)
+			}
+			return nil
+		})
+	}
+	if !opts.SkipPartitioning {
+		g.Go(func() error {
+			var err error
+			if partitioning, err = is.getPartitioning(gctx, opts.Schema, names); err != nil {
+				is.db.logger.Warn("failed to get partitioning", "schema", opts.Schema, "error", err)
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for i := range tables {
+		key := tableKey{schema: tables[i].Schema, name: tables[i].Name}
+		tables[i].Columns = columns[key]
+		tables[i].Indexes = indexes[key]
+		tables[i].Constraints = constraints[key]
+		if constraints == nil && !opts.SkipConstraints {
+			// Set empty constraints when they could not be retrieved
 			tables[i].Constraints = []ConstraintInfo{}
-		} else {
-			tables[i].Constraints = constraints
 		}
+		tables[i].Triggers = triggers[key]
+		tables[i].Partitioning = partitioning[key]
 	}
 
 	return tables, nil
@@ -222,10 +612,36 @@ func (is *IntrospectionService) GetTables(ctx context.Context, schema string) ([
 
 // GetTableColumns retrieves columns for a specific table
 func (is *IntrospectionService) GetTableColumns(ctx context.Context, schema, tableName string) ([]ColumnInfo, error) {
-	var columns []ColumnInfo
+	columns, err := is.getColumns(ctx, schema, tableNames(tableName), false)
+	if err != nil {
+		return nil, err
+	}
+	return columns[tableKey{schema: schema, name: tableName}], nil
+}
+
+// tableNames returns the filter of the detail queries for one table, none if tableName is
+// empty
+func tableNames(tableName string) []string {
+	if tableName == "" {
+		return nil
+	}
+	return []string{tableName}
+}
+
+// getColumns retrieves the columns of all tables in schema, or only of the tables named in
+// tables if not nil, keyed by table
+func (is *IntrospectionService) getColumns(ctx context.Context, schema string, tables []string, systemSchemas bool) (map[tableKey][]ColumnInfo, error) {
+	type columnRow struct {
+		TableSchema string `db:"table_schema"`
+		TableName   string `db:"table_name"`
+		ColumnInfo
+	}
 
+	var rows []columnRow
 	query := `
 		SELECT
+			c.table_schema,
+			c.table_name,
 			c.column_name,
 			c.data_type,
 			CASE WHEN c.is_nullable = 'YES' THEN true ELSE false END as is_nullable,
@@ -233,62 +649,100 @@ func (is *IntrospectionService) GetTableColumns(ctx context.Context, schema, tab
 			c.character_maximum_length,
 			c.numeric_precision,
 			c.numeric_scale,
-			CASE WHEN pk.column_name IS NOT NULL THEN true ELSE false END as is_primary_key,
-			CASE WHEN fk.column_name IS NOT NULL THEN true ELSE false END as is_foreign_key,
-			CASE WHEN uk.column_name IS NOT NULL THEN true ELSE false END as is_unique,
-			col_description(pgc.oid, c.ordinal_position) as column_comment
+			EXISTS (
+				SELECT 1 FROM pg_constraint k
+				WHERE k.conrelid = pgc.oid AND k.contype = 'p' AND c.ordinal_position = ANY(k.conkey)
+			) as is_primary_key,
+			EXISTS (
+				SELECT 1 FROM pg_constraint k
+				WHERE k.conrelid = pgc.oid AND k.contype = 'f' AND c.ordinal_position = ANY(k.conkey)
+			) as is_foreign_key,
+			EXISTS (
+				SELECT 1 FROM pg_constraint k
+				WHERE k.conrelid = pgc.oid AND k.contype = 'u' AND c.ordinal_position = ANY(k.conkey)
+			) as is_unique,
+			col_description(pgc.oid, c.ordinal_position) as column_comment,
+			CASE WHEN en.enum_name IS NOT NULL THEN en.enum_schema || '.' || en.enum_name END as enum_type,
+			en.enum_values,
+			CASE WHEN c.is_identity = 'YES' THEN true ELSE false END as is_identity,
+			c.identity_generation,
+			CASE WHEN c.is_generated = 'ALWAYS' THEN true ELSE false END as is_generated,
+			c.generation_expression,
+			c.collation_name,
+			CASE WHEN c.domain_name IS NOT NULL THEN c.domain_schema || '.' || c.domain_name END as domain_type,
+			(
+				SELECT format_type(t.typbasetype, t.typtypmod)
+				FROM pg_type t
+				JOIN pg_namespace n ON n.oid = t.typnamespace
+				WHERE n.nspname = c.domain_schema AND t.typname = c.domain_name
+			) as base_type,
+			(
+				SELECT format_type(t.typelem, NULL)
+				FROM pg_type t
+				JOIN pg_namespace n ON n.oid = t.typnamespace
+				WHERE n.nspname = c.udt_schema AND t.typname = c.udt_name AND t.typcategory = 'A'
+			) as element_type
 		FROM information_schema.columns c
-		LEFT JOIN pg_class pgc ON pgc.relname = c.table_name
-		LEFT JOIN pg_namespace pgn ON pgn.oid = pgc.relnamespace AND pgn.nspname = c.table_schema
-		LEFT JOIN (
-			SELECT ku.column_name
-			FROM information_schema.table_constraints tc
-			JOIN information_schema.key_column_usage ku ON tc.constraint_name = ku.constraint_name
-			WHERE tc.constraint_type = 'PRIMARY KEY'
-			AND tc.table_schema = $1 AND tc.table_name = $2
-		) pk ON pk.column_name = c.column_name
-		LEFT JOIN (
-			SELECT ku.column_name
-			FROM information_schema.table_constraints tc
-			JOIN information_schema.key_column_usage ku ON tc.constraint_name = ku.constraint_name
-			WHERE tc.constraint_type = 'FOREIGN KEY'
-			AND tc.table_schema = $1 AND tc.table_name = $2
-		) fk ON fk.column_name = c.column_name
+		JOIN pg_namespace pgn ON pgn.nspname = c.table_schema
+		JOIN pg_class pgc ON pgc.relnamespace = pgn.oid AND pgc.relname = c.table_name
 		LEFT JOIN (
-			SELECT ku.column_name
-			FROM information_schema.table_constraints tc
-			JOIN information_schema.key_column_usage ku ON tc.constraint_name = ku.constraint_name
-			WHERE tc.constraint_type = 'UNIQUE'
-			AND tc.table_schema = $1 AND tc.table_name = $2
-		) uk ON uk.column_name = c.column_name
-		WHERE c.table_schema = $1 AND c.table_name = $2
-		ORDER BY c.ordinal_position
+			SELECT
+				n.nspname as enum_schema,
+				t.typname as enum_name,
+				ARRAY_AGG(e.enumlabel ORDER BY e.enumsortorder) as enum_values
+			FROM pg_type t
+			JOIN pg_namespace n ON n.oid = t.typnamespace
+			JOIN pg_enum e ON e.enumtypid = t.oid
+			GROUP BY n.nspname, t.typname
+		) en ON en.enum_schema = c.udt_schema AND en.enum_name = c.udt_name
+		WHERE ($3::bool OR c.table_schema NOT IN ('information_schema', 'pg_catalog'))
+		AND ($1::text = '' OR c.table_schema = $1)
+		AND ($2::text[] IS NULL OR c.table_name = ANY($2))
+		ORDER BY c.table_schema, c.table_name, c.ordinal_position
 	`
 
 	err := is.db.WithValidation(ctx, func() error {
-		return is.db.db.SelectContext(ctx, &columns, query, schema, tableName)
+		return is.db.db.SelectContext(ctx, &rows, query, schema, pq.Array(tables), systemSchemas)
 	})
 	if err != nil {
 		return nil, WrapError(err, ErrCodeQueryFailed, "get_table_columns", "failed to get table columns")
 	}
 
+	columns := make(map[tableKey][]ColumnInfo)
+	for _, row := range rows {
+		key := tableKey{schema: row.TableSchema, name: row.TableName}
+		columns[key] = append(columns[key], row.ColumnInfo)
+	}
+
 	return columns, nil
 }
 
 // GetTableIndexes retrieves indexes for a specific table
 func (is *IntrospectionService) GetTableIndexes(ctx context.Context, schema, tableName string) ([]IndexInfo, error) {
+	indexes, err := is.getIndexes(ctx, schema, tableNames(tableName), false)
+	if err != nil {
+		return nil, err
+	}
+	return indexes[tableKey{schema: schema, name: tableName}], nil
+}
+
+// getIndexes retrieves the indexes of all tables in schema, or only of the tables named in
+// tables if not nil, keyed by table
+func (is *IntrospectionService) getIndexes(ctx context.Context, schema string, tables []string, systemSchemas bool) (map[tableKey][]IndexInfo, error) {
 	type indexRow struct {
-		IndexName  string `db:"index_name"`
-		TableName  string `db:"table_name"`
-		ColumnName string `db:"column_name"`
-		IsUnique   bool   `db:"is_unique"`
-		IsPrimary  bool   `db:"is_primary"`
-		IndexType  string `db:"index_type"`
+		TableSchema string `db:"table_schema"`
+		IndexName   string `db:"index_name"`
+		TableName   string `db:"table_name"`
+		ColumnName  string `db:"column_name"`
+		IsUnique    bool   `db:"is_unique"`
+		IsPrimary   bool   `db:"is_primary"`
+		IndexType   string `db:"index_type"`
 	}
 
 	var rows []indexRow
 	query := `
 		SELECT
+			n.nspname as table_schema,
 			i.relname as index_name,
 			t.relname as table_name,
 			a.attname as column_name,
@@ -301,271 +755,749 @@ func (is *IntrospectionService) GetTableIndexes(ctx context.Context, schema, tab
 		JOIN pg_class i ON i.oid = ix.indexrelid
 		JOIN pg_am am ON i.relam = am.oid
 		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
-		WHERE n.nspname = $1 AND t.relname = $2
-		ORDER BY i.relname, a.attnum
+		WHERE ($3::bool OR n.nspname NOT IN ('information_schema', 'pg_catalog'))
+		AND n.nspname !~ '^pg_toast'
+		AND ($1::text = '' OR n.nspname = $1)
+		AND ($2::text[] IS NULL OR t.relname = ANY($2))
+		ORDER BY n.nspname, t.relname, i.relname, a.attnum
 	`
 
 	err := is.db.WithValidation(ctx, func() error {
-		return is.db.db.SelectContext(ctx, &rows, query, schema, tableName)
+		return is.db.db.SelectContext(ctx, &rows, query, schema, pq.Array(tables), systemSchemas)
 	})
 	if err != nil {
 		return nil, WrapError(err, ErrCodeQueryFailed, "get_table_indexes", "failed to get table indexes")
 	}
 
-	// Group by index name
-	indexMap := make(map[string]*IndexInfo)
+	// Group by table and index name; rows of an index are adjacent
+	indexes := make(map[tableKey][]IndexInfo)
 	for _, row := range rows {
-		if idx, exists := indexMap[row.IndexName]; exists {
-			idx.Columns = append(idx.Columns, row.ColumnName)
-		} else {
-			indexMap[row.IndexName] = &IndexInfo{
-				Name:      row.IndexName,
-				TableName: row.TableName,
-				Columns:   []string{row.ColumnName},
-				IsUnique:  row.IsUnique,
-				IsPrimary: row.IsPrimary,
-				IndexType: row.IndexType,
-			}
+		key := tableKey{schema: row.TableSchema, name: row.TableName}
+		tableIndexes := indexes[key]
+		if n := len(tableIndexes); n > 0 && tableIndexes[n-1].Name == row.IndexName {
+			tableIndexes[n-1].Columns = append(tableIndexes[n-1].Columns, row.ColumnName)
+			continue
 		}
-	}
-
-	// Convert map to slice
-	var indexes []IndexInfo
-	for _, idx := range indexMap {
-		indexes = append(indexes, *idx)
+		indexes[key] = append(tableIndexes, IndexInfo{
+			Name:      row.IndexName,
+			TableName: row.TableName,
+			Columns:   []string{row.ColumnName},
+			IsUnique:  row.IsUnique,
+			IsPrimary: row.IsPrimary,
+			IndexType: row.IndexType,
+		})
 	}
 
 	return indexes, nil
 }
 
-// GetTableConstraints retrieves constraints for a specific table
+// GetTableConstraints retrieves the primary key, foreign key, unique, check and exclusion
+// constraints of a specific table with their definitions
 func (is *IntrospectionService) GetTableConstraints(ctx context.Context, schema, tableName string) ([]ConstraintInfo, error) {
+	// Use a shorter timeout for constraint queries to prevent hanging
+	constraintCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	constraints, err := is.getConstraints(constraintCtx, schema, tableNames(tableName), false)
+	if err != nil {
+		return nil, err
+	}
+	return constraints[tableKey{schema: schema, name: tableName}], nil
+}
+
+// getConstraints retrieves the constraints of all tables in schema, or only of the tables
+// named in tables if not nil, keyed by table
+func (is *IntrospectionService) getConstraints(ctx context.Context, schema string, tables []string, systemSchemas bool) (map[tableKey][]ConstraintInfo, error) {
 	type constraintRow struct {
-		ConstraintName       string  `db:"constraint_name"`
-		ConstraintType       string  `db:"constraint_type"`
-		TableName            string  `db:"table_name"`
-		ColumnName           string  `db:"column_name"`
-		ReferencedTableName  *string `db:"referenced_table_name"`
-		ReferencedColumnName *string `db:"referenced_column_name"`
-		UpdateRule           *string `db:"update_rule"`
-		DeleteRule           *string `db:"delete_rule"`
+		TableSchema         string         `db:"table_schema"`
+		ConstraintName      string         `db:"constraint_name"`
+		ConstraintType      string         `db:"constraint_type"`
+		TableName           string         `db:"table_name"`
+		Columns             pq.StringArray `db:"columns"`
+		ReferencedTableName *string        `db:"referenced_table_name"`
+		ReferencedColumns   pq.StringArray `db:"referenced_columns"`
+		UpdateRule          *string        `db:"update_rule"`
+		DeleteRule          *string        `db:"delete_rule"`
+		Definition          string         `db:"definition"`
 	}
 
+	// information_schema leaves out exclusion constraints and the expressions of checks, so
+	// read pg_constraint directly. NOT NULL constraints are column properties and left out.
 	var rows []constraintRow
 	query := `
 		SELECT
-			tc.constraint_name,
-			tc.constraint_type,
-			tc.table_name,
-			kcu.column_name,
-			ccu.table_name as referenced_table_name,
-			ccu.column_name as referenced_column_name,
-			rc.update_rule,
-			rc.delete_rule
-		FROM information_schema.table_constraints tc
-		LEFT JOIN information_schema.key_column_usage kcu
-			ON tc.constraint_name = kcu.constraint_name
-			AND tc.table_schema = kcu.table_schema
-		LEFT JOIN information_schema.constraint_column_usage ccu
-			ON tc.constraint_name = ccu.constraint_name
-			AND tc.table_schema = ccu.table_schema
-		LEFT JOIN information_schema.referential_constraints rc
-			ON tc.constraint_name = rc.constraint_name
-			AND tc.table_schema = rc.constraint_schema
-		WHERE tc.table_schema = $1 AND tc.table_name = $2
-		ORDER BY tc.constraint_name, kcu.ordinal_position
+			n.nspname as table_schema,
+			con.conname as constraint_name,
+			CASE con.contype
+				WHEN 'p' THEN 'PRIMARY KEY'
+				WHEN 'f' THEN 'FOREIGN KEY'
+				WHEN 'u' THEN 'UNIQUE'
+				WHEN 'c' THEN 'CHECK'
+				WHEN 'x' THEN 'EXCLUDE'
+			END as constraint_type,
+			c.relname as table_name,
+			ARRAY(
+				SELECT a.attname::text
+				FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, position)
+				JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+				ORDER BY k.position
+			) as columns,
+			rc.relname as referenced_table_name,
+			ARRAY(
+				SELECT a.attname::text
+				FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, position)
+				JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+				ORDER BY k.position
+			) as referenced_columns,
+			CASE WHEN con.contype = 'f' THEN ` + foreignKeyAction("con.confupdtype") + ` END as update_rule,
+			CASE WHEN con.contype = 'f' THEN ` + foreignKeyAction("con.confdeltype") + ` END as delete_rule,
+			pg_get_constraintdef(con.oid) as definition
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_class rc ON rc.oid = con.confrelid
+		WHERE con.contype IN ('p', 'f', 'u', 'c', 'x')
+		AND ($3::bool OR n.nspname NOT IN ('information_schema', 'pg_catalog'))
+		AND ($1::text = '' OR n.nspname = $1)
+		AND ($2::text[] IS NULL OR c.relname = ANY($2))
+		ORDER BY n.nspname, c.relname, con.conname
 	`
 
-	// Use a shorter timeout for constraint queries to prevent hanging
-	constraintCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	err := is.db.WithValidation(constraintCtx, func() error {
-		return is.db.db.SelectContext(constraintCtx, &rows, query, schema, tableName)
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &rows, query, schema, pq.Array(tables), systemSchemas)
 	})
 	if err != nil {
 		return nil, WrapError(err, ErrCodeQueryFailed, "get_table_constraints", "failed to get table constraints")
 	}
 
-	// Group by constraint name
-	constraintMap := make(map[string]*ConstraintInfo)
+	constraints := make(map[tableKey][]ConstraintInfo)
 	for _, row := range rows {
-		if constraint, exists := constraintMap[row.ConstraintName]; exists {
-			if row.ColumnName != "" {
-				constraint.Columns = append(constraint.Columns, row.ColumnName)
-			}
-			if row.ReferencedColumnName != nil {
-				constraint.ReferencedColumns = append(constraint.ReferencedColumns, *row.ReferencedColumnName)
-			}
-		} else {
-			columns := []string{}
-			if row.ColumnName != "" {
-				columns = append(columns, row.ColumnName)
-			}
+		key := tableKey{schema: row.TableSchema, name: row.TableName}
+		constraints[key] = append(constraints[key], ConstraintInfo{
+			Name:              row.ConstraintName,
+			Type:              row.ConstraintType,
+			TableName:         row.TableName,
+			Columns:           row.Columns,
+			ReferencedTable:   row.ReferencedTableName,
+			ReferencedColumns: row.ReferencedColumns,
+			UpdateRule:        row.UpdateRule,
+			DeleteRule:        row.DeleteRule,
+			Definition:        row.Definition,
+		})
+	}
 
-			referencedColumns := []string{}
-			if row.ReferencedColumnName != nil {
-				referencedColumns = append(referencedColumns, *row.ReferencedColumnName)
-			}
+	return constraints, nil
+}
 
-			constraintMap[row.ConstraintName] = &ConstraintInfo{
-				Name:              row.ConstraintName,
-				Type:              row.ConstraintType,
-				TableName:         row.TableName,
-				Columns:           columns,
-				ReferencedTable:   row.ReferencedTableName,
-				ReferencedColumns: referencedColumns,
-				UpdateRule:        row.UpdateRule,
-				DeleteRule:        row.DeleteRule,
-			}
+// foreignKeyAction translates a pg_constraint action code to its SQL name, as reported by
+// information_schema.referential_constraints
+func foreignKeyAction(column string) string {
+	return `CASE ` + column + `
+				WHEN 'a' THEN 'NO ACTION'
+				WHEN 'r' THEN 'RESTRICT'
+				WHEN 'c' THEN 'CASCADE'
+				WHEN 'n' THEN 'SET NULL'
+				WHEN 'd' THEN 'SET DEFAULT'
+			END`
+}
+
+// GetTableTriggers retrieves the user-defined triggers of a specific table; internal
+// triggers, such as those enforcing foreign keys, are left out
+func (is *IntrospectionService) GetTableTriggers(ctx context.Context, schema, tableName string) ([]TriggerInfo, error) {
+	triggers, err := is.getTriggers(ctx, schema, tableNames(tableName), false)
+	if err != nil {
+		return nil, err
+	}
+	if tableTriggers := triggers[tableKey{schema: schema, name: tableName}]; tableTriggers != nil {
+		return tableTriggers, nil
+	}
+	return []TriggerInfo{}, nil
+}
+
+// getTriggers retrieves the user-defined triggers of all tables in schema, or only of the
+// tables named in tables if not nil, keyed by table
+func (is *IntrospectionService) getTriggers(ctx context.Context, schema string, tables []string, systemSchemas bool) (map[tableKey][]TriggerInfo, error) {
+	type triggerRow struct {
+		TableSchema string `db:"table_schema"`
+		TriggerName string `db:"trigger_name"`
+		TableName   string `db:"table_name"`
+		TriggerType int    `db:"trigger_type"`
+		Function    string `db:"function_name"`
+		Enabled     string `db:"enabled"`
+		Definition  string `db:"definition"`
+	}
+
+	var rows []triggerRow
+	query := `
+		SELECT
+			n.nspname as table_schema,
+			tg.tgname as trigger_name,
+			c.relname as table_name,
+			tg.tgtype::int as trigger_type,
+			pn.nspname || '.' || p.proname as function_name,
+			tg.tgenabled as enabled,
+			pg_get_triggerdef(tg.oid) as definition
+		FROM pg_trigger tg
+		JOIN pg_class c ON c.oid = tg.tgrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_proc p ON p.oid = tg.tgfoid
+		JOIN pg_namespace pn ON pn.oid = p.pronamespace
+		WHERE NOT tg.tgisinternal
+		AND ($3::bool OR n.nspname NOT IN ('information_schema', 'pg_catalog'))
+		AND ($1::text = '' OR n.nspname = $1)
+		AND ($2::text[] IS NULL OR c.relname = ANY($2))
+		ORDER BY n.nspname, c.relname, tg.tgname
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &rows, query, schema, pq.Array(tables), systemSchemas)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_table_triggers", "failed to get table triggers")
+	}
+
+	triggers := make(map[tableKey][]TriggerInfo)
+	for _, row := range rows {
+		key := tableKey{schema: row.TableSchema, name: row.TableName}
+		timing, events, level := decodeTriggerType(row.TriggerType)
+		triggers[key] = append(triggers[key], TriggerInfo{
+			Name:      row.TriggerName,
+			TableName: row.TableName,
+			Timing:    timing,
+			Events:    events,
+			Level:     level,
+			Function:  row.Function,
+			// tgenabled is O, R or A when the trigger fires and D when it is disabled
+			Enabled:    row.Enabled != "D",
+			Definition: row.Definition,
+		})
+	}
+
+	return triggers, nil
+}
+
+// decodeTriggerType decodes the pg_trigger.tgtype bit mask into timing, events and level
+func decodeTriggerType(tgtype int) (string, []string, string) {
+	const (
+		triggerRow      = 1 << 0
+		triggerBefore   = 1 << 1
+		triggerInsert   = 1 << 2
+		triggerDelete   = 1 << 3
+		triggerUpdate   = 1 << 4
+		triggerTruncate = 1 << 5
+		triggerInstead  = 1 << 6
+	)
+
+	timing := "AFTER"
+	switch {
+	case tgtype&triggerBefore != 0:
+		timing = "BEFORE"
+	case tgtype&triggerInstead != 0:
+		timing = "INSTEAD OF"
+	}
+
+	events := []string{}
+	if tgtype&triggerInsert != 0 {
+		events = append(events, "INSERT")
+	}
+	if tgtype&triggerUpdate != 0 {
+		events = append(events, "UPDATE")
+	}
+	if tgtype&triggerDelete != 0 {
+		events = append(events, "DELETE")
+	}
+	if tgtype&triggerTruncate != 0 {
+		events = append(events, "TRUNCATE")
+	}
+
+	level := "STATEMENT"
+	if tgtype&triggerRow != 0 {
+		level = "ROW"
+	}
+
+	return timing, events, level
+}
+
+// GetTablePartitioning retrieves the partitioning of a specific table, or nil if the table
+// is not partitioned
+func (is *IntrospectionService) GetTablePartitioning(ctx context.Context, schema, tableName string) (*PartitioningInfo, error) {
+	partitioning, err := is.getPartitioning(ctx, schema, tableNames(tableName))
+	if err != nil {
+		return nil, err
+	}
+	return partitioning[tableKey{schema: schema, name: tableName}], nil
+}
+
+// getPartitioning retrieves the partitioning of all partitioned tables in schema, or only of
+// the tables named in tables if not nil, keyed by table
+func (is *IntrospectionService) getPartitioning(ctx context.Context, schema string, tables []string) (map[tableKey]*PartitioningInfo, error) {
+	var rows []struct {
+		TableSchema string `db:"table_schema"`
+		TableName   string `db:"table_name"`
+		Strategy    string `db:"strategy"`
+		KeyDef      string `db:"key_definition"`
+	}
+
+	query := `
+		SELECT
+			n.nspname as table_schema,
+			c.relname as table_name,
+			CASE pt.partstrat WHEN 'r' THEN 'range' WHEN 'l' THEN 'list' WHEN 'h' THEN 'hash' END as strategy,
+			pg_get_partkeydef(c.oid) as key_definition
+		FROM pg_partitioned_table pt
+		JOIN pg_class c ON c.oid = pt.partrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE ($1::text = '' OR n.nspname = $1)
+		AND ($2::text[] IS NULL OR c.relname = ANY($2))
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &rows, query, schema, pq.Array(tables))
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_table_partitioning", "failed to get table partitioning")
+	}
+
+	partitioning := make(map[tableKey]*PartitioningInfo)
+	if len(rows) == 0 {
+		return partitioning, nil
+	}
+
+	partitions, err := is.getPartitions(ctx, schema, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		key := tableKey{schema: row.TableSchema, name: row.TableName}
+		tablePartitions := partitions[key]
+		if tablePartitions == nil {
+			tablePartitions = []PartitionInfo{}
+		}
+		partitioning[key] = &PartitioningInfo{
+			Strategy:   row.Strategy,
+			Key:        partitionKey(row.KeyDef),
+			Partitions: tablePartitions,
 		}
 	}
 
-	// Convert map to slice
-	var constraints []ConstraintInfo
-	for _, constraint := range constraintMap {
-		constraints = append(constraints, *constraint)
+	return partitioning, nil
+}
+
+// GetPartitions retrieves the direct partitions of a partitioned table with their bounds
+func (is *IntrospectionService) GetPartitions(ctx context.Context, schema, tableName string) ([]PartitionInfo, error) {
+	partitions, err := is.getPartitions(ctx, schema, tableNames(tableName))
+	if err != nil {
+		return nil, err
 	}
+	if tablePartitions := partitions[tableKey{schema: schema, name: tableName}]; tablePartitions != nil {
+		return tablePartitions, nil
+	}
+	return []PartitionInfo{}, nil
+}
 
-	return constraints, nil
+// getPartitions retrieves the direct partitions of all partitioned tables in schema, or only
+// of the tables named in tables if not nil, keyed by parent table
+func (is *IntrospectionService) getPartitions(ctx context.Context, schema string, tables []string) (map[tableKey][]PartitionInfo, error) {
+	type partitionRow struct {
+		ParentSchema string `db:"parent_schema"`
+		ParentName   string `db:"parent_name"`
+		PartitionInfo
+	}
+
+	var rows []partitionRow
+	query := `
+		SELECT
+			pn.nspname as parent_schema,
+			p.relname as parent_name,
+			c.relname as partition_name,
+			n.nspname as partition_schema,
+			pg_get_expr(c.relpartbound, c.oid) as partition_bound,
+			c.relkind = 'p' as is_partitioned
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class p ON p.oid = i.inhparent
+		JOIN pg_namespace pn ON pn.oid = p.relnamespace
+		WHERE c.relispartition
+		AND ($1::text = '' OR pn.nspname = $1)
+		AND ($2::text[] IS NULL OR p.relname = ANY($2))
+		ORDER BY pn.nspname, p.relname, n.nspname, c.relname
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &rows, query, schema, pq.Array(tables))
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_partitions", "failed to get partitions")
+	}
+
+	partitions := make(map[tableKey][]PartitionInfo)
+	for _, row := range rows {
+		key := tableKey{schema: row.ParentSchema, name: row.ParentName}
+		partitions[key] = append(partitions[key], row.PartitionInfo)
+	}
+
+	return partitions, nil
 }
 
-// GetTableExists checks if a table exists in the database
-func (is *IntrospectionService) GetTableExists(ctx context.Context, schema, tableName string) (bool, error) {
-	var exists bool
+// partitionKey extracts the key from a partition key definition such as "RANGE (created_at)"
+func partitionKey(definition string) string {
+	start := strings.Index(definition, "(")
+	end := strings.LastIndex(definition, ")")
+	if start < 0 || end <= start {
+		return definition
+	}
+	return definition[start+1 : end]
+}
+
+// GetEnums retrieves enum types and their labels in the specified schema (empty string for all schemas)
+func (is *IntrospectionService) GetEnums(ctx context.Context, schema string) ([]EnumInfo, error) {
+	var enums []EnumInfo
+
 	query := `
-		SELECT EXISTS (
-			SELECT 1 FROM information_schema.tables
-			WHERE table_schema = $1 AND table_name = $2
-		)
+		SELECT
+			t.typname as enum_name,
+			n.nspname as enum_schema,
+			ARRAY_AGG(e.enumlabel ORDER BY e.enumsortorder) as enum_values,
+			obj_description(t.oid, 'pg_type') as enum_comment
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		JOIN pg_enum e ON e.enumtypid = t.oid
+		WHERE n.nspname NOT IN ('information_schema', 'pg_catalog')
+	`
+
+	args := []interface{}{}
+	if schema != "" {
+		query += " AND n.nspname = $1"
+		args = append(args, schema)
+	}
+
+	query += `
+		GROUP BY t.oid, t.typname, n.nspname
+		ORDER BY n.nspname, t.typname
 	`
 
 	err := is.db.WithValidation(ctx, func() error {
-		return is.db.db.GetContext(ctx, &exists, query, schema, tableName)
+		return is.db.db.SelectContext(ctx, &enums, query, args...)
 	})
 	if err != nil {
-		return false, WrapError(err, ErrCodeQueryFailed, "get_table_exists", "failed to check table existence")
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_enums", "failed to get enums")
 	}
 
-	return exists, nil
+	return enums, nil
 }
 
-// GetColumnExists checks if a column exists in a specific table
-func (is *IntrospectionService) GetColumnExists(ctx context.Context, schema, tableName, columnName string) (bool, error) {
-	var exists bool
+// GetViews retrieves views and materialized views in the specified schema (empty string for all schemas)
+func (is *IntrospectionService) GetViews(ctx context.Context, schema string) ([]ViewInfo, error) {
+	var views []ViewInfo
+
 	query := `
-		SELECT EXISTS (
-			SELECT 1 FROM information_schema.columns
-			WHERE table_schema = $1 AND table_name = $2 AND column_name = $3
+		SELECT
+			c.relname as view_name,
+			n.nspname as view_schema,
+			c.relkind = 'm' as is_materialized,
+			pg_get_viewdef(c.oid, true) as definition,
+			obj_description(c.oid, 'pg_class') as view_comment
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('v', 'm')
+		AND n.nspname NOT IN ('information_schema', 'pg_catalog')
+	`
+
+	args := []interface{}{}
+	if schema != "" {
+		query += " AND n.nspname = $1"
+		args = append(args, schema)
+	}
+
+	query += " ORDER BY n.nspname, c.relname"
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &views, query, args...)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_views", "failed to get views")
+	}
+
+	return views, nil
+}
+
+// GetFunctions retrieves functions and procedures in the specified schema (empty string for all schemas).
+// Aggregates, window functions and functions installed by extensions are left out.
+func (is *IntrospectionService) GetFunctions(ctx context.Context, schema string) ([]FunctionInfo, error) {
+	var functions []FunctionInfo
+
+	query := `
+		SELECT
+			p.proname as function_name,
+			n.nspname as function_schema,
+			CASE p.prokind WHEN 'p' THEN 'procedure' ELSE 'function' END as function_kind,
+			pg_get_function_arguments(p.oid) as arguments,
+			CASE WHEN p.prokind = 'p' THEN NULL ELSE pg_get_function_result(p.oid) END as return_type,
+			l.lanname as language,
+			CASE p.provolatile WHEN 'i' THEN 'immutable' WHEN 's' THEN 'stable' ELSE 'volatile' END as volatility,
+			p.prosrc as source,
+			obj_description(p.oid, 'pg_proc') as function_comment
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		JOIN pg_language l ON l.oid = p.prolang
+		WHERE n.nspname NOT IN ('information_schema', 'pg_catalog')
+		AND p.prokind IN ('f', 'p')
+		AND NOT EXISTS (
+			SELECT 1 FROM pg_depend d
+			WHERE d.classid = 'pg_proc'::regclass AND d.objid = p.oid AND d.deptype = 'e'
 		)
 	`
 
+	args := []interface{}{}
+	if schema != "" {
+		query += " AND n.nspname = $1"
+		args = append(args, schema)
+	}
+
+	query += " ORDER BY n.nspname, p.proname, arguments"
+
 	err := is.db.WithValidation(ctx, func() error {
-		return is.db.db.GetContext(ctx, &exists, query, schema, tableName, columnName)
+		return is.db.db.SelectContext(ctx, &functions, query, args...)
 	})
 	if err != nil {
-		return false, WrapError(err, ErrCodeQueryFailed, "get_column_exists", "failed to check column existence")
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_functions", "failed to get functions")
 	}
 
-	return exists, nil
+	return functions, nil
 }
 
-// GetForeignKeyRelationships retrieves all foreign key relationships in the database
-func (is *IntrospectionService) GetForeignKeyRelationships(ctx context.Context, schema string) ([]ConstraintInfo, error) {
-	var constraints []ConstraintInfo
+// GetExtensions retrieves the installed extensions and whether a newer version is available
+func (is *IntrospectionService) GetExtensions(ctx context.Context) ([]ExtensionInfo, error) {
+	var extensions []ExtensionInfo
+
+	query := `
+		SELECT
+			e.extname as extension_name,
+			n.nspname as extension_schema,
+			e.extversion as installed_version,
+			a.default_version,
+			a.comment as extension_comment
+		FROM pg_extension e
+		JOIN pg_namespace n ON n.oid = e.extnamespace
+		LEFT JOIN pg_available_extensions a ON a.name = e.extname
+		ORDER BY e.extname
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &extensions, query)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_extensions", "failed to get extensions")
+	}
+
+	for i := range extensions {
+		extensions[i].UpdateAvailable = extensions[i].DefaultVersion != nil &&
+			*extensions[i].DefaultVersion != extensions[i].Version
+	}
+
+	return extensions, nil
+}
+
+// GetRoles retrieves the roles of the cluster with their attributes and memberships;
+// predefined pg_* roles are left out
+func (is *IntrospectionService) GetRoles(ctx context.Context) ([]RoleInfo, error) {
+	var roles []RoleInfo
+
+	query := `
+		SELECT
+			r.rolname as role_name,
+			r.rolcanlogin as can_login,
+			r.rolsuper as is_superuser,
+			r.rolcreatedb as can_create_db,
+			r.rolcreaterole as can_create_role,
+			r.rolinherit as inherits,
+			r.rolreplication as is_replication,
+			r.rolbypassrls as bypasses_rls,
+			r.rolconnlimit as connection_limit,
+			r.rolvaliduntil as valid_until,
+			ARRAY(
+				SELECT b.rolname
+				FROM pg_auth_members m
+				JOIN pg_roles b ON b.oid = m.roleid
+				WHERE m.member = r.oid
+				ORDER BY b.rolname
+			) as member_of
+		FROM pg_roles r
+		WHERE r.rolname !~ '^pg_'
+		ORDER BY r.rolname
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &roles, query)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_roles", "failed to get roles")
+	}
+
+	return roles, nil
+}
+
+// GetTableGrants retrieves the privileges granted on a specific table and on its columns.
+// A table without explicit grants reports its owner's default privileges.
+func (is *IntrospectionService) GetTableGrants(ctx context.Context, schema, tableName string) ([]GrantInfo, error) {
+	var grants []GrantInfo
+
+	query := `
+		SELECT
+			CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(acl.grantee) END as grantee,
+			pg_get_userbyid(acl.grantor) as grantor,
+			acl.privilege_type,
+			NULL::text as column_name,
+			acl.is_grantable
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL aclexplode(COALESCE(c.relacl, acldefault('r', c.relowner))) acl
+		WHERE n.nspname = $1 AND c.relname = $2
+		UNION ALL
+		SELECT
+			CASE WHEN acl.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(acl.grantee) END as grantee,
+			pg_get_userbyid(acl.grantor) as grantor,
+			acl.privilege_type,
+			a.attname::text as column_name,
+			acl.is_grantable
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		CROSS JOIN LATERAL aclexplode(a.attacl) acl
+		WHERE n.nspname = $1 AND c.relname = $2
+		ORDER BY grantee, column_name NULLS FIRST, privilege_type
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &grants, query, schema, tableName)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_table_grants", "failed to get table grants")
+	}
+
+	return grants, nil
+}
+
+// GetTableSizes retrieves the sizes of the tables and materialized views in the specified
+// schema (empty string for all schemas), largest first
+func (is *IntrospectionService) GetTableSizes(ctx context.Context, schema string) ([]TableSize, error) {
+	sizes := []TableSize{}
 
 	query := `
 		SELECT
-			tc.constraint_name,
-			tc.constraint_type,
-			tc.table_name,
-			ARRAY_AGG(kcu.column_name ORDER BY kcu.ordinal_position) as columns,
-			ccu.table_name as referenced_table_name,
-			ARRAY_AGG(ccu.column_name ORDER BY kcu.ordinal_position) as referenced_columns,
-			rc.update_rule,
-			rc.delete_rule
-		FROM information_schema.table_constraints tc
-		JOIN information_schema.key_column_usage kcu
-			ON tc.constraint_name = kcu.constraint_name
-			AND tc.table_schema = kcu.table_schema
-		JOIN information_schema.constraint_column_usage ccu
-			ON tc.constraint_name = ccu.constraint_name
-			AND tc.table_schema = ccu.table_schema
-		JOIN information_schema.referential_constraints rc
-			ON tc.constraint_name = rc.constraint_name
-			AND tc.table_schema = rc.constraint_schema
-		WHERE tc.constraint_type = 'FOREIGN KEY'
+			table_schema,
+			table_name,
+			total_bytes,
+			total_bytes - index_bytes - toast_bytes as table_bytes,
+			index_bytes,
+			toast_bytes,
+			estimated_rows
+		FROM (
+			SELECT
+				n.nspname as table_schema,
+				c.relname as table_name,
+				pg_total_relation_size(c.oid) as total_bytes,
+				pg_indexes_size(c.oid) as index_bytes,
+				CASE WHEN c.reltoastrelid = 0 THEN 0 ELSE pg_total_relation_size(c.reltoastrelid) END as toast_bytes,
+				GREATEST(c.reltuples, 0)::bigint as estimated_rows
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relkind IN ('r', 'm')
+			AND n.nspname NOT IN ('information_schema', 'pg_catalog')
+			AND n.nspname !~ '^pg_toast'
 	`
 
 	args := []interface{}{}
 	if schema != "" {
-		query += " AND tc.table_schema = $1"
+		query += " AND n.nspname = $1"
 		args = append(args, schema)
 	}
 
 	query += `
-		GROUP BY tc.constraint_name, tc.constraint_type, tc.table_name,
-				 ccu.table_name, rc.update_rule, rc.delete_rule
-		ORDER BY tc.table_name, tc.constraint_name
+		) sizes
+		ORDER BY total_bytes DESC, table_schema, table_name
 	`
 
-	type fkRow struct {
-		ConstraintName      string  `db:"constraint_name"`
-		ConstraintType      string  `db:"constraint_type"`
-		TableName           string  `db:"table_name"`
-		Columns             string  `db:"columns"` // PostgreSQL array as string
-		ReferencedTableName string  `db:"referenced_table_name"`
-		ReferencedColumns   string  `db:"referenced_columns"` // PostgreSQL array as string
-		UpdateRule          *string `db:"update_rule"`
-		DeleteRule          *string `db:"delete_rule"`
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &sizes, query, args...)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_table_sizes", "failed to get table sizes")
 	}
 
-	var rows []fkRow
+	return sizes, nil
+}
+
+// GetTableExists checks if a table exists in the database
+func (is *IntrospectionService) GetTableExists(ctx context.Context, schema, tableName string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = $1 AND table_name = $2
+		)
+	`
+
 	err := is.db.WithValidation(ctx, func() error {
-		return is.db.db.SelectContext(ctx, &rows, query, args...)
+		return is.db.db.GetContext(ctx, &exists, query, schema, tableName)
 	})
 	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "get_foreign_key_relationships", "failed to get foreign key relationships")
+		return false, WrapError(err, ErrCodeQueryFailed, "get_table_exists", "failed to check table existence")
 	}
 
-	// Convert rows to constraints
-	for _, row := range rows {
-		// Parse PostgreSQL array format: {col1,col2,col3}
-		columns := parsePostgreSQLArray(row.Columns)
-		referencedColumns := parsePostgreSQLArray(row.ReferencedColumns)
+	return exists, nil
+}
 
-		constraint := ConstraintInfo{
-			Name:              row.ConstraintName,
-			Type:              row.ConstraintType,
-			TableName:         row.TableName,
-			Columns:           columns,
-			ReferencedTable:   &row.ReferencedTableName,
-			ReferencedColumns: referencedColumns,
-			UpdateRule:        row.UpdateRule,
-			DeleteRule:        row.DeleteRule,
-		}
-		constraints = append(constraints, constraint)
+// GetColumnExists checks if a column exists in a specific table
+func (is *IntrospectionService) GetColumnExists(ctx context.Context, schema, tableName, columnName string) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = $1 AND table_name = $2 AND column_name = $3
+		)
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.GetContext(ctx, &exists, query, schema, tableName, columnName)
+	})
+	if err != nil {
+		return false, WrapError(err, ErrCodeQueryFailed, "get_column_exists", "failed to check column existence")
 	}
 
-	return constraints, nil
+	return exists, nil
 }
 
-// parsePostgreSQLArray parses PostgreSQL array format {item1,item2,item3} into Go slice
-func parsePostgreSQLArray(arrayStr string) []string {
-	if arrayStr == "" || arrayStr == "{}" {
-		return []string{}
+// GetForeignKeyRelationships retrieves all foreign key relationships in the database, or in
+// schema if set, ordered by table and constraint name
+func (is *IntrospectionService) GetForeignKeyRelationships(ctx context.Context, schema string) ([]ConstraintInfo, error) {
+	constraints, err := is.getConstraints(ctx, schema, nil, false)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_foreign_key_relationships", "failed to get foreign key relationships")
 	}
 
-	// Remove braces
-	arrayStr = strings.Trim(arrayStr, "{}")
-
-	// Split by comma
-	items := strings.Split(arrayStr, ",")
+	keys := make([]tableKey, 0, len(constraints))
+	for key := range constraints {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].schema < keys[j].schema
+	})
 
-	// Trim whitespace from each item
-	for i, item := range items {
-		items[i] = strings.TrimSpace(item)
+	var relationships []ConstraintInfo
+	for _, key := range keys {
+		// Constraints of a table are ordered by name
+		for _, constraint := range constraints[key] {
+			if constraint.Type == "FOREIGN KEY" {
+				relationships = append(relationships, constraint)
+			}
+		}
 	}
 
-	return items
+	return relationships, nil
 }
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// WithCache returns a copy of the service that caches the results of GetTables, and so of
+// GetDatabaseInfo and ExportSchema, per schema for ttl. Keep the returned service to reuse
+// its cache, e.g. across requests of a status endpoint. Cached tables are shared between
+// callers and must not be modified; call Invalidate after changing the schema. A ttl of 0
+// or less returns a copy without a cache.
+func (is *IntrospectionService) WithCache(ttl time.Duration) *IntrospectionService {
+	service := *is
+	service.cache = nil
+	if ttl > 0 {
+		service.cache = newIntrospectionCache(ttl)
+	}
+	return &service
+}
+
+// Invalidate drops the cached tables of schema, along with those of all schemas that
+// include it. An empty schema drops everything. It does nothing without a cache.
+func (is *IntrospectionService) Invalidate(schema string) {
+	if is.cache != nil {
+		is.cache.invalidate(schema)
+	}
+}
+
+// introspectionCache holds GetTables results by schema, "" for all schemas
+type introspectionCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedTables
+}
+
+type cachedTables struct {
+	tables  []TableInfo
+	expires time.Time
+}
+
+func newIntrospectionCache(ttl time.Duration) *introspectionCache {
+	return &introspectionCache{ttl: ttl, now: time.Now, entries: make(map[string]cachedTables)}
+}
+
+// tables returns a copy of the cached tables of schema unless they expired
+func (c *introspectionCache) tables(schema string) ([]TableInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[schema]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, schema)
+		return nil, false
+	}
+	return slices.Clone(entry.tables), true
+}
+
+func (c *introspectionCache) storeTables(schema string, tables []TableInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[schema] = cachedTables{tables: tables, expires: c.now().Add(c.ttl)}
+}
+
+func (c *introspectionCache) invalidate(schema string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if schema == "" {
+		clear(c.entries)
+		return
+	}
+	delete(c.entries, schema)
+	delete(c.entries, "")
+}
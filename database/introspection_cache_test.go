@@ -0,0 +1,68 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospectionCache(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := newIntrospectionCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	_, ok := cache.tables("public")
+	assert.False(t, ok)
+
+	cache.storeTables("public", []TableInfo{{Name: "users", Schema: "public"}})
+	cache.storeTables("", []TableInfo{{Name: "users", Schema: "public"}, {Name: "jobs", Schema: "queue"}})
+
+	tables, ok := cache.tables("public")
+	require.True(t, ok)
+	assert.Equal(t, "users", tables[0].Name)
+
+	// Callers get their own slice
+	tables[0] = TableInfo{Name: "changed"}
+	tables, _ = cache.tables("public")
+	assert.Equal(t, "users", tables[0].Name)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.tables("public")
+	assert.False(t, ok, "expected the entry to expire after the TTL")
+}
+
+func TestIntrospectionCacheInvalidate(t *testing.T) {
+	cache := newIntrospectionCache(time.Hour)
+	for _, schema := range []string{"", "public", "queue"} {
+		cache.storeTables(schema, []TableInfo{})
+	}
+
+	// Invalidating a schema also drops the entry covering all schemas
+	cache.invalidate("public")
+	_, ok := cache.tables("public")
+	assert.False(t, ok)
+	_, ok = cache.tables("")
+	assert.False(t, ok)
+	_, ok = cache.tables("queue")
+	assert.True(t, ok)
+
+	cache.invalidate("")
+	_, ok = cache.tables("queue")
+	assert.False(t, ok)
+}
+
+func TestIntrospectionServiceWithCache(t *testing.T) {
+	is := NewIntrospectionService(nil)
+	assert.Nil(t, is.cache)
+	is.Invalidate("public") // no-op without a cache
+
+	cached := is.WithCache(time.Minute)
+	require.NotNil(t, cached.cache)
+	assert.Nil(t, is.cache, "expected WithCache to leave the original service unchanged")
+
+	// Other options keep the cache
+	assert.Same(t, cached.cache, cached.WithParallelism(4).cache)
+	assert.Nil(t, cached.WithCache(0).cache)
+}
@@ -2,7 +2,10 @@ package database
 
 import (
 	"context"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestIntrospectionService(t *testing.T) {
@@ -30,6 +33,16 @@ func TestIntrospectionService(t *testing.T) {
 		t.Logf("Database version: %s", version)
 	})
 
+	t.Run("get server info", func(t *testing.T) {
+		info, err := introspection.GetServerInfo(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get server info: %v", err)
+		}
+		if info.VersionNum < 90000 || info.Database != db.config.DBName || info.MaxConnections <= 0 {
+			t.Errorf("Unexpected server info %+v", info)
+		}
+	})
+
 	t.Run("get database size", func(t *testing.T) {
 		size, err := introspection.GetDatabaseSize(ctx)
 		if err != nil {
@@ -107,6 +120,128 @@ func TestIntrospectionService(t *testing.T) {
 		t.Logf("Found %d tables", len(tables))
 	})
 
+	t.Run("get tables matches per-table details", func(t *testing.T) {
+		tables, err := introspection.GetTables(ctx, "public")
+		if err != nil {
+			t.Fatalf("Failed to get tables: %v", err)
+		}
+
+		for _, table := range tables {
+			switch table.Name {
+			case "test_users":
+				columns, err := introspection.GetTableColumns(ctx, "public", "test_users")
+				if err != nil {
+					t.Errorf("Failed to get table columns: %v", err)
+				}
+				if len(table.Columns) != len(columns) {
+					t.Errorf("Expected %d columns for test_users, got %d", len(columns), len(table.Columns))
+				}
+				indexes, err := introspection.GetTableIndexes(ctx, "public", "test_users")
+				if err != nil {
+					t.Errorf("Failed to get table indexes: %v", err)
+				}
+				if len(table.Indexes) != len(indexes) {
+					t.Errorf("Expected %d indexes for test_users, got %d", len(indexes), len(table.Indexes))
+				}
+				if len(table.Triggers) != 1 {
+					t.Errorf("Expected 1 trigger for test_users, got %d", len(table.Triggers))
+				}
+				if table.Partitioning != nil {
+					t.Errorf("Expected test_users not to be partitioned")
+				}
+				if table.Unlogged || len(table.StorageParameters) != 0 || table.Fillfactor() != 100 {
+					t.Errorf("Expected test_users to be logged with default storage parameters, got %v", table.StorageParameters)
+				}
+			case "test_posts":
+				var foundFK bool
+				for _, constraint := range table.Constraints {
+					if constraint.Type == "FOREIGN KEY" {
+						foundFK = true
+					}
+				}
+				if !foundFK {
+					t.Errorf("Expected test_posts to have a foreign key constraint")
+				}
+			case "test_events":
+				if table.Partitioning == nil || len(table.Partitioning.Partitions) != 2 {
+					t.Errorf("Expected test_events to have 2 partitions, got %+v", table.Partitioning)
+				}
+			case "test_sessions":
+				if !table.Unlogged {
+					t.Errorf("Expected test_sessions to be unlogged")
+				}
+				if table.AccessMethod == nil || *table.AccessMethod != "heap" {
+					t.Errorf("Expected test_sessions to use the heap access method, got %v", table.AccessMethod)
+				}
+				if table.Fillfactor() != 70 {
+					t.Errorf("Expected fillfactor 70 for test_sessions, got %d", table.Fillfactor())
+				}
+				overrides := table.AutovacuumOverrides()
+				if overrides["autovacuum_vacuum_scale_factor"] != "0.05" || overrides["toast.autovacuum_enabled"] != "false" {
+					t.Errorf("Unexpected autovacuum overrides for test_sessions: %v", overrides)
+				}
+			}
+		}
+	})
+
+	t.Run("get tables with options", func(t *testing.T) {
+		tables, err := introspection.GetTablesWithOptions(ctx, IntrospectOptions{
+			Schema:        "public",
+			IncludeTables: []string{"test_*"},
+			ExcludeTables: []string{"test_posts", "test_events*"},
+			SkipIndexes:   true,
+			SkipTriggers:  true,
+		})
+		if err != nil {
+			t.Fatalf("Failed to get tables: %v", err)
+		}
+
+		var foundUsers bool
+		for _, table := range tables {
+			if table.Name == "test_posts" || table.Name == "test_events" {
+				t.Errorf("Expected %s to be excluded", table.Name)
+			}
+			if table.Name == "test_users" {
+				foundUsers = true
+				if len(table.Columns) == 0 || len(table.Constraints) == 0 {
+					t.Errorf("Expected test_users to have columns and constraints")
+				}
+				if len(table.Indexes) != 0 || len(table.Triggers) != 0 {
+					t.Errorf("Expected indexes and triggers to be skipped, got %d and %d", len(table.Indexes), len(table.Triggers))
+				}
+			}
+		}
+		if !foundUsers {
+			t.Errorf("Expected to find test_users")
+		}
+
+		system, err := introspection.GetTablesWithOptions(ctx, IntrospectOptions{
+			Schema:               "pg_catalog",
+			IncludeTables:        []string{"pg_class"},
+			IncludeSystemSchemas: true,
+		})
+		if err != nil {
+			t.Fatalf("Failed to get system tables: %v", err)
+		}
+		if len(system) != 1 || len(system[0].Columns) == 0 {
+			t.Errorf("Expected pg_catalog.pg_class with its columns, got %+v", system)
+		}
+	})
+
+	t.Run("get complete database info in parallel", func(t *testing.T) {
+		info, err := introspection.WithParallelism(4).GetDatabaseInfo(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get database info: %v", err)
+		}
+		serial, err := introspection.GetDatabaseInfo(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get database info: %v", err)
+		}
+		if len(info.Tables) != len(serial.Tables) || len(info.Schemas) != len(serial.Schemas) {
+			t.Errorf("Expected parallel introspection to match serial introspection")
+		}
+	})
+
 	t.Run("get table columns", func(t *testing.T) {
 		columns, err := introspection.GetTableColumns(ctx, "public", "test_users")
 		if err != nil {
@@ -143,6 +278,38 @@ func TestIntrospectionService(t *testing.T) {
 		t.Logf("Found %d columns in test_users", len(columns))
 	})
 
+	t.Run("get identity, generated, collated, domain and array columns", func(t *testing.T) {
+		columns, err := introspection.GetTableColumns(ctx, "public", "test_reservations")
+		if err != nil {
+			t.Fatalf("Failed to get table columns: %v", err)
+		}
+
+		byName := make(map[string]ColumnInfo)
+		for _, column := range columns {
+			byName[column.Name] = column
+		}
+		if id := byName["id"]; !id.IsIdentity || id.IdentityGeneration == nil || *id.IdentityGeneration != "ALWAYS" {
+			t.Errorf("Expected id to be an identity column generated always: %+v", id)
+		}
+		if nights := byName["nights"]; !nights.IsGenerated || nights.GenerationExpression == nil || nights.IsIdentity {
+			t.Errorf("Expected nights to be a generated column with an expression: %+v", nights)
+		}
+		if guest := byName["guest"]; guest.Collation == nil || *guest.Collation != "C" {
+			t.Errorf("Expected guest to have the C collation: %+v", guest)
+		}
+		contact := byName["contact"]
+		if contact.DomainType == nil || *contact.DomainType != "public.test_email" ||
+			contact.BaseType == nil || *contact.BaseType != "character varying(255)" {
+			t.Errorf("Expected contact to be a test_email domain over varchar(255): %+v", contact)
+		}
+		if seats := byName["seats"]; seats.ElementType == nil || *seats.ElementType != "integer" {
+			t.Errorf("Expected seats to be an integer array: %+v", seats)
+		}
+		if during := byName["during"]; during.IsIdentity || during.IsGenerated || during.DomainType != nil || during.ElementType != nil {
+			t.Errorf("Expected during to be a plain column: %+v", during)
+		}
+	})
+
 	t.Run("get table indexes", func(t *testing.T) {
 		indexes, err := introspection.GetTableIndexes(ctx, "public", "test_users")
 		if err != nil {
@@ -197,6 +364,725 @@ func TestIntrospectionService(t *testing.T) {
 		t.Logf("Found %d constraints in test_posts", len(constraints))
 	})
 
+	t.Run("get check and exclusion constraints", func(t *testing.T) {
+		constraints, err := introspection.GetTableConstraints(ctx, "public", "test_users")
+		if err != nil {
+			t.Fatalf("Failed to get table constraints: %v", err)
+		}
+
+		var check *ConstraintInfo
+		for i := range constraints {
+			if constraints[i].Type == "CHECK" {
+				check = &constraints[i]
+			}
+			if constraints[i].Definition == "" {
+				t.Errorf("Expected a definition for %s", constraints[i].Name)
+			}
+		}
+		if check == nil {
+			t.Fatalf("Expected test_users to have a check constraint, got %+v", constraints)
+		}
+		if check.Definition != "CHECK ((age >= 0))" || len(check.Columns) != 1 || check.Columns[0] != "age" {
+			t.Errorf("Expected a check on age with its expression, got %+v", check)
+		}
+
+		constraints, err = introspection.GetTableConstraints(ctx, "public", "test_reservations")
+		if err != nil {
+			t.Fatalf("Failed to get table constraints: %v", err)
+		}
+		var found bool
+		for _, constraint := range constraints {
+			if constraint.Name == "test_reservations_no_overlap" {
+				found = true
+				if constraint.Type != "EXCLUDE" || constraint.Definition != "EXCLUDE USING gist (during WITH &&)" {
+					t.Errorf("Expected the exclusion constraint with its definition, got %+v", constraint)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected to find the exclusion constraint, got %+v", constraints)
+		}
+	})
+
+	t.Run("get table triggers", func(t *testing.T) {
+		triggers, err := introspection.GetTableTriggers(ctx, "public", "test_users")
+		if err != nil {
+			t.Errorf("Failed to get table triggers: %v", err)
+		}
+		if len(triggers) != 1 {
+			t.Fatalf("Expected 1 trigger on test_users, got %d", len(triggers))
+		}
+
+		trigger := triggers[0]
+		if trigger.Name != "test_users_touch" {
+			t.Errorf("Expected trigger test_users_touch, got '%s'", trigger.Name)
+		}
+		if trigger.Timing != "BEFORE" || trigger.Level != "ROW" {
+			t.Errorf("Expected BEFORE ROW trigger, got %s %s", trigger.Timing, trigger.Level)
+		}
+		if len(trigger.Events) != 1 || trigger.Events[0] != "UPDATE" {
+			t.Errorf("Expected UPDATE event, got %v", trigger.Events)
+		}
+		if trigger.Function != "public.test_touch_updated_at" {
+			t.Errorf("Expected function public.test_touch_updated_at, got '%s'", trigger.Function)
+		}
+		if !trigger.Enabled {
+			t.Errorf("Expected trigger to be enabled")
+		}
+
+		// Foreign key triggers are internal and not listed
+		triggers, err = introspection.GetTableTriggers(ctx, "public", "test_posts")
+		if err != nil {
+			t.Errorf("Failed to get table triggers: %v", err)
+		}
+		if len(triggers) != 0 {
+			t.Errorf("Expected no triggers on test_posts, got %v", triggers)
+		}
+	})
+
+	t.Run("get table partitioning", func(t *testing.T) {
+		partitioning, err := introspection.GetTablePartitioning(ctx, "public", "test_events")
+		if err != nil {
+			t.Fatalf("Failed to get table partitioning: %v", err)
+		}
+		if partitioning == nil {
+			t.Fatalf("Expected test_events to be partitioned")
+		}
+		if partitioning.Strategy != "range" || partitioning.Key != "created_at" {
+			t.Errorf("Expected range partitioning on created_at, got %s on %s", partitioning.Strategy, partitioning.Key)
+		}
+		if len(partitioning.Partitions) != 2 {
+			t.Fatalf("Expected 2 partitions, got %d", len(partitioning.Partitions))
+		}
+		if partitioning.Partitions[0].Name != "test_events_2024" ||
+			!strings.HasPrefix(partitioning.Partitions[0].Bound, "FOR VALUES FROM") {
+			t.Errorf("Unexpected partition: %+v", partitioning.Partitions[0])
+		}
+		if partitioning.Partitions[1].Name != "test_events_default" || partitioning.Partitions[1].Bound != "DEFAULT" {
+			t.Errorf("Unexpected default partition: %+v", partitioning.Partitions[1])
+		}
+
+		partitioning, err = introspection.GetTablePartitioning(ctx, "public", "test_users")
+		if err != nil {
+			t.Errorf("Failed to get table partitioning: %v", err)
+		}
+		if partitioning != nil {
+			t.Errorf("Expected test_users not to be partitioned")
+		}
+	})
+
+	t.Run("get composite types", func(t *testing.T) {
+		types, err := introspection.GetCompositeTypes(ctx, "public")
+		if err != nil {
+			t.Fatalf("Failed to get composite types: %v", err)
+		}
+
+		var address *CompositeTypeInfo
+		for i := range types {
+			if types[i].Name == "test_address" {
+				address = &types[i]
+			}
+			if types[i].Name == "test_users" {
+				t.Errorf("Expected table row types to be left out")
+			}
+		}
+		if address == nil {
+			t.Fatalf("Expected to find test_address, got %+v", types)
+		}
+		if len(address.Attributes) != 3 || address.Attributes[2].Name != "zip" || address.Attributes[2].DataType != "character varying(10)" {
+			t.Errorf("Expected street, city and zip attributes, got %+v", address.Attributes)
+		}
+		if city := address.Attributes[1]; city.Collation == nil || *city.Collation != "C" {
+			t.Errorf("Expected city to have the C collation, got %+v", city)
+		}
+	})
+
+	t.Run("get domains", func(t *testing.T) {
+		domains, err := introspection.GetDomains(ctx, "public")
+		if err != nil {
+			t.Fatalf("Failed to get domains: %v", err)
+		}
+
+		var found bool
+		for _, domain := range domains {
+			if domain.Name == "test_email" {
+				found = true
+				if domain.BaseType != "character varying(255)" || !domain.IsNullable || domain.DefaultValue != nil {
+					t.Errorf("Expected a nullable varchar(255) domain without default, got %+v", domain)
+				}
+				if len(domain.Constraints) != 1 || domain.Constraints[0].Definition != "CHECK (((VALUE)::text ~~ '%@%'::text))" {
+					t.Errorf("Expected the email check constraint, got %+v", domain.Constraints)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected to find test_email domain, got %+v", domains)
+		}
+	})
+
+	t.Run("get foreign servers and tables", func(t *testing.T) {
+		servers, err := introspection.GetForeignServers(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get foreign servers: %v", err)
+		}
+
+		var found bool
+		for _, server := range servers {
+			if server.Name == "test_server" {
+				found = true
+				if server.Wrapper != "test_fdw" || server.Options["host"] != "remote.example.com" {
+					t.Errorf("Expected test_server on test_fdw with its host, got %+v", server)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected to find test_server, got %+v", servers)
+		}
+
+		tables, err := introspection.GetTables(ctx, "public")
+		if err != nil {
+			t.Fatalf("Failed to get tables: %v", err)
+		}
+		for _, table := range tables {
+			switch table.Name {
+			case "test_remote_orders":
+				if table.Type != "FOREIGN" || table.Foreign == nil {
+					t.Fatalf("Expected test_remote_orders to be a foreign table, got %+v", table)
+				}
+				if table.Foreign.Server != "test_server" || table.Foreign.Options["table_name"] != "orders" {
+					t.Errorf("Expected test_remote_orders on test_server with its table name, got %+v", table.Foreign)
+				}
+				if len(table.Columns) != 2 {
+					t.Errorf("Expected 2 columns for test_remote_orders, got %d", len(table.Columns))
+				}
+			case "test_users":
+				if table.Foreign != nil {
+					t.Errorf("Expected test_users not to be a foreign table")
+				}
+			}
+		}
+	})
+
+	t.Run("get enums", func(t *testing.T) {
+		enums, err := introspection.GetEnums(ctx, "public")
+		if err != nil {
+			t.Errorf("Failed to get enums: %v", err)
+		}
+
+		var found bool
+		for _, enum := range enums {
+			if enum.Name == "test_user_status" {
+				found = true
+				expected := []string{"active", "suspended", "deleted"}
+				if len(enum.Values) != len(expected) {
+					t.Errorf("Expected enum values %v, got %v", expected, enum.Values)
+					break
+				}
+				for i, value := range expected {
+					if enum.Values[i] != value {
+						t.Errorf("Expected enum values %v, got %v", expected, enum.Values)
+						break
+					}
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected to find test_user_status enum")
+		}
+
+		columns, err := introspection.GetTableColumns(ctx, "public", "test_users")
+		if err != nil {
+			t.Errorf("Failed to get table columns: %v", err)
+		}
+		for _, col := range columns {
+			if col.Name == "status" {
+				if col.EnumType == nil || *col.EnumType != "public.test_user_status" {
+					t.Errorf("Expected status column to reference public.test_user_status, got %v", col.EnumType)
+				}
+				if len(col.EnumValues) != 3 {
+					t.Errorf("Expected status column to have 3 enum values, got %v", col.EnumValues)
+				}
+			}
+			if col.Name == "email" && col.EnumType != nil {
+				t.Errorf("Expected email column not to be an enum")
+			}
+		}
+	})
+
+	t.Run("get functions", func(t *testing.T) {
+		functions, err := introspection.GetFunctions(ctx, "public")
+		if err != nil {
+			t.Errorf("Failed to get functions: %v", err)
+		}
+
+		var foundFunction, foundProcedure bool
+		for _, fn := range functions {
+			switch fn.Name {
+			case "test_user_post_count":
+				foundFunction = true
+				if fn.Kind != "function" || fn.Language != "sql" || fn.Volatility != "stable" {
+					t.Errorf("Unexpected function details: %+v", fn)
+				}
+				if fn.Arguments != "user_id integer" {
+					t.Errorf("Expected arguments 'user_id integer', got '%s'", fn.Arguments)
+				}
+				if fn.ReturnType == nil || *fn.ReturnType != "bigint" {
+					t.Errorf("Expected bigint return type, got %v", fn.ReturnType)
+				}
+				if !strings.Contains(fn.Source, "test_posts") {
+					t.Errorf("Expected function source to reference test_posts, got '%s'", fn.Source)
+				}
+			case "test_publish_posts":
+				foundProcedure = true
+				if fn.Kind != "procedure" || fn.Language != "plpgsql" || fn.ReturnType != nil {
+					t.Errorf("Unexpected procedure details: %+v", fn)
+				}
+			}
+		}
+		if !foundFunction {
+			t.Errorf("Expected to find test_user_post_count function")
+		}
+		if !foundProcedure {
+			t.Errorf("Expected to find test_publish_posts procedure")
+		}
+	})
+
+	t.Run("get roles", func(t *testing.T) {
+		roles, err := introspection.GetRoles(ctx)
+		if err != nil {
+			t.Errorf("Failed to get roles: %v", err)
+		}
+
+		var found bool
+		for _, role := range roles {
+			if role.Name == db.config.User {
+				found = true
+				if !role.Login {
+					t.Errorf("Expected connecting role %s to have login", role.Name)
+				}
+			}
+			if strings.HasPrefix(role.Name, "pg_") {
+				t.Errorf("Expected predefined role %s to be left out", role.Name)
+			}
+		}
+		if !found {
+			t.Errorf("Expected to find connecting role %s", db.config.User)
+		}
+	})
+
+	t.Run("get table grants", func(t *testing.T) {
+		grants, err := introspection.GetTableGrants(ctx, "public", "test_users")
+		if err != nil {
+			t.Errorf("Failed to get table grants: %v", err)
+		}
+
+		var foundOwner, foundColumn bool
+		for _, grant := range grants {
+			if grant.Grantee == db.config.User && grant.Column == nil && grant.Privilege == "SELECT" {
+				foundOwner = true
+			}
+			if grant.Grantee == "PUBLIC" && grant.Column != nil && *grant.Column == "name" && grant.Privilege == "SELECT" {
+				foundColumn = true
+			}
+		}
+		if !foundOwner {
+			t.Errorf("Expected owner %s to have SELECT on test_users, got %+v", db.config.User, grants)
+		}
+		if !foundColumn {
+			t.Errorf("Expected PUBLIC to have SELECT on test_users.name, got %+v", grants)
+		}
+	})
+
+	t.Run("get table sizes", func(t *testing.T) {
+		sizes, err := introspection.GetTableSizes(ctx, "public")
+		if err != nil {
+			t.Errorf("Failed to get table sizes: %v", err)
+		}
+
+		var found bool
+		for i, size := range sizes {
+			if i > 0 && size.TotalBytes > sizes[i-1].TotalBytes {
+				t.Errorf("Expected tables sorted by size, %s is larger than %s", size.Name, sizes[i-1].Name)
+			}
+			if size.TotalBytes != size.TableBytes+size.IndexBytes+size.ToastBytes {
+				t.Errorf("Expected total size of %s to be the sum of its parts: %+v", size.Name, size)
+			}
+			if size.Name == "test_users" {
+				found = true
+				if size.TotalBytes <= 0 || size.IndexBytes <= 0 {
+					t.Errorf("Expected test_users to have table and index sizes: %+v", size)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected to find test_users sizes")
+		}
+	})
+
+	t.Run("get index usage", func(t *testing.T) {
+		usage, err := introspection.GetIndexUsage(ctx, "public")
+		if err != nil {
+			t.Fatalf("Failed to get index usage: %v", err)
+		}
+
+		var found bool
+		for _, index := range usage {
+			if index.Name == "test_users_pkey" {
+				found = true
+				if !index.IsPrimary || !index.EnforcesConstraint || index.SizeBytes <= 0 {
+					t.Errorf("Expected test_users_pkey to be a primary key index with a size: %+v", index)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected to find test_users_pkey usage")
+		}
+	})
+
+	t.Run("get unused indexes", func(t *testing.T) {
+		report, err := introspection.GetUnusedIndexes(ctx, "public")
+		if err != nil {
+			t.Fatalf("Failed to get unused indexes: %v", err)
+		}
+
+		var total int64
+		for _, index := range report.Indexes {
+			if index.Scans > 0 || index.IsUnique || index.EnforcesConstraint {
+				t.Errorf("Expected only unscanned, droppable indexes: %+v", index)
+			}
+			total += index.SizeBytes
+		}
+		if total != report.TotalBytes {
+			t.Errorf("Expected total size %d, got %d", total, report.TotalBytes)
+		}
+	})
+
+	t.Run("get column statistics", func(t *testing.T) {
+		if _, err := db.db.ExecContext(ctx, "ANALYZE test_users"); err != nil {
+			t.Fatalf("Failed to analyze test_users: %v", err)
+		}
+
+		statistics, err := introspection.GetColumnStatistics(ctx, "public", "test_users")
+		if err != nil {
+			t.Fatalf("Failed to get column statistics: %v", err)
+		}
+		if len(statistics) == 0 || statistics[0].Column != "id" {
+			t.Fatalf("Expected statistics in column order starting with id, got %+v", statistics)
+		}
+
+		for _, column := range statistics {
+			switch column.Column {
+			case "email":
+				if column.DistinctValues != -1 || column.NullFraction != 0 {
+					t.Errorf("Expected email to be unique and not null: %+v", column)
+				}
+			case "status":
+				if len(column.MostCommonValues) != 1 || column.MostCommonValues[0] != "active" {
+					t.Errorf("Expected active to be the most common status: %+v", column)
+				}
+				if len(column.MostCommonFrequencies) != len(column.MostCommonValues) {
+					t.Errorf("Expected a frequency per common value: %+v", column)
+				}
+			}
+		}
+	})
+
+	t.Run("get maintenance stats", func(t *testing.T) {
+		stats, err := introspection.GetMaintenanceStats(ctx, "public")
+		if err != nil {
+			t.Fatalf("Failed to get maintenance stats: %v", err)
+		}
+
+		var foundUsers bool
+		for _, table := range stats {
+			if table.Schema != "public" {
+				t.Errorf("Expected only tables in public, got %s.%s", table.Schema, table.Table)
+			}
+			if table.Table == "test_users" {
+				foundUsers = true
+			}
+			if (len(table.Recommendations) == 0) != (table.Statement == "") {
+				t.Errorf("Expected a statement exactly when maintenance is recommended: %+v", table)
+			}
+		}
+		if !foundUsers {
+			t.Errorf("Expected maintenance stats for test_users")
+		}
+	})
+
+	t.Run("suggest indexes", func(t *testing.T) {
+		suggestions, err := introspection.SuggestIndexes(ctx, SuggestIndexOptions{
+			Schema:        "public",
+			MinTableBytes: 1,
+			MinSeqScans:   1,
+			UseStatements: true,
+		})
+		if err != nil {
+			t.Fatalf("Failed to suggest indexes: %v", err)
+		}
+		for _, suggestion := range suggestions {
+			if suggestion.Schema != "public" || suggestion.Confidence == "" || suggestion.Impact == "" {
+				t.Errorf("Unexpected suggestion: %+v", suggestion)
+			}
+		}
+	})
+
+	t.Run("get bloat estimates", func(t *testing.T) {
+		if _, err := db.db.ExecContext(ctx, "ANALYZE test_users"); err != nil {
+			t.Fatalf("Failed to analyze test_users: %v", err)
+		}
+
+		estimates, err := introspection.GetBloatEstimates(ctx, "public")
+		if err != nil {
+			t.Fatalf("Failed to get bloat estimates: %v", err)
+		}
+		for i, estimate := range estimates {
+			if i > 0 && estimate.WastedBytes > estimates[i-1].WastedBytes {
+				t.Errorf("Expected relations sorted by wasted space, %s wastes more than %s", estimate.Table, estimates[i-1].Table)
+			}
+			if estimate.WastedBytes < 0 || estimate.WastedBytes > estimate.SizeBytes || estimate.BloatRatio < 0 || estimate.BloatRatio > 1 {
+				t.Errorf("Unexpected estimate: %+v", estimate)
+			}
+			if (estimate.Kind == RelationIndex) != (estimate.Index != "") {
+				t.Errorf("Expected index names exactly for indexes: %+v", estimate)
+			}
+		}
+	})
+
+	t.Run("get locks", func(t *testing.T) {
+		tx, err := db.db.BeginTxx(ctx, nil)
+		if err != nil {
+			t.Fatalf("Failed to begin transaction: %v", err)
+		}
+		defer tx.Rollback()
+		if _, err := tx.ExecContext(ctx, "LOCK TABLE test_users IN ACCESS EXCLUSIVE MODE"); err != nil {
+			t.Fatalf("Failed to lock test_users: %v", err)
+		}
+
+		// Block a second session on the lock until the test ends
+		blockedCtx, cancelBlocked := context.WithCancel(ctx)
+		defer cancelBlocked()
+		go db.db.ExecContext(blockedCtx, "SELECT count(*) FROM test_users")
+
+		var trees []BlockingNode
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+			locks, err := introspection.GetLocks(ctx)
+			if err != nil {
+				t.Fatalf("Failed to get locks: %v", err)
+			}
+			if trees = BlockingTrees(locks); len(trees) > 0 {
+				break
+			}
+		}
+
+		if len(trees) != 1 || len(trees[0].Blocked) != 1 {
+			t.Fatalf("Expected one session blocked by the lock, got %+v", trees)
+		}
+		waiting := trees[0].Blocked[0].WaitingFor
+		if waiting == nil || waiting.Relation == nil || *waiting.Relation != "test_users" || waiting.Granted {
+			t.Errorf("Expected the blocked session to wait for test_users, got %+v", waiting)
+		}
+	})
+
+	t.Run("get activity and cancel backend", func(t *testing.T) {
+		sleepCtx, cancelSleep := context.WithCancel(ctx)
+		defer cancelSleep()
+		sleepDone := make(chan error, 1)
+		go func() {
+			_, err := db.db.ExecContext(sleepCtx, "SELECT pg_sleep(30) /* activity test */")
+			sleepDone <- err
+		}()
+
+		var session *SessionActivity
+		for deadline := time.Now().Add(5 * time.Second); session == nil && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+			activity, err := introspection.GetActivity(ctx, ActivityFilter{State: "active"})
+			if err != nil {
+				t.Fatalf("Failed to get activity: %v", err)
+			}
+			for i := range activity {
+				if activity[i].Query != nil && strings.Contains(*activity[i].Query, "activity test") {
+					session = &activity[i]
+				}
+			}
+		}
+		if session == nil {
+			t.Fatalf("Expected to find the sleeping session")
+		}
+
+		if err := db.CancelBackend(ctx, session.PID); err != nil {
+			t.Fatalf("Failed to cancel backend: %v", err)
+		}
+		select {
+		case err := <-sleepDone:
+			if err == nil {
+				t.Errorf("Expected the cancelled query to fail")
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("Expected the query to be cancelled")
+		}
+
+		if err := db.CancelBackend(ctx, 0); GetErrorCode(err) != ErrCodeValidation {
+			t.Errorf("Expected a validation error for an unknown PID, got %v", err)
+		}
+	})
+
+	t.Run("get top queries", func(t *testing.T) {
+		// pg_stat_statements is optional, so either outcome is fine as long as it does not fail
+		top, err := introspection.GetTopQueries(ctx, QueryOrderCalls, 5)
+		if err != nil {
+			t.Fatalf("Failed to get top queries: %v", err)
+		}
+		if !top.Available && top.Reason == "" {
+			t.Errorf("Expected a reason when pg_stat_statements is unavailable")
+		}
+		if len(top.Queries) > 5 {
+			t.Errorf("Expected at most 5 queries, got %d", len(top.Queries))
+		}
+		for i := 1; i < len(top.Queries); i++ {
+			if top.Queries[i].Calls > top.Queries[i-1].Calls {
+				t.Errorf("Expected queries ordered by calls, got %d after %d", top.Queries[i].Calls, top.Queries[i-1].Calls)
+			}
+		}
+	})
+
+	t.Run("get replication objects", func(t *testing.T) {
+		if _, err := db.db.ExecContext(ctx, "CREATE PUBLICATION test_users_pub FOR TABLE test_users WITH (publish = 'insert, update')"); err != nil {
+			t.Fatalf("Failed to create publication: %v", err)
+		}
+		defer db.db.ExecContext(ctx, "DROP PUBLICATION IF EXISTS test_users_pub")
+
+		publications, err := introspection.GetPublications(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get publications: %v", err)
+		}
+		var found bool
+		for _, publication := range publications {
+			if publication.Name == "test_users_pub" {
+				found = true
+				if !publication.Insert || !publication.Update || publication.Delete || publication.AllTables {
+					t.Errorf("Expected insert and update to be published, got %+v", publication)
+				}
+				if len(publication.Tables) != 1 || publication.Tables[0] != "public.test_users" {
+					t.Errorf("Expected public.test_users to be published, got %v", publication.Tables)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected to find test_users_pub, got %+v", publications)
+		}
+
+		if _, err := introspection.GetSubscriptions(ctx); err != nil {
+			t.Errorf("Failed to get subscriptions: %v", err)
+		}
+		if _, err := introspection.GetReplicationSlots(ctx); err != nil {
+			t.Errorf("Failed to get replication slots: %v", err)
+		}
+		if _, err := introspection.GetStandbys(ctx); err != nil {
+			t.Errorf("Failed to get standbys: %v", err)
+		}
+	})
+
+	t.Run("get views", func(t *testing.T) {
+		views, err := introspection.GetViews(ctx, "public")
+		if err != nil {
+			t.Errorf("Failed to get views: %v", err)
+		}
+
+		var found bool
+		for _, view := range views {
+			if view.Name == "test_published_posts" {
+				found = true
+				if view.Materialized || !strings.Contains(view.Definition, "published") {
+					t.Errorf("Unexpected view details: %+v", view)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Expected to find test_published_posts view")
+		}
+	})
+
+	t.Run("export schema", func(t *testing.T) {
+		doc, err := introspection.ExportSchema(ctx, ExportOptions{Schema: "public"})
+		if err != nil {
+			t.Fatalf("Failed to export schema: %v", err)
+		}
+		if doc.Version != SchemaDocumentVersion || doc.Database != db.config.DBName {
+			t.Errorf("Unexpected document header: version %d, database %s", doc.Version, doc.Database)
+		}
+
+		var foundTable bool
+		for _, table := range doc.Tables {
+			if table.Name == "test_published_posts" {
+				t.Errorf("Expected views to be exported as views, not tables")
+			}
+			if table.Name == "test_users" && len(table.Columns) > 0 {
+				foundTable = true
+			}
+		}
+		if !foundTable {
+			t.Errorf("Expected test_users with columns in the export")
+		}
+		if len(doc.Views) == 0 || len(doc.Enums) == 0 {
+			t.Errorf("Expected views and enums in the export, got %d views and %d enums", len(doc.Views), len(doc.Enums))
+		}
+	})
+
+	t.Run("diff schemas", func(t *testing.T) {
+		diff, err := DiffSchemas(ctx, db, db, DiffOptions{Schema: "public"})
+		if err != nil {
+			t.Fatalf("Failed to diff schemas: %v", err)
+		}
+		if diff.HasChanges() {
+			t.Errorf("Expected no changes diffing a database with itself, got %v", diff.Changes)
+		}
+	})
+
+	t.Run("detect schema drift", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "schema.yaml")
+		if err := introspection.SaveSchemaSnapshot(ctx, path); err != nil {
+			t.Fatalf("Failed to save schema snapshot: %v", err)
+		}
+
+		diff, err := introspection.DetectDrift(ctx, path)
+		if err != nil {
+			t.Fatalf("Failed to detect drift: %v", err)
+		}
+		if diff.HasChanges() {
+			t.Errorf("Expected no drift right after the snapshot, got %v", diff.Changes)
+		}
+
+		if _, err := db.db.ExecContext(ctx, "CREATE INDEX test_users_drift_idx ON test_users (name)"); err != nil {
+			t.Fatalf("Failed to create index: %v", err)
+		}
+		defer db.db.ExecContext(ctx, "DROP INDEX IF EXISTS test_users_drift_idx")
+
+		diff, err = introspection.DetectDrift(ctx, path)
+		if err != nil {
+			t.Fatalf("Failed to detect drift: %v", err)
+		}
+		if len(diff.Changes) != 1 || diff.Changes[0].Kind != ChangeAdded || diff.Changes[0].Name != "test_users_drift_idx" {
+			t.Errorf("Expected the added index as drift, got %v", diff.Changes)
+		}
+	})
+
+	t.Run("get table dependency order", func(t *testing.T) {
+		order, err := introspection.GetTableDependencyOrder(ctx, "public")
+		if err != nil {
+			t.Fatalf("Failed to get table dependency order: %v", err)
+		}
+
+		position := make(map[string]int)
+		for i, table := range order {
+			position[table] = i
+		}
+		users, hasUsers := position["public.test_users"]
+		posts, hasPosts := position["public.test_posts"]
+		if !hasUsers || !hasPosts || users > posts {
+			t.Errorf("Expected public.test_users before public.test_posts, got %v", order)
+		}
+	})
+
 	t.Run("check table exists", func(t *testing.T) {
 		exists, err := introspection.GetTableExists(ctx, "public", "test_users")
 		if err != nil {
@@ -254,6 +1140,48 @@ func TestIntrospectionService(t *testing.T) {
 		t.Logf("Found %d foreign key relationships", len(relationships))
 	})
 
+	t.Run("get foreign key relationships with quoted identifiers", func(t *testing.T) {
+		for _, query := range []string{
+			`CREATE TABLE "test, parents" ("key, a" INTEGER, "key b" INTEGER, PRIMARY KEY ("key, a", "key b"))`,
+			`CREATE TABLE "test children" (
+				id SERIAL PRIMARY KEY,
+				"parent, a" INTEGER,
+				"parent b" INTEGER,
+				FOREIGN KEY ("parent, a", "parent b") REFERENCES "test, parents" ("key, a", "key b")
+			)`,
+		} {
+			if _, err := db.db.ExecContext(ctx, query); err != nil {
+				t.Fatalf("Failed to create tables: %v", err)
+			}
+		}
+		defer db.db.ExecContext(ctx, `DROP TABLE IF EXISTS "test children", "test, parents"`)
+
+		relationships, err := introspection.GetForeignKeyRelationships(ctx, "public")
+		if err != nil {
+			t.Fatalf("Failed to get foreign key relationships: %v", err)
+		}
+
+		var found bool
+		for _, rel := range relationships {
+			if rel.TableName != "test children" {
+				continue
+			}
+			found = true
+			if len(rel.Columns) != 2 || rel.Columns[0] != "parent, a" || rel.Columns[1] != "parent b" {
+				t.Errorf("Expected columns [parent, a] and [parent b], got %q", rel.Columns)
+			}
+			if len(rel.ReferencedColumns) != 2 || rel.ReferencedColumns[0] != "key, a" || rel.ReferencedColumns[1] != "key b" {
+				t.Errorf("Expected referenced columns [key, a] and [key b], got %q", rel.ReferencedColumns)
+			}
+			if rel.ReferencedTable == nil || *rel.ReferencedTable != "test, parents" {
+				t.Errorf("Expected the relationship to reference \"test, parents\", got %v", rel.ReferencedTable)
+			}
+		}
+		if !found {
+			t.Errorf("Expected to find the relationship of \"test children\"")
+		}
+	})
+
 	t.Run("get complete database info", func(t *testing.T) {
 		info, err := introspection.GetDatabaseInfo(ctx)
 		if err != nil {
@@ -289,28 +1217,84 @@ func TestIntrospectionService(t *testing.T) {
 	})
 }
 
-func TestParsePostgreSQLArray(t *testing.T) {
+func TestIntrospectionParallelism(t *testing.T) {
+	db := &DB{}
+	introspection := NewIntrospectionService(db)
+	if introspection.parallelism != 1 {
+		t.Errorf("Expected serial introspection by default, got parallelism %d", introspection.parallelism)
+	}
+
+	parallel := introspection.WithParallelism(8)
+	if parallel.parallelism != 8 || parallel.db != db {
+		t.Errorf("Expected parallelism 8 on the same database, got %d", parallel.parallelism)
+	}
+	if introspection.parallelism != 1 {
+		t.Errorf("Expected WithParallelism to leave the original service unchanged")
+	}
+	if p := introspection.WithParallelism(0).parallelism; p != 1 {
+		t.Errorf("Expected parallelism below 1 to mean 1, got %d", p)
+	}
+}
+
+func TestDecodeTriggerType(t *testing.T) {
 	testCases := []struct {
-		input    string
-		expected []string
+		tgtype int
+		timing string
+		events []string
+		level  string
 	}{
-		{"{}", []string{}},
-		{"", []string{}},
-		{"{id}", []string{"id"}},
-		{"{id,name,email}", []string{"id", "name", "email"}},
-		{"{id, name, email}", []string{"id", "name", "email"}}, // with spaces
+		{1 | 2 | 16, "BEFORE", []string{"UPDATE"}, "ROW"},
+		{4 | 8 | 16, "AFTER", []string{"INSERT", "UPDATE", "DELETE"}, "STATEMENT"},
+		{1 | 64 | 4, "INSTEAD OF", []string{"INSERT"}, "ROW"},
+		{32, "AFTER", []string{"TRUNCATE"}, "STATEMENT"},
 	}
 
 	for _, tc := range testCases {
-		result := parsePostgreSQLArray(tc.input)
-		if len(result) != len(tc.expected) {
-			t.Errorf("For input '%s', expected length %d, got %d", tc.input, len(tc.expected), len(result))
-			continue
+		timing, events, level := decodeTriggerType(tc.tgtype)
+		if timing != tc.timing || level != tc.level {
+			t.Errorf("For tgtype %d, expected %s %s, got %s %s", tc.tgtype, tc.timing, tc.level, timing, level)
 		}
-		for i, expected := range tc.expected {
-			if result[i] != expected {
-				t.Errorf("For input '%s', expected[%d] = '%s', got '%s'", tc.input, i, expected, result[i])
-			}
+		if strings.Join(events, ",") != strings.Join(tc.events, ",") {
+			t.Errorf("For tgtype %d, expected events %v, got %v", tc.tgtype, tc.events, events)
+		}
+	}
+}
+
+func TestStorageParameters(t *testing.T) {
+	table := TableInfo{StorageParameters: parseOptions([]string{
+		"fillfactor=70",
+		"autovacuum_vacuum_scale_factor=0.05",
+		"toast.autovacuum_enabled=false",
+		"toast.vacuum_truncate=false",
+	})}
+
+	if table.Fillfactor() != 70 {
+		t.Errorf("Expected fillfactor 70, got %d", table.Fillfactor())
+	}
+	overrides := table.AutovacuumOverrides()
+	if len(overrides) != 2 || overrides["autovacuum_vacuum_scale_factor"] != "0.05" || overrides["toast.autovacuum_enabled"] != "false" {
+		t.Errorf("Unexpected autovacuum overrides: %v", overrides)
+	}
+
+	if parseOptions(nil) != nil {
+		t.Errorf("Expected no storage parameters for an empty list")
+	}
+	if fillfactor := (TableInfo{}).Fillfactor(); fillfactor != 100 {
+		t.Errorf("Expected the default fillfactor 100, got %d", fillfactor)
+	}
+}
+
+func TestPartitionKey(t *testing.T) {
+	testCases := map[string]string{
+		"RANGE (created_at)":             "created_at",
+		"LIST (region)":                  "region",
+		"HASH (tenant_id, lower(email))": "tenant_id, lower(email)",
+		"unexpected":                     "unexpected",
+	}
+
+	for definition, expected := range testCases {
+		if key := partitionKey(definition); key != expected {
+			t.Errorf("For definition '%s', expected key '%s', got '%s'", definition, expected, key)
 		}
 	}
 }
@@ -321,12 +1305,19 @@ func setupTestSchema(t *testing.T, db *DB) {
 
 	// Create test tables with various features
 	queries := []string{
+		// Enum type referenced by the users table
+		`DO $$ BEGIN
+			CREATE TYPE test_user_status AS ENUM ('active', 'suspended', 'deleted');
+		EXCEPTION WHEN duplicate_object THEN NULL;
+		END $$`,
+
 		// Users table with primary key, unique constraint, and indexes
 		`CREATE TABLE IF NOT EXISTS test_users (
 			id SERIAL PRIMARY KEY,
 			email VARCHAR(255) UNIQUE NOT NULL,
 			name VARCHAR(100) NOT NULL,
 			age INTEGER CHECK (age >= 0),
+			status test_user_status NOT NULL DEFAULT 'active',
 			created_at TIMESTAMP DEFAULT NOW(),
 			updated_at TIMESTAMP DEFAULT NOW()
 		)`,
@@ -341,6 +1332,80 @@ func setupTestSchema(t *testing.T, db *DB) {
 			created_at TIMESTAMP DEFAULT NOW()
 		)`,
 
+		// Function and procedure
+		`CREATE OR REPLACE FUNCTION test_user_post_count(user_id integer) RETURNS bigint
+			LANGUAGE sql STABLE
+			AS 'SELECT count(*) FROM test_posts WHERE test_posts.user_id = $1'`,
+		`CREATE OR REPLACE PROCEDURE test_publish_posts()
+			LANGUAGE plpgsql
+			AS $$ BEGIN UPDATE test_posts SET published = true; END $$`,
+
+		// Trigger keeping updated_at current
+		`CREATE OR REPLACE FUNCTION test_touch_updated_at() RETURNS trigger
+			LANGUAGE plpgsql
+			AS $$ BEGIN NEW.updated_at = NOW(); RETURN NEW; END $$`,
+		`DROP TRIGGER IF EXISTS test_users_touch ON test_users`,
+		`CREATE TRIGGER test_users_touch BEFORE UPDATE ON test_users
+			FOR EACH ROW EXECUTE FUNCTION test_touch_updated_at()`,
+
+		// Partitioned table with a range partition and a default partition
+		`CREATE TABLE IF NOT EXISTS test_events (
+			id BIGSERIAL,
+			created_at TIMESTAMP NOT NULL
+		) PARTITION BY RANGE (created_at)`,
+		`CREATE TABLE IF NOT EXISTS test_events_2024 PARTITION OF test_events
+			FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')`,
+		`CREATE TABLE IF NOT EXISTS test_events_default PARTITION OF test_events DEFAULT`,
+
+		// Table with an exclusion constraint
+		`DO $$ BEGIN
+			CREATE DOMAIN test_email AS VARCHAR(255) CHECK (VALUE LIKE '%@%');
+		EXCEPTION WHEN duplicate_object THEN NULL;
+		END $$`,
+
+		// Composite type
+		`DO $$ BEGIN
+			CREATE TYPE test_address AS (street TEXT, city TEXT COLLATE "C", zip VARCHAR(10));
+		EXCEPTION WHEN duplicate_object THEN NULL;
+		END $$`,
+
+		// Table with an exclusion constraint and identity, generated, collated, domain and
+		// array columns
+		`CREATE TABLE IF NOT EXISTS test_reservations (
+			id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+			during TSRANGE NOT NULL,
+			guest TEXT COLLATE "C",
+			contact test_email,
+			seats INTEGER[],
+			nights INTEGER GENERATED ALWAYS AS (upper(during)::date - lower(during)::date) STORED,
+			CONSTRAINT test_reservations_no_overlap EXCLUDE USING gist (during WITH &&)
+		)`,
+
+		// Unlogged table with storage parameters
+		`CREATE UNLOGGED TABLE IF NOT EXISTS test_sessions (
+			id TEXT PRIMARY KEY,
+			data TEXT
+		) WITH (fillfactor = 70, autovacuum_vacuum_scale_factor = 0.05, toast.autovacuum_enabled = false)`,
+
+		// Foreign table on a server of a wrapper without handler, which can be introspected
+		// but not queried
+		`DO $$ BEGIN
+			CREATE FOREIGN DATA WRAPPER test_fdw;
+		EXCEPTION WHEN duplicate_object THEN NULL;
+		END $$`,
+		`CREATE SERVER IF NOT EXISTS test_server FOREIGN DATA WRAPPER test_fdw OPTIONS (host 'remote.example.com')`,
+		`CREATE FOREIGN TABLE IF NOT EXISTS test_remote_orders (
+			id INTEGER NOT NULL,
+			total NUMERIC(10, 2)
+		) SERVER test_server OPTIONS (table_name 'orders')`,
+
+		// View over posts
+		`CREATE OR REPLACE VIEW test_published_posts AS
+			SELECT id, user_id, title FROM test_posts WHERE published`,
+
+		// Column privilege
+		`GRANT SELECT (name) ON test_users TO PUBLIC`,
+
 		// Additional indexes
 		`CREATE INDEX IF NOT EXISTS idx_test_users_name ON test_users(name)`,
 		`CREATE INDEX IF NOT EXISTS idx_test_posts_user_id ON test_posts(user_id)`,
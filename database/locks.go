@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// LockInfo is a lock held or awaited by a session of the current database
+type LockInfo struct {
+	PID         int64   `json:"pid" db:"pid"`
+	User        *string `json:"user,omitempty" db:"usename"`
+	Application string  `json:"application" db:"application_name"`
+	// State is the session state, e.g. active or idle in transaction
+	State *string `json:"state,omitempty" db:"state"`
+	// Query is the current or, for idle sessions, the last query of the session
+	Query    *string `json:"query,omitempty" db:"query"`
+	LockType string  `json:"lock_type" db:"locktype"`
+	Mode     string  `json:"mode" db:"mode"`
+	Granted  bool    `json:"granted" db:"granted"`
+	// Relation is the locked table or index, if the lock is on a relation
+	Relation *string `json:"relation,omitempty" db:"relation"`
+	// Duration is how long the transaction has held a granted lock, or how long the query
+	// waiting for the lock has run
+	Duration time.Duration `json:"duration" db:"duration"`
+	// BlockedBy are the sessions blocking this session, if it waits for a lock
+	BlockedBy pq.Int64Array `json:"blocked_by,omitempty" db:"blocked_by"`
+}
+
+// BlockingNode is a session in a blocking tree with the sessions waiting for it
+type BlockingNode struct {
+	PID   int64   `json:"pid"`
+	User  *string `json:"user,omitempty"`
+	State *string `json:"state,omitempty"`
+	Query *string `json:"query,omitempty"`
+	// WaitingFor is the lock the session waits for, nil for the root of a tree
+	WaitingFor *LockInfo `json:"waiting_for,omitempty"`
+	// Duration is how long the session has held its locks, or waited for WaitingFor
+	Duration time.Duration  `json:"duration"`
+	Blocked  []BlockingNode `json:"blocked,omitempty"`
+}
+
+// LongestWait returns the longest time a session of the tree has waited for a lock, 0 if
+// none waits
+func (n BlockingNode) LongestWait() time.Duration {
+	var longest time.Duration
+	if n.WaitingFor != nil {
+		longest = n.Duration
+	}
+	for _, child := range n.Blocked {
+		longest = max(longest, child.LongestWait())
+	}
+	return longest
+}
+
+// GetLocks retrieves the locks held and awaited by the other sessions of the current
+// database, waiting locks first and then the longest held. Waiting locks list the
+// sessions blocking them in BlockedBy; BlockingTrees arranges them as trees.
+func (is *IntrospectionService) GetLocks(ctx context.Context) ([]LockInfo, error) {
+	locks := []LockInfo{}
+
+	query := `
+		SELECT
+			l.pid,
+			a.usename,
+			coalesce(a.application_name, '') as application_name,
+			a.state,
+			a.query,
+			l.locktype,
+			l.mode,
+			l.granted,
+			l.relation::regclass::text as relation,
+			coalesce(extract(epoch FROM now() - CASE
+				WHEN l.granted THEN coalesce(a.xact_start, a.query_start)
+				ELSE a.query_start
+			END) * 1000000000, 0)::bigint as duration,
+			CASE WHEN l.granted THEN '{}'::int[] ELSE pg_blocking_pids(l.pid) END as blocked_by
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE a.datname = current_database()
+		AND l.pid <> pg_backend_pid()
+		ORDER BY l.granted, duration DESC, l.pid
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &locks, query)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_locks", "failed to get locks")
+	}
+
+	return locks, nil
+}
+
+// BlockingTrees arranges the sessions of locks as trees of who blocks whom. Each root is a
+// session blocking others without waiting itself, e.g. an idle transaction holding a row
+// lock, with the sessions waiting for it below; the longest blocking roots come first. A
+// session blocked by several others appears under each. Sessions waiting on each other in
+// a cycle are rooted at the longest waiting one.
+func BlockingTrees(locks []LockInfo) []BlockingNode {
+	sessions := make(map[int64]*BlockingNode)
+	// blocked[pid] are the sessions waiting for pid, in order of first appearance
+	blocked := make(map[int64][]int64)
+	waiting := make(map[int64]bool)
+	var order []int64
+
+	session := func(pid int64) *BlockingNode {
+		node, ok := sessions[pid]
+		if !ok {
+			node = &BlockingNode{PID: pid}
+			sessions[pid] = node
+			order = append(order, pid)
+		}
+		return node
+	}
+
+	for i := range locks {
+		lock := locks[i]
+		node := session(lock.PID)
+		node.User, node.State, node.Query = lock.User, lock.State, lock.Query
+
+		if lock.Granted {
+			if node.WaitingFor == nil && lock.Duration > node.Duration {
+				node.Duration = lock.Duration
+			}
+			continue
+		}
+		if len(lock.BlockedBy) == 0 || node.WaitingFor != nil {
+			continue
+		}
+		node.WaitingFor = &lock
+		node.Duration = lock.Duration
+		waiting[lock.PID] = true
+		for _, blocker := range lock.BlockedBy {
+			session(blocker)
+			blocked[blocker] = append(blocked[blocker], lock.PID)
+		}
+	}
+
+	var roots []int64
+	for _, pid := range order {
+		if !waiting[pid] && len(blocked[pid]) > 0 {
+			roots = append(roots, pid)
+		}
+	}
+	sort.SliceStable(roots, func(i, j int) bool { return sessions[roots[i]].Duration > sessions[roots[j]].Duration })
+
+	reached := make(map[int64]bool)
+	var build func(pid int64, path map[int64]bool) BlockingNode
+	build = func(pid int64, path map[int64]bool) BlockingNode {
+		reached[pid] = true
+		path[pid] = true
+		defer delete(path, pid)
+
+		node := *sessions[pid]
+		for _, child := range blocked[pid] {
+			if !path[child] {
+				node.Blocked = append(node.Blocked, build(child, path))
+			}
+		}
+		return node
+	}
+
+	trees := []BlockingNode{}
+	for _, pid := range roots {
+		trees = append(trees, build(pid, make(map[int64]bool)))
+	}
+
+	// Cycles have no root outside them
+	var cyclic []int64
+	for _, pid := range order {
+		if waiting[pid] && !reached[pid] {
+			cyclic = append(cyclic, pid)
+		}
+	}
+	sort.SliceStable(cyclic, func(i, j int) bool { return sessions[cyclic[i]].Duration > sessions[cyclic[j]].Duration })
+	for _, pid := range cyclic {
+		if !reached[pid] {
+			trees = append(trees, build(pid, make(map[int64]bool)))
+		}
+	}
+
+	return trees
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockingTrees(t *testing.T) {
+	relation := "public.orders"
+	locks := []LockInfo{
+		// 30 waits for 20, which waits for 10
+		{PID: 30, LockType: "relation", Mode: "AccessShareLock", Relation: &relation, Duration: 5 * time.Second, BlockedBy: []int64{20}},
+		{PID: 20, LockType: "relation", Mode: "AccessExclusiveLock", Relation: &relation, Duration: 8 * time.Second, BlockedBy: []int64{10}},
+		// 50 and 60 wait on each other
+		{PID: 50, LockType: "transactionid", Mode: "ShareLock", Duration: time.Second, BlockedBy: []int64{60}},
+		{PID: 60, LockType: "transactionid", Mode: "ShareLock", Duration: 2 * time.Second, BlockedBy: []int64{50}},
+		{PID: 10, LockType: "relation", Mode: "RowExclusiveLock", Relation: &relation, Granted: true, Duration: time.Minute},
+		{PID: 10, LockType: "virtualxid", Mode: "ExclusiveLock", Granted: true, Duration: time.Minute},
+		// Not involved in any blocking
+		{PID: 40, LockType: "relation", Mode: "AccessShareLock", Granted: true, Duration: time.Hour},
+	}
+
+	trees := BlockingTrees(locks)
+	require.Len(t, trees, 2)
+
+	root := trees[0]
+	assert.Equal(t, int64(10), root.PID)
+	assert.Nil(t, root.WaitingFor)
+	assert.Equal(t, time.Minute, root.Duration)
+	require.Len(t, root.Blocked, 1)
+	assert.Equal(t, int64(20), root.Blocked[0].PID)
+	assert.Equal(t, "AccessExclusiveLock", root.Blocked[0].WaitingFor.Mode)
+	require.Len(t, root.Blocked[0].Blocked, 1)
+	assert.Equal(t, int64(30), root.Blocked[0].Blocked[0].PID)
+	assert.Empty(t, root.Blocked[0].Blocked[0].Blocked)
+
+	// The cycle is rooted at the longest waiting session
+	cycle := trees[1]
+	assert.Equal(t, int64(60), cycle.PID)
+	require.Len(t, cycle.Blocked, 1)
+	assert.Equal(t, int64(50), cycle.Blocked[0].PID)
+	assert.Empty(t, cycle.Blocked[0].Blocked)
+}
+
+func TestBlockingTreesWithoutBlocking(t *testing.T) {
+	trees := BlockingTrees([]LockInfo{{PID: 40, LockType: "relation", Mode: "AccessShareLock", Granted: true}})
+	assert.Empty(t, trees)
+	assert.NotNil(t, trees)
+}
+
+func TestBlockingNodeLongestWait(t *testing.T) {
+	tree := BlockingNode{
+		PID:      10,
+		Duration: time.Hour,
+		Blocked: []BlockingNode{
+			{PID: 20, WaitingFor: &LockInfo{}, Duration: 5 * time.Second, Blocked: []BlockingNode{
+				{PID: 30, WaitingFor: &LockInfo{}, Duration: 40 * time.Second},
+			}},
+			{PID: 40, WaitingFor: &LockInfo{}, Duration: 10 * time.Second},
+		},
+	}
+	assert.Equal(t, 40*time.Second, tree.LongestWait())
+	assert.Zero(t, BlockingNode{PID: 10, Duration: time.Hour}.LongestWait())
+}
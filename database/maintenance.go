@@ -0,0 +1,371 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/sync/errgroup"
+)
+
+// Actions of a MaintenanceRecommendation
+const (
+	MaintenanceVacuum  = "vacuum"
+	MaintenanceAnalyze = "analyze"
+)
+
+// Thresholds of the maintenance recommendations. They are stricter than the autovacuum
+// defaults so that tables autovacuum falls behind on show up before they bloat.
+const (
+	// maintenanceMinDeadTuples is the number of dead tuples below which vacuum is not needed
+	maintenanceMinDeadTuples = 1000
+	// maintenanceDeadTupleRatio is the share of dead tuples from which vacuum is recommended
+	maintenanceDeadTupleRatio = 0.1
+	// maintenanceMinModifications is the number of modifications below which analyze is not needed
+	maintenanceMinModifications = 1000
+	// maintenanceModificationRatio is the share of rows modified since the last analyze
+	// from which analyze is recommended
+	maintenanceModificationRatio = 0.1
+)
+
+// MaintenanceStats is the vacuum and analyze state of a table from pg_stat_user_tables
+type MaintenanceStats struct {
+	Schema     string `json:"schema" db:"table_schema"`
+	Table      string `json:"table" db:"table_name"`
+	LiveTuples int64  `json:"live_tuples" db:"live_tuples"`
+	DeadTuples int64  `json:"dead_tuples" db:"dead_tuples"`
+	// ModificationsSinceAnalyze is the number of rows inserted, updated or deleted since the
+	// table was last analyzed
+	ModificationsSinceAnalyze int64 `json:"modifications_since_analyze" db:"modifications_since_analyze"`
+	// LastVacuum, LastAutovacuum, LastAnalyze and LastAutoanalyze are nil if the table was
+	// never processed since the statistics were last reset
+	LastVacuum       *time.Time `json:"last_vacuum,omitempty" db:"last_vacuum"`
+	LastAutovacuum   *time.Time `json:"last_autovacuum,omitempty" db:"last_autovacuum"`
+	LastAnalyze      *time.Time `json:"last_analyze,omitempty" db:"last_analyze"`
+	LastAutoanalyze  *time.Time `json:"last_autoanalyze,omitempty" db:"last_autoanalyze"`
+	VacuumCount      int64      `json:"vacuum_count" db:"vacuum_count"`
+	AutovacuumCount  int64      `json:"autovacuum_count" db:"autovacuum_count"`
+	AnalyzeCount     int64      `json:"analyze_count" db:"analyze_count"`
+	AutoanalyzeCount int64      `json:"autoanalyze_count" db:"autoanalyze_count"`
+	// Recommendations are empty for tables that need no maintenance
+	Recommendations []MaintenanceRecommendation `json:"recommendations"`
+	// Statement runs the recommended maintenance, empty without recommendations
+	Statement string `json:"statement,omitempty"`
+}
+
+// MaintenanceRecommendation is a vacuum or analyze a table needs
+type MaintenanceRecommendation struct {
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+// DeadTupleRatio is the share of dead tuples in the table
+func (s MaintenanceStats) DeadTupleRatio() float64 {
+	if s.LiveTuples+s.DeadTuples == 0 {
+		return 0
+	}
+	return float64(s.DeadTuples) / float64(s.LiveTuples+s.DeadTuples)
+}
+
+// GetMaintenanceStats retrieves the vacuum and analyze state of the tables in schema, or in
+// all schemas if empty, with most dead tuples first. Tables with at least 10% dead tuples
+// are recommended a vacuum, and tables never analyzed or with at least 10% of their rows
+// modified since the last analyze are recommended an analyze.
+func (is *IntrospectionService) GetMaintenanceStats(ctx context.Context, schema string) ([]MaintenanceStats, error) {
+	stats := []MaintenanceStats{}
+
+	query := `
+		SELECT
+			s.schemaname as table_schema,
+			s.relname as table_name,
+			s.n_live_tup as live_tuples,
+			s.n_dead_tup as dead_tuples,
+			s.n_mod_since_analyze as modifications_since_analyze,
+			s.last_vacuum,
+			s.last_autovacuum,
+			s.last_analyze,
+			s.last_autoanalyze,
+			s.vacuum_count,
+			s.autovacuum_count,
+			s.analyze_count,
+			s.autoanalyze_count
+		FROM pg_stat_user_tables s
+		WHERE ($1::text = '' OR s.schemaname = $1)
+		ORDER BY s.n_dead_tup DESC, s.schemaname, s.relname
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &stats, query, schema)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_maintenance_stats", "failed to get maintenance statistics")
+	}
+
+	for i := range stats {
+		stats[i].Recommendations = maintenanceRecommendations(stats[i])
+		stats[i].Statement = maintenanceStatement(stats[i])
+	}
+
+	return stats, nil
+}
+
+// maintenanceRecommendations applies the maintenance thresholds to the statistics of a table
+func maintenanceRecommendations(s MaintenanceStats) []MaintenanceRecommendation {
+	recommendations := []MaintenanceRecommendation{}
+
+	if s.DeadTuples >= maintenanceMinDeadTuples && s.DeadTupleRatio() >= maintenanceDeadTupleRatio {
+		recommendations = append(recommendations, MaintenanceRecommendation{
+			Action: MaintenanceVacuum,
+			Reason: fmt.Sprintf("%d dead tuples (%.0f%% of the table)", s.DeadTuples, s.DeadTupleRatio()*100),
+		})
+	}
+
+	switch {
+	case s.LastAnalyze == nil && s.LastAutoanalyze == nil && s.LiveTuples > 0:
+		recommendations = append(recommendations, MaintenanceRecommendation{
+			Action: MaintenanceAnalyze,
+			Reason: "never analyzed",
+		})
+	case s.ModificationsSinceAnalyze >= maintenanceMinModifications &&
+		float64(s.ModificationsSinceAnalyze) >= maintenanceModificationRatio*float64(s.LiveTuples):
+		recommendations = append(recommendations, MaintenanceRecommendation{
+			Action: MaintenanceAnalyze,
+			Reason: fmt.Sprintf("%d rows modified since the last analyze", s.ModificationsSinceAnalyze),
+		})
+	}
+
+	return recommendations
+}
+
+// maintenanceStatement returns the statement running the recommended maintenance of a
+// table. VACUUM (ANALYZE) covers both recommendations.
+func maintenanceStatement(s MaintenanceStats) string {
+	var vacuum, analyze bool
+	for _, r := range s.Recommendations {
+		vacuum = vacuum || r.Action == MaintenanceVacuum
+		analyze = analyze || r.Action == MaintenanceAnalyze
+	}
+
+	table := pq.QuoteIdentifier(s.Schema) + "." + pq.QuoteIdentifier(s.Table)
+	switch {
+	case vacuum && analyze:
+		return "VACUUM (ANALYZE) " + table
+	case vacuum:
+		return "VACUUM " + table
+	case analyze:
+		return "ANALYZE " + table
+	}
+	return ""
+}
+
+// MaintenanceService runs VACUUM and ANALYZE
+type MaintenanceService struct {
+	db *DB
+}
+
+// Maintenance returns the service running VACUUM and ANALYZE on the database
+func (d *DB) Maintenance() *MaintenanceService {
+	return &MaintenanceService{db: d}
+}
+
+// MaintenanceOptions configures Vacuum and Analyze
+type MaintenanceOptions struct {
+	// Tables to process, optionally schema-qualified; by default all tables and
+	// materialized views of Schema, or of all user schemas if empty
+	Tables []string
+	Schema string
+	// Full rewrites the tables with VACUUM FULL, which locks each table exclusively while
+	// it runs; Vacuum only
+	Full bool
+	// Analyze also updates the planner statistics; Vacuum only
+	Analyze bool
+	// Verbose runs the statements with VERBOSE and passes the server's progress messages
+	// to Progress
+	Verbose bool
+	// Jobs is the number of tables processed at once, 1 by default
+	Jobs int
+	// Progress receives the server messages of Verbose runs and the end of each table
+	Progress MaintenanceProgressHandler
+}
+
+// MaintenanceProgress reports a server message or the end of processing a table
+type MaintenanceProgress struct {
+	Table string `json:"table"`
+	// Message is a VERBOSE message of the server, empty when Done
+	Message  string        `json:"message,omitempty"`
+	Done     bool          `json:"done"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// MaintenanceProgressHandler receives maintenance progress. Calls are serialized, also with
+// several jobs, and block the job reporting them.
+type MaintenanceProgressHandler func(progress MaintenanceProgress)
+
+// MaintenanceResult is the outcome of the maintenance of one table
+type MaintenanceResult struct {
+	Table     string        `json:"table"`
+	Statement string        `json:"statement"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Vacuum runs VACUUM on the tables selected by opts, in the given or alphabetical order.
+// Failed tables do not stop the others; they are reported in the results and make the
+// returned error non-nil.
+func (ms *MaintenanceService) Vacuum(ctx context.Context, opts MaintenanceOptions) ([]MaintenanceResult, error) {
+	return ms.run(ctx, "vacuum", opts, func(table string) string {
+		var options []string
+		if opts.Full {
+			options = append(options, "FULL")
+		}
+		if opts.Analyze {
+			options = append(options, "ANALYZE")
+		}
+		if opts.Verbose {
+			options = append(options, "VERBOSE")
+		}
+		return maintenanceCommand("VACUUM", options, table)
+	})
+}
+
+// Analyze runs ANALYZE on the tables selected by opts, see Vacuum
+func (ms *MaintenanceService) Analyze(ctx context.Context, opts MaintenanceOptions) ([]MaintenanceResult, error) {
+	if opts.Full {
+		return nil, NewValidationError("FULL only applies to VACUUM", nil).
+			WithOperation("analyze")
+	}
+	return ms.run(ctx, "analyze", opts, func(table string) string {
+		var options []string
+		if opts.Verbose {
+			options = append(options, "VERBOSE")
+		}
+		return maintenanceCommand("ANALYZE", options, table)
+	})
+}
+
+// maintenanceCommand returns a VACUUM or ANALYZE statement with its options in parentheses
+func maintenanceCommand(command string, options []string, table string) string {
+	if len(options) == 0 {
+		return command + " " + table
+	}
+	return fmt.Sprintf("%s (%s) %s", command, strings.Join(options, ", "), table)
+}
+
+// run executes the statement of each selected table with up to opts.Jobs at once
+func (ms *MaintenanceService) run(ctx context.Context, op string, opts MaintenanceOptions, statement func(table string) string) ([]MaintenanceResult, error) {
+	if opts.Jobs < 0 {
+		return nil, NewValidationError(fmt.Sprintf("invalid number of jobs %d", opts.Jobs), nil).
+			WithContext("jobs", opts.Jobs).
+			WithOperation(op)
+	}
+
+	tables := opts.Tables
+	if len(tables) == 0 {
+		var err error
+		if tables, err = ms.tables(ctx, opts.Schema); err != nil {
+			return nil, WrapError(err, ErrCodeQueryFailed, op, "failed to list tables")
+		}
+	}
+	results := make([]MaintenanceResult, len(tables))
+	for i, table := range tables {
+		quoted, err := quoteQualifiedName(table)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = MaintenanceResult{Table: table, Statement: statement(quoted)}
+	}
+
+	var mu sync.Mutex
+	report := func(progress MaintenanceProgress) {
+		if opts.Progress == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		opts.Progress(progress)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(opts.Jobs, 1))
+	for i := range results {
+		result := &results[i]
+		g.Go(func() error {
+			start := time.Now()
+			err := ms.exec(gctx, result.Statement, opts.Verbose, func(message string) {
+				report(MaintenanceProgress{Table: result.Table, Message: message})
+			})
+			result.Duration = time.Since(start)
+			progress := MaintenanceProgress{Table: result.Table, Done: true, Duration: result.Duration}
+			if err != nil {
+				result.Error = err.Error()
+				progress.Error = result.Error
+				ms.db.logger.Warn("maintenance failed",
+					slog.String("statement", result.Statement),
+					slog.Any("error", err))
+			}
+			report(progress)
+			// Failed tables do not cancel the others
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var failed []string
+	for _, result := range results {
+		if result.Error != "" {
+			failed = append(failed, result.Table)
+		}
+	}
+	if len(failed) > 0 {
+		return results, NewDBError(ErrCodeQueryFailed, fmt.Sprintf("%s failed for %d of %d tables", op, len(failed), len(results)), nil).
+			WithContext("tables", failed).
+			WithOperation(op)
+	}
+	return results, nil
+}
+
+// exec runs a maintenance statement. Verbose statements run on their own connection, so the
+// server messages of each table go to its notice handler.
+func (ms *MaintenanceService) exec(ctx context.Context, statement string, verbose bool, notice func(message string)) error {
+	if !verbose {
+		_, err := ms.db.db.ExecContext(ctx, statement)
+		return err
+	}
+
+	connector, err := pq.NewConnector(ms.db.config.ConnectionString())
+	if err != nil {
+		return err
+	}
+	conn := sql.OpenDB(pq.ConnectorWithNoticeHandler(connector, func(e *pq.Error) {
+		notice(e.Message)
+	}))
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, statement)
+	return err
+}
+
+// tables lists the tables and materialized views of schema, or of all user schemas if
+// empty. Partitioned tables are left out, their partitions are listed instead.
+func (ms *MaintenanceService) tables(ctx context.Context, schema string) ([]string, error) {
+	var tables []string
+	err := ms.db.WithValidation(ctx, func() error {
+		return ms.db.db.SelectContext(ctx, &tables, `
+			SELECT n.nspname || '.' || c.relname
+			FROM pg_catalog.pg_class c
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relkind IN ('r', 'm')
+				AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+				AND n.nspname NOT LIKE 'pg_toast%'
+				AND n.nspname NOT LIKE 'pg_temp%'
+				AND ($1::text = '' OR n.nspname = $1)
+			ORDER BY 1`, schema)
+	})
+	return tables, err
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceRecommendations(t *testing.T) {
+	analyzed := time.Now()
+
+	testCases := []struct {
+		name      string
+		stats     MaintenanceStats
+		actions   []string
+		statement string
+	}{
+		{
+			name:  "healthy",
+			stats: MaintenanceStats{LiveTuples: 100000, DeadTuples: 500, ModificationsSinceAnalyze: 2000, LastAutoanalyze: &analyzed},
+		},
+		{
+			name:      "dead tuples",
+			stats:     MaintenanceStats{LiveTuples: 100000, DeadTuples: 25000, LastAnalyze: &analyzed},
+			actions:   []string{MaintenanceVacuum},
+			statement: `VACUUM "public"."orders"`,
+		},
+		{
+			name:  "few dead tuples in a small table",
+			stats: MaintenanceStats{LiveTuples: 100, DeadTuples: 900, LastAnalyze: &analyzed},
+		},
+		{
+			name:      "modified since analyze",
+			stats:     MaintenanceStats{LiveTuples: 100000, ModificationsSinceAnalyze: 15000, LastAutoanalyze: &analyzed},
+			actions:   []string{MaintenanceAnalyze},
+			statement: `ANALYZE "public"."orders"`,
+		},
+		{
+			name:      "never analyzed with dead tuples",
+			stats:     MaintenanceStats{LiveTuples: 10000, DeadTuples: 5000},
+			actions:   []string{MaintenanceVacuum, MaintenanceAnalyze},
+			statement: `VACUUM (ANALYZE) "public"."orders"`,
+		},
+		{
+			name:  "empty",
+			stats: MaintenanceStats{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stats := tc.stats
+			stats.Schema, stats.Table = "public", "orders"
+			stats.Recommendations = maintenanceRecommendations(stats)
+
+			var actions []string
+			for _, r := range stats.Recommendations {
+				assert.NotEmpty(t, r.Reason)
+				actions = append(actions, r.Action)
+			}
+			assert.Equal(t, tc.actions, actions)
+			assert.Equal(t, tc.statement, maintenanceStatement(stats))
+		})
+	}
+}
+
+func TestDeadTupleRatio(t *testing.T) {
+	assert.Equal(t, 0.0, MaintenanceStats{}.DeadTupleRatio())
+	assert.Equal(t, 0.25, MaintenanceStats{LiveTuples: 300, DeadTuples: 100}.DeadTupleRatio())
+}
+
+func TestMaintenanceCommand(t *testing.T) {
+	assert.Equal(t, `VACUUM "public"."users"`, maintenanceCommand("VACUUM", nil, `"public"."users"`))
+	assert.Equal(t, `VACUUM (FULL, ANALYZE) "users"`, maintenanceCommand("VACUUM", []string{"FULL", "ANALYZE"}, `"users"`))
+}
+
+func TestMaintenanceValidation(t *testing.T) {
+	ms := (&DB{}).Maintenance()
+	ctx := context.Background()
+
+	_, err := ms.Analyze(ctx, MaintenanceOptions{Tables: []string{"users"}, Full: true})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+
+	_, err = ms.Vacuum(ctx, MaintenanceOptions{Tables: []string{"users"}, Jobs: -1})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+
+	_, err = ms.Vacuum(ctx, MaintenanceOptions{Tables: []string{"public..users"}})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestVacuumAndAnalyze(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+	ctx := context.Background()
+	defer db.db.ExecContext(ctx, "DROP TABLE IF EXISTS test_vacuum_a, test_vacuum_b")
+
+	_, err := db.db.ExecContext(ctx, `
+		CREATE TABLE test_vacuum_a AS SELECT generate_series(1, 1000) AS id;
+		CREATE TABLE test_vacuum_b AS SELECT generate_series(1, 1000) AS id;
+		DELETE FROM test_vacuum_a WHERE id % 2 = 0`)
+	require.NoError(t, err)
+
+	var messages []MaintenanceProgress
+	results, err := db.Maintenance().Vacuum(ctx, MaintenanceOptions{
+		Tables:   []string{"public.test_vacuum_a", "test_vacuum_b"},
+		Analyze:  true,
+		Verbose:  true,
+		Jobs:     2,
+		Progress: func(progress MaintenanceProgress) { messages = append(messages, progress) },
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, `VACUUM (ANALYZE, VERBOSE) "public"."test_vacuum_a"`, results[0].Statement)
+	assert.NotEmpty(t, messages)
+
+	results, err = db.Maintenance().Analyze(ctx, MaintenanceOptions{Tables: []string{"test_vacuum_b", "test_vacuum_missing"}})
+	require.Error(t, err)
+	require.Len(t, results, 2)
+	assert.Empty(t, results[0].Error)
+	assert.NotEmpty(t, results[1].Error)
+}
@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// manifestSuffix is appended to a backup path to name its manifest
+const manifestSuffix = ".manifest.json"
+
+// BackupManifest describes a backup and is written alongside it
+type BackupManifest struct {
+	Timestamp     time.Time     `json:"timestamp"`
+	Database      string        `json:"database"`
+	File          string        `json:"file"`
+	Size          int64         `json:"size"`
+	SHA256        string        `json:"sha256"`
+	PgDumpVersion string        `json:"pg_dump_version,omitempty"`
+	Options       BackupOptions `json:"options"`
+	// Tables is the number of tables in the database when it was backed up, recorded
+	// for full backups so VerifyBackup can compare the restored database
+	Tables int `json:"tables,omitempty"`
+}
+
+// ManifestPath returns the path of the manifest for the backup at backupPath
+func ManifestPath(backupPath string) string {
+	return strings.TrimSuffix(backupPath, string(filepath.Separator)) + manifestSuffix
+}
+
+// isManifest reports whether a path or storage key names a backup manifest
+func isManifest(key string) bool {
+	return strings.HasSuffix(key, manifestSuffix)
+}
+
+// ReadBackupManifest reads the manifest of the backup at backupPath
+func ReadBackupManifest(backupPath string) (*BackupManifest, error) {
+	data, err := os.ReadFile(ManifestPath(backupPath))
+	if err != nil {
+		return nil, NewDBError(ErrCodeInvalidBackupFile, "failed to read backup manifest", err).
+			WithContext("backup_path", backupPath).
+			WithOperation("read_backup_manifest")
+	}
+
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, NewDBError(ErrCodeInvalidBackupFile, "invalid backup manifest", err).
+			WithContext("backup_path", backupPath).
+			WithOperation("read_backup_manifest")
+	}
+	return &manifest, nil
+}
+
+// readStoredManifest reads the manifest stored next to key, or returns nil if there is none
+func readStoredManifest(ctx context.Context, storage BackupStorage, key string) *BackupManifest {
+	reader, err := storage.Get(ctx, key+manifestSuffix)
+	if err != nil {
+		return nil
+	}
+	defer reader.Close()
+
+	var manifest BackupManifest
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return nil
+	}
+	return &manifest
+}
+
+// writeBackupManifest checksums the backup at backupPath and writes its manifest
+func writeBackupManifest(ctx context.Context, config Config, backupPath string, opts BackupOptions) (*BackupManifest, error) {
+	size, sum, err := checksumBackup(backupPath)
+	if err != nil {
+		return nil, NewBackupError("failed to checksum backup", err).
+			WithContext("backup_path", backupPath).
+			WithOperation("write_backup_manifest")
+	}
+
+	manifest := &BackupManifest{
+		Timestamp:     time.Now(),
+		Database:      config.DBName,
+		File:          filepath.Base(backupPath),
+		Size:          size,
+		SHA256:        sum,
+		PgDumpVersion: pgDumpVersion(ctx, config),
+		Options:       opts,
+	}
+	if opts.fullDump() {
+		// The table count is informational; a backup is still usable without it
+		if tables, err := countTables(ctx, config); err == nil {
+			manifest.Tables = tables
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, NewBackupError("failed to encode backup manifest", err).
+			WithOperation("write_backup_manifest")
+	}
+	if err := os.WriteFile(ManifestPath(backupPath), data, 0o644); err != nil {
+		return nil, NewBackupError("failed to write backup manifest", err).
+			WithContext("backup_path", backupPath).
+			WithOperation("write_backup_manifest")
+	}
+	return manifest, nil
+}
+
+// VerifyBackupChecksum compares the size and SHA-256 of the backup at backupPath with its
+// manifest. A missing manifest is reported as an error wrapping fs.ErrNotExist.
+func VerifyBackupChecksum(backupPath string) error {
+	manifest, err := ReadBackupManifest(backupPath)
+	if err != nil {
+		return err
+	}
+
+	size, sum, err := checksumBackup(backupPath)
+	if err != nil {
+		return NewDBError(ErrCodeInvalidBackupFile, "failed to checksum backup", err).
+			WithContext("backup_path", backupPath).
+			WithOperation("verify_backup_checksum")
+	}
+
+	if size != manifest.Size {
+		return NewDBError(ErrCodeInvalidBackupFile,
+			fmt.Sprintf("backup is %d bytes, manifest records %d; the file may be truncated", size, manifest.Size), nil).
+			WithContext("backup_path", backupPath).
+			WithOperation("verify_backup_checksum")
+	}
+	if sum != manifest.SHA256 {
+		return NewDBError(ErrCodeInvalidBackupFile, "backup checksum does not match its manifest", nil).
+			WithContext("backup_path", backupPath).
+			WithContext("expected_sha256", manifest.SHA256).
+			WithContext("actual_sha256", sum).
+			WithOperation("verify_backup_checksum")
+	}
+	return nil
+}
+
+// checksumBackup returns the size and hex SHA-256 of a backup file. Directory format
+// backups are hashed over the relative names and contents of their files in sorted order.
+func checksumBackup(backupPath string) (int64, string, error) {
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		return 0, "", err
+	}
+
+	h := sha256.New()
+	if !info.IsDir() {
+		size, err := hashFile(h, backupPath)
+		return size, hex.EncodeToString(h.Sum(nil)), err
+	}
+
+	var files []string
+	err = filepath.WalkDir(backupPath, func(p string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files = append(files, p)
+		}
+		return err
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	sort.Strings(files)
+
+	var total int64
+	for _, file := range files {
+		rel, _ := filepath.Rel(backupPath, file)
+		fmt.Fprintf(h, "%s\x00", filepath.ToSlash(rel))
+		size, err := hashFile(h, file)
+		if err != nil {
+			return 0, "", err
+		}
+		total += size
+	}
+	return total, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(h hash.Hash, path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return io.Copy(h, file)
+}
+
+// pgDumpVersion returns the output of pg_dump --version, or "" if it cannot be run
+func pgDumpVersion(ctx context.Context, config Config) string {
+	out, err := exec.CommandContext(ctx, pgTool(config, "pg_dump"), "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// verifyBeforeRestore refuses backups whose manifest does not match. Backups without a
+// manifest, e.g. taken by other tools, are restored unverified.
+func verifyBeforeRestore(backupPath string) error {
+	if err := VerifyBackupChecksum(backupPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupManifest(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "backup_app_20250101_030000.sql.gz")
+	if err := os.WriteFile(path, []byte("-- backup contents\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := BackupOptions{Compression: CompressionGzip}
+	manifest, err := writeBackupManifest(ctx, Config{DBName: "app"}, path, opts)
+	if err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	if manifest.Size != 19 || len(manifest.SHA256) != 64 || manifest.File != filepath.Base(path) {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+
+	read, err := ReadBackupManifest(path)
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	if read.SHA256 != manifest.SHA256 || read.Database != "app" || read.Options.Compression != CompressionGzip {
+		t.Errorf("Manifest did not round-trip: %+v", read)
+	}
+
+	if err := VerifyBackupChecksum(path); err != nil {
+		t.Errorf("Expected intact backup to verify, got %v", err)
+	}
+
+	// Same size, different contents
+	if err := os.WriteFile(path, []byte("-- backup CONTENTS\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyBackupChecksum(path); !errors.Is(err, NewDBError(ErrCodeInvalidBackupFile, "", nil)) {
+		t.Errorf("Expected checksum mismatch, got %v", err)
+	}
+	if err := verifyBeforeRestore(path); err == nil {
+		t.Error("Expected restore to refuse a corrupt backup")
+	}
+
+	// Truncated
+	if err := os.WriteFile(path, []byte("-- backup"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyBackupChecksum(path); err == nil {
+		t.Error("Expected truncated backup to fail verification")
+	}
+
+	// No manifest
+	if err := os.Remove(ManifestPath(path)); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyBackupChecksum(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected missing manifest error, got %v", err)
+	}
+	if err := verifyBeforeRestore(path); err != nil {
+		t.Errorf("Expected backups without a manifest to restore unverified, got %v", err)
+	}
+}
+
+func TestChecksumDirectoryBackup(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"toc.dat": "PGDMP", "3001.dat.gz": "data"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size, sum, err := checksumBackup(dir)
+	if err != nil {
+		t.Fatalf("Checksum failed: %v", err)
+	}
+	if size != 9 {
+		t.Errorf("Expected total size 9, got %d", size)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "3001.dat.gz"), []byte("DATA"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, changed, _ := checksumBackup(dir); changed == sum {
+		t.Error("Expected checksum to change with file contents")
+	}
+}
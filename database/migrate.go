@@ -3,16 +3,51 @@ package database
 import (
 	"context"
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/pressly/goose/v3"
 )
 
+const (
+	// DefaultMigrationsTable is the goose version table used for schema migrations
+	DefaultMigrationsTable = "goose_db_version"
+	// DefaultSeedsTable is the goose version table used for seed migrations
+	DefaultSeedsTable = "goose_seed_version"
+)
+
+// gooseMu serializes access to goose's package-level state such as the version table name
+var gooseMu sync.Mutex
+
+// MigrationSource describes a set of migrations tracked in their own version table,
+// e.g. a plugin's schema living next to the core application schema
+type MigrationSource struct {
+	Name  string // identifier used in logs and errors
+	Dir   string // directory containing the migrations (relative to FS when set)
+	FS    fs.FS  // optional filesystem such as an embed.FS; defaults to the OS filesystem
+	Table string // goose version table for this source
+}
+
+// SchemaMigrationResult reports the outcome of applying migrations to a single schema
+type SchemaMigrationResult struct {
+	Schema   string        `json:"schema"`
+	Version  int64         `json:"version"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
 // MigrationStatus represents the status of a single migration
 type MigrationStatus struct {
 	Version     int64     `json:"version"`
@@ -31,6 +66,16 @@ type MigrationStatusResult struct {
 	Applied    int               `json:"applied_count"`
 }
 
+// PendingMigration describes a migration that would be applied by Up, or rolled back
+// by Down, DownTo or Reset
+type PendingMigration struct {
+	Version       int64  `json:"version"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Size          int64  `json:"size"`
+	Transactional bool   `json:"transactional"`
+}
+
 // Migrator is an interface that interacts with the database migrations
 type Migrator interface {
 	// Apply migrations to the database
@@ -41,6 +86,16 @@ type Migrator interface {
 	Reset(ctx context.Context) error
 	// Get the status of the migrations
 	Status(ctx context.Context) (*MigrationStatusResult, error)
+	// Get the migrations Up would apply, in order
+	Pending(ctx context.Context) ([]PendingMigration, error)
+	// Get the migrations DownTo would roll back, newest first
+	RollbackPlan(ctx context.Context, version int64) ([]PendingMigration, error)
+	// Get the version that was current at a point in time
+	VersionAt(ctx context.Context, t time.Time) (int64, error)
+	// Set the handler that receives per-migration progress events
+	SetEventHandler(handler MigrationEventHandler)
+	// Set the notifier called after Up, Down and Reset
+	SetNotifier(notifier Notifier)
 	// Create a new migration file
 	NewMigration(ctx context.Context, name, migrationType string) error
 	// Get the source of the migrations
@@ -57,6 +112,8 @@ type Migrator interface {
 	DownTo(ctx context.Context, version int64) error
 	// Rollback one migration
 	DownByOne(ctx context.Context) error
+	// Rollback every migration applied after a point in time
+	DownToTime(ctx context.Context, t time.Time) error
 	// Get migration version information
 	Version(ctx context.Context) (int64, error)
 	// Apply multiple migrations in a transaction
@@ -65,12 +122,55 @@ type Migrator interface {
 	DownInTransaction(ctx context.Context, versions ...int64) error
 	// Validate migrations before applying
 	Validate(ctx context.Context) error
+	// Syntax-check the statements of pending SQL migrations without executing them
+	CheckPending(ctx context.Context) ([]MigrationCheck, error)
+
+	// Seed operations, versioned independently from the schema migrations
+	// Apply all pending seeds
+	SeedUp(ctx context.Context) error
+	// Rollback the most recently applied seed
+	SeedDown(ctx context.Context) error
+	// Get the source of the seeds
+	SeedsSource() string
+	// Set the source of the seeds
+	SetSeedsSource(source string)
+
+	// Multiple migration sources
+	// Register an additional migration source, applied after the primary one
+	AddSource(source MigrationSource) error
+	// Get all migration sources in the order they are applied
+	Sources() []MigrationSource
+	// Apply all sources in order
+	UpAll(ctx context.Context) error
+	// Reset all sources in reverse order
+	ResetAll(ctx context.Context) error
+
+	// Apply migrations to every schema matching a glob pattern (schema-per-tenant)
+	UpAllSchemas(ctx context.Context, schemaPattern string) ([]SchemaMigrationResult, error)
+
+	// Apply all migrations to a freshly recreated shadow database before the target
+	VerifyOnShadow(ctx context.Context, shadowConfig Config) error
 }
 
 // GooseMigrator is a concrete implementation of the Migrator interface
 type GooseMigrator struct {
 	db            *sqlx.DB
 	migrationsDir string
+	seedsDir      string
+	sources       []MigrationSource
+
+	// Set when created through New; required for per-schema connections
+	config      *Config
+	listSchemas func(ctx context.Context) ([]string, error)
+	// Set when created through New; Go migrations run through its hooks
+	owner *DB
+
+	logger   *slog.Logger
+	events   MigrationEventHandler
+	notifier Notifier
+
+	// Version table for the primary migrations, DefaultMigrationsTable when empty
+	table string
 }
 
 // NewGooseMigrator creates a new GooseMigrator
@@ -78,19 +178,134 @@ func NewGooseMigrator(db *sqlx.DB, migrationsDir string) *GooseMigrator {
 	return &GooseMigrator{db: db, migrationsDir: migrationsDir}
 }
 
+// versionTableName matches a table name, optionally qualified with a schema
+var versionTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// validateVersionTable checks that a version table name is a plain or schema-qualified
+// identifier; goose interpolates it into its queries unquoted
+func validateVersionTable(table string) error {
+	if table != "" && !versionTableName.MatchString(table) {
+		return NewValidationError(fmt.Sprintf("invalid migrations table %q, expected table or schema.table", table), nil).
+			WithContext("table", table).
+			WithOperation("validate_version_table")
+	}
+	return nil
+}
+
+// unqualifiedTable strips the schema from a version table name
+func unqualifiedTable(table string) string {
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		return table[i+1:]
+	}
+	return table
+}
+
+// SetVersionTable sets the version table for the primary migrations, e.g.
+// "infra.schema_migrations"; an empty name selects DefaultMigrationsTable
+func (migrator *GooseMigrator) SetVersionTable(table string) error {
+	if err := validateVersionTable(table); err != nil {
+		return err
+	}
+	migrator.table = table
+	return nil
+}
+
+// versionTable returns the version table for the primary migrations
+func (migrator *GooseMigrator) versionTable() string {
+	if migrator.table == "" {
+		return DefaultMigrationsTable
+	}
+	return migrator.table
+}
+
+// gooseContext returns ctx for goose, carrying the DB whose hooks the transactions of
+// Go migrations run through
+func (migrator *GooseMigrator) gooseContext(ctx context.Context) context.Context {
+	return withMigrationDB(ctx, migrator.owner)
+}
+
+// withGooseTable runs fn while goose is pointed at the given version table
+func withGooseTable(table string, fn func() error) error {
+	return withGooseSource(MigrationSource{Table: table}, fn)
+}
+
+// withGooseSource runs fn while goose is pointed at the source's version table and filesystem
+func withGooseSource(source MigrationSource, fn func() error) error {
+	gooseMu.Lock()
+	defer gooseMu.Unlock()
+
+	previous := goose.TableName()
+	goose.SetTableName(source.Table)
+	defer goose.SetTableName(previous)
+
+	// A nil FS makes goose fall back to the OS filesystem
+	goose.SetBaseFS(source.FS)
+	defer goose.SetBaseFS(nil)
+
+	return fn()
+}
+
 // Up applies the migrations to the database
+// Each migration is applied separately so progress events can be emitted between them.
 func (migrator *GooseMigrator) Up(ctx context.Context) error {
-	return goose.UpContext(ctx, migrator.db.DB, migrator.migrationsDir)
+	return migrator.withNotification(ctx, "up", func() error {
+		return migrator.up(ctx)
+	})
+}
+
+func (migrator *GooseMigrator) up(ctx context.Context) error {
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i, m := range pending {
+		event := MigrationEvent{
+			Version:   m.Version,
+			Name:      m.Name,
+			Direction: "up",
+			Index:     i + 1,
+			Total:     len(pending),
+		}
+
+		event.Type = MigrationStarted
+		migrator.emit(event)
+
+		start := time.Now()
+		err := withGooseTable(migrator.versionTable(), func() error {
+			return goose.UpToContext(migrator.gooseContext(ctx), migrator.db.DB, migrator.migrationsDir, m.Version)
+		})
+		event.Duration = time.Since(start)
+
+		if err != nil {
+			event.Type = MigrationFailed
+			event.Error = err
+			migrator.emit(event)
+			return err
+		}
+
+		event.Type = MigrationFinished
+		migrator.emit(event)
+	}
+	return nil
 }
 
 // Down rolls back the migrations to the database
 func (migrator *GooseMigrator) Down(ctx context.Context) error {
-	return goose.DownContext(ctx, migrator.db.DB, migrator.migrationsDir)
+	return migrator.withNotification(ctx, "down", func() error {
+		return withGooseTable(migrator.versionTable(), func() error {
+			return goose.DownContext(migrator.gooseContext(ctx), migrator.db.DB, migrator.migrationsDir)
+		})
+	})
 }
 
 // Reset resets the database to the initial state
 func (migrator *GooseMigrator) Reset(ctx context.Context) error {
-	return goose.ResetContext(ctx, migrator.db.DB, migrator.migrationsDir)
+	return migrator.withNotification(ctx, "reset", func() error {
+		return withGooseTable(migrator.versionTable(), func() error {
+			return goose.ResetContext(migrator.gooseContext(ctx), migrator.db.DB, migrator.migrationsDir)
+		})
+	})
 }
 
 // Status gets the status of the migrations
@@ -103,7 +318,12 @@ func (migrator *GooseMigrator) Status(ctx context.Context) (*MigrationStatusResu
 	}
 
 	// Get current database version
-	currentVersion, err := goose.GetDBVersionContext(ctx, migrator.db.DB)
+	var currentVersion int64
+	err = withGooseTable(migrator.versionTable(), func() error {
+		var versionErr error
+		currentVersion, versionErr = goose.GetDBVersionContext(ctx, migrator.db.DB)
+		return versionErr
+	})
 	if err != nil {
 		return nil, NewMigrationError("failed to get current version", err).
 			WithOperation("get_status")
@@ -142,11 +362,11 @@ func (migrator *GooseMigrator) Status(ctx context.Context) (*MigrationStatusResu
 		isApplied := false
 		var appliedAt time.Time
 
-		// Query the goose_db_version table to check if migration is applied
+		// Query the version table to check if migration is applied
 		var dbVersion int64
 		var tstamp time.Time
 		err = migrator.db.QueryRowContext(ctx,
-			"SELECT version_id, tstamp FROM goose_db_version WHERE version_id = $1",
+			"SELECT version_id, tstamp FROM "+migrator.versionTable()+" WHERE version_id = $1",
 			version).Scan(&dbVersion, &tstamp)
 
 		if err == nil {
@@ -189,7 +409,9 @@ func (migrator *GooseMigrator) Status(ctx context.Context) (*MigrationStatusResu
 // NewMigration creates a new migration file
 func (migrator *GooseMigrator) NewMigration(ctx context.Context, name, migrationType string) error {
 	// goose.Create doesn't have a context version, but it's a quick file operation
-	return goose.Create(migrator.db.DB, migrator.migrationsDir, name, migrationType)
+	return withGooseTable(migrator.versionTable(), func() error {
+		return goose.Create(migrator.db.DB, migrator.migrationsDir, name, migrationType)
+	})
 }
 
 // Source gets the source of the migrations
@@ -204,7 +426,9 @@ func (migrator *GooseMigrator) SetSource(source string) {
 
 // UpTo applies migrations up to a specific version
 func (migrator *GooseMigrator) UpTo(ctx context.Context, version int64) error {
-	err := goose.UpToContext(ctx, migrator.db.DB, migrator.migrationsDir, version)
+	err := withGooseTable(migrator.versionTable(), func() error {
+		return goose.UpToContext(migrator.gooseContext(ctx), migrator.db.DB, migrator.migrationsDir, version)
+	})
 	if err != nil {
 		return NewMigrationError(fmt.Sprintf("failed to migrate up to version %d", version), err).
 			WithContext("target_version", version).
@@ -215,7 +439,9 @@ func (migrator *GooseMigrator) UpTo(ctx context.Context, version int64) error {
 
 // UpByOne applies one migration
 func (migrator *GooseMigrator) UpByOne(ctx context.Context) error {
-	err := goose.UpByOneContext(ctx, migrator.db.DB, migrator.migrationsDir)
+	err := withGooseTable(migrator.versionTable(), func() error {
+		return goose.UpByOneContext(migrator.gooseContext(ctx), migrator.db.DB, migrator.migrationsDir)
+	})
 	if err != nil {
 		return NewMigrationError("failed to migrate up by one", err).
 			WithOperation("migrate_up_by_one")
@@ -225,7 +451,9 @@ func (migrator *GooseMigrator) UpByOne(ctx context.Context) error {
 
 // DownTo rolls back migrations to a specific version
 func (migrator *GooseMigrator) DownTo(ctx context.Context, version int64) error {
-	err := goose.DownToContext(ctx, migrator.db.DB, migrator.migrationsDir, version)
+	err := withGooseTable(migrator.versionTable(), func() error {
+		return goose.DownToContext(migrator.gooseContext(ctx), migrator.db.DB, migrator.migrationsDir, version)
+	})
 	if err != nil {
 		return NewMigrationError(fmt.Sprintf("failed to migrate down to version %d", version), err).
 			WithContext("target_version", version).
@@ -236,7 +464,9 @@ func (migrator *GooseMigrator) DownTo(ctx context.Context, version int64) error
 
 // DownByOne rolls back one migration
 func (migrator *GooseMigrator) DownByOne(ctx context.Context) error {
-	err := goose.DownContext(ctx, migrator.db.DB, migrator.migrationsDir)
+	err := withGooseTable(migrator.versionTable(), func() error {
+		return goose.DownContext(migrator.gooseContext(ctx), migrator.db.DB, migrator.migrationsDir)
+	})
 	if err != nil {
 		return NewMigrationError("failed to migrate down by one", err).
 			WithOperation("migrate_down_by_one")
@@ -244,9 +474,194 @@ func (migrator *GooseMigrator) DownByOne(ctx context.Context) error {
 	return nil
 }
 
+// DownToTime rolls back every migration applied after t, leaving the database at the
+// version that was current at that moment
+func (migrator *GooseMigrator) DownToTime(ctx context.Context, t time.Time) error {
+	version, err := migrator.VersionAt(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	err = withGooseTable(migrator.versionTable(), func() error {
+		return goose.DownToContext(migrator.gooseContext(ctx), migrator.db.DB, migrator.migrationsDir, version)
+	})
+	if err != nil {
+		return NewMigrationError(fmt.Sprintf("failed to migrate down to %s", t.Format(time.RFC3339)), err).
+			WithContext("target_time", t).
+			WithContext("target_version", version).
+			WithOperation("migrate_down_to_time")
+	}
+	return nil
+}
+
+// VersionAt returns the most recent version that was applied at or before t, or 0
+// when nothing had been applied yet
+func (migrator *GooseMigrator) VersionAt(ctx context.Context, t time.Time) (int64, error) {
+	var version int64
+	err := migrator.db.QueryRowContext(ctx,
+		"SELECT COALESCE((SELECT version_id FROM "+migrator.versionTable()+
+			" WHERE is_applied AND version_id > 0 AND tstamp <= ($1::timestamptz AT TIME ZONE current_setting('TimeZone')) ORDER BY id DESC LIMIT 1), 0)",
+		t).Scan(&version)
+	if err != nil {
+		return 0, NewMigrationError("failed to resolve version at time", err).
+			WithContext("target_time", t).
+			WithOperation("migrate_down_to_time")
+	}
+	return version, nil
+}
+
+// Pending returns the migrations that Up would apply, in the order they would run
+func (migrator *GooseMigrator) Pending(ctx context.Context) ([]PendingMigration, error) {
+	current, err := migrator.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations goose.Migrations
+	err = withGooseTable(migrator.versionTable(), func() error {
+		var collectErr error
+		migrations, collectErr = goose.CollectMigrations(migrator.migrationsDir, current, goose.MaxVersion)
+		return collectErr
+	})
+	if err != nil {
+		return nil, NewMigrationError("failed to collect pending migrations", err).
+			WithContext("migrations_dir", migrator.migrationsDir).
+			WithOperation("migrate_pending")
+	}
+
+	if err := migrator.checkMissing(ctx, current); err != nil {
+		return nil, err
+	}
+
+	pending := make([]PendingMigration, 0, len(migrations))
+	for _, m := range migrations {
+		p, err := describeMigration(m, "migrate_pending")
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
+
+// checkMissing fails when migrations with a version below current were never applied,
+// e.g. files merged from another branch, since Up only applies the versions above current
+func (migrator *GooseMigrator) checkMissing(ctx context.Context, current int64) error {
+	if current <= 0 {
+		return nil
+	}
+
+	var migrations goose.Migrations
+	err := withGooseTable(migrator.versionTable(), func() error {
+		var collectErr error
+		migrations, collectErr = goose.CollectMigrations(migrator.migrationsDir, 0, current)
+		return collectErr
+	})
+	if err != nil {
+		return NewMigrationError("failed to collect applied migrations", err).
+			WithContext("migrations_dir", migrator.migrationsDir).
+			WithOperation("migrate_pending")
+	}
+
+	var applied []int64
+	err = migrator.db.SelectContext(ctx, &applied,
+		"SELECT DISTINCT version_id FROM "+migrator.versionTable()+" WHERE is_applied")
+	if err != nil {
+		return NewMigrationError("failed to read applied migrations", err).
+			WithOperation("migrate_pending")
+	}
+
+	missing := missingVersions(migrations, applied)
+	if len(missing) == 0 {
+		return nil
+	}
+	versions := make([]string, len(missing))
+	for i, version := range missing {
+		versions[i] = strconv.FormatInt(version, 10)
+	}
+	return NewValidationError(fmt.Sprintf("migrations %s are older than the current version %d and were never applied; renumber them above %d",
+		strings.Join(versions, ", "), current, current), nil).
+		WithContext("versions", missing).
+		WithContext("current_version", current).
+		WithOperation("migrate_pending")
+}
+
+// missingVersions returns the versions of migrations that are not in applied, in order
+func missingVersions(migrations goose.Migrations, applied []int64) []int64 {
+	var missing []int64
+	for _, m := range migrations {
+		if !slices.Contains(applied, m.Version) {
+			missing = append(missing, m.Version)
+		}
+	}
+	return missing
+}
+
+// RollbackPlan returns the migrations that DownTo(version) would roll back, newest
+// first; RollbackPlan(0) is what Reset rolls back and its first entry what Down does
+func (migrator *GooseMigrator) RollbackPlan(ctx context.Context, version int64) ([]PendingMigration, error) {
+	current, err := migrator.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current <= version {
+		return []PendingMigration{}, nil
+	}
+
+	var migrations goose.Migrations
+	err = withGooseTable(migrator.versionTable(), func() error {
+		var collectErr error
+		// CollectMigrations keeps the versions above its lower bound
+		migrations, collectErr = goose.CollectMigrations(migrator.migrationsDir, version, current)
+		return collectErr
+	})
+	if err != nil {
+		return nil, NewMigrationError("failed to collect applied migrations", err).
+			WithContext("migrations_dir", migrator.migrationsDir).
+			WithOperation("migrate_rollback_plan")
+	}
+
+	plan := make([]PendingMigration, 0, len(migrations))
+	for i := len(migrations) - 1; i >= 0; i-- {
+		p, err := describeMigration(migrations[i], "migrate_rollback_plan")
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, p)
+	}
+	return plan, nil
+}
+
+// describeMigration returns the plan entry of a migration, reading SQL migrations for
+// their size and transaction mode
+func describeMigration(m *goose.Migration, op string) (PendingMigration, error) {
+	p := PendingMigration{
+		Version:       m.Version,
+		Name:          filepath.Base(m.Source),
+		Type:          string(m.Type),
+		Transactional: m.UseTx,
+	}
+	if m.Type == goose.TypeSQL {
+		content, err := os.ReadFile(m.Source)
+		if err != nil {
+			return p, NewMigrationError("failed to read migration file", err).
+				WithContext("file", m.Source).
+				WithOperation(op)
+		}
+		p.Size = int64(len(content))
+		p.Transactional = !strings.Contains(string(content), "+goose NO TRANSACTION")
+	}
+	return p, nil
+}
+
 // Version gets the current migration version
 func (migrator *GooseMigrator) Version(ctx context.Context) (int64, error) {
-	version, err := goose.GetDBVersionContext(ctx, migrator.db.DB)
+	var version int64
+	err := withGooseTable(migrator.versionTable(), func() error {
+		var versionErr error
+		version, versionErr = goose.GetDBVersionContext(ctx, migrator.db.DB)
+		return versionErr
+	})
 	if err != nil {
 		return 0, NewMigrationError("failed to get database version", err).
 			WithOperation("get_version")
@@ -339,11 +754,330 @@ func (migrator *GooseMigrator) Validate(ctx context.Context) error {
 			WithOperation("validate_migrations")
 	}
 
+	// A schema-qualified version table needs its schema; goose only creates the table
+	if table := migrator.versionTable(); table != unqualifiedTable(table) {
+		schema := strings.TrimSuffix(table, "."+unqualifiedTable(table))
+		var exists bool
+		err := migrator.db.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = $1)", schema).Scan(&exists)
+		if err != nil {
+			return NewValidationError("failed to check migrations table schema", err).
+				WithContext("schema", schema).
+				WithOperation("validate_migrations")
+		}
+		if !exists {
+			return NewValidationError(fmt.Sprintf("schema %q for migrations table %q does not exist", schema, table), nil).
+				WithContext("schema", schema).
+				WithOperation("validate_migrations")
+		}
+	}
+
 	// Check current version to ensure the database is accessible
 	_, err := migrator.Version(ctx)
 	if err != nil {
 		return WrapError(err, ErrCodeValidation, "validate_migrations", "failed to validate database connection")
 	}
 
+	// Syntax-check pending SQL migrations so broken files fail before anything executes
+	checks, err := migrator.CheckPending(ctx)
+	if err != nil {
+		return WrapError(err, ErrCodeValidation, "validate_migrations", "failed to check pending migrations")
+	}
+
+	var failures []string
+	for _, check := range checks {
+		for _, statement := range check.Statements {
+			if migrator.logger != nil {
+				migrator.logger.Debug("checked migration statement",
+					slog.String("migration", check.Name),
+					slog.Int("line", statement.Line),
+					slog.String("type", statement.Type))
+			}
+			if statement.Error != "" {
+				failures = append(failures, fmt.Sprintf("%s:%d: %s", check.Name, statement.Line, statement.Error))
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return NewValidationError(fmt.Sprintf("%d pending migration statements failed the syntax check", len(failures)), nil).
+			WithContext("errors", failures).
+			WithOperation("validate_migrations")
+	}
+
+	return nil
+}
+
+// SeedUp applies all pending seeds from the seeds directory. Seeds are tracked in
+// their own version table so reference data is versioned independently from the schema.
+func (migrator *GooseMigrator) SeedUp(ctx context.Context) error {
+	if migrator.seedsDir == "" {
+		return NewValidationError("seeds directory not set", nil).
+			WithOperation("seed_up")
+	}
+
+	err := withGooseTable(DefaultSeedsTable, func() error {
+		// Seeds may be added out of order per environment, so missing versions are applied too
+		return goose.UpContext(migrator.gooseContext(ctx), migrator.db.DB, migrator.seedsDir, goose.WithAllowMissing())
+	})
+	if err != nil {
+		return NewMigrationError("failed to apply seeds", err).
+			WithContext("seeds_dir", migrator.seedsDir).
+			WithOperation("seed_up")
+	}
+	return nil
+}
+
+// SeedDown rolls back the most recently applied seed
+func (migrator *GooseMigrator) SeedDown(ctx context.Context) error {
+	if migrator.seedsDir == "" {
+		return NewValidationError("seeds directory not set", nil).
+			WithOperation("seed_down")
+	}
+
+	err := withGooseTable(DefaultSeedsTable, func() error {
+		return goose.DownContext(migrator.gooseContext(ctx), migrator.db.DB, migrator.seedsDir)
+	})
+	if err != nil {
+		return NewMigrationError("failed to roll back seed", err).
+			WithContext("seeds_dir", migrator.seedsDir).
+			WithOperation("seed_down")
+	}
+	return nil
+}
+
+// SeedsSource gets the source of the seeds
+func (migrator *GooseMigrator) SeedsSource() string {
+	return migrator.seedsDir
+}
+
+// SetSeedsSource sets the source of the seeds
+func (migrator *GooseMigrator) SetSeedsSource(source string) {
+	migrator.seedsDir = source
+}
+
+// AddSource registers an additional migration source. Sources are applied in the
+// order they are added, after the primary migrations directory.
+func (migrator *GooseMigrator) AddSource(source MigrationSource) error {
+	if err := validateSources(append(migrator.Sources(), source)); err != nil {
+		return err
+	}
+	migrator.sources = append(migrator.sources, source)
+	return nil
+}
+
+// Sources returns all migration sources in the order they are applied, starting
+// with the primary migrations directory when one is configured
+func (migrator *GooseMigrator) Sources() []MigrationSource {
+	return append(primarySource(migrator.migrationsDir, migrator.versionTable()), migrator.sources...)
+}
+
+// primarySource returns the source for the primary migrations directory, if one is set
+func primarySource(dir, table string) []MigrationSource {
+	if dir == "" {
+		return nil
+	}
+	if table == "" {
+		table = DefaultMigrationsTable
+	}
+	return []MigrationSource{{Name: "default", Dir: dir, Table: table}}
+}
+
+// UpAll applies every migration source in order, stopping at the first failure
+func (migrator *GooseMigrator) UpAll(ctx context.Context) error {
+	for _, source := range migrator.Sources() {
+		err := withGooseSource(source, func() error {
+			return goose.UpContext(migrator.gooseContext(ctx), migrator.db.DB, source.Dir)
+		})
+		if err != nil {
+			return NewMigrationError(fmt.Sprintf("failed to apply migration source %q", source.Name), err).
+				WithContext("source", source.Name).
+				WithContext("table", source.Table).
+				WithOperation("migrate_up_all")
+		}
+	}
+	return nil
+}
+
+// ResetAll resets every migration source in reverse order so dependent sources
+// are rolled back before the sources they build on
+func (migrator *GooseMigrator) ResetAll(ctx context.Context) error {
+	sources := migrator.Sources()
+	for i := len(sources) - 1; i >= 0; i-- {
+		source := sources[i]
+		err := withGooseSource(source, func() error {
+			return goose.ResetContext(migrator.gooseContext(ctx), migrator.db.DB, source.Dir)
+		})
+		if err != nil {
+			return NewMigrationError(fmt.Sprintf("failed to reset migration source %q", source.Name), err).
+				WithContext("source", source.Name).
+				WithContext("table", source.Table).
+				WithOperation("migrate_reset_all")
+		}
+	}
+	return nil
+}
+
+// validateSources checks that every source is complete and owns a distinct name and version table
+func validateSources(sources []MigrationSource) error {
+	names := make(map[string]bool)
+	tables := make(map[string]bool)
+	for _, source := range sources {
+		if source.Name == "" || source.Dir == "" || source.Table == "" {
+			return NewValidationError("migration source requires a name, directory and version table", nil).
+				WithContext("source", source.Name).
+				WithOperation("validate_sources")
+		}
+		if names[source.Name] {
+			return NewValidationError(fmt.Sprintf("duplicate migration source name %q", source.Name), nil).
+				WithOperation("validate_sources")
+		}
+		if tables[source.Table] {
+			return NewValidationError(fmt.Sprintf("migration version table %q is used by more than one source", source.Table), nil).
+				WithContext("source", source.Name).
+				WithOperation("validate_sources")
+		}
+		names[source.Name] = true
+		tables[source.Table] = true
+	}
+	return nil
+}
+
+// UpAllSchemas discovers schemas matching schemaPattern (a glob such as "tenant_*") and
+// applies the primary migrations to each one with search_path pinned to that schema, so
+// every tenant gets its own objects and version table. All matching schemas are attempted;
+// the per-schema results are returned together with an error if any of them failed.
+func (migrator *GooseMigrator) UpAllSchemas(ctx context.Context, schemaPattern string) ([]SchemaMigrationResult, error) {
+	if migrator.config == nil || migrator.listSchemas == nil {
+		return nil, NewValidationError("per-schema migrations require a migrator created by database.New", nil).
+			WithOperation("migrate_up_all_schemas")
+	}
+	if _, err := path.Match(schemaPattern, ""); err != nil {
+		return nil, NewValidationError("invalid schema pattern", err).
+			WithContext("pattern", schemaPattern).
+			WithOperation("migrate_up_all_schemas")
+	}
+
+	schemas, err := migrator.listSchemas(ctx)
+	if err != nil {
+		return nil, NewMigrationError("failed to discover schemas", err).
+			WithOperation("migrate_up_all_schemas")
+	}
+
+	var results []SchemaMigrationResult
+	var failed []string
+	for _, schema := range schemas {
+		if matched, _ := path.Match(schemaPattern, schema); !matched {
+			continue
+		}
+
+		result := migrator.upSchema(ctx, schema)
+		if result.Error != "" {
+			failed = append(failed, schema)
+		}
+		results = append(results, result)
+	}
+
+	if len(failed) > 0 {
+		return results, NewMigrationError(fmt.Sprintf("migrations failed for %d of %d schemas", len(failed), len(results)), nil).
+			WithContext("failed_schemas", failed).
+			WithOperation("migrate_up_all_schemas")
+	}
+	return results, nil
+}
+
+// upSchema applies the primary migrations on a dedicated connection whose search_path is the schema
+func (migrator *GooseMigrator) upSchema(ctx context.Context, schema string) SchemaMigrationResult {
+	start := time.Now()
+	result := SchemaMigrationResult{Schema: schema}
+
+	conn, err := sqlx.Connect("postgres", migrator.config.ConnectionString()+" search_path="+searchPathOption(schema))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	// Each tenant keeps its own version table inside its schema
+	err = withGooseTable(unqualifiedTable(migrator.versionTable()), func() error {
+		if upErr := goose.UpContext(migrator.gooseContext(ctx), conn.DB, migrator.migrationsDir); upErr != nil {
+			return upErr
+		}
+		version, versionErr := goose.GetDBVersionContext(ctx, conn.DB)
+		result.Version = version
+		return versionErr
+	})
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// searchPathOption renders a schema as a quoted search_path value for a connection string
+func searchPathOption(schema string) string {
+	value := pq.QuoteIdentifier(schema)
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// VerifyOnShadow recreates the shadow database described by shadowConfig, applies every
+// migration source to it and, only when that succeeds, applies them to the target. Syntax
+// and ordering errors therefore surface against a throwaway database first.
+func (migrator *GooseMigrator) VerifyOnShadow(ctx context.Context, shadowConfig Config) error {
+	if err := migrator.checkShadowTarget(ctx, shadowConfig); err != nil {
+		return err
+	}
+
+	if err := recreateDatabase(ctx, shadowConfig); err != nil {
+		return WrapError(err, ErrCodeMigrationFailed, "verify_on_shadow", "failed to prepare shadow database")
+	}
+
+	conn, err := sqlx.ConnectContext(ctx, "postgres", shadowConfig.ConnectionString())
+	if err != nil {
+		return NewConnectionError("failed to connect to shadow database", err).
+			WithContext("shadow_database", shadowConfig.DBName).
+			WithOperation("verify_on_shadow")
+	}
+	defer conn.Close()
+
+	if err := migrator.shadowMigrator(conn).UpAll(ctx); err != nil {
+		return WrapError(err, ErrCodeMigrationFailed, "verify_on_shadow", "migrations failed on shadow database").
+			WithContext("shadow_database", shadowConfig.DBName)
+	}
+
+	if err := migrator.UpAll(ctx); err != nil {
+		return WrapError(err, ErrCodeMigrationFailed, "verify_on_shadow", "migrations verified on shadow but failed on target")
+	}
+	return nil
+}
+
+// shadowMigrator returns a migrator applying the same sources as migrator, into the same
+// version tables and with the same logging and events, to the shadow database on conn
+func (migrator *GooseMigrator) shadowMigrator(conn *sqlx.DB) *GooseMigrator {
+	return &GooseMigrator{
+		db:            conn,
+		migrationsDir: migrator.migrationsDir,
+		sources:       migrator.sources,
+		logger:        migrator.logger,
+		events:        migrator.events,
+		table:         migrator.table,
+	}
+}
+
+// checkShadowTarget refuses shadow configurations that point at the migration target itself
+func (migrator *GooseMigrator) checkShadowTarget(ctx context.Context, shadowConfig Config) error {
+	var current string
+	if err := migrator.db.GetContext(ctx, &current, "SELECT current_database()"); err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "verify_on_shadow", "failed to determine target database")
+	}
+
+	sameServer := migrator.config == nil ||
+		(migrator.config.Host == shadowConfig.Host && migrator.config.Port == shadowConfig.Port)
+	if sameServer && shadowConfig.DBName == current {
+		return NewValidationError("shadow database must differ from the migration target", nil).
+			WithContext("database", current).
+			WithOperation("verify_on_shadow")
+	}
 	return nil
 }
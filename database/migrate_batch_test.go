@@ -356,3 +356,297 @@ DROP INDEX idx_posts_user_id;`,
 		}
 	}
 }
+
+func TestMigrationDownToTime(t *testing.T) {
+	testDB := NewTestDatabase(t)
+	defer testDB.Close()
+
+	tempDir := t.TempDir()
+	createTestMigrations(t, tempDir)
+
+	config := testDB.GetConfig()
+	config.MigrationsDir = tempDir
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	if err := db.Migrator.Reset(ctx); err != nil {
+		t.Fatalf("Failed to reset database: %v", err)
+	}
+
+	if err := db.Migrator.UpByOne(ctx); err != nil {
+		t.Fatalf("UpByOne failed: %v", err)
+	}
+	checkpoint, err := db.Migrator.Version(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+
+	// tstamp has microsecond precision, so make sure later migrations land after the checkpoint
+	time.Sleep(10 * time.Millisecond)
+	at := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := db.Migrator.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	if err := db.Migrator.DownToTime(ctx, at); err != nil {
+		t.Fatalf("DownToTime failed: %v", err)
+	}
+
+	version, err := db.Migrator.Version(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+	if version != checkpoint {
+		t.Errorf("Expected version %d after DownToTime, got %d", checkpoint, version)
+	}
+
+	// A time before any migration was applied rolls everything back
+	if err := db.Migrator.DownToTime(ctx, at.Add(-time.Hour)); err != nil {
+		t.Fatalf("DownToTime failed: %v", err)
+	}
+	version, err = db.Migrator.Version(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get version: %v", err)
+	}
+	if version != 0 {
+		t.Errorf("Expected version 0, got %d", version)
+	}
+}
+
+func TestMigrationPending(t *testing.T) {
+	testDB := NewTestDatabase(t)
+	defer testDB.Close()
+
+	tempDir := t.TempDir()
+	createTestMigrations(t, tempDir)
+
+	noTx := "-- +goose NO TRANSACTION\n-- +goose Up\nCREATE INDEX CONCURRENTLY idx_posts_title ON posts(title);\n\n-- +goose Down\nDROP INDEX CONCURRENTLY idx_posts_title;"
+	if err := os.WriteFile(filepath.Join(tempDir, "20250102000004_concurrent_index.sql"), []byte(noTx), 0644); err != nil {
+		t.Fatalf("Failed to create migration file: %v", err)
+	}
+
+	config := testDB.GetConfig()
+	config.MigrationsDir = tempDir
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	if err := db.Migrator.Reset(ctx); err != nil {
+		t.Fatalf("Failed to reset database: %v", err)
+	}
+	if err := db.Migrator.UpByOne(ctx); err != nil {
+		t.Fatalf("UpByOne failed: %v", err)
+	}
+
+	pending, err := db.Migrator.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending failed: %v", err)
+	}
+	if len(pending) != 3 {
+		t.Fatalf("Expected 3 pending migrations, got %d", len(pending))
+	}
+
+	for i := 1; i < len(pending); i++ {
+		if pending[i].Version <= pending[i-1].Version {
+			t.Errorf("Expected pending migrations in ascending order, got %d after %d", pending[i].Version, pending[i-1].Version)
+		}
+	}
+
+	first, last := pending[0], pending[len(pending)-1]
+	if first.Name != "20250102000002_create_posts.sql" || !first.Transactional || first.Size == 0 {
+		t.Errorf("Unexpected first pending migration: %+v", first)
+	}
+	if last.Version != 20250102000004 || last.Transactional {
+		t.Errorf("Expected last pending migration to be non-transactional, got %+v", last)
+	}
+}
+
+func TestMigrationRollbackPlan(t *testing.T) {
+	testDB := NewTestDatabase(t)
+	defer testDB.Close()
+
+	tempDir := t.TempDir()
+	createTestMigrations(t, tempDir)
+
+	config := testDB.GetConfig()
+	config.MigrationsDir = tempDir
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	if err := db.Migrator.Reset(ctx); err != nil {
+		t.Fatalf("Failed to reset database: %v", err)
+	}
+	if err := db.Migrator.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	plan, err := db.Migrator.RollbackPlan(ctx, 20250102000001)
+	if err != nil {
+		t.Fatalf("RollbackPlan failed: %v", err)
+	}
+	if len(plan) != 2 || plan[0].Version != 20250102000003 || plan[1].Version != 20250102000002 {
+		t.Fatalf("Expected migrations 3 and 2 newest first, got %+v", plan)
+	}
+
+	plan, err = db.Migrator.RollbackPlan(ctx, 0)
+	if err != nil {
+		t.Fatalf("RollbackPlan failed: %v", err)
+	}
+	if len(plan) != 3 {
+		t.Errorf("Expected a reset to roll back 3 migrations, got %d", len(plan))
+	}
+
+	// Nothing is rolled back and the version is unchanged
+	version, err := db.Migrator.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	if version != 20250102000003 {
+		t.Errorf("Expected version 20250102000003, got %d", version)
+	}
+
+	plan, err = db.Migrator.RollbackPlan(ctx, version)
+	if err != nil {
+		t.Fatalf("RollbackPlan failed: %v", err)
+	}
+	if len(plan) != 0 {
+		t.Errorf("Expected an empty plan at the current version, got %+v", plan)
+	}
+}
+
+func TestMigrationUpEvents(t *testing.T) {
+	testDB := NewTestDatabase(t)
+	defer testDB.Close()
+
+	tempDir := t.TempDir()
+	createTestMigrations(t, tempDir)
+
+	config := testDB.GetConfig()
+	config.MigrationsDir = tempDir
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	if err := db.Migrator.Reset(ctx); err != nil {
+		t.Fatalf("Failed to reset database: %v", err)
+	}
+
+	var events []MigrationEvent
+	db.Migrator.SetEventHandler(func(event MigrationEvent) {
+		events = append(events, event)
+	})
+	defer db.Migrator.SetEventHandler(nil)
+
+	if err := db.Migrator.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	// One started and one finished event per migration
+	if len(events) != 6 {
+		t.Fatalf("Expected 6 events, got %d", len(events))
+	}
+	for i := 0; i < len(events); i += 2 {
+		if events[i].Type != MigrationStarted || events[i+1].Type != MigrationFinished {
+			t.Errorf("Expected started/finished pair at %d, got %s/%s", i, events[i].Type, events[i+1].Type)
+		}
+		if events[i+1].Total != 3 || events[i+1].Index != i/2+1 {
+			t.Errorf("Unexpected progress %d/%d", events[i+1].Index, events[i+1].Total)
+		}
+	}
+}
+
+func TestMigrationCheckPending(t *testing.T) {
+	testDB := NewTestDatabase(t)
+	defer testDB.Close()
+
+	tempDir := t.TempDir()
+	createTestMigrations(t, tempDir)
+
+	broken := "-- +goose Up\nCREATE TABLE comments (id SERIAL PRIMARY KEY);\nALTER TABLE comments ADD COLUMN body TEXT NOT NUL;\n\n-- +goose Down\nDROP TABLE comments;"
+	if err := os.WriteFile(filepath.Join(tempDir, "20250102000004_broken.sql"), []byte(broken), 0644); err != nil {
+		t.Fatalf("Failed to create migration file: %v", err)
+	}
+
+	config := testDB.GetConfig()
+	config.MigrationsDir = tempDir
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	if err := db.Migrator.Reset(ctx); err != nil {
+		t.Fatalf("Failed to reset database: %v", err)
+	}
+
+	checks, err := db.Migrator.CheckPending(ctx)
+	if err != nil {
+		t.Fatalf("CheckPending failed: %v", err)
+	}
+	if len(checks) != 4 {
+		t.Fatalf("Expected 4 checked migrations, got %d", len(checks))
+	}
+
+	for _, check := range checks[:3] {
+		if check.Failed() {
+			t.Errorf("Expected %s to pass the syntax check, got %+v", check.Name, check.Statements)
+		}
+	}
+
+	last := checks[3]
+	if !last.Failed() || len(last.Statements) != 2 {
+		t.Fatalf("Expected the broken migration to fail on its second statement, got %+v", last.Statements)
+	}
+	if last.Statements[0].Error != "" || last.Statements[1].Line != 3 || last.Statements[1].Type != "ALTER TABLE" {
+		t.Errorf("Unexpected statement checks: %+v", last.Statements)
+	}
+
+	// Nothing was executed by the check
+	if version, err := db.Migrator.Version(ctx); err != nil || version != 0 {
+		t.Errorf("Expected version 0 after the check, got %d (%v)", version, err)
+	}
+
+	if err := db.Migrator.Validate(ctx); err == nil {
+		t.Error("Expected Validate to fail on the broken migration")
+	}
+}
+
+func TestMigrationRoundTrip(t *testing.T) {
+	testDB := NewTestDatabase(t)
+	defer testDB.Close()
+
+	tempDir := t.TempDir()
+	createTestMigrations(t, tempDir)
+
+	config := testDB.GetConfig()
+	config.MigrationsDir = tempDir
+
+	TestMigrations(t, config)
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// StatementCheck is the result of syntax-checking a single migration statement
+type StatementCheck struct {
+	Line  int    `json:"line"`
+	Type  string `json:"type"`
+	Error string `json:"error,omitempty"`
+}
+
+// MigrationCheck is the result of syntax-checking a pending migration
+type MigrationCheck struct {
+	Version       int64            `json:"version"`
+	Name          string           `json:"name"`
+	Transactional bool             `json:"transactional"`
+	Statements    []StatementCheck `json:"statements"`
+}
+
+// Failed reports whether any statement of the migration failed the check
+func (c MigrationCheck) Failed() bool {
+	for _, statement := range c.Statements {
+		if statement.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// uncheckedStatements start with keywords PL/pgSQL would interpret itself, so they are
+// classified but not sent to the server for a syntax check
+var uncheckedStatements = map[string]bool{
+	"BEGIN": true, "START": true, "COMMIT": true, "END": true, "ROLLBACK": true,
+	"SAVEPOINT": true, "RELEASE": true, "EXECUTE": true, "FETCH": true, "MOVE": true,
+	"CLOSE": true, "GET": true, "RAISE": true, "PERFORM": true, "COPY": true,
+}
+
+// CheckPending parses the up statements of every pending SQL migration and asks the
+// server to syntax-check each one without executing it. Statements are compiled as the
+// unreachable body of a PL/pgSQL DO block inside a transaction that is always rolled back.
+func (migrator *GooseMigrator) CheckPending(ctx context.Context) ([]MigrationCheck, error) {
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := migrator.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, NewMigrationError("failed to start syntax check transaction", err).
+			WithOperation("check_migrations")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	checks := make([]MigrationCheck, 0, len(pending))
+	for _, m := range pending {
+		check := MigrationCheck{Version: m.Version, Name: m.Name, Transactional: m.Transactional}
+		if m.Type != "sql" {
+			checks = append(checks, check)
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(migrator.migrationsDir, m.Name))
+		if err != nil {
+			return nil, NewMigrationError("failed to read migration file", err).
+				WithContext("file", m.Name).
+				WithOperation("check_migrations")
+		}
+
+		statements, _, err := parseGooseSQL(string(content), "up")
+		if err != nil {
+			check.Statements = append(check.Statements, StatementCheck{Error: err.Error()})
+			checks = append(checks, check)
+			continue
+		}
+
+		for _, statement := range statements {
+			result := StatementCheck{Line: statement.Line, Type: statement.Type}
+			if !uncheckedStatements[statement.Type] && !isSelectInto(statement) {
+				if err := checkStatementSyntax(ctx, tx, statement.SQL); err != nil {
+					result.Error = err.Error()
+				}
+			}
+			check.Statements = append(check.Statements, result)
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// isSelectInto reports whether a SELECT creates a table with INTO, which PL/pgSQL would
+// read as assignment to a variable
+func isSelectInto(statement Statement) bool {
+	if statement.Type != "SELECT" {
+		return false
+	}
+	for _, word := range strings.Fields(strings.ToUpper(statement.SQL)) {
+		if word == "INTO" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStatementSyntax compiles sql as unreachable PL/pgSQL so the server parses it without
+// running it. A savepoint keeps the surrounding transaction usable after a failure.
+func checkStatementSyntax(ctx context.Context, tx *sqlx.Tx, sql string) error {
+	tag := "$dbkit_check$"
+	for i := 0; strings.Contains(sql, tag); i++ {
+		tag = fmt.Sprintf("$dbkit_check_%d$", i)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT dbkit_syntax_check"); err != nil {
+		return err
+	}
+
+	_, checkErr := tx.ExecContext(ctx, "DO "+tag+" BEGIN RETURN; "+sql+"; END "+tag)
+	if checkErr != nil {
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT dbkit_syntax_check"); err != nil {
+			return err
+		}
+		return checkErr
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT dbkit_syntax_check")
+	return err
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"log/slog"
+	"time"
+)
+
+// MigrationEventType identifies a step in a migration run
+type MigrationEventType string
+
+const (
+	// MigrationStarted is emitted before a migration is applied
+	MigrationStarted MigrationEventType = "started"
+	// MigrationFinished is emitted after a migration was applied successfully
+	MigrationFinished MigrationEventType = "finished"
+	// MigrationFailed is emitted when a migration could not be applied
+	MigrationFailed MigrationEventType = "failed"
+)
+
+// MigrationEvent reports the progress of a single migration during a run
+type MigrationEvent struct {
+	Type      MigrationEventType `json:"type"`
+	Version   int64              `json:"version"`
+	Name      string             `json:"name"`
+	Direction string             `json:"direction"`
+	Index     int                `json:"index"`
+	Total     int                `json:"total"`
+	Duration  time.Duration      `json:"duration,omitempty"`
+	Error     error              `json:"-"`
+}
+
+// MigrationEventHandler receives migration progress events. It is called synchronously
+// from the migration run, so slow handlers delay the run.
+type MigrationEventHandler func(event MigrationEvent)
+
+// SetEventHandler sets the handler that receives progress events; nil disables it
+func (migrator *GooseMigrator) SetEventHandler(handler MigrationEventHandler) {
+	migrator.events = handler
+}
+
+// emit logs the event and forwards it to the event handler, if any
+func (migrator *GooseMigrator) emit(event MigrationEvent) {
+	if migrator.logger != nil {
+		attrs := []any{
+			slog.Int64("version", event.Version),
+			slog.String("name", event.Name),
+			slog.String("direction", event.Direction),
+			slog.Int("index", event.Index),
+			slog.Int("total", event.Total),
+		}
+		switch event.Type {
+		case MigrationStarted:
+			migrator.logger.Info("migration started", attrs...)
+		case MigrationFinished:
+			migrator.logger.Info("migration finished", append(attrs, slog.Duration("duration", event.Duration))...)
+		case MigrationFailed:
+			migrator.logger.Error("migration failed", append(attrs, slog.Duration("duration", event.Duration), slog.Any("error", event.Error))...)
+		}
+	}
+
+	if migrator.events != nil {
+		migrator.events(event)
+	}
+}
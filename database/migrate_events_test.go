@@ -0,0 +1,36 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigratorEmit(t *testing.T) {
+	var buf bytes.Buffer
+	migrator := NewGooseMigrator(nil, "")
+	migrator.logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+	var events []MigrationEvent
+	migrator.SetEventHandler(func(event MigrationEvent) {
+		events = append(events, event)
+	})
+
+	migrator.emit(MigrationEvent{Type: MigrationStarted, Version: 1, Name: "1_init.sql", Direction: "up", Index: 1, Total: 2})
+	migrator.emit(MigrationEvent{Type: MigrationFailed, Version: 1, Name: "1_init.sql", Direction: "up", Index: 1, Total: 2, Error: errors.New("boom")})
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, MigrationFailed, events[1].Type)
+	assert.True(t, strings.Contains(buf.String(), "migration started"))
+	assert.True(t, strings.Contains(buf.String(), "error=boom"))
+
+	// Without a handler or logger, emitting is a no-op
+	migrator.SetEventHandler(nil)
+	migrator.logger = nil
+	migrator.emit(MigrationEvent{Type: MigrationFinished})
+	assert.Len(t, events, 2)
+}
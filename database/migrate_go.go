@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/pressly/goose/v3"
+)
+
+// GoMigrationFunc is a Go migration step that runs inside a db-kit Transaction
+type GoMigrationFunc func(tx *Transaction) error
+
+// MigrationRegistry registers Go migrations with goose, wrapping them with db-kit
+// transactions, structured errors and logging
+type MigrationRegistry struct {
+	mu     sync.RWMutex
+	logger *slog.Logger
+}
+
+// Migrations is the default registry for Go migrations, typically used from init functions:
+//
+//	func init() {
+//		database.Migrations.RegisterTx(20250101120000, upAddUsers, downAddUsers)
+//	}
+var Migrations = &MigrationRegistry{}
+
+// SetLogger sets the logger used when Go migrations run; slog.Default() is used otherwise
+func (r *MigrationRegistry) SetLogger(logger *slog.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logger = logger
+}
+
+// RegisterTx registers a Go migration whose up and down steps each run in their own transaction.
+// The version must be unique across all registered Go migrations; down may be nil for
+// irreversible migrations.
+func (r *MigrationRegistry) RegisterTx(version int64, up, down GoMigrationFunc) error {
+	if version < 1 {
+		return NewValidationError("go migration version must be greater than zero", nil).
+			WithContext("version", version).
+			WithOperation("register_go_migration")
+	}
+	if up == nil {
+		return NewValidationError("go migration requires an up function", nil).
+			WithContext("version", version).
+			WithOperation("register_go_migration")
+	}
+
+	migration := goose.NewGoMigration(version,
+		&goose.GoFunc{RunTx: r.wrap(version, "up", up)},
+		&goose.GoFunc{RunTx: r.wrap(version, "down", down)},
+	)
+	migration.Source = fmt.Sprintf("%d_go_migration.go", version)
+
+	gooseMu.Lock()
+	defer gooseMu.Unlock()
+	if err := goose.SetGlobalMigrations(migration); err != nil {
+		return NewMigrationError("failed to register go migration", err).
+			WithContext("version", version).
+			WithOperation("register_go_migration")
+	}
+	return nil
+}
+
+// wrap adapts a GoMigrationFunc to goose's *sql.Tx signature
+func (r *MigrationRegistry) wrap(version int64, direction string, fn GoMigrationFunc) func(context.Context, *sql.Tx) error {
+	if fn == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, tx *sql.Tx) error {
+		logger := r.getLogger()
+		start := time.Now()
+
+		transaction := &Transaction{
+			tx:     newSQLXTx(tx),
+			db:     migrationDB(ctx),
+			ctx:    ctx,
+			logger: logger,
+		}
+
+		if err := fn(transaction); err != nil {
+			logger.Error("go migration failed",
+				slog.Int64("version", version),
+				slog.String("direction", direction),
+				slog.Any("error", err))
+			return NewMigrationError(fmt.Sprintf("go migration %d failed (%s)", version, direction), err).
+				WithContext("version", version).
+				WithContext("direction", direction).
+				WithOperation("go_migration")
+		}
+
+		logger.Info("go migration applied",
+			slog.Int64("version", version),
+			slog.String("direction", direction),
+			slog.Duration("duration", time.Since(start)))
+		return nil
+	}
+}
+
+func (r *MigrationRegistry) getLogger() *slog.Logger {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.logger != nil {
+		return r.logger
+	}
+	return slog.Default()
+}
+
+// newSQLXTx wraps a transaction started by goose in an *sqlx.Tx. sqlx has no public
+// constructor for this, so the driver name is unset; Transaction binds named queries
+// with postgres' $N placeholders itself.
+func newSQLXTx(tx *sql.Tx) *sqlx.Tx {
+	return &sqlx.Tx{Tx: tx, Mapper: reflectx.NewMapperFunc("db", strings.ToLower)}
+}
+
+type migrationDBKey struct{}
+
+// withMigrationDB returns ctx carrying db, whose hooks the transactions of Go migrations
+// run through
+func withMigrationDB(ctx context.Context, db *DB) context.Context {
+	if db == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, migrationDBKey{}, db)
+}
+
+// migrationDB returns the DB set with withMigrationDB, or nil
+func migrationDB(ctx context.Context) *DB {
+	db, _ := ctx.Value(migrationDBKey{}).(*DB)
+	return db
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationRegistryRegisterTx(t *testing.T) {
+	t.Cleanup(goose.ResetGlobalMigrations)
+
+	registry := &MigrationRegistry{}
+	up := func(tx *Transaction) error { return nil }
+
+	t.Run("invalid version", func(t *testing.T) {
+		err := registry.RegisterTx(0, up, nil)
+		require.Error(t, err)
+		assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+	})
+
+	t.Run("missing up", func(t *testing.T) {
+		err := registry.RegisterTx(1, nil, nil)
+		require.Error(t, err)
+		assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+	})
+
+	t.Run("duplicate version", func(t *testing.T) {
+		require.NoError(t, registry.RegisterTx(900001, up, nil))
+		err := registry.RegisterTx(900001, up, nil)
+		require.Error(t, err)
+		assert.Equal(t, ErrCodeMigrationFailed, GetErrorCode(err))
+	})
+}
+
+func TestBindNamed(t *testing.T) {
+	query, args, err := bindNamed("UPDATE users SET name = :name WHERE id = :id", map[string]interface{}{"id": 7, "name": "a"})
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE users SET name = $1 WHERE id = $2", query)
+	assert.Equal(t, []interface{}{"a", 7}, args)
+}
+
+func TestGoMigrationUsesMigratorDB(t *testing.T) {
+	db := &DB{}
+	var bound *DB
+	run := Migrations.wrap(1, "up", func(tx *Transaction) error {
+		bound = tx.db
+		return nil
+	})
+
+	migrator := &GooseMigrator{owner: db}
+	require.NoError(t, run(migrator.gooseContext(context.Background()), nil))
+	assert.Same(t, db, bound)
+
+	require.NoError(t, run(context.Background(), nil))
+	assert.Nil(t, bound)
+}
@@ -2,10 +2,15 @@ package database
 
 import (
 	"context"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/pressly/goose/v3"
 )
 
 func TestMigrateUpDown(t *testing.T) {
@@ -101,3 +106,335 @@ func TestMigrationStatusStruct(t *testing.T) {
 	t.Logf("Status struct returned successfully: Current=%d, Latest=%d, Applied=%d, Pending=%d",
 		status.Current, status.Latest, status.Applied, status.Pending)
 }
+
+func TestSeedUpDown(t *testing.T) {
+	// Set up the database
+	db, close := tearUp(t)
+	defer close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Create a temporary directory for seeds
+	seedsDir := filepath.Join(t.TempDir(), "seeds")
+	err := os.MkdirAll(seedsDir, 0755)
+	if err != nil {
+		t.Fatalf("Failed to create seeds directory: %v", err)
+	}
+
+	seed := `-- +goose Up
+CREATE TABLE IF NOT EXISTS test_seed_countries (code TEXT PRIMARY KEY);
+INSERT INTO test_seed_countries (code) VALUES ('ES'), ('FR');
+
+-- +goose Down
+DROP TABLE IF EXISTS test_seed_countries;`
+	err = os.WriteFile(filepath.Join(seedsDir, "00001_countries.sql"), []byte(seed), 0644)
+	if err != nil {
+		t.Fatalf("Failed to write seed file: %v", err)
+	}
+
+	db.Migrator.SetSeedsSource(seedsDir)
+
+	// Apply seeds
+	err = db.Migrator.SeedUp(ctx)
+	if err != nil {
+		t.Fatalf("Failed to apply seeds: %v", err)
+	}
+
+	// Seeds must not be recorded in the schema migrations table
+	var count int
+	err = db.DB().GetContext(ctx, &count, "SELECT COUNT(*) FROM "+DefaultSeedsTable+" WHERE version_id = 1")
+	if err != nil {
+		t.Fatalf("Failed to query seeds table: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected seed version 1 to be recorded once, got %d", count)
+	}
+
+	// Roll the seed back
+	err = db.Migrator.SeedDown(ctx)
+	if err != nil {
+		t.Fatalf("Failed to roll back seed: %v", err)
+	}
+}
+
+func TestValidateSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []MigrationSource
+		wantErr bool
+	}{
+		{
+			name: "distinct sources",
+			sources: []MigrationSource{
+				{Name: "core", Dir: "migrations", Table: DefaultMigrationsTable},
+				{Name: "billing", Dir: "plugins/billing", Table: "billing_db_version"},
+			},
+		},
+		{
+			name: "shared version table",
+			sources: []MigrationSource{
+				{Name: "core", Dir: "migrations", Table: DefaultMigrationsTable},
+				{Name: "billing", Dir: "plugins/billing", Table: DefaultMigrationsTable},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate name",
+			sources: []MigrationSource{
+				{Name: "core", Dir: "migrations", Table: DefaultMigrationsTable},
+				{Name: "core", Dir: "plugins/billing", Table: "billing_db_version"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing table",
+			sources: []MigrationSource{{Name: "billing", Dir: "plugins/billing"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSources(tt.sources)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigratorSourcesOrder(t *testing.T) {
+	migrator := NewGooseMigrator(nil, "migrations")
+	err := migrator.AddSource(MigrationSource{Name: "billing", Dir: "plugins/billing", Table: "billing_db_version"})
+	if err != nil {
+		t.Fatalf("Failed to add source: %v", err)
+	}
+
+	sources := migrator.Sources()
+	if len(sources) != 2 {
+		t.Fatalf("Expected 2 sources, got %d", len(sources))
+	}
+	if sources[0].Table != DefaultMigrationsTable || sources[1].Name != "billing" {
+		t.Errorf("Unexpected source order: %+v", sources)
+	}
+
+	// A second source reusing the default table must be rejected
+	err = migrator.AddSource(MigrationSource{Name: "other", Dir: "plugins/other", Table: DefaultMigrationsTable})
+	if err == nil {
+		t.Error("Expected error when reusing the default version table")
+	}
+}
+
+func TestSearchPathOption(t *testing.T) {
+	tests := map[string]string{
+		"tenant_1":  `'"tenant_1"'`,
+		"Tenant 2":  `'"Tenant 2"'`,
+		"o'brien":   `'"o\'brien"'`,
+		`back\path`: `'"back\\path"'`,
+	}
+
+	for schema, expected := range tests {
+		if got := searchPathOption(schema); got != expected {
+			t.Errorf("searchPathOption(%q) = %s, expected %s", schema, got, expected)
+		}
+	}
+}
+
+func TestUpAllSchemasRequiresConfig(t *testing.T) {
+	migrator := NewGooseMigrator(nil, "migrations")
+	_, err := migrator.UpAllSchemas(context.Background(), "tenant_*")
+	if GetErrorCode(err) != ErrCodeValidation {
+		t.Errorf("Expected validation error for a migrator without config, got %v", err)
+	}
+}
+
+func TestVerifyOnShadow(t *testing.T) {
+	// Set up the database
+	db, close := tearUp(t)
+	defer close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	migrationsDir := t.TempDir()
+	createTestMigrations(t, migrationsDir)
+	db.Migrator.SetSource(migrationsDir)
+	defer db.Migrator.Reset(ctx)
+
+	// Pointing the shadow at the target itself must be rejected
+	err := db.Migrator.VerifyOnShadow(ctx, db.Config())
+	if GetErrorCode(err) != ErrCodeValidation {
+		t.Errorf("Expected validation error when shadow equals target, got %v", err)
+	}
+
+	shadowConfig := db.Config()
+	shadowConfig.DBName = db.Config().DBName + "_shadow"
+	err = db.Migrator.VerifyOnShadow(ctx, shadowConfig)
+	if err != nil {
+		t.Fatalf("Shadow verification failed: %v", err)
+	}
+}
+
+func TestShadowMigrator(t *testing.T) {
+	migrator := NewGooseMigrator(nil, "migrations")
+	if err := migrator.SetVersionTable("infra.schema_migrations"); err != nil {
+		t.Fatalf("SetVersionTable failed: %v", err)
+	}
+	migrator.sources = []MigrationSource{{Name: "audit", Dir: "audit_migrations", Table: "audit_versions"}}
+	migrator.logger = slog.Default()
+	migrator.SetEventHandler(func(MigrationEvent) {})
+
+	shadow := migrator.shadowMigrator(nil)
+	if !slices.Equal(shadow.Sources(), migrator.Sources()) {
+		t.Errorf("Expected the shadow to apply %+v, got %+v", migrator.Sources(), shadow.Sources())
+	}
+	if shadow.Sources()[0].Table != "infra.schema_migrations" {
+		t.Errorf("Expected the shadow to record versions in the custom table, got %s", shadow.Sources()[0].Table)
+	}
+	if shadow.logger != migrator.logger || shadow.events == nil {
+		t.Error("Expected the shadow to keep the logger and event handler")
+	}
+}
+
+func TestValidateVersionTable(t *testing.T) {
+	valid := []string{"", "schema_migrations", "infra.schema_migrations", "_app1.v2"}
+	for _, table := range valid {
+		if err := validateVersionTable(table); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", table, err)
+		}
+	}
+
+	invalid := []string{"infra.", "a.b.c", "schema migrations", "users; DROP TABLE x", "1table"}
+	for _, table := range invalid {
+		if err := validateVersionTable(table); err == nil {
+			t.Errorf("Expected %q to be rejected", table)
+		}
+	}
+
+	if unqualifiedTable("infra.schema_migrations") != "schema_migrations" || unqualifiedTable("versions") != "versions" {
+		t.Error("unqualifiedTable did not strip the schema")
+	}
+}
+
+func TestMigratorVersionTable(t *testing.T) {
+	migrator := NewGooseMigrator(nil, "migrations")
+	if migrator.versionTable() != DefaultMigrationsTable {
+		t.Errorf("Expected default version table, got %s", migrator.versionTable())
+	}
+
+	if err := migrator.SetVersionTable("infra.schema_migrations"); err != nil {
+		t.Fatalf("SetVersionTable failed: %v", err)
+	}
+	if sources := migrator.Sources(); sources[0].Table != "infra.schema_migrations" {
+		t.Errorf("Expected primary source to use the configured table, got %s", sources[0].Table)
+	}
+
+	if err := migrator.SetVersionTable("bad name"); err == nil {
+		t.Error("Expected invalid table name to be rejected")
+	}
+}
+
+func TestCustomVersionTable(t *testing.T) {
+	testDB := NewTestDatabase(t)
+	defer testDB.Close()
+
+	tempDir := t.TempDir()
+	createTestMigrations(t, tempDir)
+
+	config := testDB.GetConfig()
+	config.MigrationsDir = tempDir
+	config.MigrationsTable = "dbkit_infra.schema_migrations"
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Validate reports the missing schema before goose tries to create the table
+	if _, err := db.db.ExecContext(ctx, "DROP SCHEMA IF EXISTS dbkit_infra CASCADE"); err != nil {
+		t.Fatalf("Failed to drop schema: %v", err)
+	}
+	if err := db.Migrator.Validate(ctx); err == nil {
+		t.Error("Expected Validate to fail without the dbkit_infra schema")
+	}
+
+	if _, err := db.db.ExecContext(ctx, "CREATE SCHEMA dbkit_infra"); err != nil {
+		t.Fatalf("Failed to create schema: %v", err)
+	}
+	defer db.db.ExecContext(ctx, "DROP SCHEMA IF EXISTS dbkit_infra CASCADE")
+
+	if err := db.Migrator.Validate(ctx); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if err := db.Migrator.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	defer db.Migrator.Reset(ctx)
+
+	status, err := db.Migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.Applied != 3 || status.Pending != 0 {
+		t.Errorf("Expected 3 applied migrations in the custom table, got %d applied, %d pending", status.Applied, status.Pending)
+	}
+
+	var count int
+	if err := db.db.GetContext(ctx, &count, "SELECT count(*) FROM dbkit_infra.schema_migrations WHERE version_id > 0"); err != nil {
+		t.Fatalf("Failed to query custom version table: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 rows in the custom version table, got %d", count)
+	}
+}
+
+func TestMissingVersions(t *testing.T) {
+	migrations := goose.Migrations{{Version: 1}, {Version: 2}, {Version: 3}, {Version: 4}}
+	if got := missingVersions(migrations, []int64{0, 1, 3, 4}); !slices.Equal(got, []int64{2}) {
+		t.Errorf("Expected version 2 to be missing, got %v", got)
+	}
+	if got := missingVersions(migrations, []int64{1, 2, 3, 4}); got != nil {
+		t.Errorf("Expected no missing versions, got %v", got)
+	}
+}
+
+func TestUpRefusesOutOfOrderMigrations(t *testing.T) {
+	testDB := NewTestDatabase(t)
+	defer testDB.Close()
+
+	tempDir := t.TempDir()
+	createTestMigrations(t, tempDir)
+
+	config := testDB.GetConfig()
+	config.MigrationsDir = tempDir
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := db.Migrator.Up(ctx); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	defer db.Migrator.Reset(ctx)
+
+	// A migration merged from another branch, older than the applied ones
+	content := "-- +goose Up\nCREATE TABLE out_of_order (id int);\n\n-- +goose Down\nDROP TABLE out_of_order;"
+	if err := os.WriteFile(filepath.Join(tempDir, "20250101000000_out_of_order.sql"), []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+
+	err = db.Migrator.Up(ctx)
+	if GetErrorCode(err) != ErrCodeValidation || !strings.Contains(err.Error(), "20250101000000") {
+		t.Errorf("Expected Up to name the out-of-order migration, got %v", err)
+	}
+	if _, err := db.Migrator.Pending(ctx); GetErrorCode(err) != ErrCodeValidation {
+		t.Errorf("Expected Pending to report the out-of-order migration, got %v", err)
+	}
+}
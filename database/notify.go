@@ -0,0 +1,219 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"text/template"
+	"time"
+)
+
+// MigrationNotification describes a finished Up, Down or Reset run
+type MigrationNotification struct {
+	Operation   string        `json:"operation"`
+	Database    string        `json:"database,omitempty"`
+	FromVersion int64         `json:"from_version"`
+	ToVersion   int64         `json:"to_version"`
+	Versions    []int64       `json:"versions"`
+	Duration    time.Duration `json:"duration"`
+	Success     bool          `json:"success"`
+	Error       string        `json:"error,omitempty"`
+	Timestamp   time.Time     `json:"timestamp"`
+}
+
+// Notifier is notified after migration runs complete or fail
+type Notifier interface {
+	Notify(ctx context.Context, notification MigrationNotification) error
+}
+
+// notifyTimeout bounds how long a notification may delay the migration result
+const notifyTimeout = 10 * time.Second
+
+// WebhookNotifier posts migration notifications to an HTTP endpoint. The request body is
+// rendered from a text/template with the MigrationNotification as its data; without a
+// template the notification is sent as JSON.
+type WebhookNotifier struct {
+	url         string
+	template    *template.Template
+	contentType string
+	headers     map[string]string
+	client      *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url. payloadTemplate may be empty to send
+// the notification as JSON; templates can use the json function to quote values, e.g.
+//
+//	{"text": {{ printf "migrate %s: %v" .Operation .Versions | json }}}
+func NewWebhookNotifier(url, payloadTemplate string) (*WebhookNotifier, error) {
+	if url == "" {
+		return nil, NewConfigError("webhook URL is required", nil).
+			WithOperation("new_webhook_notifier")
+	}
+
+	notifier := &WebhookNotifier{
+		url:         url,
+		contentType: "application/json",
+		headers:     map[string]string{},
+		client:      &http.Client{Timeout: notifyTimeout},
+	}
+
+	if payloadTemplate != "" {
+		tmpl, err := template.New("payload").Funcs(template.FuncMap{
+			"json": func(v interface{}) (string, error) {
+				data, err := json.Marshal(v)
+				return string(data), err
+			},
+		}).Parse(payloadTemplate)
+		if err != nil {
+			return nil, NewConfigError("invalid webhook payload template", err).
+				WithOperation("new_webhook_notifier")
+		}
+		notifier.template = tmpl
+	}
+
+	return notifier, nil
+}
+
+// SetHeader sets a header sent with every request, e.g. an authorization token
+func (w *WebhookNotifier) SetHeader(key, value string) {
+	w.headers[key] = value
+}
+
+// SetContentType sets the Content-Type of the request body (application/json by default)
+func (w *WebhookNotifier) SetContentType(contentType string) {
+	w.contentType = contentType
+}
+
+// Notify posts the rendered notification to the webhook
+func (w *WebhookNotifier) Notify(ctx context.Context, notification MigrationNotification) error {
+	body, err := w.render(notification)
+	if err != nil {
+		return NewDBError(ErrCodeInternal, "failed to render webhook payload", err).
+			WithOperation("notify_webhook")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return NewDBError(ErrCodeInternal, "failed to create webhook request", err).
+			WithOperation("notify_webhook")
+	}
+	req.Header.Set("Content-Type", w.contentType)
+	for key, value := range w.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return NewDBError(ErrCodeInternal, "failed to send webhook", err).
+			WithOperation("notify_webhook")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return NewDBError(ErrCodeInternal, fmt.Sprintf("webhook returned status %d", resp.StatusCode), nil).
+			WithContext("status", resp.StatusCode).
+			WithOperation("notify_webhook")
+	}
+	return nil
+}
+
+func (w *WebhookNotifier) render(notification MigrationNotification) ([]byte, error) {
+	if w.template == nil {
+		return json.Marshal(notification)
+	}
+	var buf bytes.Buffer
+	if err := w.template.Execute(&buf, notification); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SetNotifier sets the notifier called after Up, Down and Reset; nil disables notifications
+func (migrator *GooseMigrator) SetNotifier(notifier Notifier) {
+	migrator.notifier = notifier
+}
+
+// withNotification runs a migration operation and reports its outcome to the notifier.
+// Notification failures are logged and never change the operation's result.
+func (migrator *GooseMigrator) withNotification(ctx context.Context, operation string, fn func() error) error {
+	if migrator.notifier == nil {
+		return fn()
+	}
+
+	before := migrator.appliedVersions(ctx)
+	start := time.Now()
+	err := fn()
+
+	after := migrator.appliedVersions(ctx)
+	notification := MigrationNotification{
+		Operation:   operation,
+		FromVersion: lastVersion(before),
+		ToVersion:   lastVersion(after),
+		Versions:    changedVersions(before, after),
+		Duration:    time.Since(start),
+		Success:     err == nil,
+		Timestamp:   time.Now(),
+	}
+	if migrator.config != nil {
+		notification.Database = migrator.config.DBName
+	}
+	if err != nil {
+		notification.Error = err.Error()
+	}
+
+	// The operation's context may already be expired, e.g. after a timeout
+	notifyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+	if notifyErr := migrator.notifier.Notify(notifyCtx, notification); notifyErr != nil && migrator.logger != nil {
+		migrator.logger.Warn("failed to send migration notification",
+			slog.String("operation", operation),
+			slog.Any("error", notifyErr))
+	}
+
+	return err
+}
+
+// appliedVersions returns the applied migration versions in ascending order, or nil when
+// the version table cannot be read
+func (migrator *GooseMigrator) appliedVersions(ctx context.Context) []int64 {
+	var versions []int64
+	err := migrator.db.SelectContext(ctx, &versions,
+		"SELECT DISTINCT version_id FROM "+migrator.versionTable()+" WHERE is_applied AND version_id > 0 ORDER BY version_id")
+	if err != nil {
+		return nil
+	}
+	return versions
+}
+
+// changedVersions returns the versions present in exactly one of before and after, sorted
+func changedVersions(before, after []int64) []int64 {
+	counts := make(map[int64]int)
+	for _, v := range before {
+		counts[v]++
+	}
+	for _, v := range after {
+		counts[v]--
+	}
+
+	changed := []int64{}
+	for v, count := range counts {
+		if count != 0 {
+			changed = append(changed, v)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i] < changed[j] })
+	return changed
+}
+
+func lastVersion(versions []int64) int64 {
+	if len(versions) == 0 {
+		return 0
+	}
+	return versions[len(versions)-1]
+}
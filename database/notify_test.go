@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var (
+		body    []byte
+		headers http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		headers = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notification := MigrationNotification{
+		Operation: "up",
+		Database:  "app",
+		ToVersion: 2,
+		Versions:  []int64{1, 2},
+		Duration:  time.Second,
+		Success:   true,
+	}
+
+	t.Run("default json payload", func(t *testing.T) {
+		notifier, err := NewWebhookNotifier(server.URL, "")
+		require.NoError(t, err)
+		notifier.SetHeader("Authorization", "Bearer token")
+
+		require.NoError(t, notifier.Notify(context.Background(), notification))
+
+		var got MigrationNotification
+		require.NoError(t, json.Unmarshal(body, &got))
+		assert.Equal(t, []int64{1, 2}, got.Versions)
+		assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+		assert.Equal(t, "application/json", headers.Get("Content-Type"))
+	})
+
+	t.Run("templated payload", func(t *testing.T) {
+		notifier, err := NewWebhookNotifier(server.URL, `{"text": {{ printf "%s on %s: %v" .Operation .Database .Versions | json }}}`)
+		require.NoError(t, err)
+
+		require.NoError(t, notifier.Notify(context.Background(), notification))
+		assert.JSONEq(t, `{"text": "up on app: [1 2]"}`, string(body))
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := NewWebhookNotifier(server.URL, "{{ .Operation")
+		require.Error(t, err)
+		assert.Equal(t, ErrCodeInvalidConfig, GetErrorCode(err))
+	})
+
+	t.Run("missing url", func(t *testing.T) {
+		_, err := NewWebhookNotifier("", "")
+		require.Error(t, err)
+	})
+}
+
+func TestWebhookNotifierErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier(server.URL, "")
+	require.NoError(t, err)
+	assert.Error(t, notifier.Notify(context.Background(), MigrationNotification{Operation: "down"}))
+}
+
+type recordingNotifier struct {
+	notifications []MigrationNotification
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, notification MigrationNotification) error {
+	r.notifications = append(r.notifications, notification)
+	return errors.New("delivery failed")
+}
+
+func TestMigrationUpNotification(t *testing.T) {
+	testDB := NewTestDatabase(t)
+	defer testDB.Close()
+
+	tempDir := t.TempDir()
+	createTestMigrations(t, tempDir)
+
+	notifier := &recordingNotifier{}
+	config := testDB.GetConfig()
+	config.MigrationsDir = tempDir
+	config.MigrationNotifier = notifier
+
+	db, err := New(config)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	require.NoError(t, db.Migrator.Reset(ctx))
+	notifier.notifications = nil
+
+	// A failing notifier does not fail the migration
+	require.NoError(t, db.Migrator.Up(ctx))
+
+	require.Len(t, notifier.notifications, 1)
+	notification := notifier.notifications[0]
+	assert.Equal(t, "up", notification.Operation)
+	assert.True(t, notification.Success)
+	assert.Equal(t, []int64{20250102000001, 20250102000002, 20250102000003}, notification.Versions)
+	assert.Equal(t, int64(20250102000003), notification.ToVersion)
+}
+
+func TestChangedVersions(t *testing.T) {
+	assert.Equal(t, []int64{3}, changedVersions([]int64{1, 2, 3}, []int64{1, 2}))
+	assert.Equal(t, []int64{2, 3}, changedVersions([]int64{1}, []int64{1, 2, 3}))
+	assert.Equal(t, []int64{}, changedVersions(nil, nil))
+}
@@ -0,0 +1,405 @@
+package database
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Recovery target actions, see recovery_target_action in the PostgreSQL documentation
+const (
+	RecoveryActionPromote  = "promote"
+	RecoveryActionPause    = "pause"
+	RecoveryActionShutdown = "shutdown"
+)
+
+// WALArchiveStatus reports whether the server archives WAL for point-in-time recovery
+type WALArchiveStatus struct {
+	WALLevel    string `json:"wal_level"`
+	ArchiveMode string `json:"archive_mode"`
+	// ArchiveCommand is only visible to superusers and members of pg_read_all_settings
+	ArchiveCommand  string     `json:"archive_command,omitempty"`
+	ArchivedCount   int64      `json:"archived_count"`
+	LastArchivedWAL string     `json:"last_archived_wal,omitempty"`
+	LastArchivedAt  *time.Time `json:"last_archived_at,omitempty"`
+	FailedCount     int64      `json:"failed_count"`
+	LastFailedWAL   string     `json:"last_failed_wal,omitempty"`
+	LastFailedAt    *time.Time `json:"last_failed_at,omitempty"`
+}
+
+// Enabled reports whether the server is configured to archive WAL
+func (s WALArchiveStatus) Enabled() bool {
+	return s.ArchiveMode != "" && s.ArchiveMode != "off" && s.WALLevel != "minimal"
+}
+
+// WALArchiveStatus reads the archiving settings and pg_stat_archiver counters of the server
+func (d *DB) WALArchiveStatus(ctx context.Context) (*WALArchiveStatus, error) {
+	var settings []struct {
+		Name    string `db:"name"`
+		Setting string `db:"setting"`
+	}
+	err := d.db.SelectContext(ctx, &settings, `
+		SELECT name, setting FROM pg_settings
+		WHERE name IN ('wal_level', 'archive_mode', 'archive_command')`)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "wal_archive_status", "failed to read archive settings")
+	}
+
+	status := &WALArchiveStatus{}
+	for _, s := range settings {
+		switch s.Name {
+		case "wal_level":
+			status.WALLevel = s.Setting
+		case "archive_mode":
+			status.ArchiveMode = s.Setting
+		case "archive_command":
+			status.ArchiveCommand = s.Setting
+		}
+	}
+
+	var stats struct {
+		ArchivedCount   int64      `db:"archived_count"`
+		LastArchivedWAL string     `db:"last_archived_wal"`
+		LastArchivedAt  *time.Time `db:"last_archived_time"`
+		FailedCount     int64      `db:"failed_count"`
+		LastFailedWAL   string     `db:"last_failed_wal"`
+		LastFailedAt    *time.Time `db:"last_failed_time"`
+	}
+	err = d.db.GetContext(ctx, &stats, `
+		SELECT archived_count, coalesce(last_archived_wal, '') AS last_archived_wal, last_archived_time,
+		       failed_count, coalesce(last_failed_wal, '') AS last_failed_wal, last_failed_time
+		FROM pg_stat_archiver`)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "wal_archive_status", "failed to read pg_stat_archiver")
+	}
+	status.ArchivedCount = stats.ArchivedCount
+	status.LastArchivedWAL = stats.LastArchivedWAL
+	status.LastArchivedAt = stats.LastArchivedAt
+	status.FailedCount = stats.FailedCount
+	status.LastFailedWAL = stats.LastFailedWAL
+	status.LastFailedAt = stats.LastFailedAt
+	return status, nil
+}
+
+// ArchiveCommand returns an archive_command that copies WAL segments into dir without
+// overwriting segments already archived
+func ArchiveCommand(dir string) string {
+	return fmt.Sprintf(`test ! -f "%[1]s/%%f" && cp "%%p" "%[1]s/%%f"`, dir)
+}
+
+// RestoreCommand returns a restore_command that reads WAL segments archived by
+// ArchiveCommand(dir)
+func RestoreCommand(dir string) string {
+	return fmt.Sprintf(`cp "%s/%%f" "%%p"`, dir)
+}
+
+// BaseBackup takes a physical base backup of the whole cluster with pg_basebackup into a
+// new timestamped directory under dir, or BackupsDir if dir is empty, and returns its path.
+// The backup is written in gzipped tar format with the WAL needed to make it consistent.
+func (d *DB) BaseBackup(ctx context.Context, dir string) (string, error) {
+	if dir == "" {
+		dir = d.config.BackupsDir
+	}
+	if IsStorageURL(dir) {
+		return "", NewValidationError("base backups must be written to a local directory", nil).
+			WithContext("dir", dir).
+			WithOperation("base_backup")
+	}
+
+	backupPath := filepath.Join(dir, "basebackup_"+time.Now().Format("20060102_150405"))
+	cmd := exec.CommandContext(ctx, pgTool(d.config, "pg_basebackup"),
+		"--host", d.config.Host,
+		"--port", fmt.Sprintf("%d", d.config.Port),
+		"--username", d.config.User,
+		"--pgdata", backupPath,
+		"--format", "tar",
+		"--gzip",
+		"--wal-method", "fetch",
+		"--checkpoint", "fast",
+		"--label", "db-kit",
+		"--no-password",
+	)
+	cmd.Env = append(cmd.Env, fmt.Sprintf("PGPASSWORD=%s", d.config.Password))
+
+	output := captureOutput(cmd, d.config)
+	if err := cmd.Run(); err != nil {
+		return "", NewBackupError("pg_basebackup command failed", err).
+			WithContext("backup_path", backupPath).
+			WithContext("output", output.String()).
+			WithOperation("base_backup")
+	}
+
+	d.logger.Info("base backup created", slog.String("path", backupPath))
+	return backupPath, nil
+}
+
+// RecoveryOptions configures a point-in-time recovery from a base backup and archived WAL
+type RecoveryOptions struct {
+	// BaseBackup is a pg_basebackup directory in plain or tar format
+	BaseBackup string
+	// DataDir is the new data directory; it must not exist or be empty
+	DataDir string
+	// WALArchiveDir is the directory WAL segments were archived to
+	WALArchiveDir string
+	// RestoreCommand overrides the restore_command built from WALArchiveDir
+	RestoreCommand string
+	// TargetTime is the point in time to recover to
+	TargetTime time.Time
+	// TargetAction is what the server does once the target is reached, promote by default
+	TargetAction string
+}
+
+// Validate checks that the options describe a recovery
+func (o RecoveryOptions) Validate() error {
+	if o.BaseBackup == "" || o.DataDir == "" {
+		return NewValidationError("base backup and data directory are required", nil).
+			WithOperation("validate_recovery_options")
+	}
+	if o.TargetTime.IsZero() {
+		return NewValidationError("recovery target time is required", nil).
+			WithOperation("validate_recovery_options")
+	}
+	if o.TargetTime.After(time.Now()) {
+		return NewValidationError("recovery target time is in the future", nil).
+			WithContext("target_time", o.TargetTime).
+			WithOperation("validate_recovery_options")
+	}
+	if o.WALArchiveDir == "" && o.RestoreCommand == "" {
+		return NewValidationError("a WAL archive directory or restore command is required", nil).
+			WithOperation("validate_recovery_options")
+	}
+	switch o.TargetAction {
+	case "", RecoveryActionPromote, RecoveryActionPause, RecoveryActionShutdown:
+	default:
+		return NewValidationError(fmt.Sprintf("unsupported recovery target action %q", o.TargetAction), nil).
+			WithContext("target_action", o.TargetAction).
+			WithOperation("validate_recovery_options")
+	}
+	return nil
+}
+
+// RecoveryPlan describes a prepared data directory and what is left to do
+type RecoveryPlan struct {
+	DataDir string `json:"data_dir"`
+	// Settings are the recovery parameters appended to postgresql.auto.conf
+	Settings string `json:"settings"`
+	// Steps are the manual steps that complete the recovery
+	Steps []string `json:"steps"`
+}
+
+// PrepareRecovery restores the base backup into a new data directory and configures it to
+// replay archived WAL up to the target time. PostgreSQL is not started; the returned plan
+// lists the remaining steps. This must run on the host that will run the recovered server.
+func PrepareRecovery(ctx context.Context, opts RecoveryOptions) (*RecoveryPlan, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.TargetAction == "" {
+		opts.TargetAction = RecoveryActionPromote
+	}
+	if opts.RestoreCommand == "" {
+		if _, err := os.Stat(opts.WALArchiveDir); err != nil {
+			return nil, NewRestoreError("failed to read WAL archive directory", err).
+				WithContext("wal_archive_dir", opts.WALArchiveDir).
+				WithOperation("prepare_recovery")
+		}
+		opts.RestoreCommand = RestoreCommand(opts.WALArchiveDir)
+	}
+
+	if err := prepareDataDir(opts.DataDir); err != nil {
+		return nil, err
+	}
+	if err := restoreBaseBackup(ctx, opts.BaseBackup, opts.DataDir); err != nil {
+		return nil, NewRestoreError("failed to restore base backup", err).
+			WithContext("base_backup", opts.BaseBackup).
+			WithContext("data_dir", opts.DataDir).
+			WithOperation("prepare_recovery")
+	}
+
+	settings := recoverySettings(opts)
+	if err := writeRecoveryConfig(opts.DataDir, settings); err != nil {
+		return nil, NewRestoreError("failed to write recovery configuration", err).
+			WithContext("data_dir", opts.DataDir).
+			WithOperation("prepare_recovery")
+	}
+
+	return &RecoveryPlan{
+		DataDir:  opts.DataDir,
+		Settings: settings,
+		Steps: []string{
+			fmt.Sprintf("Start PostgreSQL on the data directory, e.g. pg_ctl -D %s start", opts.DataDir),
+			fmt.Sprintf("PostgreSQL replays archived WAL until %s and then runs recovery_target_action '%s'",
+				opts.TargetTime.Format(time.RFC3339), opts.TargetAction),
+			`Check the server log for "recovery stopping before" and verify the data before pointing applications at it`,
+		},
+	}, nil
+}
+
+// recoverySettings returns the postgresql.conf lines that recover to opts.TargetTime
+func recoverySettings(opts RecoveryOptions) string {
+	var b strings.Builder
+	b.WriteString("# Point-in-time recovery configured by db-kit\n")
+	fmt.Fprintf(&b, "restore_command = %s\n", quoteConfigValue(opts.RestoreCommand))
+	fmt.Fprintf(&b, "recovery_target_time = %s\n", quoteConfigValue(opts.TargetTime.Format("2006-01-02 15:04:05.999999-07:00")))
+	fmt.Fprintf(&b, "recovery_target_action = %s\n", quoteConfigValue(opts.TargetAction))
+	return b.String()
+}
+
+// quoteConfigValue quotes a postgresql.conf string value
+func quoteConfigValue(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// prepareDataDir creates dataDir with the permissions PostgreSQL requires, refusing to
+// touch a directory that already has contents
+func prepareDataDir(dataDir string) error {
+	entries, err := os.ReadDir(dataDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return NewRestoreError("failed to read data directory", err).
+			WithContext("data_dir", dataDir).
+			WithOperation("prepare_recovery")
+	}
+	if len(entries) > 0 {
+		return NewValidationError("data directory is not empty", nil).
+			WithContext("data_dir", dataDir).
+			WithOperation("prepare_recovery")
+	}
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return NewRestoreError("failed to create data directory", err).
+			WithContext("data_dir", dataDir).
+			WithOperation("prepare_recovery")
+	}
+	return os.Chmod(dataDir, 0o700)
+}
+
+// restoreBaseBackup copies a plain format base backup, or extracts the base and pg_wal
+// archives of a tar format one, into dataDir
+func restoreBaseBackup(ctx context.Context, baseBackup, dataDir string) error {
+	if _, err := os.Stat(filepath.Join(baseBackup, "PG_VERSION")); err == nil {
+		return os.CopyFS(dataDir, os.DirFS(baseBackup))
+	}
+
+	base, err := findArchive(baseBackup, "base.tar")
+	if err != nil {
+		return err
+	}
+	if err := extractTar(ctx, base, dataDir); err != nil {
+		return err
+	}
+
+	// pg_wal.tar is only written with --wal-method stream
+	if wal, err := findArchive(baseBackup, "pg_wal.tar"); err == nil {
+		return extractTar(ctx, wal, filepath.Join(dataDir, "pg_wal"))
+	}
+	return nil
+}
+
+// findArchive returns the path of name in dir, allowing the extensions added by
+// pg_basebackup compression
+func findArchive(dir, name string) (string, error) {
+	for _, ext := range []string{"", ".gz", ".zst"} {
+		p := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found in %s: %w", name, dir, fs.ErrNotExist)
+}
+
+// extractTar extracts a possibly compressed tar archive into dir, refusing entries that
+// would be written outside of it
+func extractTar(ctx context.Context, archive, dir string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	input := bufio.NewReader(file)
+	decompressed, err := newDecompressReader(input, detectCompression(input))
+	if err != nil {
+		return err
+	}
+	defer decompressed.Close()
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	reader := tar.NewReader(decompressed)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if rel, err := filepath.Rel(dir, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %q is outside of the data directory", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeTarFile(reader, target, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			// Tablespaces are linked from pg_tblspc
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeTarFile(r io.Reader, target string, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeRecoveryConfig appends the recovery settings to postgresql.auto.conf and creates
+// recovery.signal so PostgreSQL starts in targeted recovery
+func writeRecoveryConfig(dataDir, settings string) error {
+	conf, err := os.OpenFile(filepath.Join(dataDir, "postgresql.auto.conf"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := conf.WriteString("\n" + settings); err != nil {
+		conf.Close()
+		return err
+	}
+	if err := conf.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dataDir, "recovery.signal"), nil, 0o600)
+}
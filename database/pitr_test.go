@@ -0,0 +1,160 @@
+package database
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecoveryOptionsValidate(t *testing.T) {
+	valid := RecoveryOptions{
+		BaseBackup:    "/backups/basebackup_20250101_030000",
+		DataDir:       "/var/lib/postgresql/recovered",
+		WALArchiveDir: "/archive",
+		TargetTime:    time.Now().Add(-time.Hour),
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(o *RecoveryOptions)
+		wantErr bool
+	}{
+		{"valid", func(o *RecoveryOptions) {}, false},
+		{"restore command instead of archive", func(o *RecoveryOptions) { o.WALArchiveDir, o.RestoreCommand = "", "aws s3 cp s3://wal/%f %p" }, false},
+		{"missing data dir", func(o *RecoveryOptions) { o.DataDir = "" }, true},
+		{"missing target time", func(o *RecoveryOptions) { o.TargetTime = time.Time{} }, true},
+		{"future target time", func(o *RecoveryOptions) { o.TargetTime = time.Now().Add(time.Hour) }, true},
+		{"missing WAL source", func(o *RecoveryOptions) { o.WALArchiveDir = "" }, true},
+		{"invalid action", func(o *RecoveryOptions) { o.TargetAction = "restart" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := valid
+			tt.modify(&opts)
+			if err := opts.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecoverySettings(t *testing.T) {
+	target := time.Date(2025, 1, 2, 15, 4, 5, 0, time.FixedZone("CET", 3600))
+	settings := recoverySettings(RecoveryOptions{
+		RestoreCommand: RestoreCommand("/mnt/o'brien/wal"),
+		TargetTime:     target,
+		TargetAction:   RecoveryActionPause,
+	})
+
+	for _, want := range []string{
+		`restore_command = 'cp "/mnt/o''brien/wal/%f" "%p"'`,
+		`recovery_target_time = '2025-01-02 15:04:05+01:00'`,
+		`recovery_target_action = 'pause'`,
+	} {
+		if !strings.Contains(settings, want) {
+			t.Errorf("Expected %q in settings:\n%s", want, settings)
+		}
+	}
+}
+
+func TestPrepareRecovery(t *testing.T) {
+	baseBackup := t.TempDir()
+	writeTestTar(t, filepath.Join(baseBackup, "base.tar.gz"), map[string]string{
+		"PG_VERSION":           "17\n",
+		"postgresql.auto.conf": "# Do not edit this file manually!\n",
+		"pg_wal/":              "",
+		"global/pg_control":    "control",
+	})
+	writeTestTar(t, filepath.Join(baseBackup, "pg_wal.tar.gz"), map[string]string{
+		"000000010000000000000002": "wal",
+	})
+
+	dataDir := filepath.Join(t.TempDir(), "recovered")
+	plan, err := PrepareRecovery(context.Background(), RecoveryOptions{
+		BaseBackup:    baseBackup,
+		DataDir:       dataDir,
+		WALArchiveDir: t.TempDir(),
+		TargetTime:    time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("PrepareRecovery failed: %v", err)
+	}
+	if len(plan.Steps) == 0 {
+		t.Error("Expected the remaining recovery steps")
+	}
+
+	for _, name := range []string{"PG_VERSION", "global/pg_control", "pg_wal/000000010000000000000002", "recovery.signal"} {
+		if _, err := os.Stat(filepath.Join(dataDir, name)); err != nil {
+			t.Errorf("Expected %s in data directory: %v", name, err)
+		}
+	}
+
+	conf, _ := os.ReadFile(filepath.Join(dataDir, "postgresql.auto.conf"))
+	if !strings.HasPrefix(string(conf), "# Do not edit") || !strings.Contains(string(conf), "recovery_target_action = 'promote'") {
+		t.Errorf("Expected recovery settings appended to postgresql.auto.conf:\n%s", conf)
+	}
+
+	_, err = PrepareRecovery(context.Background(), RecoveryOptions{
+		BaseBackup:    baseBackup,
+		DataDir:       dataDir,
+		WALArchiveDir: t.TempDir(),
+		TargetTime:    time.Now().Add(-time.Minute),
+	})
+	if err == nil {
+		t.Error("Expected a non-empty data directory to be refused")
+	}
+}
+
+func TestExtractTarRejectsTraversal(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "base.tar.gz")
+	writeTestTar(t, archive, map[string]string{"../escape": "x"})
+
+	if err := extractTar(context.Background(), archive, t.TempDir()); err == nil {
+		t.Error("Expected an entry outside the data directory to be refused")
+	}
+}
+
+func TestWALArchiveStatusEnabled(t *testing.T) {
+	if !(WALArchiveStatus{WALLevel: "replica", ArchiveMode: "on"}).Enabled() {
+		t.Error("Expected archiving to be enabled")
+	}
+	if (WALArchiveStatus{WALLevel: "replica", ArchiveMode: "off"}).Enabled() {
+		t.Error("Expected archiving to be disabled")
+	}
+}
+
+// writeTestTar writes a gzipped tar archive; names ending in / are directories
+func writeTestTar(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(name, "/") {
+			header = &tar.Header{Name: name, Mode: 0o700, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Query result formats
+const (
+	QueryFormatTable = "table"
+	QueryFormatJSON  = "json"
+	QueryFormatYAML  = "yaml"
+	QueryFormatCSV   = "csv"
+)
+
+// QueryOptions configures Query
+type QueryOptions struct {
+	// MaxRows stops reading after this many rows and sets QueryResult.Truncated; all rows
+	// by default
+	MaxRows int
+}
+
+// QueryResult holds the rows returned by a statement
+type QueryResult struct {
+	Columns []string `json:"columns"`
+	// Rows hold one value per column: nil for NULL, int64, float64, bool or time.Time for
+	// the types the driver decodes, and strings for all others. bytea values are strings in
+	// the hex format, e.g. \x0102.
+	Rows [][]interface{} `json:"rows"`
+	// Truncated is set when rows beyond MaxRows were left out
+	Truncated bool          `json:"truncated"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Query runs an ad-hoc statement and returns its rows, e.g. for the CLI. Statements that
+// return no rows yield a result without columns. Unlike the other operations, the
+// statement is not retried on transient failures since it may have side effects.
+func (d *DB) Query(ctx context.Context, query string, opts QueryOptions) (*QueryResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, NewValidationError("no statement to run", nil).
+			WithOperation("query")
+	}
+	if opts.MaxRows < 0 {
+		return nil, NewValidationError(fmt.Sprintf("invalid row limit %d", opts.MaxRows), nil).
+			WithContext("max_rows", opts.MaxRows).
+			WithOperation("query")
+	}
+
+	if err := d.ValidateConnection(ctx); err != nil {
+		return nil, WrapError(err, ErrCodeConnectionFailed, "query", "connection validation failed")
+	}
+
+	var result *QueryResult
+	err := d.withQueryHooks(ctx, &QueryEvent{Operation: "query", Query: query}, func(ctx context.Context) (int64, error) {
+		var err error
+		if result, err = d.readQuery(ctx, query, opts); err != nil {
+			return -1, err
+		}
+		return int64(len(result.Rows)), nil
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "query", "query failed")
+	}
+	return result, nil
+}
+
+// readQuery runs query and reads up to opts.MaxRows of its rows
+func (d *DB) readQuery(ctx context.Context, query string, opts QueryOptions) (*QueryResult, error) {
+	start := time.Now()
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "query", "failed to read result columns")
+	}
+
+	result := &QueryResult{Columns: make([]string, len(columnTypes)), Rows: [][]interface{}{}}
+	for i, column := range columnTypes {
+		result.Columns[i] = column.Name()
+	}
+
+	for rows.Next() {
+		if opts.MaxRows > 0 && len(result.Rows) == opts.MaxRows {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(columnTypes))
+		pointers := make([]interface{}, len(values))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, WrapError(err, ErrCodeQueryFailed, "query", "failed to scan row")
+		}
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				if columnTypes[i].DatabaseTypeName() == "BYTEA" {
+					values[i] = `\x` + hex.EncodeToString(b)
+				} else {
+					values[i] = string(b)
+				}
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "query", "failed to read rows")
+	}
+	result.Duration = time.Since(start)
+
+	return result, nil
+}
+
+// Encode writes the rows to w as an aligned table, as a JSON or YAML list with an object
+// per row or as CSV with a header. NULL is written as NULL in tables, null in JSON and YAML
+// and an empty field in CSV.
+func (r *QueryResult) Encode(w io.Writer, format string) error {
+	var err error
+	switch format {
+	case "", QueryFormatTable:
+		err = r.encodeTable(w)
+	case QueryFormatJSON:
+		err = r.encodeJSON(w)
+	case QueryFormatYAML:
+		err = r.encodeYAML(w)
+	case QueryFormatCSV:
+		err = r.encodeCSV(w)
+	default:
+		return NewValidationError(fmt.Sprintf("unsupported query result format %q", format), nil).
+			WithContext("format", format).
+			WithOperation("encode_query_result")
+	}
+	if err != nil {
+		return NewDBError(ErrCodeInternal, "failed to write query result", err).
+			WithOperation("encode_query_result")
+	}
+	return nil
+}
+
+// tableCellReplacer removes the tabs and newlines that would break the table alignment
+var tableCellReplacer = strings.NewReplacer("\t", " ", "\n", " ", "\r", "")
+
+func (r *QueryResult) encodeTable(w io.Writer) error {
+	if len(r.Columns) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	separators := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		separators[i] = strings.Repeat("-", max(len(column), 1))
+	}
+	fmt.Fprintln(tw, strings.Join(r.Columns, "\t"))
+	fmt.Fprintln(tw, strings.Join(separators, "\t"))
+
+	fields := make([]string, len(r.Columns))
+	for _, row := range r.Rows {
+		for i, value := range row {
+			if value == nil {
+				fields[i] = "NULL"
+				continue
+			}
+			fields[i] = tableCellReplacer.Replace(formatValue(value))
+		}
+		fmt.Fprintln(tw, strings.Join(fields, "\t"))
+	}
+	return tw.Flush()
+}
+
+// encodeJSON writes the rows as objects keyed by column, keeping the column order
+func (r *QueryResult) encodeJSON(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("[")
+	for i, row := range r.Rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for j, value := range row {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			name, err := json.Marshal(r.Columns[j])
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			b.Write(name)
+			b.WriteString(": ")
+			b.Write(data)
+		}
+		b.WriteString("}")
+	}
+	if len(r.Rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// encodeYAML writes the rows as mappings keyed by column, keeping the column order
+func (r *QueryResult) encodeYAML(w io.Writer) error {
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, row := range r.Rows {
+		object := &yaml.Node{Kind: yaml.MappingNode}
+		for i, value := range row {
+			var node yaml.Node
+			if err := node.Encode(value); err != nil {
+				return err
+			}
+			name := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: r.Columns[i]}
+			object.Content = append(object.Content, name, &node)
+		}
+		list.Content = append(list.Content, object)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(list); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func (r *QueryResult) encodeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.Columns); err != nil {
+		return err
+	}
+	fields := make([]string, len(r.Columns))
+	for _, row := range r.Rows {
+		for i, value := range row {
+			fields[i] = ""
+			if value != nil {
+				fields[i] = formatValue(value)
+			}
+		}
+		if err := cw.Write(fields); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatValue formats a non-NULL value of a QueryResult as text
+func formatValue(value interface{}) string {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
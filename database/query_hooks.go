@@ -0,0 +1,201 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// QueryEvent describes a statement run through DB or Transaction
+type QueryEvent struct {
+	// Operation names the method running the statement, e.g. "transaction_exec_context"
+	Operation string
+	Query     string
+	Args      []interface{}
+	Start     time.Time
+
+	// Set for AfterQuery: Rows is the number of rows affected or returned, or -1 when it
+	// is not known, and Err the error of the statement or of a BeforeQuery hook
+	Duration time.Duration
+	Rows     int64
+	Err      error
+}
+
+// TxEvent describes a transaction of DB.WithTransaction or DB.WithTransactionIsolation
+type TxEvent struct {
+	Operation string
+	Isolation sql.IsolationLevel
+	Start     time.Time
+
+	// Set for AfterTx: Err is nil if the transaction was committed
+	Duration time.Duration
+	Err      error
+}
+
+// Hook intercepts the statements and transactions of a DB, e.g. for logging, metrics,
+// tracing or policies such as DenyWrites. Hooks run in the order they were registered;
+// BeforeQuery and BeforeTx may return a derived context, e.g. carrying a span, and an
+// error to fail the statement or transaction before it is sent to the server. The After
+// methods of the hooks whose Before method ran are called in reverse order, also when a
+// Before method failed. Embed BaseHook to only implement some of the methods.
+type Hook interface {
+	BeforeQuery(ctx context.Context, event *QueryEvent) (context.Context, error)
+	AfterQuery(ctx context.Context, event *QueryEvent)
+	BeforeTx(ctx context.Context, event *TxEvent) (context.Context, error)
+	AfterTx(ctx context.Context, event *TxEvent)
+}
+
+// BaseHook implements Hook without doing anything
+type BaseHook struct{}
+
+// BeforeQuery implements Hook
+func (BaseHook) BeforeQuery(ctx context.Context, _ *QueryEvent) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterQuery implements Hook
+func (BaseHook) AfterQuery(context.Context, *QueryEvent) {}
+
+// BeforeTx implements Hook
+func (BaseHook) BeforeTx(ctx context.Context, _ *TxEvent) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterTx implements Hook
+func (BaseHook) AfterTx(context.Context, *TxEvent) {}
+
+// newHooks returns the hooks of a DB: statement logging if configured, the audit recorder
+// if any, then Config.Hooks, so statements failed by those are logged and audited too
+func newHooks(config Config, logger *slog.Logger, audit *auditRecorder) []Hook {
+	var hooks []Hook
+	if config.LogQueries || config.SlowQueryThreshold > 0 {
+		hooks = append(hooks, queryLogHook{logger: logger, all: config.LogQueries, slow: config.SlowQueryThreshold})
+	}
+	if audit != nil {
+		hooks = append(hooks, audit)
+	}
+	return append(hooks, config.Hooks...)
+}
+
+// AddHook registers hook after the hooks of Config.Hooks. Hooks must be added before the
+// DB is used by several goroutines.
+func (d *DB) AddHook(hook Hook) {
+	d.hooks = append(d.hooks, hook)
+}
+
+// withQueryHooks runs op, the statement of event, between the BeforeQuery and AfterQuery
+// hooks. op returns the rows affected or returned, or -1.
+func (d *DB) withQueryHooks(ctx context.Context, event *QueryEvent, op func(ctx context.Context) (int64, error)) error {
+	event.Start = time.Now()
+	event.Rows = -1
+
+	ran := 0
+	var err error
+	for _, hook := range d.hooks {
+		ran++
+		if ctx, err = hook.BeforeQuery(ctx, event); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		event.Rows, err = op(ctx)
+	}
+
+	event.Duration = time.Since(event.Start)
+	event.Err = err
+	for i := ran - 1; i >= 0; i-- {
+		d.hooks[i].AfterQuery(ctx, event)
+	}
+	return err
+}
+
+// withTxHooks runs op, the transaction of event, between the BeforeTx and AfterTx hooks
+func (d *DB) withTxHooks(ctx context.Context, event *TxEvent, op func(ctx context.Context) error) error {
+	event.Start = time.Now()
+
+	ran := 0
+	var err error
+	for _, hook := range d.hooks {
+		ran++
+		if ctx, err = hook.BeforeTx(ctx, event); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = op(ctx)
+	}
+
+	event.Duration = time.Since(event.Start)
+	event.Err = err
+	for i := ran - 1; i >= 0; i-- {
+		d.hooks[i].AfterTx(ctx, event)
+	}
+	return err
+}
+
+// withQueryHooks runs op through the hooks of the transaction's DB. Transactions of Go
+// migrations run by a migrator not created through New are not bound to a DB and run op
+// directly.
+func (t *Transaction) withQueryHooks(ctx context.Context, operation, query string, args []interface{}, op func(ctx context.Context) (int64, error)) error {
+	if t.db == nil {
+		_, err := op(ctx)
+		return err
+	}
+	return t.db.withQueryHooks(ctx, &QueryEvent{Operation: operation, Query: query, Args: args}, op)
+}
+
+// readStatements are the statement types DenyWrites lets through
+var readStatements = map[string]bool{
+	"SELECT": true, "SHOW": true, "EXPLAIN": true, "VALUES": true, "TABLE": true,
+	"FETCH": true, "WITH": true,
+}
+
+// dataModifyingKeywords make a WITH statement a write
+var dataModifyingKeywords = []string{"INSERT", "UPDATE", "DELETE", "MERGE"}
+
+// DenyWrites returns a Hook failing every statement that is not a read, e.g. SELECT,
+// SHOW or EXPLAIN, with a validation error, to guard connections meant for reporting.
+// SELECT ... FOR UPDATE and functions with side effects are not detected.
+func DenyWrites() Hook {
+	return denyWritesHook{}
+}
+
+type denyWritesHook struct {
+	BaseHook
+}
+
+func (denyWritesHook) BeforeQuery(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	for _, statement := range SplitStatements(event.Query) {
+		if !isReadStatement(statement) {
+			return ctx, NewValidationError(fmt.Sprintf("%s statements are not allowed, writes are denied", statement.Type), nil).
+				WithContext("line", statement.Line).
+				WithOperation(event.Operation)
+		}
+	}
+	return ctx, nil
+}
+
+// isReadStatement reports whether statement only reads data
+func isReadStatement(statement Statement) bool {
+	if !readStatements[statement.Type] {
+		return false
+	}
+	if statement.Type != "WITH" {
+		return true
+	}
+	words := strings.FieldsFunc(strings.ToUpper(statement.SQL), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, word := range words {
+		for _, keyword := range dataModifyingKeywords {
+			if word == keyword {
+				return false
+			}
+		}
+	}
+	return true
+}
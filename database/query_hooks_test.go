@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+)
+
+type hookTestKey struct{}
+
+// recordingHook records the hook calls in calls, failing BeforeQuery with err if set
+type recordingHook struct {
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (h recordingHook) BeforeQuery(ctx context.Context, _ *QueryEvent) (context.Context, error) {
+	*h.calls = append(*h.calls, "before_query:"+h.name)
+	return context.WithValue(ctx, hookTestKey{}, h.name), h.err
+}
+
+func (h recordingHook) AfterQuery(context.Context, *QueryEvent) {
+	*h.calls = append(*h.calls, "after_query:"+h.name)
+}
+
+func (h recordingHook) BeforeTx(ctx context.Context, _ *TxEvent) (context.Context, error) {
+	*h.calls = append(*h.calls, "before_tx:"+h.name)
+	return ctx, h.err
+}
+
+func (h recordingHook) AfterTx(context.Context, *TxEvent) {
+	*h.calls = append(*h.calls, "after_tx:"+h.name)
+}
+
+func newHooksTestDB(hooks ...Hook) *DB {
+	config := Config{Hooks: hooks}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return &DB{config: config, logger: logger, hooks: newHooks(config, logger, nil)}
+}
+
+func TestQueryHooks(t *testing.T) {
+	var calls []string
+	db := newHooksTestDB(recordingHook{name: "a", calls: &calls})
+	db.AddHook(recordingHook{name: "b", calls: &calls})
+
+	event := &QueryEvent{Operation: "query", Query: "SELECT 1"}
+	err := db.withQueryHooks(context.Background(), event, func(ctx context.Context) (int64, error) {
+		calls = append(calls, "query:"+ctx.Value(hookTestKey{}).(string))
+		return 1, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the statement to run, got %v", err)
+	}
+	want := []string{"before_query:a", "before_query:b", "query:b", "after_query:b", "after_query:a"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, calls)
+	}
+	if event.Rows != 1 || event.Err != nil || event.Start.IsZero() {
+		t.Errorf("Expected the event to hold the outcome, got %+v", event)
+	}
+
+	// A failing hook skips the statement and the later hooks
+	calls = nil
+	denied := errors.New("denied")
+	db = newHooksTestDB(recordingHook{name: "a", calls: &calls}, recordingHook{name: "b", calls: &calls, err: denied}, recordingHook{name: "c", calls: &calls})
+	event = &QueryEvent{Operation: "query", Query: "DELETE FROM users"}
+	err = db.withQueryHooks(context.Background(), event, func(context.Context) (int64, error) {
+		calls = append(calls, "query")
+		return 1, nil
+	})
+	if !errors.Is(err, denied) || !errors.Is(event.Err, denied) || event.Rows != -1 {
+		t.Errorf("Expected the hook error, got %v and %+v", err, event)
+	}
+	want = []string{"before_query:a", "before_query:b", "after_query:b", "after_query:a"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, calls)
+	}
+
+	// Transaction hooks
+	calls = nil
+	db = newHooksTestDB(recordingHook{name: "a", calls: &calls})
+	txEvent := &TxEvent{Operation: "with_transaction"}
+	failed := errors.New("failed")
+	if err := db.withTxHooks(context.Background(), txEvent, func(context.Context) error { return failed }); !errors.Is(err, failed) {
+		t.Errorf("Expected the transaction error, got %v", err)
+	}
+	want = []string{"before_tx:a", "after_tx:a"}
+	if !slices.Equal(calls, want) || !errors.Is(txEvent.Err, failed) {
+		t.Errorf("Expected calls %v with the error, got %v and %v", want, calls, txEvent.Err)
+	}
+}
+
+func TestTransactionWithoutDBSkipsHooks(t *testing.T) {
+	ran := false
+	tx := &Transaction{}
+	err := tx.withQueryHooks(context.Background(), "transaction_exec", "SELECT 1", nil, func(context.Context) (int64, error) {
+		ran = true
+		return -1, nil
+	})
+	if err != nil || !ran {
+		t.Errorf("Expected the statement to run without hooks, got %v", err)
+	}
+}
+
+func TestQueryRowHookError(t *testing.T) {
+	tx := &Transaction{db: newHooksTestDB(DenyWrites())}
+	row := tx.QueryRowContext(context.Background(), "DELETE FROM users WHERE id = 1 RETURNING id")
+
+	var id int64
+	err := row.Scan(&id)
+	if GetErrorCode(err) != ErrCodeValidation || errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the validation error of the hook, got %v", err)
+	}
+	if row.Err() != err {
+		t.Errorf("Expected Err to return the hook error, got %v", row.Err())
+	}
+	if _, columnsErr := row.Columns(); columnsErr != err {
+		t.Errorf("Expected Columns to return the hook error, got %v", columnsErr)
+	}
+}
+
+func TestDenyWrites(t *testing.T) {
+	hook := DenyWrites()
+	for _, query := range []string{
+		"SELECT * FROM users",
+		"  -- report\nselect count(*) from orders",
+		"WITH recent AS (SELECT * FROM orders) SELECT * FROM recent",
+		"EXPLAIN SELECT 1",
+		"SHOW search_path",
+	} {
+		if _, err := hook.BeforeQuery(context.Background(), &QueryEvent{Operation: "query", Query: query}); err != nil {
+			t.Errorf("Expected %q to be allowed, got %v", query, err)
+		}
+	}
+	for _, query := range []string{
+		"INSERT INTO users (name) VALUES ('a')",
+		"UPDATE users SET name = 'b'",
+		"DROP TABLE users",
+		"WITH gone AS (DELETE FROM users RETURNING id) SELECT count(*) FROM gone",
+		"SELECT 1; TRUNCATE users",
+	} {
+		_, err := hook.BeforeQuery(context.Background(), &QueryEvent{Operation: "query", Query: query})
+		if GetErrorCode(err) != ErrCodeValidation {
+			t.Errorf("Expected %q to be denied, got %v", query, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testQueryResult() *QueryResult {
+	return &QueryResult{
+		Columns: []string{"id", "email", "note", "created_at"},
+		Rows: [][]interface{}{
+			{int64(1), "ada@example.com", "first\tline\nsecond", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+			{int64(2), "grace@example.com", nil, nil},
+		},
+	}
+}
+
+func TestQueryResultEncodeTable(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testQueryResult().Encode(&buf, QueryFormatTable))
+	assert.Equal(t, `id  email              note               created_at
+--  -----              ----               ----------
+1   ada@example.com    first line second  2024-05-01T12:00:00Z
+2   grace@example.com  NULL               NULL
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, (&QueryResult{}).Encode(&buf, QueryFormatTable))
+	assert.Empty(t, buf.String())
+}
+
+func TestQueryResultEncodeJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testQueryResult().Encode(&buf, QueryFormatJSON))
+	assert.Equal(t, `[
+  {"id": 1, "email": "ada@example.com", "note": "first\tline\nsecond", "created_at": "2024-05-01T12:00:00Z"},
+  {"id": 2, "email": "grace@example.com", "note": null, "created_at": null}
+]
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, (&QueryResult{Columns: []string{"id"}}).Encode(&buf, QueryFormatJSON))
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestQueryResultEncodeYAML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testQueryResult().Encode(&buf, QueryFormatYAML))
+	assert.Equal(t, `- id: 1
+  email: ada@example.com
+  note: |-
+    first	line
+    second
+  created_at: 2024-05-01T12:00:00Z
+- id: 2
+  email: grace@example.com
+  note: null
+  created_at: null
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, (&QueryResult{Columns: []string{"id"}}).Encode(&buf, QueryFormatYAML))
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestQueryResultEncodeCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testQueryResult().Encode(&buf, QueryFormatCSV))
+	assert.Equal(t, `id,email,note,created_at
+1,ada@example.com,"first	line
+second",2024-05-01T12:00:00Z
+2,grace@example.com,,
+`, buf.String())
+}
+
+func TestQueryResultEncodeInvalidFormat(t *testing.T) {
+	err := testQueryResult().Encode(&bytes.Buffer{}, "xml")
+	require.Error(t, err)
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestQueryValidation(t *testing.T) {
+	db := &DB{}
+
+	_, err := db.Query(context.Background(), "  \n", QueryOptions{})
+	require.Error(t, err)
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+
+	_, err = db.Query(context.Background(), "SELECT 1", QueryOptions{MaxRows: -1})
+	require.Error(t, err)
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestQuery(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+	ctx := context.Background()
+
+	result, err := db.Query(ctx, `SELECT n AS id, 'row ' || n AS label, NULL::text AS note, '\x0102'::bytea AS data
+		FROM generate_series(1, 5) n`, QueryOptions{MaxRows: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "label", "note", "data"}, result.Columns)
+	require.Len(t, result.Rows, 3)
+	assert.True(t, result.Truncated)
+	assert.Equal(t, []interface{}{int64(1), "row 1", nil, `\x0102`}, result.Rows[0])
+
+	result, err = db.Query(ctx, "SELECT 1 WHERE false", QueryOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Rows)
+	assert.False(t, result.Truncated)
+
+	_, err = db.Query(ctx, "SELEC 1", QueryOptions{})
+	require.Error(t, err)
+	assert.Equal(t, ErrCodeQueryFailed, GetErrorCode(err))
+}
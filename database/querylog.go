@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// queryLogLength is the length statements are shortened to in query logs
+const queryLogLength = 200
+
+// queryLogHook logs statements at INFO when all is set, and at WARN when they ran for at
+// least slow. It is the first hook of a DB with Config.LogQueries or
+// Config.SlowQueryThreshold.
+type queryLogHook struct {
+	BaseHook
+	logger *slog.Logger
+	all    bool
+	slow   time.Duration
+}
+
+func (h queryLogHook) AfterQuery(ctx context.Context, event *QueryEvent) {
+	slow := h.slow > 0 && event.Duration >= h.slow
+	if !slow && !h.all {
+		return
+	}
+
+	level, message := slog.LevelInfo, "query executed"
+	if slow {
+		level, message = slog.LevelWarn, "slow query"
+	}
+	attrs := []slog.Attr{
+		slog.String("operation", event.Operation),
+		slog.Duration("duration", event.Duration),
+	}
+	if event.Rows >= 0 && event.Err == nil {
+		attrs = append(attrs, slog.Int64("rows", event.Rows))
+	}
+	attrs = append(attrs, slog.String("query", shortenQuery(event.Query, queryLogLength)))
+	if event.Err != nil {
+		attrs = append(attrs, slog.Any("error", event.Err))
+	}
+	h.logger.LogAttrs(ctx, level, message, attrs...)
+}
+
+// shortenQuery returns query on one line with its whitespace collapsed, shortened to
+// length characters
+func shortenQuery(query string, length int) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if runes := []rune(query); len(runes) > length {
+		return string(runes[:length-3]) + "..."
+	}
+	return query
+}
+
+// rowsAffected returns the rows affected by a statement, or -1 when unknown
+func rowsAffected(result sql.Result) int64 {
+	if result == nil {
+		return -1
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return rows
+}
+
+// destRows returns the number of rows scanned into dest, a pointer to a slice, or -1
+func destRows(dest interface{}) int64 {
+	value := reflect.Indirect(reflect.ValueOf(dest))
+	if value.Kind() != reflect.Slice {
+		return -1
+	}
+	return int64(value.Len())
+}
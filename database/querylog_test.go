@@ -0,0 +1,89 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newQueryLogTestDB(config Config) (*DB, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return &DB{config: config, logger: logger, hooks: newHooks(config, logger, nil)}, &buf
+}
+
+// runTestQuery runs query through the hooks of db as a statement returning rows and err
+func runTestQuery(db *DB, operation, query string, rows int64, err error) {
+	_ = db.withQueryHooks(context.Background(), &QueryEvent{Operation: operation, Query: query}, func(context.Context) (int64, error) {
+		return rows, err
+	})
+}
+
+func TestQueryLog(t *testing.T) {
+	db, buf := newQueryLogTestDB(Config{})
+	if len(db.hooks) != 0 {
+		t.Errorf("Expected no hooks without statement logging, got %d", len(db.hooks))
+	}
+	runTestQuery(db, "query", "SELECT 1", 1, nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no logs without LogQueries, got %q", buf.String())
+	}
+
+	db, buf = newQueryLogTestDB(Config{LogQueries: true})
+	runTestQuery(db, "transaction_exec", "UPDATE users\n   SET active = true", 3, nil)
+	logged := buf.String()
+	for _, want := range []string{"level=INFO", `msg="query executed"`, "operation=transaction_exec", "rows=3", `query="UPDATE users SET active = true"`} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("Expected %s in %q", want, logged)
+		}
+	}
+
+	buf.Reset()
+	runTestQuery(db, "transaction_get", "SELECT name FROM users", 1, errors.New("no rows"))
+	if logged := buf.String(); strings.Contains(logged, "rows=") || !strings.Contains(logged, `error="no rows"`) {
+		t.Errorf("Expected the error without rows, got %q", logged)
+	}
+
+	buf.Reset()
+	runTestQuery(db, "transaction_query", "SELECT * FROM users", -1, nil)
+	if logged := buf.String(); strings.Contains(logged, "rows=") {
+		t.Errorf("Expected no rows when unknown, got %q", logged)
+	}
+
+	// Slow statements are logged without LogQueries
+	db, buf = newQueryLogTestDB(Config{SlowQueryThreshold: time.Second})
+	runTestQuery(db, "query", "SELECT 1", 1, nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no logs for a fast statement, got %q", buf.String())
+	}
+	db.hooks[0].AfterQuery(context.Background(), &QueryEvent{Operation: "query", Query: "SELECT pg_sleep(2)", Duration: 2 * time.Second, Rows: 1})
+	if logged := buf.String(); !strings.Contains(logged, "level=WARN") || !strings.Contains(logged, `msg="slow query"`) {
+		t.Errorf("Expected a slow query warning, got %q", logged)
+	}
+}
+
+func TestShortenQuery(t *testing.T) {
+	if got := shortenQuery("SELECT *\n\tFROM users", 80); got != "SELECT * FROM users" {
+		t.Errorf("Expected the query on one line, got %q", got)
+	}
+	long := "SELECT " + strings.Repeat("column, ", 50) + "id FROM users"
+	got := shortenQuery(long, 40)
+	if len(got) != 40 || !strings.HasSuffix(got, "...") {
+		t.Errorf("Expected the query shortened to 40 characters, got %q", got)
+	}
+}
+
+func TestDestRows(t *testing.T) {
+	names := []string{"a", "b"}
+	if got := destRows(&names); got != 2 {
+		t.Errorf("Expected 2 rows, got %d", got)
+	}
+	var name string
+	if got := destRows(&name); got != -1 {
+		t.Errorf("Expected -1 for a single value, got %d", got)
+	}
+}
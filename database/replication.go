@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PublicationInfo is a logical replication publication of the current database
+type PublicationInfo struct {
+	Name  string `json:"name" db:"publication_name"`
+	Owner string `json:"owner" db:"owner"`
+	// AllTables is set for publications created FOR ALL TABLES
+	AllTables bool `json:"all_tables" db:"all_tables"`
+	// Insert, Update, Delete and Truncate are the published operations
+	Insert   bool `json:"insert" db:"publishes_insert"`
+	Update   bool `json:"update" db:"publishes_update"`
+	Delete   bool `json:"delete" db:"publishes_delete"`
+	Truncate bool `json:"truncate" db:"publishes_truncate"`
+	// Tables are the schema-qualified published tables
+	Tables pq.StringArray `json:"tables" db:"tables"`
+}
+
+// SubscriptionInfo is a logical replication subscription of the current database with the
+// status of its apply worker
+type SubscriptionInfo struct {
+	Name         string         `json:"name" db:"subscription_name"`
+	Owner        string         `json:"owner" db:"owner"`
+	Enabled      bool           `json:"enabled" db:"enabled"`
+	Publications pq.StringArray `json:"publications" db:"publications"`
+	// SlotName is the replication slot on the publisher, nil if the subscription has none
+	SlotName *string `json:"slot_name,omitempty" db:"slot_name"`
+	// WorkerPID is the apply worker, nil if it is not running
+	WorkerPID *int64 `json:"worker_pid,omitempty" db:"worker_pid"`
+	// ReceivedLSN is the last WAL location received from the publisher
+	ReceivedLSN *string `json:"received_lsn,omitempty" db:"received_lsn"`
+	// LastMessageAt is when the last message from the publisher was received
+	LastMessageAt *time.Time `json:"last_message_at,omitempty" db:"last_message_at"`
+}
+
+// ReplicationSlot is a physical or logical replication slot of the server
+type ReplicationSlot struct {
+	Name string `json:"name" db:"slot_name"`
+	// Type is physical or logical
+	Type string `json:"type" db:"slot_type"`
+	// Plugin is the output plugin of logical slots
+	Plugin *string `json:"plugin,omitempty" db:"plugin"`
+	// Database is the database of logical slots
+	Database  *string `json:"database,omitempty" db:"database"`
+	Temporary bool    `json:"temporary" db:"temporary"`
+	Active    bool    `json:"active" db:"active"`
+	ActivePID *int64  `json:"active_pid,omitempty" db:"active_pid"`
+	// RestartLSN is the oldest WAL location the slot's consumer may still need
+	RestartLSN *string `json:"restart_lsn,omitempty" db:"restart_lsn"`
+	// ConfirmedFlushLSN is the location up to which a logical slot's consumer confirmed
+	// receiving data
+	ConfirmedFlushLSN *string `json:"confirmed_flush_lsn,omitempty" db:"confirmed_flush_lsn"`
+	// RetainedWALBytes is the WAL kept on disk for the slot, from RestartLSN to the current
+	// location. An inactive slot retains WAL until it is dropped and can fill the disk.
+	RetainedWALBytes *int64 `json:"retained_wal_bytes,omitempty" db:"retained_wal_bytes"`
+	// LagBytes is how far a logical slot's consumer is behind the current location
+	LagBytes *int64 `json:"lag_bytes,omitempty" db:"lag_bytes"`
+	// WALStatus is reserved, extended, unreserved or lost, nil before PostgreSQL 13
+	WALStatus *string `json:"wal_status,omitempty" db:"wal_status"`
+}
+
+// StandbyInfo is a standby or other WAL receiver streaming from the server, with how far it
+// is behind
+type StandbyInfo struct {
+	PID         int64   `json:"pid" db:"pid"`
+	Application string  `json:"application" db:"application_name"`
+	ClientAddr  *string `json:"client_addr,omitempty" db:"client_addr"`
+	// State is startup, catchup, streaming, backup or stopping
+	State string `json:"state" db:"state"`
+	// SyncState is async, potential, sync or quorum
+	SyncState string `json:"sync_state" db:"sync_state"`
+	// ReplayLagBytes is the WAL the standby has yet to replay
+	ReplayLagBytes *int64 `json:"replay_lag_bytes,omitempty" db:"replay_lag_bytes"`
+	// ReplayLag is how long recent WAL took to be replayed on the standby, 0 when it is
+	// caught up and idle
+	ReplayLag time.Duration `json:"replay_lag" db:"replay_lag"`
+}
+
+// GetPublications retrieves the publications of the current database with their tables
+func (is *IntrospectionService) GetPublications(ctx context.Context) ([]PublicationInfo, error) {
+	publications := []PublicationInfo{}
+
+	query := `
+		SELECT
+			p.pubname as publication_name,
+			pg_get_userbyid(p.pubowner) as owner,
+			p.puballtables as all_tables,
+			p.pubinsert as publishes_insert,
+			p.pubupdate as publishes_update,
+			p.pubdelete as publishes_delete,
+			p.pubtruncate as publishes_truncate,
+			ARRAY(
+				SELECT pt.schemaname || '.' || pt.tablename
+				FROM pg_publication_tables pt
+				WHERE pt.pubname = p.pubname
+				ORDER BY pt.schemaname, pt.tablename
+			) as tables
+		FROM pg_publication p
+		ORDER BY p.pubname
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &publications, query)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_publications", "failed to get publications")
+	}
+
+	return publications, nil
+}
+
+// GetSubscriptions retrieves the subscriptions of the current database. The connection
+// strings are left out since only superusers may read them.
+func (is *IntrospectionService) GetSubscriptions(ctx context.Context) ([]SubscriptionInfo, error) {
+	subscriptions := []SubscriptionInfo{}
+
+	// The apply worker is the pg_stat_subscription row without relid; table
+	// synchronization workers have one
+	query := `
+		SELECT
+			s.subname as subscription_name,
+			pg_get_userbyid(s.subowner) as owner,
+			s.subenabled as enabled,
+			s.subpublications as publications,
+			s.subslotname as slot_name,
+			st.pid as worker_pid,
+			st.received_lsn::text as received_lsn,
+			st.last_msg_receipt_time as last_message_at
+		FROM pg_subscription s
+		LEFT JOIN pg_stat_subscription st ON st.subid = s.oid AND st.relid IS NULL
+		WHERE s.subdbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+		ORDER BY s.subname
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &subscriptions, query)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_subscriptions", "failed to get subscriptions")
+	}
+
+	return subscriptions, nil
+}
+
+// GetReplicationSlots retrieves the replication slots of the server, those retaining the
+// most WAL first
+func (is *IntrospectionService) GetReplicationSlots(ctx context.Context) ([]ReplicationSlot, error) {
+	slots := []ReplicationSlot{}
+
+	// On a standby the current location is the last WAL received. wal_status is read
+	// through to_jsonb since the column only exists from PostgreSQL 13.
+	query := `
+		WITH wal AS (
+			SELECT CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END as lsn
+		)
+		SELECT
+			s.slot_name,
+			s.slot_type,
+			s.plugin,
+			s.database,
+			s.temporary,
+			s.active,
+			s.active_pid,
+			s.restart_lsn::text as restart_lsn,
+			s.confirmed_flush_lsn::text as confirmed_flush_lsn,
+			pg_wal_lsn_diff(wal.lsn, s.restart_lsn)::bigint as retained_wal_bytes,
+			pg_wal_lsn_diff(wal.lsn, s.confirmed_flush_lsn)::bigint as lag_bytes,
+			to_jsonb(s) ->> 'wal_status' as wal_status
+		FROM pg_replication_slots s, wal
+		ORDER BY retained_wal_bytes DESC NULLS LAST, s.slot_name
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &slots, query)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_replication_slots", "failed to get replication slots")
+	}
+
+	return slots, nil
+}
+
+// GetStandbys retrieves the standbys streaming WAL from the server, the furthest behind
+// first. Standbys of a standby are listed on it, not on the primary.
+func (is *IntrospectionService) GetStandbys(ctx context.Context) ([]StandbyInfo, error) {
+	standbys := []StandbyInfo{}
+
+	query := `
+		SELECT
+			r.pid,
+			coalesce(r.application_name, '') as application_name,
+			host(r.client_addr) as client_addr,
+			r.state,
+			r.sync_state,
+			pg_wal_lsn_diff(CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END,
+				r.replay_lsn)::bigint as replay_lag_bytes,
+			coalesce(extract(epoch FROM r.replay_lag) * 1000000000, 0)::bigint as replay_lag
+		FROM pg_stat_replication r
+		ORDER BY replay_lag_bytes DESC NULLS LAST, r.pid
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &standbys, query)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_standbys", "failed to get standbys")
+	}
+
+	return standbys, nil
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// RestorePlan describes what RestoreWithOptions would do with a backup, without touching
+// the database
+type RestorePlan struct {
+	Backup   string `json:"backup"`
+	Database string `json:"database"`
+	Host     string `json:"host"`
+	// Tool is pg_restore for archives (custom, directory and tar format) and psql for plain
+	// SQL backups
+	Tool        string `json:"tool"`
+	Compression string `json:"compression"`
+	Encrypted   bool   `json:"encrypted"`
+	// Verified is set when a local backup matched its manifest; backups without one, and
+	// those in remote storage, are restored unverified
+	Verified   bool `json:"verified"`
+	CreateDB   bool `json:"create_db"`
+	Clean      bool `json:"clean"`
+	SchemaOnly bool `json:"schema_only"`
+	DataOnly   bool `json:"data_only"`
+}
+
+// PlanRestore checks that the backup at backupPath, a local path or storage URL, can be
+// restored with opts and describes the restore. Only the header of the backup is read.
+func (d *DB) PlanRestore(ctx context.Context, backupPath string, opts RestoreOptions) (*RestorePlan, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	plan := &RestorePlan{
+		Backup:      backupPath,
+		Database:    opts.targetDB(d.config),
+		Host:        d.config.Host,
+		Compression: CompressionNone,
+		CreateDB:    opts.CreateDB,
+		Clean:       opts.Clean,
+		SchemaOnly:  opts.SchemaOnly,
+		DataOnly:    opts.DataOnly,
+	}
+
+	reader, err := d.openBackupForPlan(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	if reader == nil {
+		// Directory format
+		plan.Tool = "pg_restore"
+		return plan, nil
+	}
+	defer reader.Close()
+
+	input, decompressed, compression, encrypted, err := decodeBackup(reader, d.config, backupPath)
+	if err != nil {
+		return nil, err
+	}
+	defer decompressed.Close()
+	plan.Compression = compression
+	plan.Encrypted = encrypted
+
+	plan.Tool = "pg_restore"
+	if !isArchiveFormat(input) {
+		plan.Tool = "psql"
+		if _, err := psqlArgs(d.config, opts); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// openBackupForPlan opens the backup of plan, verifying local backups against their
+// manifest. Directories return a nil reader.
+func (d *DB) openBackupForPlan(ctx context.Context, plan *RestorePlan) (io.ReadCloser, error) {
+	if IsStorageURL(plan.Backup) {
+		base, key := splitStorageURL(plan.Backup)
+		if key == "" {
+			return nil, NewValidationError("backup URL must name a backup file", nil).
+				WithContext("url", plan.Backup).
+				WithOperation("plan_restore")
+		}
+		storage, err := OpenBackupStorage(ctx, base)
+		if err != nil {
+			return nil, err
+		}
+		return storage.Get(ctx, key)
+	}
+
+	info, err := os.Stat(plan.Backup)
+	if err != nil {
+		return nil, NewRestoreError("failed to read backup file", err).
+			WithContext("backup_path", plan.Backup).
+			WithOperation("plan_restore")
+	}
+	switch err := VerifyBackupChecksum(plan.Backup); {
+	case err == nil:
+		plan.Verified = true
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, nil
+	}
+
+	file, err := os.Open(plan.Backup)
+	if err != nil {
+		return nil, NewRestoreError("failed to open backup file", err).
+			WithContext("backup_path", plan.Backup).
+			WithOperation("plan_restore")
+	}
+	return file, nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanRestore(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "app.sql")
+	require.NoError(t, os.WriteFile(plain, []byte("CREATE TABLE users (id INT);\n"), 0o644))
+	custom := filepath.Join(dir, "app.dump")
+	require.NoError(t, os.WriteFile(custom, append([]byte("PGDMP"), make([]byte, 64)...), 0o644))
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write([]byte("CREATE TABLE users (id INT);\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	gzipped := filepath.Join(dir, "app.sql.gz")
+	require.NoError(t, os.WriteFile(gzipped, compressed.Bytes(), 0o644))
+
+	db := &DB{config: Config{Host: "db.internal", DBName: "app"}}
+	ctx := context.Background()
+
+	plan, err := db.PlanRestore(ctx, plain, RestoreOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "psql", plan.Tool)
+	assert.Equal(t, CompressionNone, plan.Compression)
+	assert.Equal(t, "app", plan.Database)
+	assert.False(t, plan.Verified)
+
+	plan, err = db.PlanRestore(ctx, gzipped, RestoreOptions{TargetDBName: "app_copy"})
+	require.NoError(t, err)
+	assert.Equal(t, "psql", plan.Tool)
+	assert.Equal(t, CompressionGzip, plan.Compression)
+	assert.Equal(t, "app_copy", plan.Database)
+
+	plan, err = db.PlanRestore(ctx, custom, RestoreOptions{Clean: true, SchemaOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, "pg_restore", plan.Tool)
+	assert.True(t, plan.SchemaOnly)
+
+	plan, err = db.PlanRestore(ctx, dir, RestoreOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "pg_restore", plan.Tool)
+
+	// Plain SQL backups cannot be filtered
+	_, err = db.PlanRestore(ctx, plain, RestoreOptions{SchemaOnly: true})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+
+	_, err = db.PlanRestore(ctx, filepath.Join(dir, "missing.sql"), RestoreOptions{})
+	assert.Error(t, err)
+}
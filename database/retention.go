@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// RetentionPolicy decides which backups are kept when pruning. A backup is kept if any
+// rule selects it; the newest backup of each day, week or month represents that period.
+type RetentionPolicy struct {
+	// KeepLast keeps the most recent backups
+	KeepLast int
+	// KeepDaily keeps the newest backup of each of the most recent days
+	KeepDaily int
+	// KeepWeekly keeps the newest backup of each of the most recent ISO weeks
+	KeepWeekly int
+	// KeepMonthly keeps the newest backup of each of the most recent months
+	KeepMonthly int
+	// DryRun reports what would be pruned without deleting anything
+	DryRun bool
+}
+
+// Validate checks that the policy keeps at least one backup
+func (p RetentionPolicy) Validate() error {
+	for name, keep := range map[string]int{
+		"keep_last":    p.KeepLast,
+		"keep_daily":   p.KeepDaily,
+		"keep_weekly":  p.KeepWeekly,
+		"keep_monthly": p.KeepMonthly,
+	} {
+		if keep < 0 {
+			return NewValidationError(fmt.Sprintf("invalid retention %s %d", name, keep), nil).
+				WithContext(name, keep).
+				WithOperation("validate_retention_policy")
+		}
+	}
+	if p.KeepLast == 0 && p.KeepDaily == 0 && p.KeepWeekly == 0 && p.KeepMonthly == 0 {
+		return NewValidationError("retention policy must keep at least one backup", nil).
+			WithOperation("validate_retention_policy")
+	}
+	return nil
+}
+
+// PruneResult lists the backups kept and pruned by a retention policy, newest first
+type PruneResult struct {
+	Kept   []BackupObject `json:"kept"`
+	Pruned []BackupObject `json:"pruned"`
+}
+
+// Apply splits backups into those kept and those pruned by the policy
+func (p RetentionPolicy) Apply(backups []BackupObject) PruneResult {
+	sorted := append([]BackupObject(nil), backups...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return backupTime(sorted[i]).After(backupTime(sorted[j]))
+	})
+
+	keep := make([]bool, len(sorted))
+	for i := 0; i < len(sorted) && i < p.KeepLast; i++ {
+		keep[i] = true
+	}
+
+	periods := []struct {
+		count  int
+		bucket func(time.Time) string
+	}{
+		{p.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{p.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{p.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, period := range periods {
+		seen := make(map[string]bool)
+		for i, backup := range sorted {
+			if len(seen) >= period.count {
+				break
+			}
+			bucket := period.bucket(backupTime(backup))
+			if !seen[bucket] {
+				seen[bucket] = true
+				keep[i] = true
+			}
+		}
+	}
+
+	result := PruneResult{Kept: []BackupObject{}, Pruned: []BackupObject{}}
+	for i, backup := range sorted {
+		if keep[i] {
+			result.Kept = append(result.Kept, backup)
+		} else {
+			result.Pruned = append(result.Pruned, backup)
+		}
+	}
+	return result
+}
+
+// backupTimestamp matches the timestamp in generated backup names
+var backupTimestamp = regexp.MustCompile(`_(\d{8}_\d{6})(\.|$)`)
+
+// backupTime returns when a backup was taken, from its generated name when possible since
+// remote modification times reflect the upload
+func backupTime(backup BackupObject) time.Time {
+	if match := backupTimestamp.FindStringSubmatch(backup.Key); match != nil {
+		if t, err := time.ParseInLocation("20060102_150405", match[1], time.Local); err == nil {
+			return t
+		}
+	}
+	return backup.ModTime
+}
+
+// PruneBackups deletes the backups of this database in BackupsDir, a local directory or
+// storage URL, that the policy does not keep. Only files named like generated backups
+// (backup_<db>_<timestamp>) are considered; their manifests are deleted with them.
+func (d *DB) PruneBackups(ctx context.Context, policy RetentionPolicy) (PruneResult, error) {
+	if err := policy.Validate(); err != nil {
+		return PruneResult{}, err
+	}
+
+	storage, err := OpenBackupStorage(ctx, d.config.BackupsDir)
+	if err != nil {
+		return PruneResult{}, err
+	}
+
+	objects, err := storage.List(ctx, fmt.Sprintf("backup_%s_", d.config.DBName))
+	if err != nil {
+		return PruneResult{}, err
+	}
+
+	generated := regexp.MustCompile(`^backup_` + regexp.QuoteMeta(d.config.DBName) + `_\d{8}_\d{6}(\.|$)`)
+	backups := []BackupObject{}
+	manifests := make(map[string]bool)
+	for _, object := range objects {
+		if isManifest(object.Key) {
+			manifests[object.Key] = true
+		} else if generated.MatchString(object.Key) {
+			backups = append(backups, object)
+		}
+	}
+
+	result := policy.Apply(backups)
+	if policy.DryRun {
+		return result, nil
+	}
+
+	for _, backup := range result.Pruned {
+		if err := storage.Delete(ctx, backup.Key); err != nil {
+			return result, err
+		}
+		if manifests[backup.Key+manifestSuffix] {
+			if err := storage.Delete(ctx, backup.Key+manifestSuffix); err != nil {
+				return result, err
+			}
+		}
+		d.logger.Info("pruned backup", slog.String("key", backup.Key))
+	}
+	return result, nil
+}
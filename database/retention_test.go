@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetentionPolicyValidate(t *testing.T) {
+	if err := (RetentionPolicy{}).Validate(); err == nil {
+		t.Error("Expected error for a policy keeping nothing")
+	}
+	if err := (RetentionPolicy{KeepLast: -1, KeepDaily: 1}).Validate(); err == nil {
+		t.Error("Expected error for negative keep count")
+	}
+	if err := (RetentionPolicy{KeepWeekly: 4}).Validate(); err != nil {
+		t.Errorf("Expected valid policy, got %v", err)
+	}
+}
+
+func TestRetentionPolicyApply(t *testing.T) {
+	// Two backups a day from 2025-01-01 to 2025-03-01
+	var backups []BackupObject
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	for day := start; !day.After(time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)); day = day.AddDate(0, 0, 1) {
+		for _, hour := range []int{3, 15} {
+			at := day.Add(time.Duration(hour) * time.Hour)
+			backups = append(backups, BackupObject{Key: "backup_app_" + at.Format("20060102_150405") + ".sql.gz"})
+		}
+	}
+
+	keys := func(objects []BackupObject) string {
+		var names []string
+		for _, object := range objects {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(object.Key, "backup_app_"), ".sql.gz"))
+		}
+		return strings.Join(names, ",")
+	}
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		kept   string
+	}{
+		{"last", RetentionPolicy{KeepLast: 3}, "20250301_150000,20250301_030000,20250228_150000"},
+		{"daily", RetentionPolicy{KeepDaily: 2}, "20250301_150000,20250228_150000"},
+		{"weekly", RetentionPolicy{KeepWeekly: 2}, "20250301_150000,20250223_150000"},
+		{"monthly", RetentionPolicy{KeepMonthly: 3}, "20250301_150000,20250228_150000,20250131_150000"},
+		{"combined", RetentionPolicy{KeepLast: 2, KeepDaily: 2, KeepMonthly: 2}, "20250301_150000,20250301_030000,20250228_150000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.policy.Apply(backups)
+			if got := keys(result.Kept); got != tt.kept {
+				t.Errorf("Kept %s, want %s", got, tt.kept)
+			}
+			if len(result.Kept)+len(result.Pruned) != len(backups) {
+				t.Errorf("Expected every backup to be kept or pruned")
+			}
+		})
+	}
+}
+
+func TestBackupTime(t *testing.T) {
+	modTime := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	named := backupTime(BackupObject{Key: "prod/backup_app_20250102_030405.dump", ModTime: modTime})
+	if want := time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local); !named.Equal(want) {
+		t.Errorf("Expected time from name %v, got %v", want, named)
+	}
+
+	if got := backupTime(BackupObject{Key: "manual.sql", ModTime: modTime}); !got.Equal(modTime) {
+		t.Errorf("Expected modification time %v, got %v", modTime, got)
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	db.config.BackupsDir = dir
+
+	storage := NewFileStorage(dir)
+	names := []string{
+		"backup_" + db.config.DBName + "_20250101_030000.sql",
+		"backup_" + db.config.DBName + "_20250101_030000.sql" + manifestSuffix,
+		"backup_" + db.config.DBName + "_20250102_030000.sql",
+		"backup_" + db.config.DBName + "_20250103_030000.sql",
+		"backup_" + db.config.DBName + "_staging_20250101_030000.sql",
+		"manual.sql",
+	}
+	for _, name := range names {
+		if err := storage.Put(ctx, name, strings.NewReader("--")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := db.PruneBackups(ctx, RetentionPolicy{KeepLast: 1, DryRun: true})
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(result.Pruned) != 2 {
+		t.Fatalf("Expected 2 backups to prune, got %+v", result.Pruned)
+	}
+	if _, err := os.Stat(filepath.Join(dir, result.Pruned[0].Key)); err != nil {
+		t.Errorf("Dry run must not delete backups: %v", err)
+	}
+
+	if _, err := db.PruneBackups(ctx, RetentionPolicy{KeepLast: 1}); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	remaining, err := storage.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 3 {
+		t.Errorf("Expected the newest backup and unrelated files to remain without pruned manifests, got %+v", remaining)
+	}
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// scramIterations is the iteration count of SCRAM-SHA-256 password secrets, the server's
+// default
+const scramIterations = 4096
+
+// RoleOptions configures CreateRole
+type RoleOptions struct {
+	// Password of the role, sent as a SCRAM-SHA-256 secret so the server never sees it;
+	// roles without one cannot log in with password authentication
+	Password string
+	// Login lets the role connect, which makes it a user
+	Login      bool
+	CreateDB   bool
+	CreateRole bool
+	// ValidUntil expires the password at that time; never by default
+	ValidUntil *time.Time
+	// InRoles are the roles the new role becomes a member of
+	InRoles []string
+	// IfNotExists succeeds without changes if the role exists
+	IfNotExists bool
+}
+
+// DropRoleOptions configures DropRole
+type DropRoleOptions struct {
+	// IfExists succeeds without changes if the role does not exist
+	IfExists bool
+	// ReassignTo hands the objects the role owns in the current database to this role and
+	// drops its privileges there before the role is dropped. Objects in other databases
+	// still prevent the drop.
+	ReassignTo string
+}
+
+// CreateRole creates a role, e.g. a user with Login and a password. The connecting user
+// needs CREATEROLE.
+func (d *DB) CreateRole(ctx context.Context, name string, opts RoleOptions) error {
+	if name == "" {
+		return NewValidationError("role name is required", nil).
+			WithOperation("create_role")
+	}
+
+	if opts.IfNotExists {
+		exists, err := d.roleExists(ctx, name)
+		if err != nil {
+			return WrapError(err, ErrCodeQueryFailed, "create_role", "failed to look up role").
+				WithContext("role", name)
+		}
+		if exists {
+			d.logger.Info("role exists", slog.String("role", name))
+			return nil
+		}
+	}
+
+	statement, err := createRoleStatement(name, opts)
+	if err != nil {
+		return NewDBError(ErrCodeInternal, "failed to hash password", err).
+			WithOperation("create_role")
+	}
+	err = d.WithValidation(ctx, func() error {
+		_, err := d.db.ExecContext(ctx, statement)
+		return err
+	})
+	if err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "create_role", "failed to create role").
+			WithContext("role", name)
+	}
+
+	d.logger.Info("role created", slog.String("role", name))
+	return nil
+}
+
+// DropRole drops a role, first reassigning what it owns in the current database if
+// opts.ReassignTo is set
+func (d *DB) DropRole(ctx context.Context, name string, opts DropRoleOptions) error {
+	if name == "" {
+		return NewValidationError("role name is required", nil).
+			WithOperation("drop_role")
+	}
+
+	exists, err := d.roleExists(ctx, name)
+	if err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "drop_role", "failed to look up role").
+			WithContext("role", name)
+	}
+	if !exists {
+		if opts.IfExists {
+			return nil
+		}
+		return NewValidationError(fmt.Sprintf("role %s does not exist", name), nil).
+			WithContext("role", name).
+			WithOperation("drop_role")
+	}
+
+	err = d.WithTransaction(ctx, func(tx *Transaction) error {
+		for _, statement := range dropRoleStatements(name, opts) {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return WrapError(err, ErrCodeQueryFailed, "drop_role", "failed to drop role").
+			WithContext("role", name)
+	}
+
+	d.logger.Info("role dropped", slog.String("role", name))
+	return nil
+}
+
+// roleExists reports whether the role exists in the cluster
+func (d *DB) roleExists(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := d.WithValidation(ctx, func() error {
+		return d.db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)", name)
+	})
+	return exists, err
+}
+
+// createRoleStatement builds the CREATE ROLE statement for CreateRole
+func createRoleStatement(name string, opts RoleOptions) (string, error) {
+	attributes := []string{"NOLOGIN"}
+	if opts.Login {
+		attributes[0] = "LOGIN"
+	}
+	if opts.CreateDB {
+		attributes = append(attributes, "CREATEDB")
+	}
+	if opts.CreateRole {
+		attributes = append(attributes, "CREATEROLE")
+	}
+	if opts.Password != "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		secret, err := scramSHA256Secret(opts.Password, salt, scramIterations)
+		if err != nil {
+			return "", err
+		}
+		attributes = append(attributes, "PASSWORD "+pq.QuoteLiteral(secret))
+	}
+	if opts.ValidUntil != nil {
+		attributes = append(attributes, "VALID UNTIL "+pq.QuoteLiteral(opts.ValidUntil.UTC().Format(time.RFC3339)))
+	}
+	if len(opts.InRoles) > 0 {
+		quoted := make([]string, len(opts.InRoles))
+		for i, role := range opts.InRoles {
+			quoted[i] = pq.QuoteIdentifier(role)
+		}
+		attributes = append(attributes, "IN ROLE "+strings.Join(quoted, ", "))
+	}
+	return "CREATE ROLE " + pq.QuoteIdentifier(name) + " " + strings.Join(attributes, " "), nil
+}
+
+// dropRoleStatements returns the statements DropRole runs in one transaction
+func dropRoleStatements(name string, opts DropRoleOptions) []string {
+	role := pq.QuoteIdentifier(name)
+	var statements []string
+	if opts.ReassignTo != "" {
+		statements = append(statements,
+			"REASSIGN OWNED BY "+role+" TO "+pq.QuoteIdentifier(opts.ReassignTo),
+			"DROP OWNED BY "+role)
+	}
+	return append(statements, "DROP ROLE "+role)
+}
+
+// scramSHA256Secret returns the SCRAM-SHA-256 secret the server stores for a password,
+// as computed by psql's \password: SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>
+func scramSHA256Secret(password string, salt []byte, iterations int) (string, error) {
+	salted, err := pbkdf2.Key(sha256.New, password, salt, iterations, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	mac := func(key []byte, message string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(message))
+		return h.Sum(nil)
+	}
+	storedKey := sha256.Sum256(mac(salted, "Client Key"))
+	serverKey := mac(salted, "Server Key")
+
+	encode := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("SCRAM-SHA-256$%d:%s$%s:%s", iterations, encode(salt), encode(storedKey[:]), encode(serverKey)), nil
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScramSHA256Secret(t *testing.T) {
+	salt := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	secret, err := scramSHA256Secret("pencil", salt, 4096)
+	require.NoError(t, err)
+	assert.Equal(t, "SCRAM-SHA-256$4096:AAECAwQFBgcICQoLDA0ODw==$zHCdol2044/ZyWzPLi7oxApCkamKw9Z+E4U/QApd/5Y=:dd5peBOitVnLNFu7VmwP+HiDaaw4OUCv396eVCWhYiE=", secret)
+}
+
+func TestCreateRoleStatement(t *testing.T) {
+	statement, err := createRoleStatement("reporting", RoleOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE ROLE "reporting" NOLOGIN`, statement)
+
+	validUntil := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	statement, err = createRoleStatement("app", RoleOptions{
+		Login:      true,
+		CreateDB:   true,
+		Password:   "secret",
+		ValidUntil: &validUntil,
+		InRoles:    []string{"reporting", "app_rw"},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(statement, `CREATE ROLE "app" LOGIN CREATEDB PASSWORD 'SCRAM-SHA-256$4096:`), statement)
+	assert.True(t, strings.HasSuffix(statement, `' VALID UNTIL '2026-01-01T00:00:00Z' IN ROLE "reporting", "app_rw"`), statement)
+	assert.NotContains(t, statement, "secret")
+}
+
+func TestDropRoleStatements(t *testing.T) {
+	assert.Equal(t, []string{`DROP ROLE "app"`}, dropRoleStatements("app", DropRoleOptions{}))
+	assert.Equal(t, []string{
+		`REASSIGN OWNED BY "app" TO "postgres"`,
+		`DROP OWNED BY "app"`,
+		`DROP ROLE "app"`,
+	}, dropRoleStatements("app", DropRoleOptions{ReassignTo: "postgres"}))
+
+	err := (&DB{}).DropRole(context.Background(), "", DropRoleOptions{})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+	err = (&DB{}).CreateRole(context.Background(), "", RoleOptions{})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestCreateAndDropRole(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+	ctx := context.Background()
+	defer db.db.ExecContext(ctx, "DROP ROLE IF EXISTS test_role_user")
+
+	require.NoError(t, db.CreateRole(ctx, "test_role_user", RoleOptions{Login: true, Password: "secret"}))
+	require.NoError(t, db.CreateRole(ctx, "test_role_user", RoleOptions{IfNotExists: true}))
+
+	var secret string
+	require.NoError(t, db.db.GetContext(ctx, &secret, "SELECT rolpassword FROM pg_authid WHERE rolname = 'test_role_user'"))
+	assert.True(t, strings.HasPrefix(secret, "SCRAM-SHA-256$4096:"))
+
+	require.NoError(t, db.DropRole(ctx, "test_role_user", DropRoleOptions{ReassignTo: db.Config().User}))
+	require.NoError(t, db.DropRole(ctx, "test_role_user", DropRoleOptions{IfExists: true}))
+	err := db.DropRole(ctx, "test_role_user", DropRoleOptions{})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
@@ -0,0 +1,390 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ChangeKind is how an object differs between two schemas
+type ChangeKind string
+
+// Change kinds
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeDropped ChangeKind = "dropped"
+	ChangeAltered ChangeKind = "altered"
+)
+
+// Schema object types compared by DiffSchemas
+const (
+	ObjectTable      = "table"
+	ObjectColumn     = "column"
+	ObjectIndex      = "index"
+	ObjectConstraint = "constraint"
+	ObjectType       = "type"
+	ObjectDomain     = "domain"
+	ObjectServer     = "server"
+)
+
+// SchemaChange is one difference between two schemas
+type SchemaChange struct {
+	Kind ChangeKind `json:"kind"`
+	// Object is table, column, index, constraint, type, domain or server
+	Object string `json:"object"`
+	// Table is the schema-qualified table the object belongs to, or the schema-qualified
+	// name of a type or domain
+	Table string `json:"table"`
+	// Name is the column, index or constraint name, empty for tables
+	Name string `json:"name,omitempty"`
+	// Details lists the altered attributes as "attribute: before -> after"
+	Details []string `json:"details,omitempty"`
+}
+
+// SchemaDiff is the change set turning the source schema into the target schema
+type SchemaDiff struct {
+	Source  string         `json:"source"`
+	Target  string         `json:"target"`
+	Changes []SchemaChange `json:"changes"`
+}
+
+// DiffOptions configures DiffSchemas
+type DiffOptions struct {
+	// Schema limits the comparison to one schema; all schemas by default
+	Schema string
+}
+
+// DiffSchemas compares the tables, columns, indexes, constraints, composite types and
+// domains of two databases. The
+// changes describe how target differs from source: added objects exist only in target.
+func DiffSchemas(ctx context.Context, source, target *DB, opts DiffOptions) (*SchemaDiff, error) {
+	exportOpts := ExportOptions{Schema: opts.Schema}
+
+	sourceDoc, err := source.Introspection().ExportSchema(ctx, exportOpts)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "diff_schemas", "failed to export the source schema")
+	}
+	targetDoc, err := target.Introspection().ExportSchema(ctx, exportOpts)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "diff_schemas", "failed to export the target schema")
+	}
+
+	return DiffSchemaDocuments(sourceDoc, targetDoc), nil
+}
+
+// DiffSchemaDocuments compares two exported schemas, see DiffSchemas
+func DiffSchemaDocuments(source, target *SchemaDocument) *SchemaDiff {
+	diff := &SchemaDiff{
+		Source:  source.Database,
+		Target:  target.Database,
+		Changes: []SchemaChange{},
+	}
+
+	sourceTables := tablesByName(source.Tables)
+	targetTables := tablesByName(target.Tables)
+
+	for _, name := range unionKeys(sourceTables, targetTables) {
+		before, inSource := sourceTables[name]
+		after, inTarget := targetTables[name]
+		switch {
+		case !inTarget:
+			diff.Changes = append(diff.Changes, SchemaChange{Kind: ChangeDropped, Object: ObjectTable, Table: name})
+		case !inSource:
+			diff.Changes = append(diff.Changes, SchemaChange{Kind: ChangeAdded, Object: ObjectTable, Table: name})
+		default:
+			diff.Changes = append(diff.Changes, diffTable(name, before, after)...)
+		}
+	}
+
+	diff.Changes = append(diff.Changes, diffSchemaObjects(ObjectType,
+		objectsByName(source.CompositeTypes, func(t CompositeTypeInfo) string { return t.Schema + "." + t.Name }),
+		objectsByName(target.CompositeTypes, func(t CompositeTypeInfo) string { return t.Schema + "." + t.Name }),
+		diffCompositeType)...)
+	diff.Changes = append(diff.Changes, diffSchemaObjects(ObjectDomain,
+		objectsByName(source.Domains, func(d DomainInfo) string { return d.Schema + "." + d.Name }),
+		objectsByName(target.Domains, func(d DomainInfo) string { return d.Schema + "." + d.Name }),
+		diffDomain)...)
+	diff.Changes = append(diff.Changes, diffSchemaObjects(ObjectServer,
+		objectsByName(source.ForeignServers, func(s ForeignServerInfo) string { return s.Name }),
+		objectsByName(target.ForeignServers, func(s ForeignServerInfo) string { return s.Name }),
+		diffForeignServer)...)
+
+	return diff
+}
+
+// HasChanges reports whether the schemas differ
+func (d *SchemaDiff) HasChanges() bool {
+	return len(d.Changes) > 0
+}
+
+// Report writes a human-readable report of the changes to w, one change per line prefixed
+// with + for added, - for dropped and ~ for altered objects
+func (d *SchemaDiff) Report(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Schema diff: %s -> %s\n", d.Source, d.Target)
+
+	if !d.HasChanges() {
+		b.WriteString("No differences\n")
+	}
+
+	counts := make(map[ChangeKind]int)
+	for _, change := range d.Changes {
+		counts[change.Kind]++
+
+		var prefix string
+		switch change.Kind {
+		case ChangeAdded:
+			prefix = "+"
+		case ChangeDropped:
+			prefix = "-"
+		default:
+			prefix = "~"
+		}
+
+		object := change.Table
+		if change.Name != "" {
+			object += "." + change.Name
+		}
+		fmt.Fprintf(&b, "%s %s %s\n", prefix, change.Object, object)
+		for _, detail := range change.Details {
+			fmt.Fprintf(&b, "    %s\n", detail)
+		}
+	}
+
+	if d.HasChanges() {
+		fmt.Fprintf(&b, "\n%d changes: %d added, %d dropped, %d altered\n",
+			len(d.Changes), counts[ChangeAdded], counts[ChangeDropped], counts[ChangeAltered])
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return NewDBError(ErrCodeInternal, "failed to write schema diff", err).
+			WithOperation("report_schema_diff")
+	}
+	return nil
+}
+
+// diffTable compares a table present in both schemas
+func diffTable(name string, before, after TableInfo) []SchemaChange {
+	var changes []SchemaChange
+
+	var details []string
+	details = diffAttribute(details, "type", before.Type, after.Type)
+	details = diffAttribute(details, "partitioning", partitioningString(before.Partitioning), partitioningString(after.Partitioning))
+	details = diffAttribute(details, "tablespace", optionalString(before.Tablespace), optionalString(after.Tablespace))
+	details = diffAttribute(details, "access_method", optionalString(before.AccessMethod), optionalString(after.AccessMethod))
+	details = diffAttribute(details, "unlogged", fmt.Sprint(before.Unlogged), fmt.Sprint(after.Unlogged))
+	details = diffAttribute(details, "storage_parameters", optionsString(before.StorageParameters), optionsString(after.StorageParameters))
+	details = diffAttribute(details, "foreign", foreignTableString(before.Foreign), foreignTableString(after.Foreign))
+	if len(details) > 0 {
+		changes = append(changes, SchemaChange{Kind: ChangeAltered, Object: ObjectTable, Table: name, Details: details})
+	}
+
+	changes = append(changes, diffObjects(ObjectColumn, name,
+		objectsByName(before.Columns, func(c ColumnInfo) string { return c.Name }),
+		objectsByName(after.Columns, func(c ColumnInfo) string { return c.Name }),
+		diffColumn)...)
+	changes = append(changes, diffObjects(ObjectIndex, name,
+		objectsByName(before.Indexes, func(i IndexInfo) string { return i.Name }),
+		objectsByName(after.Indexes, func(i IndexInfo) string { return i.Name }),
+		diffIndex)...)
+	changes = append(changes, diffObjects(ObjectConstraint, name,
+		objectsByName(before.Constraints, func(c ConstraintInfo) string { return c.Name }),
+		objectsByName(after.Constraints, func(c ConstraintInfo) string { return c.Name }),
+		diffConstraint)...)
+
+	return changes
+}
+
+// diffObjects compares the columns, indexes or constraints of a table by name
+func diffObjects[T any](object, table string, before, after map[string]T, compare func(a, b T) []string) []SchemaChange {
+	var changes []SchemaChange
+	for _, name := range unionKeys(before, after) {
+		a, inBefore := before[name]
+		b, inAfter := after[name]
+		switch {
+		case !inAfter:
+			changes = append(changes, SchemaChange{Kind: ChangeDropped, Object: object, Table: table, Name: name})
+		case !inBefore:
+			changes = append(changes, SchemaChange{Kind: ChangeAdded, Object: object, Table: table, Name: name})
+		default:
+			if details := compare(a, b); len(details) > 0 {
+				changes = append(changes, SchemaChange{Kind: ChangeAltered, Object: object, Table: table, Name: name, Details: details})
+			}
+		}
+	}
+	return changes
+}
+
+// diffSchemaObjects compares types, domains or servers by name, schema-qualified if they
+// belong to a schema
+func diffSchemaObjects[T any](object string, before, after map[string]T, compare func(a, b T) []string) []SchemaChange {
+	changes := diffObjects(object, "", before, after, compare)
+	for i := range changes {
+		changes[i].Table, changes[i].Name = changes[i].Name, ""
+	}
+	return changes
+}
+
+func diffColumn(a, b ColumnInfo) []string {
+	var details []string
+	details = diffAttribute(details, "data_type", a.DataType, b.DataType)
+	details = diffAttribute(details, "nullable", fmt.Sprint(a.IsNullable), fmt.Sprint(b.IsNullable))
+	details = diffAttribute(details, "default", optionalString(a.DefaultValue), optionalString(b.DefaultValue))
+	details = diffAttribute(details, "max_length", optionalInt(a.MaxLength), optionalInt(b.MaxLength))
+	details = diffAttribute(details, "numeric_precision", optionalInt(a.NumericPrecision), optionalInt(b.NumericPrecision))
+	details = diffAttribute(details, "numeric_scale", optionalInt(a.NumericScale), optionalInt(b.NumericScale))
+	details = diffAttribute(details, "enum_values", strings.Join(a.EnumValues, ", "), strings.Join(b.EnumValues, ", "))
+	details = diffAttribute(details, "identity", optionalString(a.IdentityGeneration), optionalString(b.IdentityGeneration))
+	details = diffAttribute(details, "generation_expression", optionalString(a.GenerationExpression), optionalString(b.GenerationExpression))
+	details = diffAttribute(details, "collation", optionalString(a.Collation), optionalString(b.Collation))
+	details = diffAttribute(details, "domain_type", optionalString(a.DomainType), optionalString(b.DomainType))
+	return details
+}
+
+func diffIndex(a, b IndexInfo) []string {
+	var details []string
+	details = diffAttribute(details, "columns", strings.Join(a.Columns, ", "), strings.Join(b.Columns, ", "))
+	details = diffAttribute(details, "unique", fmt.Sprint(a.IsUnique), fmt.Sprint(b.IsUnique))
+	details = diffAttribute(details, "primary", fmt.Sprint(a.IsPrimary), fmt.Sprint(b.IsPrimary))
+	details = diffAttribute(details, "index_type", a.IndexType, b.IndexType)
+	return details
+}
+
+func diffConstraint(a, b ConstraintInfo) []string {
+	var details []string
+	details = diffAttribute(details, "type", a.Type, b.Type)
+	details = diffAttribute(details, "columns", strings.Join(a.Columns, ", "), strings.Join(b.Columns, ", "))
+	details = diffAttribute(details, "referenced_table", optionalString(a.ReferencedTable), optionalString(b.ReferencedTable))
+	details = diffAttribute(details, "referenced_columns", strings.Join(a.ReferencedColumns, ", "), strings.Join(b.ReferencedColumns, ", "))
+	details = diffAttribute(details, "update_rule", optionalString(a.UpdateRule), optionalString(b.UpdateRule))
+	details = diffAttribute(details, "delete_rule", optionalString(a.DeleteRule), optionalString(b.DeleteRule))
+	details = diffAttribute(details, "definition", a.Definition, b.Definition)
+	return details
+}
+
+func diffCompositeType(a, b CompositeTypeInfo) []string {
+	return diffAttribute(nil, "attributes", compositeAttributesString(a.Attributes), compositeAttributesString(b.Attributes))
+}
+
+func diffDomain(a, b DomainInfo) []string {
+	var details []string
+	details = diffAttribute(details, "base_type", a.BaseType, b.BaseType)
+	details = diffAttribute(details, "nullable", fmt.Sprint(a.IsNullable), fmt.Sprint(b.IsNullable))
+	details = diffAttribute(details, "default", optionalString(a.DefaultValue), optionalString(b.DefaultValue))
+	details = diffAttribute(details, "collation", optionalString(a.Collation), optionalString(b.Collation))
+	details = diffAttribute(details, "constraints", domainConstraintsString(a.Constraints), domainConstraintsString(b.Constraints))
+	return details
+}
+
+func diffForeignServer(a, b ForeignServerInfo) []string {
+	var details []string
+	details = diffAttribute(details, "wrapper", a.Wrapper, b.Wrapper)
+	details = diffAttribute(details, "type", optionalString(a.Type), optionalString(b.Type))
+	details = diffAttribute(details, "version", optionalString(a.Version), optionalString(b.Version))
+	details = diffAttribute(details, "options", optionsString(a.Options), optionsString(b.Options))
+	return details
+}
+
+// diffAttribute appends "attribute: before -> after" to details if the values differ
+func diffAttribute(details []string, attribute, before, after string) []string {
+	if before == after {
+		return details
+	}
+	return append(details, fmt.Sprintf("%s: %s -> %s", attribute, before, after))
+}
+
+func tablesByName(tables []TableInfo) map[string]TableInfo {
+	return objectsByName(tables, func(t TableInfo) string { return t.Schema + "." + t.Name })
+}
+
+func objectsByName[T any](objects []T, name func(T) string) map[string]T {
+	byName := make(map[string]T, len(objects))
+	for _, object := range objects {
+		byName[name(object)] = object
+	}
+	return byName
+}
+
+// unionKeys returns the sorted keys present in either map
+func unionKeys[T any](a, b map[string]T) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func partitioningString(p *PartitioningInfo) string {
+	if p == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%s (%s)", p.Strategy, p.Key)
+}
+
+// foreignTableString describes the server and options of a foreign table, e.g.
+// "server remote (table_name=users)"
+func foreignTableString(f *ForeignTableInfo) string {
+	if f == nil {
+		return "none"
+	}
+	return fmt.Sprintf("server %s (%s)", f.Server, optionsString(f.Options))
+}
+
+// compositeAttributesString lists attributes as "name type", e.g. "street text, zip text"
+func compositeAttributesString(attributes []CompositeAttribute) string {
+	parts := make([]string, 0, len(attributes))
+	for _, a := range attributes {
+		part := a.Name + " " + a.DataType
+		if a.Collation != nil {
+			part += " COLLATE " + *a.Collation
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func domainConstraintsString(constraints []DomainConstraint) string {
+	if len(constraints) == 0 {
+		return "none"
+	}
+	parts := make([]string, 0, len(constraints))
+	for _, c := range constraints {
+		parts = append(parts, c.Name+" "+c.Definition)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// optionsString lists storage parameters or foreign options sorted by name, e.g. "fillfactor=70"
+func optionsString(parameters map[string]string) string {
+	if len(parameters) == 0 {
+		return "none"
+	}
+	parts := make([]string, 0, len(parameters))
+	for name, value := range parameters {
+		parts = append(parts, name+"="+value)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func optionalString(s *string) string {
+	if s == nil {
+		return "none"
+	}
+	return *s
+}
+
+func optionalInt(i *int) string {
+	if i == nil {
+		return "none"
+	}
+	return fmt.Sprint(*i)
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchemaDocuments(t *testing.T) {
+	source := testSchemaDocument()
+	source.Tables[0].Indexes = []IndexInfo{{Name: "users_pkey", TableName: "users", Columns: []string{"id"}, IsUnique: true, IsPrimary: true, IndexType: "btree"}}
+	source.Tables = append(source.Tables, TableInfo{Name: "legacy", Schema: "public", Type: "BASE TABLE"})
+
+	target := testSchemaDocument()
+	target.Database = "app_staging"
+	target.Tables[0].Columns[0].DataType = "bigint"
+	target.Tables[0].Columns = append(target.Tables[0].Columns, ColumnInfo{Name: "email", DataType: "text"})
+	target.Tables = append(target.Tables, TableInfo{Name: "orders", Schema: "public", Type: "BASE TABLE"})
+
+	diff := DiffSchemaDocuments(source, target)
+	require.True(t, diff.HasChanges())
+	assert.Equal(t, []SchemaChange{
+		{Kind: ChangeDropped, Object: ObjectTable, Table: "public.legacy"},
+		{Kind: ChangeAdded, Object: ObjectTable, Table: "public.orders"},
+		{Kind: ChangeAdded, Object: ObjectColumn, Table: "public.users", Name: "email"},
+		{Kind: ChangeAltered, Object: ObjectColumn, Table: "public.users", Name: "id", Details: []string{"data_type: integer -> bigint"}},
+		{Kind: ChangeDropped, Object: ObjectIndex, Table: "public.users", Name: "users_pkey"},
+	}, diff.Changes)
+
+	var buf bytes.Buffer
+	require.NoError(t, diff.Report(&buf))
+	assert.Equal(t, `Schema diff: app -> app_staging
+- table public.legacy
++ table public.orders
++ column public.users.email
+~ column public.users.id
+    data_type: integer -> bigint
+- index public.users.users_pkey
+
+5 changes: 2 added, 2 dropped, 1 altered
+`, buf.String())
+}
+
+func TestDiffSchemaDocumentsIdentical(t *testing.T) {
+	diff := DiffSchemaDocuments(testSchemaDocument(), testSchemaDocument())
+	assert.False(t, diff.HasChanges())
+
+	var buf bytes.Buffer
+	require.NoError(t, diff.Report(&buf))
+	assert.Equal(t, "Schema diff: app -> app\nNo differences\n", buf.String())
+}
+
+func TestDiffSchemaDocumentsCheckDefinition(t *testing.T) {
+	source := testSchemaDocument()
+	source.Tables[0].Constraints = []ConstraintInfo{{Name: "users_age_check", Type: "CHECK", TableName: "users", Columns: []string{"age"}, Definition: "CHECK ((age >= 0))"}}
+
+	target := testSchemaDocument()
+	target.Tables[0].Constraints = []ConstraintInfo{{Name: "users_age_check", Type: "CHECK", TableName: "users", Columns: []string{"age"}, Definition: "CHECK ((age >= 18))"}}
+
+	diff := DiffSchemaDocuments(source, target)
+	assert.Equal(t, []SchemaChange{
+		{Kind: ChangeAltered, Object: ObjectConstraint, Table: "public.users", Name: "users_age_check",
+			Details: []string{"definition: CHECK ((age >= 0)) -> CHECK ((age >= 18))"}},
+	}, diff.Changes)
+}
+
+func TestDiffSchemaDocumentsStorage(t *testing.T) {
+	source := testSchemaDocument()
+	source.Tables[0].StorageParameters = map[string]string{"fillfactor": "90"}
+
+	target := testSchemaDocument()
+	target.Tables[0].Unlogged = true
+	target.Tables[0].StorageParameters = map[string]string{"fillfactor": "70", "autovacuum_enabled": "false"}
+
+	diff := DiffSchemaDocuments(source, target)
+	assert.Equal(t, []SchemaChange{
+		{Kind: ChangeAltered, Object: ObjectTable, Table: "public.users", Details: []string{
+			"unlogged: false -> true",
+			"storage_parameters: fillfactor=90 -> autovacuum_enabled=false, fillfactor=70",
+		}},
+	}, diff.Changes)
+}
+
+func TestDiffSchemaDocumentsForeign(t *testing.T) {
+	source := testSchemaDocument()
+	source.ForeignServers = []ForeignServerInfo{{Name: "remote", Wrapper: "postgres_fdw", Options: map[string]string{"host": "db1"}}}
+	source.Tables = append(source.Tables, TableInfo{Name: "remote_orders", Schema: "public", Type: "FOREIGN",
+		Foreign: &ForeignTableInfo{Server: "remote", Options: map[string]string{"table_name": "orders"}}})
+
+	target := testSchemaDocument()
+	target.ForeignServers = []ForeignServerInfo{{Name: "remote", Wrapper: "postgres_fdw", Options: map[string]string{"host": "db2"}}}
+	target.Tables = append(target.Tables, TableInfo{Name: "remote_orders", Schema: "public", Type: "FOREIGN",
+		Foreign: &ForeignTableInfo{Server: "remote", Options: map[string]string{"schema_name": "sales", "table_name": "orders"}}})
+
+	diff := DiffSchemaDocuments(source, target)
+	assert.Equal(t, []SchemaChange{
+		{Kind: ChangeAltered, Object: ObjectTable, Table: "public.remote_orders", Details: []string{
+			"foreign: server remote (table_name=orders) -> server remote (schema_name=sales, table_name=orders)",
+		}},
+		{Kind: ChangeAltered, Object: ObjectServer, Table: "remote", Details: []string{"options: host=db1 -> host=db2"}},
+	}, diff.Changes)
+}
+
+func TestDiffSchemaDocumentsTypesAndDomains(t *testing.T) {
+	source := testSchemaDocument()
+	source.CompositeTypes = []CompositeTypeInfo{
+		{Name: "address", Schema: "public", Attributes: []CompositeAttribute{{Name: "street", DataType: "text"}}},
+		{Name: "point3d", Schema: "public"},
+	}
+	source.Domains = []DomainInfo{{Name: "email", Schema: "public", BaseType: "text", IsNullable: true}}
+
+	target := testSchemaDocument()
+	target.CompositeTypes = []CompositeTypeInfo{
+		{Name: "address", Schema: "public", Attributes: []CompositeAttribute{{Name: "street", DataType: "text"}, {Name: "zip", DataType: "text"}}},
+	}
+	target.Domains = []DomainInfo{{Name: "email", Schema: "public", BaseType: "text", IsNullable: true,
+		Constraints: []DomainConstraint{{Name: "email_check", Definition: "CHECK ((VALUE ~~ '%@%'::text))"}}}}
+
+	diff := DiffSchemaDocuments(source, target)
+	assert.Equal(t, []SchemaChange{
+		{Kind: ChangeAltered, Object: ObjectType, Table: "public.address", Details: []string{"attributes: street text -> street text, zip text"}},
+		{Kind: ChangeDropped, Object: ObjectType, Table: "public.point3d"},
+		{Kind: ChangeAltered, Object: ObjectDomain, Table: "public.email", Details: []string{"constraints: none -> email_check CHECK ((VALUE ~~ '%@%'::text))"}},
+	}, diff.Changes)
+
+	var buf bytes.Buffer
+	require.NoError(t, diff.Report(&buf))
+	assert.Contains(t, buf.String(), "- type public.point3d\n")
+}
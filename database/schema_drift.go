@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// schemaFormatForPath returns YAML for .yaml and .yml files and JSON otherwise
+func schemaFormatForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return SchemaFormatYAML
+	default:
+		return SchemaFormatJSON
+	}
+}
+
+// SaveSchemaSnapshot exports the schema of all schemas to path, as YAML if the file ends in
+// .yaml or .yml and as JSON otherwise. The snapshot is meant to be committed alongside the
+// migrations and checked with DetectDrift.
+func (is *IntrospectionService) SaveSchemaSnapshot(ctx context.Context, path string) error {
+	doc, err := is.ExportSchema(ctx, ExportOptions{})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return NewDBError(ErrCodeInternal, "failed to create schema snapshot", err).
+			WithContext("path", path).
+			WithOperation("save_schema_snapshot")
+	}
+	defer file.Close()
+
+	if err := doc.Encode(file, schemaFormatForPath(path)); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return NewDBError(ErrCodeInternal, "failed to write schema snapshot", err).
+			WithContext("path", path).
+			WithOperation("save_schema_snapshot")
+	}
+
+	is.db.logger.Info("Saved schema snapshot", "path", path, "tables", len(doc.Tables))
+	return nil
+}
+
+// DetectDrift compares the live schema with the snapshot at path. The changes describe how
+// the live schema differs from the snapshot: added objects exist only in the database, e.g.
+// an index created by hand. The snapshot's schema filter is applied to the live schema.
+func (is *IntrospectionService) DetectDrift(ctx context.Context, path string) (*SchemaDiff, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, NewConfigError("failed to open schema snapshot", err).
+			WithContext("path", path).
+			WithOperation("detect_drift")
+	}
+	defer file.Close()
+
+	snapshot, err := DecodeSchemaDocument(file)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := is.ExportSchema(ctx, ExportOptions{Schema: snapshot.Schema})
+	if err != nil {
+		return nil, err
+	}
+
+	diff := DiffSchemaDocuments(snapshot, live)
+	diff.Source = path
+	if diff.HasChanges() {
+		is.db.logger.Warn("Schema drift detected", "snapshot", path, "changes", len(diff.Changes))
+	}
+	return diff, nil
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaDocumentVersion is the version of the SchemaDocument format. It is bumped whenever
+// fields are renamed or removed so consumers can reject documents they do not understand.
+const SchemaDocumentVersion = 1
+
+// Schema document formats
+const (
+	SchemaFormatJSON = "json"
+	SchemaFormatYAML = "yaml"
+)
+
+// SchemaDocument is a structured export of a database schema, the input for diffing, code
+// generation and documentation
+type SchemaDocument struct {
+	Version       int       `json:"version"`
+	Database      string    `json:"database"`
+	ServerVersion string    `json:"server_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	// Schema is the exported schema, empty if all schemas were exported
+	Schema  string      `json:"schema,omitempty"`
+	Schemas []string    `json:"schemas"`
+	Tables  []TableInfo `json:"tables"`
+	Views   []ViewInfo  `json:"views"`
+	Enums   []EnumInfo  `json:"enums"`
+	// CompositeTypes and Domains are absent from documents exported by older versions
+	CompositeTypes []CompositeTypeInfo `json:"composite_types"`
+	Domains        []DomainInfo        `json:"domains"`
+	// ForeignServers are the servers of the database, whatever the schema; absent from
+	// documents exported by older versions
+	ForeignServers []ForeignServerInfo `json:"foreign_servers"`
+}
+
+// ExportOptions configures ExportSchema
+type ExportOptions struct {
+	// Schema limits the export to one schema; all schemas by default
+	Schema string
+}
+
+// ExportSchema exports the tables with their columns, indexes, constraints, triggers and
+// partitioning, the views, the enums, the composite types, the domains and the foreign
+// servers of the database as a SchemaDocument
+func (is *IntrospectionService) ExportSchema(ctx context.Context, opts ExportOptions) (*SchemaDocument, error) {
+	doc := &SchemaDocument{
+		Version:    SchemaDocumentVersion,
+		Database:   is.db.config.DBName,
+		ExportedAt: time.Now().UTC(),
+		Schema:     opts.Schema,
+	}
+
+	version, err := is.GetDatabaseVersion(ctx)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "export_schema", "failed to get database version")
+	}
+	doc.ServerVersion = version
+
+	if opts.Schema != "" {
+		doc.Schemas = []string{opts.Schema}
+	} else if doc.Schemas, err = is.GetSchemas(ctx); err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "export_schema", "failed to get schemas")
+	}
+
+	tables, err := is.GetTables(ctx, opts.Schema)
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "export_schema", "failed to get tables")
+	}
+	// Views are exported with their definitions instead
+	doc.Tables = []TableInfo{}
+	for _, table := range tables {
+		if table.Type != "VIEW" {
+			doc.Tables = append(doc.Tables, table)
+		}
+	}
+
+	if doc.Views, err = is.GetViews(ctx, opts.Schema); err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "export_schema", "failed to get views")
+	}
+	if doc.Enums, err = is.GetEnums(ctx, opts.Schema); err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "export_schema", "failed to get enums")
+	}
+	if doc.CompositeTypes, err = is.GetCompositeTypes(ctx, opts.Schema); err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "export_schema", "failed to get composite types")
+	}
+	if doc.Domains, err = is.GetDomains(ctx, opts.Schema); err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "export_schema", "failed to get domains")
+	}
+	if doc.ForeignServers, err = is.GetForeignServers(ctx); err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "export_schema", "failed to get foreign servers")
+	}
+	if doc.Views == nil {
+		doc.Views = []ViewInfo{}
+	}
+	if doc.Enums == nil {
+		doc.Enums = []EnumInfo{}
+	}
+
+	return doc, nil
+}
+
+// Encode writes the document to w as indented JSON or as YAML. YAML uses the same keys as
+// JSON.
+func (d *SchemaDocument) Encode(w io.Writer, format string) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case "", SchemaFormatJSON:
+		data, err = json.MarshalIndent(d, "", "  ")
+		data = append(data, '\n')
+	case SchemaFormatYAML:
+		data, err = MarshalYAML(d)
+	default:
+		return NewValidationError(fmt.Sprintf("unsupported schema document format %q", format), nil).
+			WithContext("format", format).
+			WithOperation("encode_schema_document")
+	}
+	if err != nil {
+		return NewDBError(ErrCodeInternal, "failed to encode schema document", err).
+			WithOperation("encode_schema_document")
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return NewDBError(ErrCodeInternal, "failed to write schema document", err).
+			WithOperation("encode_schema_document")
+	}
+	return nil
+}
+
+// DecodeSchemaDocument reads a document written by Encode in either format. Documents from
+// a newer SchemaDocumentVersion are rejected.
+func DecodeSchemaDocument(r io.Reader) (*SchemaDocument, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, NewDBError(ErrCodeInternal, "failed to read schema document", err).
+			WithOperation("decode_schema_document")
+	}
+
+	// JSON is valid YAML, so both formats decode as YAML and convert through JSON to use
+	// the JSON field names
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, NewValidationError("invalid schema document", err).
+			WithOperation("decode_schema_document")
+	}
+	if data, err = json.Marshal(value); err != nil {
+		return nil, NewValidationError("invalid schema document", err).
+			WithOperation("decode_schema_document")
+	}
+
+	var doc SchemaDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, NewValidationError("invalid schema document", err).
+			WithOperation("decode_schema_document")
+	}
+	if doc.Version < 1 || doc.Version > SchemaDocumentVersion {
+		return nil, NewValidationError(fmt.Sprintf("unsupported schema document version %d", doc.Version), nil).
+			WithContext("version", doc.Version).
+			WithOperation("decode_schema_document")
+	}
+	return &doc, nil
+}
+
+// FilterSchema returns a copy of the document limited to the tables, views and types of
+// schema. Foreign servers are kept, as they belong to no schema.
+func (d *SchemaDocument) FilterSchema(schema string) *SchemaDocument {
+	filtered := *d
+	filtered.Schema = schema
+	filtered.Schemas = []string{schema}
+	filtered.Tables = []TableInfo{}
+	for _, table := range d.Tables {
+		if table.Schema == schema {
+			filtered.Tables = append(filtered.Tables, table)
+		}
+	}
+	filtered.Views = []ViewInfo{}
+	for _, view := range d.Views {
+		if view.Schema == schema {
+			filtered.Views = append(filtered.Views, view)
+		}
+	}
+	filtered.Enums = []EnumInfo{}
+	for _, enum := range d.Enums {
+		if enum.Schema == schema {
+			filtered.Enums = append(filtered.Enums, enum)
+		}
+	}
+	filtered.CompositeTypes = []CompositeTypeInfo{}
+	for _, composite := range d.CompositeTypes {
+		if composite.Schema == schema {
+			filtered.CompositeTypes = append(filtered.CompositeTypes, composite)
+		}
+	}
+	filtered.Domains = []DomainInfo{}
+	for _, domain := range d.Domains {
+		if domain.Schema == schema {
+			filtered.Domains = append(filtered.Domains, domain)
+		}
+	}
+	return &filtered
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func testSchemaDocument() *SchemaDocument {
+	comment := "User accounts"
+	return &SchemaDocument{
+		Version:       SchemaDocumentVersion,
+		Database:      "app",
+		ServerVersion: "PostgreSQL 17.2",
+		ExportedAt:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Schemas:       []string{"public"},
+		Tables: []TableInfo{{
+			Name:    "users",
+			Schema:  "public",
+			Type:    "BASE TABLE",
+			Comment: &comment,
+			Columns: []ColumnInfo{
+				{Name: "id", DataType: "integer", IsPrimaryKey: true},
+				// Values that look like other YAML types must stay strings
+				{Name: "true", DataType: "123"},
+			},
+		}},
+		Views: []ViewInfo{{Name: "active_users", Schema: "public", Definition: " SELECT id\n   FROM users;"}},
+		Enums: []EnumInfo{{Name: "status", Schema: "public", Values: []string{"on", "off"}}},
+	}
+}
+
+func TestSchemaDocumentEncodeJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testSchemaDocument().Encode(&buf, SchemaFormatJSON))
+
+	var decoded SchemaDocument
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, SchemaDocumentVersion, decoded.Version)
+	assert.Equal(t, "users", decoded.Tables[0].Name)
+	assert.True(t, strings.HasSuffix(buf.String(), "}\n"))
+}
+
+func TestSchemaDocumentEncodeYAML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testSchemaDocument().Encode(&buf, SchemaFormatYAML))
+	out := buf.String()
+
+	// Keys follow the JSON names and order
+	assert.True(t, strings.HasPrefix(out, "version: 1\ndatabase: app\n"), out)
+	assert.Contains(t, out, "exported_at: ")
+	assert.Contains(t, out, "is_primary_key: true")
+
+	// Decoding the YAML as generic data gives the same document as the JSON encoding
+	var fromYAML interface{}
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &fromYAML))
+	var jsonBuf bytes.Buffer
+	require.NoError(t, testSchemaDocument().Encode(&jsonBuf, SchemaFormatJSON))
+	var fromJSON interface{}
+	require.NoError(t, json.Unmarshal(jsonBuf.Bytes(), &fromJSON))
+
+	yamlJSON, err := json.Marshal(fromYAML)
+	require.NoError(t, err)
+	jsonJSON, err := json.Marshal(fromJSON)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(jsonJSON), string(yamlJSON))
+}
+
+func TestSchemaDocumentEncodeUnsupportedFormat(t *testing.T) {
+	err := testSchemaDocument().Encode(&bytes.Buffer{}, "xml")
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestDecodeSchemaDocument(t *testing.T) {
+	for _, format := range []string{SchemaFormatJSON, SchemaFormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, testSchemaDocument().Encode(&buf, format))
+
+			doc, err := DecodeSchemaDocument(&buf)
+			require.NoError(t, err)
+			assert.Equal(t, testSchemaDocument(), doc)
+		})
+	}
+}
+
+func TestDecodeSchemaDocumentVersion(t *testing.T) {
+	_, err := DecodeSchemaDocument(strings.NewReader(`{"version": 99}`))
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+
+	_, err = DecodeSchemaDocument(strings.NewReader(`tables: [`))
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestSchemaDocumentFilterSchema(t *testing.T) {
+	doc := &SchemaDocument{
+		Version: SchemaDocumentVersion,
+		Schemas: []string{"billing", "public"},
+		Tables:  []TableInfo{{Schema: "public", Name: "users"}, {Schema: "billing", Name: "invoices"}},
+		Enums:   []EnumInfo{{Schema: "billing", Name: "invoice_status"}},
+	}
+
+	filtered := doc.FilterSchema("billing")
+	assert.Equal(t, []string{"billing"}, filtered.Schemas)
+	assert.Equal(t, []TableInfo{{Schema: "billing", Name: "invoices"}}, filtered.Tables)
+	assert.Len(t, filtered.Enums, 1)
+	assert.Empty(t, filtered.Views)
+	assert.Len(t, doc.Tables, 2)
+}
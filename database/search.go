@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Kinds of SearchResult
+const (
+	SearchKindTable            = "table"
+	SearchKindView             = "view"
+	SearchKindMaterializedView = "materialized view"
+	SearchKindForeignTable     = "foreign table"
+	SearchKindColumn           = "column"
+	SearchKindIndex            = "index"
+	SearchKindConstraint       = "constraint"
+	SearchKindFunction         = "function"
+	SearchKindProcedure        = "procedure"
+)
+
+// searchKinds are the kinds SearchOptions.Kinds accepts
+var searchKinds = []string{SearchKindTable, SearchKindView, SearchKindMaterializedView, SearchKindForeignTable,
+	SearchKindColumn, SearchKindIndex, SearchKindConstraint, SearchKindFunction, SearchKindProcedure}
+
+// SearchOptions narrows Search
+type SearchOptions struct {
+	// Schema limits the search to one schema; all user schemas by default
+	Schema string
+	// Kinds limits the search to these kinds of objects, e.g. SearchKindColumn; all by default
+	Kinds []string
+	// Limit caps the number of results; unlimited if 0
+	Limit int
+}
+
+// SearchResult is a database object whose name matched a Search pattern
+type SearchResult struct {
+	Kind   string `json:"kind" db:"object_kind"`
+	Schema string `json:"schema" db:"object_schema"`
+	// Table is the table of columns, indexes and constraints, empty for other objects
+	Table string `json:"table,omitempty" db:"object_table"`
+	Name  string `json:"name" db:"object_name"`
+	// Location is the qualified name of the object, e.g. billing.invoices.total
+	Location string `json:"location" db:"object_location"`
+	// Detail is the type of columns, the access method of indexes, the type of constraints
+	// and the arguments of functions; empty for tables and views
+	Detail string `json:"detail,omitempty" db:"detail"`
+}
+
+// searchQuery lists the objects of the user schemas by kind. Functions installed by
+// extensions are left out, as in GetFunctions.
+const searchQuery = `
+	WITH objects AS (
+		SELECT
+			CASE c.relkind WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized view' WHEN 'f' THEN 'foreign table' ELSE 'table' END as object_kind,
+			n.nspname as object_schema, '' as object_table, c.relname as object_name, '' as detail
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f')
+		UNION ALL
+		SELECT 'column', n.nspname, c.relname, a.attname, format_type(a.atttypid, a.atttypmod)
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND a.attnum > 0 AND NOT a.attisdropped
+		UNION ALL
+		SELECT 'index', n.nspname, t.relname, i.relname, am.amname
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = i.relnamespace
+		JOIN pg_am am ON am.oid = i.relam
+		UNION ALL
+		SELECT 'constraint', n.nspname, c.relname, k.conname,
+			CASE k.contype
+				WHEN 'p' THEN 'PRIMARY KEY'
+				WHEN 'f' THEN 'FOREIGN KEY'
+				WHEN 'u' THEN 'UNIQUE'
+				WHEN 'c' THEN 'CHECK'
+				WHEN 'x' THEN 'EXCLUDE'
+				ELSE k.contype::text
+			END
+		FROM pg_constraint k
+		JOIN pg_class c ON c.oid = k.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		UNION ALL
+		SELECT CASE p.prokind WHEN 'p' THEN 'procedure' ELSE 'function' END, n.nspname, '', p.proname,
+			pg_get_function_identity_arguments(p.oid)
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE p.prokind IN ('f', 'p')
+		AND NOT EXISTS (
+			SELECT 1 FROM pg_depend d
+			WHERE d.classid = 'pg_proc'::regclass AND d.objid = p.oid AND d.deptype = 'e'
+		)
+	)
+	SELECT
+		object_kind, object_schema, object_table, object_name, detail,
+		concat_ws('.', object_schema, NULLIF(object_table, ''), object_name) as object_location
+	FROM objects
+	WHERE object_name ILIKE $1
+	AND object_schema NOT IN ('information_schema', 'pg_catalog')
+	AND object_schema NOT LIKE 'pg\_toast%'
+	AND object_schema NOT LIKE 'pg\_temp\_%'
+	AND ($2 = '' OR object_schema = $2)
+	AND (cardinality($3::text[]) = 0 OR object_kind = ANY($3))
+	ORDER BY object_schema, object_table, object_kind, object_name, detail`
+
+// Search finds the tables, views, columns, indexes, constraints and functions whose name
+// matches pattern, case-insensitively, across the user schemas. Patterns are globs where *
+// matches any characters and ? a single one, e.g. "*_id"; patterns without wildcards match
+// names containing them. Results are sorted by location.
+func (is *IntrospectionService) Search(ctx context.Context, pattern string, opts SearchOptions) ([]SearchResult, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, NewValidationError("empty search pattern", nil).
+			WithOperation("search")
+	}
+	for _, kind := range opts.Kinds {
+		if !slices.Contains(searchKinds, kind) {
+			return nil, NewValidationError(fmt.Sprintf("unknown object kind %q, expected one of %s", kind, strings.Join(searchKinds, ", ")), nil).
+				WithContext("kind", kind).
+				WithOperation("search")
+		}
+	}
+
+	query := searchQuery
+	args := []interface{}{searchLikePattern(pattern), opts.Schema, pq.StringArray(opts.Kinds)}
+	if opts.Limit > 0 {
+		query += " LIMIT $4"
+		args = append(args, opts.Limit)
+	}
+
+	results := []SearchResult{}
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &results, query, args...)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "search", "failed to search database objects").
+			WithContext("pattern", pattern)
+	}
+	return results, nil
+}
+
+// searchLikePattern converts a search glob to an ILIKE pattern, escaping the LIKE
+// wildcards of the name itself
+func searchLikePattern(pattern string) string {
+	var b strings.Builder
+	wildcards := false
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteRune('%')
+			wildcards = true
+		case '?':
+			b.WriteRune('_')
+			wildcards = true
+		case '%', '_', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if !wildcards {
+		return "%" + b.String() + "%"
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchLikePattern(t *testing.T) {
+	for pattern, expected := range map[string]string{
+		"customer":   "%customer%",
+		"*_id":       `%\_id`,
+		"order?":     "order_",
+		"100%":       `%100\%%`,
+		`back\slash`: `%back\\slash%`,
+	} {
+		assert.Equal(t, expected, searchLikePattern(pattern), pattern)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+	ctx := context.Background()
+
+	_, err := db.db.ExecContext(ctx, `
+		CREATE TABLE test_search_orders (id int PRIMARY KEY, test_search_customer_id int NOT NULL);
+		CREATE INDEX test_search_customer_idx ON test_search_orders (test_search_customer_id);
+		CREATE FUNCTION test_search_customer_total(customer int) RETURNS int LANGUAGE sql AS 'SELECT 1'`)
+	require.NoError(t, err)
+	defer db.db.ExecContext(ctx, `DROP TABLE IF EXISTS test_search_orders; DROP FUNCTION IF EXISTS test_search_customer_total(int)`)
+
+	introspection := db.Introspection()
+
+	results, err := introspection.Search(ctx, "test_search_CUSTOMER", SearchOptions{})
+	require.NoError(t, err)
+	locations := map[string]string{}
+	for _, result := range results {
+		locations[result.Location] = result.Kind
+	}
+	assert.Equal(t, map[string]string{
+		"public.test_search_orders.test_search_customer_id":  SearchKindColumn,
+		"public.test_search_orders.test_search_customer_idx": SearchKindIndex,
+		"public.test_search_customer_total":                  SearchKindFunction,
+	}, locations)
+
+	results, err = introspection.Search(ctx, "test_search_*", SearchOptions{Schema: "public", Kinds: []string{SearchKindTable, SearchKindConstraint}})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "public.test_search_orders", results[0].Location)
+	assert.Equal(t, "test_search_orders_pkey", results[1].Name)
+	assert.Equal(t, "PRIMARY KEY", results[1].Detail)
+
+	results, err = introspection.Search(ctx, "test_search", SearchOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	_, err = introspection.Search(ctx, "test_search", SearchOptions{Kinds: []string{"sequence"}})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+	_, err = introspection.Search(ctx, " ", SearchOptions{})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
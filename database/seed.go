@@ -0,0 +1,392 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"gopkg.in/yaml.v3"
+)
+
+// CommonSeedSet is the seed set applied in every environment, before the set of the
+// environment
+const CommonSeedSet = "common"
+
+// seedsTable records the seed files applied by RunSeeds
+const seedsTable = "dbkit_seeds"
+
+// SeedStatus is the outcome of one seed file in a RunSeeds call
+type SeedStatus string
+
+const (
+	// SeedApplied seeds were applied by this run
+	SeedApplied SeedStatus = "applied"
+	// SeedSkipped seeds were applied by an earlier run
+	SeedSkipped SeedStatus = "skipped"
+	// SeedChanged seeds were applied by an earlier run and edited since; they are not
+	// applied again
+	SeedChanged SeedStatus = "changed"
+	// SeedPending seeds would be applied by a run without DryRun
+	SeedPending SeedStatus = "pending"
+)
+
+// SeedOptions configures RunSeeds
+type SeedOptions struct {
+	// Dir holds one directory per seed set: common for all environments and one per
+	// environment, e.g. dev or staging; the configured SeedsDir by default
+	Dir string
+	// Env selects the seed set applied after the common set; only the common set is
+	// applied without it
+	Env string
+	// DryRun reports the seeds that would be applied without applying them
+	DryRun bool
+}
+
+// SeedResult is the outcome of one seed file
+type SeedResult struct {
+	// Name is the path of the file relative to the seeds directory, e.g. dev/010_users.yaml
+	Name   string     `json:"name"`
+	Set    string     `json:"set"`
+	Status SeedStatus `json:"status"`
+	// Rows is the number of rows inserted or changed by an applied seed
+	Rows     int64         `json:"rows"`
+	Duration time.Duration `json:"duration"`
+}
+
+// seedFile is a parsed seed file: SQL statements, or fixture rows by table
+type seedFile struct {
+	Name       string
+	Set        string
+	Checksum   string
+	Statements []Statement
+	Fixtures   map[string][]map[string]interface{}
+}
+
+// RunSeeds applies the seed files of the common set and of opts.Env in that order, each
+// set sorted by file name. Seed files are either SQL scripts (.sql) or fixtures (.yaml,
+// .yml or .json) mapping tables to the rows to insert, e.g.
+//
+//	users:
+//	  - id: 1
+//	    email: admin@example.com
+//	audit.events:
+//	  - user_id: 1
+//	    payload: {action: signup}
+//
+// Fixture tables are loaded in foreign key order; unqualified tables are in the public
+// schema and nested values are inserted as JSON. Each file runs in its own transaction
+// and is recorded in the dbkit_seeds table, so later runs skip it. Seeds edited after they
+// were applied are reported as changed and not applied again.
+func (d *DB) RunSeeds(ctx context.Context, opts SeedOptions) ([]SeedResult, error) {
+	if opts.Dir == "" {
+		opts.Dir = d.config.SeedsDir
+	}
+	files, err := loadSeedFiles(opts.Dir, opts.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.ValidateConnection(ctx); err != nil {
+		return nil, WrapError(err, ErrCodeConnectionFailed, "run_seeds", "connection validation failed")
+	}
+	applied, err := d.appliedSeeds(ctx, !opts.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SeedResult, 0, len(files))
+	for _, file := range files {
+		result := SeedResult{Name: file.Name, Set: file.Set}
+		checksum, ok := applied[file.Name]
+		switch {
+		case ok && checksum == file.Checksum:
+			result.Status = SeedSkipped
+		case ok:
+			result.Status = SeedChanged
+			d.logger.Warn("seed changed since it was applied", slog.String("seed", file.Name))
+		case opts.DryRun:
+			result.Status = SeedPending
+		default:
+			start := time.Now()
+			rows, err := d.applySeed(ctx, file)
+			if err != nil {
+				return results, err
+			}
+			result.Status = SeedApplied
+			result.Rows = rows
+			result.Duration = time.Since(start)
+			d.logger.Info("seed applied",
+				slog.String("seed", file.Name),
+				slog.Int64("rows", rows),
+				slog.Duration("duration", result.Duration))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// appliedSeeds returns the checksums of the applied seeds by name, creating the tracking
+// table if create is set
+func (d *DB) appliedSeeds(ctx context.Context, create bool) (map[string]string, error) {
+	applied := map[string]string{}
+	if create {
+		_, err := d.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+seedsTable+` (
+			name text PRIMARY KEY,
+			checksum text NOT NULL,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`)
+		if err != nil {
+			return nil, WrapError(err, ErrCodeQueryFailed, "run_seeds", "failed to create the seeds table")
+		}
+	} else {
+		var exists bool
+		if err := d.db.GetContext(ctx, &exists, "SELECT to_regclass($1) IS NOT NULL", seedsTable); err != nil {
+			return nil, WrapError(err, ErrCodeQueryFailed, "run_seeds", "failed to look up the seeds table")
+		}
+		if !exists {
+			return applied, nil
+		}
+	}
+
+	var rows []struct {
+		Name     string `db:"name"`
+		Checksum string `db:"checksum"`
+	}
+	if err := d.db.SelectContext(ctx, &rows, "SELECT name, checksum FROM "+seedsTable); err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "run_seeds", "failed to read the applied seeds")
+	}
+	for _, row := range rows {
+		applied[row.Name] = row.Checksum
+	}
+	return applied, nil
+}
+
+// applySeed runs a seed file and records it in one transaction
+func (d *DB) applySeed(ctx context.Context, file seedFile) (int64, error) {
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, WrapError(err, ErrCodeTransactionBegin, "run_seeds", "failed to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var rows int64
+	if file.Fixtures != nil {
+		rows, err = insertFixtures(ctx, tx, file.Fixtures)
+	} else {
+		rows, err = execSeedStatements(ctx, tx, file.Statements)
+	}
+	if err != nil {
+		return 0, WrapError(err, ErrCodeQueryFailed, "run_seeds", "failed to apply seed").
+			WithContext("seed", file.Name)
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO "+seedsTable+" (name, checksum) VALUES ($1, $2)", file.Name, file.Checksum); err != nil {
+		return 0, WrapError(err, ErrCodeQueryFailed, "run_seeds", "failed to record seed").
+			WithContext("seed", file.Name)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, WrapError(err, ErrCodeTransactionCommit, "run_seeds", "failed to commit seed").
+			WithContext("seed", file.Name)
+	}
+	return rows, nil
+}
+
+// execSeedStatements executes the statements of a SQL seed
+func execSeedStatements(ctx context.Context, tx *sqlx.Tx, statements []Statement) (int64, error) {
+	var total int64
+	for _, statement := range statements {
+		res, err := tx.ExecContext(ctx, statement.SQL)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", statement.Line, err)
+		}
+		if rows, err := res.RowsAffected(); err == nil {
+			total += rows
+		}
+	}
+	return total, nil
+}
+
+// insertFixtures inserts the rows of the fixture tables in foreign key order
+func insertFixtures(ctx context.Context, tx *sqlx.Tx, fixtures map[string][]map[string]interface{}) (int64, error) {
+	// Qualified names match the foreign key edges, which are always schema-qualified
+	tables := make([]string, 0, len(fixtures))
+	names := make(map[string]string, len(fixtures))
+	for name := range fixtures {
+		qualified := name
+		if !strings.Contains(name, ".") {
+			qualified = "public." + name
+		}
+		tables = append(tables, qualified)
+		names[qualified] = name
+	}
+	sort.Strings(tables)
+
+	dependencies, err := tableDependencies(ctx, tx, "")
+	if err != nil {
+		return 0, err
+	}
+	if tables, err = SortTablesByDependencies(tables, dependencies); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, table := range tables {
+		quoted, err := quoteQualifiedName(table)
+		if err != nil {
+			return 0, err
+		}
+		for i, row := range fixtures[names[table]] {
+			statement, args, err := fixtureInsert(quoted, row)
+			if err != nil {
+				return 0, fmt.Errorf("%s row %d: %w", names[table], i+1, err)
+			}
+			if _, err := tx.ExecContext(ctx, statement, args...); err != nil {
+				return 0, fmt.Errorf("%s row %d: %w", names[table], i+1, err)
+			}
+			total++
+		}
+	}
+	return total, nil
+}
+
+// fixtureInsert returns the INSERT statement of a fixture row with its columns sorted.
+// Nested maps and lists are passed as JSON.
+func fixtureInsert(table string, row map[string]interface{}) (string, []interface{}, error) {
+	if len(row) == 0 {
+		return "INSERT INTO " + table + " DEFAULT VALUES", nil, nil
+	}
+
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		quoted[i] = pq.QuoteIdentifier(column)
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		switch value := row[column].(type) {
+		case map[string]interface{}, []interface{}:
+			data, err := json.Marshal(value)
+			if err != nil {
+				return "", nil, fmt.Errorf("column %s: %w", column, err)
+			}
+			args[i] = string(data)
+		default:
+			args[i] = value
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table,
+		strings.Join(quoted, ", "), strings.Join(placeholders, ", ")), args, nil
+}
+
+// loadSeedFiles reads and parses the seed files of the common set and of env under dir.
+// A missing common set is empty, while a missing environment set is an error.
+func loadSeedFiles(dir, env string) ([]seedFile, error) {
+	if env != "" && (env == "." || env == ".." || strings.ContainsAny(env, `/\`)) {
+		return nil, NewValidationError(fmt.Sprintf("invalid seed environment %q", env), nil).
+			WithContext("env", env).
+			WithOperation("run_seeds")
+	}
+
+	sets := []string{CommonSeedSet}
+	if env != "" && env != CommonSeedSet {
+		sets = append(sets, env)
+	}
+
+	var files []seedFile
+	for _, set := range sets {
+		entries, err := os.ReadDir(filepath.Join(dir, set))
+		if errors.Is(err, fs.ErrNotExist) && set == CommonSeedSet {
+			continue
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, NewValidationError(fmt.Sprintf("no seed set for environment %q in %s", env, dir), err).
+				WithContext("env", env).
+				WithContext("dir", dir).
+				WithOperation("run_seeds")
+		}
+		if err != nil {
+			return nil, NewConfigError("failed to read the seeds directory", err).
+				WithContext("dir", dir).
+				WithOperation("run_seeds")
+		}
+
+		// ReadDir sorts entries by file name
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			file, ok, err := readSeedFile(dir, set, entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// readSeedFile reads and parses a seed file; files that are not seeds are ignored
+func readSeedFile(dir, set, name string) (seedFile, bool, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext != ".sql" && ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		return seedFile{}, false, nil
+	}
+
+	file := seedFile{Name: set + "/" + name, Set: set}
+	data, err := os.ReadFile(filepath.Join(dir, set, name))
+	if err != nil {
+		return file, false, NewConfigError("failed to read seed file", err).
+			WithContext("seed", file.Name).
+			WithOperation("run_seeds")
+	}
+	sum := sha256.Sum256(data)
+	file.Checksum = hex.EncodeToString(sum[:])
+
+	if ext == ".sql" {
+		file.Statements = SplitStatements(string(data))
+		for _, statement := range file.Statements {
+			if transactionControlStatements[statement.Type] {
+				return file, false, NewValidationError(fmt.Sprintf("%s line %d: %s is not allowed, seeds run in a transaction", file.Name, statement.Line, statement.Type), nil).
+					WithContext("seed", file.Name).
+					WithContext("line", statement.Line).
+					WithOperation("run_seeds")
+			}
+		}
+		return file, true, nil
+	}
+
+	// JSON is valid YAML, so both fixture formats share the decoder
+	file.Fixtures = map[string][]map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &file.Fixtures); err != nil {
+		return file, false, NewValidationError(fmt.Sprintf("invalid fixture file %s", file.Name), err).
+			WithContext("seed", file.Name).
+			WithOperation("run_seeds")
+	}
+	for table := range file.Fixtures {
+		if _, _, err := splitTableName(table); err != nil {
+			return file, false, NewValidationError(fmt.Sprintf("%s: invalid table name %q", file.Name, table), err).
+				WithContext("seed", file.Name).
+				WithOperation("run_seeds")
+		}
+	}
+	return file, true, nil
+}
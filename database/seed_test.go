@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSeedFiles writes files, keyed by path relative to the returned seeds directory
+func writeSeedFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestLoadSeedFiles(t *testing.T) {
+	dir := writeSeedFiles(t, map[string]string{
+		"common/010_roles.sql":  "INSERT INTO roles VALUES (1, 'admin');\nINSERT INTO roles VALUES (2, 'user');",
+		"common/README.md":      "not a seed",
+		"dev/020_users.yaml":    "users:\n  - id: 1\n    settings: {theme: dark}\n",
+		"dev/010_teams.json":    `{"public.teams": [{"id": 1, "name": "core"}]}`,
+		"staging/010_users.sql": "INSERT INTO users VALUES (1);",
+		"001_goose_seed.sql":    "-- +goose Up\nSELECT 1;",
+	})
+
+	files, err := loadSeedFiles(dir, "dev")
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Equal(t, "common/010_roles.sql", files[0].Name)
+	assert.Equal(t, CommonSeedSet, files[0].Set)
+	assert.Len(t, files[0].Statements, 2)
+	assert.Len(t, files[0].Checksum, 64)
+	assert.Equal(t, "dev/010_teams.json", files[1].Name)
+	assert.Equal(t, "core", files[1].Fixtures["public.teams"][0]["name"])
+	assert.Equal(t, "dev/020_users.yaml", files[2].Name)
+	assert.Equal(t, map[string]interface{}{"theme": "dark"}, files[2].Fixtures["users"][0]["settings"])
+
+	// Without an environment only the common set applies
+	files, err = loadSeedFiles(dir, "")
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	_, err = loadSeedFiles(dir, "prod")
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+	_, err = loadSeedFiles(dir, "../dev")
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+
+	// A directory without a common set is fine
+	files, err = loadSeedFiles(t.TempDir(), "")
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestLoadSeedFilesInvalid(t *testing.T) {
+	tests := map[string]string{
+		"common/010_tx.sql":      "BEGIN;\nINSERT INTO roles VALUES (1);\nCOMMIT;",
+		"common/010_rows.yaml":   "users: {id: 1}\n",
+		"common/010_table.yaml":  "public..users:\n  - id: 1\n",
+		"common/010_broken.json": `{"users": [`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := writeSeedFiles(t, map[string]string{name: content})
+			_, err := loadSeedFiles(dir, "")
+			require.Error(t, err)
+			assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+		})
+	}
+}
+
+func TestFixtureInsert(t *testing.T) {
+	statement, args, err := fixtureInsert(`"public"."users"`, map[string]interface{}{
+		"id":       1,
+		"email":    "admin@example.com",
+		"settings": map[string]interface{}{"theme": "dark"},
+		"tags":     []interface{}{"admin"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "public"."users" ("email", "id", "settings", "tags") VALUES ($1, $2, $3, $4)`, statement)
+	assert.Equal(t, []interface{}{"admin@example.com", 1, `{"theme":"dark"}`, `["admin"]`}, args)
+
+	statement, args, err = fixtureInsert(`"users"`, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "users" DEFAULT VALUES`, statement)
+	assert.Empty(t, args)
+}
+
+func TestRunSeeds(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+	ctx := context.Background()
+	defer db.db.ExecContext(ctx, "DROP TABLE IF EXISTS test_seed_orders, test_seed_users, "+seedsTable)
+
+	_, err := db.db.ExecContext(ctx, `
+		CREATE TABLE test_seed_users (id INT PRIMARY KEY, email TEXT NOT NULL, settings JSONB);
+		CREATE TABLE test_seed_orders (id INT PRIMARY KEY, user_id INT NOT NULL REFERENCES test_seed_users (id))`)
+	require.NoError(t, err)
+
+	// The orders fixture sorts first but is loaded after the users it references
+	dir := writeSeedFiles(t, map[string]string{
+		"common/010_users.sql": "INSERT INTO test_seed_users VALUES (1, 'admin@example.com');",
+		"dev/010_orders.yaml": `test_seed_orders:
+  - {id: 1, user_id: 2}
+test_seed_users:
+  - {id: 2, email: dev@example.com, settings: {theme: dark}}
+`,
+	})
+
+	results, err := db.RunSeeds(ctx, SeedOptions{Dir: dir, Env: "dev", DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, SeedPending, results[0].Status)
+
+	results, err = db.RunSeeds(ctx, SeedOptions{Dir: dir, Env: "dev"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, SeedApplied, results[0].Status)
+	assert.Equal(t, int64(1), results[0].Rows)
+	assert.Equal(t, SeedApplied, results[1].Status)
+	assert.Equal(t, int64(2), results[1].Rows)
+
+	var theme string
+	require.NoError(t, db.db.GetContext(ctx, &theme, "SELECT settings->>'theme' FROM test_seed_users WHERE id = 2"))
+	assert.Equal(t, "dark", theme)
+
+	// Applied seeds are skipped, edited ones reported
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "common/010_users.sql"),
+		[]byte("INSERT INTO test_seed_users VALUES (3, 'other@example.com');"), 0o644))
+	results, err = db.RunSeeds(ctx, SeedOptions{Dir: dir, Env: "dev"})
+	require.NoError(t, err)
+	assert.Equal(t, SeedChanged, results[0].Status)
+	assert.Equal(t, SeedSkipped, results[1].Status)
+
+	var count int
+	require.NoError(t, db.db.GetContext(ctx, &count, "SELECT count(*) FROM test_seed_users"))
+	assert.Equal(t, 2, count)
+}
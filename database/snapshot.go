@@ -0,0 +1,217 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotsDir is the directory of the backups directory holding snapshots. It is hidden
+// so snapshots stay out of the backup catalog and retention.
+const snapshotsDir = ".snapshots"
+
+// snapshotExtension is the extension of snapshot files, custom format dumps
+const snapshotExtension = ".dump"
+
+// snapshotName matches valid snapshot names
+var snapshotName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// SnapshotInfo describes a named snapshot of a database
+type SnapshotInfo struct {
+	Name     string    `json:"name"`
+	Database string    `json:"database"`
+	Created  time.Time `json:"created"`
+	Size     int64     `json:"size"`
+	Path     string    `json:"path"`
+}
+
+// validateSnapshotName checks that name can name a snapshot file
+func validateSnapshotName(name string) error {
+	if !snapshotName.MatchString(name) || strings.HasSuffix(name, snapshotExtension) {
+		return NewValidationError(fmt.Sprintf("invalid snapshot name %q, use letters, digits, '_', '-' and '.'", name), nil).
+			WithContext("snapshot", name).
+			WithOperation("snapshot")
+	}
+	return nil
+}
+
+// snapshotDir returns the directory of the snapshots of the configured database. Snapshots
+// are kept on local disk, so storage URLs are refused.
+func (d *DB) snapshotDir() (string, error) {
+	if IsStorageURL(d.config.BackupsDir) {
+		return "", NewValidationError("snapshots need a local backups directory", nil).
+			WithContext("backups_dir", d.config.BackupsDir).
+			WithOperation("snapshot")
+	}
+	return filepath.Join(d.config.BackupsDir, snapshotsDir, d.config.DBName), nil
+}
+
+// snapshotPath returns the path of the snapshot called name
+func (d *DB) snapshotPath(name string) (string, error) {
+	if err := validateSnapshotName(name); err != nil {
+		return "", err
+	}
+	dir, err := d.snapshotDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+snapshotExtension), nil
+}
+
+// Snapshot captures the schema and data of the configured database as the snapshot called
+// name, a custom format dump in the backups directory, to be restored with RestoreSnapshot
+// while developing, e.g. before trying a migration. An existing snapshot of that name is
+// only replaced if replace is set, once the new one is complete.
+func (d *DB) Snapshot(ctx context.Context, name string, replace bool) (*SnapshotInfo, error) {
+	path, err := d.snapshotPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil && !replace {
+		return nil, NewValidationError(fmt.Sprintf("snapshot %s already exists", name), nil).
+			WithContext("snapshot", name).
+			WithOperation("snapshot").
+			WithUserMessage(fmt.Sprintf("Snapshot %s already exists; replace it to take it again.", name))
+	}
+	if err := d.checkClientTools(ctx, "pg_dump"); err != nil {
+		return nil, err
+	}
+
+	// Dump next to the snapshots, then move the dump and its manifest into place
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, NewBackupError("failed to create the snapshots directory", err).
+			WithContext("dir", dir).
+			WithOperation("snapshot")
+	}
+	tmpDir, err := os.MkdirTemp(dir, ".tmp-")
+	if err != nil {
+		return nil, NewBackupError("failed to create a temporary directory", err).
+			WithContext("dir", dir).
+			WithOperation("snapshot")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tmpPath := filepath.Join(tmpDir, filepath.Base(path))
+	if _, err := d.Backuper.BackupWithOptions(ctx, d.config, tmpPath, BackupOptions{Format: FormatCustom}); err != nil {
+		return nil, WrapError(err, ErrCodeBackupFailed, "snapshot", "failed to dump the database").
+			WithContext("snapshot", name)
+	}
+	for _, file := range []string{path, ManifestPath(path)} {
+		if err := os.Rename(filepath.Join(tmpDir, filepath.Base(file)), file); err != nil {
+			return nil, NewBackupError("failed to store the snapshot", err).
+				WithContext("snapshot", name).
+				WithOperation("snapshot")
+		}
+	}
+	return d.snapshotInfo(name, path)
+}
+
+// RestoreSnapshot replaces the configured database with the snapshot called name: the
+// database is dropped, terminating the sessions connected to it, those of d included, and
+// created again from the snapshot. Needs PostgreSQL 13 or later.
+func (d *DB) RestoreSnapshot(ctx context.Context, name string) (*SnapshotInfo, error) {
+	path, err := d.snapshotPath(name)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := d.snapshotInfo(name, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.checkClientTools(ctx, "pg_restore", "psql"); err != nil {
+		return nil, err
+	}
+
+	if err := DropDatabase(ctx, d.config, DropDatabaseOptions{IfExists: true, Force: true}); err != nil {
+		return nil, err
+	}
+	opts := RestoreOptions{CreateDB: true, StopOnError: true}
+	if err := d.Restorer.RestoreWithOptions(ctx, d.config, path, opts); err != nil {
+		return nil, WrapError(err, ErrCodeRestoreFailed, "restore_snapshot", "failed to restore the snapshot").
+			WithContext("snapshot", name)
+	}
+	return snapshot, nil
+}
+
+// ListSnapshots returns the snapshots of the configured database, newest first
+func (d *DB) ListSnapshots(ctx context.Context) ([]SnapshotInfo, error) {
+	dir, err := d.snapshotDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, NewBackupError("failed to list snapshots", err).
+			WithContext("dir", dir).
+			WithOperation("list_snapshots")
+	}
+
+	snapshots := []SnapshotInfo{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), snapshotExtension)
+		if !ok || entry.IsDir() || validateSnapshotName(name) != nil {
+			continue
+		}
+		snapshot, err := d.snapshotInfo(name, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Created.After(snapshots[j].Created) })
+	return snapshots, nil
+}
+
+// DeleteSnapshot removes the snapshot called name
+func (d *DB) DeleteSnapshot(ctx context.Context, name string) error {
+	path, err := d.snapshotPath(name)
+	if err != nil {
+		return err
+	}
+	if _, err := d.snapshotInfo(name, path); err != nil {
+		return err
+	}
+	for _, file := range []string{path, ManifestPath(path)} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return NewBackupError("failed to delete the snapshot", err).
+				WithContext("snapshot", name).
+				WithOperation("delete_snapshot")
+		}
+	}
+	return nil
+}
+
+// snapshotInfo describes the snapshot at path, dated by its manifest if it has one
+func (d *DB) snapshotInfo(name, path string) (*SnapshotInfo, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, NewValidationError(fmt.Sprintf("snapshot %s not found", name), err).
+			WithContext("snapshot", name).
+			WithOperation("snapshot")
+	}
+	if err != nil {
+		return nil, NewBackupError("failed to read the snapshot", err).
+			WithContext("snapshot", name).
+			WithOperation("snapshot")
+	}
+
+	snapshot := &SnapshotInfo{
+		Name:     name,
+		Database: d.config.DBName,
+		Created:  info.ModTime(),
+		Size:     info.Size(),
+		Path:     path,
+	}
+	if manifest, err := ReadBackupManifest(path); err == nil {
+		snapshot.Created = manifest.Timestamp
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotNames(t *testing.T) {
+	for _, name := range []string{"seeded", "before-0042", "v1.2_clean"} {
+		assert.NoError(t, validateSnapshotName(name), name)
+	}
+	for _, name := range []string{"", ".hidden", "../escape", "a/b", "dump.dump", "with space"} {
+		assert.Equal(t, ErrCodeValidation, GetErrorCode(validateSnapshotName(name)), name)
+	}
+
+	_, err := (&DB{config: Config{DBName: "app", BackupsDir: "s3://bucket/backups"}}).ListSnapshots(context.Background())
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestListSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	db := &DB{config: Config{DBName: "app", BackupsDir: dir}}
+	ctx := context.Background()
+
+	snapshots, err := db.ListSnapshots(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+
+	snapshotDir := filepath.Join(dir, snapshotsDir, "app")
+	require.NoError(t, os.MkdirAll(filepath.Join(snapshotDir, ".tmp-1"), 0o755))
+	for i, name := range []string{"old.dump", "new.dump", "notes.txt"} {
+		path := filepath.Join(snapshotDir, name)
+		require.NoError(t, os.WriteFile(path, []byte("snapshot"), 0o644))
+		modTime := time.Now().Add(time.Duration(i) * time.Hour)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	snapshots, err = db.ListSnapshots(ctx)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "new", snapshots[0].Name)
+	assert.Equal(t, "old", snapshots[1].Name)
+	assert.Equal(t, int64(8), snapshots[0].Size)
+
+	// Snapshots stay out of the backup catalog
+	backups, err := db.ListBackups(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+
+	require.NoError(t, db.DeleteSnapshot(ctx, "old"))
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(db.DeleteSnapshot(ctx, "old")))
+	_, err = db.Snapshot(ctx, "new", false)
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	source, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Snapshots replace the whole database, so work on a clone
+	config := source.config
+	config.DBName = fmt.Sprintf("%s_snapshot_%d", source.config.DBName, time.Now().UnixNano())
+	config.BackupsDir = t.TempDir()
+	_, err := source.Clone(ctx, config.DBName, CloneOptions{})
+	require.NoError(t, err)
+	defer func() { _ = dropDatabase(context.Background(), config) }()
+
+	db, err := New(config)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.db.ExecContext(ctx, "CREATE TABLE test_snapshot (id int); INSERT INTO test_snapshot VALUES (1)")
+	require.NoError(t, err)
+
+	snapshot, err := db.Snapshot(ctx, "one-row", false)
+	require.NoError(t, err)
+	assert.Equal(t, "one-row", snapshot.Name)
+	assert.NoError(t, VerifyBackupChecksum(snapshot.Path))
+
+	_, err = db.db.ExecContext(ctx, "INSERT INTO test_snapshot VALUES (2); CREATE TABLE test_snapshot_later (id int)")
+	require.NoError(t, err)
+
+	_, err = db.RestoreSnapshot(ctx, "one-row")
+	require.NoError(t, err)
+
+	restored, err := New(config)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	var count int
+	require.NoError(t, restored.db.GetContext(ctx, &count, "SELECT count(*) FROM test_snapshot"))
+	assert.Equal(t, 1, count)
+	var later bool
+	require.NoError(t, restored.db.GetContext(ctx, &later, "SELECT to_regclass('test_snapshot_later') IS NOT NULL"))
+	assert.False(t, later)
+}
@@ -0,0 +1,455 @@
+package database
+
+import (
+	"bufio"
+	"strings"
+	"unicode"
+)
+
+// Statement is a single SQL statement split out of a script
+type Statement struct {
+	SQL  string `json:"sql"`
+	Line int    `json:"line"`
+	Type string `json:"type"`
+}
+
+// SplitStatements splits a SQL script into statements on semicolons. Semicolons inside
+// string literals, quoted identifiers, dollar-quoted bodies and comments are ignored.
+// Statements containing only comments or whitespace are dropped.
+func SplitStatements(script string) []Statement {
+	return splitStatements(script, 1)
+}
+
+// IncompleteStatement reports whether script ends inside a statement: before its closing
+// semicolon or inside a string literal, quoted identifier, dollar-quoted body or block
+// comment. Interactive shells use it to decide whether to read another line.
+func IncompleteStatement(script string) bool {
+	// Text after a complete script starts a new statement, while an open statement,
+	// quote or comment absorbs it
+	const probe = "\nx"
+	return len(splitStatements(script+probe, 1)) == len(splitStatements(script, 1))
+}
+
+// splitStatements splits script, numbering lines from firstLine
+func splitStatements(script string, firstLine int) []Statement {
+	var (
+		statements  []Statement
+		start       = 0
+		line        = firstLine
+		startLine   = 0
+		significant = false
+		i           int
+	)
+
+	flush := func(end int) {
+		if significant {
+			sql := strings.TrimSpace(script[start:end])
+			statements = append(statements, Statement{SQL: sql, Line: startLine, Type: StatementType(sql)})
+		}
+		significant = false
+	}
+
+	// mark records the first significant character of a statement, so leading
+	// comments are not part of it
+	mark := func() {
+		if !significant {
+			significant = true
+			start = i
+			startLine = line
+		}
+	}
+
+	for i = 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\n':
+			line++
+
+		case c == '-' && i+1 < len(script) && script[i+1] == '-':
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			if i < len(script) {
+				line++
+			}
+
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			depth := 0
+			for ; i < len(script); i++ {
+				if script[i] == '\n' {
+					line++
+				} else if script[i] == '/' && i+1 < len(script) && script[i+1] == '*' {
+					depth++
+					i++
+				} else if script[i] == '*' && i+1 < len(script) && script[i+1] == '/' {
+					depth--
+					i++
+					if depth == 0 {
+						break
+					}
+				}
+			}
+
+		case c == '\'' || c == '"':
+			mark()
+			escapes := c == '\'' && i > 0 && (script[i-1] == 'E' || script[i-1] == 'e') &&
+				(i == 1 || !isIdentChar(script[i-2]))
+			for i++; i < len(script); i++ {
+				if script[i] == '\n' {
+					line++
+				} else if escapes && script[i] == '\\' {
+					i++
+				} else if script[i] == c {
+					if i+1 < len(script) && script[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+
+		case c == '$':
+			mark()
+			if tag, ok := dollarTag(script[i:]); ok {
+				end := strings.Index(script[i+len(tag):], tag)
+				body := script[i:]
+				if end >= 0 {
+					body = script[i : i+len(tag)+end+len(tag)]
+				}
+				line += strings.Count(body, "\n")
+				i += len(body) - 1
+			}
+
+		case c == ';':
+			flush(i)
+
+		case !unicode.IsSpace(rune(c)):
+			mark()
+		}
+	}
+	flush(len(script))
+
+	return statements
+}
+
+// dollarTag returns the opening dollar-quote tag at the start of s, such as $$ or $body$
+func dollarTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '$' {
+			return s[:i+1], true
+		}
+		if !isIdentChar(s[i]) || (i == 1 && s[i] >= '0' && s[i] <= '9') {
+			// $1 style parameters are not dollar quotes
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// statementModifiers are skipped when classifying CREATE/ALTER/DROP statements
+var statementModifiers = map[string]bool{
+	"OR": true, "REPLACE": true, "UNIQUE": true, "TEMP": true, "TEMPORARY": true,
+	"UNLOGGED": true, "GLOBAL": true, "LOCAL": true, "TRUSTED": true, "PROCEDURAL": true,
+	"DEFAULT": true, "RECURSIVE": true, "CONSTRAINT": true,
+}
+
+// statementObjectPrefixes are object types that take a second keyword, e.g. MATERIALIZED VIEW
+var statementObjectPrefixes = map[string]bool{
+	"MATERIALIZED": true, "FOREIGN": true, "EVENT": true, "TEXT": true, "ACCESS": true, "OPERATOR": true,
+}
+
+// StatementType classifies a statement by its leading keywords, e.g. "SELECT",
+// "CREATE TABLE", "ALTER TABLE" or "DROP MATERIALIZED VIEW"
+func StatementType(sql string) string {
+	words := leadingKeywords(sql, 6)
+	if len(words) == 0 {
+		return ""
+	}
+
+	switch words[0] {
+	case "CREATE", "ALTER", "DROP", "COMMENT":
+		kind := []string{words[0]}
+		for _, word := range words[1:] {
+			if statementModifiers[word] {
+				continue
+			}
+			kind = append(kind, word)
+			if !statementObjectPrefixes[word] {
+				break
+			}
+		}
+		return strings.Join(kind, " ")
+	default:
+		return words[0]
+	}
+}
+
+// leadingKeywords returns up to n upper-cased words at the start of sql, skipping comments
+func leadingKeywords(sql string, n int) []string {
+	sql = stripLeadingComments(sql)
+
+	var words []string
+	scanner := bufio.NewScanner(strings.NewReader(sql))
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() && len(words) < n {
+		word := scanner.Text()
+		end := 0
+		for end < len(word) && isIdentChar(word[end]) {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		words = append(words, strings.ToUpper(word[:end]))
+		if end < len(word) {
+			break
+		}
+	}
+	return words
+}
+
+// stripLeadingComments removes whitespace and comments from the start of sql
+func stripLeadingComments(sql string) string {
+	for {
+		sql = strings.TrimSpace(sql)
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			if i := strings.IndexByte(sql, '\n'); i >= 0 {
+				sql = sql[i+1:]
+			} else {
+				return ""
+			}
+		case strings.HasPrefix(sql, "/*"):
+			if i := strings.Index(sql, "*/"); i >= 0 {
+				sql = sql[i+2:]
+			} else {
+				return ""
+			}
+		default:
+			return sql
+		}
+	}
+}
+
+// parseGooseSQL extracts the statements of one direction ("up" or "down") from a goose SQL
+// migration, honouring StatementBegin/StatementEnd blocks, and reports whether the
+// migration opts out of transactions
+func parseGooseSQL(content, direction string) ([]Statement, bool, error) {
+	var (
+		statements []Statement
+		noTx       bool
+		section    string
+		inBlock    bool
+		block      strings.Builder
+		blockLine  int
+		chunk      strings.Builder
+		chunkLine  int
+	)
+
+	flushChunk := func() {
+		if chunk.Len() > 0 {
+			statements = append(statements, splitStatements(chunk.String(), chunkLine)...)
+			chunk.Reset()
+		}
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, text := range lines {
+		lineNo := i + 1
+		annotation, isAnnotation := gooseAnnotation(text)
+
+		if isAnnotation {
+			switch annotation {
+			case "up", "down":
+				flushChunk()
+				section = annotation
+			case "no transaction":
+				noTx = true
+			case "statementbegin":
+				if section == direction {
+					flushChunk()
+					inBlock = true
+					block.Reset()
+					blockLine = 0
+				}
+			case "statementend":
+				if section == direction {
+					if !inBlock {
+						return nil, noTx, NewValidationError("StatementEnd without StatementBegin", nil).
+							WithContext("line", lineNo)
+					}
+					sql := strings.TrimSpace(block.String())
+					sql = strings.TrimSuffix(sql, ";")
+					if sql != "" {
+						statements = append(statements, Statement{SQL: sql, Line: blockLine, Type: StatementType(sql)})
+					}
+					inBlock = false
+				}
+			}
+			continue
+		}
+
+		if section != direction {
+			continue
+		}
+
+		if inBlock {
+			if blockLine == 0 && strings.TrimSpace(text) != "" {
+				blockLine = lineNo
+			}
+			block.WriteString(text)
+			block.WriteByte('\n')
+			continue
+		}
+
+		if chunk.Len() == 0 {
+			chunkLine = lineNo
+		}
+		chunk.WriteString(text)
+		chunk.WriteByte('\n')
+	}
+
+	if inBlock {
+		return nil, noTx, NewValidationError("StatementBegin without StatementEnd", nil)
+	}
+	flushChunk()
+
+	return statements, noTx, nil
+}
+
+// gooseAnnotation returns the lower-cased goose annotation on a line, e.g. "up" for "-- +goose Up"
+func gooseAnnotation(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "--") {
+		return "", false
+	}
+	line = strings.TrimSpace(strings.TrimPrefix(line, "--"))
+	if !strings.HasPrefix(line, "+goose ") {
+		return "", false
+	}
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "+goose "))), true
+}
+
+// sqlTokenKind classifies the tokens of tokenizeSQL
+type sqlTokenKind int
+
+const (
+	tokenWord    sqlTokenKind = iota // keyword or unquoted identifier
+	tokenQuoted                      // quoted identifier
+	tokenLiteral                     // string, number or dollar-quoted body
+	tokenParam                       // positional parameter such as $1
+	tokenPunct                       // any other character
+)
+
+// sqlToken is a lexical token of a statement
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// tokenizeSQL splits a statement into tokens, dropping whitespace and comments. String
+// prefixes such as E'...' are part of their literal.
+func tokenizeSQL(sql string) []sqlToken {
+	var tokens []sqlToken
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		start := i
+		switch {
+		case unicode.IsSpace(rune(c)):
+			continue
+
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			continue
+
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			depth := 0
+			for ; i < len(sql); i++ {
+				if sql[i] == '/' && i+1 < len(sql) && sql[i+1] == '*' {
+					depth++
+					i++
+				} else if sql[i] == '*' && i+1 < len(sql) && sql[i+1] == '/' {
+					depth--
+					i++
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			continue
+
+		case c == '\'' || c == '"':
+			kind := tokenQuoted
+			escapes := false
+			if c == '\'' {
+				kind = tokenLiteral
+				// E'...', B'...' and X'...' prefixes belong to the literal
+				if n := len(tokens); n > 0 && tokens[n-1].kind == tokenWord && len(tokens[n-1].text) == 1 &&
+					strings.ContainsRune("EeBbXx", rune(tokens[n-1].text[0])) && i > 0 && isIdentChar(sql[i-1]) {
+					escapes = tokens[n-1].text == "E" || tokens[n-1].text == "e"
+					start = i - 1
+					tokens = tokens[:n-1]
+				}
+			}
+			for i++; i < len(sql); i++ {
+				if escapes && sql[i] == '\\' {
+					i++
+				} else if sql[i] == c {
+					if i+1 < len(sql) && sql[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: sql[start:min(i+1, len(sql))]})
+
+		case c == '$':
+			if tag, ok := dollarTag(sql[i:]); ok {
+				end := strings.Index(sql[i+len(tag):], tag)
+				if end < 0 {
+					i = len(sql)
+				} else {
+					i += len(tag) + end + len(tag) - 1
+				}
+				tokens = append(tokens, sqlToken{kind: tokenLiteral, text: sql[start:min(i+1, len(sql))]})
+				continue
+			}
+			for i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9' {
+				i++
+			}
+			kind := tokenParam
+			if i == start {
+				kind = tokenPunct
+			}
+			tokens = append(tokens, sqlToken{kind: kind, text: sql[start : i+1]})
+
+		case (c >= '0' && c <= '9') || (c == '.' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9'):
+			for i+1 < len(sql) {
+				next := sql[i+1]
+				exponentSign := (next == '+' || next == '-') && (sql[i] == 'e' || sql[i] == 'E')
+				if !(next >= '0' && next <= '9') && next != '.' && next != 'e' && next != 'E' && next != '_' && !exponentSign {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenLiteral, text: sql[start : i+1]})
+
+		case isIdentChar(c) || c >= 0x80:
+			for i+1 < len(sql) && (isIdentChar(sql[i+1]) || sql[i+1] == '$' || sql[i+1] >= 0x80) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{kind: tokenWord, text: sql[start : i+1]})
+
+		default:
+			tokens = append(tokens, sqlToken{kind: tokenPunct, text: sql[start : i+1]})
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- leading comment; not a statement
+CREATE TABLE users (id int, note text DEFAULT 'a;b');
+INSERT INTO users VALUES (1, E'it\'s; fine');
+
+/* block; comment */
+CREATE FUNCTION f() RETURNS int AS $body$
+BEGIN
+  RETURN 1;
+END
+$body$ LANGUAGE plpgsql;
+SELECT $1::int, "weird;name" FROM users
+`
+
+	statements := SplitStatements(script)
+	require.Len(t, statements, 4)
+
+	assert.Equal(t, "CREATE TABLE users (id int, note text DEFAULT 'a;b')", statements[0].SQL)
+	assert.Equal(t, 2, statements[0].Line)
+	assert.Equal(t, "CREATE TABLE", statements[0].Type)
+
+	assert.Equal(t, "INSERT", statements[1].Type)
+	assert.Equal(t, 3, statements[1].Line)
+
+	assert.Equal(t, "CREATE FUNCTION", statements[2].Type)
+	assert.Equal(t, 6, statements[2].Line)
+	assert.Contains(t, statements[2].SQL, "RETURN 1;")
+
+	assert.Equal(t, "SELECT", statements[3].Type)
+	assert.Equal(t, 11, statements[3].Line)
+}
+
+func TestSplitStatementsEmpty(t *testing.T) {
+	assert.Empty(t, SplitStatements(""))
+	assert.Empty(t, SplitStatements("-- only a comment\n;\n/* and another */"))
+}
+
+func TestIncompleteStatement(t *testing.T) {
+	tests := map[string]bool{
+		"":                               false,
+		"  \n":                           false,
+		"-- only a comment":              false,
+		"SELECT 1;":                      false,
+		"SELECT 1; -- trailing comment":  false,
+		"SELECT 1; SELECT 2;\n":          false,
+		"SELECT 1":                       true,
+		"SELECT 1; SELECT 2":             true,
+		"SELECT 'a;":                     true,
+		`SELECT "weird;`:                 true,
+		"DO $$ BEGIN PERFORM 1;":         true,
+		"DO $$ BEGIN PERFORM 1; END $$;": false,
+		"SELECT 1; /* open; comment":     true,
+	}
+
+	for script, want := range tests {
+		assert.Equal(t, want, IncompleteStatement(script), script)
+	}
+}
+
+func TestStatementType(t *testing.T) {
+	tests := map[string]string{
+		"select 1":                                     "SELECT",
+		"CREATE UNIQUE INDEX idx ON t(a)":              "CREATE INDEX",
+		"create or replace function f()":               "CREATE FUNCTION",
+		"CREATE TEMP TABLE t(a int)":                   "CREATE TABLE",
+		"DROP MATERIALIZED VIEW v":                     "DROP MATERIALIZED VIEW",
+		"ALTER TABLE t ADD COLUMN b int":               "ALTER TABLE",
+		"-- comment\nUPDATE t SET a = 1":               "UPDATE",
+		"/* c */ WITH x AS (SELECT 1) SELECT * FROM x": "WITH",
+		"": "",
+	}
+
+	for sql, want := range tests {
+		assert.Equal(t, want, StatementType(sql), sql)
+	}
+}
+
+func TestParseGooseSQL(t *testing.T) {
+	content := `-- +goose NO TRANSACTION
+-- +goose Up
+CREATE TABLE a (id int);
+CREATE INDEX CONCURRENTLY idx_a ON a(id);
+
+-- +goose StatementBegin
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION touch();
+DROP TABLE a;
+`
+
+	up, noTx, err := parseGooseSQL(content, "up")
+	require.NoError(t, err)
+	assert.True(t, noTx)
+	require.Len(t, up, 3)
+	assert.Equal(t, 3, up[0].Line)
+	assert.Equal(t, "CREATE INDEX", up[1].Type)
+	assert.Equal(t, "CREATE FUNCTION", up[2].Type)
+	assert.Equal(t, 7, up[2].Line)
+	assert.Contains(t, up[2].SQL, "RETURN NEW;")
+
+	down, _, err := parseGooseSQL(content, "down")
+	require.NoError(t, err)
+	require.Len(t, down, 2)
+	assert.Equal(t, "DROP FUNCTION", down[0].Type)
+	assert.Equal(t, 15, down[0].Line)
+
+	_, _, err = parseGooseSQL("-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n", "up")
+	assert.Error(t, err)
+}
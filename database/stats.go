@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// IndexUsage represents how often an index was used since the statistics were last reset
+type IndexUsage struct {
+	Schema string `json:"schema" db:"index_schema"`
+	Table  string `json:"table" db:"table_name"`
+	Name   string `json:"name" db:"index_name"`
+	// Scans is the number of index scans
+	Scans int64 `json:"scans" db:"scans"`
+	// TuplesRead is the number of index entries returned by scans
+	TuplesRead int64 `json:"tuples_read" db:"tuples_read"`
+	// TuplesFetched is the number of live table rows fetched by simple index scans
+	TuplesFetched int64 `json:"tuples_fetched" db:"tuples_fetched"`
+	SizeBytes     int64 `json:"size_bytes" db:"size_bytes"`
+	IsUnique      bool  `json:"is_unique" db:"is_unique"`
+	IsPrimary     bool  `json:"is_primary" db:"is_primary"`
+	// EnforcesConstraint is set for indexes backing a primary key, unique or exclusion
+	// constraint, which cannot be dropped on their own
+	EnforcesConstraint bool `json:"enforces_constraint" db:"enforces_constraint"`
+}
+
+// UnusedIndexReport lists the indexes without scans since the statistics were last reset
+type UnusedIndexReport struct {
+	// StatsReset is when the database statistics were last reset, nil if never
+	StatsReset *time.Time   `json:"stats_reset,omitempty"`
+	Indexes    []IndexUsage `json:"indexes"`
+	// TotalBytes is the combined size of the unused indexes
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+// GetIndexUsage retrieves the scan counts and sizes of the indexes in schema, or in all
+// schemas if empty, least used first
+func (is *IntrospectionService) GetIndexUsage(ctx context.Context, schema string) ([]IndexUsage, error) {
+	usage := []IndexUsage{}
+
+	query := `
+		SELECT
+			s.schemaname as index_schema,
+			s.relname as table_name,
+			s.indexrelname as index_name,
+			s.idx_scan as scans,
+			s.idx_tup_read as tuples_read,
+			s.idx_tup_fetch as tuples_fetched,
+			pg_relation_size(s.indexrelid) as size_bytes,
+			i.indisunique as is_unique,
+			i.indisprimary as is_primary,
+			EXISTS (
+				SELECT 1 FROM pg_constraint c
+				WHERE c.conrelid = i.indrelid
+				AND c.conindid = i.indexrelid
+				AND c.contype IN ('p', 'u', 'x')
+			) as enforces_constraint
+		FROM pg_stat_user_indexes s
+		JOIN pg_index i ON i.indexrelid = s.indexrelid
+	`
+
+	args := []interface{}{}
+	if schema != "" {
+		query += " WHERE s.schemaname = $1"
+		args = append(args, schema)
+	}
+
+	query += `
+		ORDER BY s.idx_scan, size_bytes DESC, s.schemaname, s.relname, s.indexrelname
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &usage, query, args...)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_index_usage", "failed to get index usage")
+	}
+
+	return usage, nil
+}
+
+// GetUnusedIndexes reports the indexes in schema, or in all schemas if empty, that were
+// never scanned since the statistics were last reset, largest first. Indexes enforcing a
+// constraint or uniqueness are left out since they are needed even without scans. Check
+// StatsReset before dropping anything: a recent reset makes every index look unused, and
+// statistics are per server, so indexes used only on replicas show no scans here.
+func (is *IntrospectionService) GetUnusedIndexes(ctx context.Context, schema string) (*UnusedIndexReport, error) {
+	report := &UnusedIndexReport{Indexes: []IndexUsage{}}
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.GetContext(ctx, &report.StatsReset,
+			"SELECT stats_reset FROM pg_stat_database WHERE datname = current_database()")
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_unused_indexes", "failed to get statistics reset time")
+	}
+
+	usage, err := is.GetIndexUsage(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	// Usage is ordered by scans, then size
+	for _, index := range usage {
+		if index.Scans > 0 || index.IsUnique || index.EnforcesConstraint {
+			continue
+		}
+		report.Indexes = append(report.Indexes, index)
+		report.TotalBytes += index.SizeBytes
+	}
+
+	return report, nil
+}
+
+// ColumnStatistics is the value distribution of a column as sampled by ANALYZE, from pg_stats
+type ColumnStatistics struct {
+	Column string `json:"column" db:"column_name"`
+	// Inherited is set for the statistics of a parent table covering its inheritance children
+	// or partitions; a parent may have both
+	Inherited bool `json:"inherited" db:"inherited"`
+	// NullFraction is the fraction of rows where the column is null
+	NullFraction float64 `json:"null_fraction" db:"null_frac"`
+	// AverageWidth is the average size in bytes of the non-null values
+	AverageWidth int `json:"average_width" db:"avg_width"`
+	// DistinctValues is the number of distinct values if positive, or minus their number
+	// divided by the rows if negative, e.g. -1 for a unique column
+	DistinctValues float64 `json:"distinct_values" db:"n_distinct"`
+	// EstimatedDistinct is the number of distinct values at the current table size
+	EstimatedDistinct int64 `json:"estimated_distinct" db:"estimated_distinct"`
+	// MostCommonValues are the most common values in text form, with the fraction of rows
+	// holding each in MostCommonFrequencies
+	MostCommonValues      pq.StringArray  `json:"most_common_values,omitempty" db:"most_common_values"`
+	MostCommonFrequencies pq.Float64Array `json:"most_common_frequencies,omitempty" db:"most_common_frequencies"`
+	// Correlation is the statistical correlation between the physical row order and the
+	// order of the values, from -1 to 1; near ±1 index range scans read few pages. Nil for
+	// types without an ordering.
+	Correlation *float64 `json:"correlation,omitempty" db:"correlation"`
+}
+
+// GetColumnStatistics retrieves the statistics of the columns of a table in column order.
+// Columns of tables never analyzed, and those the current role cannot read, have no
+// statistics and are left out.
+func (is *IntrospectionService) GetColumnStatistics(ctx context.Context, schema, tableName string) ([]ColumnStatistics, error) {
+	statistics := []ColumnStatistics{}
+
+	query := `
+		SELECT
+			s.attname as column_name,
+			s.inherited,
+			s.null_frac,
+			s.avg_width,
+			s.n_distinct,
+			round(CASE
+				WHEN s.n_distinct < 0 THEN -s.n_distinct * greatest(c.reltuples, 0)
+				ELSE s.n_distinct
+			END)::bigint as estimated_distinct,
+			s.most_common_vals::text::text[] as most_common_values,
+			s.most_common_freqs as most_common_frequencies,
+			s.correlation
+		FROM pg_stats s
+		JOIN pg_namespace n ON n.nspname = s.schemaname
+		JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = s.tablename
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attname = s.attname
+		WHERE s.schemaname = $1 AND s.tablename = $2
+		ORDER BY a.attnum, s.inherited
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &statistics, query, schema, tableName)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_column_statistics", "failed to get column statistics").
+			WithContext("schema", schema).
+			WithContext("table", tableName)
+	}
+
+	return statistics, nil
+}
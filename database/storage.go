@@ -0,0 +1,371 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupObject describes a backup stored in a BackupStorage
+type BackupObject struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// BackupStorage stores backup files. Keys are slash-separated and relative to the
+// location the storage was opened at.
+type BackupStorage interface {
+	// Put stores the contents of r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the object stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the objects whose keys start with prefix, sorted by key
+	List(ctx context.Context, prefix string) ([]BackupObject, error)
+	// Delete removes the object stored under key
+	Delete(ctx context.Context, key string) error
+}
+
+// Storage URL schemes understood by OpenBackupStorage
+const (
+	StorageSchemeFile  = "file"
+	StorageSchemeS3    = "s3"
+	StorageSchemeGCS   = "gs"
+	StorageSchemeAzure = "azblob"
+	StorageSchemeHTTP  = "http"
+	StorageSchemeHTTPS = "https"
+)
+
+// IsStorageURL reports whether location names a remote backup storage rather than a
+// local path, e.g. s3://bucket/prefix
+func IsStorageURL(location string) bool {
+	u, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case StorageSchemeS3, StorageSchemeGCS, StorageSchemeAzure, StorageSchemeHTTP, StorageSchemeHTTPS:
+		return true
+	default:
+		return false
+	}
+}
+
+// OpenBackupStorage opens the storage at location, which is either a local directory, a
+// file:// URL or a remote URL:
+//
+//	s3://bucket/prefix        AWS S3 (credentials from the default AWS chain)
+//	gs://bucket/prefix        Google Cloud Storage through its S3-compatible XML API
+//	azblob://container/prefix Azure Blob Storage
+//	https://host/path         read-only HTTP(S), for restores
+func OpenBackupStorage(ctx context.Context, location string) (BackupStorage, error) {
+	if !strings.Contains(location, "://") {
+		return NewFileStorage(location), nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, NewConfigError("invalid backup storage URL", err).
+			WithContext("url", location).
+			WithOperation("open_backup_storage")
+	}
+
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case StorageSchemeFile:
+		return NewFileStorage(u.Path), nil
+	case StorageSchemeS3:
+		return NewS3Storage(ctx, u.Host, prefix)
+	case StorageSchemeGCS:
+		return NewGCSStorage(ctx, u.Host, prefix)
+	case StorageSchemeAzure:
+		return NewAzureStorage(u.Host, prefix)
+	case StorageSchemeHTTP, StorageSchemeHTTPS:
+		return NewHTTPStorage(location), nil
+	default:
+		return nil, NewConfigError(fmt.Sprintf("unsupported backup storage scheme %q", u.Scheme), nil).
+			WithContext("url", location).
+			WithOperation("open_backup_storage")
+	}
+}
+
+// splitStorageURL splits a storage URL naming a single object into the URL of its parent
+// and the object key. URLs ending in a slash name a prefix and return an empty key.
+func splitStorageURL(location string) (string, string) {
+	if strings.HasSuffix(location, "/") {
+		return strings.TrimSuffix(location, "/"), ""
+	}
+	i := strings.LastIndex(location, "/")
+	if i < 0 || strings.HasSuffix(location[:i], ":/") {
+		return location, ""
+	}
+	return location[:i], location[i+1:]
+}
+
+// joinKey joins a storage prefix and key
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
+
+// fileStorage implements BackupStorage on a local directory
+type fileStorage struct {
+	dir string
+}
+
+// NewFileStorage creates a BackupStorage storing backups in dir
+func NewFileStorage(dir string) BackupStorage {
+	return &fileStorage{dir: dir}
+}
+
+func (s *fileStorage) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+// Put writes r to a temporary file renamed into place, so a failed write never leaves a
+// truncated backup behind
+func (s *fileStorage) Put(ctx context.Context, key string, r io.Reader) (err error) {
+	target := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return NewBackupError("failed to create backup directory", err).
+			WithContext("key", key).
+			WithOperation("storage_put")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*")
+	if err != nil {
+		return NewBackupError("failed to create backup file", err).
+			WithContext("key", key).
+			WithOperation("storage_put")
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return NewBackupError("failed to write backup file", err).
+			WithContext("key", key).
+			WithOperation("storage_put")
+	}
+	if err := tmp.Close(); err != nil {
+		return NewBackupError("failed to write backup file", err).
+			WithContext("key", key).
+			WithOperation("storage_put")
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return NewBackupError("failed to store backup file", err).
+			WithContext("key", key).
+			WithOperation("storage_put")
+	}
+	return nil
+}
+
+// Get opens the backup file stored under key
+func (s *fileStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(s.path(key))
+	if err != nil {
+		return nil, NewRestoreError("failed to open backup file", err).
+			WithContext("key", key).
+			WithOperation("storage_get")
+	}
+	return file, nil
+}
+
+// List walks the storage directory for files whose keys start with prefix. Directory
+// format backups are listed as a single object.
+func (s *fileStorage) List(ctx context.Context, prefix string) ([]BackupObject, error) {
+	objects := []BackupObject{}
+	err := filepath.WalkDir(s.dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == s.dir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if strings.HasPrefix(path.Base(key), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() && !isDirectoryBackup(p) {
+			return nil
+		}
+		if strings.HasPrefix(key, prefix) {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			objects = append(objects, BackupObject{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		}
+		if entry.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, NewBackupError("failed to list backups", err).
+			WithContext("dir", s.dir).
+			WithOperation("storage_list")
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// Delete removes the backup file or directory stored under key
+func (s *fileStorage) Delete(ctx context.Context, key string) error {
+	if err := os.RemoveAll(s.path(key)); err != nil {
+		return NewBackupError("failed to delete backup", err).
+			WithContext("key", key).
+			WithOperation("storage_delete")
+	}
+	return nil
+}
+
+// isDirectoryBackup reports whether dir holds a pg_dump directory format backup
+func isDirectoryBackup(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "toc.dat"))
+	return err == nil
+}
+
+// backupToStorage dumps into a temporary file and uploads it and its manifest to the storage
+// at location.
+// When isPrefix is set, or location ends in a slash, a timestamped name is generated.
+func (d *DB) backupToStorage(ctx context.Context, location string, isPrefix bool, opts BackupOptions) (string, error) {
+	if opts.Format == FormatDirectory {
+		return "", NewValidationError("directory backups cannot be uploaded to remote storage", nil).
+			WithContext("url", location).
+			WithOperation("backup")
+	}
+
+	recipients, err := encryptionRecipients(d.config, opts)
+	if err != nil {
+		return "", err
+	}
+	opts.Recipients = recipients
+
+	base, key := splitStorageURL(location)
+	if isPrefix {
+		base, key = strings.TrimSuffix(location, "/"), ""
+	}
+	if key == "" {
+		key = backupFileName(d.config.DBName, opts)
+	}
+
+	storage, err := OpenBackupStorage(ctx, base)
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "db-kit-backup-*")
+	if err != nil {
+		return "", NewBackupError("failed to create temporary backup directory", err).
+			WithOperation("backup")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	localPath, err := d.Backuper.BackupWithOptions(ctx, d.config, filepath.Join(tmpDir, path.Base(key)), opts)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", NewBackupError("failed to open backup file", err).
+			WithContext("backup_path", localPath).
+			WithOperation("backup")
+	}
+	defer file.Close()
+
+	if err := storage.Put(ctx, key, file); err != nil {
+		return "", err
+	}
+
+	if manifest, err := os.Open(ManifestPath(localPath)); err == nil {
+		defer manifest.Close()
+		if err := storage.Put(ctx, key+manifestSuffix, manifest); err != nil {
+			return "", err
+		}
+	}
+	return base + "/" + key, nil
+}
+
+// restoreFromStorage restores the backup at location. Backups are streamed from the storage
+// unless parallel jobs are requested, which need a local file pg_restore can seek in; the
+// backup and its manifest are then downloaded into a temporary directory first.
+func (d *DB) restoreFromStorage(ctx context.Context, location string, opts RestoreOptions) error {
+	base, key := splitStorageURL(location)
+	if key == "" {
+		return NewValidationError("backup URL must name a backup file", nil).
+			WithContext("url", location).
+			WithOperation("restore")
+	}
+
+	storage, err := OpenBackupStorage(ctx, base)
+	if err != nil {
+		return err
+	}
+
+	if opts.Jobs <= 1 {
+		return d.Restorer.RestoreFromStorage(ctx, d.config, storage, key, opts)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "db-kit-restore-*")
+	if err != nil {
+		return NewRestoreError("failed to create temporary restore directory", err).
+			WithOperation("restore")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	localPath := filepath.Join(tmpDir, path.Base(key))
+	if err := downloadBackup(ctx, storage, key, localPath); err != nil {
+		return err
+	}
+	// The manifest is optional; without it the backup is restored unverified
+	_ = downloadBackup(ctx, storage, key+manifestSuffix, ManifestPath(localPath))
+	return d.Restorer.RestoreWithOptions(ctx, d.config, localPath, opts)
+}
+
+// downloadBackup copies the object stored under key to localPath
+func downloadBackup(ctx context.Context, storage BackupStorage, key, localPath string) error {
+	reader, err := storage.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return NewRestoreError("failed to create temporary backup file", err).
+			WithOperation("restore")
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, reader); err != nil {
+		return NewRestoreError("failed to download backup", err).
+			WithContext("key", key).
+			WithOperation("restore")
+	}
+	return file.Close()
+}
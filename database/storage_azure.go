@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+)
+
+// azureStorage implements BackupStorage on an Azure Blob Storage container
+type azureStorage struct {
+	client    *azblob.Client
+	container string
+	prefix    string
+}
+
+// NewAzureStorage creates a BackupStorage storing backups in an Azure Blob Storage container
+// under prefix. The account is configured with AZURE_STORAGE_CONNECTION_STRING, or with
+// AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY.
+func NewAzureStorage(container, prefix string) (BackupStorage, error) {
+	if container == "" {
+		return nil, NewConfigError("Azure container is required", nil).
+			WithOperation("new_azure_storage")
+	}
+
+	var (
+		client *azblob.Client
+		err    error
+	)
+	if connectionString := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); connectionString != "" {
+		client, err = azblob.NewClientFromConnectionString(connectionString, nil)
+	} else {
+		account, key := os.Getenv("AZURE_STORAGE_ACCOUNT"), os.Getenv("AZURE_STORAGE_KEY")
+		if account == "" || key == "" {
+			return nil, NewConfigError("AZURE_STORAGE_CONNECTION_STRING or AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY are required for azblob:// storage", nil).
+				WithOperation("new_azure_storage")
+		}
+		var credential *azblob.SharedKeyCredential
+		credential, err = azblob.NewSharedKeyCredential(account, key)
+		if err == nil {
+			client, err = azblob.NewClientWithSharedKeyCredential(
+				fmt.Sprintf("https://%s.blob.core.windows.net/", account), credential, nil)
+		}
+	}
+	if err != nil {
+		return nil, NewConfigError("failed to create Azure storage client", err).
+			WithOperation("new_azure_storage")
+	}
+
+	return &azureStorage{client: client, container: container, prefix: prefix}, nil
+}
+
+// Put uploads r as a block blob in parts
+func (s *azureStorage) Put(ctx context.Context, key string, r io.Reader) error {
+	if _, err := s.client.UploadStream(ctx, s.container, joinKey(s.prefix, key), r, nil); err != nil {
+		return NewBackupError("failed to upload backup", err).
+			WithContext("container", s.container).
+			WithContext("key", key).
+			WithOperation("storage_put")
+	}
+	return nil
+}
+
+// Get streams the blob stored under key
+func (s *azureStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.client.DownloadStream(ctx, s.container, joinKey(s.prefix, key), nil)
+	if err != nil {
+		return nil, NewRestoreError("failed to download backup", err).
+			WithContext("container", s.container).
+			WithContext("key", key).
+			WithOperation("storage_get")
+	}
+	return resp.Body, nil
+}
+
+// List returns the blobs under the storage prefix whose keys start with prefix
+func (s *azureStorage) List(ctx context.Context, prefix string) ([]BackupObject, error) {
+	root := ""
+	if s.prefix != "" {
+		root = s.prefix + "/"
+	}
+	fullPrefix := root + prefix
+
+	objects := []BackupObject{}
+	pager := s.client.NewListBlobsFlatPager(s.container, &azblob.ListBlobsFlatOptions{Prefix: &fullPrefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, NewBackupError("failed to list backups", err).
+				WithContext("container", s.container).
+				WithOperation("storage_list")
+		}
+		for _, blob := range page.Segment.BlobItems {
+			object := BackupObject{Key: strings.TrimPrefix(*blob.Name, root)}
+			if blob.Properties != nil {
+				if blob.Properties.ContentLength != nil {
+					object.Size = *blob.Properties.ContentLength
+				}
+				if blob.Properties.LastModified != nil {
+					object.ModTime = *blob.Properties.LastModified
+				}
+			}
+			objects = append(objects, object)
+		}
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// Delete removes the blob stored under key
+func (s *azureStorage) Delete(ctx context.Context, key string) error {
+	if _, err := s.client.DeleteBlob(ctx, s.container, joinKey(s.prefix, key), nil); err != nil {
+		return NewBackupError("failed to delete backup", err).
+			WithContext("container", s.container).
+			WithContext("key", key).
+			WithOperation("storage_delete")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// httpStorage implements a read-only BackupStorage over HTTP(S), e.g. for restoring from
+// presigned URLs or an internal file server
+type httpStorage struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPStorage creates a read-only BackupStorage fetching backups below baseURL
+func NewHTTPStorage(baseURL string) BackupStorage {
+	return &httpStorage{baseURL: strings.TrimSuffix(baseURL, "/"), client: http.DefaultClient}
+}
+
+// Get downloads the backup at baseURL/key
+func (s *httpStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	url := s.baseURL + "/" + key
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, NewRestoreError("failed to create backup request", err).
+			WithContext("url", url).
+			WithOperation("storage_get")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, NewRestoreError("failed to download backup", err).
+			WithContext("url", url).
+			WithOperation("storage_get")
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, NewRestoreError(fmt.Sprintf("backup download returned status %d", resp.StatusCode), nil).
+			WithContext("url", url).
+			WithContext("status", resp.StatusCode).
+			WithOperation("storage_get")
+	}
+	return resp.Body, nil
+}
+
+// Put is not supported by HTTP storage
+func (s *httpStorage) Put(ctx context.Context, key string, r io.Reader) error {
+	return s.readOnly("storage_put")
+}
+
+// List is not supported by HTTP storage
+func (s *httpStorage) List(ctx context.Context, prefix string) ([]BackupObject, error) {
+	return nil, s.readOnly("storage_list")
+}
+
+// Delete is not supported by HTTP storage
+func (s *httpStorage) Delete(ctx context.Context, key string) error {
+	return s.readOnly("storage_delete")
+}
+
+func (s *httpStorage) readOnly(operation string) error {
+	return NewConfigError("HTTP backup storage is read-only", nil).
+		WithContext("url", s.baseURL).
+		WithOperation(operation)
+}
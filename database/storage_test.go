@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsStorageURL(t *testing.T) {
+	tests := map[string]bool{
+		"s3://bucket/prefix":        true,
+		"gs://bucket":               true,
+		"azblob://container/prefix": true,
+		"file:///var/backups":       false,
+		"./tmp/backups":             false,
+		"/var/backups/backup.sql":   false,
+	}
+	for location, want := range tests {
+		if got := IsStorageURL(location); got != want {
+			t.Errorf("IsStorageURL(%q) = %v, want %v", location, got, want)
+		}
+	}
+}
+
+func TestSplitStorageURL(t *testing.T) {
+	tests := []struct {
+		location string
+		base     string
+		key      string
+	}{
+		{"s3://bucket/prefix/backup.sql.gz", "s3://bucket/prefix", "backup.sql.gz"},
+		{"s3://bucket/backup.sql", "s3://bucket", "backup.sql"},
+		{"s3://bucket/prefix/", "s3://bucket/prefix", ""},
+		{"s3://bucket", "s3://bucket", ""},
+	}
+	for _, tt := range tests {
+		base, key := splitStorageURL(tt.location)
+		if base != tt.base || key != tt.key {
+			t.Errorf("splitStorageURL(%q) = (%q, %q), want (%q, %q)", tt.location, base, key, tt.base, tt.key)
+		}
+	}
+}
+
+func TestOpenBackupStorageErrors(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := OpenBackupStorage(ctx, "ftp://host/backups"); err == nil {
+		t.Error("Expected error for unsupported scheme")
+	}
+	if _, err := OpenBackupStorage(ctx, "s3:///prefix"); err == nil {
+		t.Error("Expected error for missing bucket")
+	}
+
+	t.Setenv("GCS_HMAC_ACCESS_ID", "")
+	if _, err := OpenBackupStorage(ctx, "gs://bucket/prefix"); err == nil {
+		t.Error("Expected error for missing GCS credentials")
+	}
+}
+
+func TestFileStorage(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	storage, err := OpenBackupStorage(ctx, "file://"+dir)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+
+	for _, key := range []string{"backup_app_1.sql", "backup_app_2.sql.gz", "other/backup_app_3.sql"} {
+		if err := storage.Put(ctx, key, strings.NewReader("-- "+key)); err != nil {
+			t.Fatalf("Put(%s) failed: %v", key, err)
+		}
+	}
+
+	// Directory format backups are listed as one object, temporary files are skipped
+	if err := os.MkdirAll(filepath.Join(dir, "backup_app_4"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "backup_app_4", "toc.dat"), []byte("PGDMP"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".backup_app_5.sql.123"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := storage.List(ctx, "backup_")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var keys []string
+	for _, object := range objects {
+		keys = append(keys, object.Key)
+	}
+	if got := strings.Join(keys, ","); got != "backup_app_1.sql,backup_app_2.sql.gz,backup_app_4" {
+		t.Errorf("Unexpected objects: %s", got)
+	}
+
+	reader, err := storage.Get(ctx, "other/backup_app_3.sql")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	content, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(content) != "-- other/backup_app_3.sql" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+
+	if err := storage.Delete(ctx, "backup_app_4"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "backup_app_4")); !os.IsNotExist(err) {
+		t.Error("Expected directory backup to be deleted")
+	}
+
+	empty, err := NewFileStorage(filepath.Join(dir, "missing")).List(ctx, "")
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Expected empty listing for missing directory, got %v (%v)", empty, err)
+	}
+}
+
+func TestHTTPStorage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/backups/backup_app_20250101_030000.sql" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, "SELECT 1;")
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	location := server.URL + "/backups/backup_app_20250101_030000.sql"
+	if !IsStorageURL(location) {
+		t.Fatalf("Expected %s to be a storage URL", location)
+	}
+
+	base, key := splitStorageURL(location)
+	storage, err := OpenBackupStorage(ctx, base)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+
+	reader, err := storage.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	content, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(content) != "SELECT 1;" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+
+	if _, err := storage.Get(ctx, "missing.sql"); err == nil {
+		t.Error("Expected error for missing backup")
+	}
+	if err := storage.Put(ctx, key, strings.NewReader("")); err == nil {
+		t.Error("Expected HTTP storage to be read-only")
+	}
+}
+
+func TestRestoreFromStorage(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	db.config.BackupsDir = dir
+
+	path, err := db.BackupWithOptions(ctx, "", BackupOptions{Compression: CompressionGzip})
+	if err != nil {
+		t.Skipf("Backup failed (pg_dump may not be available): %v", err)
+	}
+
+	storage := NewFileStorage(dir)
+	if err := db.Restorer.RestoreFromStorage(ctx, db.config, storage, filepath.Base(path), DefaultRestoreOptions()); err != nil {
+		t.Fatalf("Streaming restore failed: %v", err)
+	}
+
+	// A manifest that does not match the streamed bytes is reported
+	if err := os.WriteFile(ManifestPath(path), []byte(`{"size": 1, "sha256": "00"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Restorer.RestoreFromStorage(ctx, db.config, storage, filepath.Base(path), DefaultRestoreOptions()); err == nil {
+		t.Error("Expected checksum mismatch to be reported")
+	}
+}
@@ -0,0 +1,432 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Formats of ExportData and ImportData
+const (
+	DataFormatCSV    = "csv"
+	DataFormatNDJSON = "ndjson"
+)
+
+// ExportDataOptions selects the rows ExportData writes
+type ExportDataOptions struct {
+	// Table to export, optionally schema-qualified; exclusive with Query
+	Table string
+	// Query is a SELECT whose result is exported instead of a table
+	Query string
+	// Where filters the rows with an SQL condition, e.g. "created_at > now() - interval '1 day'"
+	Where string
+	// Format is DataFormatCSV, with a header row, or DataFormatNDJSON, one JSON object per row
+	Format string
+	// Masks replaces exported columns with fake values, by column name, with the strategies
+	// of a MaskingConfig, e.g. from its TableMasks
+	Masks map[string]string
+	// MaskSeed keys the fake values of Masks; a random seed is used if empty
+	MaskSeed string
+}
+
+// ImportDataOptions configures ImportData
+type ImportDataOptions struct {
+	// Table to import into, optionally schema-qualified
+	Table string
+	// Format is DataFormatCSV, with a header row naming the columns, or DataFormatNDJSON
+	Format string
+	// Columns maps the columns of the file, CSV header names or JSON keys, to the columns of
+	// the table; the others are imported into the column of the same name
+	Columns map[string]string
+	// Truncate empties the table first, in the same transaction as the import
+	Truncate bool
+}
+
+// validateDataFormat checks a format of ExportData and ImportData
+func validateDataFormat(format, op string) error {
+	switch format {
+	case DataFormatCSV, DataFormatNDJSON:
+		return nil
+	case "parquet":
+		// No Parquet encoder is vendored yet; convert from NDJSON with external tools
+		return NewValidationError("parquet is not supported yet, use csv or ndjson", nil).
+			WithContext("format", format).
+			WithOperation(op)
+	}
+	return NewValidationError(fmt.Sprintf("unsupported data format %q, expected csv or ndjson", format), nil).
+		WithContext("format", format).
+		WithOperation(op)
+}
+
+// selection returns the query selecting the exported rows, as a subquery aliased t
+func (o ExportDataOptions) selection() (string, error) {
+	var source string
+	switch {
+	case o.Table != "" && o.Query != "":
+		return "", NewValidationError("export a table or a query, not both", nil).
+			WithOperation("export_data")
+	case o.Table != "":
+		table, err := quoteQualifiedName(o.Table)
+		if err != nil {
+			return "", err
+		}
+		source = "SELECT * FROM " + table
+	case strings.TrimSpace(o.Query) != "":
+		source = strings.TrimSuffix(strings.TrimSpace(o.Query), ";")
+	default:
+		return "", NewValidationError("no table or query to export", nil).
+			WithOperation("export_data")
+	}
+
+	selection := "(" + source + ") t"
+	if o.Where != "" {
+		selection += " WHERE " + o.Where
+	}
+	return selection, nil
+}
+
+// ExportData streams the rows of a table or query to w as CSV or NDJSON and returns the
+// number of rows written. CSV values are the text representation of PostgreSQL, which
+// ImportData and COPY read back, with NULL as an empty field.
+func (d *DB) ExportData(ctx context.Context, w io.Writer, opts ExportDataOptions) (int64, error) {
+	if err := validateDataFormat(opts.Format, "export_data"); err != nil {
+		return 0, err
+	}
+	selection, err := opts.selection()
+	if err != nil {
+		return 0, err
+	}
+
+	var columns []string
+	if opts.Format == DataFormatCSV || len(opts.Masks) > 0 {
+		if columns, err = d.selectionColumns(ctx, selection); err != nil {
+			return 0, WrapError(err, ErrCodeQueryFailed, "export_data", "failed to query the exported columns")
+		}
+	}
+	if len(opts.Masks) > 0 {
+		seed := opts.MaskSeed
+		if seed == "" {
+			if seed, err = randomMaskSeed(); err != nil {
+				return 0, err
+			}
+		}
+		if selection, err = maskSelection(selection, columns, opts.Masks, seed); err != nil {
+			return 0, err
+		}
+	}
+
+	var rows int64
+	if opts.Format == DataFormatNDJSON {
+		rows, err = d.exportNDJSON(ctx, w, selection)
+	} else {
+		rows, err = d.exportCSV(ctx, w, selection, columns)
+	}
+	if err != nil {
+		return rows, WrapError(err, ErrCodeQueryFailed, "export_data", "failed to export rows").
+			WithContext("rows_written", rows)
+	}
+	d.logger.Info("data exported", "rows", rows, "format", opts.Format)
+	return rows, nil
+}
+
+// selectionColumns returns the columns of selection, which may come from any query
+func (d *DB) selectionColumns(ctx context.Context, selection string) ([]string, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT * FROM "+selection+" LIMIT 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rows.Columns()
+}
+
+// exportCSV writes the rows of selection with a header row of its columns
+func (d *DB) exportCSV(ctx context.Context, w io.Writer, selection string, columns []string) (int64, error) {
+	selected := make([]string, len(columns))
+	for i, column := range columns {
+		selected[i] = "t." + pq.QuoteIdentifier(column) + "::text"
+	}
+	rows, err := d.db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s", strings.Join(selected, ", "), selection))
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return 0, err
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+
+	var written int64
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return written, err
+		}
+		for i, value := range values {
+			record[i] = value.String
+		}
+		if err := writer.Write(record); err != nil {
+			return written, err
+		}
+		written++
+	}
+	if err := rows.Err(); err != nil {
+		return written, err
+	}
+	writer.Flush()
+	return written, writer.Error()
+}
+
+// exportNDJSON writes the rows of selection as JSON objects, one per line
+func (d *DB) exportNDJSON(ctx context.Context, w io.Writer, selection string) (int64, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT row_to_json(t)::text FROM "+selection)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	buffered := bufio.NewWriter(w)
+	var written int64
+	for rows.Next() {
+		var row string
+		if err := rows.Scan(&row); err != nil {
+			return written, err
+		}
+		if _, err := buffered.WriteString(row + "\n"); err != nil {
+			return written, err
+		}
+		written++
+	}
+	if err := rows.Err(); err != nil {
+		return written, err
+	}
+	return written, buffered.Flush()
+}
+
+// dataRecords reads the records of an import file as column names and rows of values, nil
+// for NULL
+type dataRecords interface {
+	columns() []string
+	next() ([]*string, error)
+}
+
+// ImportData loads CSV or NDJSON rows from r into a table with COPY, in one transaction,
+// and returns the number of rows imported. Empty CSV fields and JSON nulls are NULL;
+// JSON objects and arrays are imported as their JSON text, e.g. into json columns.
+func (d *DB) ImportData(ctx context.Context, r io.Reader, opts ImportDataOptions) (int64, error) {
+	if err := validateDataFormat(opts.Format, "import_data"); err != nil {
+		return 0, err
+	}
+	schema, name, err := splitTableName(opts.Table)
+	if err != nil {
+		return 0, err
+	}
+	table, _ := quoteQualifiedName(opts.Table)
+
+	var records dataRecords
+	if opts.Format == DataFormatNDJSON {
+		records, err = newNDJSONRecords(r)
+	} else {
+		records, err = newCSVRecords(r)
+	}
+	if err != nil {
+		return 0, NewValidationError("failed to read the import file header", err).
+			WithContext("format", opts.Format).
+			WithOperation("import_data")
+	}
+
+	columns := make([]string, len(records.columns()))
+	for i, column := range records.columns() {
+		if mapped, ok := opts.Columns[column]; ok {
+			column = mapped
+		}
+		columns[i] = column
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, WrapError(err, ErrCodeTransactionBegin, "import_data", "failed to begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if opts.Truncate {
+		if _, err := tx.ExecContext(ctx, "TRUNCATE "+table); err != nil {
+			return 0, WrapError(err, ErrCodeQueryFailed, "import_data", "failed to truncate table").
+				WithContext("table", opts.Table)
+		}
+	}
+
+	copyStatement := pq.CopyIn(name, columns...)
+	if schema != "" {
+		copyStatement = pq.CopyInSchema(schema, name, columns...)
+	}
+	stmt, err := tx.PrepareContext(ctx, copyStatement)
+	if err != nil {
+		return 0, WrapError(err, ErrCodeQueryFailed, "import_data", "failed to start COPY").
+			WithContext("table", opts.Table).
+			WithContext("columns", columns)
+	}
+	defer stmt.Close()
+
+	var imported int64
+	args := make([]interface{}, len(columns))
+	for {
+		values, err := records.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, NewValidationError(fmt.Sprintf("invalid record %d", imported+1), err).
+				WithContext("table", opts.Table).
+				WithOperation("import_data")
+		}
+		for i, value := range values {
+			args[i] = nil
+			if value != nil {
+				args[i] = *value
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return 0, WrapError(err, ErrCodeQueryFailed, "import_data", "failed to copy rows").
+				WithContext("table", opts.Table).
+				WithContext("record", imported+1)
+		}
+		imported++
+	}
+
+	// An Exec without arguments flushes the COPY and reports rows the server rejected
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, WrapError(err, ErrCodeQueryFailed, "import_data", "failed to copy rows").
+			WithContext("table", opts.Table)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, WrapError(err, ErrCodeTransactionCommit, "import_data", "failed to commit import").
+			WithContext("table", opts.Table)
+	}
+
+	d.logger.Info("data imported", "table", opts.Table, "rows", imported)
+	return imported, nil
+}
+
+// csvRecords reads a CSV file with a header row
+type csvRecords struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCSVRecords(r io.Reader) (*csvRecords, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	// Later records must have as many fields as the header
+	reader.ReuseRecord = true
+	return &csvRecords{reader: reader, header: header}, nil
+}
+
+func (c *csvRecords) columns() []string {
+	return c.header
+}
+
+func (c *csvRecords) next() ([]*string, error) {
+	record, err := c.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*string, len(record))
+	for i, field := range record {
+		if field != "" {
+			values[i] = &field
+		}
+	}
+	return values, nil
+}
+
+// ndjsonRecords reads JSON objects, one per line, whose keys are the keys of the first
+type ndjsonRecords struct {
+	decoder *json.Decoder
+	keys    []string
+	first   map[string]interface{}
+}
+
+func newNDJSONRecords(r io.Reader) (*ndjsonRecords, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var first map[string]interface{}
+	if err := decoder.Decode(&first); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(first))
+	for key := range first {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return &ndjsonRecords{decoder: decoder, keys: keys, first: first}, nil
+}
+
+func (n *ndjsonRecords) columns() []string {
+	return n.keys
+}
+
+func (n *ndjsonRecords) next() ([]*string, error) {
+	object := n.first
+	n.first = nil
+	if object == nil {
+		if err := n.decoder.Decode(&object); err != nil {
+			return nil, err
+		}
+	}
+
+	values := make([]*string, len(n.keys))
+	for i, key := range n.keys {
+		value, err := jsonDataValue(object[key])
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key, err)
+		}
+		values[i] = value
+		delete(object, key)
+	}
+	for key := range object {
+		return nil, fmt.Errorf("key %s is not in the first record", key)
+	}
+	return values, nil
+}
+
+// jsonDataValue returns the COPY value of a decoded JSON value, nil for null
+func jsonDataValue(value interface{}) (*string, error) {
+	var text string
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		text = value
+	case json.Number:
+		text = value.String()
+	case bool:
+		text = fmt.Sprintf("%t", value)
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	return &text, nil
+}
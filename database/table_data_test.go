@@ -0,0 +1,137 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportDataSelection(t *testing.T) {
+	selection, err := ExportDataOptions{Table: "audit.events", Where: "id > 10"}.selection()
+	require.NoError(t, err)
+	assert.Equal(t, `(SELECT * FROM "audit"."events") t WHERE id > 10`, selection)
+
+	selection, err = ExportDataOptions{Query: " SELECT id FROM users; "}.selection()
+	require.NoError(t, err)
+	assert.Equal(t, `(SELECT id FROM users) t`, selection)
+
+	for _, opts := range []ExportDataOptions{{}, {Table: "users", Query: "SELECT 1"}, {Table: "a.b.c"}} {
+		_, err := opts.selection()
+		assert.Equal(t, ErrCodeValidation, GetErrorCode(err), "%+v", opts)
+	}
+
+	db := &DB{}
+	_, err = db.ExportData(context.Background(), io.Discard, ExportDataOptions{Table: "users", Format: "parquet"})
+	assert.Equal(t, ErrCodeValidation, GetErrorCode(err))
+}
+
+// readRecords reads all the records of an import file, with "NULL" for nil values
+func readRecords(t *testing.T, records dataRecords) [][]string {
+	t.Helper()
+	var all [][]string
+	for {
+		values, err := records.next()
+		if errors.Is(err, io.EOF) {
+			return all
+		}
+		require.NoError(t, err)
+		record := make([]string, len(values))
+		for i, value := range values {
+			record[i] = "NULL"
+			if value != nil {
+				record[i] = *value
+			}
+		}
+		all = append(all, record)
+	}
+}
+
+func TestCSVRecords(t *testing.T) {
+	records, err := newCSVRecords(strings.NewReader("id,name\n1,\"Ada, Countess\"\n2,\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "name"}, records.columns())
+	assert.Equal(t, [][]string{{"1", "Ada, Countess"}, {"2", "NULL"}}, readRecords(t, records))
+
+	records, err = newCSVRecords(strings.NewReader("id,name\n1\n"))
+	require.NoError(t, err)
+	_, err = records.next()
+	assert.Error(t, err)
+
+	_, err = newCSVRecords(strings.NewReader(""))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestNDJSONRecords(t *testing.T) {
+	input := `{"id": 1, "name": "Ada", "tags": ["a"], "active": true}
+{"id": 12345678901234567890, "name": null, "tags": {"k": 1}, "active": false}
+`
+	records, err := newNDJSONRecords(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"active", "id", "name", "tags"}, records.columns())
+	assert.Equal(t, [][]string{
+		{"true", "1", "Ada", `["a"]`},
+		{"false", "12345678901234567890", "NULL", `{"k":1}`},
+	}, readRecords(t, records))
+
+	records, err = newNDJSONRecords(strings.NewReader(`{"id": 1}` + "\n" + `{"id": 2, "extra": 3}`))
+	require.NoError(t, err)
+	_, err = records.next()
+	require.NoError(t, err)
+	_, err = records.next()
+	assert.ErrorContains(t, err, "extra")
+}
+
+func TestExportImportData(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+	ctx := context.Background()
+
+	_, err := db.db.ExecContext(ctx, `CREATE TABLE test_table_data (id int PRIMARY KEY, name text, created_on date)`)
+	require.NoError(t, err)
+	defer db.db.ExecContext(ctx, "DROP TABLE IF EXISTS test_table_data")
+	_, err = db.db.ExecContext(ctx, `INSERT INTO test_table_data VALUES (1, 'Ada', '2024-01-02'), (2, NULL, NULL), (3, 'Grace', '2024-03-04')`)
+	require.NoError(t, err)
+
+	var csvData bytes.Buffer
+	rows, err := db.ExportData(ctx, &csvData, ExportDataOptions{Table: "test_table_data", Where: "id < 3", Format: DataFormatCSV})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), rows)
+	assert.Equal(t, "id,name,created_on\n1,Ada,2024-01-02\n2,,\n", csvData.String())
+
+	var ndjsonData bytes.Buffer
+	rows, err = db.ExportData(ctx, &ndjsonData, ExportDataOptions{Query: "SELECT id, name AS label FROM test_table_data ORDER BY id", Format: DataFormatNDJSON})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), rows)
+	assert.Equal(t, `{"id":1,"label":"Ada"}`, strings.SplitN(ndjsonData.String(), "\n", 2)[0])
+
+	rows, err = db.ImportData(ctx, &csvData, ImportDataOptions{Table: "test_table_data", Format: DataFormatCSV, Truncate: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), rows)
+
+	rows, err = db.ImportData(ctx, strings.NewReader(`{"id": 3, "label": "Grace"}`), ImportDataOptions{
+		Table:   "public.test_table_data",
+		Format:  DataFormatNDJSON,
+		Columns: map[string]string{"label": "name"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+
+	var names []*string
+	require.NoError(t, db.db.SelectContext(ctx, &names, "SELECT name FROM test_table_data ORDER BY id"))
+	require.Len(t, names, 3)
+	assert.Nil(t, names[1])
+	assert.Equal(t, "Grace", *names[2])
+
+	// A duplicate key fails the import, which leaves the table as it was
+	_, err = db.ImportData(ctx, strings.NewReader("id,name\n4,Linus\n1,Ada\n"), ImportDataOptions{Table: "test_table_data", Format: DataFormatCSV})
+	require.Error(t, err)
+	var count int
+	require.NoError(t, db.db.GetContext(ctx, &count, "SELECT count(*) FROM test_table_data"))
+	assert.Equal(t, 3, count)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -236,6 +237,9 @@ func (td *TestDatabase) CleanupTestTables(t *testing.T, db *DB) {
 	testTables := []string{
 		"test_users",
 		"test_posts",
+		"test_events",
+		"test_reservations",
+		"test_sessions",
 		"test_transactions",
 		"test_methods",
 		"test_panic",
@@ -264,6 +268,49 @@ func (td *TestDatabase) CleanupTestTables(t *testing.T, db *DB) {
 			t.Logf("Warning: Failed to drop test index %s: %v", index, err)
 		}
 	}
+
+	// Clean up test functions and procedures
+	testRoutines := []string{
+		"test_user_post_count",
+		"test_publish_posts",
+		"test_touch_updated_at",
+	}
+
+	for _, routine := range testRoutines {
+		_, err := db.db.ExecContext(ctx, fmt.Sprintf("DROP ROUTINE IF EXISTS %s CASCADE", routine))
+		if err != nil {
+			t.Logf("Warning: Failed to drop test routine %s: %v", routine, err)
+		}
+	}
+
+	// Clean up test types, after the tables using them
+	testTypes := []string{
+		"test_user_status",
+		"test_address",
+	}
+
+	for _, typ := range testTypes {
+		_, err := db.db.ExecContext(ctx, fmt.Sprintf("DROP TYPE IF EXISTS %s CASCADE", typ))
+		if err != nil {
+			t.Logf("Warning: Failed to drop test type %s: %v", typ, err)
+		}
+	}
+
+	testDomains := []string{
+		"test_email",
+	}
+
+	for _, domain := range testDomains {
+		_, err := db.db.ExecContext(ctx, fmt.Sprintf("DROP DOMAIN IF EXISTS %s CASCADE", domain))
+		if err != nil {
+			t.Logf("Warning: Failed to drop test domain %s: %v", domain, err)
+		}
+	}
+
+	// Dropping the wrapper drops its servers and their foreign tables
+	if _, err := db.db.ExecContext(ctx, "DROP FOREIGN DATA WRAPPER IF EXISTS test_fdw CASCADE"); err != nil {
+		t.Logf("Warning: Failed to drop test foreign data wrapper: %v", err)
+	}
 }
 
 // testLocalPostgreSQL tests if a local PostgreSQL instance is available
@@ -317,3 +364,110 @@ func parseLogLevel(level string) slog.Level {
 		return slog.LevelInfo
 	}
 }
+
+// TestMigrations checks that the migrations in config.MigrationsDir survive an
+// up/down/up round trip. It applies every migration, rolls all of them back, and
+// re-applies them, comparing the schema at each step through the introspection
+// service. Down migrations that leave objects behind, or up migrations that produce a
+// different schema the second time, are reported as test errors.
+//
+// The target database is reset, so it must be dedicated to the test.
+func TestMigrations(t *testing.T, config Config) {
+	t.Helper()
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	if err := db.Migrator.Reset(ctx); err != nil {
+		t.Fatalf("Failed to reset migrations before the round trip: %v", err)
+	}
+	baseline := schemaSnapshot(t, db)
+
+	if err := db.Migrator.Up(ctx); err != nil {
+		t.Fatalf("Failed to apply migrations: %v", err)
+	}
+	applied := schemaSnapshot(t, db)
+
+	if err := db.Migrator.Reset(ctx); err != nil {
+		t.Fatalf("Failed to roll back migrations: %v", err)
+	}
+	for _, line := range diffSchemaSnapshots(baseline, schemaSnapshot(t, db)) {
+		t.Errorf("Schema differs after rolling back all migrations: %s", line)
+	}
+
+	if err := db.Migrator.Up(ctx); err != nil {
+		t.Fatalf("Failed to re-apply migrations after rollback: %v", err)
+	}
+	for _, line := range diffSchemaSnapshots(applied, schemaSnapshot(t, db)) {
+		t.Errorf("Schema differs after re-applying migrations: %s", line)
+	}
+}
+
+// schemaSnapshot describes every table, column, index and constraint as sorted lines,
+// leaving out goose's version tables
+func schemaSnapshot(t *testing.T, db *DB) []string {
+	t.Helper()
+
+	tables, err := db.Introspection().GetTables(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Failed to introspect schema: %v", err)
+	}
+
+	var lines []string
+	for _, table := range tables {
+		if table.Name == DefaultMigrationsTable || table.Name == DefaultSeedsTable ||
+			table.Name == unqualifiedTable(db.config.MigrationsTable) {
+			continue
+		}
+
+		name := table.Schema + "." + table.Name
+		lines = append(lines, fmt.Sprintf("table %s (%s)", name, table.Type))
+		for _, column := range table.Columns {
+			def := ""
+			if column.DefaultValue != nil {
+				def = " default " + *column.DefaultValue
+			}
+			lines = append(lines, fmt.Sprintf("column %s.%s %s nullable=%t%s", name, column.Name, column.DataType, column.IsNullable, def))
+		}
+		for _, index := range table.Indexes {
+			lines = append(lines, fmt.Sprintf("index %s.%s (%s) unique=%t type=%s", name, index.Name, strings.Join(index.Columns, ", "), index.IsUnique, index.IndexType))
+		}
+		for _, constraint := range table.Constraints {
+			lines = append(lines, fmt.Sprintf("constraint %s.%s %s (%s)", name, constraint.Name, constraint.Type, strings.Join(constraint.Columns, ", ")))
+		}
+	}
+
+	sort.Strings(lines)
+	return lines
+}
+
+// diffSchemaSnapshots returns the lines missing from after ("-") and the lines only in after ("+")
+func diffSchemaSnapshots(before, after []string) []string {
+	seen := make(map[string]int, len(before))
+	for _, line := range before {
+		seen[line]++
+	}
+	for _, line := range after {
+		seen[line]--
+	}
+
+	var diff []string
+	for _, line := range before {
+		if seen[line] > 0 {
+			diff = append(diff, "- "+line)
+			seen[line]--
+		}
+	}
+	for _, line := range after {
+		if seen[line] < 0 {
+			diff = append(diff, "+ "+line)
+			seen[line]++
+		}
+	}
+	return diff
+}
@@ -109,3 +109,20 @@ func TestCreateTestDBWithEnv(t *testing.T) {
 	// If we get here without panicking, the test passes
 	t.Logf("CreateTestDBWithEnv completed successfully (database connection available)")
 }
+
+func TestDiffSchemaSnapshots(t *testing.T) {
+	before := []string{"table public.users (BASE TABLE)", "column public.users.id integer nullable=false", "index public.users.users_pkey (id) unique=true type=btree"}
+	after := []string{"table public.users (BASE TABLE)", "column public.users.id bigint nullable=false", "index public.users.users_pkey (id) unique=true type=btree"}
+
+	diff := diffSchemaSnapshots(before, after)
+	if len(diff) != 2 {
+		t.Fatalf("Expected 2 diff lines, got %v", diff)
+	}
+	if diff[0] != "- column public.users.id integer nullable=false" || diff[1] != "+ column public.users.id bigint nullable=false" {
+		t.Errorf("Unexpected diff: %v", diff)
+	}
+
+	if diff := diffSchemaSnapshots(before, before); len(diff) != 0 {
+		t.Errorf("Expected no diff for identical snapshots, got %v", diff)
+	}
+}
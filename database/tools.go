@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ClientTool describes a PostgreSQL client binary used for backups and restores
+type ClientTool struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// Major is the major version, e.g. 17, or 906 for 9.6
+	Major int `json:"major"`
+}
+
+// pgTool returns the path of a PostgreSQL client binary, from PgBinDir if configured
+func pgTool(config Config, name string) string {
+	if config.PgBinDir != "" {
+		return filepath.Join(config.PgBinDir, name)
+	}
+	return name
+}
+
+// toolVersionPattern matches the version printed by pg_dump --version and friends,
+// e.g. "pg_dump (PostgreSQL) 17.2 (Debian 17.2-1.pgdg120+1)"
+var toolVersionPattern = regexp.MustCompile(`\(PostgreSQL\) (\d+)(?:\.(\d+))?`)
+
+// majorVersion returns the major version of a PostgreSQL version string; releases before
+// 10 keep the minor version in the major, so 9.6 is 906
+func majorVersion(version string) (int, error) {
+	match := toolVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return 0, fmt.Errorf("unrecognised version %q", version)
+	}
+	major, _ := strconv.Atoi(match[1])
+	if major < 10 && match[2] != "" {
+		minor, _ := strconv.Atoi(match[2])
+		return major*100 + minor, nil
+	}
+	return major, nil
+}
+
+// serverMajorVersion converts server_version_num, e.g. 170002 or 90624, to a major version
+// comparable with majorVersion
+func serverMajorVersion(versionNum int) int {
+	if versionNum >= 100000 {
+		return versionNum / 10000
+	}
+	return versionNum / 100
+}
+
+// inspectTool runs a client binary with --version
+func inspectTool(ctx context.Context, config Config, name string) (ClientTool, error) {
+	tool := ClientTool{Name: name, Path: pgTool(config, name)}
+	out, err := exec.CommandContext(ctx, tool.Path, "--version").Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+			return tool, NewConfigError(fmt.Sprintf("%s not found", name), err).
+				WithContext("path", tool.Path).
+				WithOperation("check_client_tools").
+				WithUserMessage(fmt.Sprintf("Install the PostgreSQL client tools or set PG_BIN_DIR to the directory containing %s", name))
+		}
+		return tool, NewConfigError(fmt.Sprintf("failed to run %s --version", name), err).
+			WithContext("path", tool.Path).
+			WithOperation("check_client_tools")
+	}
+
+	tool.Version = strings.TrimSpace(string(out))
+	if tool.Major, err = majorVersion(tool.Version); err != nil {
+		return tool, NewConfigError(fmt.Sprintf("failed to read the %s version", name), err).
+			WithContext("path", tool.Path).
+			WithOperation("check_client_tools")
+	}
+	return tool, nil
+}
+
+// CheckClientTools checks that the named client binaries, pg_dump, pg_restore and psql by
+// default, can be run and are not older than the server. pg_dump refuses to dump newer
+// servers, and older restore tools may not understand newer dumps.
+func (d *DB) CheckClientTools(ctx context.Context, names ...string) ([]ClientTool, error) {
+	if len(names) == 0 {
+		names = []string{"pg_dump", "pg_restore", "psql"}
+	}
+
+	var versionNum int
+	if err := d.db.GetContext(ctx, &versionNum, "SELECT current_setting('server_version_num')::int"); err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "check_client_tools", "failed to read the server version")
+	}
+	server := serverMajorVersion(versionNum)
+
+	tools := make([]ClientTool, 0, len(names))
+	for _, name := range names {
+		tool, err := inspectTool(ctx, d.config, name)
+		if err != nil {
+			return tools, err
+		}
+		if tool.Major < server {
+			return tools, NewConfigError(fmt.Sprintf("%s %d is older than the server (%d)", name, tool.Major, server), nil).
+				WithContext("path", tool.Path).
+				WithContext("client_version", tool.Version).
+				WithContext("server_version_num", versionNum).
+				WithOperation("check_client_tools").
+				WithUserMessage(fmt.Sprintf("Install PostgreSQL %d client tools or set PG_BIN_DIR to their directory", server))
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+// checkClientTools runs CheckClientTools once per tool for the built-in Backuper and
+// Restorer; custom implementations may not use the client binaries
+func (d *DB) checkClientTools(ctx context.Context, names ...string) error {
+	_, dump := d.Backuper.(*pgDump)
+	_, restore := d.Restorer.(*pgRestore)
+
+	d.toolsMu.Lock()
+	defer d.toolsMu.Unlock()
+
+	var unchecked []string
+	for _, name := range names {
+		builtIn := (name == "pg_dump" && dump) || (name != "pg_dump" && restore)
+		if builtIn && !d.checkedTools[name] {
+			unchecked = append(unchecked, name)
+		}
+	}
+	if len(unchecked) == 0 {
+		return nil
+	}
+
+	if _, err := d.CheckClientTools(ctx, unchecked...); err != nil {
+		return err
+	}
+	if d.checkedTools == nil {
+		d.checkedTools = make(map[string]bool)
+	}
+	for _, name := range unchecked {
+		d.checkedTools[name] = true
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPgTool(t *testing.T) {
+	assert.Equal(t, "pg_dump", pgTool(Config{}, "pg_dump"))
+	assert.Equal(t, "/usr/lib/postgresql/17/bin/pg_dump", pgTool(Config{PgBinDir: "/usr/lib/postgresql/17/bin"}, "pg_dump"))
+}
+
+func TestMajorVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		major   int
+	}{
+		{"pg_dump (PostgreSQL) 17.2 (Debian 17.2-1.pgdg120+1)", 17},
+		{"pg_restore (PostgreSQL) 16.4", 16},
+		{"psql (PostgreSQL) 18beta1", 18},
+		{"pg_dump (PostgreSQL) 9.6.24", 906},
+	}
+	for _, tt := range tests {
+		major, err := majorVersion(tt.version)
+		require.NoError(t, err, tt.version)
+		assert.Equal(t, tt.major, major, tt.version)
+	}
+
+	_, err := majorVersion("pg_dump 17.2")
+	assert.Error(t, err)
+}
+
+func TestServerMajorVersion(t *testing.T) {
+	assert.Equal(t, 17, serverMajorVersion(170002))
+	assert.Equal(t, 10, serverMajorVersion(100023))
+	assert.Equal(t, 906, serverMajorVersion(90624))
+}
+
+func TestInspectToolNotFound(t *testing.T) {
+	_, err := inspectTool(context.Background(), Config{PgBinDir: t.TempDir()}, "pg_dump")
+	require.Error(t, err)
+
+	var dbErr *DBError
+	require.True(t, errors.As(err, &dbErr))
+	assert.Equal(t, ErrCodeInvalidConfig, dbErr.Code)
+	assert.Contains(t, dbErr.UserMessage, "PG_BIN_DIR")
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"log/slog"
 
 	"github.com/jmoiron/sqlx"
@@ -12,6 +13,7 @@ import (
 type Transaction struct {
 	tx     *sqlx.Tx
 	db     *DB
+	ctx    context.Context
 	logger *slog.Logger
 }
 
@@ -23,126 +25,136 @@ type TransactionFunc func(tx *Transaction) error
 // or rolled back if the function returns an error or panics
 func (d *DB) WithTransaction(ctx context.Context, fn TransactionFunc) error {
 	return d.withRetry(ctx, func() error {
-		// Validate connection before starting transaction
-		if err := d.ValidateConnection(ctx); err != nil {
-			return WrapError(err, ErrCodeConnectionFailed, "with_transaction", "connection validation failed before transaction")
-		}
+		return d.withTxHooks(ctx, &TxEvent{Operation: "with_transaction", Isolation: sql.LevelDefault}, func(ctx context.Context) error {
+			// Validate connection before starting transaction
+			if err := d.ValidateConnection(ctx); err != nil {
+				return WrapError(err, ErrCodeConnectionFailed, "with_transaction", "connection validation failed before transaction")
+			}
 
-		// Begin transaction
-		tx, err := d.db.BeginTxx(ctx, nil)
-		if err != nil {
-			return WrapError(err, ErrCodeTransactionBegin, "with_transaction", "failed to begin transaction")
-		}
+			// Begin transaction
+			tx, err := d.db.BeginTxx(ctx, nil)
+			if err != nil {
+				return WrapError(err, ErrCodeTransactionBegin, "with_transaction", "failed to begin transaction")
+			}
 
-		transaction := &Transaction{
-			tx:     tx,
-			db:     d,
-			logger: d.logger,
-		}
+			transaction := &Transaction{
+				tx:     tx,
+				db:     d,
+				ctx:    ctx,
+				logger: d.logger,
+			}
+
+			// Handle panics by rolling back the transaction
+			defer func() {
+				if r := recover(); r != nil {
+					d.logger.Error("transaction panicked, rolling back", slog.Any("panic", r))
+					if rollbackErr := tx.Rollback(); rollbackErr != nil {
+						d.logger.Error("failed to rollback transaction after panic", slog.Any("error", rollbackErr))
+					}
+					panic(r) // re-panic
+				}
+			}()
 
-		// Handle panics by rolling back the transaction
-		defer func() {
-			if r := recover(); r != nil {
-				d.logger.Error("transaction panicked, rolling back", slog.Any("panic", r))
+			// Execute the function
+			if err := fn(transaction); err != nil {
+				// Rollback on error
 				if rollbackErr := tx.Rollback(); rollbackErr != nil {
-					d.logger.Error("failed to rollback transaction after panic", slog.Any("error", rollbackErr))
+					d.logger.Error("failed to rollback transaction",
+						slog.Any("original_error", err),
+						slog.Any("rollback_error", rollbackErr))
+					// Return the original error, not the rollback error
+					// The rollback failure is logged but shouldn't mask the original issue
 				}
-				panic(r) // re-panic
+				return WrapError(err, ErrCodeTransactionFailed, "with_transaction", "transaction function failed")
 			}
-		}()
-
-		// Execute the function
-		if err := fn(transaction); err != nil {
-			// Rollback on error
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				d.logger.Error("failed to rollback transaction",
-					slog.Any("original_error", err),
-					slog.Any("rollback_error", rollbackErr))
-				// Return the original error, not the rollback error
-				// The rollback failure is logged but shouldn't mask the original issue
-			}
-			return WrapError(err, ErrCodeTransactionFailed, "with_transaction", "transaction function failed")
-		}
 
-		// Commit the transaction
-		if err := tx.Commit(); err != nil {
-			return WrapError(err, ErrCodeTransactionCommit, "with_transaction", "failed to commit transaction")
-		}
+			// Commit the transaction
+			if err := tx.Commit(); err != nil {
+				return WrapError(err, ErrCodeTransactionCommit, "with_transaction", "failed to commit transaction")
+			}
 
-		return nil
+			return nil
+		})
 	})
 }
 
 // WithTransactionIsolation executes a function within a transaction with specific isolation level
 func (d *DB) WithTransactionIsolation(ctx context.Context, isolation sql.IsolationLevel, fn TransactionFunc) error {
 	return d.withRetry(ctx, func() error {
-		// Validate connection before starting transaction
-		if err := d.ValidateConnection(ctx); err != nil {
-			return WrapError(err, ErrCodeConnectionFailed, "with_transaction_isolation", "connection validation failed before transaction")
-		}
+		return d.withTxHooks(ctx, &TxEvent{Operation: "with_transaction_isolation", Isolation: isolation}, func(ctx context.Context) error {
+			// Validate connection before starting transaction
+			if err := d.ValidateConnection(ctx); err != nil {
+				return WrapError(err, ErrCodeConnectionFailed, "with_transaction_isolation", "connection validation failed before transaction")
+			}
 
-		// Begin transaction with isolation level
-		tx, err := d.db.BeginTxx(ctx, &sql.TxOptions{
-			Isolation: isolation,
-		})
-		if err != nil {
-			return WrapError(err, ErrCodeTransactionBegin, "with_transaction_isolation", "failed to begin transaction with isolation level")
-		}
+			// Begin transaction with isolation level
+			tx, err := d.db.BeginTxx(ctx, &sql.TxOptions{
+				Isolation: isolation,
+			})
+			if err != nil {
+				return WrapError(err, ErrCodeTransactionBegin, "with_transaction_isolation", "failed to begin transaction with isolation level")
+			}
 
-		transaction := &Transaction{
-			tx:     tx,
-			db:     d,
-			logger: d.logger,
-		}
+			transaction := &Transaction{
+				tx:     tx,
+				db:     d,
+				ctx:    ctx,
+				logger: d.logger,
+			}
+
+			// Handle panics by rolling back the transaction
+			defer func() {
+				if r := recover(); r != nil {
+					d.logger.Error("transaction panicked, rolling back", slog.Any("panic", r))
+					if rollbackErr := tx.Rollback(); rollbackErr != nil {
+						d.logger.Error("failed to rollback transaction after panic", slog.Any("error", rollbackErr))
+					}
+					panic(r) // re-panic
+				}
+			}()
 
-		// Handle panics by rolling back the transaction
-		defer func() {
-			if r := recover(); r != nil {
-				d.logger.Error("transaction panicked, rolling back", slog.Any("panic", r))
+			// Execute the function
+			if err := fn(transaction); err != nil {
+				// Rollback on error
 				if rollbackErr := tx.Rollback(); rollbackErr != nil {
-					d.logger.Error("failed to rollback transaction after panic", slog.Any("error", rollbackErr))
+					d.logger.Error("failed to rollback transaction",
+						slog.Any("original_error", err),
+						slog.Any("rollback_error", rollbackErr))
+					// Return the original error, not the rollback error
+					// The rollback failure is logged but shouldn't mask the original issue
 				}
-				panic(r) // re-panic
+				return WrapError(err, ErrCodeTransactionFailed, "with_transaction_isolation", "transaction function failed")
 			}
-		}()
-
-		// Execute the function
-		if err := fn(transaction); err != nil {
-			// Rollback on error
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				d.logger.Error("failed to rollback transaction",
-					slog.Any("original_error", err),
-					slog.Any("rollback_error", rollbackErr))
-				// Return the original error, not the rollback error
-				// The rollback failure is logged but shouldn't mask the original issue
-			}
-			return WrapError(err, ErrCodeTransactionFailed, "with_transaction_isolation", "transaction function failed")
-		}
 
-		// Commit the transaction
-		if err := tx.Commit(); err != nil {
-			return WrapError(err, ErrCodeTransactionCommit, "with_transaction_isolation", "failed to commit transaction")
-		}
+			// Commit the transaction
+			if err := tx.Commit(); err != nil {
+				return WrapError(err, ErrCodeTransactionCommit, "with_transaction_isolation", "failed to commit transaction")
+			}
 
-		return nil
+			return nil
+		})
 	})
 }
 
 // Exec executes a query within the transaction
 func (t *Transaction) Exec(query string, args ...interface{}) (sql.Result, error) {
-	result, err := t.tx.Exec(query, args...)
-	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "transaction_exec", "failed to execute query in transaction").
-			WithContext("query", query)
-	}
-	return result, nil
+	return t.exec(t.Context(), "transaction_exec", query, args)
 }
 
 // ExecContext executes a query within the transaction with context
 func (t *Transaction) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	result, err := t.tx.ExecContext(ctx, query, args...)
+	return t.exec(ctx, "transaction_exec_context", query, args)
+}
+
+func (t *Transaction) exec(ctx context.Context, operation, query string, args []interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := t.withQueryHooks(ctx, operation, query, args, func(ctx context.Context) (int64, error) {
+		var err error
+		result, err = t.tx.ExecContext(ctx, query, args...)
+		return rowsAffected(result), err
+	})
 	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "transaction_exec_context", "failed to execute query in transaction").
+		return nil, WrapError(err, ErrCodeQueryFailed, operation, "failed to execute query in transaction").
 			WithContext("query", query)
 	}
 	return result, nil
@@ -150,19 +162,23 @@ func (t *Transaction) ExecContext(ctx context.Context, query string, args ...int
 
 // Query executes a query that returns rows within the transaction
 func (t *Transaction) Query(query string, args ...interface{}) (*sqlx.Rows, error) {
-	rows, err := t.tx.Queryx(query, args...)
-	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "transaction_query", "failed to execute query in transaction").
-			WithContext("query", query)
-	}
-	return rows, nil
+	return t.query(t.Context(), "transaction_query", query, args)
 }
 
 // QueryContext executes a query that returns rows within the transaction with context
 func (t *Transaction) QueryContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
-	rows, err := t.tx.QueryxContext(ctx, query, args...)
+	return t.query(ctx, "transaction_query_context", query, args)
+}
+
+func (t *Transaction) query(ctx context.Context, operation, query string, args []interface{}) (*sqlx.Rows, error) {
+	var rows *sqlx.Rows
+	err := t.withQueryHooks(ctx, operation, query, args, func(ctx context.Context) (int64, error) {
+		var err error
+		rows, err = t.tx.QueryxContext(ctx, query, args...)
+		return -1, err
+	})
 	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "transaction_query_context", "failed to execute query in transaction").
+		return nil, WrapError(err, ErrCodeQueryFailed, operation, "failed to execute query in transaction").
 			WithContext("query", query)
 	}
 	return rows, nil
@@ -170,29 +186,70 @@ func (t *Transaction) QueryContext(ctx context.Context, query string, args ...in
 
 // QueryRow executes a query that returns a single row within the transaction
 func (t *Transaction) QueryRow(query string, args ...interface{}) *sqlx.Row {
-	return t.tx.QueryRowx(query, args...)
+	return t.queryRow(t.Context(), "transaction_query_row", query, args)
 }
 
 // QueryRowContext executes a query that returns a single row within the transaction with context
 func (t *Transaction) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
-	return t.tx.QueryRowxContext(ctx, query, args...)
+	return t.queryRow(ctx, "transaction_query_row_context", query, args)
+}
+
+// queryRow runs a single row query through the hooks. A statement failed by a BeforeQuery
+// hook is not sent, and the row's Scan and Err return the error of the hook.
+func (t *Transaction) queryRow(ctx context.Context, operation, query string, args []interface{}) *sqlx.Row {
+	var row *sqlx.Row
+	err := t.withQueryHooks(ctx, operation, query, args, func(ctx context.Context) (int64, error) {
+		row = t.tx.QueryRowxContext(ctx, query, args...)
+		return -1, row.Err()
+	})
+	if row == nil {
+		return errRow(WrapError(err, ErrCodeQueryFailed, operation, "failed to execute query in transaction").
+			WithContext("query", query))
+	}
+	return row
+}
+
+// errRow returns a row reporting err. The error of an *sqlx.Row can only be set by running
+// the query, so the row comes from a database whose connections fail with err.
+func errRow(err error) *sqlx.Row {
+	db := sqlx.NewDb(sql.OpenDB(failingConnector{err: err}), "postgres")
+	defer db.Close()
+	return db.QueryRowx("")
+}
+
+// failingConnector is a driver.Connector whose connections fail with err
+type failingConnector struct {
+	err error
+}
+
+func (c failingConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, c.err
+}
+
+func (c failingConnector) Driver() driver.Driver {
+	return c
+}
+
+func (c failingConnector) Open(string) (driver.Conn, error) {
+	return nil, c.err
 }
 
 // Get scans a single row into dest within the transaction
 func (t *Transaction) Get(dest interface{}, query string, args ...interface{}) error {
-	err := t.tx.Get(dest, query, args...)
-	if err != nil {
-		return WrapError(err, ErrCodeQueryFailed, "transaction_get", "failed to get single row in transaction").
-			WithContext("query", query)
-	}
-	return nil
+	return t.get(t.Context(), "transaction_get", dest, query, args)
 }
 
 // GetContext scans a single row into dest within the transaction with context
 func (t *Transaction) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	err := t.tx.GetContext(ctx, dest, query, args...)
+	return t.get(ctx, "transaction_get_context", dest, query, args)
+}
+
+func (t *Transaction) get(ctx context.Context, operation string, dest interface{}, query string, args []interface{}) error {
+	err := t.withQueryHooks(ctx, operation, query, args, func(ctx context.Context) (int64, error) {
+		return 1, t.tx.GetContext(ctx, dest, query, args...)
+	})
 	if err != nil {
-		return WrapError(err, ErrCodeQueryFailed, "transaction_get_context", "failed to get single row in transaction").
+		return WrapError(err, ErrCodeQueryFailed, operation, "failed to get single row in transaction").
 			WithContext("query", query)
 	}
 	return nil
@@ -200,19 +257,21 @@ func (t *Transaction) GetContext(ctx context.Context, dest interface{}, query st
 
 // Select scans multiple rows into dest within the transaction
 func (t *Transaction) Select(dest interface{}, query string, args ...interface{}) error {
-	err := t.tx.Select(dest, query, args...)
-	if err != nil {
-		return WrapError(err, ErrCodeQueryFailed, "transaction_select", "failed to select rows in transaction").
-			WithContext("query", query)
-	}
-	return nil
+	return t.selectRows(t.Context(), "transaction_select", dest, query, args)
 }
 
 // SelectContext scans multiple rows into dest within the transaction with context
 func (t *Transaction) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	err := t.tx.SelectContext(ctx, dest, query, args...)
+	return t.selectRows(ctx, "transaction_select_context", dest, query, args)
+}
+
+func (t *Transaction) selectRows(ctx context.Context, operation string, dest interface{}, query string, args []interface{}) error {
+	err := t.withQueryHooks(ctx, operation, query, args, func(ctx context.Context) (int64, error) {
+		err := t.tx.SelectContext(ctx, dest, query, args...)
+		return destRows(dest), err
+	})
 	if err != nil {
-		return WrapError(err, ErrCodeQueryFailed, "transaction_select_context", "failed to select rows in transaction").
+		return WrapError(err, ErrCodeQueryFailed, operation, "failed to select rows in transaction").
 			WithContext("query", query)
 	}
 	return nil
@@ -220,19 +279,26 @@ func (t *Transaction) SelectContext(ctx context.Context, dest interface{}, query
 
 // NamedExec executes a named query within the transaction
 func (t *Transaction) NamedExec(query string, arg interface{}) (sql.Result, error) {
-	result, err := t.tx.NamedExec(query, arg)
-	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "transaction_named_exec", "failed to execute named query in transaction").
-			WithContext("query", query)
-	}
-	return result, nil
+	return t.namedExec(t.Context(), "transaction_named_exec", query, arg)
 }
 
 // NamedExecContext executes a named query within the transaction with context
 func (t *Transaction) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
-	result, err := t.tx.NamedExecContext(ctx, query, arg)
+	return t.namedExec(ctx, "transaction_named_exec_context", query, arg)
+}
+
+func (t *Transaction) namedExec(ctx context.Context, operation, query string, arg interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := t.withQueryHooks(ctx, operation, query, []interface{}{arg}, func(ctx context.Context) (int64, error) {
+		bound, args, err := bindNamed(query, arg)
+		if err != nil {
+			return -1, err
+		}
+		result, err = t.tx.ExecContext(ctx, bound, args...)
+		return rowsAffected(result), err
+	})
 	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "transaction_named_exec_context", "failed to execute named query in transaction").
+		return nil, WrapError(err, ErrCodeQueryFailed, operation, "failed to execute named query in transaction").
 			WithContext("query", query)
 	}
 	return result, nil
@@ -240,7 +306,15 @@ func (t *Transaction) NamedExecContext(ctx context.Context, query string, arg in
 
 // NamedQuery executes a named query that returns rows within the transaction
 func (t *Transaction) NamedQuery(query string, arg interface{}) (*sqlx.Rows, error) {
-	rows, err := t.tx.NamedQuery(query, arg)
+	var rows *sqlx.Rows
+	err := t.withQueryHooks(t.Context(), "transaction_named_query", query, []interface{}{arg}, func(ctx context.Context) (int64, error) {
+		bound, args, err := bindNamed(query, arg)
+		if err != nil {
+			return -1, err
+		}
+		rows, err = t.tx.QueryxContext(ctx, bound, args...)
+		return -1, err
+	})
 	if err != nil {
 		return nil, WrapError(err, ErrCodeQueryFailed, "transaction_named_query", "failed to execute named query in transaction").
 			WithContext("query", query)
@@ -250,15 +324,15 @@ func (t *Transaction) NamedQuery(query string, arg interface{}) (*sqlx.Rows, err
 
 // NamedQueryContext executes a named query that returns rows within the transaction with context
 func (t *Transaction) NamedQueryContext(ctx context.Context, query string, arg interface{}) (*sqlx.Rows, error) {
-	// sqlx.Tx doesn't have NamedQueryContext, so we'll use a prepared statement approach
-	stmt, err := t.tx.PrepareNamedContext(ctx, query)
-	if err != nil {
-		return nil, WrapError(err, ErrCodeQueryFailed, "transaction_named_query_context", "failed to prepare named query in transaction").
-			WithContext("query", query)
-	}
-	defer stmt.Close()
-
-	rows, err := stmt.QueryxContext(ctx, arg)
+	var rows *sqlx.Rows
+	err := t.withQueryHooks(ctx, "transaction_named_query_context", query, []interface{}{arg}, func(ctx context.Context) (int64, error) {
+		bound, args, err := bindNamed(query, arg)
+		if err != nil {
+			return -1, err
+		}
+		rows, err = t.tx.QueryxContext(ctx, bound, args...)
+		return -1, err
+	})
 	if err != nil {
 		return nil, WrapError(err, ErrCodeQueryFailed, "transaction_named_query_context", "failed to execute named query in transaction").
 			WithContext("query", query)
@@ -266,6 +340,13 @@ func (t *Transaction) NamedQueryContext(ctx context.Context, query string, arg i
 	return rows, nil
 }
 
+// bindNamed binds the :name parameters of query to arg with postgres' $N placeholders.
+// Transactions of Go migrations wrap goose's *sql.Tx without a driver name, so the bind
+// type is not taken from the *sqlx.Tx.
+func bindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return sqlx.BindNamed(sqlx.DOLLAR, query, arg)
+}
+
 // Prepare creates a prepared statement within the transaction
 func (t *Transaction) Prepare(query string) (*sql.Stmt, error) {
 	stmt, err := t.tx.Prepare(query)
@@ -324,7 +405,16 @@ func (t *Transaction) Commit() error {
 	return nil
 }
 
-// Tx returns the underlying *sqlx.Tx for advanced use cases
+// Context returns the context the transaction was started with
+func (t *Transaction) Context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// Tx returns the underlying *sqlx.Tx for advanced use cases. In Go migrations it has no
+// driver name, so its named queries and Rebind do not use postgres placeholders.
 func (t *Transaction) Tx() *sqlx.Tx {
 	return t.tx
 }
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+// CompositeTypeInfo represents a composite type created with CREATE TYPE ... AS
+type CompositeTypeInfo struct {
+	Name       string               `json:"name" db:"type_name"`
+	Schema     string               `json:"schema" db:"type_schema"`
+	Attributes []CompositeAttribute `json:"attributes"`
+	Comment    *string              `json:"comment,omitempty" db:"type_comment"`
+}
+
+// CompositeAttribute is an attribute of a composite type
+type CompositeAttribute struct {
+	Name     string `json:"name" db:"attribute_name"`
+	DataType string `json:"data_type" db:"data_type"`
+	// Collation is the collation of the attribute, if it is not the default of its type
+	Collation *string `json:"collation,omitempty" db:"collation_name"`
+}
+
+// DomainInfo represents a domain with its constraints
+type DomainInfo struct {
+	Name   string `json:"name" db:"domain_name"`
+	Schema string `json:"schema" db:"domain_schema"`
+	// BaseType is the underlying type, e.g. "character varying(255)"
+	BaseType     string  `json:"base_type" db:"base_type"`
+	IsNullable   bool    `json:"is_nullable" db:"is_nullable"`
+	DefaultValue *string `json:"default_value,omitempty" db:"domain_default"`
+	// Collation is the collation of the domain, if it is not the default of its base type
+	Collation   *string            `json:"collation,omitempty" db:"collation_name"`
+	Constraints []DomainConstraint `json:"constraints"`
+	Comment     *string            `json:"comment,omitempty" db:"domain_comment"`
+}
+
+// DomainConstraint is a CHECK constraint of a domain
+type DomainConstraint struct {
+	Name string `json:"name"`
+	// Definition is the constraint clause, e.g. "CHECK (VALUE > 0)"
+	Definition string `json:"definition"`
+}
+
+// GetCompositeTypes retrieves the composite types in the specified schema (empty string for
+// all schemas) with their attributes. Row types of tables and types created by extensions
+// are left out.
+func (is *IntrospectionService) GetCompositeTypes(ctx context.Context, schema string) ([]CompositeTypeInfo, error) {
+	type attributeRow struct {
+		TypeName      string  `db:"type_name"`
+		TypeSchema    string  `db:"type_schema"`
+		TypeComment   *string `db:"type_comment"`
+		AttributeName *string `db:"attribute_name"`
+		DataType      *string `db:"data_type"`
+		CollationName *string `db:"collation_name"`
+	}
+
+	var rows []attributeRow
+	query := `
+		SELECT
+			t.typname as type_name,
+			n.nspname as type_schema,
+			obj_description(t.oid, 'pg_type') as type_comment,
+			a.attname as attribute_name,
+			format_type(a.atttypid, a.atttypmod) as data_type,
+			CASE WHEN a.attcollation <> at.typcollation THEN co.collname END as collation_name
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		JOIN pg_class c ON c.oid = t.typrelid AND c.relkind = 'c'
+		LEFT JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		LEFT JOIN pg_type at ON at.oid = a.atttypid
+		LEFT JOIN pg_collation co ON co.oid = a.attcollation
+		WHERE t.typtype = 'c'
+		AND n.nspname NOT IN ('information_schema', 'pg_catalog')
+		AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = t.oid AND d.deptype = 'e')
+		AND ($1::text = '' OR n.nspname = $1)
+		ORDER BY n.nspname, t.typname, a.attnum
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &rows, query, schema)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_composite_types", "failed to get composite types")
+	}
+
+	// Rows of a type are adjacent
+	types := []CompositeTypeInfo{}
+	for _, row := range rows {
+		n := len(types)
+		if n == 0 || types[n-1].Name != row.TypeName || types[n-1].Schema != row.TypeSchema {
+			types = append(types, CompositeTypeInfo{
+				Name:       row.TypeName,
+				Schema:     row.TypeSchema,
+				Attributes: []CompositeAttribute{},
+				Comment:    row.TypeComment,
+			})
+			n++
+		}
+		if row.AttributeName != nil && row.DataType != nil {
+			types[n-1].Attributes = append(types[n-1].Attributes, CompositeAttribute{
+				Name:      *row.AttributeName,
+				DataType:  *row.DataType,
+				Collation: row.CollationName,
+			})
+		}
+	}
+
+	return types, nil
+}
+
+// GetDomains retrieves the domains in the specified schema (empty string for all schemas)
+// with their defaults and constraints. Domains created by extensions are left out.
+func (is *IntrospectionService) GetDomains(ctx context.Context, schema string) ([]DomainInfo, error) {
+	type domainRow struct {
+		DomainInfo
+		ConstraintNames       pq.StringArray `db:"constraint_names"`
+		ConstraintDefinitions pq.StringArray `db:"constraint_definitions"`
+	}
+
+	var rows []domainRow
+	query := `
+		SELECT
+			t.typname as domain_name,
+			n.nspname as domain_schema,
+			format_type(t.typbasetype, t.typtypmod) as base_type,
+			NOT t.typnotnull as is_nullable,
+			t.typdefault as domain_default,
+			CASE WHEN t.typcollation <> bt.typcollation THEN co.collname END as collation_name,
+			obj_description(t.oid, 'pg_type') as domain_comment,
+			ARRAY(
+				SELECT con.conname::text FROM pg_constraint con
+				WHERE con.contypid = t.oid AND con.contype = 'c'
+				ORDER BY con.conname
+			) as constraint_names,
+			ARRAY(
+				SELECT pg_get_constraintdef(con.oid) FROM pg_constraint con
+				WHERE con.contypid = t.oid AND con.contype = 'c'
+				ORDER BY con.conname
+			) as constraint_definitions
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		JOIN pg_type bt ON bt.oid = t.typbasetype
+		LEFT JOIN pg_collation co ON co.oid = t.typcollation
+		WHERE t.typtype = 'd'
+		AND n.nspname NOT IN ('information_schema', 'pg_catalog')
+		AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = t.oid AND d.deptype = 'e')
+		AND ($1::text = '' OR n.nspname = $1)
+		ORDER BY n.nspname, t.typname
+	`
+
+	err := is.db.WithValidation(ctx, func() error {
+		return is.db.db.SelectContext(ctx, &rows, query, schema)
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeQueryFailed, "get_domains", "failed to get domains")
+	}
+
+	domains := make([]DomainInfo, 0, len(rows))
+	for _, row := range rows {
+		domain := row.DomainInfo
+		domain.Constraints = make([]DomainConstraint, 0, len(row.ConstraintNames))
+		for i, name := range row.ConstraintNames {
+			domain.Constraints = append(domain.Constraints, DomainConstraint{Name: name, Definition: row.ConstraintDefinitions[i]})
+		}
+		domains = append(domains, domain)
+	}
+
+	return domains, nil
+}
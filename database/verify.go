@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// BackupVerification reports the result of restoring a backup into a temporary database
+type BackupVerification struct {
+	Backup string `json:"backup"`
+	// Database is the temporary database the backup was restored into; it is dropped
+	// before VerifyBackup returns
+	Database string `json:"database"`
+	// Tables is the number of tables found after the restore
+	Tables int `json:"tables"`
+	// ExpectedTables is the number of tables recorded in the manifest, or 0 if unknown
+	ExpectedTables int           `json:"expected_tables,omitempty"`
+	Duration       time.Duration `json:"duration"`
+}
+
+// VerifyBackup restores the backup at backupPath, a local path or storage URL, into a new
+// temporary database, checks that the restored tables match the manifest and drops the
+// temporary database again. Backups without a manifest only need to restore cleanly.
+func (d *DB) VerifyBackup(ctx context.Context, backupPath string) (*BackupVerification, error) {
+	started := time.Now()
+
+	manifest := d.backupManifest(ctx, backupPath)
+	if manifest != nil && manifest.Options.DataOnly {
+		return nil, NewValidationError("data-only backups cannot be verified in an empty database", nil).
+			WithContext("backup_path", backupPath).
+			WithOperation("verify_backup")
+	}
+
+	target := d.config
+	target.DBName = verificationDBName()
+	defer func() {
+		// Drop the temporary database even if ctx was cancelled
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := dropDatabase(cleanupCtx, target); err != nil {
+			d.logger.Warn("failed to drop verification database",
+				slog.String("database", target.DBName), slog.Any("error", err))
+		}
+	}()
+
+	d.logger.Info("verifying backup",
+		slog.String("backup", backupPath), slog.String("database", target.DBName))
+	// Restore hooks guard the configured database, not the temporary one
+	err := d.restore(ctx, backupPath, RestoreOptions{
+		CreateDB:     true,
+		TargetDBName: target.DBName,
+		StopOnError:  true,
+	})
+	if err != nil {
+		return nil, WrapError(err, ErrCodeRestoreFailed, "verify_backup", "backup could not be restored").
+			WithContext("backup_path", backupPath)
+	}
+
+	tables, err := countTables(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BackupVerification{
+		Backup:   backupPath,
+		Database: target.DBName,
+		Tables:   tables,
+	}
+	if manifest != nil {
+		result.ExpectedTables = manifest.Tables
+	}
+	result.Duration = time.Since(started)
+
+	if err := result.check(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// check compares the restored database with the manifest
+func (v *BackupVerification) check() error {
+	if v.ExpectedTables > 0 && v.Tables != v.ExpectedTables {
+		return NewDBError(ErrCodeInvalidBackupFile,
+			fmt.Sprintf("restored %d tables, manifest records %d", v.Tables, v.ExpectedTables), nil).
+			WithContext("backup_path", v.Backup).
+			WithOperation("verify_backup")
+	}
+	return nil
+}
+
+// backupManifest returns the manifest of a local or stored backup, or nil if it has none
+func (d *DB) backupManifest(ctx context.Context, backupPath string) *BackupManifest {
+	if !IsStorageURL(backupPath) {
+		manifest, err := ReadBackupManifest(backupPath)
+		if err != nil {
+			return nil
+		}
+		return manifest
+	}
+
+	base, key := splitStorageURL(backupPath)
+	storage, err := OpenBackupStorage(ctx, base)
+	if err != nil || key == "" {
+		return nil
+	}
+	return readStoredManifest(ctx, storage, key)
+}
+
+// verificationDBName returns a unique name for a temporary verification database
+func verificationDBName() string {
+	return fmt.Sprintf("db_kit_verify_%d", time.Now().UnixNano())
+}
+
+// countTables returns the number of user tables in config.DBName
+func countTables(ctx context.Context, config Config) (int, error) {
+	conn, err := sqlx.ConnectContext(ctx, "postgres", config.ConnectionString())
+	if err != nil {
+		return 0, NewConnectionError("failed to connect to database", err).
+			WithContext("database", config.DBName).
+			WithOperation("count_tables")
+	}
+	defer conn.Close()
+
+	var count int
+	err = conn.GetContext(ctx, &count, `
+		SELECT count(*) FROM pg_catalog.pg_tables
+		WHERE schemaname NOT IN ('pg_catalog', 'information_schema')`)
+	if err != nil {
+		return 0, WrapError(err, ErrCodeQueryFailed, "count_tables", "failed to count tables").
+			WithContext("database", config.DBName)
+	}
+	return count, nil
+}
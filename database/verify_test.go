@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupVerificationCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		verification BackupVerification
+		wantErr      bool
+	}{
+		{"matching tables", BackupVerification{Tables: 3, ExpectedTables: 3}, false},
+		{"no manifest count", BackupVerification{Tables: 3}, false},
+		{"missing tables", BackupVerification{Tables: 2, ExpectedTables: 3}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.verification.check(); (err != nil) != tt.wantErr {
+				t.Errorf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackupOptionsFullDump(t *testing.T) {
+	if !(BackupOptions{Format: FormatCustom, SchemaOnly: true}).fullDump() {
+		t.Error("Expected schema-only backup to contain every table")
+	}
+	if (BackupOptions{ExcludeTables: []string{"audit_*"}}).fullDump() {
+		t.Error("Expected backup excluding tables not to be a full dump")
+	}
+}
+
+func TestVerificationDBName(t *testing.T) {
+	name := verificationDBName()
+	if !strings.HasPrefix(name, "db_kit_verify_") || len(name) > 63 {
+		t.Errorf("Unexpected verification database name %q", name)
+	}
+}
+
+func TestVerifyBackup(t *testing.T) {
+	db, closeDB := tearUp(t)
+	defer closeDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	db.config.BackupsDir = t.TempDir()
+	path, err := db.BackupWithOptions(ctx, "", BackupOptions{Format: FormatCustom})
+	if err != nil {
+		t.Skipf("Backup failed (pg_dump may not be available): %v", err)
+	}
+
+	result, err := db.VerifyBackup(ctx, path)
+	if err != nil {
+		t.Fatalf("VerifyBackup failed: %v", err)
+	}
+	if result.ExpectedTables != 0 && result.Tables != result.ExpectedTables {
+		t.Errorf("Restored %d tables, expected %d", result.Tables, result.ExpectedTables)
+	}
+}